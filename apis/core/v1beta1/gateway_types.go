@@ -16,6 +16,15 @@ type AuthSpec struct {
 	Noauth   *noauth.ServerConfig        `json:"noauth,omitempty"`
 }
 
+type PluginSignatureSpec struct {
+	// How plugin signatures are enforced: "enforce" (the default), "warn",
+	// or "disabled".
+	//+kubebuilder:validation:Enum=warn;enforce;disabled
+	Mode cfgv1beta1.SignatureMode `json:"mode,omitempty"`
+	// PEM encoded ed25519 public keys which are trusted to sign plugins.
+	TrustedKeys []string `json:"trustedKeys,omitempty"`
+}
+
 type OpenIDConfigSpec struct {
 	openid.OpenidConfig `json:",inline,omitempty,squash"`
 	ClientID            string   `json:"clientID,omitempty"`
@@ -44,6 +53,8 @@ type GatewaySpec struct {
 	// Deprecated: this field is ignored.
 	PluginSearchDirs []string `json:"pluginSearchDirs,omitempty"`
 
+	PluginSignatures PluginSignatureSpec `json:"pluginSignatures,omitempty"`
+
 	Alerting AlertingSpec                `json:"alerting,omitempty"`
 	NatsRef  corev1.LocalObjectReference `json:"natsCluster"`
 
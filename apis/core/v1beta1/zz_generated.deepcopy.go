@@ -6,6 +6,7 @@
 package v1beta1
 
 import (
+	cfgv1beta1 "github.com/rancher/opni/pkg/config/v1beta1"
 	"github.com/rancher/opni/pkg/util/meta"
 	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/api/core/v1"
@@ -413,6 +414,7 @@ func (in *GatewaySpec) DeepCopyInto(out *GatewaySpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	in.PluginSignatures.DeepCopyInto(&out.PluginSignatures)
 	in.Alerting.DeepCopyInto(&out.Alerting)
 	out.NatsRef = in.NatsRef
 	if in.ServiceAnnotations != nil {
@@ -423,6 +425,16 @@ func (in *GatewaySpec) DeepCopyInto(out *GatewaySpec) {
 		}
 	}
 	out.Management = in.Management
+	if in.Management.ClientAuth != nil {
+		in, out := &in.Management.ClientAuth, &out.Management.ClientAuth
+		*out = new(cfgv1beta1.ManagementClientAuthSpec)
+		**out = **in
+		if (*in).TrustedProxies != nil {
+			in, out := &(*in).TrustedProxies, &(*out).TrustedProxies
+			*out = make([]string, len(*in))
+			copy(*out, *in)
+		}
+	}
 	if in.NodeSelector != nil {
 		in, out := &in.NodeSelector, &out.NodeSelector
 		*out = make(map[string]string, len(*in))
@@ -999,6 +1011,26 @@ func (in *PVCSource) DeepCopy() *PVCSource {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PluginSignatureSpec) DeepCopyInto(out *PluginSignatureSpec) {
+	*out = *in
+	if in.TrustedKeys != nil {
+		in, out := &in.TrustedKeys, &out.TrustedKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PluginSignatureSpec.
+func (in *PluginSignatureSpec) DeepCopy() *PluginSignatureSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PluginSignatureSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WorkloadStatus) DeepCopyInto(out *WorkloadStatus) {
 	*out = *in
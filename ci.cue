@@ -54,6 +54,8 @@ dagger.#Plan & {
 			GITHUB_TOKEN?:          dagger.#Secret
 			GIT_USER?:              string
 			GIT_EMAIL?:             string
+			// PEM encoded ed25519 key used to sign plugins
+			OPNI_PLUGIN_SIGNING_KEY?: dagger.#Secret
 		}
 		filesystem: {
 			".": read: {
@@ -141,6 +143,9 @@ dagger.#Plan & {
 				workdir: "/src"
 				env: {
 					"BUILD_VERSION": client.env.BUILD_VERSION
+					if client.env.OPNI_PLUGIN_SIGNING_KEY != _|_ {
+						"OPNI_PLUGIN_SIGNING_KEY": client.env.OPNI_PLUGIN_SIGNING_KEY
+					}
 				}
 				command: {
 					name: "mage"
@@ -186,6 +186,11 @@ func startOpniServer(configFile string) {
 	//	  request and 5xx response codes are assumed to be recoverable.
 	// therefore, non-recoverable errors should have error codes 3XX and 4XX
 	mux.HandleFunc(shared.AlertingDefaultHookName, func(wr http.ResponseWriter, req *http.Request) {})
+	relayHandler, err := routing.NewRelayHandler(lg.Named("relay"), configFile)
+	if err != nil {
+		panic(err)
+	}
+	mux.HandleFunc(shared.AlertingRelayHookName, relayHandler)
 	mux.HandleFunc("/config", func(wr http.ResponseWriter, req *http.Request) {
 		lg.Debug("testing requested config equality")
 		r1 := &routing.RoutingTree{}
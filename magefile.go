@@ -4,7 +4,11 @@ package main
 
 import (
 	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
 	_ "embed"
+	"encoding/base64"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -19,6 +23,7 @@ import (
 	"github.com/magefile/mage/mg"
 	"github.com/magefile/mage/sh"
 	"github.com/samber/lo"
+	"golang.org/x/crypto/blake2b"
 
 	"github.com/kralicky/ragu"
 	_ "github.com/kralicky/ragu/compat"
@@ -103,6 +108,54 @@ func Build() error {
 			}
 		}
 	}
+	if _, ok := os.LookupEnv(pluginSigningKeyEnv); ok {
+		return SignPlugins()
+	}
+	return nil
+}
+
+// PEM encoded (PKCS #8) ed25519 private key used to sign plugins, such as one
+// created with "openssl genpkey -algorithm ed25519"
+const pluginSigningKeyEnv = "OPNI_PLUGIN_SIGNING_KEY"
+
+// SignPlugins signs the plugins in bin/plugins with the key in $OPNI_PLUGIN_SIGNING_KEY.
+// The build signs plugins automatically if the key is set.
+//
+// Signatures are written next to each plugin in the format expected by the
+// plugin loader (see pkg/plugins/signature.go): a base64 encoded ed25519
+// signature of the plugin's BLAKE2b-256 digest.
+func SignPlugins() error {
+	block, _ := pem.Decode([]byte(os.Getenv(pluginSigningKeyEnv)))
+	if block == nil {
+		return fmt.Errorf("%s: no PEM encoded private key found", pluginSigningKeyEnv)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("%s: %w", pluginSigningKeyEnv, err)
+	}
+	key, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return fmt.Errorf("%s: not an ed25519 key (got %T)", pluginSigningKeyEnv, parsed)
+	}
+	paths, err := filepath.Glob(filepath.Join("bin", "plugins", "plugin_*"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if filepath.Ext(path) == ".sig" {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		fmt.Println("signing " + path)
+		digest := blake2b.Sum256(data)
+		signature := base64.StdEncoding.EncodeToString(ed25519.Sign(key, digest[:]))
+		if err := os.WriteFile(path+".sig", []byte(signature+"\n"), 0644); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
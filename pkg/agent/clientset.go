@@ -9,17 +9,20 @@ import (
 
 type ClientSet interface {
 	controlv1.HealthClient
+	controlv1.KeyringRotationClient
 	capabilityv1.NodeClient
 }
 
 type clientSet struct {
 	controlv1.HealthClient
+	controlv1.KeyringRotationClient
 	capabilityv1.NodeClient
 }
 
 func NewClientSet(cc grpc.ClientConnInterface) ClientSet {
 	return &clientSet{
-		HealthClient: controlv1.NewHealthClient(cc),
-		NodeClient:   capabilityv1.NewNodeClient(cc),
+		HealthClient:          controlv1.NewHealthClient(cc),
+		KeyringRotationClient: controlv1.NewKeyringRotationClient(cc),
+		NodeClient:            capabilityv1.NewNodeClient(cc),
 	}
 }
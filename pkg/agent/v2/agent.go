@@ -265,6 +265,11 @@ func New(ctx context.Context, conf *v1beta1.AgentConfig, opts ...AgentOption) (*
 		return nil, fmt.Errorf("error configuring gateway client: %w", err)
 	}
 	controlv1.RegisterIdentityServer(gatewayClient, identserver.NewFromProvider(ip))
+	controlv1.RegisterKeyringRotationServer(gatewayClient, &keyringRotationServer{
+		keyringStore:  ks,
+		gatewayClient: gatewayClient,
+		logger:        lg.Named("keyring"),
+	})
 
 	hm := health.NewAggregator(health.WithStaticAnnotations(map[string]string{
 		annotations.AgentVersion: annotations.Version2,
@@ -477,6 +482,10 @@ func (a *Agent) runGatewayClient(ctx context.Context) error {
 			lg.Warn("encountered non-retriable error")
 			return errF.Get()
 		case codes.Unauthenticated:
+			if a.gatewayClient.FallBackToPreviousKeys() {
+				lg.Warn("the gateway rejected the current keys, retrying with previous keys")
+				break
+			}
 			return errF.Get()
 		}
 		isRetry = true
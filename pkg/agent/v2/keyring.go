@@ -0,0 +1,95 @@
+package v2
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	controlv1 "github.com/rancher/opni/pkg/apis/control/v1"
+	"github.com/rancher/opni/pkg/clients"
+	"github.com/rancher/opni/pkg/ecdh"
+	"github.com/rancher/opni/pkg/keyring"
+	"github.com/rancher/opni/pkg/storage"
+	"github.com/rancher/opni/pkg/validation"
+)
+
+type keyringRotationServer struct {
+	controlv1.UnsafeKeyringRotationServer
+	mu            sync.Mutex
+	keyringStore  storage.KeyringStore
+	gatewayClient clients.GatewayClient
+	logger        *zap.SugaredLogger
+
+	// keys derived by the last RotateKeys call, waiting to be committed
+	pending *pendingRotation
+}
+
+type pendingRotation struct {
+	clientPubKey []byte
+	keyring      keyring.Keyring
+	gracePeriod  time.Duration
+}
+
+var _ controlv1.KeyringRotationServer = (*keyringRotationServer)(nil)
+
+func (s *keyringRotationServer) RotateKeys(ctx context.Context, in *controlv1.RotateKeysRequest) (*controlv1.RotateKeysResponse, error) {
+	if err := validation.Validate(in); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kr, err := s.keyringStore.Get(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to get keyring: %v", err)
+	}
+
+	ekp := ecdh.NewEphemeralKeyPair()
+	sharedSecret, err := ecdh.DeriveSharedSecret(ekp, ecdh.PeerPublicKey{
+		PublicKey: in.GetServerPubKey(),
+		PeerType:  ecdh.PeerTypeServer,
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to derive shared secret: %v", err)
+	}
+
+	s.pending = &pendingRotation{
+		clientPubKey: ekp.PublicKey,
+		keyring:      keyring.RotateSharedKeys(kr, keyring.NewSharedKeys(sharedSecret), in.GetGracePeriod().AsDuration()),
+		gracePeriod:  in.GetGracePeriod().AsDuration(),
+	}
+	return &controlv1.RotateKeysResponse{
+		ClientPubKey: ekp.PublicKey,
+	}, nil
+}
+
+func (s *keyringRotationServer) CommitKeys(ctx context.Context, in *controlv1.CommitKeysRequest) (*emptypb.Empty, error) {
+	if err := validation.Validate(in); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pending == nil || !bytes.Equal(s.pending.clientPubKey, in.GetClientPubKey()) {
+		return nil, status.Error(codes.FailedPrecondition, "no pending keys to commit")
+	}
+	rotated := s.pending.keyring
+	if err := s.keyringStore.Put(ctx, rotated); err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to persist keyring: %v", err)
+	}
+	if err := s.gatewayClient.UseKeyring(rotated); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to update gateway client keys: %v", err)
+	}
+	s.logger.With(
+		"gracePeriod", s.pending.gracePeriod,
+	).Info("keyring rotated")
+	s.pending = nil
+
+	return &emptypb.Empty{}, nil
+}
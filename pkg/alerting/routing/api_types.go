@@ -27,13 +27,16 @@ type Receiver struct {
 	EmailConfigs     []*EmailConfig         `yaml:"email_configs,omitempty" json:"email_configs,omitempty"`
 	PagerdutyConfigs []*PagerdutyConfig     `yaml:"pagerduty_configs,omitempty" json:"pagerduty_configs,omitempty"`
 	SlackConfigs     []*SlackConfig         `yaml:"slack_configs,omitempty" json:"slack_configs,omitempty"`
-	WebhookConfigs   []*cfg.WebhookConfig   `yaml:"webhook_configs,omitempty" json:"webhook_configs,omitempty"`
-	OpsGenieConfigs  []*cfg.OpsGenieConfig  `yaml:"opsgenie_configs,omitempty" json:"opsgenie_configs,omitempty"`
+	WebhookConfigs   []*WebhookConfig       `yaml:"webhook_configs,omitempty" json:"webhook_configs,omitempty"`
+	OpsGenieConfigs  []*OpsGenieConfig      `yaml:"opsgenie_configs,omitempty" json:"opsgenie_configs,omitempty"`
 	WechatConfigs    []*cfg.WechatConfig    `yaml:"wechat_configs,omitempty" json:"wechat_configs,omitempty"`
 	PushoverConfigs  []*cfg.PushoverConfig  `yaml:"pushover_configs,omitempty" json:"pushover_configs,omitempty"`
 	VictorOpsConfigs []*cfg.VictorOpsConfig `yaml:"victorops_configs,omitempty" json:"victorops_configs,omitempty"`
 	SNSConfigs       []*cfg.SNSConfig       `yaml:"sns_configs,omitempty" json:"sns_configs,omitempty"`
 	TelegramConfigs  []*cfg.TelegramConfig  `yaml:"telegram_configs,omitempty" json:"telegram_configs,omitempty"`
+
+	// AlertManager has no MS Teams receiver : these are marshalled as webhook configs to the opni relay
+	MSTeamsConfigs []*MSTeamsConfig `yaml:"-" json:"msteams_configs,omitempty"`
 }
 
 func (r *Receiver) Equal(other *Receiver) (bool, string) {
@@ -48,9 +51,35 @@ func (c *Receiver) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	if c.Name == "" {
 		return fmt.Errorf("missing name in receiver")
 	}
+	webhooks := []*WebhookConfig{}
+	for _, webhook := range c.WebhookConfigs {
+		if isMSTeamsRelay(webhook) {
+			c.MSTeamsConfigs = append(c.MSTeamsConfigs, &MSTeamsConfig{WebhookConfig: *webhook})
+			continue
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	if len(c.MSTeamsConfigs) > 0 {
+		c.WebhookConfigs = webhooks
+	}
 	return nil
 }
 
+// MarshalYAML merges the MS Teams configs into the webhook configs, after the
+// generic webhooks, so that AlertManager can load the receiver.
+func (c *Receiver) MarshalYAML() (interface{}, error) {
+	type plain Receiver
+	p := plain(*c)
+	if len(c.MSTeamsConfigs) > 0 {
+		p.WebhookConfigs = append([]*WebhookConfig{}, c.WebhookConfigs...)
+		for _, msTeams := range c.MSTeamsConfigs {
+			webhook := msTeams.WebhookConfig
+			p.WebhookConfigs = append(p.WebhookConfigs, &webhook)
+		}
+	}
+	return p, nil
+}
+
 // required due to https://github.com/rancher/opni/issues/542
 type GlobalConfig struct {
 	// ResolveTimeout is the time after which an alert is declared resolved
@@ -82,6 +82,15 @@ func receiversAreEqual(r1 *Receiver, r2 *Receiver) (equal bool, reason string) {
 	if len(r1.PagerdutyConfigs) != len(r2.PagerdutyConfigs) {
 		return false, fmt.Sprintf("pager duty configs are not yet synced: found num old %d <-> num new %d ", len(r1.PagerdutyConfigs), len(r2.PagerdutyConfigs))
 	}
+	if len(r1.WebhookConfigs) != len(r2.WebhookConfigs) {
+		return false, fmt.Sprintf("webhook configs are not yet synced: found num old %d <-> num new %d ", len(r1.WebhookConfigs), len(r2.WebhookConfigs))
+	}
+	if len(r1.MSTeamsConfigs) != len(r2.MSTeamsConfigs) {
+		return false, fmt.Sprintf("ms teams configs are not yet synced: found num old %d <-> num new %d ", len(r1.MSTeamsConfigs), len(r2.MSTeamsConfigs))
+	}
+	if len(r1.OpsGenieConfigs) != len(r2.OpsGenieConfigs) {
+		return false, fmt.Sprintf("opsgenie configs are not yet synced: found num old %d <-> num new %d ", len(r1.OpsGenieConfigs), len(r2.OpsGenieConfigs))
+	}
 	for idx, emailConfig := range r1.EmailConfigs {
 		if equal, reason := emailConfig.Equal(r2.EmailConfigs[idx]); !equal {
 			return false, fmt.Sprintf("email config mismatch : %s", reason)
@@ -97,6 +106,21 @@ func receiversAreEqual(r1 *Receiver, r2 *Receiver) (equal bool, reason string) {
 			return false, fmt.Sprintf("pager duty config mismatch %s", reason)
 		}
 	}
+	for idx, webhookConfig := range r1.WebhookConfigs {
+		if equal, reason := webhookConfig.Equal(r2.WebhookConfigs[idx]); !equal {
+			return false, fmt.Sprintf("webhook config mismatch %s", reason)
+		}
+	}
+	for idx, msTeamsConfig := range r1.MSTeamsConfigs {
+		if equal, reason := msTeamsConfig.Equal(r2.MSTeamsConfigs[idx]); !equal {
+			return false, fmt.Sprintf("ms teams config mismatch %s", reason)
+		}
+	}
+	for idx, opsGenieConfig := range r1.OpsGenieConfigs {
+		if equal, reason := opsGenieConfig.Equal(r2.OpsGenieConfigs[idx]); !equal {
+			return false, fmt.Sprintf("opsgenie config mismatch %s", reason)
+		}
+	}
 	return true, ""
 }
 
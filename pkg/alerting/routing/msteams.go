@@ -0,0 +1,44 @@
+package routing
+
+import (
+	cfg "github.com/prometheus/alertmanager/config"
+	"github.com/rancher/opni/pkg/alerting/shared"
+	alertingv1 "github.com/rancher/opni/pkg/apis/alerting/v1"
+)
+
+var _ OpniConfig = (*MSTeamsConfig)(nil)
+
+// MSTeamsConfig configures notifications via MS Teams incoming webhooks.
+//
+// AlertManager has no MS Teams receiver, so these are sent as AlertManager webhooks
+// to the opni relay, which renders them as MS Teams message cards.
+type MSTeamsConfig struct {
+	WebhookConfig `yaml:",inline" json:",inline"`
+}
+
+func (c *MSTeamsConfig) Equal(other *MSTeamsConfig) (bool, string) {
+	return webhookConfigsAreEqual(&c.WebhookConfig, &other.WebhookConfig)
+}
+
+func (c *MSTeamsConfig) InternalId() string {
+	return "msteams"
+}
+
+func (c *MSTeamsConfig) ExtractDetails() *alertingv1.EndpointImplementation {
+	return c.WebhookConfig.ExtractDetails()
+}
+
+func (c *MSTeamsConfig) Default() OpniConfig {
+	return &MSTeamsConfig{
+		WebhookConfig: WebhookConfig{
+			NotifierConfig: cfg.NotifierConfig{
+				VSendResolved: true,
+			},
+		},
+	}
+}
+
+func isMSTeamsRelay(c *WebhookConfig) bool {
+	return c.URL != nil && c.URL.URL != nil &&
+		c.URL.Path == shared.AlertingRelayHookName+RelayKindMSTeams
+}
@@ -123,6 +123,15 @@ func (r *RoutingTree) UpdateIndividualEndpointNode(
 		if p := req.GetAlertEndpoint().GetPagerDuty(); p != nil {
 			return (&PagerdutyConfig{}).InternalId()
 		}
+		if w := req.GetAlertEndpoint().GetWebhook(); w != nil {
+			return (&WebhookConfig{}).InternalId()
+		}
+		if m := req.GetAlertEndpoint().GetMsTeams(); m != nil {
+			return (&MSTeamsConfig{}).InternalId()
+		}
+		if o := req.GetAlertEndpoint().GetOpsgenie(); o != nil {
+			return (&OpsGenieConfig{}).InternalId()
+		}
 		return "unknown"
 	}
 	newEndpointType := newEndpointTypeFunc()
@@ -187,6 +196,36 @@ func (r *RoutingTree) UpdateIndividualEndpointNode(
 					return err
 				}
 				r.Receivers[recvPos].PagerdutyConfigs[toTraverseItem.position] = pagerCfg
+			case (&WebhookConfig{}).InternalId():
+				webhookCfg, err := NewRelayReceiverNode(req.GetAlertEndpoint())
+				if err != nil {
+					return err
+				}
+				webhookCfg, err = WithWebhookImplementation(webhookCfg, toTraverseItem.details)
+				if err != nil {
+					return err
+				}
+				r.Receivers[recvPos].WebhookConfigs[toTraverseItem.position] = webhookCfg
+			case (&MSTeamsConfig{}).InternalId():
+				webhookCfg, err := NewRelayReceiverNode(req.GetAlertEndpoint())
+				if err != nil {
+					return err
+				}
+				webhookCfg, err = WithWebhookImplementation(webhookCfg, toTraverseItem.details)
+				if err != nil {
+					return err
+				}
+				r.Receivers[recvPos].MSTeamsConfigs[toTraverseItem.position] = &MSTeamsConfig{WebhookConfig: *webhookCfg}
+			case (&OpsGenieConfig{}).InternalId():
+				opsGenieCfg, err := NewOpsGenieReceiverNode(req.GetAlertEndpoint().GetOpsgenie())
+				if err != nil {
+					return err
+				}
+				opsGenieCfg, err = WithOpsGenieImplementation(opsGenieCfg, toTraverseItem.details)
+				if err != nil {
+					return err
+				}
+				r.Receivers[recvPos].OpsGenieConfigs[toTraverseItem.position] = opsGenieCfg
 			}
 		}
 	} else {
@@ -223,7 +262,21 @@ func (r *RoutingTree) UpdateIndividualEndpointNode(
 					r.Receivers[recvPos].PagerdutyConfigs,
 					toTraverseItem.position,
 					toTraverseItem.position+1)
-
+			case (&WebhookConfig{}).InternalId():
+				r.Receivers[recvPos].WebhookConfigs = slices.Delete(
+					r.Receivers[recvPos].WebhookConfigs,
+					toTraverseItem.position,
+					toTraverseItem.position+1)
+			case (&MSTeamsConfig{}).InternalId():
+				r.Receivers[recvPos].MSTeamsConfigs = slices.Delete(
+					r.Receivers[recvPos].MSTeamsConfigs,
+					toTraverseItem.position,
+					toTraverseItem.position+1)
+			case (&OpsGenieConfig{}).InternalId():
+				r.Receivers[recvPos].OpsGenieConfigs = slices.Delete(
+					r.Receivers[recvPos].OpsGenieConfigs,
+					toTraverseItem.position,
+					toTraverseItem.position+1)
 			}
 
 			newPos, newType, err := r.Receivers[recvPos].AddEndpoint(req.GetAlertEndpoint(), toTraverseItem.details)
@@ -294,6 +347,24 @@ func (r *RoutingTree) DeleteIndividualEndpointNode(
 				toTraverseItem.position,
 				toTraverseItem.position+1,
 			)
+		case (&WebhookConfig{}).InternalId():
+			r.Receivers[recvPos].WebhookConfigs = slices.Delete(
+				r.Receivers[recvPos].WebhookConfigs,
+				toTraverseItem.position,
+				toTraverseItem.position+1,
+			)
+		case (&MSTeamsConfig{}).InternalId():
+			r.Receivers[recvPos].MSTeamsConfigs = slices.Delete(
+				r.Receivers[recvPos].MSTeamsConfigs,
+				toTraverseItem.position,
+				toTraverseItem.position+1,
+			)
+		case (&OpsGenieConfig{}).InternalId():
+			r.Receivers[recvPos].OpsGenieConfigs = slices.Delete(
+				r.Receivers[recvPos].OpsGenieConfigs,
+				toTraverseItem.position,
+				toTraverseItem.position+1,
+			)
 		}
 	}
 	// clean up
@@ -0,0 +1,95 @@
+package routing
+
+import (
+	"fmt"
+
+	cfg "github.com/prometheus/alertmanager/config"
+	commoncfg "github.com/prometheus/common/config"
+	alertingv1 "github.com/rancher/opni/pkg/apis/alerting/v1"
+	"golang.org/x/exp/slices"
+)
+
+var _ OpniConfig = (*OpsGenieConfig)(nil)
+
+// OpsGenieConfig configures notifications via OpsGenie.
+type OpsGenieConfig struct {
+	cfg.NotifierConfig `yaml:",inline" json:",inline"`
+
+	HTTPConfig *commoncfg.HTTPClientConfig `yaml:"http_config,omitempty" json:"http_config,omitempty"`
+
+	// Change from secret to string since the string is stored in a kube secret anyways
+	APIKey       string                        `yaml:"api_key,omitempty" json:"api_key,omitempty"`
+	APIKeyFile   string                        `yaml:"api_key_file,omitempty" json:"api_key_file,omitempty"`
+	APIURL       *cfg.URL                      `yaml:"api_url,omitempty" json:"api_url,omitempty"`
+	Message      string                        `yaml:"message,omitempty" json:"message,omitempty"`
+	Description  string                        `yaml:"description,omitempty" json:"description,omitempty"`
+	Source       string                        `yaml:"source,omitempty" json:"source,omitempty"`
+	Details      map[string]string             `yaml:"details,omitempty" json:"details,omitempty"`
+	Entity       string                        `yaml:"entity,omitempty" json:"entity,omitempty"`
+	Responders   []cfg.OpsGenieConfigResponder `yaml:"responders,omitempty" json:"responders,omitempty"`
+	Actions      string                        `yaml:"actions,omitempty" json:"actions,omitempty"`
+	Tags         string                        `yaml:"tags,omitempty" json:"tags,omitempty"`
+	Note         string                        `yaml:"note,omitempty" json:"note,omitempty"`
+	Priority     string                        `yaml:"priority,omitempty" json:"priority,omitempty"`
+	UpdateAlerts bool                          `yaml:"update_alerts,omitempty" json:"update_alerts,omitempty"`
+}
+
+// AlertManager Compatible unmarshalling that implements the the yaml.Unmarshaler interface.
+func (c *OpsGenieConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	DefaultOpsGenie := c.Default().(*OpsGenieConfig)
+	*c = *DefaultOpsGenie
+	type plain OpsGenieConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.APIKey != "" && len(c.APIKeyFile) > 0 {
+		return fmt.Errorf("at most one of api_key & api_key_file must be configured")
+	}
+	return nil
+}
+
+func (c *OpsGenieConfig) Equal(other *OpsGenieConfig) (bool, string) {
+	return opsGenieConfigsAreEqual(c, other)
+}
+
+func (c *OpsGenieConfig) InternalId() string {
+	return "opsgenie"
+}
+
+func (c *OpsGenieConfig) ExtractDetails() *alertingv1.EndpointImplementation {
+	return &alertingv1.EndpointImplementation{
+		Title:        c.Message,
+		Body:         c.Description,
+		SendResolved: &c.VSendResolved,
+	}
+}
+
+func (c *OpsGenieConfig) Default() OpniConfig {
+	return &OpsGenieConfig{
+		NotifierConfig: cfg.NotifierConfig{
+			VSendResolved: true,
+		},
+		Message:     `{{ template "opsgenie.default.message" . }}`,
+		Description: `{{ template "opsgenie.default.description" . }}`,
+		Source:      `{{ template "opsgenie.default.source" . }}`,
+	}
+}
+
+func opsGenieConfigsAreEqual(o1, o2 *OpsGenieConfig) (equal bool, reason string) {
+	if o1.APIKey != o2.APIKey {
+		return false, fmt.Sprintf("api key mismatch %s <-> %s ", o1.APIKey, o2.APIKey)
+	}
+	if urlString(o1.APIURL) != urlString(o2.APIURL) {
+		return false, fmt.Sprintf("api url mismatch %s <-> %s ", urlString(o1.APIURL), urlString(o2.APIURL))
+	}
+	if !slices.Equal(o1.Responders, o2.Responders) {
+		return false, fmt.Sprintf("responders mismatch %v <-> %v ", o1.Responders, o2.Responders)
+	}
+	if o1.Message != o2.Message {
+		return false, fmt.Sprintf("message mismatch %s <-> %s ", o1.Message, o2.Message)
+	}
+	if o1.Description != o2.Description {
+		return false, fmt.Sprintf("description mismatch %s <-> %s ", o1.Description, o2.Description)
+	}
+	return true, ""
+}
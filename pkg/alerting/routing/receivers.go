@@ -56,6 +56,43 @@ func (r *Receiver) AddEndpoint(
 		r.PagerdutyConfigs = append(r.PagerdutyConfigs, pagerCfg)
 		return len(r.PagerdutyConfigs) - 1, pagerCfg.InternalId(), nil
 	}
+	if alertEndpoint.GetWebhook() != nil {
+		webhookCfg, err := NewRelayReceiverNode(alertEndpoint)
+		if err != nil {
+			return -1, "", err
+		}
+		webhookCfg, err = WithWebhookImplementation(webhookCfg, details)
+		if err != nil {
+			return -1, "", err
+		}
+		r.WebhookConfigs = append(r.WebhookConfigs, webhookCfg)
+		return len(r.WebhookConfigs) - 1, webhookCfg.InternalId(), nil
+	}
+	if alertEndpoint.GetMsTeams() != nil {
+		webhookCfg, err := NewRelayReceiverNode(alertEndpoint)
+		if err != nil {
+			return -1, "", err
+		}
+		webhookCfg, err = WithWebhookImplementation(webhookCfg, details)
+		if err != nil {
+			return -1, "", err
+		}
+		msTeamsCfg := &MSTeamsConfig{WebhookConfig: *webhookCfg}
+		r.MSTeamsConfigs = append(r.MSTeamsConfigs, msTeamsCfg)
+		return len(r.MSTeamsConfigs) - 1, msTeamsCfg.InternalId(), nil
+	}
+	if o := alertEndpoint.GetOpsgenie(); o != nil {
+		opsGenieCfg, err := NewOpsGenieReceiverNode(o)
+		if err != nil {
+			return -1, "", err
+		}
+		opsGenieCfg, err = WithOpsGenieImplementation(opsGenieCfg, details)
+		if err != nil {
+			return -1, "", err
+		}
+		r.OpsGenieConfigs = append(r.OpsGenieConfigs, opsGenieCfg)
+		return len(r.OpsGenieConfigs) - 1, opsGenieCfg.InternalId(), nil
+	}
 	return -1, "", validation.Errorf("unknown endpoint type : %v", alertEndpoint)
 }
 
@@ -210,6 +247,97 @@ func WithPagerDutyImplementatino(pg *PagerdutyConfig, impl *alertingv1.EndpointI
 	return pg, nil
 }
 
+// NewRelayReceiverNode handles both generic webhook & MS Teams endpoints,
+// which share the webhook configs of a receiver since both are sent through the opni relay
+func NewRelayReceiverNode(endpoint *alertingv1.AlertEndpoint) (*WebhookConfig, error) {
+	relay := &RelayRequest{}
+	if w := endpoint.GetWebhook(); w != nil {
+		if w.Url == "" {
+			return nil, validation.Errorf("webhook url is empty")
+		}
+		relay.Kind = RelayKindWebhook
+		relay.Target = w.Url
+		relay.Headers = w.Headers
+		relay.Template = w.Body
+	} else if m := endpoint.GetMsTeams(); m != nil {
+		if m.WebhookUrl == "" {
+			return nil, validation.Errorf("ms teams webhook url is empty")
+		}
+		relay.Kind = RelayKindMSTeams
+		relay.Target = m.WebhookUrl
+	} else {
+		return nil, validation.Errorf("endpoint %v is not relayed", endpoint)
+	}
+	if _, err := url.Parse(relay.Target); err != nil {
+		return nil, err
+	}
+	webhook := &WebhookConfig{}
+	if err := webhook.setRelayRequest(relay); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func WithWebhookImplementation(webhook *WebhookConfig, impl *alertingv1.EndpointImplementation) (*WebhookConfig, error) {
+	if def := impl.SendResolved; def != nil {
+		webhook.NotifierConfig = cfg.NotifierConfig{
+			VSendResolved: *def,
+		}
+	} else {
+		webhook.NotifierConfig = cfg.NotifierConfig{
+			VSendResolved: false,
+		}
+	}
+	relay, err := webhook.RelayRequest()
+	if err != nil {
+		return nil, err
+	}
+	relay.Title = impl.Title
+	relay.Body = impl.Body
+	if err := webhook.setRelayRequest(relay); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func NewOpsGenieReceiverNode(endpoint *alertingv1.OpsgenieEndpoint) (*OpsGenieConfig, error) {
+	if endpoint.ApiKey == "" {
+		return nil, validation.Errorf("opsgenie api key is empty")
+	}
+	og := &OpsGenieConfig{
+		APIKey: endpoint.ApiKey,
+	}
+	if endpoint.ApiUrl != nil {
+		apiURL, err := parseURL(*endpoint.ApiUrl)
+		if err != nil {
+			return nil, err
+		}
+		og.APIURL = apiURL
+	} // otherwise is set to global default
+	for _, r := range endpoint.Responders {
+		og.Responders = append(og.Responders, cfg.OpsGenieConfigResponder{
+			Name: r.Name,
+			Type: r.Type,
+		})
+	}
+	return og, nil
+}
+
+func WithOpsGenieImplementation(og *OpsGenieConfig, impl *alertingv1.EndpointImplementation) (*OpsGenieConfig, error) {
+	if def := impl.SendResolved; def != nil {
+		og.NotifierConfig = cfg.NotifierConfig{
+			VSendResolved: *def,
+		}
+	} else {
+		og.NotifierConfig = cfg.NotifierConfig{
+			VSendResolved: false,
+		}
+	}
+	og.Message = impl.Title
+	og.Description = impl.Body
+	return og, nil
+}
+
 // does the opposite of WithXXXXImplementation
 func (r *RoutingTree) ExtractImplementationDetails(conditionId, endpointType string, position int) (*alertingv1.EndpointImplementation, error) {
 	// find the condition Id receiver
@@ -240,6 +368,12 @@ func (r *RoutingTree) ExtractImplementationDetails(conditionId, endpointType str
 			Body:         body,
 			SendResolved: &r.Receivers[recvIdx].PagerdutyConfigs[position].VSendResolved,
 		}, nil
+	case (&WebhookConfig{}).InternalId():
+		return r.Receivers[recvIdx].WebhookConfigs[position].ExtractDetails(), nil
+	case (&MSTeamsConfig{}).InternalId():
+		return r.Receivers[recvIdx].MSTeamsConfigs[position].ExtractDetails(), nil
+	case (&OpsGenieConfig{}).InternalId():
+		return r.Receivers[recvIdx].OpsGenieConfigs[position].ExtractDetails(), nil
 	default:
 		return nil, validation.Errorf("unknown endpoint type %s", endpointType)
 	}
@@ -249,6 +383,7 @@ func (r *Receiver) IsEmpty() bool {
 	return len(r.EmailConfigs) == 0 &&
 		len(r.SlackConfigs) == 0 &&
 		len(r.WebhookConfigs) == 0 &&
+		len(r.MSTeamsConfigs) == 0 &&
 		len(r.PagerdutyConfigs) == 0 &&
 		len(r.OpsGenieConfigs) == 0 &&
 		len(r.VictorOpsConfigs) == 0 &&
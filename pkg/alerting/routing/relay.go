@@ -0,0 +1,196 @@
+package routing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/prometheus/alertmanager/template"
+	"go.uber.org/zap"
+)
+
+const relayTimeout = 30 * time.Second
+
+// relayTemplateData is the data available to the body templates of generic webhooks
+type relayTemplateData struct {
+	*template.Data
+	Title string
+	Body  string
+}
+
+// relayPayload is the default payload of generic webhooks : the AlertManager webhook payload
+// along with the rendered title & body
+type relayPayload struct {
+	*webhook.Message
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// https://learn.microsoft.com/en-us/outlook/actionable-messages/message-card-reference
+type msTeamsMessageCard struct {
+	Type       string `json:"@type"`
+	Context    string `json:"@context"`
+	ThemeColor string `json:"themeColor"`
+	Summary    string `json:"summary"`
+	Title      string `json:"title"`
+	Text       string `json:"text"`
+}
+
+// NewRelayHandler handles the AlertManager webhooks of endpoints that AlertManager
+// cannot send natively, see RelayRequest.
+//
+// Only the relay requests of the webhooks in the AlertManager config file are forwarded,
+// so that the relay cannot be used to send requests to arbitrary destinations.
+//
+// Like every AlertManager webhook, 5XX responses are retried while other errors are not.
+func NewRelayHandler(lg *zap.SugaredLogger, configFile string) (http.HandlerFunc, error) {
+	tmpl, err := template.FromGlobs()
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Timeout: relayTimeout}
+	return func(wr http.ResponseWriter, req *http.Request) {
+		relay, err := ParseRelayRequest(req)
+		if err != nil {
+			lg.Errorf("failed to parse relay request : %s", err)
+			http.Error(wr, err.Error(), http.StatusBadRequest)
+			return
+		}
+		configured, err := configuredRelays(configFile)
+		if err != nil {
+			lg.Errorf("failed to read the configured relays : %s", err)
+			http.Error(wr, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if credentials, _ := relay.Credentials(); !configured[credentials] {
+			lg.Warnf("rejecting %s relay request which is not configured by any receiver", relay.Kind)
+			http.Error(wr, "relay request is not configured by any receiver", http.StatusForbidden)
+			return
+		}
+		if req.Body == nil {
+			http.Error(wr, "request body required", http.StatusBadRequest)
+			return
+		}
+		defer req.Body.Close()
+		msg := &webhook.Message{}
+		if err := json.NewDecoder(req.Body).Decode(msg); err != nil {
+			lg.Errorf("failed to decode alertmanager payload : %s", err)
+			http.Error(wr, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if msg.Data == nil {
+			http.Error(wr, "alertmanager payload has no data", http.StatusBadRequest)
+			return
+		}
+		payload, err := relay.render(tmpl, msg)
+		if err != nil {
+			lg.Errorf("failed to render %s notification : %s", relay.Kind, err)
+			http.Error(wr, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fwd, err := http.NewRequestWithContext(req.Context(), http.MethodPost, relay.Target, bytes.NewReader(payload))
+		if err != nil {
+			http.Error(wr, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fwd.Header.Set("Content-Type", "application/json")
+		for name, value := range relay.Headers {
+			fwd.Header.Set(name, value)
+		}
+		resp, err := client.Do(fwd)
+		if err != nil {
+			lg.Warnf("failed to relay %s notification : %s", relay.Kind, err)
+			http.Error(wr, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		_, _ = io.Copy(io.Discard, resp.Body)
+		switch {
+		case resp.StatusCode/100 == 2:
+			wr.WriteHeader(http.StatusOK)
+		case resp.StatusCode/100 == 5:
+			lg.Warnf("%s notification destination responded with %s", relay.Kind, resp.Status)
+			http.Error(wr, resp.Status, http.StatusBadGateway)
+		default:
+			lg.Errorf("%s notification destination responded with %s", relay.Kind, resp.Status)
+			http.Error(wr, resp.Status, http.StatusBadRequest)
+		}
+	}, nil
+}
+
+// configuredRelays returns the encoded relay requests of the receivers in the
+// AlertManager config file
+func configuredRelays(configFile string) (map[string]bool, error) {
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := NewRoutingTreeFrom(string(data))
+	if err != nil {
+		return nil, err
+	}
+	configured := map[string]bool{}
+	for _, recv := range tree.Receivers {
+		for _, webhook := range recv.WebhookConfigs {
+			if credentials := webhook.relayCredentials(); credentials != "" {
+				configured[credentials] = true
+			}
+		}
+		for _, msTeams := range recv.MSTeamsConfigs {
+			if credentials := msTeams.relayCredentials(); credentials != "" {
+				configured[credentials] = true
+			}
+		}
+	}
+	return configured, nil
+}
+
+func (r *RelayRequest) render(tmpl *template.Template, msg *webhook.Message) ([]byte, error) {
+	title, err := tmpl.ExecuteTextString(r.Title, msg.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render title : %w", err)
+	}
+	body, err := tmpl.ExecuteTextString(r.Body, msg.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render body : %w", err)
+	}
+	switch r.Kind {
+	case RelayKindWebhook:
+		if r.Template == nil {
+			return json.Marshal(relayPayload{
+				Message: msg,
+				Title:   title,
+				Body:    body,
+			})
+		}
+		payload, err := tmpl.ExecuteTextString(*r.Template, relayTemplateData{
+			Data:  msg.Data,
+			Title: title,
+			Body:  body,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to render webhook template : %w", err)
+		}
+		return []byte(payload), nil
+	case RelayKindMSTeams:
+		color := "2DC72D"
+		if msg.Status == "firing" {
+			color = "D63333"
+		}
+		return json.Marshal(msTeamsMessageCard{
+			Type:       "MessageCard",
+			Context:    "http://schema.org/extensions",
+			ThemeColor: color,
+			Summary:    title,
+			Title:      title,
+			Text:       body,
+		})
+	default:
+		return nil, fmt.Errorf("unknown relay kind %s", r.Kind)
+	}
+}
@@ -0,0 +1,293 @@
+package routing_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/alertmanager/notify/webhook"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/samber/lo"
+
+	"github.com/rancher/opni/pkg/alerting/backend"
+	"github.com/rancher/opni/pkg/alerting/routing"
+	"github.com/rancher/opni/pkg/alerting/shared"
+	alertingv1 "github.com/rancher/opni/pkg/apis/alerting/v1"
+	"github.com/rancher/opni/pkg/logger"
+	"github.com/rancher/opni/pkg/test"
+)
+
+var _ = Describe("Relayed and opsgenie endpoints", Ordered, Label(test.Unit, test.Slow), func() {
+	When("we encode relay requests in webhook credentials", func() {
+		relay := &routing.RelayRequest{
+			Kind:     routing.RelayKindWebhook,
+			Target:   "https://example.com/hook?token=abc",
+			Headers:  map[string]string{"Authorization": "Bearer a:b", "X-Other": "value"},
+			Template: lo.ToPtr(`{"text" : "{{ .Title }}"}`),
+			Title:    "title",
+			Body:     "body",
+		}
+
+		It("should round trip relay requests", func() {
+			credentials, err := relay.Credentials()
+			Expect(err).To(Succeed())
+			parsed, err := routing.ParseRelayCredentials(credentials)
+			Expect(err).To(Succeed())
+			Expect(parsed).To(Equal(relay))
+
+			parsed, err = routing.ParseRelayRequest(relayHTTPRequest(relay, nil))
+			Expect(err).To(Succeed())
+			Expect(parsed).To(Equal(relay))
+		})
+
+		It("should not expose the target or headers in the relay url", func() {
+			Expect(relay.URL().String()).NotTo(ContainSubstring("example.com"))
+			Expect(relay.URL().String()).NotTo(ContainSubstring("Bearer"))
+			Expect(relay.URL().RawQuery).To(BeEmpty())
+		})
+
+		It("should reject requests that are not relay requests", func() {
+			By("rejecting unknown relay kinds")
+			unknown := &routing.RelayRequest{Kind: "unknown", Target: "https://example.com"}
+			credentials, err := unknown.Credentials()
+			Expect(err).To(Succeed())
+			_, err = routing.ParseRelayCredentials(credentials)
+			Expect(err).To(HaveOccurred())
+
+			By("rejecting requests without credentials")
+			req := relayHTTPRequest(relay, nil)
+			req.Header.Del("Authorization")
+			_, err = routing.ParseRelayRequest(req)
+			Expect(err).To(HaveOccurred())
+
+			By("rejecting requests whose path does not match the relay kind")
+			req = relayHTTPRequest(relay, nil)
+			req.URL.Path = shared.AlertingRelayHookName + routing.RelayKindMSTeams
+			_, err = routing.ParseRelayRequest(req)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	When("we attach the new endpoints to a condition", func() {
+		It("should construct a routing tree AlertManager accepts", func() {
+			conditionId := uuid.New().String()
+			webhookId, teamsId, opsgenieId := uuid.New().String(), uuid.New().String(), uuid.New().String()
+			details := &alertingv1.EndpointImplementation{
+				Title: "title",
+				Body:  "body",
+			}
+			r, _, ir, _ := Create2RoutingTrees()
+			err := r.CreateRoutingNodeForCondition(conditionId, &alertingv1.FullAttachedEndpoints{
+				Items: []*alertingv1.FullAttachedEndpoint{
+					{
+						EndpointId: webhookId,
+						AlertEndpoint: &alertingv1.AlertEndpoint{
+							Name: "webhook",
+							Endpoint: &alertingv1.AlertEndpoint_Webhook{
+								Webhook: &alertingv1.WebhookEndpoint{
+									Url:     "https://example.com/hook",
+									Headers: map[string]string{"Authorization": "Bearer token"},
+								},
+							},
+						},
+						Details: details,
+					},
+					{
+						EndpointId: teamsId,
+						AlertEndpoint: &alertingv1.AlertEndpoint{
+							Name: "teams",
+							Endpoint: &alertingv1.AlertEndpoint_MsTeams{
+								MsTeams: &alertingv1.MSTeamsEndpoint{
+									WebhookUrl: "https://example.webhook.office.com/webhookb2/id",
+								},
+							},
+						},
+						Details: details,
+					},
+					{
+						EndpointId: opsgenieId,
+						AlertEndpoint: &alertingv1.AlertEndpoint{
+							Name: "opsgenie",
+							Endpoint: &alertingv1.AlertEndpoint_Opsgenie{
+								Opsgenie: &alertingv1.OpsgenieEndpoint{
+									ApiKey: "some-key",
+									Responders: []*alertingv1.OpsgenieResponder{
+										{Name: "on-call", Type: "team"},
+									},
+								},
+							},
+						},
+						Details: details,
+					},
+				},
+				Details: details,
+			}, ir)
+			Expect(err).To(Succeed())
+
+			config, err := r.Marshal()
+			Expect(err).To(Succeed())
+			Expect(backend.ValidateIncomingConfig(string(config), logger.NewPluginLogger().Named("alerting"))).To(Succeed())
+
+			Expect(string(config)).NotTo(ContainSubstring("example.com"))
+			Expect(string(config)).NotTo(ContainSubstring("msteams_configs"))
+
+			reparsed := &routing.RoutingTree{}
+			Expect(reparsed.Parse(string(config))).To(Succeed())
+			equal, reason := r.Equal(reparsed)
+			Expect(equal).To(BeTrue(), reason)
+			recvIdx, err := reparsed.FindReceivers(conditionId)
+			Expect(err).To(Succeed())
+			Expect(reparsed.Receivers[recvIdx].WebhookConfigs).To(HaveLen(1))
+			Expect(reparsed.Receivers[recvIdx].MSTeamsConfigs).To(HaveLen(1))
+
+			for _, endpointId := range []string{webhookId, teamsId, opsgenieId} {
+				metadata, err := ir.Get(conditionId, endpointId)
+				Expect(err).To(Succeed())
+				extracted, err := r.ExtractImplementationDetails(conditionId, metadata.EndpointType, *metadata.Position)
+				Expect(err).To(Succeed())
+				Expect(extracted.Title).To(Equal(details.Title))
+				Expect(extracted.Body).To(Equal(details.Body))
+			}
+		})
+	})
+
+	When("the relay receives alertmanager webhooks", func() {
+		var received chan *http.Request
+		var receivedBody chan []byte
+		var target *httptest.Server
+		var relay http.HandlerFunc
+		var webhookRelay, teamsRelay *routing.RelayRequest
+
+		BeforeAll(func() {
+			received = make(chan *http.Request, 1)
+			receivedBody = make(chan []byte, 1)
+			target = httptest.NewServer(http.HandlerFunc(func(wr http.ResponseWriter, req *http.Request) {
+				body, _ := io.ReadAll(req.Body)
+				received <- req
+				receivedBody <- body
+			}))
+			DeferCleanup(target.Close)
+
+			conditionId := uuid.New().String()
+			r, _, ir, _ := Create2RoutingTrees()
+			Expect(r.CreateRoutingNodeForCondition(conditionId, &alertingv1.FullAttachedEndpoints{
+				Items: []*alertingv1.FullAttachedEndpoint{
+					{
+						EndpointId: uuid.New().String(),
+						AlertEndpoint: &alertingv1.AlertEndpoint{
+							Name: "webhook",
+							Endpoint: &alertingv1.AlertEndpoint_Webhook{
+								Webhook: &alertingv1.WebhookEndpoint{
+									Url:     target.URL,
+									Headers: map[string]string{"Authorization": "Bearer token"},
+									Body:    lo.ToPtr(`{"summary" : "{{ .Title }} : {{ .CommonLabels.alertname }}"}`),
+								},
+							},
+						},
+					},
+					{
+						EndpointId: uuid.New().String(),
+						AlertEndpoint: &alertingv1.AlertEndpoint{
+							Name: "teams",
+							Endpoint: &alertingv1.AlertEndpoint_MsTeams{
+								MsTeams: &alertingv1.MSTeamsEndpoint{
+									WebhookUrl: target.URL,
+								},
+							},
+						},
+					},
+				},
+				Details: &alertingv1.EndpointImplementation{
+					Title: "{{ .Status }}",
+					Body:  "{{ .CommonLabels.alertname }}",
+				},
+			}, ir)).To(Succeed())
+			recvIdx, err := r.FindReceivers(conditionId)
+			Expect(err).To(Succeed())
+			webhookRelay, err = r.Receivers[recvIdx].WebhookConfigs[0].RelayRequest()
+			Expect(err).To(Succeed())
+			teamsRelay, err = r.Receivers[recvIdx].MSTeamsConfigs[0].RelayRequest()
+			Expect(err).To(Succeed())
+
+			config, err := r.Marshal()
+			Expect(err).To(Succeed())
+			configFile := filepath.Join(GinkgoT().TempDir(), "alertmanager.yaml")
+			Expect(os.WriteFile(configFile, config, 0600)).To(Succeed())
+			relay, err = routing.NewRelayHandler(logger.NewPluginLogger().Named("relay"), configFile)
+			Expect(err).To(Succeed())
+		})
+
+		It("should forward templated generic webhooks with their headers", func() {
+			rec := httptest.NewRecorder()
+			relay(rec, relayHTTPRequest(webhookRelay, payload()))
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			req := <-received
+			Expect(req.Header.Get("Authorization")).To(Equal("Bearer token"))
+			Expect(string(<-receivedBody)).To(Equal(`{"summary" : "firing : test"}`))
+		})
+
+		It("should forward ms teams message cards", func() {
+			rec := httptest.NewRecorder()
+			relay(rec, relayHTTPRequest(teamsRelay, payload()))
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			<-received
+			card := map[string]string{}
+			Expect(json.Unmarshal(<-receivedBody, &card)).To(Succeed())
+			Expect(card["@type"]).To(Equal("MessageCard"))
+			Expect(card["title"]).To(Equal("firing"))
+			Expect(card["text"]).To(Equal("test"))
+		})
+
+		It("should refuse to forward relay requests that are not configured", func() {
+			By("rejecting unknown targets")
+			unknown := *webhookRelay
+			unknown.Target = "http://169.254.169.254/latest/meta-data"
+			rec := httptest.NewRecorder()
+			relay(rec, relayHTTPRequest(&unknown, payload()))
+			Expect(rec.Code).To(Equal(http.StatusForbidden))
+
+			By("rejecting modified headers of configured targets")
+			modified := *webhookRelay
+			modified.Headers = map[string]string{"Authorization": "Bearer other"}
+			rec = httptest.NewRecorder()
+			relay(rec, relayHTTPRequest(&modified, payload()))
+			Expect(rec.Code).To(Equal(http.StatusForbidden))
+
+			By("rejecting requests without a relay request")
+			req := relayHTTPRequest(webhookRelay, payload())
+			req.Header.Del("Authorization")
+			rec = httptest.NewRecorder()
+			relay(rec, req)
+			Expect(rec.Code).To(Equal(http.StatusBadRequest))
+			Expect(received).NotTo(Receive())
+		})
+	})
+})
+
+func payload() io.Reader {
+	data, err := json.Marshal(&webhook.Message{
+		Data: &template.Data{
+			Status:       "firing",
+			CommonLabels: template.KV{"alertname": "test"},
+		},
+		Version: "4",
+	})
+	Expect(err).To(Succeed())
+	return bytes.NewReader(data)
+}
+
+// relayHTTPRequest builds the webhook AlertManager sends for a relay request
+func relayHTTPRequest(relay *routing.RelayRequest, body io.Reader) *http.Request {
+	credentials, err := relay.Credentials()
+	Expect(err).To(Succeed())
+	req := httptest.NewRequest(http.MethodPost, relay.URL().String(), body)
+	req.Header.Set("Authorization", "OpniRelay "+credentials)
+	return req
+}
@@ -0,0 +1,225 @@
+package routing
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	cfg "github.com/prometheus/alertmanager/config"
+	"github.com/rancher/opni/pkg/alerting/shared"
+	alertingv1 "github.com/rancher/opni/pkg/apis/alerting/v1"
+)
+
+var _ OpniConfig = (*WebhookConfig)(nil)
+
+// WebhookConfig configures notifications via a generic webhook.
+//
+// Generic webhooks & MS Teams endpoints are not natively supported by AlertManager,
+// so their webhooks point to the opni relay (see RelayRequest), which renders and
+// forwards the notification to the actual destination.
+type WebhookConfig struct {
+	cfg.NotifierConfig `yaml:",inline" json:",inline"`
+
+	HTTPConfig *WebhookHTTPConfig `yaml:"http_config,omitempty" json:"http_config,omitempty"`
+
+	// URL to send POST request to.
+	URL *cfg.URL `yaml:"url" json:"url"`
+	// MaxAlerts is the maximum number of alerts to be sent per webhook message.
+	MaxAlerts uint64 `yaml:"max_alerts" json:"max_alerts"`
+}
+
+// WebhookHTTPConfig is the subset of AlertManager's http client config used by
+// relayed webhooks.
+// Changed from prometheus' HTTPClientConfig so that credentials are not marshalled as secrets
+type WebhookHTTPConfig struct {
+	Authorization *WebhookAuthorization `yaml:"authorization,omitempty" json:"authorization,omitempty"`
+}
+
+type WebhookAuthorization struct {
+	Type        string `yaml:"type,omitempty" json:"type,omitempty"`
+	Credentials string `yaml:"credentials,omitempty" json:"credentials,omitempty"`
+}
+
+// AlertManager Compatible unmarshalling that implements the the yaml.Unmarshaler interface.
+func (c *WebhookConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	DefaultWebhook := c.Default().(*WebhookConfig)
+	*c = *DefaultWebhook
+	type plain WebhookConfig
+	if err := unmarshal((*plain)(c)); err != nil {
+		return err
+	}
+	if c.URL == nil {
+		return fmt.Errorf("missing URL in webhook config")
+	}
+	if c.URL.Scheme != "https" && c.URL.Scheme != "http" {
+		return fmt.Errorf("scheme required for webhook url")
+	}
+	return nil
+}
+
+func (c *WebhookConfig) Equal(other *WebhookConfig) (bool, string) {
+	return webhookConfigsAreEqual(c, other)
+}
+
+func (c *WebhookConfig) InternalId() string {
+	return "webhook"
+}
+
+func (c *WebhookConfig) ExtractDetails() *alertingv1.EndpointImplementation {
+	details := &alertingv1.EndpointImplementation{
+		SendResolved: &c.VSendResolved,
+	}
+	if relay, err := c.RelayRequest(); err == nil {
+		details.Title = relay.Title
+		details.Body = relay.Body
+	}
+	return details
+}
+
+func (c *WebhookConfig) Default() OpniConfig {
+	return &WebhookConfig{
+		NotifierConfig: cfg.NotifierConfig{
+			VSendResolved: true,
+		},
+	}
+}
+
+// RelayRequest returns the relay request sent by this webhook, if it points to
+// the opni relay
+func (c *WebhookConfig) RelayRequest() (*RelayRequest, error) {
+	return ParseRelayCredentials(c.relayCredentials())
+}
+
+func (c *WebhookConfig) relayCredentials() string {
+	if c.HTTPConfig == nil || c.HTTPConfig.Authorization == nil ||
+		c.HTTPConfig.Authorization.Type != relayAuthorizationType {
+		return ""
+	}
+	return c.HTTPConfig.Authorization.Credentials
+}
+
+func (c *WebhookConfig) setRelayRequest(r *RelayRequest) error {
+	credentials, err := r.Credentials()
+	if err != nil {
+		return err
+	}
+	c.URL = &cfg.URL{URL: r.URL()}
+	c.HTTPConfig = &WebhookHTTPConfig{
+		Authorization: &WebhookAuthorization{
+			Type:        relayAuthorizationType,
+			Credentials: credentials,
+		},
+	}
+	return nil
+}
+
+func webhookConfigsAreEqual(w1, w2 *WebhookConfig) (equal bool, reason string) {
+	if w1.VSendResolved != w2.VSendResolved {
+		return false, fmt.Sprintf("send resolved mismatch %v <-> %v ", w1.VSendResolved, w2.VSendResolved)
+	}
+	if urlString(w1.URL) != urlString(w2.URL) {
+		return false, fmt.Sprintf("url mismatch %s <-> %s ", urlString(w1.URL), urlString(w2.URL))
+	}
+	// the relay request holds secrets, so it is not part of the reason
+	if w1.relayCredentials() != w2.relayCredentials() {
+		return false, "relay request mismatch"
+	}
+	if w1.MaxAlerts != w2.MaxAlerts {
+		return false, fmt.Sprintf("max alerts mismatch %d <-> %d ", w1.MaxAlerts, w2.MaxAlerts)
+	}
+	return true, ""
+}
+
+func urlString(u *cfg.URL) string {
+	if u == nil || u.URL == nil {
+		return ""
+	}
+	return u.String()
+}
+
+const (
+	RelayKindWebhook = "webhook"
+	RelayKindMSTeams = "msteams"
+
+	// authorization type of the AlertManager webhooks to the opni relay
+	relayAuthorizationType = "OpniRelay"
+)
+
+// RelayRequest holds everything the opni relay needs to forward a notification.
+//
+// It is encoded in the authorization credentials of the AlertManager webhook, which
+// AlertManager treats as a secret, rather than in the webhook url, which AlertManager
+// logs & displays.
+type RelayRequest struct {
+	// one of RelayKindWebhook, RelayKindMSTeams
+	Kind    string            `json:"kind"`
+	Target  string            `json:"target"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// optional body template of generic webhooks
+	Template *string `json:"template,omitempty"`
+	// templated title & body of the endpoint implementation
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// URL of the relay on the opni server embedded in AlertManager
+func (r *RelayRequest) URL() *url.URL {
+	return &url.URL{
+		Scheme: "http",
+		Host:   fmt.Sprintf("127.0.0.1:%d", shared.AlertingDefaultHookPort),
+		Path:   shared.AlertingRelayHookName + r.Kind,
+	}
+}
+
+// Credentials encodes the relay request in the authorization credentials of
+// AlertManager webhooks. The encoding is stable, so that routing trees can be compared.
+func (r *RelayRequest) Credentials() (string, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func ParseRelayCredentials(credentials string) (*RelayRequest, error) {
+	if credentials == "" {
+		return nil, fmt.Errorf("missing relay request")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(credentials)
+	if err != nil {
+		return nil, fmt.Errorf("malformed relay request : %w", err)
+	}
+	r := &RelayRequest{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("malformed relay request : %w", err)
+	}
+	if r.Kind != RelayKindWebhook && r.Kind != RelayKindMSTeams {
+		return nil, fmt.Errorf("unknown relay kind %s", r.Kind)
+	}
+	if r.Target == "" {
+		return nil, fmt.Errorf("missing relay target")
+	}
+	return r, nil
+}
+
+// ParseRelayRequest parses the relay request of an AlertManager webhook sent to the opni relay
+func ParseRelayRequest(req *http.Request) (*RelayRequest, error) {
+	if !strings.HasPrefix(req.URL.Path, shared.AlertingRelayHookName) {
+		return nil, fmt.Errorf("%s is not a relay url", req.URL.Path)
+	}
+	authType, credentials, _ := strings.Cut(req.Header.Get("Authorization"), " ")
+	if authType != relayAuthorizationType {
+		return nil, fmt.Errorf("missing relay request")
+	}
+	r, err := ParseRelayCredentials(credentials)
+	if err != nil {
+		return nil, err
+	}
+	if kind := strings.TrimPrefix(req.URL.Path, shared.AlertingRelayHookName); kind != r.Kind {
+		return nil, fmt.Errorf("relay kind mismatch %s <-> %s", kind, r.Kind)
+	}
+	return r, nil
+}
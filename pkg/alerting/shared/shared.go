@@ -19,6 +19,9 @@ import (
 var ComparisonOperators = []string{"<", ">", "<=", ">=", "=", "!="}
 var KubeStates = []string{"Pending", "Running", "Succeeded", "Failed", "Unknown"}
 
+// Endpoint constants
+var OpsgenieResponderTypes = []string{"team", "user", "escalation", "schedule"}
+
 const CortexDistributor = "distributor"
 const CortexIngester = "ingester"
 const CortexRuler = "ruler"
@@ -73,6 +76,8 @@ const (
 	AlertingHookReceiverName               = "opni.hook"
 	AlertingDefaultHookName                = "/opni/hook"
 	AlertingDefaultHookPort                = 3000
+	// relays notifications alertmanager cannot send natively, from its webhooks
+	AlertingRelayHookName = "/opni/relay/"
 )
 
 var (
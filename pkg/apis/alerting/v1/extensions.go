@@ -238,7 +238,7 @@ func (a *AlertCondition) GetTriggerAnnotations(conditionId string) map[string]st
 	res[shared.BackendConditionSeverityLabel] = a.GetSeverity().String()
 	res[shared.BackendConditionNameLabel] = a.GetName()
 	res[shared.BackendConditionIdLabel] = conditionId
-	res[shared.BackendConditionClusterIdLabel] = a.GetClusterId().Id
+	res[shared.BackendConditionClusterIdLabel] = a.GetClusterId().GetId()
 	if a.GetAlertType().GetSystem() != nil {
 		res = lo.Assign(res, a.GetAlertType().GetSystem().GetTriggerAnnotations())
 	}
@@ -248,6 +248,12 @@ func (a *AlertCondition) GetTriggerAnnotations(conditionId string) map[string]st
 	if a.GetAlertType().GetMonitoringBackend() != nil {
 		res = lo.Assign(res, a.GetAlertType().GetMonitoringBackend().GetTriggerAnnotations())
 	}
+	if a.GetAlertType().GetComposition() != nil {
+		res = lo.Assign(res, a.GetAlertType().GetComposition().GetTriggerAnnotations())
+	}
+	if a.GetAlertType().GetControlFlow() != nil {
+		res = lo.Assign(res, a.GetAlertType().GetControlFlow().GetTriggerAnnotations())
+	}
 	// prometheus query won't have specific template args
 	return res
 }
@@ -271,3 +277,20 @@ func (a *AlertConditionMonitoringBackend) GetTriggerAnnotations() map[string]str
 		"unhealthyThreshold": a.GetFor().String(),
 	}
 }
+
+func (a *AlertConditionComposition) GetTriggerAnnotations() map[string]string {
+	return map[string]string{
+		"action":     a.GetAction().String(),
+		"conditionX": a.GetX().GetId(),
+		"conditionY": a.GetY().GetId(),
+	}
+}
+
+func (a *AlertConditionControlFlow) GetTriggerAnnotations() map[string]string {
+	return map[string]string{
+		"action":     a.GetAction().String(),
+		"conditionX": a.GetX().GetId(),
+		"conditionY": a.GetY().GetId(),
+		"for":        a.GetFor().String(),
+	}
+}
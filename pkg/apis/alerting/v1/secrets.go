@@ -15,6 +15,19 @@ func (e *AlertEndpoint) RedactSecrets() {
 	if pg := e.GetPagerDuty(); pg != nil {
 		pg.IntegrationKey = storagev1.Redacted
 	}
+	if wh := e.GetWebhook(); wh != nil {
+		// webhook urls commonly embed tokens
+		wh.Url = storagev1.Redacted
+		for name := range wh.Headers {
+			wh.Headers[name] = storagev1.Redacted
+		}
+	}
+	if teams := e.GetMsTeams(); teams != nil {
+		teams.WebhookUrl = storagev1.Redacted
+	}
+	if og := e.GetOpsgenie(); og != nil {
+		og.ApiKey = storagev1.Redacted
+	}
 }
 
 func (e *AlertEndpoint) UnredactSecrets(unredacted *AlertEndpoint) {
@@ -30,6 +43,22 @@ func (e *AlertEndpoint) UnredactSecrets(unredacted *AlertEndpoint) {
 	if e.GetPagerDuty() != nil && e.GetPagerDuty().IntegrationKey == storagev1.Redacted {
 		e.GetPagerDuty().IntegrationKey = unredacted.GetPagerDuty().IntegrationKey
 	}
+	if e.GetWebhook() != nil {
+		if e.GetWebhook().Url == storagev1.Redacted {
+			e.GetWebhook().Url = unredacted.GetWebhook().GetUrl()
+		}
+		for name, value := range e.GetWebhook().Headers {
+			if value == storagev1.Redacted {
+				e.GetWebhook().Headers[name] = unredacted.GetWebhook().GetHeaders()[name]
+			}
+		}
+	}
+	if e.GetMsTeams() != nil && e.GetMsTeams().WebhookUrl == storagev1.Redacted {
+		e.GetMsTeams().WebhookUrl = unredacted.GetMsTeams().WebhookUrl
+	}
+	if e.GetOpsgenie() != nil && e.GetOpsgenie().ApiKey == storagev1.Redacted {
+		e.GetOpsgenie().ApiKey = unredacted.GetOpsgenie().ApiKey
+	}
 }
 
 func (e *AlertEndpoint) HasSameImplementation(other *AlertEndpoint) bool {
@@ -42,5 +71,14 @@ func (e *AlertEndpoint) HasSameImplementation(other *AlertEndpoint) bool {
 	if e.GetPagerDuty() != nil {
 		return other.GetPagerDuty() != nil
 	}
+	if e.GetWebhook() != nil {
+		return other.GetWebhook() != nil
+	}
+	if e.GetMsTeams() != nil {
+		return other.GetMsTeams() != nil
+	}
+	if e.GetOpsgenie() != nil {
+		return other.GetOpsgenie() != nil
+	}
 	return false
 }
@@ -68,5 +68,57 @@ var _ = Describe("Redacting opni alerting secrets", Ordered, Label(test.Unit, te
 			Expect(originalSlack.GetSlack().WebhookUrl).To(Equal(originalSlackCopy.GetSlack().WebhookUrl))
 			Expect(*originalEmail.GetEmail().SmtpAuthPassword).To(Equal(*originalEmailCopy.GetEmail().SmtpAuthPassword))
 		})
+
+		It("should redact/unredact secrets of webhook, ms teams and opsgenie endpoints", func() {
+			originalWebhook := &alertingv1.AlertEndpoint{
+				Name:        "some-name",
+				Description: "some-description",
+				Endpoint: &alertingv1.AlertEndpoint_Webhook{
+					Webhook: &alertingv1.WebhookEndpoint{
+						Url:     "http://mock-webhook-url",
+						Headers: map[string]string{"Authorization": "Bearer some-token"},
+					},
+				},
+			}
+			originalTeams := &alertingv1.AlertEndpoint{
+				Name:        "some-name",
+				Description: "some-description",
+				Endpoint: &alertingv1.AlertEndpoint_MsTeams{
+					MsTeams: &alertingv1.MSTeamsEndpoint{
+						WebhookUrl: "http://mock-teams-url",
+					},
+				},
+			}
+			originalOpsgenie := &alertingv1.AlertEndpoint{
+				Name:        "some-name",
+				Description: "some-description",
+				Endpoint: &alertingv1.AlertEndpoint_Opsgenie{
+					Opsgenie: &alertingv1.OpsgenieEndpoint{
+						ApiKey: "some-key",
+					},
+				},
+			}
+			originalWebhookCopy := util.ProtoClone(originalWebhook)
+			originalTeamsCopy := util.ProtoClone(originalTeams)
+			originalOpsgenieCopy := util.ProtoClone(originalOpsgenie)
+
+			originalWebhook.RedactSecrets()
+			originalTeams.RedactSecrets()
+			originalOpsgenie.RedactSecrets()
+
+			Expect(originalWebhook.GetWebhook().Headers["Authorization"]).To(Equal(storagev1.Redacted))
+			Expect(originalWebhook.GetWebhook().Url).To(Equal(storagev1.Redacted))
+			Expect(originalTeams.GetMsTeams().WebhookUrl).To(Equal(storagev1.Redacted))
+			Expect(originalOpsgenie.GetOpsgenie().ApiKey).To(Equal(storagev1.Redacted))
+
+			originalWebhook.UnredactSecrets(originalWebhookCopy)
+			originalTeams.UnredactSecrets(originalTeamsCopy)
+			originalOpsgenie.UnredactSecrets(originalOpsgenieCopy)
+
+			Expect(originalWebhook.GetWebhook().Url).To(Equal(originalWebhookCopy.GetWebhook().Url))
+			Expect(originalWebhook.GetWebhook().Headers).To(Equal(originalWebhookCopy.GetWebhook().Headers))
+			Expect(originalTeams.GetMsTeams().WebhookUrl).To(Equal(originalTeamsCopy.GetMsTeams().WebhookUrl))
+			Expect(originalOpsgenie.GetOpsgenie().ApiKey).To(Equal(originalOpsgenieCopy.GetOpsgenie().ApiKey))
+		})
 	})
 })
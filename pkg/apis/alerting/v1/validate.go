@@ -4,9 +4,12 @@ import (
 	"net/mail"
 	"net/url"
 	"strings"
+	texttemplate "text/template"
 
+	amtemplate "github.com/prometheus/alertmanager/template"
 	promql "github.com/prometheus/prometheus/promql/parser"
 	"github.com/rancher/opni/pkg/alerting/shared"
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
 	"github.com/rancher/opni/pkg/validation"
 	"golang.org/x/exp/slices"
 )
@@ -51,12 +54,37 @@ func (k *AlertConditionKubeState) Validate() error {
 	return nil
 }
 
+func validateConditionOperands(x, y *corev1.Reference) error {
+	if x.GetId() == "" {
+		return validation.Error("x condition must be set")
+	}
+	if y.GetId() == "" {
+		return validation.Error("y condition must be set")
+	}
+	if x.GetId() == y.GetId() {
+		return validation.Error("x and y must reference different conditions")
+	}
+	return nil
+}
+
 func (c *AlertConditionComposition) Validate() error {
-	return shared.WithUnimplementedErrorf("Composition alerts not implemented yet")
+	if _, ok := CompositionAction_name[int32(c.GetAction())]; !ok {
+		return validation.Errorf("unknown composition action %d", c.GetAction())
+	}
+	return validateConditionOperands(c.GetX(), c.GetY())
 }
 
 func (c *AlertConditionControlFlow) Validate() error {
-	return shared.WithUnimplementedErrorf("Control flow alerts not implemented yet")
+	if _, ok := ControlFlowAction_name[int32(c.GetAction())]; !ok {
+		return validation.Errorf("unknown control flow action %d", c.GetAction())
+	}
+	if err := validateConditionOperands(c.GetX(), c.GetY()); err != nil {
+		return err
+	}
+	if c.GetFor().AsDuration() <= 0 {
+		return validation.Error("\"for\" duration must be set")
+	}
+	return nil
 }
 
 func (c *AlertConditionCPUSaturation) Validate() error {
@@ -236,6 +264,15 @@ func (a *AlertEndpoint) Validate() error {
 	if a.GetPagerDuty() != nil {
 		return a.GetPagerDuty().Validate()
 	}
+	if a.GetWebhook() != nil {
+		return a.GetWebhook().Validate()
+	}
+	if a.GetMsTeams() != nil {
+		return a.GetMsTeams().Validate()
+	}
+	if a.GetOpsgenie() != nil {
+		return a.GetOpsgenie().Validate()
+	}
 	return shared.WithUnimplementedErrorf("AlertEndpoint type %v not implemented yet", a)
 }
 
@@ -288,6 +325,56 @@ func (a *PagerDutyEndpoint) Validate() error {
 	return nil
 }
 
+func (w *WebhookEndpoint) Validate() error {
+	if w.GetUrl() == "" {
+		return validation.Error("url must be set for webhook endpoint")
+	}
+	if _, err := url.ParseRequestURI(w.GetUrl()); err != nil {
+		return validation.Errorf("url must be a valid url : %s", err)
+	}
+	for name := range w.GetHeaders() {
+		if name == "" {
+			return validation.Error("webhook header names must be non-empty")
+		}
+	}
+	if w.Body != nil {
+		if _, err := texttemplate.New("body").Funcs(texttemplate.FuncMap(amtemplate.DefaultFuncs)).Parse(w.GetBody()); err != nil {
+			return validation.Errorf("body must be a valid template : %s", err)
+		}
+	}
+	return nil
+}
+
+func (m *MSTeamsEndpoint) Validate() error {
+	if m.GetWebhookUrl() == "" {
+		return validation.Error("webhook must be set for ms teams endpoint")
+	}
+	if _, err := url.ParseRequestURI(m.GetWebhookUrl()); err != nil {
+		return validation.Errorf("webhook must be a valid url : %s", err)
+	}
+	return nil
+}
+
+func (o *OpsgenieEndpoint) Validate() error {
+	if o.GetApiKey() == "" {
+		return validation.Error("api key must be set for opsgenie endpoint")
+	}
+	if o.ApiUrl != nil {
+		if _, err := url.ParseRequestURI(o.GetApiUrl()); err != nil {
+			return validation.Errorf("api url must be a valid url : %s", err)
+		}
+	}
+	for _, r := range o.GetResponders() {
+		if r.GetName() == "" {
+			return validation.Error("opsgenie responder name must be set")
+		}
+		if !slices.Contains(shared.OpsgenieResponderTypes, r.GetType()) {
+			return validation.Errorf("opsgenie responder type must be one of %v", shared.OpsgenieResponderTypes)
+		}
+	}
+	return nil
+}
+
 func (l *ListAlertEndpointsRequest) Validate() error {
 	return nil
 }
@@ -0,0 +1,75 @@
+package v1_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	alertingv1 "github.com/rancher/opni/pkg/apis/alerting/v1"
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	"github.com/rancher/opni/pkg/test"
+	"github.com/rancher/opni/pkg/validation"
+)
+
+func validateEntry[T validation.Validator](in T, valid bool) {
+	if valid {
+		Expect(in.Validate()).To(Succeed())
+	} else {
+		Expect(in.Validate()).NotTo(Succeed())
+	}
+}
+
+var _ = Describe("Alert condition validation", Label(test.Unit), func() {
+	DescribeTable("AlertConditionComposition", validateEntry[*alertingv1.AlertConditionComposition],
+		Entry("valid AND", &alertingv1.AlertConditionComposition{
+			Action: alertingv1.CompositionAction_AND,
+			X:      &corev1.Reference{Id: "x"},
+			Y:      &corev1.Reference{Id: "y"},
+		}, true),
+		Entry("valid OR", &alertingv1.AlertConditionComposition{
+			Action: alertingv1.CompositionAction_OR,
+			X:      &corev1.Reference{Id: "x"},
+			Y:      &corev1.Reference{Id: "y"},
+		}, true),
+		Entry("missing x", &alertingv1.AlertConditionComposition{
+			Y: &corev1.Reference{Id: "y"},
+		}, false),
+		Entry("missing y", &alertingv1.AlertConditionComposition{
+			X: &corev1.Reference{Id: "x"},
+		}, false),
+		Entry("same operands", &alertingv1.AlertConditionComposition{
+			X: &corev1.Reference{Id: "x"},
+			Y: &corev1.Reference{Id: "x"},
+		}, false),
+		Entry("unknown action", &alertingv1.AlertConditionComposition{
+			Action: alertingv1.CompositionAction(42),
+			X:      &corev1.Reference{Id: "x"},
+			Y:      &corev1.Reference{Id: "y"},
+		}, false),
+	)
+	DescribeTable("AlertConditionControlFlow", validateEntry[*alertingv1.AlertConditionControlFlow],
+		Entry("valid IF_THEN", &alertingv1.AlertConditionControlFlow{
+			Action: alertingv1.ControlFlowAction_IF_THEN,
+			X:      &corev1.Reference{Id: "x"},
+			Y:      &corev1.Reference{Id: "y"},
+			For:    durationpb.New(time.Minute),
+		}, true),
+		Entry("valid IF_NOT_THEN", &alertingv1.AlertConditionControlFlow{
+			Action: alertingv1.ControlFlowAction_IF_NOT_THEN,
+			X:      &corev1.Reference{Id: "x"},
+			Y:      &corev1.Reference{Id: "y"},
+			For:    durationpb.New(time.Minute),
+		}, true),
+		Entry("missing for", &alertingv1.AlertConditionControlFlow{
+			X: &corev1.Reference{Id: "x"},
+			Y: &corev1.Reference{Id: "y"},
+		}, false),
+		Entry("same operands", &alertingv1.AlertConditionControlFlow{
+			X:   &corev1.Reference{Id: "x"},
+			Y:   &corev1.Reference{Id: "x"},
+			For: durationpb.New(time.Minute),
+		}, false),
+	)
+})
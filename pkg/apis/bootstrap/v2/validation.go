@@ -12,5 +12,8 @@ func (h *BootstrapAuthRequest) Validate() error {
 	if len(h.ClientPubKey) == 0 {
 		return validation.Errorf("%w: %s", validation.ErrMissingRequiredField, "ClientPubKey")
 	}
+	if err := validation.ValidateLabels(h.Labels); err != nil {
+		return err
+	}
 	return nil
 }
@@ -86,3 +86,25 @@ func (a *PatchList) Validate() error {
 	}
 	return nil
 }
+
+func (r *RotateKeysRequest) Validate() error {
+	if len(r.ServerPubKey) == 0 {
+		return validation.Errorf("%w: %s", validation.ErrMissingRequiredField, "serverPubKey")
+	}
+	if r.GracePeriod != nil {
+		if err := r.GracePeriod.CheckValid(); err != nil {
+			return validation.Errorf("invalid gracePeriod: %v", err)
+		}
+		if r.GracePeriod.AsDuration() < 0 {
+			return validation.Error("gracePeriod cannot be negative")
+		}
+	}
+	return nil
+}
+
+func (r *CommitKeysRequest) Validate() error {
+	if len(r.ClientPubKey) == 0 {
+		return validation.Errorf("%w: %s", validation.ErrMissingRequiredField, "clientPubKey")
+	}
+	return nil
+}
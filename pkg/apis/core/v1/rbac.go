@@ -0,0 +1,125 @@
+package v1
+
+import (
+	"github.com/samber/lo"
+	"golang.org/x/exp/slices"
+)
+
+type Verb string
+
+const (
+	VerbRead  Verb = "read"
+	VerbWrite Verb = "write"
+	VerbAdmin Verb = "admin"
+)
+
+type ResourceKind string
+
+const (
+	ResourceMetrics         ResourceKind = "metrics"
+	ResourceLogs            ResourceKind = "logs"
+	ResourceAlertConditions ResourceKind = "alertconditions"
+	ResourceSLOs            ResourceKind = "slos"
+	ResourceTokens          ResourceKind = "tokens"
+	ResourceClusters        ResourceKind = "clusters"
+	ResourceRoles           ResourceKind = "roles"
+	ResourceAll             ResourceKind = "*"
+)
+
+// AllClustersID can be listed in a role's cluster IDs to apply the role to
+// every cluster.
+const AllClustersID = "*"
+
+var (
+	Verbs         = []Verb{VerbRead, VerbWrite, VerbAdmin}
+	ResourceKinds = []ResourceKind{
+		ResourceMetrics,
+		ResourceLogs,
+		ResourceAlertConditions,
+		ResourceSLOs,
+		ResourceTokens,
+		ResourceClusters,
+		ResourceRoles,
+		ResourceAll,
+	}
+)
+
+// Includes reports whether the verb implies the other verb:
+// admin implies write, which implies read.
+func (v Verb) Includes(other Verb) bool {
+	return slices.Index(Verbs, v) >= slices.Index(Verbs, other) && slices.Contains(Verbs, other)
+}
+
+// Includes reports whether the resource kind covers the other resource kind.
+func (k ResourceKind) Includes(other ResourceKind) bool {
+	return k == ResourceAll || k == other
+}
+
+// ClusterScoped reports whether resources of the kind belong to clusters, so
+// that access to them depends on the clusters a role applies to.
+func (k ResourceKind) ClusterScoped() bool {
+	switch k {
+	case ResourceTokens, ResourceRoles:
+		return false
+	default:
+		return true
+	}
+}
+
+// EffectiveVerbs returns the verbs allowed by the role. Roles created before
+// verbs were introduced only granted read access.
+func (r *Role) EffectiveVerbs() []Verb {
+	if len(r.GetVerbs()) == 0 {
+		return []Verb{VerbRead}
+	}
+	verbs := make([]Verb, len(r.GetVerbs()))
+	for i, v := range r.GetVerbs() {
+		verbs[i] = Verb(v)
+	}
+	return verbs
+}
+
+// EffectiveResources returns the resource kinds the role applies to. Roles
+// created before resource kinds were introduced only applied to metrics.
+func (r *Role) EffectiveResources() []ResourceKind {
+	if len(r.GetResources()) == 0 {
+		return []ResourceKind{ResourceMetrics}
+	}
+	resources := make([]ResourceKind, len(r.GetResources()))
+	for i, res := range r.GetResources() {
+		resources[i] = ResourceKind(res)
+	}
+	return resources
+}
+
+// Grants reports whether the role allows the verb on the resource kind.
+func (r *Role) Grants(verb Verb, resource ResourceKind) bool {
+	verbAllowed := lo.ContainsBy(r.EffectiveVerbs(), func(v Verb) bool {
+		return v.Includes(verb)
+	})
+	return verbAllowed && lo.ContainsBy(r.EffectiveResources(), func(k ResourceKind) bool {
+		return k.Includes(resource)
+	})
+}
+
+// AppliesToAllClusters reports whether the role applies to every cluster,
+// regardless of its label selector.
+func (r *Role) AppliesToAllClusters() bool {
+	return slices.Contains(r.GetClusterIDs(), AllClustersID)
+}
+
+// RequestedVerb returns the verb of the request, defaulting to read.
+func (sar *SubjectAccessRequest) RequestedVerb() Verb {
+	if sar.GetVerb() == "" {
+		return VerbRead
+	}
+	return Verb(sar.GetVerb())
+}
+
+// RequestedResource returns the resource kind of the request, defaulting to metrics.
+func (sar *SubjectAccessRequest) RequestedResource() ResourceKind {
+	if sar.GetResource() == "" {
+		return ResourceMetrics
+	}
+	return ResourceKind(sar.GetResource())
+}
@@ -0,0 +1,35 @@
+package v1_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "github.com/rancher/opni/pkg/apis/core/v1"
+)
+
+var _ = Describe("RBAC", Label("unit"), func() {
+	DescribeTable("Role grants",
+		func(role *v1.Role, verb v1.Verb, resource v1.ResourceKind, granted bool) {
+			Expect(role.Grants(verb, resource)).To(Equal(granted))
+		},
+		Entry("legacy roles read metrics", &v1.Role{}, v1.VerbRead, v1.ResourceMetrics, true),
+		Entry("legacy roles cannot write metrics", &v1.Role{}, v1.VerbWrite, v1.ResourceMetrics, false),
+		Entry("legacy roles cannot read slos", &v1.Role{}, v1.VerbRead, v1.ResourceSLOs, false),
+		Entry("write implies read", &v1.Role{
+			Verbs:     []string{"write"},
+			Resources: []string{"slos"},
+		}, v1.VerbRead, v1.ResourceSLOs, true),
+		Entry("write does not imply admin", &v1.Role{
+			Verbs:     []string{"write"},
+			Resources: []string{"slos"},
+		}, v1.VerbAdmin, v1.ResourceSLOs, false),
+		Entry("resources are scoped", &v1.Role{
+			Verbs:     []string{"admin"},
+			Resources: []string{"slos"},
+		}, v1.VerbRead, v1.ResourceClusters, false),
+		Entry("wildcard resources", &v1.Role{
+			Verbs:     []string{"admin"},
+			Resources: []string{"*"},
+		}, v1.VerbAdmin, v1.ResourceClusters, true),
+	)
+})
@@ -0,0 +1,50 @@
+package v1
+
+import (
+	"path"
+	"strings"
+)
+
+// UsageLimit returns the maximum number of times the token can be used to
+// join a cluster, or 0 if the number of uses is not limited.
+func (m *BootstrapTokenMetadata) UsageLimit() int64 {
+	if m.GetOneShot() {
+		return 1
+	}
+	return m.GetMaxUsages()
+}
+
+// UsageLimitReached returns true if the token cannot be used any more.
+func (m *BootstrapTokenMetadata) UsageLimitReached() bool {
+	limit := m.UsageLimit()
+	return limit > 0 && m.GetUsageCount() >= limit
+}
+
+// AllowsClusterId returns true if a cluster with the given ID may join using
+// the token.
+func (m *BootstrapTokenMetadata) AllowsClusterId(id string) bool {
+	if len(m.GetAllowedClusterIds()) == 0 {
+		return true
+	}
+	for _, pattern := range m.GetAllowedClusterIds() {
+		if ok, _ := path.Match(pattern, id); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsLabel returns true if a cluster joining with the token may request
+// the given label.
+func (m *BootstrapTokenMetadata) AllowsLabel(key, value string) bool {
+	for _, pattern := range m.GetAllowedLabels() {
+		patternKey, valuePattern, ok := strings.Cut(pattern, "=")
+		if !ok || patternKey != key {
+			continue
+		}
+		if ok, _ := path.Match(valuePattern, value); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package v1_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	v1 "github.com/rancher/opni/pkg/apis/core/v1"
+)
+
+var _ = Describe("Bootstrap Token Scopes", Label("unit"), func() {
+	It("should compute usage limits", func() {
+		Expect((&v1.BootstrapTokenMetadata{}).UsageLimit()).To(BeEquivalentTo(0))
+		Expect((&v1.BootstrapTokenMetadata{MaxUsages: 3}).UsageLimit()).To(BeEquivalentTo(3))
+		Expect((&v1.BootstrapTokenMetadata{MaxUsages: 3, OneShot: true}).UsageLimit()).To(BeEquivalentTo(1))
+
+		Expect((&v1.BootstrapTokenMetadata{UsageCount: 100}).UsageLimitReached()).To(BeFalse())
+		Expect((&v1.BootstrapTokenMetadata{UsageCount: 2, MaxUsages: 3}).UsageLimitReached()).To(BeFalse())
+		Expect((&v1.BootstrapTokenMetadata{UsageCount: 3, MaxUsages: 3}).UsageLimitReached()).To(BeTrue())
+		Expect((&v1.BootstrapTokenMetadata{UsageCount: 1, OneShot: true}).UsageLimitReached()).To(BeTrue())
+	})
+	It("should match allowed cluster IDs", func() {
+		Expect((&v1.BootstrapTokenMetadata{}).AllowsClusterId("anything")).To(BeTrue())
+
+		md := &v1.BootstrapTokenMetadata{
+			AllowedClusterIds: []string{"prod-*", "staging"},
+		}
+		Expect(md.AllowsClusterId("prod-1")).To(BeTrue())
+		Expect(md.AllowsClusterId("staging")).To(BeTrue())
+		Expect(md.AllowsClusterId("staging-1")).To(BeFalse())
+		Expect(md.AllowsClusterId("dev")).To(BeFalse())
+	})
+	It("should match allowed labels", func() {
+		Expect((&v1.BootstrapTokenMetadata{}).AllowsLabel("foo", "bar")).To(BeFalse())
+
+		md := &v1.BootstrapTokenMetadata{
+			AllowedLabels: []string{"env=prod-*", "team=*"},
+		}
+		Expect(md.AllowsLabel("env", "prod-1")).To(BeTrue())
+		Expect(md.AllowsLabel("env", "dev")).To(BeFalse())
+		Expect(md.AllowsLabel("team", "")).To(BeTrue())
+		Expect(md.AllowsLabel("region", "us")).To(BeFalse())
+	})
+})
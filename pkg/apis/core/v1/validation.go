@@ -5,6 +5,7 @@ import (
 
 	"github.com/rancher/opni/pkg/validation"
 	"github.com/samber/lo"
+	"golang.org/x/exp/slices"
 )
 
 func (c *Cluster) Validate() error {
@@ -82,6 +83,9 @@ func (r *Role) Validate() error {
 		return fmt.Errorf("%w: %q", err, r.Id)
 	}
 	for _, clusterID := range r.ClusterIDs {
+		if clusterID == AllClustersID {
+			continue
+		}
 		if err := validation.ValidateID(clusterID); err != nil {
 			return fmt.Errorf("%w: %q", err, clusterID)
 		}
@@ -94,6 +98,36 @@ func (r *Role) Validate() error {
 			return err
 		}
 	}
+	for _, verb := range r.Verbs {
+		if err := Verb(verb).Validate(); err != nil {
+			return err
+		}
+	}
+	if len(lo.Uniq(r.Verbs)) != len(r.Verbs) {
+		return fmt.Errorf("%w: %s", validation.ErrDuplicate, "verbs")
+	}
+	for _, resource := range r.Resources {
+		if err := ResourceKind(resource).Validate(); err != nil {
+			return err
+		}
+	}
+	if len(lo.Uniq(r.Resources)) != len(r.Resources) {
+		return fmt.Errorf("%w: %s", validation.ErrDuplicate, "resources")
+	}
+	return nil
+}
+
+func (v Verb) Validate() error {
+	if !slices.Contains(Verbs, v) {
+		return fmt.Errorf("%w: unknown verb %q", validation.ErrInvalidValue, v)
+	}
+	return nil
+}
+
+func (k ResourceKind) Validate() error {
+	if !slices.Contains(ResourceKinds, k) {
+		return fmt.Errorf("%w: unknown resource kind %q", validation.ErrInvalidValue, k)
+	}
 	return nil
 }
 
@@ -135,6 +169,16 @@ func (sar *SubjectAccessRequest) Validate() error {
 	if err := validation.ValidateSubject(sar.Subject); err != nil {
 		return err
 	}
+	if sar.Verb != "" {
+		if err := Verb(sar.Verb).Validate(); err != nil {
+			return err
+		}
+	}
+	if sar.Resource != "" {
+		if err := ResourceKind(sar.Resource).Validate(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
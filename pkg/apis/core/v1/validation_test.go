@@ -87,6 +87,31 @@ var _ = Describe("Validation", Label("unit"), func() {
 				},
 			},
 		}, nil),
+		Entry(nil, &v1.Role{
+			Id:         "foo",
+			ClusterIDs: []string{v1.AllClustersID},
+		}, nil),
+		Entry(nil, &v1.Role{
+			Id:    "foo",
+			Verbs: []string{"delete"},
+		}, validation.ErrInvalidValue),
+		Entry(nil, &v1.Role{
+			Id:    "foo",
+			Verbs: []string{"read", "read"},
+		}, validation.ErrDuplicate),
+		Entry(nil, &v1.Role{
+			Id:        "foo",
+			Resources: []string{"everything"},
+		}, validation.ErrInvalidValue),
+		Entry(nil, &v1.Role{
+			Id:        "foo",
+			Resources: []string{"slos", "slos"},
+		}, validation.ErrDuplicate),
+		Entry(nil, &v1.Role{
+			Id:        "foo",
+			Verbs:     []string{"read", "write"},
+			Resources: []string{"slos", "alertconditions"},
+		}, nil),
 	)
 	DescribeTable("RoleBinding", validateEntry[*v1.RoleBinding],
 		Entry(nil, &v1.RoleBinding{}, validation.ErrMissingRequiredField),
@@ -113,6 +138,9 @@ var _ = Describe("Validation", Label("unit"), func() {
 		Entry(nil, &v1.SubjectAccessRequest{}, validation.ErrMissingRequiredField),
 		Entry(nil, &v1.SubjectAccessRequest{Subject: "\\"}, validation.ErrInvalidSubjectName),
 		Entry(nil, &v1.SubjectAccessRequest{Subject: "foo"}, nil),
+		Entry(nil, &v1.SubjectAccessRequest{Subject: "foo", Verb: "delete"}, validation.ErrInvalidValue),
+		Entry(nil, &v1.SubjectAccessRequest{Subject: "foo", Resource: "everything"}, validation.ErrInvalidValue),
+		Entry(nil, &v1.SubjectAccessRequest{Subject: "foo", Verb: "write", Resource: "slos"}, nil),
 	)
 	DescribeTable("MatchOptions", validateEntry[v1.MatchOptions],
 		Entry(nil, v1.MatchOptions_Default, nil),
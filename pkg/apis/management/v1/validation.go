@@ -3,7 +3,10 @@ package v1
 import (
 	"encoding/json"
 	"fmt"
+	"path"
+	"strings"
 
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
 	"github.com/rancher/opni/pkg/validation"
 )
 
@@ -22,6 +25,26 @@ func (r *CreateBootstrapTokenRequest) Validate() error {
 			return err
 		}
 	}
+	if r.GetMaxUsages() < 0 {
+		return fmt.Errorf("%w: %s", validation.ErrInvalidValue, "maxUsages cannot be negative")
+	}
+	for _, pattern := range r.GetAllowedClusterIds() {
+		if _, err := path.Match(pattern, ""); pattern == "" || err != nil {
+			return fmt.Errorf("%w (allowedClusterIds): invalid pattern %q", validation.ErrInvalidValue, pattern)
+		}
+	}
+	for _, pattern := range r.GetAllowedLabels() {
+		key, valuePattern, ok := strings.Cut(pattern, "=")
+		if !ok {
+			return fmt.Errorf("%w (allowedLabels): %q is not of the form key=value", validation.ErrInvalidValue, pattern)
+		}
+		if err := validation.ValidateLabelName(key); err != nil {
+			return err
+		}
+		if _, err := path.Match(valuePattern, ""); err != nil {
+			return fmt.Errorf("%w (allowedLabels): invalid pattern %q", validation.ErrInvalidValue, pattern)
+		}
+	}
 	return nil
 }
 
@@ -50,6 +73,54 @@ func (r *EditClusterRequest) Validate() error {
 	return nil
 }
 
+func (r *RotateClusterKeyringRequest) Validate() error {
+	if r.Cluster == nil {
+		return fmt.Errorf("%w: %s", validation.ErrMissingRequiredField, "cluster")
+	}
+	if err := validation.Validate(r.Cluster); err != nil {
+		return err
+	}
+	if r.GracePeriod != nil {
+		if err := r.GetGracePeriod().CheckValid(); err != nil {
+			return validation.Errorf("invalid gracePeriod: %v", err)
+		}
+		if r.GetGracePeriod().AsDuration() < 0 {
+			return validation.Error("gracePeriod cannot be negative")
+		}
+	}
+	return nil
+}
+
+func (r *TaskReference) Validate() error {
+	if r.GetNamespace() == "" {
+		return validation.Errorf("%s: namespace", validation.ErrMissingRequiredField)
+	}
+	for _, field := range []struct{ name, value string }{
+		{"controller", r.GetController()},
+		{"id", r.GetId()},
+	} {
+		if field.value == "" {
+			return validation.Errorf("%s: %s", validation.ErrMissingRequiredField, field.name)
+		}
+		if strings.Contains(field.value, "/") {
+			return validation.Errorf("invalid %s: %q", field.name, field.value)
+		}
+	}
+	return nil
+}
+
+func (r *PruneTasksRequest) Validate() error {
+	if r.Retention != nil {
+		if err := r.GetRetention().CheckValid(); err != nil {
+			return validation.Errorf("invalid retention: %v", err)
+		}
+		if r.GetRetention().AsDuration() < 0 {
+			return validation.Error("retention cannot be negative")
+		}
+	}
+	return nil
+}
+
 func (r *WatchClustersRequest) Validate() error {
 	for _, c := range r.GetKnownClusters().GetItems() {
 		if err := validation.Validate(c); err != nil {
@@ -110,3 +181,51 @@ func (r *CapabilityUninstallCancelRequest) Validate() error {
 	}
 	return nil
 }
+
+func validateBulkSelector(selector *corev1.ClusterSelector) error {
+	if selector == nil {
+		return validation.Errorf("%s: selector", validation.ErrMissingRequiredField)
+	}
+	if err := validation.Validate(selector); err != nil {
+		return err
+	}
+	// an empty selector would otherwise match every cluster
+	if selector.GetLabelSelector().IsEmpty() && len(selector.GetClusterIDs()) == 0 {
+		return validation.Error("selector must match at least one cluster id or label")
+	}
+	return nil
+}
+
+func validateConcurrency(concurrency int32) error {
+	if concurrency < 0 {
+		return validation.Errorf("%s: concurrency cannot be negative", validation.ErrInvalidValue)
+	}
+	return nil
+}
+
+func (r *BulkCapabilityInstallRequest) Validate() error {
+	if err := validation.ValidateID(r.Name); err != nil {
+		return err
+	}
+	if err := validateBulkSelector(r.Selector); err != nil {
+		return err
+	}
+	return validateConcurrency(r.Concurrency)
+}
+
+func (r *BulkCapabilityUninstallRequest) Validate() error {
+	if err := validation.ValidateID(r.Name); err != nil {
+		return err
+	}
+	if err := validateBulkSelector(r.Selector); err != nil {
+		return err
+	}
+	return validateConcurrency(r.Concurrency)
+}
+
+func (r *BulkCapabilityStatusRequest) Validate() error {
+	if err := validation.ValidateID(r.Name); err != nil {
+		return err
+	}
+	return validateBulkSelector(r.Selector)
+}
@@ -96,6 +96,64 @@ var _ = Describe("Validation", Label("unit"), func() {
 			},
 		}, nil),
 	)
+	DescribeTable("RotateClusterKeyringRequest",
+		validateEntry[*v1.RotateClusterKeyringRequest],
+		Entry(nil, &v1.RotateClusterKeyringRequest{}, validation.ErrMissingRequiredField),
+		Entry(nil, &v1.RotateClusterKeyringRequest{
+			Cluster: &corev1.Reference{
+				Id: "\\",
+			},
+		}, validation.ErrInvalidID),
+		Entry(nil, &v1.RotateClusterKeyringRequest{
+			Cluster: &corev1.Reference{
+				Id: "foo",
+			},
+			GracePeriod: durationpb.New(-1),
+		}, validation.Error("gracePeriod cannot be negative")),
+		Entry(nil, &v1.RotateClusterKeyringRequest{
+			Cluster: &corev1.Reference{
+				Id: "foo",
+			},
+		}, nil),
+		Entry(nil, &v1.RotateClusterKeyringRequest{
+			Cluster: &corev1.Reference{
+				Id: "foo",
+			},
+			GracePeriod: durationpb.New(0),
+		}, nil),
+	)
+	DescribeTable("TaskReference",
+		validateEntry[*v1.TaskReference],
+		Entry(nil, &v1.TaskReference{}, validation.Error("missing required field: namespace")),
+		Entry(nil, &v1.TaskReference{
+			Namespace: "github.com/rancher/opni/plugins/metrics",
+			Id:        "foo",
+		}, validation.Error("missing required field: controller")),
+		Entry(nil, &v1.TaskReference{
+			Namespace:  "github.com/rancher/opni/plugins/metrics",
+			Controller: "uninstall",
+		}, validation.Error("missing required field: id")),
+		Entry(nil, &v1.TaskReference{
+			Namespace:  "github.com/rancher/opni/plugins/metrics",
+			Controller: "uninstall",
+			Id:         "foo/bar",
+		}, validation.Error(`invalid id: "foo/bar"`)),
+		Entry(nil, &v1.TaskReference{
+			Namespace:  "github.com/rancher/opni/plugins/metrics",
+			Controller: "uninstall",
+			Id:         "foo",
+		}, nil),
+	)
+	DescribeTable("PruneTasksRequest",
+		validateEntry[*v1.PruneTasksRequest],
+		Entry(nil, &v1.PruneTasksRequest{}, nil),
+		Entry(nil, &v1.PruneTasksRequest{
+			Retention: durationpb.New(0),
+		}, nil),
+		Entry(nil, &v1.PruneTasksRequest{
+			Retention: durationpb.New(-1),
+		}, validation.Error("retention cannot be negative")),
+	)
 	DescribeTable("WatchClustersRequest",
 		validateEntry[*v1.WatchClustersRequest],
 		Entry(nil, &v1.WatchClustersRequest{}, nil),
@@ -205,6 +205,9 @@ func (m *ClusterMiddleware) VerifyKeyring(authHeader string, expectedNonce uuid.
 		authorized := false
 		var sharedKeys *keyring.SharedKeys
 		if ok := kr.Try(func(shared *keyring.SharedKeys) {
+			if shared.Expired() {
+				return
+			}
 			if err := b2mac.Verify(mac, clusterID, expectedNonce, msgBody, shared.ClientKey); err == nil {
 				authorized = true
 				sharedKeys = shared
@@ -231,6 +234,15 @@ func (m *ClusterMiddleware) VerifyKeyring(authHeader string, expectedNonce uuid.
 }
 
 func NewClientStreamInterceptor(id string, sharedKeys *keyring.SharedKeys) grpc.StreamClientInterceptor {
+	return NewDynamicClientStreamInterceptor(id, func() *keyring.SharedKeys {
+		return sharedKeys
+	})
+}
+
+// NewDynamicClientStreamInterceptor is like NewClientStreamInterceptor, but
+// obtains the shared keys each time a new stream is opened, allowing the keys
+// to be replaced without re-dialing the server.
+func NewDynamicClientStreamInterceptor(id string, sharedKeys func() *keyring.SharedKeys) grpc.StreamClientInterceptor {
 	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
 		clientStream, err := streamer(ctx, desc, cc, method, opts...)
 		if err != nil {
@@ -248,7 +260,7 @@ func NewClientStreamInterceptor(id string, sharedKeys *keyring.SharedKeys) grpc.
 		if err != nil {
 			return nil, status.Errorf(codes.Internal, "server sent an invalid challenge header")
 		}
-		mac, err := b2mac.New512([]byte(id), nonce, []byte(method), sharedKeys.ClientKey)
+		mac, err := b2mac.New512([]byte(id), nonce, []byte(method), sharedKeys().ClientKey)
 		if err != nil {
 			return nil, err
 		}
@@ -67,3 +67,16 @@ func invalidAuthHeader[T, U string | []byte](id T, payload U) (uuid.UUID, string
 	}
 	return nonce, str
 }
+
+func newTestSharedKeys() *keyring.SharedKeys {
+	kp1 := ecdh.NewEphemeralKeyPair()
+	kp2 := ecdh.NewEphemeralKeyPair()
+	sec, err := ecdh.DeriveSharedSecret(kp1, ecdh.PeerPublicKey{
+		PublicKey: kp2.PublicKey,
+		PeerType:  ecdh.PeerTypeClient,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return keyring.NewSharedKeys(sec)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
@@ -14,6 +15,7 @@ import (
 	v1 "github.com/rancher/opni/pkg/apis/core/v1"
 	"github.com/rancher/opni/pkg/auth/cluster"
 	"github.com/rancher/opni/pkg/b2mac"
+	"github.com/rancher/opni/pkg/keyring"
 	"github.com/rancher/opni/pkg/storage"
 	"github.com/rancher/opni/pkg/test"
 	"github.com/rancher/opni/pkg/test/testgrpc"
@@ -230,4 +232,40 @@ var _ = Describe("Cluster Auth", Ordered, Label("unit"), func() {
 			Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
 		})
 	})
+	When("the keyring has been rotated", func() {
+		dialWithKeys := func(keys *keyring.SharedKeys) testgrpc.StreamServiceClient {
+			cc, err := grpc.Dial("bufconn", grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+				return listener.Dial()
+			}), grpc.WithInsecure(), grpc.WithStreamInterceptor(cluster.NewDynamicClientStreamInterceptor("foo", func() *keyring.SharedKeys {
+				return keys
+			})))
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(cc.Close)
+			return testgrpc.NewStreamServiceClient(cc)
+		}
+		It("should accept both the new and previous keys during the grace period", func() {
+			newKeys := newTestSharedKeys()
+			rotated := keyring.RotateSharedKeys(testKeyring, newKeys, 1*time.Hour)
+			Expect(broker.KeyringStore("gateway", &v1.Reference{Id: "foo"}).Put(context.Background(), rotated)).To(Succeed())
+
+			Expect(doPingPong(dialWithKeys(newKeys))).To(Succeed())
+			Expect(doPingPong(dialWithKeys(testSharedKeys))).To(Succeed())
+		})
+		It("should reject the previous keys once they have expired", func() {
+			newKeys := newTestSharedKeys()
+			expiresAt := time.Now().Add(-1 * time.Second)
+			expired := &keyring.SharedKeys{
+				ClientKey: testSharedKeys.ClientKey,
+				ServerKey: testSharedKeys.ServerKey,
+				ExpiresAt: &expiresAt,
+			}
+			Expect(broker.KeyringStore("gateway", &v1.Reference{Id: "foo"}).Put(context.Background(), keyring.New(newKeys, expired))).To(Succeed())
+
+			Expect(doPingPong(dialWithKeys(newKeys))).To(Succeed())
+			err := doPingPong(dialWithKeys(testSharedKeys))
+			Expect(status.Code(err)).To(Equal(codes.Unauthenticated))
+
+			Expect(broker.KeyringStore("gateway", &v1.Reference{Id: "foo"}).Put(context.Background(), testKeyring)).To(Succeed())
+		})
+	})
 })
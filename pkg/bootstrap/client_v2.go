@@ -29,6 +29,8 @@ type ClientConfigV2 struct {
 	K8sConfig     *rest.Config
 	K8sNamespace  string
 	TrustStrategy trust.Strategy
+	// Labels to request for the cluster. The bootstrap token must allow them.
+	Labels map[string]string
 }
 
 func (c *ClientConfigV2) Bootstrap(
@@ -77,6 +79,7 @@ func (c *ClientConfigV2) Bootstrap(
 	authReq := &bootstrapv2.BootstrapAuthRequest{
 		ClientID:     id,
 		ClientPubKey: ekp.PublicKey,
+		Labels:       c.Labels,
 	}
 
 	authResp, err := client.Auth(metadata.NewOutgoingContext(ctx, metadata.Pairs(
@@ -0,0 +1,57 @@
+package bootstrap
+
+import (
+	"context"
+	"errors"
+
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	"github.com/rancher/opni/pkg/storage"
+	"github.com/rancher/opni/pkg/util"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// checkTokenScope returns an error if the token may not be used by a cluster
+// with the given ID requesting the given labels.
+func checkTokenScope(token *corev1.BootstrapToken, clusterId string, labels map[string]string) error {
+	md := token.GetMetadata()
+	if md.UsageLimitReached() {
+		return status.Errorf(codes.ResourceExhausted, "token has reached its usage limit (%d)", md.UsageLimit())
+	}
+	if !md.AllowsClusterId(clusterId) {
+		return status.Errorf(codes.PermissionDenied, "cluster ID %q is not allowed by this token", clusterId)
+	}
+	keys := maps.Keys(labels)
+	slices.Sort(keys)
+	for _, key := range keys {
+		if !md.AllowsLabel(key, labels[key]) {
+			return status.Errorf(codes.PermissionDenied, "label %s=%s is not allowed by this token", key, labels[key])
+		}
+	}
+	return nil
+}
+
+// consumeToken uses up one use of the token, or deletes it if it is a one-shot
+// token. It is called before the cluster is created, so that a failure to
+// consume the token can't leave a token which outlived its uses. The caller
+// must hold the token's lock and pass the token as read under that lock.
+func consumeToken(ctx context.Context, st Storage, token *corev1.BootstrapToken) error {
+	if token.GetMetadata().GetOneShot() {
+		if err := st.DeleteToken(ctx, token.Reference()); err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				return util.StatusError(codes.PermissionDenied)
+			}
+			return status.Errorf(codes.Unavailable, "error revoking one-shot token: %v", err)
+		}
+		return nil
+	}
+	if _, err := st.UpdateToken(ctx, token.Reference(), storage.NewIncrementUsageCountMutator()); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return util.StatusError(codes.PermissionDenied)
+		}
+		return status.Errorf(codes.Unavailable, "error incrementing usage count: %v", err)
+	}
+	return nil
+}
@@ -34,6 +34,7 @@ type Server struct {
 	storage         Storage
 	capBackendStore capabilities.BackendStore
 	clusterIdLocks  util.LockMap[string, *sync.Mutex]
+	tokenIdLocks    util.LockMap[string, *sync.Mutex]
 }
 
 func NewServer(storage Storage, privateKey crypto.Signer, capBackendStore capabilities.BackendStore) *Server {
@@ -42,6 +43,7 @@ func NewServer(storage Storage, privateKey crypto.Signer, capBackendStore capabi
 		storage:         storage,
 		capBackendStore: capBackendStore,
 		clusterIdLocks:  util.NewLockMap[string, *sync.Mutex](),
+		tokenIdLocks:    util.NewLockMap[string, *sync.Mutex](),
 	}
 }
 
@@ -119,6 +121,24 @@ func (h *Server) Auth(ctx context.Context, authReq *bootstrapv1.BootstrapAuthReq
 	lock.Lock()
 	defer lock.Unlock()
 
+	// lock the token as well, so that its usage count is checked and
+	// incremented atomically
+	tokenLock := h.tokenIdLocks.Get(token.HexID())
+	tokenLock.Lock()
+	defer tokenLock.Unlock()
+
+	bootstrapToken, err = h.storage.GetToken(ctx, token.Reference())
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			// the token was revoked while waiting for the lock
+			return nil, util.StatusError(codes.PermissionDenied)
+		}
+		return nil, util.StatusError(codes.Unavailable)
+	}
+	if err := checkTokenScope(bootstrapToken, authReq.ClientID, nil); err != nil {
+		return nil, err
+	}
+
 	// If the cluster with the requested ID does not exist, it can be created
 	// normally. If it does exist, and the client advertises a capability that
 	// the cluster does not yet have, and the token has the capability to edit
@@ -167,8 +187,11 @@ func (h *Server) Auth(ctx context.Context, authReq *bootstrapv1.BootstrapAuthReq
 		return nil, status.Errorf(codes.Unavailable, "capability %q cannot be installed: %v", authReq.Capability, err)
 	}
 
+	if err := consumeToken(ctx, h.storage, bootstrapToken); err != nil {
+		return nil, err
+	}
 	if shouldEditExisting {
-		if err := h.handleEdit(ctx, existing, backendClient, bootstrapToken, kr); err != nil {
+		if err := h.handleEdit(ctx, existing, backendClient, kr); err != nil {
 			return nil, status.Errorf(codes.Internal, "error installing capability %q: %v", authReq.Capability, err)
 		}
 	} else {
@@ -205,17 +228,17 @@ func (h Server) handleCreate(
 	if err := h.storage.CreateCluster(ctx, newCluster); err != nil {
 		return fmt.Errorf("error creating cluster: %w", err)
 	}
-	_, err := h.storage.UpdateToken(ctx, token.Reference(),
-		storage.NewCompositeMutator(
-			storage.NewIncrementUsageCountMutator(),
+	// one-shot tokens have already been deleted
+	if !token.GetMetadata().GetOneShot() {
+		_, err := h.storage.UpdateToken(ctx, token.Reference(),
 			storage.NewAddCapabilityMutator[*corev1.BootstrapToken](&corev1.TokenCapability{
 				Type:      string(capabilities.JoinExistingCluster),
 				Reference: newCluster.Reference(),
 			}),
-		),
-	)
-	if err != nil {
-		return fmt.Errorf("error incrementing usage count: %w", err)
+		)
+		if err != nil {
+			return fmt.Errorf("error adding capability to token: %w", err)
+		}
 	}
 	krStore := h.storage.KeyringStore("gateway", newCluster.Reference())
 	if err := krStore.Put(ctx, kr); err != nil {
@@ -231,14 +254,8 @@ func (h Server) handleEdit(
 	ctx context.Context,
 	existingCluster *corev1.Reference,
 	newCapability capabilityv1.BackendClient,
-	token *corev1.BootstrapToken,
 	keyring keyring.Keyring,
 ) error {
-	_, err := h.storage.UpdateToken(ctx, token.Reference(),
-		storage.NewIncrementUsageCountMutator())
-	if err != nil {
-		return fmt.Errorf("error incrementing usage count: %w", err)
-	}
 	krStore := h.storage.KeyringStore("gateway", existingCluster)
 	kr, err := krStore.Get(ctx)
 	if err != nil {
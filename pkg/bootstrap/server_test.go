@@ -241,6 +241,41 @@ var _ = Describe("Server", Label("slow"), func() {
 					Expect(util.StatusCode(err)).To(Equal(codes.PermissionDenied))
 				})
 			})
+			When("the token is one-shot", func() {
+				It("should revoke the token and not allow it to be reused", func() {
+					oneShot, err := mockTokenStore.CreateToken(context.Background(), 1*time.Hour,
+						storage.WithOneShot(true),
+					)
+					Expect(err).NotTo(HaveOccurred())
+					rawToken, err := tokens.FromBootstrapToken(oneShot)
+					Expect(err).NotTo(HaveOccurred())
+					jsonData, err := json.Marshal(rawToken)
+					Expect(err).NotTo(HaveOccurred())
+					sig, err := jws.Sign(jsonData, jwa.EdDSA, cert.PrivateKey)
+					Expect(err).NotTo(HaveOccurred())
+					ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("Authorization", "Bearer "+string(sig)))
+
+					ekp := ecdh.NewEphemeralKeyPair()
+					_, err = client.Auth(ctx, &bootstrapv1.BootstrapAuthRequest{
+						ClientID:     "foo",
+						ClientPubKey: ekp.PublicKey,
+						Capability:   "test",
+					})
+					Expect(err).NotTo(HaveOccurred())
+
+					_, err = mockTokenStore.GetToken(context.Background(), oneShot.Reference())
+					Expect(err).To(MatchError(storage.ErrNotFound))
+
+					_, err = client.Auth(ctx, &bootstrapv1.BootstrapAuthRequest{
+						ClientID:     "bar",
+						ClientPubKey: ekp.PublicKey,
+						Capability:   "test",
+					})
+					Expect(util.StatusCode(err)).To(Equal(codes.PermissionDenied))
+					_, err = mockClusterStore.GetCluster(context.Background(), &corev1.Reference{Id: "bar"})
+					Expect(err).To(MatchError(storage.ErrNotFound))
+				})
+			})
 			When("joining with an additional capability", func() {
 				var ekp ecdh.EphemeralKeyPair
 				var newCtx func() context.Context
@@ -30,6 +30,7 @@ type ServerV2 struct {
 	privateKey     crypto.Signer
 	storage        Storage
 	clusterIdLocks util.LockMap[string, *sync.Mutex]
+	tokenIdLocks   util.LockMap[string, *sync.Mutex]
 }
 
 func NewServerV2(storage Storage, privateKey crypto.Signer) *ServerV2 {
@@ -37,6 +38,7 @@ func NewServerV2(storage Storage, privateKey crypto.Signer) *ServerV2 {
 		privateKey:     privateKey,
 		storage:        storage,
 		clusterIdLocks: util.NewLockMap[string, *sync.Mutex](),
+		tokenIdLocks:   util.NewLockMap[string, *sync.Mutex](),
 	}
 }
 
@@ -114,6 +116,24 @@ func (h *ServerV2) Auth(ctx context.Context, authReq *bootstrapv2.BootstrapAuthR
 	lock.Lock()
 	defer lock.Unlock()
 
+	// lock the token as well, so that its usage count is checked and
+	// incremented atomically
+	tokenLock := h.tokenIdLocks.Get(token.HexID())
+	tokenLock.Lock()
+	defer tokenLock.Unlock()
+
+	bootstrapToken, err = h.storage.GetToken(ctx, token.Reference())
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			// the token was revoked while waiting for the lock
+			return nil, util.StatusError(codes.PermissionDenied)
+		}
+		return nil, util.StatusError(codes.Unavailable)
+	}
+	if err := checkTokenScope(bootstrapToken, authReq.ClientID, authReq.Labels); err != nil {
+		return nil, err
+	}
+
 	existing := &corev1.Reference{
 		Id: authReq.ClientID,
 	}
@@ -136,27 +156,25 @@ func (h *ServerV2) Auth(ctx context.Context, authReq *bootstrapv2.BootstrapAuthR
 
 	tokenLabels := maps.Clone(bootstrapToken.GetMetadata().GetLabels())
 	delete(tokenLabels, corev1.NameLabel)
-	if tokenLabels == nil {
-		tokenLabels = map[string]string{}
+	clusterLabels := maps.Clone(authReq.Labels)
+	if clusterLabels == nil {
+		clusterLabels = map[string]string{}
 	}
-	tokenLabels[annotations.AgentVersion] = annotations.Version2
+	// labels set by the token take precedence over requested labels
+	maps.Copy(clusterLabels, tokenLabels)
+	clusterLabels[annotations.AgentVersion] = annotations.Version2
 	newCluster := &corev1.Cluster{
 		Id: authReq.ClientID,
 		Metadata: &corev1.ClusterMetadata{
-			Labels: tokenLabels,
+			Labels: clusterLabels,
 		},
 	}
+	if err := consumeToken(ctx, h.storage, bootstrapToken); err != nil {
+		return nil, err
+	}
 	if err := h.storage.CreateCluster(ctx, newCluster); err != nil {
 		return nil, status.Error(codes.Internal, fmt.Sprintf("error creating cluster: %v", err))
 	}
-	_, err = h.storage.UpdateToken(ctx, token.Reference(),
-		storage.NewCompositeMutator(
-			storage.NewIncrementUsageCountMutator(),
-		),
-	)
-	if err != nil {
-		return nil, status.Error(codes.Internal, fmt.Sprintf("error incrementing usage count: %v", err))
-	}
 	krStore := h.storage.KeyringStore("gateway", newCluster.Reference())
 	if err := krStore.Put(ctx, kr); err != nil {
 		return nil, status.Error(codes.Internal, fmt.Sprintf("error storing keyring: %s", err))
@@ -165,6 +165,99 @@ var _ = Describe("Server V2", func() {
 					})
 				})
 			})
+			When("the token has a limited scope", func() {
+				authContext := func(t *corev1.BootstrapToken) context.Context {
+					rawToken, err := tokens.FromBootstrapToken(t)
+					Expect(err).NotTo(HaveOccurred())
+					jsonData, err := json.Marshal(rawToken)
+					Expect(err).NotTo(HaveOccurred())
+					sig, err := jws.Sign(jsonData, jwa.EdDSA, cert.PrivateKey)
+					Expect(err).NotTo(HaveOccurred())
+					return metadata.NewOutgoingContext(context.Background(), metadata.Pairs("Authorization", "Bearer "+string(sig)))
+				}
+				authRequest := func(id string, labels map[string]string) *bootstrapv2.BootstrapAuthRequest {
+					return &bootstrapv2.BootstrapAuthRequest{
+						ClientID:     id,
+						ClientPubKey: ecdh.NewEphemeralKeyPair().PublicKey,
+						Labels:       labels,
+					}
+				}
+				It("should reject clusters once the usage limit is reached", func() {
+					limited, err := mockTokenStore.CreateToken(context.Background(), 1*time.Hour,
+						storage.WithMaxUsages(2),
+					)
+					Expect(err).NotTo(HaveOccurred())
+					ctx := authContext(limited)
+
+					_, err = client.Auth(ctx, authRequest("foo", nil))
+					Expect(err).NotTo(HaveOccurred())
+					_, err = client.Auth(ctx, authRequest("bar", nil))
+					Expect(err).NotTo(HaveOccurred())
+					_, err = client.Auth(ctx, authRequest("baz", nil))
+					Expect(util.StatusCode(err)).To(Equal(codes.ResourceExhausted))
+					Expect(err.Error()).To(ContainSubstring("usage limit (2)"))
+
+					_, err = mockClusterStore.GetCluster(context.Background(), &corev1.Reference{Id: "baz"})
+					Expect(err).To(MatchError(storage.ErrNotFound))
+				})
+				It("should revoke one-shot tokens after they are used", func() {
+					oneShot, err := mockTokenStore.CreateToken(context.Background(), 1*time.Hour,
+						storage.WithOneShot(true),
+					)
+					Expect(err).NotTo(HaveOccurred())
+					ctx := authContext(oneShot)
+
+					_, err = client.Auth(ctx, authRequest("foo", nil))
+					Expect(err).NotTo(HaveOccurred())
+
+					_, err = mockTokenStore.GetToken(context.Background(), oneShot.Reference())
+					Expect(err).To(MatchError(storage.ErrNotFound))
+
+					_, err = client.Auth(ctx, authRequest("bar", nil))
+					Expect(util.StatusCode(err)).To(Equal(codes.PermissionDenied))
+				})
+				It("should only allow matching cluster IDs", func() {
+					scoped, err := mockTokenStore.CreateToken(context.Background(), 1*time.Hour,
+						storage.WithAllowedClusterIds([]string{"prod-*", "staging"}),
+					)
+					Expect(err).NotTo(HaveOccurred())
+					ctx := authContext(scoped)
+
+					_, err = client.Auth(ctx, authRequest("dev-1", nil))
+					Expect(util.StatusCode(err)).To(Equal(codes.PermissionDenied))
+					Expect(err.Error()).To(ContainSubstring(`cluster ID "dev-1" is not allowed`))
+
+					_, err = client.Auth(ctx, authRequest("prod-1", nil))
+					Expect(err).NotTo(HaveOccurred())
+					_, err = client.Auth(ctx, authRequest("staging", nil))
+					Expect(err).NotTo(HaveOccurred())
+				})
+				It("should only allow requested labels matching the allowed labels", func() {
+					scoped, err := mockTokenStore.CreateToken(context.Background(), 1*time.Hour,
+						storage.WithLabels(map[string]string{"team": "a"}),
+						storage.WithAllowedLabels([]string{"env=prod-*", "team=*"}),
+					)
+					Expect(err).NotTo(HaveOccurred())
+					ctx := authContext(scoped)
+
+					_, err = client.Auth(ctx, authRequest("foo", map[string]string{"env": "dev"}))
+					Expect(util.StatusCode(err)).To(Equal(codes.PermissionDenied))
+					Expect(err.Error()).To(ContainSubstring("label env=dev is not allowed"))
+
+					_, err = client.Auth(ctx, authRequest("foo", map[string]string{"env": "prod-1", "team": "b"}))
+					Expect(err).NotTo(HaveOccurred())
+
+					cluster, err := mockClusterStore.GetCluster(context.Background(), &corev1.Reference{Id: "foo"})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(cluster.GetLabels()).To(HaveKeyWithValue("env", "prod-1"))
+					By("checking that the token's labels take precedence")
+					Expect(cluster.GetLabels()).To(HaveKeyWithValue("team", "a"))
+				})
+				It("should not allow requested labels if the token allows none", func() {
+					_, err := client.Auth(authContext(token), authRequest("foo", map[string]string{"env": "prod"}))
+					Expect(util.StatusCode(err)).To(Equal(codes.PermissionDenied))
+				})
+			})
 			When("the token is invalid", func() {
 				It("should return http 401", func() {
 					ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("Authorization", "Bearer invalid"))
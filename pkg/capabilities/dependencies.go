@@ -0,0 +1,189 @@
+package capabilities
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	capabilityv1 "github.com/rancher/opni/pkg/apis/capability/v1"
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	"github.com/samber/lo"
+)
+
+var (
+	ErrMissingDependencies = errors.New("capability has dependencies which are not installed")
+	ErrInstalledDependents = errors.New("capability has dependents which are still installed")
+	ErrConflict            = errors.New("capability conflicts with another capability")
+	ErrDependencyCycle     = errors.New("capability dependencies contain a cycle")
+)
+
+// A Step is a single capability install or uninstall in a plan.
+type Step struct {
+	Name string
+	// Human-readable explanation of why the step is part of the plan
+	Reason string
+}
+
+// DependencyGraph describes the dependencies and conflicts between
+// capabilities, as declared by their backends.
+type DependencyGraph struct {
+	details map[string]*capabilityv1.Details
+}
+
+func NewDependencyGraph(details ...*capabilityv1.Details) *DependencyGraph {
+	g := &DependencyGraph{
+		details: make(map[string]*capabilityv1.Details, len(details)),
+	}
+	for _, d := range details {
+		g.details[d.GetName()] = d
+	}
+	return g
+}
+
+// InstalledCapabilities returns the names of the capabilities installed on
+// the cluster, excluding capabilities which are being uninstalled.
+func InstalledCapabilities(cluster *corev1.Cluster) []string {
+	var names []string
+	for _, c := range cluster.GetCapabilities() {
+		if c.GetDeletionTimestamp() == nil {
+			names = append(names, c.GetName())
+		}
+	}
+	return names
+}
+
+// PlanInstall returns the capabilities which must be installed, in order, to
+// install the named capability on a cluster which already has the given
+// capabilities installed. The named capability is always the last step.
+//
+// If the capability has dependencies which are not installed, they are only
+// included in the plan if cascade is true; otherwise ErrMissingDependencies
+// is returned. An error is also returned if any capability in the plan
+// conflicts with an installed capability, or with another capability in the
+// plan.
+func (g *DependencyGraph) PlanInstall(name string, installed []string, cascade bool) ([]Step, error) {
+	isInstalled := lo.SliceToMap(installed, func(n string) (string, bool) {
+		return n, true
+	})
+	var steps []Step
+	visiting := map[string]bool{}
+	visited := map[string]bool{}
+	var visit func(n, reason string) error
+	visit = func(n, reason string) error {
+		if visiting[n] {
+			return fmt.Errorf("%w: %s", ErrDependencyCycle, n)
+		}
+		if visited[n] {
+			return nil
+		}
+		d, ok := g.details[n]
+		if !ok {
+			return fmt.Errorf("%w: %s (%s)", ErrBackendNotFound, n, reason)
+		}
+		visiting[n] = true
+		for _, dep := range d.GetDependencies() {
+			if isInstalled[dep] {
+				continue
+			}
+			if err := visit(dep, fmt.Sprintf("required by %s", n)); err != nil {
+				return err
+			}
+		}
+		visiting[n] = false
+		visited[n] = true
+		steps = append(steps, Step{
+			Name:   n,
+			Reason: reason,
+		})
+		return nil
+	}
+	if err := visit(name, "requested"); err != nil {
+		return nil, err
+	}
+
+	if len(steps) > 1 && !cascade {
+		missing := lo.Map(steps[:len(steps)-1], func(s Step, _ int) string {
+			return s.Name
+		})
+		return nil, fmt.Errorf("%w: %s requires %s", ErrMissingDependencies, name, strings.Join(missing, ", "))
+	}
+
+	// every capability which will be installed once the plan is complete
+	result := append(append([]string{}, installed...), lo.Map(steps, func(s Step, _ int) string {
+		return s.Name
+	})...)
+	for _, step := range steps {
+		for _, other := range result {
+			if other == step.Name {
+				continue
+			}
+			if g.conflicts(step.Name, other) {
+				return nil, fmt.Errorf("%w: %s conflicts with %s", ErrConflict, step.Name, other)
+			}
+		}
+	}
+	return steps, nil
+}
+
+// PlanUninstall returns the capabilities which must be uninstalled, in order,
+// to uninstall the named capability from a cluster which has the given
+// capabilities installed. The named capability is always the last step.
+//
+// If any installed capabilities depend on the named capability, they are only
+// included in the plan if cascade is true; otherwise ErrInstalledDependents
+// is returned. Installed capabilities whose backends are not known are
+// ignored.
+func (g *DependencyGraph) PlanUninstall(name string, installed []string, cascade bool) ([]Step, error) {
+	installed = append([]string{}, installed...)
+	sort.Strings(installed)
+
+	var steps []Step
+	visiting := map[string]bool{}
+	visited := map[string]bool{}
+	var visit func(n, reason string) error
+	visit = func(n, reason string) error {
+		if visiting[n] {
+			return fmt.Errorf("%w: %s", ErrDependencyCycle, n)
+		}
+		if visited[n] {
+			return nil
+		}
+		visiting[n] = true
+		for _, other := range installed {
+			if other == n {
+				continue
+			}
+			if lo.Contains(g.details[other].GetDependencies(), n) {
+				if err := visit(other, fmt.Sprintf("depends on %s", n)); err != nil {
+					return err
+				}
+			}
+		}
+		visiting[n] = false
+		visited[n] = true
+		steps = append(steps, Step{
+			Name:   n,
+			Reason: reason,
+		})
+		return nil
+	}
+	if err := visit(name, "requested"); err != nil {
+		return nil, err
+	}
+
+	if len(steps) > 1 && !cascade {
+		dependents := lo.Map(steps[:len(steps)-1], func(s Step, _ int) string {
+			return s.Name
+		})
+		return nil, fmt.Errorf("%w: %s is required by %s", ErrInstalledDependents, name, strings.Join(dependents, ", "))
+	}
+	return steps, nil
+}
+
+// conflicts reports whether either capability declares a conflict with the
+// other.
+func (g *DependencyGraph) conflicts(a, b string) bool {
+	return lo.Contains(g.details[a].GetConflicts(), b) ||
+		lo.Contains(g.details[b].GetConflicts(), a)
+}
@@ -0,0 +1,105 @@
+package capabilities_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	capabilityv1 "github.com/rancher/opni/pkg/apis/capability/v1"
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	"github.com/rancher/opni/pkg/capabilities"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func stepNames(steps []capabilities.Step) []string {
+	names := make([]string, len(steps))
+	for i, step := range steps {
+		names[i] = step.Name
+	}
+	return names
+}
+
+var _ = Describe("Dependency Graph", Label("unit"), func() {
+	var graph *capabilities.DependencyGraph
+	BeforeEach(func() {
+		// metrics <- slo <- reports
+		// logs <-> legacy-logs (conflict)
+		graph = capabilities.NewDependencyGraph(
+			&capabilityv1.Details{Name: "metrics"},
+			&capabilityv1.Details{Name: "slo", Dependencies: []string{"metrics"}},
+			&capabilityv1.Details{Name: "reports", Dependencies: []string{"slo", "metrics"}},
+			&capabilityv1.Details{Name: "logs"},
+			&capabilityv1.Details{Name: "legacy-logs", Conflicts: []string{"logs"}},
+			&capabilityv1.Details{Name: "broken", Dependencies: []string{"missing"}},
+			&capabilityv1.Details{Name: "cycle-a", Dependencies: []string{"cycle-b"}},
+			&capabilityv1.Details{Name: "cycle-b", Dependencies: []string{"cycle-a"}},
+		)
+	})
+	Context("install", func() {
+		It("should plan a capability with no dependencies", func() {
+			steps, err := graph.PlanInstall("metrics", nil, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(steps).To(Equal([]capabilities.Step{{Name: "metrics", Reason: "requested"}}))
+		})
+		It("should skip dependencies which are already installed", func() {
+			steps, err := graph.PlanInstall("reports", []string{"metrics", "slo"}, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stepNames(steps)).To(Equal([]string{"reports"}))
+		})
+		It("should require cascade to install missing dependencies", func() {
+			_, err := graph.PlanInstall("reports", nil, false)
+			Expect(err).To(MatchError(capabilities.ErrMissingDependencies))
+			Expect(err.Error()).To(ContainSubstring("metrics, slo"))
+		})
+		It("should install dependencies in order when cascading", func() {
+			steps, err := graph.PlanInstall("reports", nil, true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stepNames(steps)).To(Equal([]string{"metrics", "slo", "reports"}))
+			Expect(steps[0].Reason).To(Equal("required by slo"))
+			Expect(steps[1].Reason).To(Equal("required by reports"))
+		})
+		It("should reject conflicting capabilities in either direction", func() {
+			_, err := graph.PlanInstall("legacy-logs", []string{"logs"}, false)
+			Expect(err).To(MatchError(capabilities.ErrConflict))
+			_, err = graph.PlanInstall("logs", []string{"legacy-logs"}, false)
+			Expect(err).To(MatchError(capabilities.ErrConflict))
+		})
+		It("should reject unknown dependencies", func() {
+			_, err := graph.PlanInstall("broken", nil, true)
+			Expect(err).To(MatchError(capabilities.ErrBackendNotFound))
+			_, err = graph.PlanInstall("unknown", nil, true)
+			Expect(err).To(MatchError(capabilities.ErrBackendNotFound))
+		})
+		It("should detect dependency cycles", func() {
+			_, err := graph.PlanInstall("cycle-a", nil, true)
+			Expect(err).To(MatchError(capabilities.ErrDependencyCycle))
+		})
+	})
+	Context("uninstall", func() {
+		It("should plan a capability with no installed dependents", func() {
+			steps, err := graph.PlanUninstall("metrics", []string{"metrics", "logs"}, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stepNames(steps)).To(Equal([]string{"metrics"}))
+		})
+		It("should require cascade to uninstall installed dependents", func() {
+			_, err := graph.PlanUninstall("metrics", []string{"metrics", "slo"}, false)
+			Expect(err).To(MatchError(capabilities.ErrInstalledDependents))
+			Expect(err.Error()).To(ContainSubstring("required by slo"))
+		})
+		It("should uninstall dependents in reverse order when cascading", func() {
+			steps, err := graph.PlanUninstall("metrics", []string{"reports", "slo", "metrics", "logs", "unknown"}, true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stepNames(steps)).To(Equal([]string{"reports", "slo", "metrics"}))
+			Expect(steps[1].Reason).To(Equal("depends on metrics"))
+		})
+	})
+	It("should ignore capabilities which are being uninstalled", func() {
+		cluster := &corev1.Cluster{
+			Metadata: &corev1.ClusterMetadata{
+				Capabilities: []*corev1.ClusterCapability{
+					{Name: "metrics"},
+					{Name: "slo", DeletionTimestamp: timestamppb.Now()},
+				},
+			},
+		}
+		Expect(capabilities.InstalledCapabilities(cluster)).To(Equal([]string{"metrics"}))
+	})
+})
@@ -0,0 +1,180 @@
+package capabilities
+
+import (
+	"context"
+
+	capabilityv1 "github.com/rancher/opni/pkg/apis/capability/v1"
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	"github.com/rancher/opni/pkg/storage"
+	"github.com/rancher/opni/pkg/util/future"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// GatewayBackend is a capability backend for capabilities which only exist in
+// the gateway, such as the per-cluster features of gateway plugins which rely
+// on other capabilities being installed on the cluster. Installing or
+// uninstalling the capability only updates the cluster's capabilities, so
+// uninstalling completes immediately.
+type GatewayBackend struct {
+	capabilityv1.UnsafeBackendServer
+	GatewayBackendOptions
+	details      *capabilityv1.Details
+	clusterStore future.Future[storage.ClusterStore]
+}
+
+type GatewayBackendOptions struct {
+	canUninstall func(ctx context.Context, cluster *corev1.Reference) error
+}
+
+type GatewayBackendOption func(*GatewayBackendOptions)
+
+func (o *GatewayBackendOptions) apply(opts ...GatewayBackendOption) {
+	for _, op := range opts {
+		op(o)
+	}
+}
+
+// WithUninstallCheck sets a function which is called before the capability is
+// uninstalled from a cluster. If it returns an error, the capability is not
+// uninstalled. It can be used to prevent uninstalling the capability while
+// the cluster still has objects which depend on it.
+func WithUninstallCheck(fn func(ctx context.Context, cluster *corev1.Reference) error) GatewayBackendOption {
+	return func(o *GatewayBackendOptions) {
+		o.canUninstall = fn
+	}
+}
+
+func NewGatewayBackend(
+	details *capabilityv1.Details,
+	clusterStore future.Future[storage.ClusterStore],
+	opts ...GatewayBackendOption,
+) *GatewayBackend {
+	options := GatewayBackendOptions{}
+	options.apply(opts...)
+	return &GatewayBackend{
+		GatewayBackendOptions: options,
+		details:               details,
+		clusterStore:          clusterStore,
+	}
+}
+
+var _ capabilityv1.BackendServer = (*GatewayBackend)(nil)
+
+// EnsureInstalled adds the capability to the cluster if it is not already
+// installed. Plugins call it when creating objects which depend on the
+// capability, so that the capability's dependencies can't be uninstalled
+// while the objects exist.
+func (b *GatewayBackend) EnsureInstalled(ctx context.Context, cluster *corev1.Reference) error {
+	store, err := b.clusterStore.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	c, err := store.GetCluster(ctx, cluster)
+	if err != nil {
+		return err
+	}
+	if Has(c, Cluster(b.details.GetName())) {
+		return nil
+	}
+	_, err = store.UpdateCluster(ctx, cluster,
+		storage.NewAddCapabilityMutator[*corev1.Cluster](Cluster(b.details.GetName())),
+	)
+	return err
+}
+
+func (b *GatewayBackend) Info(context.Context, *emptypb.Empty) (*capabilityv1.Details, error) {
+	return proto.Clone(b.details).(*capabilityv1.Details), nil
+}
+
+func (b *GatewayBackend) CanInstall(context.Context, *emptypb.Empty) (*emptypb.Empty, error) {
+	return &emptypb.Empty{}, nil
+}
+
+func (b *GatewayBackend) Install(ctx context.Context, req *capabilityv1.InstallRequest) (*capabilityv1.InstallResponse, error) {
+	store, err := b.clusterStore.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_, err = store.UpdateCluster(ctx, req.Cluster,
+		storage.NewAddCapabilityMutator[*corev1.Cluster](Cluster(b.details.GetName())),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &capabilityv1.InstallResponse{
+		Status: capabilityv1.InstallResponseStatus_Success,
+	}, nil
+}
+
+func (b *GatewayBackend) Status(ctx context.Context, req *capabilityv1.StatusRequest) (*capabilityv1.NodeCapabilityStatus, error) {
+	store, err := b.clusterStore.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c, err := store.GetCluster(ctx, req.Cluster)
+	if err != nil {
+		return nil, err
+	}
+	if !Has(c, Cluster(b.details.GetName())) {
+		return nil, status.Error(codes.NotFound, "capability is not installed on this cluster")
+	}
+	return &capabilityv1.NodeCapabilityStatus{
+		Enabled:  true,
+		LastSync: timestamppb.Now(),
+	}, nil
+}
+
+func (b *GatewayBackend) Uninstall(ctx context.Context, req *capabilityv1.UninstallRequest) (*emptypb.Empty, error) {
+	store, err := b.clusterStore.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c, err := store.GetCluster(ctx, req.Cluster)
+	if err != nil {
+		return nil, err
+	}
+	if !Has(c, Cluster(b.details.GetName())) {
+		return nil, status.Error(codes.FailedPrecondition, "cluster does not have the requested capability")
+	}
+	if b.canUninstall != nil {
+		if err := b.canUninstall(ctx, req.Cluster); err != nil {
+			return nil, err
+		}
+	}
+	_, err = store.UpdateCluster(ctx, req.Cluster,
+		storage.NewRemoveCapabilityMutator[*corev1.Cluster](Cluster(b.details.GetName())),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (b *GatewayBackend) UninstallStatus(ctx context.Context, cluster *corev1.Reference) (*corev1.TaskStatus, error) {
+	store, err := b.clusterStore.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c, err := store.GetCluster(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	if Has(c, Cluster(b.details.GetName())) {
+		return nil, status.Error(codes.NotFound, "capability has not been uninstalled from this cluster")
+	}
+	return &corev1.TaskStatus{
+		State: corev1.TaskState_Completed,
+	}, nil
+}
+
+func (b *GatewayBackend) CancelUninstall(context.Context, *corev1.Reference) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.FailedPrecondition, "uninstall has already completed")
+}
+
+func (b *GatewayBackend) InstallerTemplate(context.Context, *emptypb.Empty) (*capabilityv1.InstallerTemplateResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "capability has no agent-side installer")
+}
@@ -0,0 +1,94 @@
+package capabilities_test
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	capabilityv1 "github.com/rancher/opni/pkg/apis/capability/v1"
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	"github.com/rancher/opni/pkg/capabilities"
+	"github.com/rancher/opni/pkg/storage"
+	"github.com/rancher/opni/pkg/test"
+	"github.com/rancher/opni/pkg/util"
+	"github.com/rancher/opni/pkg/util/future"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+var _ = Describe("Gateway Backend", Label("unit"), func() {
+	var clusterStore storage.ClusterStore
+	var backend *capabilities.GatewayBackend
+	var uninstallErr error
+	ref := &corev1.Reference{Id: "foo"}
+
+	BeforeEach(func() {
+		clusterStore = test.NewTestClusterStore(ctrl)
+		Expect(clusterStore.CreateCluster(context.Background(), &corev1.Cluster{
+			Id:       ref.Id,
+			Metadata: &corev1.ClusterMetadata{},
+		})).To(Succeed())
+		uninstallErr = nil
+		backend = capabilities.NewGatewayBackend(&capabilityv1.Details{
+			Name:         "gateway",
+			Source:       "test",
+			Dependencies: []string{"metrics"},
+		}, future.Instant(clusterStore), capabilities.WithUninstallCheck(func(context.Context, *corev1.Reference) error {
+			return uninstallErr
+		}))
+	})
+
+	isInstalled := func() bool {
+		c, err := clusterStore.GetCluster(context.Background(), ref)
+		Expect(err).NotTo(HaveOccurred())
+		return capabilities.Has(c, capabilities.Cluster("gateway"))
+	}
+
+	It("should declare its details", func() {
+		info, err := backend.Info(context.Background(), &emptypb.Empty{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info.GetName()).To(Equal("gateway"))
+		Expect(info.GetDependencies()).To(ConsistOf("metrics"))
+	})
+	It("should install and uninstall the capability", func() {
+		resp, err := backend.Install(context.Background(), &capabilityv1.InstallRequest{Cluster: ref})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.GetStatus()).To(Equal(capabilityv1.InstallResponseStatus_Success))
+		Expect(isInstalled()).To(BeTrue())
+
+		stat, err := backend.Status(context.Background(), &capabilityv1.StatusRequest{Cluster: ref})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stat.GetEnabled()).To(BeTrue())
+
+		_, err = backend.Uninstall(context.Background(), &capabilityv1.UninstallRequest{Cluster: ref})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(isInstalled()).To(BeFalse())
+
+		task, err := backend.UninstallStatus(context.Background(), ref)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(task.GetState()).To(Equal(corev1.TaskState_Completed))
+	})
+	It("should install the capability on demand", func() {
+		Expect(backend.EnsureInstalled(context.Background(), ref)).To(Succeed())
+		Expect(isInstalled()).To(BeTrue())
+		Expect(backend.EnsureInstalled(context.Background(), ref)).To(Succeed())
+		c, err := clusterStore.GetCluster(context.Background(), ref)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.GetCapabilities()).To(HaveLen(1))
+	})
+	It("should not uninstall the capability if the uninstall check fails", func() {
+		Expect(backend.EnsureInstalled(context.Background(), ref)).To(Succeed())
+		uninstallErr = errors.New("in use")
+		_, err := backend.Uninstall(context.Background(), &capabilityv1.UninstallRequest{Cluster: ref})
+		Expect(err).To(MatchError("in use"))
+		Expect(isInstalled()).To(BeTrue())
+
+		_, err = backend.UninstallStatus(context.Background(), ref)
+		Expect(util.StatusCode(err)).To(Equal(codes.NotFound))
+	})
+	It("should not uninstall the capability if it is not installed", func() {
+		_, err := backend.Uninstall(context.Background(), &capabilityv1.UninstallRequest{Cluster: ref})
+		Expect(util.StatusCode(err)).To(Equal(codes.FailedPrecondition))
+	})
+})
@@ -20,6 +20,8 @@ type BackendStore interface {
 	Get(name string) (capabilityv1.BackendClient, error)
 	// Add a capability backend with the given name
 	Add(name string, backend capabilityv1.BackendClient) error
+	// Remove the capability backend with the given name
+	Remove(name string) error
 	// Returns all capability names known to the store
 	List() []string
 	// Render the installer command template for the given capability
@@ -62,6 +64,16 @@ func (s *backendStore) Add(name string, backend capabilityv1.BackendClient) erro
 	return nil
 }
 
+func (s *backendStore) Remove(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.backends[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrBackendNotFound, name)
+	}
+	delete(s.backends, name)
+	return nil
+}
+
 func (s *backendStore) List() []string {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -5,4 +5,7 @@ const (
 	CapabilityMetrics  = "metrics"
 	CapabilityTraces   = "traces"
 	CapabilityTopology = "topology"
+	// Gateway-only capabilities, which have no agent-side component
+	CapabilitySLO      = "slo"
+	CapabilityAlerting = "alerting"
 )
@@ -34,6 +34,13 @@ type GatewayClient interface {
 	Connect(context.Context) (grpc.ClientConnInterface, future.Future[error])
 	RegisterSplicedStream(cc grpc.ClientConnInterface, name string)
 	ClientConn() grpc.ClientConnInterface
+	// UseKeyring replaces the keys used to authenticate new streams with the
+	// shared keys in the given keyring.
+	UseKeyring(keyring.Keyring) error
+	// FallBackToPreviousKeys switches to the next previous shared keys in the
+	// keyring which have not yet expired, for use when the gateway rejects
+	// the current keys. It returns false if there are no keys left to try.
+	FallBackToPreviousKeys() bool
 }
 
 func NewGatewayClient(
@@ -47,19 +54,12 @@ func NewGatewayClient(
 	if err != nil {
 		return nil, err
 	}
-	var sharedKeys *keyring.SharedKeys
-	kr.Try(func(sk *keyring.SharedKeys) {
-		if sharedKeys != nil {
-			err = errors.New("keyring contains multiple shared key sets")
-			return
-		}
-		sharedKeys = sk
-	})
-	if err != nil {
-		return nil, err
+	client := &gatewayClient{
+		id:     id,
+		logger: logger.New().Named("gateway-client"),
 	}
-	if sharedKeys == nil {
-		return nil, errors.New("keyring is missing shared keys")
+	if err := client.UseKeyring(kr); err != nil {
+		return nil, err
 	}
 
 	tlsConfig, err := trustStrategy.TLSConfig()
@@ -67,22 +67,17 @@ func NewGatewayClient(
 		return nil, fmt.Errorf("failed to create TLS config: %w", err)
 	}
 
-	cc, err := dial(ctx, address, id, sharedKeys, tlsConfig)
+	cc, err := dial(ctx, address, id, client.sharedKeys, tlsConfig)
 	if err != nil {
 		return nil, err
 	}
+	client.cc = cc
 
 	go func() {
 		<-ctx.Done()
 		cc.Close()
 	}()
 
-	client := &gatewayClient{
-		cc:     cc,
-		id:     id,
-		logger: logger.New().Named("gateway-client"),
-	}
-
 	return client, nil
 }
 
@@ -99,6 +94,43 @@ type gatewayClient struct {
 	mu       sync.RWMutex
 	services []util.ServicePack[any]
 	spliced  []*splicedConn
+
+	keysMu   sync.Mutex
+	keys     []*keyring.SharedKeys
+	keyIndex int
+}
+
+func (gc *gatewayClient) UseKeyring(kr keyring.Keyring) error {
+	keys := keyring.ActiveSharedKeys(kr)
+	if len(keys) == 0 {
+		return errors.New("keyring is missing shared keys")
+	}
+	if len(keys) > 1 && keys[1].ExpiresAt == nil {
+		return errors.New("keyring contains multiple shared key sets")
+	}
+	gc.keysMu.Lock()
+	defer gc.keysMu.Unlock()
+	gc.keys = keys
+	gc.keyIndex = 0
+	return nil
+}
+
+func (gc *gatewayClient) FallBackToPreviousKeys() bool {
+	gc.keysMu.Lock()
+	defer gc.keysMu.Unlock()
+	for gc.keyIndex+1 < len(gc.keys) {
+		gc.keyIndex++
+		if !gc.keys[gc.keyIndex].Expired() {
+			return true
+		}
+	}
+	return false
+}
+
+func (gc *gatewayClient) sharedKeys() *keyring.SharedKeys {
+	gc.keysMu.Lock()
+	defer gc.keysMu.Unlock()
+	return gc.keys[gc.keyIndex]
 }
 
 func (gc *gatewayClient) RegisterService(desc *grpc.ServiceDesc, impl any) {
@@ -121,10 +153,10 @@ func (gc *gatewayClient) RegisterSplicedStream(cc grpc.ClientConnInterface, name
 	})
 }
 
-func dial(ctx context.Context, address, id string, sharedKeys *keyring.SharedKeys, tlsConfig *tls.Config) (*grpc.ClientConn, error) {
+func dial(ctx context.Context, address, id string, sharedKeys func() *keyring.SharedKeys, tlsConfig *tls.Config) (*grpc.ClientConn, error) {
 	return grpc.DialContext(ctx, address,
 		grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)),
-		grpc.WithChainStreamInterceptor(otelgrpc.StreamClientInterceptor(), cluster.NewClientStreamInterceptor(id, sharedKeys)),
+		grpc.WithChainStreamInterceptor(otelgrpc.StreamClientInterceptor(), cluster.NewDynamicClientStreamInterceptor(id, sharedKeys)),
 		grpc.WithChainUnaryInterceptor(otelgrpc.UnaryClientInterceptor()),
 		grpc.WithDefaultCallOptions(
 			grpc.WaitForReady(true),
@@ -53,6 +53,9 @@ type BootstrapSpec struct {
 	// List of paths to CA Certs. Used when the trust strategy is "cacerts".
 	// If empty, the system certs will be used.
 	CACerts []string `json:"caCerts,omitempty"`
+	// Labels to request for the cluster when bootstrapping. They must be
+	// allowed by the bootstrap token.
+	Labels map[string]string `json:"labels,omitempty"`
 }
 
 func (s *AgentConfigSpec) ContainsBootstrapCredentials() bool {
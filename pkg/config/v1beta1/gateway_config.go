@@ -28,6 +28,7 @@ type GatewayConfigSpec struct {
 	Certs          CertsSpec      `json:"certs,omitempty"`
 	Plugins        PluginsSpec    `json:"plugins,omitempty"`
 	Alerting       AlertingSpec   `json:"alerting,omitempty"`
+	Topology       TopologySpec   `json:"topology,omitempty"`
 	Profiling      ProfilingSpec  `json:"profiling,omitempty"`
 }
 
@@ -44,6 +45,12 @@ type AlertingSpec struct {
 	ManagementHookHandler string `json:"managementHookHandler,omitempty"`
 }
 
+type TopologySpec struct {
+	// How long versioned snapshots of each cluster's topology are kept
+	// (e.g. "24h"). Defaults to 24 hours.
+	SnapshotRetention string `json:"snapshotRetention,omitempty"`
+}
+
 type MetricsSpec struct {
 	//+kubebuilder:default="/metrics"
 	Path string `json:"path,omitempty"`
@@ -75,6 +82,26 @@ type ManagementSpec struct {
 	HTTPListenAddress string `json:"httpListenAddress,omitempty"`
 	//+kubebuilder:default="0.0.0.0:12080"
 	WebListenAddress string `json:"webListenAddress,omitempty"`
+	// If set, clients of the management API are authenticated with TLS client
+	// certificates, and role-based access control is enforced for their
+	// requests. Otherwise, every client has full access.
+	ClientAuth *ManagementClientAuthSpec `json:"clientAuth,omitempty"`
+	// How long tasks are kept after they are done before they are pruned
+	// (e.g. "168h"). Defaults to 7 days. Set to "0" to disable pruning.
+	TaskRetention string `json:"taskRetention,omitempty"`
+}
+
+type ManagementClientAuthSpec struct {
+	// Path to a PEM encoded CA certificate used to verify client certificates.
+	// The common name of a client certificate is the subject of its requests.
+	ClientCA string `json:"clientCA,omitempty"`
+	// Common names of the client certificates of authenticating proxies, which
+	// make requests on behalf of the subject in the x-opni-rbac-subject header.
+	TrustedProxies []string `json:"trustedProxies,omitempty"`
+	// Unix socket on which the management API is served without client
+	// certificates to the gateway's own components, such as plugins, and to
+	// local users. Defaults to the default management socket.
+	LocalListenAddress string `json:"localListenAddress,omitempty"`
 }
 
 func (m ManagementSpec) GetGRPCListenAddress() string {
@@ -207,7 +234,8 @@ const (
 )
 
 const (
-	PatchEngineBsdiff PatchEngine = "bsdiff"
+	PatchEngineBsdiff  PatchEngine = "bsdiff"
+	PatchEngineChunked PatchEngine = "chunked"
 )
 
 type PluginsSpec struct {
@@ -215,6 +243,44 @@ type PluginsSpec struct {
 	Dir string `json:"dir,omitempty"`
 	// Options for caching plugins
 	Cache CacheSpec `json:"cache,omitempty"`
+	// Options for reloading plugins when they change on disk
+	Reload ReloadSpec `json:"reload,omitempty"`
+	// Options for verifying plugin signatures
+	Signatures SignatureSpec `json:"signatures,omitempty"`
+}
+
+type SignatureSpec struct {
+	// Paths to PEM encoded ed25519 public keys which are trusted to sign plugins
+	TrustedKeys []string `json:"trustedKeys,omitempty"`
+	// PEM encoded ed25519 public keys which are trusted to sign plugins
+	TrustedKeyData [][]byte `json:"trustedKeyData,omitempty"`
+	// How plugin signatures are enforced. Defaults to "enforce".
+	// Plugins run with full privileges on the gateway and on every agent, so
+	// "warn" and "disabled" should only be used for development.
+	Mode SignatureMode `json:"mode,omitempty"`
+}
+
+type SignatureMode string
+
+const (
+	// Plugins are verified, but plugins which are not signed by a trusted key
+	// are still loaded, and a warning is logged.
+	SignatureModeWarn SignatureMode = "warn"
+	// Plugins which are not signed by a trusted key are rejected.
+	SignatureModeEnforce SignatureMode = "enforce"
+	// Plugin signatures are not checked.
+	SignatureModeDisabled SignatureMode = "disabled"
+)
+
+type ReloadSpec struct {
+	// If true, the plugin directory is watched for changes, and plugins are
+	// reloaded without restarting the gateway.
+	Enabled bool `json:"enabled,omitempty"`
+	// How often to check the plugin directory for changes (e.g. "10s")
+	Interval string `json:"interval,omitempty"`
+	// How long to wait for in-flight requests to complete before stopping
+	// a plugin which has been replaced or removed (e.g. "30s")
+	DrainTimeout string `json:"drainTimeout,omitempty"`
 }
 
 type CacheSpec struct {
@@ -298,6 +364,12 @@ func (s *GatewayConfigSpec) SetDefaults() {
 	if s.Plugins.Dir == "" {
 		s.Plugins.Dir = "/var/lib/opni/plugins"
 	}
+	if s.Plugins.Reload.Interval == "" {
+		s.Plugins.Reload.Interval = "10s"
+	}
+	if s.Plugins.Reload.DrainTimeout == "" {
+		s.Plugins.Reload.DrainTimeout = "30s"
+	}
 	if s.Plugins.Cache.PatchEngine == "" {
 		s.Plugins.Cache.PatchEngine = PatchEngineBsdiff
 	}
@@ -320,6 +392,8 @@ const (
 	// and it is recommended to use the etcd storage type instead for performance
 	// reasons.
 	StorageTypeCRDs StorageType = "customResources"
+	// Use a SQL database (PostgreSQL) for key-value storage.
+	StorageTypeSQL StorageType = "sql"
 )
 
 type StorageSpec struct {
@@ -327,6 +401,7 @@ type StorageSpec struct {
 	Etcd            *EtcdStorageSpec            `json:"etcd,omitempty"`
 	JetStream       *JetStreamStorageSpec       `json:"jetstream,omitempty"`
 	CustomResources *CustomResourcesStorageSpec `json:"customResources,omitempty"`
+	SQL             *SQLStorageSpec             `json:"sql,omitempty"`
 }
 
 type EtcdStorageSpec struct {
@@ -341,6 +416,16 @@ type JetStreamStorageSpec struct {
 	NkeySeedPath string `json:"nkeySeedPath,omitempty"`
 }
 
+type SQLStorageSpec struct {
+	// Name of the database/sql driver. Defaults to "postgres".
+	Driver string `json:"driver,omitempty"`
+	// Data source name (connection string) passed to the driver.
+	DSN string `json:"dsn,omitempty"`
+	// Path to a file containing the data source name. Takes precedence
+	// over DSN, which is useful when the DSN contains credentials.
+	DSNPath string `json:"dsnPath,omitempty"`
+}
+
 type CustomResourcesStorageSpec struct {
 	// Kubernetes namespace where custom resource objects will be stored.
 	Namespace string `json:"namespace,omitempty"`
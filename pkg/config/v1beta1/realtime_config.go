@@ -15,6 +15,9 @@ type RealtimeServerSpec struct {
 	MetricsListenAddress string               `json:"metricsListenAddress,omitempty"`
 	Metrics              MetricsSpec          `json:"metrics,omitempty"`
 	ManagementClient     ManagementClientSpec `json:"managementClient,omitempty"`
+	// Directory in which modules persist their state across restarts. If
+	// not set, state is kept in memory only.
+	StateDir string `json:"stateDir,omitempty"`
 }
 
 type ManagementClientSpec struct {
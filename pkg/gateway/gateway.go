@@ -6,13 +6,17 @@ import (
 	"crypto/tls"
 	"fmt"
 	"net"
+	"sync"
+	"time"
 
 	"github.com/rancher/opni/pkg/patch"
 
 	"github.com/hashicorp/go-plugin"
+	gsync "github.com/kralicky/gpkg/sync"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/samber/lo"
 	"go.uber.org/zap"
+	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
 	"golang.org/x/mod/module"
 	"google.golang.org/grpc"
@@ -59,6 +63,8 @@ type Gateway struct {
 	storageBackend  storage.Backend
 	capBackendStore capabilities.BackendStore
 	syncRequester   *SyncRequester
+	keyringRotator  *KeyringRotator
+	systemKvStores  *systemKeyValueStores
 }
 
 type GatewayOptions struct {
@@ -109,6 +115,7 @@ func NewGateway(ctx context.Context, conf *config.GatewayConfig, pl plugins.Load
 	}, lg)
 
 	// add capabilities from plugins
+	capNamesByModule := gsync.Map[string, string]{}
 	pl.Hook(hooks.OnLoadM(func(p types.CapabilityBackendPlugin, md meta.PluginMeta) {
 		info, err := p.Info(ctx, &emptypb.Empty{})
 		if err != nil {
@@ -122,14 +129,28 @@ func NewGateway(ctx context.Context, conf *config.GatewayConfig, pl plugins.Load
 				zap.String("plugin", md.Module),
 				zap.Error(err),
 			).Error("failed to add capability backend")
+			return
 		}
+		capNamesByModule.Store(md.Module, info.Name)
 		lg.With(
 			zap.String("plugin", md.Module),
 			zap.String("capability", info.Name),
 		).Info("added capability backend")
 	}))
+	pl.Hook(hooks.OnUnload(func(md meta.PluginMeta) {
+		if name, ok := capNamesByModule.LoadAndDelete(md.Module); ok {
+			capBackendStore.Remove(name)
+			lg.With(
+				zap.String("plugin", md.Module),
+				zap.String("capability", name),
+			).Info("removed capability backend")
+		}
+	}))
 
 	// serve system plugin kv stores
+	systemKvStores := &systemKeyValueStores{
+		stores: make(map[string]storage.KeyValueStore),
+	}
 	pl.Hook(hooks.OnLoadM(func(p types.SystemPlugin, md meta.PluginMeta) {
 		ns := md.Module
 		if err := module.CheckPath(ns); err != nil {
@@ -140,8 +161,12 @@ func NewGateway(ctx context.Context, conf *config.GatewayConfig, pl plugins.Load
 			return
 		}
 		store := storageBackend.KeyValueStore(ns)
+		systemKvStores.add(ns, store)
 		go p.ServeKeyValueStore(store)
 	}))
+	pl.Hook(hooks.OnUnload(func(md meta.PluginMeta) {
+		systemKvStores.remove(md.Module)
+	}))
 
 	// set up http server
 	tlsConfig, pkey, err := loadTLSConfig(&conf.Spec)
@@ -199,8 +224,9 @@ func NewGateway(ctx context.Context, conf *config.GatewayConfig, pl plugins.Load
 	listener := health.NewListener()
 	monitor := health.NewMonitor(health.WithLogger(lg.Named("monitor")))
 	sync := NewSyncRequester(lg)
+	keyringRotator := NewKeyringRotator(storageBackend, lg)
 	// set up agent connection handlers
-	agentHandler := MultiConnectionHandler(listener, sync)
+	agentHandler := MultiConnectionHandler(listener, sync, keyringRotator)
 	//// set up ref count to health listener
 	//versionHandler := MultiConnectionHandler(listener, )
 	go monitor.Run(ctx, listener)
@@ -218,6 +244,25 @@ func NewGateway(ctx context.Context, conf *config.GatewayConfig, pl plugins.Load
 			).Error("failed to add plugin remote stream service")
 		}
 	}))
+	pl.Hook(hooks.OnUnload(func(md meta.PluginMeta) {
+		streamSvc.RemoveRemote(md.Filename())
+	}))
+
+	pl.Hook(hooks.OnPluginEvent(func(event hooks.PluginEvent) {
+		lg := lg.With(
+			"plugin", event.Plugin.Module,
+			"event", event.Type.String(),
+		)
+		if event.Error != nil {
+			lg.With(zap.Error(event.Error)).Warn("plugin reload failed")
+			return
+		}
+		lg.Info("plugins changed")
+		// agents will download the new plugin binaries when they reconnect
+		if err := syncServer.RefreshManifest(); err != nil {
+			lg.With(zap.Error(err)).Error("failed to refresh plugin manifest")
+		}
+	}))
 
 	// set up bootstrap server
 	bootstrapServerV1 := bootstrap.NewServer(storageBackend, pkey, capBackendStore)
@@ -236,6 +281,8 @@ func NewGateway(ctx context.Context, conf *config.GatewayConfig, pl plugins.Load
 		grpcServer:      grpcServer,
 		statusQuerier:   monitor,
 		syncRequester:   sync,
+		keyringRotator:  keyringRotator,
+		systemKvStores:  systemKvStores,
 	}
 
 	waitctx.Go(ctx, func() {
@@ -252,6 +299,30 @@ type keyValueStoreServer interface {
 	ServeKeyValueStore(store storage.KeyValueStore)
 }
 
+// Key-value stores served to system plugins, by plugin namespace
+type systemKeyValueStores struct {
+	mu     sync.RWMutex
+	stores map[string]storage.KeyValueStore
+}
+
+func (s *systemKeyValueStores) add(namespace string, store storage.KeyValueStore) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stores[namespace] = store
+}
+
+func (s *systemKeyValueStores) remove(namespace string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.stores, namespace)
+}
+
+func (s *systemKeyValueStores) list() map[string]storage.KeyValueStore {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return maps.Clone(s.stores)
+}
+
 func (g *Gateway) ListenAndServe(ctx context.Context) error {
 	lg := g.logger
 	ctx, ca := context.WithCancel(ctx)
@@ -336,6 +407,16 @@ func (g *Gateway) WatchClusterHealthStatus(ctx context.Context) <-chan *corev1.C
 	return g.statusQuerier.WatchHealthStatus(ctx)
 }
 
+// Implements management.KeyringDataSource
+func (g *Gateway) RotateClusterKeyring(ctx context.Context, ref *corev1.Reference, gracePeriod time.Duration) error {
+	return g.keyringRotator.RotateKeyring(ctx, ref, gracePeriod)
+}
+
+// Implements management.TasksDataSource
+func (g *Gateway) TaskStores() map[string]storage.KeyValueStore {
+	return g.systemKvStores.list()
+}
+
 func (g *Gateway) MustRegisterCollector(collector prometheus.Collector) {
 	g.httpServer.metricsRegisterer.MustRegister(collector)
 }
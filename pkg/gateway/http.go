@@ -50,6 +50,27 @@ type GatewayHTTPServer struct {
 
 	routesMu             sync.Mutex
 	reservedPrefixRoutes []string
+	// routes registered by plugins, keyed by method and path. Routes cannot
+	// be removed from the router, so when a plugin is reloaded or removed,
+	// its routes are pointed at the new version or disabled instead.
+	pluginRoutes map[string]*pluginRoute
+
+	metricsMu     sync.Mutex
+	pluginMetrics map[string]prometheus.Collector
+}
+
+type pluginRoute struct {
+	module  string
+	handler atomic.Pointer[gin.HandlerFunc]
+}
+
+func (r *pluginRoute) serve(c *gin.Context) {
+	h := r.handler.Load()
+	if h == nil {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	(*h)(c)
 }
 
 func NewHTTPServer(
@@ -110,11 +131,16 @@ func NewHTTPServer(
 			cfg.Metrics.GetPath(),
 			"/healthz",
 		},
+		pluginRoutes:  make(map[string]*pluginRoute),
+		pluginMetrics: make(map[string]prometheus.Collector),
 	}
 
 	srv.metricsRegisterer.MustRegister(apiCollectors...)
-	pl.Hook(hooks.OnLoad(func(p types.MetricsPlugin) {
+	pl.Hook(hooks.OnLoadM(func(p types.MetricsPlugin, md meta.PluginMeta) {
+		srv.metricsMu.Lock()
+		defer srv.metricsMu.Unlock()
 		srv.metricsRegisterer.MustRegister(p)
+		srv.pluginMetrics[md.Module] = p
 	}))
 
 	pl.Hook(hooks.OnLoadM(func(p types.HTTPAPIExtensionPlugin, md meta.PluginMeta) {
@@ -131,6 +157,16 @@ func NewHTTPServer(
 		srv.setupPluginRoutes(cfg, md)
 	}))
 
+	pl.Hook(hooks.OnUnload(func(md meta.PluginMeta) {
+		srv.removePluginRoutes(md)
+		srv.metricsMu.Lock()
+		defer srv.metricsMu.Unlock()
+		if c, ok := srv.pluginMetrics[md.Module]; ok {
+			srv.metricsRegisterer.Unregister(c)
+			delete(srv.pluginMetrics, md.Module)
+		}
+	}))
+
 	return srv
 }
 
@@ -199,6 +235,25 @@ ROUTES:
 			"route", route.Method+" "+route.Path,
 			"plugin", pluginMeta.Module,
 		).Debug("configured route for plugin")
-		s.router.Handle(route.Method, route.Path, forwarder)
+		key := route.Method + " " + route.Path
+		r, ok := s.pluginRoutes[key]
+		if !ok {
+			r = &pluginRoute{}
+			s.pluginRoutes[key] = r
+			s.router.Handle(route.Method, route.Path, r.serve)
+		}
+		r.module = pluginMeta.Module
+		r.handler.Store(&forwarder)
+	}
+}
+
+// removePluginRoutes disables all routes registered by the given plugin.
+func (s *GatewayHTTPServer) removePluginRoutes(pluginMeta meta.PluginMeta) {
+	s.routesMu.Lock()
+	defer s.routesMu.Unlock()
+	for _, r := range s.pluginRoutes {
+		if r.module == pluginMeta.Module {
+			r.handler.Store(nil)
+		}
 	}
 }
@@ -0,0 +1,134 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	agentv1 "github.com/rancher/opni/pkg/agent"
+	controlv1 "github.com/rancher/opni/pkg/apis/control/v1"
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	"github.com/rancher/opni/pkg/auth/cluster"
+	"github.com/rancher/opni/pkg/ecdh"
+	"github.com/rancher/opni/pkg/keyring"
+	"github.com/rancher/opni/pkg/storage"
+	"github.com/rancher/opni/pkg/util"
+)
+
+// KeyringRotator replaces the shared keys of connected agents. New keys are
+// derived using an ECDH key exchange with the agent, and both the agent and
+// the gateway keep the previous keys until the end of the grace period. The
+// gateway persists the new keys before the agent is told to switch to them.
+type KeyringRotator struct {
+	mu           sync.RWMutex
+	activeAgents map[string]agentv1.ClientSet
+	broker       storage.KeyringStoreBroker
+	clusterLocks util.LockMap[string, *sync.Mutex]
+	logger       *zap.SugaredLogger
+}
+
+func NewKeyringRotator(broker storage.KeyringStoreBroker, lg *zap.SugaredLogger) *KeyringRotator {
+	return &KeyringRotator{
+		activeAgents: make(map[string]agentv1.ClientSet),
+		broker:       broker,
+		clusterLocks: util.NewLockMap[string, *sync.Mutex](),
+		logger:       lg.Named("keyring"),
+	}
+}
+
+func (r *KeyringRotator) HandleAgentConnection(ctx context.Context, clientSet agentv1.ClientSet) {
+	id := cluster.StreamAuthorizedID(ctx)
+	r.mu.Lock()
+	r.activeAgents[id] = clientSet
+	r.mu.Unlock()
+
+	<-ctx.Done()
+
+	r.mu.Lock()
+	if r.activeAgents[id] == clientSet {
+		delete(r.activeAgents, id)
+	}
+	r.mu.Unlock()
+}
+
+// RotateKeyring replaces the shared keys of the given cluster. The cluster's
+// agent must be connected.
+func (r *KeyringRotator) RotateKeyring(ctx context.Context, ref *corev1.Reference, gracePeriod time.Duration) error {
+	if gracePeriod < 0 {
+		return status.Error(codes.InvalidArgument, "grace period cannot be negative")
+	}
+	lock := r.clusterLocks.Get(ref.GetId())
+	lock.Lock()
+	defer lock.Unlock()
+
+	r.mu.RLock()
+	clientSet, ok := r.activeAgents[ref.GetId()]
+	r.mu.RUnlock()
+	if !ok {
+		return status.Error(codes.Unavailable, "agent is not connected")
+	}
+
+	ks := r.broker.KeyringStore("gateway", ref)
+	kr, err := ks.Get(ctx)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return status.Error(codes.NotFound, "keyring not found")
+		}
+		return fmt.Errorf("failed to get keyring: %w", err)
+	}
+
+	lg := r.logger.With("id", ref.GetId())
+	lg.Info("rotating cluster keyring")
+
+	ekp := ecdh.NewEphemeralKeyPair()
+	resp, err := clientSet.RotateKeys(ctx, &controlv1.RotateKeysRequest{
+		ServerPubKey: ekp.PublicKey,
+		GracePeriod:  durationpb.New(gracePeriod),
+	})
+	if err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			return status.Error(codes.FailedPrecondition, "agent does not support keyring rotation")
+		}
+		return fmt.Errorf("agent failed to rotate keys: %w", err)
+	}
+	sharedSecret, err := ecdh.DeriveSharedSecret(ekp, ecdh.PeerPublicKey{
+		PublicKey: resp.GetClientPubKey(),
+		PeerType:  ecdh.PeerTypeClient,
+	})
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to derive shared secret: %v", err)
+	}
+
+	// The agent only switches to the new keys once they are committed, so the
+	// rotation can be abandoned until then.
+	if err := ks.Put(ctx, keyring.RotateSharedKeys(kr, keyring.NewSharedKeys(sharedSecret), gracePeriod)); err != nil {
+		lg.With(
+			zap.Error(err),
+		).Error("failed to persist rotated keyring")
+		return fmt.Errorf("failed to persist keyring: %w", err)
+	}
+	if _, err := clientSet.CommitKeys(ctx, &controlv1.CommitKeysRequest{
+		ClientPubKey: resp.GetClientPubKey(),
+	}); err != nil {
+		lg.With(
+			zap.Error(err),
+		).Error("agent failed to commit keys, restoring previous keyring")
+		if err := ks.Put(ctx, kr); err != nil {
+			lg.With(
+				zap.Error(err),
+			).Error("failed to restore previous keyring")
+		}
+		return fmt.Errorf("agent failed to commit keys: %w", err)
+	}
+	lg.With(
+		"gracePeriod", gracePeriod,
+	).Info("cluster keyring rotated")
+	return nil
+}
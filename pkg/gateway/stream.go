@@ -1,12 +1,14 @@
 package gateway
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sync"
 
 	"github.com/kralicky/totem"
 	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -24,6 +26,13 @@ type remote struct {
 	cc   *grpc.ClientConn
 }
 
+// splicedStream is an agent stream connection, and the remotes which were
+// spliced into it.
+type splicedStream struct {
+	remotes []*grpc.ClientConn
+	cancel  context.CancelFunc
+}
+
 type StreamServer struct {
 	streamv1.UnimplementedStreamServer
 	logger       *zap.SugaredLogger
@@ -32,6 +41,7 @@ type StreamServer struct {
 	services     []util.ServicePack[any]
 	remotesMu    sync.Mutex
 	remotes      []remote
+	streams      map[*splicedStream]struct{}
 }
 
 func NewStreamServer(
@@ -43,6 +53,7 @@ func NewStreamServer(
 		logger:       lg.Named("grpc"),
 		handler:      handler,
 		clusterStore: clusterStore,
+		streams:      map[*splicedStream]struct{}{},
 	}
 }
 
@@ -76,8 +87,23 @@ func (s *StreamServer) Connect(stream streamv1.Stream_ConnectServer) error {
 		return status.Error(codes.Internal, err.Error())
 	}
 	ctx = storage.NewWatchContext(ctx, eventC)
+	ctx, ca := context.WithCancel(ctx)
+	defer ca()
 
-	for _, r := range s.remotes {
+	s.remotesMu.Lock()
+	remotes := slices.Clone(s.remotes)
+	spliced := &splicedStream{
+		cancel: ca,
+	}
+	s.streams[spliced] = struct{}{}
+	s.remotesMu.Unlock()
+	defer func() {
+		s.remotesMu.Lock()
+		delete(s.streams, spliced)
+		s.remotesMu.Unlock()
+	}()
+
+	for _, r := range remotes {
 		streamClient := streamv1.NewStreamClient(r.cc)
 		ctx := cluster.AuthorizedOutgoingContext(ctx)
 		splicedStream, err := streamClient.Connect(ctx)
@@ -95,6 +121,9 @@ func (s *StreamServer) Connect(stream streamv1.Stream_ConnectServer) error {
 			).Warn("failed to splice remote stream, skipping")
 			continue
 		}
+		s.remotesMu.Lock()
+		spliced.remotes = append(spliced.remotes, r.cc)
+		s.remotesMu.Unlock()
 	}
 
 	cc, errC := ts.Serve()
@@ -140,15 +169,49 @@ func (s *StreamServer) RegisterService(desc *grpc.ServiceDesc, impl any) {
 	s.services = append(s.services, util.PackService(desc, impl))
 }
 
+// AddRemote adds a remote stream connection which will be spliced into new
+// agent streams. If a remote with the same name already exists, it is
+// replaced, and the agent streams it was spliced into are closed so that the
+// agents reconnect and are spliced into the new remote.
 func (s *StreamServer) AddRemote(cc *grpc.ClientConn, name string) error {
 	s.remotesMu.Lock()
 	defer s.remotesMu.Unlock()
 	s.logger.With(
 		zap.String("address", cc.Target()),
 	).Debug("adding remote connection")
-	s.remotes = append(s.remotes, remote{
+	r := remote{
 		name: name,
 		cc:   cc,
-	})
+	}
+	if i := slices.IndexFunc(s.remotes, func(r remote) bool {
+		return r.name == name
+	}); i >= 0 {
+		s.closeStreamsLocked(s.remotes[i])
+		s.remotes[i] = r
+		return nil
+	}
+	s.remotes = append(s.remotes, r)
 	return nil
 }
+
+// RemoveRemote removes the remote stream connection with the given name, if
+// it exists. The agent streams it was spliced into are closed so that the
+// agents reconnect without it.
+func (s *StreamServer) RemoveRemote(name string) {
+	s.remotesMu.Lock()
+	defer s.remotesMu.Unlock()
+	if i := slices.IndexFunc(s.remotes, func(r remote) bool {
+		return r.name == name
+	}); i >= 0 {
+		s.closeStreamsLocked(s.remotes[i])
+		s.remotes = slices.Delete(s.remotes, i, i+1)
+	}
+}
+
+func (s *StreamServer) closeStreamsLocked(r remote) {
+	for stream := range s.streams {
+		if slices.Contains(stream.remotes, r.cc) {
+			stream.cancel()
+		}
+	}
+}
@@ -84,15 +84,16 @@ func (ct *defaultConditionTracker) Set(key string, value ConditionStatus, reason
 		"reason", reason,
 	)
 	if v, ok := ct.conditions.Load(key); ok {
-		if v != value {
-			lg.Info("condition changed")
+		if v == value {
+			return
 		}
-	} else if v != value {
+		lg.Info("condition changed")
+	} else {
 		lg.Info("condition set")
-		ct.conditions.Store(key, value)
-		ct.modTime.Store(time.Now())
-		ct.notifyListeners()
 	}
+	ct.conditions.Store(key, value)
+	ct.modTime.Store(time.Now())
+	ct.notifyListeners()
 }
 
 func (ct *defaultConditionTracker) Clear(key string, reason ...string) {
@@ -0,0 +1,37 @@
+package health_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/rancher/opni/pkg/health"
+	"github.com/rancher/opni/pkg/test"
+)
+
+var _ = Describe("Condition Tracker", Label("unit"), func() {
+	var ct health.ConditionTracker
+	BeforeEach(func() {
+		ct = health.NewDefaultConditionTracker(test.Log)
+		ct.Clear(health.CondConfigSync)
+	})
+	It("should set new conditions", func() {
+		ct.Set("foo", health.StatusPending, "")
+		ct.Set("bar", health.StatusFailure, "")
+		Expect(ct.List()).To(ConsistOf("foo Pending", "bar Failure"))
+	})
+	It("should update the status of existing conditions", func() {
+		ct.Set("foo", health.StatusPending, "")
+		modTime := ct.LastModified()
+		ct.Set("foo", health.StatusPending, "")
+		Expect(ct.LastModified()).To(Equal(modTime))
+
+		ct.Set("foo", health.StatusFailure, "")
+		Expect(ct.List()).To(ConsistOf("foo Failure"))
+		Expect(ct.LastModified()).NotTo(Equal(modTime))
+	})
+	It("should clear conditions", func() {
+		ct.Set("foo", health.StatusFailure, "")
+		ct.Clear("foo")
+		Expect(ct.List()).To(BeEmpty())
+	})
+})
@@ -3,6 +3,7 @@ package keyring
 import (
 	"crypto/ed25519"
 	"crypto/x509"
+	"time"
 
 	"github.com/rancher/opni/pkg/pkp"
 	"golang.org/x/exp/slices"
@@ -14,6 +15,9 @@ import (
 type SharedKeys struct {
 	ClientKey ed25519.PrivateKey `json:"clientKey"`
 	ServerKey ed25519.PrivateKey `json:"serverKey"`
+	// Set on keys which have been replaced by newer keys, and which remain
+	// valid until the end of the rotation grace period.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
 }
 
 type PKPKey struct {
@@ -35,6 +39,12 @@ func NewSharedKeys(secret []byte) *SharedKeys {
 	}
 }
 
+// Expired returns true if the keys were replaced and their grace period has
+// ended. Keys which were never replaced do not expire.
+func (k *SharedKeys) Expired() bool {
+	return k.ExpiresAt != nil && time.Now().After(*k.ExpiresAt)
+}
+
 func NewPKPKey(pinnedKeys []*pkp.PublicKeyPin) *PKPKey {
 	key := &PKPKey{
 		PinnedKeys: make([]*pkp.PublicKeyPin, len(pinnedKeys)),
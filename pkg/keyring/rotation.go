@@ -0,0 +1,56 @@
+package keyring
+
+import (
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// RotateSharedKeys returns a copy of the keyring in which newKeys are the
+// current shared keys. Shared keys which were current in the original keyring
+// remain in the new keyring until the grace period has elapsed, after which
+// they expire. Expired shared keys are dropped, and all other keys are kept
+// as-is.
+func RotateSharedKeys(kr Keyring, newKeys *SharedKeys, gracePeriod time.Duration) Keyring {
+	expiresAt := time.Now().Add(gracePeriod)
+	keys := []interface{}{newKeys}
+	kr.ForEach(func(key interface{}) {
+		sk, ok := key.(*SharedKeys)
+		if !ok {
+			keys = append(keys, key)
+			return
+		}
+		if gracePeriod <= 0 || sk.Expired() {
+			return
+		}
+		previous := &SharedKeys{
+			ClientKey: sk.ClientKey,
+			ServerKey: sk.ServerKey,
+			ExpiresAt: sk.ExpiresAt,
+		}
+		if previous.ExpiresAt == nil || previous.ExpiresAt.After(expiresAt) {
+			previous.ExpiresAt = &expiresAt
+		}
+		keys = append(keys, previous)
+	})
+	return New(keys...)
+}
+
+// ActiveSharedKeys returns the current (non-expiring) shared keys in the
+// keyring, followed by any previous shared keys which have not yet expired,
+// ordered by their expiration time from latest to earliest.
+func ActiveSharedKeys(kr Keyring) []*SharedKeys {
+	var current, previous []*SharedKeys
+	kr.Try(func(sk *SharedKeys) {
+		switch {
+		case sk.ExpiresAt == nil:
+			current = append(current, sk)
+		case !sk.Expired():
+			previous = append(previous, sk)
+		}
+	})
+	slices.SortStableFunc(previous, func(a, b *SharedKeys) bool {
+		return a.ExpiresAt.After(*b.ExpiresAt)
+	})
+	return append(current, previous...)
+}
@@ -0,0 +1,98 @@
+package keyring_test
+
+import (
+	"crypto/rand"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/rancher/opni/pkg/keyring"
+	"github.com/rancher/opni/pkg/pkp"
+)
+
+func newTestSharedKeys() *keyring.SharedKeys {
+	secret := make([]byte, 64)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+	return keyring.NewSharedKeys(secret)
+}
+
+var _ = Describe("Keyring Rotation", Label("unit"), func() {
+	It("should keep previous keys until the grace period ends", func() {
+		oldKeys := newTestSharedKeys()
+		pkpKey := keyring.NewPKPKey([]*pkp.PublicKeyPin{
+			{
+				Algorithm:   "sha256",
+				Fingerprint: []byte("test"),
+			},
+		})
+		kr := keyring.New(oldKeys, pkpKey)
+
+		newKeys := newTestSharedKeys()
+		rotated := keyring.RotateSharedKeys(kr, newKeys, 1*time.Hour)
+
+		active := keyring.ActiveSharedKeys(rotated)
+		Expect(active).To(HaveLen(2))
+		Expect(active[0]).To(Equal(newKeys))
+		Expect(active[0].ExpiresAt).To(BeNil())
+		Expect(active[1].ClientKey).To(Equal(oldKeys.ClientKey))
+		Expect(active[1].ExpiresAt).NotTo(BeNil())
+		Expect(*active[1].ExpiresAt).To(BeTemporally("~", time.Now().Add(1*time.Hour), 1*time.Second))
+
+		By("checking that the original keys were not modified")
+		Expect(oldKeys.ExpiresAt).To(BeNil())
+
+		By("checking that other keys are kept")
+		Expect(rotated.Try(func(key *keyring.PKPKey) {
+			Expect(key).To(Equal(pkpKey))
+		})).To(BeTrue())
+
+		By("checking that expiration times survive a round trip")
+		data, err := rotated.Marshal()
+		Expect(err).NotTo(HaveOccurred())
+		unmarshaled, err := keyring.Unmarshal(data)
+		Expect(err).NotTo(HaveOccurred())
+		active = keyring.ActiveSharedKeys(unmarshaled)
+		Expect(active).To(HaveLen(2))
+		Expect(active[0].ExpiresAt).To(BeNil())
+		Expect(active[1].ExpiresAt).NotTo(BeNil())
+	})
+	It("should drop previous keys immediately if there is no grace period", func() {
+		kr := keyring.New(newTestSharedKeys())
+		newKeys := newTestSharedKeys()
+		rotated := keyring.RotateSharedKeys(kr, newKeys, 0)
+		Expect(keyring.ActiveSharedKeys(rotated)).To(ConsistOf(newKeys))
+	})
+	It("should drop expired keys", func() {
+		expired := newTestSharedKeys()
+		expiresAt := time.Now().Add(-1 * time.Minute)
+		expired.ExpiresAt = &expiresAt
+		Expect(expired.Expired()).To(BeTrue())
+
+		current := newTestSharedKeys()
+		Expect(current.Expired()).To(BeFalse())
+
+		kr := keyring.New(expired, current)
+		Expect(keyring.ActiveSharedKeys(kr)).To(ConsistOf(current))
+
+		newKeys := newTestSharedKeys()
+		rotated := keyring.RotateSharedKeys(kr, newKeys, 1*time.Hour)
+		active := keyring.ActiveSharedKeys(rotated)
+		Expect(active).To(HaveLen(2))
+		Expect(active[0]).To(Equal(newKeys))
+		Expect(active[1].ClientKey).To(Equal(current.ClientKey))
+	})
+	It("should not extend the grace period of previous keys", func() {
+		kr := keyring.New(newTestSharedKeys())
+		first := keyring.RotateSharedKeys(kr, newTestSharedKeys(), 10*time.Minute)
+		second := keyring.RotateSharedKeys(first, newTestSharedKeys(), 1*time.Hour)
+
+		active := keyring.ActiveSharedKeys(second)
+		Expect(active).To(HaveLen(3))
+		Expect(active[0].ExpiresAt).To(BeNil())
+		Expect(*active[1].ExpiresAt).To(BeTemporally("~", time.Now().Add(1*time.Hour), 1*time.Second))
+		Expect(*active[2].ExpiresAt).To(BeTemporally("~", time.Now().Add(10*time.Minute), 1*time.Second))
+	})
+})
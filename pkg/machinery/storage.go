@@ -9,6 +9,7 @@ import (
 	"github.com/rancher/opni/pkg/storage/crds"
 	"github.com/rancher/opni/pkg/storage/etcd"
 	"github.com/rancher/opni/pkg/storage/jetstream"
+	"github.com/rancher/opni/pkg/storage/sql"
 )
 
 func ConfigureStorageBackend(ctx context.Context, cfg *v1beta1.StorageSpec) (storage.Backend, error) {
@@ -38,6 +39,15 @@ func ConfigureStorageBackend(ctx context.Context, cfg *v1beta1.StorageSpec) (sto
 			return nil, err
 		}
 		storageBackend.Use(store)
+	case v1beta1.StorageTypeSQL:
+		if cfg.SQL == nil {
+			return nil, errors.New("sql storage options are not set")
+		}
+		store, err := sql.NewSQLStore(ctx, cfg.SQL)
+		if err != nil {
+			return nil, err
+		}
+		storageBackend.Use(store)
 	default:
 		return nil, errors.New("unknown storage type")
 	}
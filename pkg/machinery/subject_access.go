@@ -19,6 +19,13 @@ func SubjectAccessCapableStore(client managementv1.ManagementClient) storage.Sub
 	}
 }
 
+func (s *subjectAccessCapableStore) GetCluster(
+	ctx context.Context,
+	ref *corev1.Reference,
+) (*corev1.Cluster, error) {
+	return s.client.GetCluster(ctx, ref)
+}
+
 func (s *subjectAccessCapableStore) ListClusters(
 	ctx context.Context,
 	matchLabels *corev1.LabelSelector,
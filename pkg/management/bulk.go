@@ -0,0 +1,348 @@
+package management
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	capabilityv1 "github.com/rancher/opni/pkg/apis/capability/v1"
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	managementv1 "github.com/rancher/opni/pkg/apis/management/v1"
+	"github.com/rancher/opni/pkg/capabilities"
+	"github.com/rancher/opni/pkg/storage"
+	"github.com/rancher/opni/pkg/task"
+	"github.com/rancher/opni/pkg/validation"
+)
+
+const (
+	// TaskNamespace is the namespace of the tasks owned by the management
+	// server in the tasks API.
+	TaskNamespace = "management"
+
+	capabilityTaskController = "capabilities"
+	defaultBulkConcurrency   = 10
+)
+
+type capabilityOperation string
+
+const (
+	capabilityOperationInstall   capabilityOperation = "install"
+	capabilityOperationUninstall capabilityOperation = "uninstall"
+)
+
+// capabilityTaskMetadata describes the operation performed by a single task
+// of a bulk capability operation.
+type capabilityTaskMetadata struct {
+	Operation capabilityOperation `json:"operation"`
+	// ID shared by all tasks of the same bulk operation
+	OperationId    string         `json:"operationId"`
+	Capability     string         `json:"capability"`
+	Cluster        string         `json:"cluster"`
+	Concurrency    int            `json:"concurrency"`
+	IgnoreWarnings bool           `json:"ignoreWarnings,omitempty"`
+	Cascade        bool           `json:"cascade,omitempty"`
+	Options        map[string]any `json:"options,omitempty"`
+}
+
+// capabilityTaskRunner installs or uninstalls a capability on a single
+// cluster. Tasks belonging to the same bulk operation share a concurrency
+// limit.
+type capabilityTaskRunner struct {
+	server *Server
+
+	limitsMu sync.Mutex
+	limits   map[string]*operationLimit
+}
+
+// operationLimit is the concurrency limit of a bulk operation. It is removed
+// once none of the operation's tasks are running.
+type operationLimit struct {
+	sem  chan struct{}
+	refs int
+}
+
+// acquire waits for a slot in the operation's concurrency limit. The returned
+// function releases the slot.
+func (r *capabilityTaskRunner) acquire(ctx context.Context, md capabilityTaskMetadata) (func(), error) {
+	r.limitsMu.Lock()
+	if r.limits == nil {
+		r.limits = make(map[string]*operationLimit)
+	}
+	limit, ok := r.limits[md.OperationId]
+	if !ok {
+		limit = &operationLimit{
+			sem: make(chan struct{}, lo.Max([]int{md.Concurrency, 1})),
+		}
+		r.limits[md.OperationId] = limit
+	}
+	limit.refs++
+	r.limitsMu.Unlock()
+
+	unref := func() {
+		r.limitsMu.Lock()
+		defer r.limitsMu.Unlock()
+		limit.refs--
+		if limit.refs == 0 {
+			delete(r.limits, md.OperationId)
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		unref()
+		return nil, ctx.Err()
+	case limit.sem <- struct{}{}:
+	}
+	return func() {
+		<-limit.sem
+		unref()
+	}, nil
+}
+
+func (r *capabilityTaskRunner) OnTaskPending(ctx context.Context, ti task.ActiveTask) error {
+	return nil
+}
+
+func (r *capabilityTaskRunner) OnTaskRunning(ctx context.Context, ti task.ActiveTask) error {
+	var md capabilityTaskMetadata
+	ti.LoadTaskMetadata(&md)
+
+	release, err := r.acquire(ctx, md)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	ref := &corev1.Reference{
+		Id: md.Cluster,
+	}
+	switch md.Operation {
+	case capabilityOperationInstall:
+		ti.AddLogEntry(zapcore.InfoLevel, fmt.Sprintf("Installing capability %s", md.Capability))
+		resp, err := r.server.InstallCapability(ctx, &managementv1.CapabilityInstallRequest{
+			Name: md.Capability,
+			Target: &capabilityv1.InstallRequest{
+				Cluster:        ref,
+				IgnoreWarnings: md.IgnoreWarnings,
+			},
+			Cascade: md.Cascade,
+		})
+		if err != nil {
+			return err
+		}
+		switch resp.GetStatus() {
+		case capabilityv1.InstallResponseStatus_Error:
+			return fmt.Errorf("install failed: %s", resp.GetMessage())
+		case capabilityv1.InstallResponseStatus_Warning:
+			ti.AddLogEntry(zapcore.WarnLevel, fmt.Sprintf("Capability installed with warning: %s", resp.GetMessage()))
+		default:
+			ti.AddLogEntry(zapcore.InfoLevel, "Capability installed successfully")
+		}
+	case capabilityOperationUninstall:
+		ti.AddLogEntry(zapcore.InfoLevel, fmt.Sprintf("Uninstalling capability %s", md.Capability))
+		var options *structpb.Struct
+		if md.Options != nil {
+			var err error
+			options, err = structpb.NewStruct(md.Options)
+			if err != nil {
+				return task.Permanent(fmt.Errorf("invalid uninstall options: %w", err))
+			}
+		}
+		if _, err := r.server.UninstallCapability(ctx, &managementv1.CapabilityUninstallRequest{
+			Name: md.Capability,
+			Target: &capabilityv1.UninstallRequest{
+				Cluster: ref,
+				Options: options,
+			},
+			Cascade: md.Cascade,
+		}); err != nil {
+			return err
+		}
+		// the uninstall runs in the capability's own task; keep holding the
+		// concurrency slot until it is done
+		ti.AddLogEntry(zapcore.InfoLevel, "Waiting for the uninstall to complete")
+		backend, err := r.server.capabilitiesDataSource.CapabilitiesStore().Get(md.Capability)
+		if err != nil {
+			return err
+		}
+		if err := waitForUninstall(ctx, backend, ref); err != nil {
+			return err
+		}
+		ti.AddLogEntry(zapcore.InfoLevel, "Capability uninstalled successfully")
+	default:
+		return task.Permanent(fmt.Errorf("unknown operation %q", md.Operation))
+	}
+	return nil
+}
+
+func (r *capabilityTaskRunner) OnTaskCompleted(ctx context.Context, ti task.ActiveTask, state task.State, args ...any) {
+	switch state {
+	case task.StateFailed:
+		ti.AddLogEntry(zapcore.ErrorLevel, fmt.Sprintf("Operation failed: %v", args[0]))
+	case task.StateCanceled:
+		ti.AddLogEntry(zapcore.InfoLevel, "Operation canceled")
+	}
+}
+
+func (m *Server) BulkInstallCapability(
+	ctx context.Context,
+	in *managementv1.BulkCapabilityInstallRequest,
+) (*managementv1.BulkCapabilityResponse, error) {
+	if err := validation.Validate(in); err != nil {
+		return nil, err
+	}
+	return m.launchBulkCapabilityTasks(ctx, in.Name, in.Selector, in.Concurrency,
+		func(c *corev1.Cluster) (capabilityTaskMetadata, string) {
+			if lo.Contains(capabilities.InstalledCapabilities(c), in.Name) {
+				return capabilityTaskMetadata{}, "capability is already installed"
+			}
+			return capabilityTaskMetadata{
+				Operation:      capabilityOperationInstall,
+				IgnoreWarnings: in.IgnoreWarnings,
+				Cascade:        in.Cascade,
+			}, ""
+		},
+	)
+}
+
+func (m *Server) BulkUninstallCapability(
+	ctx context.Context,
+	in *managementv1.BulkCapabilityUninstallRequest,
+) (*managementv1.BulkCapabilityResponse, error) {
+	if err := validation.Validate(in); err != nil {
+		return nil, err
+	}
+	return m.launchBulkCapabilityTasks(ctx, in.Name, in.Selector, in.Concurrency,
+		func(c *corev1.Cluster) (capabilityTaskMetadata, string) {
+			if !lo.Contains(capabilities.InstalledCapabilities(c), in.Name) {
+				return capabilityTaskMetadata{}, "capability is not installed"
+			}
+			return capabilityTaskMetadata{
+				Operation: capabilityOperationUninstall,
+				Cascade:   in.Cascade,
+				Options:   in.GetOptions().AsMap(),
+			}, ""
+		},
+	)
+}
+
+func (m *Server) BulkCapabilityUninstallStatus(
+	ctx context.Context,
+	in *managementv1.BulkCapabilityStatusRequest,
+) (*managementv1.BulkCapabilityStatusResponse, error) {
+	if err := validation.Validate(in); err != nil {
+		return nil, err
+	}
+	clusters, err := m.selectClusters(ctx, in.Selector)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*managementv1.ClusterTaskStatus, len(clusters))
+	sem := make(chan struct{}, defaultBulkConcurrency)
+	var wg sync.WaitGroup
+	for i, c := range clusters {
+		i, c := i, c
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			item := &managementv1.ClusterTaskStatus{
+				Cluster: c.Reference(),
+			}
+			stat, err := m.CapabilityUninstallStatus(ctx, &managementv1.CapabilityStatusRequest{
+				Name:    in.Name,
+				Cluster: c.Reference(),
+			})
+			if err != nil {
+				item.Error = status.Convert(err).Message()
+			} else {
+				item.Status = stat
+			}
+			items[i] = item
+		}()
+	}
+	wg.Wait()
+	return &managementv1.BulkCapabilityStatusResponse{
+		Items: items,
+	}, nil
+}
+
+// launchBulkCapabilityTasks launches a task for each cluster matching the
+// selector. The prepare function returns the metadata for a cluster's task,
+// or the reason the cluster should be skipped.
+func (m *Server) launchBulkCapabilityTasks(
+	ctx context.Context,
+	name string,
+	selector *corev1.ClusterSelector,
+	concurrency int32,
+	prepare func(*corev1.Cluster) (capabilityTaskMetadata, string),
+) (*managementv1.BulkCapabilityResponse, error) {
+	if m.capabilityTasks == nil || m.capabilitiesDataSource == nil {
+		return nil, status.Error(codes.Unavailable, "bulk capability operations are not available")
+	}
+	if _, err := m.capabilitiesDataSource.CapabilitiesStore().Get(name); err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	clusters, err := m.selectClusters(ctx, selector)
+	if err != nil {
+		return nil, err
+	}
+	if concurrency == 0 {
+		concurrency = defaultBulkConcurrency
+	}
+
+	operationId := uuid.NewString()[:8]
+	resp := &managementv1.BulkCapabilityResponse{}
+	for _, c := range clusters {
+		target := &managementv1.BulkCapabilityTarget{
+			Cluster: c.Reference(),
+		}
+		resp.Items = append(resp.Items, target)
+		md, skipped := prepare(c)
+		if skipped != "" {
+			target.Skipped = skipped
+			continue
+		}
+		md.OperationId = operationId
+		md.Capability = name
+		md.Cluster = c.Id
+		md.Concurrency = int(concurrency)
+		id := fmt.Sprintf("%s-%s", operationId, c.Id)
+		if err := m.capabilityTasks.LaunchTask(id, task.WithMetadata(md)); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to launch task for cluster %s: %v", c.Id, err)
+		}
+		target.Task = &managementv1.TaskReference{
+			Namespace:  TaskNamespace,
+			Controller: capabilityTaskController,
+			Id:         id,
+		}
+	}
+	return resp, nil
+}
+
+// selectClusters returns the clusters matching the selector, sorted by id.
+func (m *Server) selectClusters(ctx context.Context, selector *corev1.ClusterSelector) ([]*corev1.Cluster, error) {
+	list, err := m.coreDataSource.StorageBackend().ListClusters(ctx, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	predicate := storage.NewSelectorPredicate(selector)
+	clusters := lo.Filter(list.GetItems(), func(c *corev1.Cluster, _ int) bool {
+		return predicate(c)
+	})
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].Id < clusters[j].Id
+	})
+	return clusters, nil
+}
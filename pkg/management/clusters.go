@@ -4,10 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	capabilityv1 "github.com/rancher/opni/pkg/apis/capability/v1"
 	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
 	managementv1 "github.com/rancher/opni/pkg/apis/management/v1"
+	"github.com/rancher/opni/pkg/capabilities"
 	"github.com/rancher/opni/pkg/storage"
 	"github.com/rancher/opni/pkg/validation"
 	"github.com/samber/lo"
@@ -155,50 +158,225 @@ func (m *Server) InstallCapability(
 	if err := validation.Validate(in); err != nil {
 		return nil, err
 	}
-	if err := m.ensureReferenceResolved(ctx, in.Target.Cluster); err != nil {
+	steps, err := m.planCapabilityInstall(ctx, in)
+	if err != nil {
 		return nil, err
 	}
 
+	// dependencies are installed first, in order; the requested capability
+	// is always the last step
 	backendStore := m.capabilitiesDataSource.CapabilitiesStore()
-	backend, err := backendStore.Get(in.Name)
+	var warnings []string
+	for _, step := range steps {
+		backend, err := backendStore.Get(step.Name)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := backend.Install(ctx, in.Target)
+		if err != nil {
+			if step.Name != in.Name {
+				return nil, status.Errorf(status.Code(err), "failed to install dependency %s: %s", step.Name, status.Convert(err).Message())
+			}
+			return nil, err
+		}
+		if resp == nil {
+			resp = &capabilityv1.InstallResponse{
+				Status: capabilityv1.InstallResponseStatus_Success,
+			}
+		}
+		if step.Name == in.Name {
+			if len(warnings) > 0 && resp.Status == capabilityv1.InstallResponseStatus_Success {
+				return &capabilityv1.InstallResponse{
+					Status:  capabilityv1.InstallResponseStatus_Warning,
+					Message: strings.Join(warnings, "; "),
+				}, nil
+			}
+			return resp, nil
+		}
+		switch resp.Status {
+		case capabilityv1.InstallResponseStatus_Error:
+			return &capabilityv1.InstallResponse{
+				Status:  capabilityv1.InstallResponseStatus_Error,
+				Message: fmt.Sprintf("failed to install dependency %s: %s", step.Name, resp.Message),
+			}, nil
+		case capabilityv1.InstallResponseStatus_Warning:
+			warnings = append(warnings, fmt.Sprintf("%s: %s", step.Name, resp.Message))
+		}
+	}
+	return nil, status.Error(codes.Internal, "install plan did not include the requested capability")
+}
+
+func (m *Server) UninstallCapability(
+	ctx context.Context,
+	in *managementv1.CapabilityUninstallRequest,
+) (*emptypb.Empty, error) {
+	if err := validation.Validate(in); err != nil {
+		return nil, err
+	}
+	steps, err := m.planCapabilityUninstall(ctx, in)
 	if err != nil {
 		return nil, err
 	}
 
-	resp, err := backend.Install(ctx, in.Target)
-	if err != nil {
+	// dependents are uninstalled first, in order; the requested capability
+	// is always the last step
+	backendStore := m.capabilitiesDataSource.CapabilitiesStore()
+	for _, step := range steps {
+		backend, err := backendStore.Get(step.Name)
+		if err != nil {
+			return nil, err
+		}
+		_, err = backend.Uninstall(ctx, &capabilityv1.UninstallRequest{
+			Cluster: in.Target.Cluster,
+			Options: in.Target.Options,
+		})
+		if err != nil {
+			if step.Name != in.Name {
+				return nil, status.Errorf(status.Code(err), "failed to uninstall dependent capability %s: %s", step.Name, status.Convert(err).Message())
+			}
+			return nil, err
+		}
+		if step.Name != in.Name {
+			// the dependent must be fully uninstalled before the capability
+			// it depends on is uninstalled
+			if err := waitForUninstall(ctx, backend, in.Target.Cluster); err != nil {
+				return nil, status.Errorf(status.Code(err), "failed to uninstall dependent capability %s: %s", step.Name, status.Convert(err).Message())
+			}
+		}
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// UninstallStatusPollInterval is how often the uninstall status of a
+// capability is checked while waiting for the uninstall to finish.
+var UninstallStatusPollInterval = 1 * time.Second
+
+// waitForUninstall waits until the capability's uninstall task on the cluster
+// has completed. An error is returned if the task fails or is canceled.
+func waitForUninstall(ctx context.Context, backend capabilityv1.BackendClient, cluster *corev1.Reference) error {
+	ticker := time.NewTicker(UninstallStatusPollInterval)
+	defer ticker.Stop()
+	for {
+		stat, err := backend.UninstallStatus(ctx, cluster)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		switch stat.GetState() {
+		case corev1.TaskState_Completed:
+			return nil
+		case corev1.TaskState_Failed:
+			return status.Error(codes.Aborted, "uninstall failed")
+		case corev1.TaskState_Canceled:
+			return status.Error(codes.Aborted, "uninstall was canceled")
+		}
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Server) PlanCapabilityInstall(
+	ctx context.Context,
+	in *managementv1.CapabilityInstallRequest,
+) (*managementv1.CapabilityPlan, error) {
+	if err := validation.Validate(in); err != nil {
 		return nil, err
 	}
-	if resp == nil {
-		return &capabilityv1.InstallResponse{
-			Status: capabilityv1.InstallResponseStatus_Success,
-		}, nil
+	steps, err := m.planCapabilityInstall(ctx, in)
+	if err != nil {
+		return nil, err
 	}
-	return resp, nil
+	return newCapabilityPlan(managementv1.CapabilityAction_Install, steps), nil
 }
 
-func (m *Server) UninstallCapability(
+func (m *Server) PlanCapabilityUninstall(
 	ctx context.Context,
 	in *managementv1.CapabilityUninstallRequest,
-) (*emptypb.Empty, error) {
+) (*managementv1.CapabilityPlan, error) {
 	if err := validation.Validate(in); err != nil {
 		return nil, err
 	}
-	if err := m.ensureReferenceResolved(ctx, in.Target.Cluster); err != nil {
+	steps, err := m.planCapabilityUninstall(ctx, in)
+	if err != nil {
 		return nil, err
 	}
+	return newCapabilityPlan(managementv1.CapabilityAction_Uninstall, steps), nil
+}
 
-	backendStore := m.capabilitiesDataSource.CapabilitiesStore()
-	backend, err := backendStore.Get(in.Name)
+func (m *Server) planCapabilityInstall(
+	ctx context.Context,
+	in *managementv1.CapabilityInstallRequest,
+) ([]capabilities.Step, error) {
+	cluster, err := m.resolveCluster(ctx, in.Target.Cluster)
+	if err != nil {
+		return nil, err
+	}
+	in.Target.Cluster.Id = cluster.Id
+	graph, err := m.capabilityDependencyGraph(ctx, in.Name)
 	if err != nil {
 		return nil, err
 	}
+	steps, err := graph.PlanInstall(in.Name, capabilities.InstalledCapabilities(cluster), in.Cascade)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return steps, nil
+}
 
-	_, err = backend.Uninstall(ctx, in.Target)
+func (m *Server) planCapabilityUninstall(
+	ctx context.Context,
+	in *managementv1.CapabilityUninstallRequest,
+) ([]capabilities.Step, error) {
+	cluster, err := m.resolveCluster(ctx, in.Target.Cluster)
 	if err != nil {
 		return nil, err
 	}
-	return &emptypb.Empty{}, nil
+	in.Target.Cluster.Id = cluster.Id
+	graph, err := m.capabilityDependencyGraph(ctx, in.Name)
+	if err != nil {
+		return nil, err
+	}
+	steps, err := graph.PlanUninstall(in.Name, capabilities.InstalledCapabilities(cluster), in.Cascade)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return steps, nil
+}
+
+// capabilityDependencyGraph builds a dependency graph from the details of
+// all known capability backends. The requested capability must be known.
+func (m *Server) capabilityDependencyGraph(ctx context.Context, name string) (*capabilities.DependencyGraph, error) {
+	backendStore := m.capabilitiesDataSource.CapabilitiesStore()
+	if _, err := backendStore.Get(name); err != nil {
+		return nil, err
+	}
+	var details []*capabilityv1.Details
+	for _, backendName := range backendStore.List() {
+		backend, err := backendStore.Get(backendName)
+		if err != nil {
+			continue // removed concurrently
+		}
+		info, err := backend.Info(ctx, &emptypb.Empty{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get info for capability %s: %w", backendName, err)
+		}
+		details = append(details, info)
+	}
+	return capabilities.NewDependencyGraph(details...), nil
+}
+
+func newCapabilityPlan(action managementv1.CapabilityAction, steps []capabilities.Step) *managementv1.CapabilityPlan {
+	plan := &managementv1.CapabilityPlan{}
+	for _, step := range steps {
+		plan.Steps = append(plan.Steps, &managementv1.CapabilityPlanStep{
+			Name:   step.Name,
+			Action: action,
+			Reason: step.Reason,
+		})
+	}
+	return plan
 }
 
 func (m *Server) CapabilityUninstallStatus(
@@ -3,11 +3,13 @@ package management_test
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	capabilityv1 "github.com/rancher/opni/pkg/apis/capability/v1"
 	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
 	managementv1 "github.com/rancher/opni/pkg/apis/management/v1"
 	"github.com/rancher/opni/pkg/capabilities"
@@ -17,8 +19,11 @@ import (
 	"github.com/rancher/opni/pkg/test"
 	"github.com/rancher/opni/pkg/util"
 	"github.com/rancher/opni/pkg/validation"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 var _ = Describe("Clusters", Ordered, Label("slow"), func() {
@@ -298,4 +303,358 @@ var _ = Describe("Clusters", Ordered, Label("slow"), func() {
 		Expect(err).NotTo(HaveOccurred())
 		Expect(updatedC.Metadata.Labels).To(And(HaveKeyWithValue("foo", "baz"), HaveKeyWithValue("opni.io/test", "1")))
 	})
+	It("should install and uninstall capabilities in dependency order", func() {
+		for _, info := range []*test.CapabilityInfo{
+			{Name: "base"},
+			{Name: "dependent", Dependencies: []string{"base"}},
+			{Name: "conflicting", Conflicts: []string{"base"}},
+		} {
+			info.CanInstall = true
+			info.Storage = tv.storageBackend
+			Expect(capBackendStore.Add(info.Name, test.NewTestCapabilityBackend(tv.ctrl, info))).To(Succeed())
+		}
+		ref := &corev1.Reference{Id: uuid.NewString()}
+		Expect(tv.storageBackend.CreateCluster(context.Background(), &corev1.Cluster{
+			Id:       ref.Id,
+			Metadata: &corev1.ClusterMetadata{},
+		})).To(Succeed())
+		installedCapabilities := func() []string {
+			c, err := tv.storageBackend.GetCluster(context.Background(), ref)
+			Expect(err).NotTo(HaveOccurred())
+			return capabilities.InstalledCapabilities(c)
+		}
+		installReq := &managementv1.CapabilityInstallRequest{
+			Name: "dependent",
+			Target: &capabilityv1.InstallRequest{
+				Cluster: ref,
+			},
+		}
+
+		By("rejecting installs with missing dependencies")
+		_, err := tv.client.InstallCapability(context.Background(), installReq)
+		Expect(status.Code(err)).To(Equal(codes.FailedPrecondition))
+		Expect(installedCapabilities()).To(BeEmpty())
+
+		By("planning an install with dependencies")
+		installReq.Cascade = true
+		plan, err := tv.client.PlanCapabilityInstall(context.Background(), installReq)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Steps).To(HaveLen(2))
+		Expect(plan.Steps[0].Name).To(Equal("base"))
+		Expect(plan.Steps[0].Action).To(Equal(managementv1.CapabilityAction_Install))
+		Expect(plan.Steps[1].Name).To(Equal("dependent"))
+		Expect(installedCapabilities()).To(BeEmpty())
+
+		By("installing dependencies first")
+		resp, err := tv.client.InstallCapability(context.Background(), installReq)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Status).To(Equal(capabilityv1.InstallResponseStatus_Success))
+		Expect(installedCapabilities()).To(Equal([]string{"base", "dependent"}))
+
+		By("rejecting conflicting capabilities")
+		_, err = tv.client.InstallCapability(context.Background(), &managementv1.CapabilityInstallRequest{
+			Name: "conflicting",
+			Target: &capabilityv1.InstallRequest{
+				Cluster: ref,
+			},
+		})
+		Expect(status.Code(err)).To(Equal(codes.FailedPrecondition))
+
+		By("rejecting uninstalls with installed dependents")
+		uninstallReq := &managementv1.CapabilityUninstallRequest{
+			Name: "base",
+			Target: &capabilityv1.UninstallRequest{
+				Cluster: ref,
+			},
+		}
+		_, err = tv.client.UninstallCapability(context.Background(), uninstallReq)
+		Expect(status.Code(err)).To(Equal(codes.FailedPrecondition))
+
+		By("planning an uninstall with dependents")
+		uninstallReq.Cascade = true
+		plan, err = tv.client.PlanCapabilityUninstall(context.Background(), uninstallReq)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Steps).To(HaveLen(2))
+		Expect(plan.Steps[0].Name).To(Equal("dependent"))
+		Expect(plan.Steps[0].Action).To(Equal(managementv1.CapabilityAction_Uninstall))
+		Expect(plan.Steps[1].Name).To(Equal("base"))
+
+		By("uninstalling dependents first")
+		_, err = tv.client.UninstallCapability(context.Background(), uninstallReq)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(installedCapabilities()).To(BeEmpty())
+	})
+	It("should wait for dependents to finish uninstalling", func() {
+		interval := management.UninstallStatusPollInterval
+		management.UninstallStatusPollInterval = 50 * time.Millisecond
+		DeferCleanup(func() {
+			management.UninstallStatusPollInterval = interval
+		})
+		for _, info := range []*test.CapabilityInfo{
+			{Name: "slow-base"},
+			{Name: "slow-dependent", Dependencies: []string{"slow-base"}},
+		} {
+			info.CanInstall = true
+			info.Storage = tv.storageBackend
+			var backend capabilityv1.BackendClient = test.NewTestCapabilityBackend(tv.ctrl, info)
+			if info.Name == "slow-dependent" {
+				backend = &slowUninstallBackend{
+					BackendClient: backend,
+					name:          info.Name,
+					storage:       tv.storageBackend,
+				}
+			}
+			Expect(capBackendStore.Add(info.Name, backend)).To(Succeed())
+		}
+		ref := &corev1.Reference{Id: uuid.NewString()}
+		Expect(tv.storageBackend.CreateCluster(context.Background(), &corev1.Cluster{
+			Id:       ref.Id,
+			Metadata: &corev1.ClusterMetadata{},
+		})).To(Succeed())
+		_, err := tv.client.InstallCapability(context.Background(), &managementv1.CapabilityInstallRequest{
+			Name:    "slow-dependent",
+			Cascade: true,
+			Target: &capabilityv1.InstallRequest{
+				Cluster: ref,
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = tv.client.UninstallCapability(context.Background(), &managementv1.CapabilityUninstallRequest{
+			Name:    "slow-base",
+			Cascade: true,
+			Target: &capabilityv1.UninstallRequest{
+				Cluster: ref,
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		// the dependent's uninstall must have completed, not only started
+		c, err := tv.storageBackend.GetCluster(context.Background(), ref)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.GetCapabilities()).To(BeEmpty())
+	})
+	It("should install and uninstall capabilities on clusters matching a selector", func() {
+		Expect(capBackendStore.Add("bulk", test.NewTestCapabilityBackend(tv.ctrl, &test.CapabilityInfo{
+			Name:       "bulk",
+			CanInstall: true,
+			Storage:    tv.storageBackend,
+		}))).To(Succeed())
+		group := uuid.NewString()
+		ids := []string{"bulk-1", "bulk-2", "bulk-3"}
+		for i, id := range ids {
+			env := "prod"
+			if i == 2 {
+				env = "dev"
+			}
+			Expect(tv.storageBackend.CreateCluster(context.Background(), &corev1.Cluster{
+				Id: id,
+				Metadata: &corev1.ClusterMetadata{
+					Labels: map[string]string{
+						"group": group,
+						"env":   env,
+					},
+				},
+			})).To(Succeed())
+		}
+		selector := &corev1.ClusterSelector{
+			LabelSelector: &corev1.LabelSelector{
+				MatchLabels: map[string]string{
+					"group": group,
+					"env":   "prod",
+				},
+			},
+		}
+		waitForTasks := func(targets []*managementv1.BulkCapabilityTarget) {
+			for _, target := range targets {
+				if target.Task == nil {
+					continue
+				}
+				Eventually(func() (corev1.TaskState, error) {
+					t, err := tv.client.GetTask(context.Background(), target.Task)
+					return t.GetStatus().GetState(), err
+				}).Should(Equal(corev1.TaskState_Completed))
+			}
+		}
+		installed := func(id string) []string {
+			c, err := tv.storageBackend.GetCluster(context.Background(), &corev1.Reference{Id: id})
+			Expect(err).NotTo(HaveOccurred())
+			return capabilities.InstalledCapabilities(c)
+		}
+
+		By("rejecting empty selectors")
+		_, err := tv.client.BulkInstallCapability(context.Background(), &managementv1.BulkCapabilityInstallRequest{
+			Name:     "bulk",
+			Selector: &corev1.ClusterSelector{},
+		})
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+
+		By("skipping clusters which already have the capability")
+		_, err = tv.client.InstallCapability(context.Background(), &managementv1.CapabilityInstallRequest{
+			Name: "bulk",
+			Target: &capabilityv1.InstallRequest{
+				Cluster: &corev1.Reference{Id: "bulk-2"},
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		resp, err := tv.client.BulkInstallCapability(context.Background(), &managementv1.BulkCapabilityInstallRequest{
+			Name:        "bulk",
+			Selector:    selector,
+			Concurrency: 1,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Items).To(HaveLen(2))
+		Expect(resp.Items[0].Cluster.Id).To(Equal("bulk-1"))
+		Expect(resp.Items[0].Skipped).To(BeEmpty())
+		Expect(resp.Items[0].Task.Namespace).To(Equal(management.TaskNamespace))
+		Expect(resp.Items[1].Cluster.Id).To(Equal("bulk-2"))
+		Expect(resp.Items[1].Skipped).NotTo(BeEmpty())
+		Expect(resp.Items[1].Task).To(BeNil())
+
+		By("installing the capability on matching clusters")
+		waitForTasks(resp.Items)
+		Expect(installed("bulk-1")).To(ConsistOf("bulk"))
+		Expect(installed("bulk-2")).To(ConsistOf("bulk"))
+		Expect(installed("bulk-3")).To(BeEmpty())
+
+		By("listing the tasks in the tasks API")
+		list, err := tv.client.ListTasks(context.Background(), &managementv1.ListTasksRequest{
+			Namespace: management.TaskNamespace,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(list.Items).To(HaveLen(1))
+
+		By("uninstalling the capability from matching clusters")
+		resp, err = tv.client.BulkUninstallCapability(context.Background(), &managementv1.BulkCapabilityUninstallRequest{
+			Name:     "bulk",
+			Selector: selector,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Items).To(HaveLen(2))
+		for _, item := range resp.Items {
+			Expect(item.Skipped).To(BeEmpty())
+			Expect(item.Task).NotTo(BeNil())
+		}
+		waitForTasks(resp.Items)
+		Expect(installed("bulk-1")).To(BeEmpty())
+		Expect(installed("bulk-2")).To(BeEmpty())
+
+		By("reporting the uninstall status of each cluster")
+		stat, err := tv.client.BulkCapabilityUninstallStatus(context.Background(), &managementv1.BulkCapabilityStatusRequest{
+			Name:     "bulk",
+			Selector: selector,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stat.Items).To(HaveLen(2))
+		for _, item := range stat.Items {
+			Expect(item.Error).To(BeEmpty())
+			Expect(item.Status).NotTo(BeNil())
+		}
+	})
+	It("should limit the number of concurrent uninstalls until they complete", func() {
+		interval := management.UninstallStatusPollInterval
+		management.UninstallStatusPollInterval = 50 * time.Millisecond
+		DeferCleanup(func() {
+			management.UninstallStatusPollInterval = interval
+		})
+		info := &test.CapabilityInfo{
+			Name:       "slow-bulk",
+			CanInstall: true,
+			Storage:    tv.storageBackend,
+		}
+		backend := &slowUninstallBackend{
+			BackendClient: test.NewTestCapabilityBackend(tv.ctrl, info),
+			name:          info.Name,
+			storage:       tv.storageBackend,
+		}
+		Expect(capBackendStore.Add(info.Name, backend)).To(Succeed())
+		group := uuid.NewString()
+		for i := 0; i < 3; i++ {
+			ref := &corev1.Reference{Id: fmt.Sprintf("slow-bulk-%d", i)}
+			Expect(tv.storageBackend.CreateCluster(context.Background(), &corev1.Cluster{
+				Id: ref.Id,
+				Metadata: &corev1.ClusterMetadata{
+					Labels: map[string]string{
+						"group": group,
+					},
+				},
+			})).To(Succeed())
+			_, err := tv.client.InstallCapability(context.Background(), &managementv1.CapabilityInstallRequest{
+				Name: info.Name,
+				Target: &capabilityv1.InstallRequest{
+					Cluster: ref,
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+		}
+
+		resp, err := tv.client.BulkUninstallCapability(context.Background(), &managementv1.BulkCapabilityUninstallRequest{
+			Name: info.Name,
+			Selector: &corev1.ClusterSelector{
+				LabelSelector: &corev1.LabelSelector{
+					MatchLabels: map[string]string{
+						"group": group,
+					},
+				},
+			},
+			Concurrency: 1,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Items).To(HaveLen(3))
+		for _, target := range resp.Items {
+			Eventually(func() (corev1.TaskState, error) {
+				t, err := tv.client.GetTask(context.Background(), target.Task)
+				return t.GetStatus().GetState(), err
+			}, 10*time.Second).Should(Equal(corev1.TaskState_Completed))
+		}
+		Expect(backend.maxActive()).To(Equal(1))
+	})
 })
+
+// slowUninstallBackend finishes uninstalling the capability some time after
+// the uninstall was requested, like backends which uninstall in a task.
+type slowUninstallBackend struct {
+	capabilityv1.BackendClient
+	name    string
+	storage storage.ClusterStore
+
+	mu     sync.Mutex
+	active int
+	max    int
+}
+
+// maxActive returns the largest number of uninstalls that were in progress
+// at the same time.
+func (b *slowUninstallBackend) maxActive() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.max
+}
+
+func (b *slowUninstallBackend) Uninstall(ctx context.Context, req *capabilityv1.UninstallRequest, _ ...grpc.CallOption) (*emptypb.Empty, error) {
+	_, err := b.storage.UpdateCluster(ctx, req.Cluster, func(c *corev1.Cluster) {
+		for _, cap := range c.GetCapabilities() {
+			if cap.Name == b.name {
+				cap.DeletionTimestamp = timestamppb.Now()
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	b.active++
+	if b.active > b.max {
+		b.max = b.active
+	}
+	b.mu.Unlock()
+	go func() {
+		time.Sleep(250 * time.Millisecond)
+		b.mu.Lock()
+		b.active--
+		b.mu.Unlock()
+		b.storage.UpdateCluster(context.Background(), req.Cluster,
+			storage.NewRemoveCapabilityMutator[*corev1.Cluster](capabilities.Cluster(b.name)))
+	}()
+	return &emptypb.Empty{}, nil
+}
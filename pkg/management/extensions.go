@@ -14,6 +14,7 @@ import (
 	"github.com/jhump/protoreflect/grpcreflect"
 	gsync "github.com/kralicky/gpkg/sync"
 	"github.com/kralicky/grpc-gateway/v2/runtime"
+	"github.com/samber/lo"
 	"go.uber.org/zap"
 	"google.golang.org/genproto/googleapis/api/annotations"
 	statuspb "google.golang.org/genproto/googleapis/rpc/status"
@@ -32,6 +33,7 @@ import (
 	"github.com/rancher/opni/pkg/plugins/hooks"
 	"github.com/rancher/opni/pkg/plugins/meta"
 	"github.com/rancher/opni/pkg/plugins/types"
+	"github.com/rancher/opni/pkg/rbac"
 )
 
 func (m *Server) APIExtensions(context.Context, *emptypb.Empty) (*managementv1.APIExtensionInfoList, error) {
@@ -58,6 +60,7 @@ type StreamDirector func(ctx context.Context, fullMethodName string) (context.Co
 func (m *Server) configureApiExtensionDirector(ctx context.Context, pl plugins.LoaderInterface) StreamDirector {
 	lg := m.logger
 	methodTable := gsync.Map[string, *UnknownStreamMetadata]{}
+	methodsByModule := gsync.Map[string, []string]{}
 	pl.Hook(hooks.OnLoadMC(func(p types.ManagementAPIExtensionPlugin, md meta.PluginMeta, cc *grpc.ClientConn) {
 		reflectClient := grpcreflect.NewClient(ctx, rpb.NewServerReflectionClient(cc))
 		sds, err := p.Descriptors(ctx, &emptypb.Empty{})
@@ -94,6 +97,8 @@ func (m *Server) configureApiExtensionDirector(ctx context.Context, pl plugins.L
 					InputType:  mtd.GetInputType(),
 					OutputType: mtd.GetOutputType(),
 				})
+				methods, _ := methodsByModule.Load(md.Module)
+				methodsByModule.Store(md.Module, append(methods, fullName))
 			}
 			httpRules := loadHttpRuleDescriptors(svcDesc)
 			if len(httpRules) > 0 {
@@ -114,6 +119,7 @@ func (m *Server) configureApiExtensionDirector(ctx context.Context, pl plugins.L
 			client := apiextensions.NewManagementAPIExtensionClient(cc)
 			m.apiExtMu.Lock()
 			m.apiExtensions = append(m.apiExtensions, apiExtension{
+				module:      md.Module,
 				client:      client,
 				clientConn:  cc,
 				serviceDesc: svcDesc,
@@ -121,6 +127,20 @@ func (m *Server) configureApiExtensionDirector(ctx context.Context, pl plugins.L
 			})
 			m.apiExtMu.Unlock()
 		}
+		m.reloadHttpApiExtensions()
+	}))
+	pl.Hook(hooks.OnUnload(func(md meta.PluginMeta) {
+		if methods, ok := methodsByModule.LoadAndDelete(md.Module); ok {
+			for _, fullName := range methods {
+				methodTable.Delete(fullName)
+			}
+		}
+		m.apiExtMu.Lock()
+		m.apiExtensions = lo.Reject(m.apiExtensions, func(ext apiExtension, _ int) bool {
+			return ext.module == md.Module
+		})
+		m.apiExtMu.Unlock()
+		m.reloadHttpApiExtensions()
 	}))
 
 	return func(ctx context.Context, fullMethodName string) (context.Context, *UnknownStreamMetadata, error) {
@@ -133,6 +153,17 @@ func (m *Server) configureApiExtensionDirector(ctx context.Context, pl plugins.L
 	}
 }
 
+// reloadHttpApiExtensions rebuilds the http gateway with the current set of
+// api extensions, if the http server is running.
+func (m *Server) reloadHttpApiExtensions() {
+	m.gatewayMuxMu.Lock()
+	defer m.gatewayMuxMu.Unlock()
+	if m.newGatewayMux == nil {
+		return
+	}
+	m.gatewayMux.Store(m.newGatewayMux())
+}
+
 func (m *Server) configureHttpApiExtensions(mux *runtime.ServeMux) {
 	lg := m.logger
 	m.apiExtMu.RLock()
@@ -161,7 +192,7 @@ func (m *Server) configureHttpApiExtensions(mux *runtime.ServeMux) {
 				path = rp.Patch
 			}
 			qualifiedPath := fmt.Sprintf("/%s%s", svcDesc.GetName(), path)
-			if err := mux.HandlePath(method, qualifiedPath, newHandler(stub, svcDesc, mux, rule, path)); err != nil {
+			if err := mux.HandlePath(method, qualifiedPath, newHandler(stub, svcDesc, mux, rule, path, m.authorizer)); err != nil {
 				lg.With(
 					zap.Error(err),
 					zap.String("method", method),
@@ -226,6 +257,7 @@ func newHandler(
 	mux *runtime.ServeMux,
 	rule *managementv1.HTTPRuleDescriptor,
 	path string,
+	authorizer *rbac.Authorizer,
 ) runtime.HandlerFunc {
 	lg := logger.New().Named("apiext")
 	return func(w http.ResponseWriter, req *http.Request, pathParams map[string]string) {
@@ -289,6 +321,16 @@ func newHandler(
 			}
 		}
 
+		// the stub calls the plugin directly, bypassing the management server
+		// interceptors, so the client is authorized here
+		if authorizer != nil {
+			if err := authorizer.AuthorizeHTTPRequest(req,
+				fmt.Sprintf("/%s/%s", svcDesc.GetFullyQualifiedName(), methodDesc.GetName()), reqMsg); err != nil {
+				runtime.HTTPError(ctx, mux, outboundMarshaler, w, req, err)
+				return
+			}
+		}
+
 		var metadata runtime.ServerMetadata
 		resp, err := stub.InvokeRpc(rctx, methodDesc, reqMsg,
 			grpc.Header(&metadata.HeaderMD), grpc.Trailer(&metadata.TrailerMD))
@@ -15,12 +15,15 @@ import (
 	"github.com/samber/lo"
 	"go.uber.org/atomic"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/descriptorpb"
 	"google.golang.org/protobuf/types/known/emptypb"
 
 	"github.com/rancher/opni/pkg/plugins"
 	"github.com/rancher/opni/pkg/plugins/apis/apiextensions"
+	"github.com/rancher/opni/pkg/plugins/hooks"
 	"github.com/rancher/opni/pkg/plugins/meta"
 	"github.com/rancher/opni/pkg/test"
 	mock_apiextensions "github.com/rancher/opni/pkg/test/mock/apiextensions"
@@ -45,12 +48,13 @@ type ext2SrvImpl struct {
 
 var _ = Describe("Extensions", Ordered, Label("slow"), func() {
 	var tv *testVars
+	var pl *plugins.PluginLoader
 	var descriptorLogic func() (*apiextensions.ServiceDescriptorProtoList, error)
 	shouldLoadExt1 := atomic.NewBool(true)
 	shouldLoadExt2 := atomic.NewBool(false)
 	JustBeforeEach(func() {
 		tv = &testVars{}
-		pl := plugins.NewPluginLoader()
+		pl = plugins.NewPluginLoader(plugins.WithDrainTimeout(time.Second))
 		tv.ctrl = gomock.NewController(GinkgoT())
 		extSrv := mock_ext.NewMockExtServer(tv.ctrl)
 		extSrv.EXPECT().
@@ -330,4 +334,99 @@ var _ = Describe("Extensions", Ordered, Label("slow"), func() {
 			Expect(extensions.Items[0].Rules).To(BeEmpty())
 		})
 	})
+	When("a plugin is reloaded", func() {
+		It("should forward calls to the new version of the plugin", func() {
+			extSrv := mock_ext.NewMockExtServer(tv.ctrl)
+			extSrv.EXPECT().
+				Foo(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, req *ext.FooRequest) (*ext.FooResponse, error) {
+					return &ext.FooResponse{
+						Response: "reloaded " + req.Request,
+					}, nil
+				}).
+				AnyTimes()
+			apiextSrv := &apiExtensionSrvImpl{
+				MockManagementAPIExtensionServer: mock_apiextensions.NewMockManagementAPIExtensionServer(tv.ctrl),
+			}
+			serviceDescriptor, err := grpcreflect.LoadServiceDescriptor(&ext.Ext_ServiceDesc)
+			Expect(err).NotTo(HaveOccurred())
+			apiextSrv.EXPECT().
+				Descriptors(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(context.Context, *emptypb.Empty) (*apiextensions.ServiceDescriptorProtoList, error) {
+					fqn := serviceDescriptor.GetFullyQualifiedName()
+					sd := serviceDescriptor.AsServiceDescriptorProto()
+					sd.Name = &fqn
+					return &apiextensions.ServiceDescriptorProtoList{
+						Items: []*descriptorpb.ServiceDescriptorProto{sd},
+					}, nil
+				})
+
+			events := make(chan hooks.PluginEvent, 10)
+			pl.Hook(hooks.OnPluginEvent(func(event hooks.PluginEvent) {
+				events <- event
+			}))
+
+			cc := test.NewApiExtensionTestPlugin(apiextSrv, &ext.Ext_ServiceDesc, &extSrvImpl{
+				MockExtServer: extSrv,
+			})
+			Expect(pl.Reload(context.Background(), meta.PluginMeta{
+				BinaryPath: "test1",
+				GoVersion:  "test1",
+				Module:     "test1",
+			}, cc)).To(Succeed())
+
+			var event hooks.PluginEvent
+			Eventually(events).Should(Receive(&event))
+			Expect(event.Type).To(Equal(hooks.PluginReloaded))
+			Expect(event.Plugin.Module).To(Equal("test1"))
+
+			extensions, err := tv.client.APIExtensions(context.Background(), &emptypb.Empty{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(extensions.Items).To(HaveLen(1))
+
+			grpcCC, err := grpc.Dial(tv.grpcEndpoint,
+				grpc.WithTransportCredentials(insecure.NewCredentials()),
+				grpc.WithBlock(),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			defer grpcCC.Close()
+			client := ext.NewExtClient(grpcCC)
+			resp, err := client.Foo(context.Background(), &ext.FooRequest{
+				Request: "hello",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Response).To(Equal("reloaded hello"))
+
+			Eventually(func() (string, error) {
+				resp, err := http.Post(tv.httpEndpoint+"/Ext/foo",
+					"application/json", strings.NewReader(`{"request": "hello"}`))
+				if err != nil {
+					return "", err
+				}
+				defer resp.Body.Close()
+				body, err := io.ReadAll(resp.Body)
+				return string(body), err
+			}).Should(Equal(`{"response":"reloaded hello"}`))
+
+			By("unloading the plugin")
+			pl.Unload("test1")
+			Eventually(events).Should(Receive(&event))
+			Expect(event.Type).To(Equal(hooks.PluginRemoved))
+
+			extensions, err = tv.client.APIExtensions(context.Background(), &emptypb.Empty{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(extensions.Items).To(BeEmpty())
+
+			_, err = client.Foo(context.Background(), &ext.FooRequest{
+				Request: "hello",
+			})
+			Expect(status.Code(err)).To(Equal(codes.Unimplemented))
+
+			httpResp, err := http.Post(tv.httpEndpoint+"/Ext/foo",
+				"application/json", strings.NewReader(`{"request": "hello"}`))
+			Expect(err).NotTo(HaveOccurred())
+			httpResp.Body.Close()
+			Expect(httpResp.StatusCode).To(Equal(http.StatusNotFound))
+		})
+	})
 })
@@ -0,0 +1,38 @@
+package management
+
+import (
+	"context"
+	"time"
+
+	managementv1 "github.com/rancher/opni/pkg/apis/management/v1"
+	"github.com/rancher/opni/pkg/validation"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const defaultKeyringGracePeriod = 1 * time.Hour
+
+func (m *Server) RotateClusterKeyring(
+	ctx context.Context,
+	in *managementv1.RotateClusterKeyringRequest,
+) (*emptypb.Empty, error) {
+	if m.keyringDataSource == nil {
+		return nil, status.Error(codes.Unavailable, "keyring API not configured")
+	}
+	if err := validation.Validate(in); err != nil {
+		return nil, err
+	}
+	if err := m.ensureReferenceResolved(ctx, in.Cluster); err != nil {
+		return nil, err
+	}
+
+	gracePeriod := defaultKeyringGracePeriod
+	if in.GracePeriod != nil {
+		gracePeriod = in.GetGracePeriod().AsDuration()
+	}
+	if err := m.keyringDataSource.RotateClusterKeyring(ctx, in.Cluster, gracePeriod); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,103 @@
+package management_test
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	managementv1 "github.com/rancher/opni/pkg/apis/management/v1"
+	"github.com/rancher/opni/pkg/management"
+	"github.com/rancher/opni/pkg/plugins"
+)
+
+type rotateKeyringCall struct {
+	id          string
+	gracePeriod time.Duration
+}
+
+type testKeyringDataSource struct {
+	mu    sync.Mutex
+	calls []rotateKeyringCall
+	err   error
+}
+
+func (t *testKeyringDataSource) RotateClusterKeyring(_ context.Context, ref *corev1.Reference, gracePeriod time.Duration) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.calls = append(t.calls, rotateKeyringCall{
+		id:          ref.GetId(),
+		gracePeriod: gracePeriod,
+	})
+	return t.err
+}
+
+func (t *testKeyringDataSource) Calls() []rotateKeyringCall {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]rotateKeyringCall(nil), t.calls...)
+}
+
+var _ = Describe("Keyring", Ordered, Label("slow"), func() {
+	var tv *testVars
+	keyringDataSource := &testKeyringDataSource{}
+	BeforeAll(func() {
+		setupManagementServer(&tv, plugins.NoopLoader, management.WithKeyringDataSource(keyringDataSource))()
+
+		Expect(tv.storageBackend.CreateCluster(context.Background(), &corev1.Cluster{
+			Id: "foo",
+		})).To(Succeed())
+	})
+
+	It("should rotate cluster keyrings using the default grace period", func() {
+		_, err := tv.client.RotateClusterKeyring(context.Background(), &managementv1.RotateClusterKeyringRequest{
+			Cluster: &corev1.Reference{Id: "foo"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(keyringDataSource.Calls()).To(ConsistOf(rotateKeyringCall{
+			id:          "foo",
+			gracePeriod: 1 * time.Hour,
+		}))
+	})
+	It("should rotate cluster keyrings using the requested grace period", func() {
+		_, err := tv.client.RotateClusterKeyring(context.Background(), &managementv1.RotateClusterKeyringRequest{
+			Cluster:     &corev1.Reference{Id: "foo"},
+			GracePeriod: durationpb.New(5 * time.Minute),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(keyringDataSource.Calls()).To(HaveLen(2))
+		Expect(keyringDataSource.Calls()[1]).To(Equal(rotateKeyringCall{
+			id:          "foo",
+			gracePeriod: 5 * time.Minute,
+		}))
+	})
+	It("should reject invalid requests", func() {
+		_, err := tv.client.RotateClusterKeyring(context.Background(), &managementv1.RotateClusterKeyringRequest{
+			Cluster:     &corev1.Reference{Id: "foo"},
+			GracePeriod: durationpb.New(-1 * time.Minute),
+		})
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+
+		_, err = tv.client.RotateClusterKeyring(context.Background(), &managementv1.RotateClusterKeyringRequest{
+			Cluster: &corev1.Reference{Id: "bar"},
+		})
+		Expect(status.Code(err)).To(Equal(codes.NotFound))
+		Expect(keyringDataSource.Calls()).To(HaveLen(2))
+	})
+	It("should return errors from the keyring data source", func() {
+		keyringDataSource.mu.Lock()
+		keyringDataSource.err = status.Error(codes.Unavailable, "agent is not connected")
+		keyringDataSource.mu.Unlock()
+
+		_, err := tv.client.RotateClusterKeyring(context.Background(), &managementv1.RotateClusterKeyringRequest{
+			Cluster: &corev1.Reference{Id: "foo"},
+		})
+		Expect(status.Code(err)).To(Equal(codes.Unavailable))
+	})
+})
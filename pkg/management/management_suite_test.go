@@ -4,6 +4,8 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -54,6 +56,14 @@ func (t *testCoreDataSource) TLSConfig() *tls.Config {
 }
 
 func setupManagementServer(vars **testVars, pl plugins.LoaderInterface, opts ...management.ManagementServerOption) func() {
+	return setupManagementServerWithClientAuth(vars, pl, false, opts...)
+}
+
+// setupManagementServerWithClientAuth optionally enables client certificate
+// authentication. Clients must then present a certificate signed by
+// root_ca.crt, and the certificate of opni-proxy is a trusted proxy. The
+// client in testVars connects to the local listener.
+func setupManagementServerWithClientAuth(vars **testVars, pl plugins.LoaderInterface, clientAuth bool, opts ...management.ManagementServerOption) func() {
 	return func() {
 		tv := &testVars{}
 		if *vars != nil && (*vars).ctrl != nil {
@@ -69,6 +79,22 @@ func setupManagementServer(vars **testVars, pl plugins.LoaderInterface, opts ...
 			GRPCListenAddress: fmt.Sprintf("tcp://127.0.0.1:%d", ports[0]),
 			HTTPListenAddress: fmt.Sprintf("127.0.0.1:%d", ports[1]),
 		}
+		clientAddress := fmt.Sprintf("127.0.0.1:%d", ports[0])
+		httpScheme := "http"
+		if clientAuth {
+			dir, err := os.MkdirTemp("", "opni-management-test")
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(os.RemoveAll, dir)
+			caPath := filepath.Join(dir, "root_ca.crt")
+			Expect(os.WriteFile(caPath, test.TestData("root_ca.crt"), 0o600)).To(Succeed())
+			conf.ClientAuth = &v1beta1.ManagementClientAuthSpec{
+				ClientCA:           caPath,
+				TrustedProxies:     []string{"opni-proxy"},
+				LocalListenAddress: "unix://" + filepath.Join(dir, "management.sock"),
+			}
+			clientAddress = conf.ClientAuth.LocalListenAddress
+			httpScheme = "https"
+		}
 		cert, err := tls.X509KeyPair(test.TestData("localhost.crt"), test.TestData("localhost.key"))
 		Expect(err).NotTo(HaveOccurred())
 		cds := &testCoreDataSource{
@@ -87,12 +113,12 @@ func setupManagementServer(vars **testVars, pl plugins.LoaderInterface, opts ...
 			}
 		}))
 		tv.client, err = management.NewClient(ctx,
-			management.WithListenAddress(fmt.Sprintf("127.0.0.1:%d", ports[0])),
+			management.WithListenAddress(clientAddress),
 			management.WithDialOptions(grpc.WithDefaultCallOptions(grpc.WaitForReady(true))),
 		)
 		Expect(err).NotTo(HaveOccurred())
 		tv.grpcEndpoint = fmt.Sprintf("127.0.0.1:%d", ports[0])
-		tv.httpEndpoint = fmt.Sprintf("http://127.0.0.1:%d", ports[1])
+		tv.httpEndpoint = fmt.Sprintf("%s://127.0.0.1:%d", httpScheme, ports[1])
 		*vars = tv
 		DeferCleanup(func() {
 			ca()
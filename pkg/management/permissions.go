@@ -0,0 +1,238 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jhump/protoreflect/dynamic"
+	"google.golang.org/grpc/codes"
+
+	capabilityv1 "github.com/rancher/opni/pkg/apis/capability/v1"
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	managementv1 "github.com/rancher/opni/pkg/apis/management/v1"
+	"github.com/rancher/opni/pkg/rbac"
+	"github.com/rancher/opni/pkg/util"
+)
+
+func perm(verb corev1.Verb, resource corev1.ResourceKind) *rbac.Permission {
+	return &rbac.Permission{
+		Verb:     verb,
+		Resource: resource,
+	}
+}
+
+// Permissions required by the methods of the management API, by method name.
+// Methods mapped to nil are available to every subject.
+var managementPermissions = map[string]*rbac.Permission{
+	"CreateBootstrapToken":          perm(corev1.VerbWrite, corev1.ResourceTokens),
+	"RevokeBootstrapToken":          perm(corev1.VerbWrite, corev1.ResourceTokens),
+	"ListBootstrapTokens":           perm(corev1.VerbRead, corev1.ResourceTokens),
+	"GetBootstrapToken":             perm(corev1.VerbRead, corev1.ResourceTokens),
+	"ListClusters":                  perm(corev1.VerbRead, corev1.ResourceClusters),
+	"WatchClusters":                 perm(corev1.VerbRead, corev1.ResourceClusters),
+	"DeleteCluster":                 perm(corev1.VerbAdmin, corev1.ResourceClusters),
+	"CertsInfo":                     perm(corev1.VerbRead, corev1.ResourceClusters),
+	"GetCluster":                    perm(corev1.VerbRead, corev1.ResourceClusters),
+	"GetClusterHealthStatus":        perm(corev1.VerbRead, corev1.ResourceClusters),
+	"WatchClusterHealthStatus":      perm(corev1.VerbRead, corev1.ResourceClusters),
+	"EditCluster":                   perm(corev1.VerbWrite, corev1.ResourceClusters),
+	"RotateClusterKeyring":          perm(corev1.VerbAdmin, corev1.ResourceClusters),
+	"CreateRole":                    perm(corev1.VerbAdmin, corev1.ResourceRoles),
+	"DeleteRole":                    perm(corev1.VerbAdmin, corev1.ResourceRoles),
+	"GetRole":                       perm(corev1.VerbRead, corev1.ResourceRoles),
+	"CreateRoleBinding":             perm(corev1.VerbAdmin, corev1.ResourceRoles),
+	"DeleteRoleBinding":             perm(corev1.VerbAdmin, corev1.ResourceRoles),
+	"GetRoleBinding":                perm(corev1.VerbRead, corev1.ResourceRoles),
+	"ListRoles":                     perm(corev1.VerbRead, corev1.ResourceRoles),
+	"ListRoleBindings":              perm(corev1.VerbRead, corev1.ResourceRoles),
+	"SubjectAccess":                 perm(corev1.VerbRead, corev1.ResourceRoles),
+	"APIExtensions":                 nil,
+	"GetConfig":                     perm(corev1.VerbAdmin, corev1.ResourceAll),
+	"UpdateConfig":                  perm(corev1.VerbAdmin, corev1.ResourceAll),
+	"ListCapabilities":              perm(corev1.VerbRead, corev1.ResourceClusters),
+	"CapabilityInstaller":           perm(corev1.VerbRead, corev1.ResourceClusters),
+	"InstallCapability":             perm(corev1.VerbAdmin, corev1.ResourceClusters),
+	"UninstallCapability":           perm(corev1.VerbAdmin, corev1.ResourceClusters),
+	"CapabilityUninstallStatus":     perm(corev1.VerbRead, corev1.ResourceClusters),
+	"CancelCapabilityUninstall":     perm(corev1.VerbAdmin, corev1.ResourceClusters),
+	"PlanCapabilityInstall":         perm(corev1.VerbRead, corev1.ResourceClusters),
+	"PlanCapabilityUninstall":       perm(corev1.VerbRead, corev1.ResourceClusters),
+	"BulkInstallCapability":         perm(corev1.VerbAdmin, corev1.ResourceClusters),
+	"BulkUninstallCapability":       perm(corev1.VerbAdmin, corev1.ResourceClusters),
+	"BulkCapabilityUninstallStatus": perm(corev1.VerbRead, corev1.ResourceClusters),
+	"ListTasks":                     perm(corev1.VerbRead, corev1.ResourceClusters),
+	"GetTask":                       perm(corev1.VerbRead, corev1.ResourceClusters),
+	"WatchTasks":                    perm(corev1.VerbRead, corev1.ResourceClusters),
+	"CancelTask":                    perm(corev1.VerbAdmin, corev1.ResourceClusters),
+	"PruneTasks":                    perm(corev1.VerbAdmin, corev1.ResourceClusters),
+	"GetDashboardSettings":          nil,
+	"UpdateDashboardSettings":       perm(corev1.VerbAdmin, corev1.ResourceAll),
+}
+
+// Resource kinds of the services served by plugin API extensions, by package
+// prefix. The most specific prefix wins.
+var apiExtensionResources = map[string]corev1.ResourceKind{
+	"slo.":          corev1.ResourceSLOs,
+	"alerting.":     corev1.ResourceAlertConditions,
+	"alerting.slo.": corev1.ResourceSLOs,
+	"cortexadmin.":  corev1.ResourceMetrics,
+	"cortexops.":    corev1.ResourceMetrics,
+	"loggingadmin.": corev1.ResourceLogs,
+	"opensearch.":   corev1.ResourceLogs,
+}
+
+// Services of API extensions which install or configure backends, whose
+// mutating methods require the admin verb.
+var apiExtensionAdminServices = map[string]struct{}{
+	"cortexops.CortexOps":          {},
+	"alerting.ops.AlertingAdmin":   {},
+	"alerting.ops.DynamicAlerting": {},
+	"loggingadmin.LoggingAdmin":    {},
+	"loggingadmin.LoggingAdminV2":  {},
+	"opensearch.Opensearch":        {},
+}
+
+var readOnlyMethodPrefixes = []string{
+	"Get", "List", "Watch", "Query", "Fetch", "Extract", "Preview", "Timeline", "Info", "All",
+}
+
+// methodPermission resolves the permission required to call methods of the
+// management API and of plugin API extensions. Methods the resolver knows
+// nothing about require admin access to every resource.
+func methodPermission(fullMethod string) *rbac.Permission {
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok {
+		return perm(corev1.VerbAdmin, corev1.ResourceAll)
+	}
+	switch service {
+	case managementv1.Management_ServiceDesc.ServiceName:
+		if p, ok := managementPermissions[method]; ok {
+			return p
+		}
+		return perm(corev1.VerbAdmin, corev1.ResourceAll)
+	case capabilityv1.NodeManager_ServiceDesc.ServiceName:
+		return perm(corev1.VerbWrite, corev1.ResourceClusters)
+	}
+	var resource corev1.ResourceKind
+	longestPrefix := 0
+	for prefix, kind := range apiExtensionResources {
+		if strings.HasPrefix(service, prefix) && len(prefix) > longestPrefix {
+			resource, longestPrefix = kind, len(prefix)
+		}
+	}
+	if resource == "" {
+		return perm(corev1.VerbAdmin, corev1.ResourceAll)
+	}
+	if strings.HasSuffix(method, "Status") {
+		return perm(corev1.VerbRead, resource)
+	}
+	for _, prefix := range readOnlyMethodPrefixes {
+		if strings.HasPrefix(method, prefix) {
+			return perm(corev1.VerbRead, resource)
+		}
+	}
+	if _, ok := apiExtensionAdminServices[service]; ok {
+		return perm(corev1.VerbAdmin, resource)
+	}
+	return perm(corev1.VerbWrite, resource)
+}
+
+// Methods of the management API which take a reference to a cluster.
+var clusterReferenceMethods = map[string]struct{}{
+	"DeleteCluster":          {},
+	"GetCluster":             {},
+	"GetClusterHealthStatus": {},
+}
+
+// Getters of API extension objects which belong to clusters, by service.
+// Requests to these services which reference an existing object by ID also
+// act on the clusters of the object.
+var apiExtensionObjectGetters = map[string]string{
+	"slo.SLO":                            "GetSLO",
+	"alerting.slo.SLO":                   "GetSLO",
+	"alerting.condition.AlertConditions": "GetAlertCondition",
+}
+
+// requestTargets finds the clusters a request to the management API or to a
+// plugin API extension acts on.
+func (m *Server) requestTargets(ctx context.Context, fullMethod string, req any) (rbac.Targets, error) {
+	targets, err := rbac.TargetsFromMessage(req)
+	if err != nil {
+		return rbac.Targets{}, err
+	}
+	service, method, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if service == managementv1.Management_ServiceDesc.ServiceName {
+		if _, ok := clusterReferenceMethods[method]; ok {
+			if ref, ok := req.(*corev1.Reference); ok && ref.GetId() != "" {
+				targets.Add(rbac.Targets{Clusters: []string{ref.GetId()}})
+			}
+		}
+		return targets, nil
+	}
+	getter, ok := apiExtensionObjectGetters[service]
+	if !ok {
+		return targets, nil
+	}
+	id, err := referencedObjectID(req)
+	if err != nil || id == "" {
+		return targets, err
+	}
+	objectTargets, err := m.apiExtensionObjectTargets(ctx, fmt.Sprintf("/%s/%s", service, getter), id)
+	if err != nil {
+		return rbac.Targets{}, err
+	}
+	targets.Add(objectTargets)
+	return targets, nil
+}
+
+// referencedObjectID returns the ID of the existing object referenced by a
+// request to an API extension, if any.
+func referencedObjectID(req any) (string, error) {
+	marshaler, ok := req.(json.Marshaler)
+	if !ok {
+		return "", nil
+	}
+	data, err := marshaler.MarshalJSON()
+	if err != nil {
+		return "", err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return "", nil
+	}
+	for _, key := range []string{"id", "conditionId", "cloneId"} {
+		switch v := fields[key].(type) {
+		case string:
+			return v, nil
+		case map[string]any:
+			if id, ok := v["id"].(string); ok {
+				return id, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// apiExtensionObjectTargets looks up an API extension object by ID with the
+// given getter, and returns the clusters it belongs to. Objects which do not
+// exist belong to no clusters.
+func (m *Server) apiExtensionObjectTargets(ctx context.Context, getter string, id string) (rbac.Targets, error) {
+	outgoingCtx, meta, err := m.director(ctx, getter)
+	if err != nil {
+		return rbac.Targets{}, err
+	}
+	request := dynamic.NewMessage(meta.InputType)
+	if err := request.TrySetFieldByName("id", id); err != nil {
+		return rbac.Targets{}, err
+	}
+	reply := dynamic.NewMessage(meta.OutputType)
+	if err := meta.Conn.Invoke(outgoingCtx, getter, request, reply); err != nil {
+		if util.StatusCode(err) == codes.NotFound {
+			return rbac.Targets{}, nil
+		}
+		return rbac.Targets{}, err
+	}
+	return rbac.TargetsFromMessage(reply)
+}
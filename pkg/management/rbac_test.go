@@ -2,22 +2,38 @@ package management_test
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"fmt"
+	"math/big"
+	"net/http"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	managementv1 "github.com/rancher/opni/pkg/apis/management/v1"
 	"github.com/rancher/opni/pkg/plugins"
+	"github.com/rancher/opni/pkg/rbac"
+	"github.com/rancher/opni/pkg/test"
 	"github.com/rancher/opni/pkg/util"
 	"github.com/rancher/opni/pkg/validation"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
 var _ = Describe("RBAC", Ordered, Label("slow"), func() {
 	var tv *testVars
-	BeforeAll(setupManagementServer(&tv, plugins.NoopLoader))
+	BeforeAll(setupManagementServerWithClientAuth(&tv, plugins.NoopLoader, true))
 
 	It("should initially have no RBAC objects", func() {
 		roles, err := tv.client.ListRoles(context.Background(), &emptypb.Empty{})
@@ -110,6 +126,172 @@ var _ = Describe("RBAC", Ordered, Label("slow"), func() {
 		}
 	})
 
+	When("clients are authenticated with certificates", func() {
+		newClient := func(cert tls.Certificate) managementv1.ManagementClient {
+			cc, err := grpc.Dial(tv.grpcEndpoint,
+				grpc.WithTransportCredentials(credentials.NewTLS(clientTLSConfig(cert))),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(cc.Close)
+			return managementv1.NewManagementClient(cc)
+		}
+		withSubject := func(subject string) context.Context {
+			return metadata.AppendToOutgoingContext(context.Background(), rbac.SubjectMetadataKey, subject)
+		}
+		var leaf, proxy managementv1.ManagementClient
+		BeforeAll(func() {
+			cert, err := tls.X509KeyPair(test.TestData("localhost.crt"), test.TestData("localhost.key"))
+			Expect(err).NotTo(HaveOccurred())
+			leaf = newClient(cert)
+			proxy = newClient(newClientCert("opni-proxy"))
+
+			_, err = tv.client.CreateRole(context.Background(), &corev1.Role{
+				Id:        "token-editor",
+				Verbs:     []string{string(corev1.VerbWrite)},
+				Resources: []string{string(corev1.ResourceTokens)},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = tv.client.CreateRoleBinding(context.Background(), &corev1.RoleBinding{
+				Id:       "token-editors",
+				RoleId:   "token-editor",
+				Subjects: []string{"leaf", "app-team"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(func() {
+				tv.client.DeleteRoleBinding(context.Background(), &corev1.Reference{Id: "token-editors"})
+				tv.client.DeleteRole(context.Background(), &corev1.Reference{Id: "token-editor"})
+			})
+		})
+		It("should allow methods granted by the subject's roles", func() {
+			_, err := leaf.ListBootstrapTokens(context.Background(), &emptypb.Empty{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("should deny methods not granted by the subject's roles", func() {
+			_, err := leaf.ListClusters(context.Background(), &managementv1.ListClustersRequest{})
+			Expect(util.StatusCode(err)).To(Equal(codes.PermissionDenied))
+			_, err = leaf.DeleteRole(context.Background(), &corev1.Reference{Id: "token-editor"})
+			Expect(util.StatusCode(err)).To(Equal(codes.PermissionDenied))
+		})
+		It("should ignore subjects set by clients which are not trusted proxies", func() {
+			_, err := leaf.ListClusters(withSubject("admin"), &managementv1.ListClustersRequest{})
+			Expect(util.StatusCode(err)).To(Equal(codes.PermissionDenied))
+		})
+		It("should use subjects set by trusted proxies", func() {
+			_, err := proxy.ListBootstrapTokens(withSubject("app-team"), &emptypb.Empty{})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = proxy.ListBootstrapTokens(withSubject("someone-else"), &emptypb.Empty{})
+			Expect(util.StatusCode(err)).To(Equal(codes.PermissionDenied))
+			_, err = proxy.ListBootstrapTokens(withSubject(rbac.LocalSubject), &emptypb.Empty{})
+			Expect(util.StatusCode(err)).To(Equal(codes.PermissionDenied))
+		})
+		It("should reject clients without a certificate", func() {
+			cc, err := grpc.Dial(tv.grpcEndpoint,
+				grpc.WithTransportCredentials(credentials.NewTLS(clientTLSConfig())),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			defer cc.Close()
+			_, err = managementv1.NewManagementClient(cc).ListBootstrapTokens(context.Background(), &emptypb.Empty{})
+			Expect(util.StatusCode(err)).To(Equal(codes.Unavailable))
+
+			cc, err = grpc.Dial(tv.grpcEndpoint,
+				grpc.WithTransportCredentials(insecure.NewCredentials()),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			defer cc.Close()
+			_, err = managementv1.NewManagementClient(cc).ListBootstrapTokens(withSubject("app-team"), &emptypb.Empty{})
+			Expect(util.StatusCode(err)).To(Equal(codes.Unavailable))
+		})
+		It("should authenticate http clients", func() {
+			get := func(client *http.Client, path string, header http.Header) int {
+				req, err := http.NewRequest(http.MethodGet, tv.httpEndpoint+path, nil)
+				Expect(err).NotTo(HaveOccurred())
+				for k, v := range header {
+					req.Header[k] = v
+				}
+				resp, err := client.Do(req)
+				Expect(err).NotTo(HaveOccurred())
+				resp.Body.Close()
+				return resp.StatusCode
+			}
+			cert, err := tls.X509KeyPair(test.TestData("localhost.crt"), test.TestData("localhost.key"))
+			Expect(err).NotTo(HaveOccurred())
+			client := &http.Client{
+				Transport: &http.Transport{
+					TLSClientConfig: clientTLSConfig(cert),
+				},
+			}
+			Eventually(func() int {
+				return get(client, "/management/tokens", nil)
+			}).Should(Equal(http.StatusOK))
+			Expect(get(client, "/management/clusters", nil)).To(Equal(http.StatusForbidden))
+			Expect(get(client, "/management/clusters", http.Header{
+				"X-Opni-Rbac-Subject":               {"admin"},
+				"Grpc-Metadata-X-Opni-Rbac-Subject": {"admin"},
+			})).To(Equal(http.StatusForbidden))
+
+			_, err = (&http.Client{
+				Transport: &http.Transport{
+					TLSClientConfig: clientTLSConfig(),
+				},
+			}).Get(tv.httpEndpoint + "/management/tokens")
+			Expect(err).To(HaveOccurred())
+		})
+		When("a role is limited to some clusters", func() {
+			BeforeAll(func() {
+				for _, cluster := range []*corev1.Cluster{
+					{Id: "rbac-cluster-1"},
+					{Id: "rbac-cluster-2", Metadata: &corev1.ClusterMetadata{Labels: map[string]string{"env": "dev"}}},
+					{Id: "rbac-cluster-3"},
+				} {
+					Expect(tv.storageBackend.CreateCluster(context.Background(), cluster)).To(Succeed())
+				}
+				_, err := tv.client.CreateRole(context.Background(), &corev1.Role{
+					Id:         "cluster-editor",
+					Verbs:      []string{string(corev1.VerbRead), string(corev1.VerbWrite)},
+					Resources:  []string{string(corev1.ResourceClusters)},
+					ClusterIDs: []string{"rbac-cluster-1"},
+					MatchLabels: &corev1.LabelSelector{
+						MatchLabels: map[string]string{"env": "dev"},
+					},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				_, err = tv.client.CreateRoleBinding(context.Background(), &corev1.RoleBinding{
+					Id:       "cluster-editors",
+					RoleId:   "cluster-editor",
+					Subjects: []string{"leaf"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				DeferCleanup(func() {
+					tv.client.DeleteRoleBinding(context.Background(), &corev1.Reference{Id: "cluster-editors"})
+					tv.client.DeleteRole(context.Background(), &corev1.Reference{Id: "cluster-editor"})
+				})
+			})
+			It("should allow requests for clusters matching the role", func() {
+				_, err := leaf.GetCluster(context.Background(), &corev1.Reference{Id: "rbac-cluster-1"})
+				Expect(err).NotTo(HaveOccurred())
+				_, err = leaf.EditCluster(context.Background(), &managementv1.EditClusterRequest{
+					Cluster: &corev1.Reference{Id: "rbac-cluster-1"},
+					Labels:  map[string]string{"foo": "bar"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				_, err = leaf.EditCluster(context.Background(), &managementv1.EditClusterRequest{
+					Cluster: &corev1.Reference{Id: "rbac-cluster-2"},
+					Labels:  map[string]string{"env": "dev", "foo": "bar"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("should deny requests for other clusters", func() {
+				_, err := leaf.GetCluster(context.Background(), &corev1.Reference{Id: "rbac-cluster-3"})
+				Expect(util.StatusCode(err)).To(Equal(codes.PermissionDenied))
+				_, err = leaf.EditCluster(context.Background(), &managementv1.EditClusterRequest{
+					Cluster: &corev1.Reference{Id: "rbac-cluster-3"},
+					Labels:  map[string]string{"foo": "bar"},
+				})
+				Expect(util.StatusCode(err)).To(Equal(codes.PermissionDenied))
+			})
+		})
+	})
+
 	Context("error handling", func() {
 		When("creating a rolebinding with taints", func() {
 			It("should error indicating the field is read-only", func() {
@@ -126,3 +308,38 @@ var _ = Describe("RBAC", Ordered, Label("slow"), func() {
 		})
 	})
 })
+
+func clientTLSConfig(certs ...tls.Certificate) *tls.Config {
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(test.TestData("root_ca.crt"))
+	return &tls.Config{
+		RootCAs:      pool,
+		ServerName:   "localhost",
+		Certificates: certs,
+	}
+}
+
+// newClientCert returns a client certificate with the given common name,
+// signed by root_ca.crt.
+func newClientCert(commonName string) tls.Certificate {
+	ca, err := tls.X509KeyPair(test.TestData("root_ca.crt"), test.TestData("root_ca.key"))
+	Expect(err).NotTo(HaveOccurred())
+	caCert, err := x509.ParseCertificate(ca.Certificate[0])
+	Expect(err).NotTo(HaveOccurred())
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, key.Public(), ca.PrivateKey)
+	Expect(err).NotTo(HaveOccurred())
+	return tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+	}
+}
@@ -9,8 +9,10 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jhump/protoreflect/desc"
@@ -20,7 +22,9 @@ import (
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
 
@@ -39,6 +43,7 @@ import (
 	"github.com/rancher/opni/pkg/plugins/types"
 	"github.com/rancher/opni/pkg/rbac"
 	"github.com/rancher/opni/pkg/storage"
+	"github.com/rancher/opni/pkg/task"
 	"github.com/rancher/opni/pkg/util"
 )
 
@@ -61,7 +66,19 @@ type HealthStatusDataSource interface {
 	WatchClusterHealthStatus(ctx context.Context) <-chan *corev1.ClusterHealthStatus
 }
 
+// KeyringDataSource provides a way to rotate the keyrings of connected agents
+type KeyringDataSource interface {
+	RotateClusterKeyring(ctx context.Context, ref *corev1.Reference, gracePeriod time.Duration) error
+}
+
+// TasksDataSource provides access to the key-value stores in which plugins
+// persist the status of their tasks, by plugin namespace
+type TasksDataSource interface {
+	TaskStores() map[string]storage.KeyValueStore
+}
+
 type apiExtension struct {
+	module      string
 	client      apiextensions.ManagementAPIExtensionClient
 	clientConn  *grpc.ClientConn
 	serviceDesc *desc.ServiceDescriptor
@@ -74,12 +91,29 @@ type Server struct {
 	config            *v1beta1.ManagementSpec
 	logger            *zap.SugaredLogger
 	rbacProvider      rbac.Provider
+	authorizer        *rbac.Authorizer
+	director          StreamDirector
 	coreDataSource    CoreDataSource
 	grpcServer        *grpc.Server
 	dashboardSettings *DashboardSettingsManager
 
+	// tls config of the grpc and http servers when client authentication is
+	// enabled, otherwise nil
+	tlsConfig *tls.Config
+
 	apiExtMu      sync.RWMutex
 	apiExtensions []apiExtension
+
+	// The http gateway is rebuilt whenever api extensions are reloaded,
+	// since routes cannot be removed from a ServeMux.
+	gatewayMuxMu  sync.Mutex
+	gatewayMux    atomic.Pointer[runtime.ServeMux]
+	newGatewayMux func() *runtime.ServeMux
+
+	// tasks owned by the management server, such as bulk capability
+	// operations, are stored in this namespace
+	managementTasks storage.KeyValueStore
+	capabilityTasks *task.Controller
 }
 
 var _ managementv1.ManagementServer = (*Server)(nil)
@@ -88,6 +122,8 @@ type managementServerOptions struct {
 	lifecycler             config.Lifecycler
 	capabilitiesDataSource CapabilitiesDataSource
 	healthStatusDataSource HealthStatusDataSource
+	keyringDataSource      KeyringDataSource
+	tasksDataSource        TasksDataSource
 }
 
 type ManagementServerOption func(*managementServerOptions)
@@ -116,6 +152,18 @@ func WithHealthStatusDataSource(src HealthStatusDataSource) ManagementServerOpti
 	}
 }
 
+func WithKeyringDataSource(src KeyringDataSource) ManagementServerOption {
+	return func(o *managementServerOptions) {
+		o.keyringDataSource = src
+	}
+}
+
+func WithTasksDataSource(src TasksDataSource) ManagementServerOption {
+	return func(o *managementServerOptions) {
+		o.tasksDataSource = src
+	}
+}
+
 func NewServer(
 	ctx context.Context,
 	conf *v1beta1.ManagementSpec,
@@ -139,12 +187,46 @@ func NewServer(
 		},
 	}
 
-	director := m.configureApiExtensionDirector(ctx, pluginLoader)
+	m.managementTasks = cds.StorageBackend().KeyValueStore(TaskNamespace)
+	capabilityTasks, err := task.NewController(ctx, capabilityTaskController,
+		storage.NewProtoKeyValueStore[*corev1.TaskStatus](m.managementTasks),
+		&capabilityTaskRunner{server: m},
+	)
+	if err != nil {
+		lg.With(
+			zap.Error(err),
+		).Error("failed to start capability task controller, bulk capability operations will be unavailable")
+	} else {
+		m.capabilityTasks = capabilityTasks
+	}
+	m.startTaskPruner(ctx)
+
+	m.director = m.configureApiExtensionDirector(ctx, pluginLoader)
+	streamInterceptors := []grpc.StreamServerInterceptor{otelgrpc.StreamServerInterceptor()}
+	unaryInterceptors := []grpc.UnaryServerInterceptor{otelgrpc.UnaryServerInterceptor()}
+	creds := insecure.NewCredentials()
+	if conf.ClientAuth != nil {
+		m.tlsConfig, err = clientAuthTLSConfig(cds.TLSConfig(), conf.ClientAuth)
+		if err != nil {
+			lg.With(
+				zap.Error(err),
+			).Panic("failed to configure management API client authentication")
+		}
+		creds = rbac.NewLocalOrTLSCredentials(credentials.NewTLS(m.tlsConfig))
+		m.authorizer = rbac.NewAuthorizer(m.rbacProvider, methodPermission,
+			rbac.WithTrustedProxies(conf.ClientAuth.TrustedProxies...),
+			rbac.WithTargetResolver(m.requestTargets),
+		)
+		streamInterceptors = append(streamInterceptors, m.authorizer.StreamServerInterceptor())
+		unaryInterceptors = append(unaryInterceptors, m.authorizer.UnaryServerInterceptor())
+	} else {
+		lg.Warn("management API client authentication is not configured, role-based access control will not be enforced")
+	}
 	m.grpcServer = grpc.NewServer(
-		grpc.Creds(insecure.NewCredentials()),
-		grpc.UnknownServiceHandler(unknownServiceHandler(director)),
-		grpc.ChainStreamInterceptor(otelgrpc.StreamServerInterceptor()),
-		grpc.ChainUnaryInterceptor(otelgrpc.UnaryServerInterceptor()),
+		grpc.Creds(creds),
+		grpc.UnknownServiceHandler(unknownServiceHandler(m.director)),
+		grpc.ChainStreamInterceptor(streamInterceptors...),
+		grpc.ChainUnaryInterceptor(unaryInterceptors...),
 	)
 	managementv1.RegisterManagementServer(m.grpcServer, m)
 	if m.capabilitiesDataSource != nil {
@@ -157,7 +239,7 @@ func NewServer(
 			go sp.ServeNodeManagerServer(m.capabilitiesDataSource.NodeManagerServer())
 		}
 		go func() {
-			if err := sp.ServeAPIExtensions(m.config.GRPCListenAddress); err != nil {
+			if err := sp.ServeAPIExtensions(m.localGrpcAddress()); err != nil {
 				lg.With(
 					zap.String("plugin", md.Module),
 				).Error("failed to serve plugin API extensions")
@@ -168,6 +250,39 @@ func NewServer(
 	return m
 }
 
+// clientAuthTLSConfig returns a copy of the gateway's serving tls config which
+// requires clients to present a certificate signed by the client CA.
+func clientAuthTLSConfig(serving *tls.Config, spec *v1beta1.ManagementClientAuthSpec) (*tls.Config, error) {
+	if serving == nil {
+		return nil, errors.New("gateway serving certificate is not configured")
+	}
+	data, err := os.ReadFile(spec.ClientCA)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in client CA %q", spec.ClientCA)
+	}
+	conf := serving.Clone()
+	conf.ClientCAs = pool
+	conf.ClientAuth = tls.RequireAndVerifyClientCert
+	conf.MinVersion = tls.VersionTLS12
+	return conf, nil
+}
+
+// localGrpcAddress returns the address at which the gateway's own components
+// can reach the management grpc server without client certificates.
+func (m *Server) localGrpcAddress() string {
+	if m.config.ClientAuth == nil || strings.HasPrefix(m.config.GRPCListenAddress, "unix://") {
+		return m.config.GRPCListenAddress
+	}
+	if m.config.ClientAuth.LocalListenAddress != "" {
+		return m.config.ClientAuth.LocalListenAddress
+	}
+	return managementv1.DefaultManagementSocket()
+}
+
 type managementApiServer interface {
 	ServeManagementAPI(managementv1.ManagementServer)
 }
@@ -199,26 +314,65 @@ func (m *Server) listenAndServeGrpc(ctx context.Context) error {
 		return errors.New("GRPCListenAddress not configured")
 	}
 	lg := m.logger
-	listener, err := util.NewProtocolListener(m.config.GRPCListenAddress)
-	if err != nil {
-		return err
+	addresses := []string{m.config.GRPCListenAddress}
+	if local := m.localGrpcAddress(); local != m.config.GRPCListenAddress {
+		addresses = append(addresses, local)
+	}
+	var listeners []net.Listener
+	for _, addr := range addresses {
+		listener, err := util.NewProtocolListener(addr)
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return err
+		}
+		lg.With(
+			"address", listener.Addr().String(),
+		).Info("management gRPC server starting")
+		listeners = append(listeners, listener)
 	}
-	lg.With(
-		"address", listener.Addr().String(),
-	).Info("management gRPC server starting")
 
-	errC := lo.Async(func() error {
-		return m.grpcServer.Serve(listener)
-	})
+	errC := make(chan error, len(listeners))
+	for _, listener := range listeners {
+		listener := listener
+		go func() {
+			errC <- m.grpcServer.Serve(listener)
+		}()
+	}
 	select {
 	case <-ctx.Done():
 		m.grpcServer.Stop()
 		return ctx.Err()
 	case err := <-errC:
+		m.grpcServer.Stop()
 		return err
 	}
 }
 
+// incomingHeaderMatcher drops the rbac subject header, which is only set by
+// the http server itself after authenticating the client, and forwards the
+// headers forwarded by default.
+func incomingHeaderMatcher(key string) (string, bool) {
+	if strings.EqualFold(key, rbac.SubjectMetadataKey) ||
+		strings.EqualFold(key, runtime.MetadataHeaderPrefix+rbac.SubjectMetadataKey) {
+		return "", false
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// subjectMetadata forwards the authenticated subject of an http request to the
+// grpc server on the local listener.
+func (m *Server) subjectMetadata(_ context.Context, r *http.Request) metadata.MD {
+	if m.authorizer == nil {
+		return nil
+	}
+	if subject, ok := rbac.SubjectFromHTTPRequest(r, m.authorizer.TrustedProxies()); ok {
+		return metadata.Pairs(rbac.SubjectMetadataKey, subject)
+	}
+	return nil
+}
+
 func (m *Server) listenAndServeHttp(ctx context.Context) error {
 	if m.config.GRPCListenAddress == "" {
 		return errors.New("GRPCListenAddress not configured")
@@ -233,24 +387,50 @@ func (m *Server) listenAndServeHttp(ctx context.Context) error {
 			lg.Error(err)
 		}
 	})
-	gwmux := runtime.NewServeMux(runtime.WithErrorHandler(extensionsErrorHandler))
-	if err := managementv1.RegisterManagementHandlerFromEndpoint(ctx, gwmux,
-		strings.TrimPrefix(m.config.GRPCListenAddress, "tcp://"),
-		[]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}); err != nil {
-		lg.With(
-			zap.Error(err),
-		).Panic("failed to register management handler")
+	cc, err := grpc.DialContext(ctx, strings.TrimPrefix(m.localGrpcAddress(), "tcp://"),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return err
+	}
+	defer cc.Close()
+	m.gatewayMuxMu.Lock()
+	m.newGatewayMux = func() *runtime.ServeMux {
+		gwmux := runtime.NewServeMux(
+			runtime.WithErrorHandler(extensionsErrorHandler),
+			runtime.WithIncomingHeaderMatcher(incomingHeaderMatcher),
+			runtime.WithMetadata(m.subjectMetadata),
+		)
+		if err := managementv1.RegisterManagementHandler(ctx, gwmux, cc); err != nil {
+			lg.With(
+				zap.Error(err),
+			).Panic("failed to register management handler")
+		}
+		m.configureHttpApiExtensions(gwmux)
+		return gwmux
 	}
-	m.configureHttpApiExtensions(gwmux)
-	mux.Handle("/", gwmux)
+	m.gatewayMux.Store(m.newGatewayMux())
+	m.gatewayMuxMu.Unlock()
+	mux.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.authorizer != nil {
+			if _, ok := rbac.SubjectFromHTTPRequest(r, m.authorizer.TrustedProxies()); !ok {
+				http.Error(w, "request has no authenticated subject", http.StatusUnauthorized)
+				return
+			}
+		}
+		m.gatewayMux.Load().ServeHTTP(w, r)
+	}))
 	server := &http.Server{
-		Addr:    m.config.HTTPListenAddress,
-		Handler: mux,
+		Addr:      m.config.HTTPListenAddress,
+		Handler:   mux,
+		TLSConfig: m.tlsConfig,
 		BaseContext: func(net.Listener) context.Context {
 			return ctx
 		},
 	}
 	errC := lo.Async(func() error {
+		if m.tlsConfig != nil {
+			return server.ListenAndServeTLS("", "")
+		}
 		return server.ListenAndServe()
 	})
 	select {
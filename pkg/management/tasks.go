@@ -0,0 +1,354 @@
+package management
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	managementv1 "github.com/rancher/opni/pkg/apis/management/v1"
+	"github.com/rancher/opni/pkg/storage"
+	"github.com/rancher/opni/pkg/task"
+	"github.com/rancher/opni/pkg/validation"
+)
+
+const (
+	defaultTaskRetention = 7 * 24 * time.Hour
+
+	maintenanceTaskController = "maintenance"
+	pruneTasksTaskId          = "prune-tasks"
+)
+
+// TaskPruneInterval is how often tasks are pruned according to the configured
+// retention period.
+var TaskPruneInterval = 1 * time.Hour
+
+type taskFilter struct {
+	*managementv1.ListTasksRequest
+}
+
+func (f taskFilter) matchesNamespace(namespace string) bool {
+	return f.GetNamespace() == "" || f.GetNamespace() == namespace
+}
+
+func (f taskFilter) matches(entry task.Entry) bool {
+	if f.GetController() != "" && f.GetController() != entry.Controller {
+		return false
+	}
+	if len(f.GetStates()) > 0 && !slices.Contains(f.GetStates(), entry.Status.GetState()) {
+		return false
+	}
+	return true
+}
+
+func newTask(namespace string, entry task.Entry) *managementv1.Task {
+	return &managementv1.Task{
+		Ref: &managementv1.TaskReference{
+			Namespace:  namespace,
+			Controller: entry.Controller,
+			Id:         entry.Id,
+		},
+		Status: entry.Status,
+	}
+}
+
+// Returns the task stores of all plugins, along with the store for tasks
+// owned by the management server itself.
+func (m *Server) allTaskStores() (map[string]storage.KeyValueStore, error) {
+	if m.tasksDataSource == nil && m.managementTasks == nil {
+		return nil, status.Error(codes.Unavailable, "tasks API not configured")
+	}
+	stores := map[string]storage.KeyValueStore{}
+	if m.tasksDataSource != nil {
+		maps.Copy(stores, m.tasksDataSource.TaskStores())
+	}
+	if m.managementTasks != nil {
+		stores[TaskNamespace] = m.managementTasks
+	}
+	return stores, nil
+}
+
+// Returns the task stores matching the filter, sorted by namespace.
+func (m *Server) taskStores(filter taskFilter) ([]string, map[string]storage.KeyValueStore, error) {
+	all, err := m.allTaskStores()
+	if err != nil {
+		return nil, nil, err
+	}
+	stores := lo.PickBy(all, func(namespace string, _ storage.KeyValueStore) bool {
+		return filter.matchesNamespace(namespace)
+	})
+	namespaces := maps.Keys(stores)
+	sort.Strings(namespaces)
+	return namespaces, stores, nil
+}
+
+func (m *Server) taskStore(ref *managementv1.TaskReference) (storage.KeyValueStore, error) {
+	stores, err := m.allTaskStores()
+	if err != nil {
+		return nil, err
+	}
+	if err := validation.Validate(ref); err != nil {
+		return nil, err
+	}
+	store, ok := stores[ref.GetNamespace()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "no plugin with namespace %q", ref.GetNamespace())
+	}
+	return store, nil
+}
+
+func (m *Server) ListTasks(
+	ctx context.Context,
+	in *managementv1.ListTasksRequest,
+) (*managementv1.TaskList, error) {
+	filter := taskFilter{in}
+	namespaces, stores, err := m.taskStores(filter)
+	if err != nil {
+		return nil, err
+	}
+	list := &managementv1.TaskList{}
+	for _, namespace := range namespaces {
+		entries, err := task.ListAll(ctx, stores[namespace])
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to list tasks for %s: %v", namespace, err)
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			if entries[i].Controller != entries[j].Controller {
+				return entries[i].Controller < entries[j].Controller
+			}
+			return entries[i].Id < entries[j].Id
+		})
+		for _, entry := range entries {
+			if filter.matches(entry) {
+				list.Items = append(list.Items, newTask(namespace, entry))
+			}
+		}
+	}
+	return list, nil
+}
+
+func (m *Server) GetTask(
+	ctx context.Context,
+	in *managementv1.TaskReference,
+) (*managementv1.Task, error) {
+	store, err := m.taskStore(in)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := task.Get(ctx, store, in.GetController(), in.GetId())
+	if err != nil {
+		return nil, err
+	}
+	return newTask(in.GetNamespace(), entry), nil
+}
+
+func (m *Server) WatchTasks(
+	in *managementv1.ListTasksRequest,
+	stream managementv1.Management_WatchTasksServer,
+) error {
+	ctx := stream.Context()
+	filter := taskFilter{in}
+	namespaces, stores, err := m.taskStores(filter)
+	if err != nil {
+		return err
+	}
+
+	// start watching before sending the current state of each task, so that
+	// no updates are missed in between
+	updates := make(chan *managementv1.Task, 64)
+	var wg sync.WaitGroup
+	for _, namespace := range namespaces {
+		namespace := namespace
+		entryC, err := task.Watch(ctx, stores[namespace])
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to watch tasks for %s: %v", namespace, err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for entry := range entryC {
+				if !filter.matches(entry) {
+					continue
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case updates <- newTask(namespace, entry):
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(updates)
+	}()
+
+	current, err := m.ListTasks(ctx, in)
+	if err != nil {
+		return err
+	}
+	for _, t := range current.Items {
+		if err := stream.Send(t); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case t, ok := <-updates:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(t); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (m *Server) CancelTask(
+	ctx context.Context,
+	in *managementv1.TaskReference,
+) (*emptypb.Empty, error) {
+	store, err := m.taskStore(in)
+	if err != nil {
+		return nil, err
+	}
+	if err := task.RequestCancel(ctx, store, in.GetController(), in.GetId()); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (m *Server) PruneTasks(
+	ctx context.Context,
+	in *managementv1.PruneTasksRequest,
+) (*managementv1.TaskList, error) {
+	if err := validation.Validate(in); err != nil {
+		return nil, err
+	}
+	filter := taskFilter{&managementv1.ListTasksRequest{
+		Namespace:  in.GetNamespace(),
+		Controller: in.GetController(),
+	}}
+	retention := m.taskRetention()
+	if retention <= 0 {
+		retention = defaultTaskRetention
+	}
+	if in.Retention != nil {
+		retention = in.GetRetention().AsDuration()
+	}
+	return m.pruneTasks(ctx, filter, retention)
+}
+
+func (m *Server) pruneTasks(ctx context.Context, filter taskFilter, retention time.Duration) (*managementv1.TaskList, error) {
+	namespaces, stores, err := m.taskStores(filter)
+	if err != nil {
+		return nil, err
+	}
+	list := &managementv1.TaskList{}
+	for _, namespace := range namespaces {
+		pruned, err := task.Prune(ctx, stores[namespace], retention, filter.matches)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to prune tasks for %s: %v", namespace, err)
+		}
+		for _, entry := range pruned {
+			list.Items = append(list.Items, newTask(namespace, entry))
+		}
+	}
+	if len(list.Items) > 0 {
+		m.logger.With(
+			"count", len(list.Items),
+			"retention", retention,
+		).Info("pruned tasks")
+	}
+	return list, nil
+}
+
+// taskRetention returns the configured task retention period, or 0 if
+// pruning is disabled.
+func (m *Server) taskRetention() time.Duration {
+	if m.config.TaskRetention == "" {
+		return defaultTaskRetention
+	}
+	retention, err := time.ParseDuration(m.config.TaskRetention)
+	if err != nil || retention < 0 {
+		m.logger.With(
+			"taskRetention", m.config.TaskRetention,
+		).Warn("invalid task retention, using the default")
+		return defaultTaskRetention
+	}
+	return retention
+}
+
+// taskPruneRunner prunes the tasks of every plugin according to the
+// configured retention period. It is run on a schedule by the maintenance
+// task controller, so the time of the last run survives restarts.
+type taskPruneRunner struct {
+	server    *Server
+	retention time.Duration
+}
+
+func (r *taskPruneRunner) OnTaskPending(ctx context.Context, ti task.ActiveTask) error {
+	return nil
+}
+
+func (r *taskPruneRunner) OnTaskRunning(ctx context.Context, ti task.ActiveTask) error {
+	pruned, err := r.server.pruneTasks(ctx, taskFilter{&managementv1.ListTasksRequest{}}, r.retention)
+	if err != nil {
+		return err
+	}
+	ti.AddLogEntry(zapcore.InfoLevel, fmt.Sprintf("Pruned %d tasks", len(pruned.GetItems())))
+	return nil
+}
+
+func (r *taskPruneRunner) OnTaskCompleted(ctx context.Context, ti task.ActiveTask, state task.State, args ...any) {
+	if state == task.StateFailed {
+		ti.AddLogEntry(zapcore.ErrorLevel, fmt.Sprintf("Pruning failed: %v", args[0]))
+		r.server.logger.With(
+			"error", args[0],
+		).Warn("failed to prune tasks")
+	}
+}
+
+// startTaskPruner schedules pruning of the tasks of every plugin according to
+// the configured retention period, until ctx is done.
+func (m *Server) startTaskPruner(ctx context.Context) {
+	retention := m.taskRetention()
+	if retention == 0 {
+		m.logger.Info("task pruning is disabled")
+		return
+	}
+	ctrl, err := task.NewController(ctx, maintenanceTaskController,
+		storage.NewProtoKeyValueStore[*corev1.TaskStatus](m.managementTasks),
+		&taskPruneRunner{server: m, retention: retention},
+	)
+	if err != nil {
+		m.logger.With(
+			zap.Error(err),
+		).Error("failed to start maintenance task controller, tasks will not be pruned")
+		return
+	}
+	err = ctrl.ScheduleTask(pruneTasksTaskId, task.Every(TaskPruneInterval),
+		task.WithRetryPolicy(&corev1.TaskRetryPolicy{
+			MaxAttempts: 3,
+		}),
+	)
+	if err != nil {
+		m.logger.With(
+			zap.Error(err),
+		).Error("failed to schedule task pruning")
+	}
+}
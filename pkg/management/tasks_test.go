@@ -0,0 +1,268 @@
+package management_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/exp/slices"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	managementv1 "github.com/rancher/opni/pkg/apis/management/v1"
+	"github.com/rancher/opni/pkg/config/v1beta1"
+	"github.com/rancher/opni/pkg/management"
+	"github.com/rancher/opni/pkg/plugins"
+	"github.com/rancher/opni/pkg/storage"
+	"github.com/rancher/opni/pkg/test"
+	"github.com/rancher/opni/pkg/test/testutil"
+)
+
+type testTasksDataSource struct {
+	stores map[string]storage.KeyValueStore
+}
+
+func (t *testTasksDataSource) TaskStores() map[string]storage.KeyValueStore {
+	return t.stores
+}
+
+var _ = Describe("Tasks", Ordered, Label("slow"), func() {
+	var tv *testVars
+	const (
+		pluginA = "github.com/rancher/opni/plugins/a"
+		pluginB = "github.com/rancher/opni/plugins/b"
+	)
+	tasksDataSource := &testTasksDataSource{}
+
+	putTask := func(namespace, key string, ts *corev1.TaskStatus) {
+		data, err := proto.Marshal(ts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tasksDataSource.stores[namespace].Put(context.Background(), key, data)).To(Succeed())
+	}
+	ended := func(state corev1.TaskState, ago time.Duration) *corev1.TaskStatus {
+		return &corev1.TaskStatus{
+			State: state,
+			Transitions: []*corev1.StateTransition{
+				{
+					State:     state,
+					Timestamp: timestamppb.New(time.Now().Add(-ago)),
+				},
+			},
+		}
+	}
+
+	BeforeAll(func() {
+		setupManagementServer(&tv, plugins.NoopLoader, management.WithTasksDataSource(tasksDataSource))()
+		tasksDataSource.stores = map[string]storage.KeyValueStore{
+			pluginA: test.NewTestKeyValueStore(tv.ctrl, slices.Clone[[]byte]),
+			pluginB: test.NewTestKeyValueStore(tv.ctrl, slices.Clone[[]byte]),
+		}
+		putTask(pluginA, "/tasks/uninstall/cluster-1", &corev1.TaskStatus{
+			State: corev1.TaskState_Running,
+			Progress: &corev1.Progress{
+				Current: 1,
+				Total:   3,
+			},
+		})
+		putTask(pluginA, "/tasks/uninstall/cluster-2", ended(corev1.TaskState_Completed, 30*24*time.Hour))
+		putTask(pluginB, "/tasks/uninstall/cluster-1", ended(corev1.TaskState_Failed, time.Hour))
+	})
+
+	It("should list tasks from all plugins", func() {
+		list, err := tv.client.ListTasks(context.Background(), &managementv1.ListTasksRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(list.Items).To(HaveLen(3))
+		Expect(list.Items[0].GetRef()).To(testutil.ProtoEqual(&managementv1.TaskReference{
+			Namespace:  pluginA,
+			Controller: "uninstall",
+			Id:         "cluster-1",
+		}))
+		Expect(list.Items[0].GetStatus().GetProgress().GetCurrent()).To(BeEquivalentTo(1))
+
+		By("filtering by namespace and state")
+		list, err = tv.client.ListTasks(context.Background(), &managementv1.ListTasksRequest{
+			Namespace: pluginA,
+			States:    []corev1.TaskState{corev1.TaskState_Completed, corev1.TaskState_Failed},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(list.Items).To(HaveLen(1))
+		Expect(list.Items[0].GetRef().GetId()).To(Equal("cluster-2"))
+	})
+	It("should get individual tasks", func() {
+		t, err := tv.client.GetTask(context.Background(), &managementv1.TaskReference{
+			Namespace:  pluginB,
+			Controller: "uninstall",
+			Id:         "cluster-1",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(t.GetStatus().GetState()).To(Equal(corev1.TaskState_Failed))
+
+		_, err = tv.client.GetTask(context.Background(), &managementv1.TaskReference{
+			Namespace:  pluginB,
+			Controller: "uninstall",
+			Id:         "cluster-2",
+		})
+		Expect(status.Code(err)).To(Equal(codes.NotFound))
+
+		_, err = tv.client.GetTask(context.Background(), &managementv1.TaskReference{
+			Namespace:  "github.com/rancher/opni/plugins/c",
+			Controller: "uninstall",
+			Id:         "cluster-1",
+		})
+		Expect(status.Code(err)).To(Equal(codes.NotFound))
+
+		_, err = tv.client.GetTask(context.Background(), &managementv1.TaskReference{})
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+	})
+	It("should stream task updates", func() {
+		ctx, ca := context.WithCancel(context.Background())
+		defer ca()
+		stream, err := tv.client.WatchTasks(ctx, &managementv1.ListTasksRequest{
+			States: []corev1.TaskState{corev1.TaskState_Running},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		By("receiving the current state of matching tasks")
+		t, err := stream.Recv()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(t.GetRef().GetNamespace()).To(Equal(pluginA))
+		Expect(t.GetStatus().GetProgress().GetCurrent()).To(BeEquivalentTo(1))
+
+		By("receiving updates")
+		putTask(pluginA, "/tasks/uninstall/cluster-1", &corev1.TaskStatus{
+			State: corev1.TaskState_Running,
+			Progress: &corev1.Progress{
+				Current: 2,
+				Total:   3,
+			},
+		})
+		t, err = stream.Recv()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(t.GetRef().GetId()).To(Equal("cluster-1"))
+		Expect(t.GetStatus().GetProgress().GetCurrent()).To(BeEquivalentTo(2))
+	})
+	It("should request cancellation of running tasks", func() {
+		ref := &managementv1.TaskReference{
+			Namespace:  pluginA,
+			Controller: "uninstall",
+			Id:         "cluster-1",
+		}
+		_, err := tv.client.CancelTask(context.Background(), ref)
+		Expect(err).NotTo(HaveOccurred())
+		t, err := tv.client.GetTask(context.Background(), ref)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(t.GetStatus().GetCancelRequested()).To(BeTrue())
+
+		_, err = tv.client.CancelTask(context.Background(), &managementv1.TaskReference{
+			Namespace:  pluginA,
+			Controller: "uninstall",
+			Id:         "cluster-2",
+		})
+		Expect(status.Code(err)).To(Equal(codes.FailedPrecondition))
+	})
+	It("should prune tasks according to the retention period", func() {
+		By("using the default retention period")
+		pruned, err := tv.client.PruneTasks(context.Background(), &managementv1.PruneTasksRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pruned.Items).To(HaveLen(1))
+		Expect(pruned.Items[0].GetRef().GetNamespace()).To(Equal(pluginA))
+		Expect(pruned.Items[0].GetRef().GetId()).To(Equal("cluster-2"))
+
+		By("using a shorter retention period")
+		pruned, err = tv.client.PruneTasks(context.Background(), &managementv1.PruneTasksRequest{
+			Retention: durationpb.New(time.Minute),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pruned.Items).To(HaveLen(1))
+		Expect(pruned.Items[0].GetRef().GetNamespace()).To(Equal(pluginB))
+
+		list, err := tv.client.ListTasks(context.Background(), &managementv1.ListTasksRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(list.Items).To(HaveLen(1))
+
+		_, err = tv.client.PruneTasks(context.Background(), &managementv1.PruneTasksRequest{
+			Retention: durationpb.New(-time.Minute),
+		})
+		Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+	})
+})
+
+var _ = Describe("Task Pruner", Label("unit"), func() {
+	var ctrl *gomock.Controller
+	var store storage.KeyValueStore
+	var backend storage.Backend
+	putTask := func(key string, ts *corev1.TaskStatus) {
+		data, err := proto.Marshal(ts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(store.Put(context.Background(), key, data)).To(Succeed())
+	}
+	ended := func(ago time.Duration) *corev1.TaskStatus {
+		return &corev1.TaskStatus{
+			State: corev1.TaskState_Completed,
+			Transitions: []*corev1.StateTransition{
+				{
+					State:     corev1.TaskState_Completed,
+					Timestamp: timestamppb.New(time.Now().Add(-ago)),
+				},
+			},
+		}
+	}
+	startServer := func(retention string) {
+		prev := management.TaskPruneInterval
+		management.TaskPruneInterval = 10 * time.Millisecond
+		DeferCleanup(func() {
+			management.TaskPruneInterval = prev
+		})
+		ctx, ca := context.WithCancel(context.Background())
+		DeferCleanup(ca)
+		backend = test.NewTestStorageBackend(ctx, ctrl)
+		management.NewServer(ctx, &v1beta1.ManagementSpec{TaskRetention: retention}, &testCoreDataSource{
+			storageBackend: backend,
+		}, plugins.NoopLoader, management.WithTasksDataSource(&testTasksDataSource{
+			stores: map[string]storage.KeyValueStore{"plugin": store},
+		}))
+	}
+	exists := func(key string) func() error {
+		return func() error {
+			_, err := store.Get(context.Background(), key)
+			return err
+		}
+	}
+
+	BeforeEach(func() {
+		ctrl = gomock.NewController(GinkgoT())
+		store = test.NewTestKeyValueStore(ctrl, slices.Clone[[]byte])
+		putTask("/tasks/uninstall/old", ended(2*time.Hour))
+		putTask("/tasks/uninstall/new", ended(time.Minute))
+		putTask("/tasks/uninstall/running", &corev1.TaskStatus{State: corev1.TaskState_Running})
+	})
+	It("should periodically prune tasks older than the configured retention", func() {
+		startServer("1h")
+		Eventually(exists("/tasks/uninstall/old")).Should(MatchError(storage.ErrNotFound))
+		Consistently(exists("/tasks/uninstall/new"), 100*time.Millisecond).Should(Succeed())
+		Expect(exists("/tasks/uninstall/running")()).To(Succeed())
+
+		By("recording the last run of the pruning task")
+		Eventually(func() (*corev1.TaskSchedule, error) {
+			data, err := backend.KeyValueStore(management.TaskNamespace).Get(context.Background(), "/tasks/maintenance/prune-tasks")
+			if err != nil {
+				return nil, err
+			}
+			ts := &corev1.TaskStatus{}
+			if err := proto.Unmarshal(data, ts); err != nil {
+				return nil, err
+			}
+			return ts.GetSchedule(), nil
+		}).Should(WithTransform((*corev1.TaskSchedule).GetLastRun, Not(BeNil())))
+	})
+	It("should not prune tasks if pruning is disabled", func() {
+		startServer("0")
+		Consistently(exists("/tasks/uninstall/old"), 100*time.Millisecond).Should(Succeed())
+	})
+})
@@ -316,3 +316,34 @@ func (a *IndicesAPI) UpdateDocument(ctx context.Context, index, documentID strin
 	res, err := a.Perform(req)
 	return (*Response)(res), err
 }
+
+func generateSearchPath(indices []string) strings.Builder {
+	var path strings.Builder
+	path.Grow(1 + len(strings.Join(indices, ",")) + 1 + len("_search"))
+	if len(indices) > 0 {
+		path.WriteString("/")
+		path.WriteString(strings.Join(indices, ","))
+	}
+	path.WriteString("/")
+	path.WriteString("_search")
+	return path
+}
+
+func (a *IndicesAPI) Search(ctx context.Context, indices []string, body io.Reader) (*Response, error) {
+	method := http.MethodPost
+	path := generateSearchPath(indices)
+
+	req, err := http.NewRequest(method, path.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	if ctx != nil {
+		req = req.WithContext(ctx)
+	}
+
+	req.Header.Add(headerContentType, jsonContentHeader)
+
+	res, err := a.Perform(req)
+	return (*Response)(res), err
+}
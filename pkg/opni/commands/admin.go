@@ -47,6 +47,7 @@ func BuildCortexOpsCmd() *cobra.Command {
 	cmd.AddCommand(BuildCortexClusterConfigureCmd())
 	cmd.AddCommand(BuildCortexClusterGetConfigurationCmd())
 	cmd.AddCommand(BuildCortexClusterUninstallCmd())
+	cmd.AddCommand(BuildRelabelRulesCmd())
 
 	return cmd
 }
@@ -5,6 +5,8 @@ package commands
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
 	"sort"
 	"strings"
 	"time"
@@ -12,7 +14,9 @@ import (
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
 	storagev1 "github.com/rancher/opni/pkg/apis/storage/v1"
+	cliutil "github.com/rancher/opni/pkg/opni/util"
 	"github.com/rancher/opni/pkg/util"
 	"github.com/rancher/opni/plugins/metrics/pkg/apis/cortexops"
 	"github.com/samber/lo"
@@ -153,6 +157,144 @@ func BuildCortexClusterUninstallCmd() *cobra.Command {
 	return cmd
 }
 
+func BuildRelabelRulesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "relabel-rules",
+		Aliases: []string{"relabel"},
+		Short:   "Manage relabel rules applied by agents to remote-write requests",
+	}
+	cmd.AddCommand(BuildRelabelRulesListCmd())
+	cmd.AddCommand(BuildRelabelRulesGetCmd())
+	cmd.AddCommand(BuildRelabelRulesPutCmd())
+	cmd.AddCommand(BuildRelabelRulesDeleteCmd())
+	return cmd
+}
+
+func BuildRelabelRulesListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List relabel rule sets",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			list, err := opsClient.ListRelabelRuleSets(cmd.Context(), &emptypb.Empty{})
+			if err != nil {
+				return err
+			}
+			fmt.Println(cliutil.RenderRelabelRuleSetList(list))
+			return nil
+		},
+	}
+	return cmd
+}
+
+func BuildRelabelRulesGetCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "get <id>",
+		Short: "Show a relabel rule set",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rs, err := opsClient.GetRelabelRuleSet(cmd.Context(), &corev1.Reference{
+				Id: args[0],
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Println(protojson.MarshalOptions{
+				Multiline: true,
+			}.Format(rs))
+			return nil
+		},
+	}
+	return cmd
+}
+
+func BuildRelabelRulesPutCmd() *cobra.Command {
+	var file string
+	var selector []string
+	var clusterIds []string
+	cmd := &cobra.Command{
+		Use:   "put <id> --file <path>",
+		Short: "Create or replace a relabel rule set",
+		Long: `Create or replace a relabel rule set. The file contains the rule set in JSON
+format, for example:
+
+{
+  "relabelConfigs": [
+    {
+      "sourceLabels": ["__name__"],
+      "regex": "go_gc_.*",
+      "action": "drop"
+    }
+  ]
+}
+
+Fields have the same meaning and defaults as in a Prometheus relabel config.
+If --selector or --clusters is given, it replaces the selector in the file.
+Rule sets without a selector apply to all clusters.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var data []byte
+			var err error
+			if file == "-" {
+				data, err = io.ReadAll(os.Stdin)
+			} else {
+				data, err = os.ReadFile(file)
+			}
+			if err != nil {
+				return err
+			}
+			rs := &cortexops.RelabelRuleSet{}
+			if err := protojson.Unmarshal(data, rs); err != nil {
+				return fmt.Errorf("failed to parse rule set: %w", err)
+			}
+			rs.Id = args[0]
+			if len(selector) > 0 || len(clusterIds) > 0 {
+				sel, err := parseClusterSelector(selector)
+				if err != nil {
+					return err
+				}
+				sel.ClusterIDs = clusterIds
+				rs.Selector = sel
+			}
+			if _, err := opsClient.PutRelabelRuleSet(cmd.Context(), rs); err != nil {
+				return err
+			}
+			lg.With(
+				"id", rs.Id,
+				"rules", len(rs.RelabelConfigs),
+			).Info("Relabel rule set saved")
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&file, "file", "f", "", "Path to the rule set file, or - to read from stdin")
+	cmd.Flags().StringSliceVar(&selector, "selector", nil, "Apply the rules to clusters with these labels (key=value)")
+	cmd.Flags().StringSliceVar(&clusterIds, "clusters", nil, "Apply the rules to these clusters")
+	cmd.MarkFlagRequired("file")
+	return cmd
+}
+
+func BuildRelabelRulesDeleteCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "delete <id>",
+		Aliases: []string{"rm"},
+		Short:   "Delete a relabel rule set",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := opsClient.DeleteRelabelRuleSet(cmd.Context(), &corev1.Reference{
+				Id: args[0],
+			}); err != nil {
+				return err
+			}
+			lg.With(
+				"id", args[0],
+			).Info("Relabel rule set deleted")
+			return nil
+		},
+	}
+	return cmd
+}
+
 func watchForDesiredState(desiredStates ...cortexops.InstallState) error {
 	m := clusterStatusModel{
 		desiredStates: desiredStates,
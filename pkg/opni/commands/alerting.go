@@ -0,0 +1,545 @@
+//go:build !noplugins
+
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	alertingv1 "github.com/rancher/opni/pkg/apis/alerting/v1"
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	cliutil "github.com/rancher/opni/pkg/opni/util"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func BuildAlertingPluginCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "alerting",
+		Short: "Interact with alerting plugin APIs",
+	}
+
+	cmd.AddCommand(BuildAlertConditionsCmd())
+	cmd.AddCommand(BuildAlertEndpointsCmd())
+	cmd.AddCommand(BuildAlertSilencesCmd())
+	cmd.AddCommand(BuildAlertTimelineCmd())
+	cmd.AddCommand(BuildAlertLogsCmd())
+
+	ConfigureManagementCommand(cmd)
+	ConfigureAlertingCommand(cmd)
+	return cmd
+}
+
+func BuildAlertConditionsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "conditions",
+		Aliases: []string{"condition", "cond"},
+		Short:   "Manage alert conditions",
+	}
+	cmd.AddCommand(BuildAlertConditionsListCmd())
+	cmd.AddCommand(BuildAlertConditionsGetCmd())
+	cmd.AddCommand(BuildAlertConditionsCreateCmd())
+	cmd.AddCommand(BuildAlertConditionsUpdateCmd())
+	cmd.AddCommand(BuildAlertConditionsDeleteCmd())
+	cmd.AddCommand(BuildAlertConditionsCloneToCmd())
+	cmd.AddCommand(BuildAlertConditionsStatusCmd())
+	return cmd
+}
+
+func BuildAlertConditionsListCmd() *cobra.Command {
+	var outputFormat string
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List alert conditions",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			list, err := alertConditionsClient.ListAlertConditions(cmd.Context(), &alertingv1.ListAlertConditionRequest{})
+			if err != nil {
+				return err
+			}
+			return printOutput(outputFormat, list, func() string {
+				states := map[string]string{}
+				for _, item := range list.GetItems() {
+					stat, err := alertConditionsClient.AlertConditionStatus(cmd.Context(), item.GetId())
+					if err != nil {
+						states[item.GetId().GetId()] = "-"
+						continue
+					}
+					states[item.GetId().GetId()] = stat.GetState().String()
+				}
+				return cliutil.RenderAlertConditionList(list, states)
+			})
+		},
+	}
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table|json|yaml)")
+	return cmd
+}
+
+func BuildAlertConditionsGetCmd() *cobra.Command {
+	var outputFormat string
+	cmd := &cobra.Command{
+		Use:   "get <id>",
+		Short: "Show an alert condition",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ref := &corev1.Reference{Id: args[0]}
+			cond, err := alertConditionsClient.GetAlertCondition(cmd.Context(), ref)
+			if err != nil {
+				return err
+			}
+			return printOutput(outputFormat, cond, func() string {
+				return cliutil.RenderAlertConditionList(&alertingv1.AlertConditionList{
+					Items: []*alertingv1.AlertConditionWithId{
+						{Id: ref, AlertCondition: cond},
+					},
+				}, nil)
+			})
+		},
+	}
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "yaml", "Output format (table|json|yaml)")
+	return cmd
+}
+
+func BuildAlertConditionsCreateCmd() *cobra.Command {
+	var file string
+	cmd := &cobra.Command{
+		Use:   "create --file <path>",
+		Short: "Create an alert condition from a YAML or JSON file",
+		Long: `Create an alert condition from a YAML or JSON file. The file contains the
+condition in the same format as the output of 'opni alerting conditions get'.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cond := &alertingv1.AlertCondition{}
+			if err := readProtoFile(file, cond); err != nil {
+				return err
+			}
+			ref, err := alertConditionsClient.CreateAlertCondition(cmd.Context(), cond)
+			if err != nil {
+				return err
+			}
+			fmt.Println(ref.GetId())
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&file, "file", "f", "", "Path to the condition file, or - to read from stdin")
+	cmd.MarkFlagRequired("file")
+	return cmd
+}
+
+func BuildAlertConditionsUpdateCmd() *cobra.Command {
+	var file string
+	cmd := &cobra.Command{
+		Use:   "update <id> --file <path>",
+		Short: "Replace an alert condition with the contents of a YAML or JSON file",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cond := &alertingv1.AlertCondition{}
+			if err := readProtoFile(file, cond); err != nil {
+				return err
+			}
+			_, err := alertConditionsClient.UpdateAlertCondition(cmd.Context(), &alertingv1.UpdateAlertConditionRequest{
+				Id:          &corev1.Reference{Id: args[0]},
+				UpdateAlert: cond,
+			})
+			if err != nil {
+				return err
+			}
+			lg.With(
+				"id", args[0],
+			).Info("Alert condition updated")
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&file, "file", "f", "", "Path to the condition file, or - to read from stdin")
+	cmd.MarkFlagRequired("file")
+	return cmd
+}
+
+func BuildAlertConditionsDeleteCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "delete <id> [<id>...]",
+		Aliases: []string{"rm"},
+		Short:   "Delete alert conditions",
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, id := range args {
+				if _, err := alertConditionsClient.DeleteAlertCondition(cmd.Context(), &corev1.Reference{Id: id}); err != nil {
+					return fmt.Errorf("failed to delete alert condition %s: %w", id, err)
+				}
+				lg.With(
+					"id", id,
+				).Info("Alert condition deleted")
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+func BuildAlertConditionsCloneToCmd() *cobra.Command {
+	var clusters []string
+	cmd := &cobra.Command{
+		Use:   "clone-to <id> --clusters <cluster-id>[,<cluster-id>...]",
+		Short: "Copy an alert condition to other clusters",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cond, err := alertConditionsClient.GetAlertCondition(cmd.Context(), &corev1.Reference{Id: args[0]})
+			if err != nil {
+				return err
+			}
+			if _, err := alertConditionsClient.CloneTo(cmd.Context(), &alertingv1.CloneToRequest{
+				AlertCondition: cond,
+				ToClusters:     clusters,
+			}); err != nil {
+				return err
+			}
+			lg.With(
+				"id", args[0],
+				"clusters", clusters,
+			).Info("Alert condition cloned")
+			return nil
+		},
+	}
+	cmd.Flags().StringSliceVar(&clusters, "clusters", nil, "Clusters to copy the condition to")
+	cmd.MarkFlagRequired("clusters")
+	cmd.RegisterFlagCompletionFunc("clusters", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeClusters(cmd, args, toComplete)
+	})
+	return cmd
+}
+
+func BuildAlertConditionsStatusCmd() *cobra.Command {
+	var outputFormat string
+	cmd := &cobra.Command{
+		Use:   "status <id>",
+		Short: "Show the state of an alert condition",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stat, err := alertConditionsClient.AlertConditionStatus(cmd.Context(), &corev1.Reference{Id: args[0]})
+			if err != nil {
+				return err
+			}
+			return printOutput(outputFormat, stat, func() string {
+				return stat.GetState().String()
+			})
+		},
+	}
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table|json|yaml)")
+	return cmd
+}
+
+func BuildAlertEndpointsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "endpoints",
+		Aliases: []string{"endpoint", "ep"},
+		Short:   "Manage alert endpoints",
+	}
+	cmd.AddCommand(BuildAlertEndpointsListCmd())
+	cmd.AddCommand(BuildAlertEndpointsGetCmd())
+	cmd.AddCommand(BuildAlertEndpointsCreateCmd())
+	cmd.AddCommand(BuildAlertEndpointsUpdateCmd())
+	cmd.AddCommand(BuildAlertEndpointsDeleteCmd())
+	return cmd
+}
+
+func BuildAlertEndpointsListCmd() *cobra.Command {
+	var outputFormat string
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List alert endpoints",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			list, err := alertEndpointsClient.ListAlertEndpoints(cmd.Context(), &alertingv1.ListAlertEndpointsRequest{})
+			if err != nil {
+				return err
+			}
+			return printOutput(outputFormat, list, func() string {
+				return cliutil.RenderAlertEndpointList(list)
+			})
+		},
+	}
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table|json|yaml)")
+	return cmd
+}
+
+func BuildAlertEndpointsGetCmd() *cobra.Command {
+	var outputFormat string
+	cmd := &cobra.Command{
+		Use:   "get <id>",
+		Short: "Show an alert endpoint",
+		Long:  "Show an alert endpoint. Secrets in the endpoint configuration are redacted.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ref := &corev1.Reference{Id: args[0]}
+			ep, err := alertEndpointsClient.GetAlertEndpoint(cmd.Context(), ref)
+			if err != nil {
+				return err
+			}
+			return printOutput(outputFormat, ep, func() string {
+				return cliutil.RenderAlertEndpointList(&alertingv1.AlertEndpointList{
+					Items: []*alertingv1.AlertEndpointWithId{
+						{Id: ref, Endpoint: ep},
+					},
+				})
+			})
+		},
+	}
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "yaml", "Output format (table|json|yaml)")
+	return cmd
+}
+
+func BuildAlertEndpointsCreateCmd() *cobra.Command {
+	var file string
+	cmd := &cobra.Command{
+		Use:   "create --file <path>",
+		Short: "Create an alert endpoint from a YAML or JSON file",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ep := &alertingv1.AlertEndpoint{}
+			if err := readProtoFile(file, ep); err != nil {
+				return err
+			}
+			ref, err := alertEndpointsClient.CreateAlertEndpoint(cmd.Context(), ep)
+			if err != nil {
+				return err
+			}
+			fmt.Println(ref.GetId())
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&file, "file", "f", "", "Path to the endpoint file, or - to read from stdin")
+	cmd.MarkFlagRequired("file")
+	return cmd
+}
+
+func BuildAlertEndpointsUpdateCmd() *cobra.Command {
+	var file string
+	var force bool
+	cmd := &cobra.Command{
+		Use:   "update <id> --file <path>",
+		Short: "Replace an alert endpoint with the contents of a YAML or JSON file",
+		Long: `Replace an alert endpoint with the contents of a YAML or JSON file.
+Redacted secrets in the file are replaced with the endpoint's current secrets.
+If the endpoint is attached to any alert conditions, --force is required.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ep := &alertingv1.AlertEndpoint{}
+			if err := readProtoFile(file, ep); err != nil {
+				return err
+			}
+			involved, err := alertEndpointsClient.UpdateAlertEndpoint(cmd.Context(), &alertingv1.UpdateAlertEndpointRequest{
+				ForceUpdate: force,
+				Id:          &corev1.Reference{Id: args[0]},
+				UpdateAlert: ep,
+			})
+			if err != nil {
+				return err
+			}
+			if !force && len(involved.GetItems()) > 0 {
+				return fmt.Errorf("endpoint is attached to alert conditions %v; use --force to update it", refIds(involved.GetItems()))
+			}
+			lg.With(
+				"id", args[0],
+			).Info("Alert endpoint updated")
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&file, "file", "f", "", "Path to the endpoint file, or - to read from stdin")
+	cmd.Flags().BoolVar(&force, "force", false, "Update the endpoint even if it is attached to alert conditions")
+	cmd.MarkFlagRequired("file")
+	return cmd
+}
+
+func BuildAlertEndpointsDeleteCmd() *cobra.Command {
+	var force bool
+	cmd := &cobra.Command{
+		Use:     "delete <id>",
+		Aliases: []string{"rm"},
+		Short:   "Delete an alert endpoint",
+		Long: `Delete an alert endpoint. If the endpoint is attached to any alert conditions,
+--force is required, and the endpoint is detached from those conditions.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			involved, err := alertEndpointsClient.DeleteAlertEndpoint(cmd.Context(), &alertingv1.DeleteAlertEndpointRequest{
+				ForceDelete: force,
+				Id:          &corev1.Reference{Id: args[0]},
+			})
+			if err != nil {
+				return err
+			}
+			if !force && len(involved.GetItems()) > 0 {
+				return fmt.Errorf("endpoint is attached to alert conditions %v; use --force to delete it", refIds(involved.GetItems()))
+			}
+			lg.With(
+				"id", args[0],
+			).Info("Alert endpoint deleted")
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&force, "force", false, "Delete the endpoint even if it is attached to alert conditions")
+	return cmd
+}
+
+func BuildAlertSilencesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "silences",
+		Aliases: []string{"silence"},
+		Short:   "Manage alert condition silences",
+	}
+	cmd.AddCommand(BuildAlertSilencesListCmd())
+	cmd.AddCommand(BuildAlertSilencesActivateCmd())
+	cmd.AddCommand(BuildAlertSilencesDeactivateCmd())
+	return cmd
+}
+
+func BuildAlertSilencesListCmd() *cobra.Command {
+	var outputFormat string
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List alert conditions which are currently silenced",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			list, err := alertConditionsClient.ListAlertConditions(cmd.Context(), &alertingv1.ListAlertConditionRequest{})
+			if err != nil {
+				return err
+			}
+			silenced := &alertingv1.AlertConditionList{}
+			now := time.Now()
+			for _, item := range list.GetItems() {
+				silence := item.GetAlertCondition().GetSilence()
+				if silence != nil && silence.GetEndsAt().AsTime().After(now) {
+					silenced.Items = append(silenced.Items, item)
+				}
+			}
+			return printOutput(outputFormat, silenced, func() string {
+				return cliutil.RenderAlertSilenceList(silenced)
+			})
+		},
+	}
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table|json|yaml)")
+	return cmd
+}
+
+func BuildAlertSilencesActivateCmd() *cobra.Command {
+	var duration time.Duration
+	cmd := &cobra.Command{
+		Use:   "activate <condition-id> --duration <duration>",
+		Short: "Silence an alert condition",
+		Long:  "Silence an alert condition. If the condition is already silenced, the silence is replaced.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := alertConditionsClient.ActivateSilence(cmd.Context(), &alertingv1.SilenceRequest{
+				ConditionId: &corev1.Reference{Id: args[0]},
+				Duration:    durationpb.New(duration),
+			}); err != nil {
+				return err
+			}
+			lg.With(
+				"id", args[0],
+				"until", time.Now().Add(duration).Format(time.RFC3339),
+			).Info("Alert condition silenced")
+			return nil
+		},
+	}
+	cmd.Flags().DurationVar(&duration, "duration", 0, "How long to silence the condition for")
+	cmd.MarkFlagRequired("duration")
+	return cmd
+}
+
+func BuildAlertSilencesDeactivateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "deactivate <condition-id>",
+		Short: "Remove the silence from an alert condition",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := alertConditionsClient.DeactivateSilence(cmd.Context(), &corev1.Reference{Id: args[0]}); err != nil {
+				return err
+			}
+			lg.With(
+				"id", args[0],
+			).Info("Alert condition silence removed")
+			return nil
+		},
+	}
+	return cmd
+}
+
+func BuildAlertTimelineCmd() *cobra.Command {
+	var lookback time.Duration
+	var outputFormat string
+	cmd := &cobra.Command{
+		Use:   "timeline",
+		Short: "Show when alert conditions were firing or silenced",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			timeline, err := alertConditionsClient.Timeline(cmd.Context(), &alertingv1.TimelineRequest{
+				LookbackWindow: durationpb.New(lookback),
+			})
+			if err != nil {
+				return err
+			}
+			return printOutput(outputFormat, timeline, func() string {
+				names := map[string]string{}
+				if list, err := alertConditionsClient.ListAlertConditions(cmd.Context(), &alertingv1.ListAlertConditionRequest{}); err == nil {
+					for _, item := range list.GetItems() {
+						names[item.GetId().GetId()] = item.GetAlertCondition().GetName()
+					}
+				}
+				return cliutil.RenderAlertTimeline(timeline, names)
+			})
+		},
+	}
+	cmd.Flags().DurationVar(&lookback, "lookback", 24*time.Hour, "How far back to look")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table|json|yaml)")
+	return cmd
+}
+
+func BuildAlertLogsCmd() *cobra.Command {
+	var labels []string
+	var limit uint64
+	var since, until time.Duration
+	var outputFormat string
+	cmd := &cobra.Command{
+		Use:   "logs",
+		Short: "Show alert logs",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			now := time.Now()
+			req := &alertingv1.ListAlertLogRequest{
+				Labels:         labels,
+				Limit:          limit,
+				StartTimestamp: timestamppb.New(now.Add(-since)),
+				EndTimestamp:   timestamppb.New(now.Add(-until)),
+			}
+			list, err := alertLogsClient.ListAlertLogs(cmd.Context(), req)
+			if err != nil {
+				return err
+			}
+			return printOutput(outputFormat, list, func() string {
+				return cliutil.RenderAlertLogList(list)
+			})
+		},
+	}
+	cmd.Flags().StringSliceVar(&labels, "labels", nil, "Only show logs for conditions with these labels")
+	cmd.Flags().Uint64Var(&limit, "limit", 100, "Maximum number of logs to show")
+	cmd.Flags().DurationVar(&since, "since", 24*time.Hour, "Show logs newer than this duration")
+	cmd.Flags().DurationVar(&until, "until", 0, "Show logs older than this duration")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table|json|yaml)")
+	return cmd
+}
+
+func refIds(refs []*corev1.Reference) []string {
+	ids := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		ids = append(ids, ref.GetId())
+	}
+	return ids
+}
+
+func init() {
+	AddCommandsToGroup(PluginAPIs, BuildAlertingPluginCmd())
+}
@@ -2,6 +2,7 @@ package commands
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -11,6 +12,7 @@ import (
 	managementv1 "github.com/rancher/opni/pkg/apis/management/v1"
 	"github.com/rancher/opni/pkg/logger"
 	cliutil "github.com/rancher/opni/pkg/opni/util"
+	"github.com/rancher/opni/pkg/task"
 	"github.com/samber/lo"
 	"github.com/spf13/cobra"
 	"github.com/ttacon/chalk"
@@ -56,11 +58,13 @@ func BuildCapabilityListCmd() *cobra.Command {
 }
 
 func BuildCapabilityInstallCmd() *cobra.Command {
-	var ignoreWarnings bool
+	var ignoreWarnings, cascade, dryRun bool
+	var selectorLabels []string
+	var concurrency int32
 	cmd := &cobra.Command{
-		Use:   "install <capability-name> <cluster-id> [cluster-id ...]",
+		Use:   "install <capability-name> {<cluster-id> [cluster-id ...] | --selector key=value ...}",
 		Short: "Install a capability on one or more clusters",
-		Args:  cobra.MinimumNArgs(2),
+		Args:  capabilityTargetArgs(&selectorLabels),
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			if len(args) == 0 {
 				return completeCapability(cmd, args, toComplete)
@@ -68,8 +72,29 @@ func BuildCapabilityInstallCmd() *cobra.Command {
 			return completeClusters(cmd, args[1:], toComplete, filterDoesNotHaveCapability(args[0]))
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(selectorLabels) > 0 {
+				if dryRun {
+					return errors.New("--dry-run cannot be used with --selector")
+				}
+				selector, err := parseClusterSelector(selectorLabels)
+				if err != nil {
+					return err
+				}
+				resp, err := mgmtClient.BulkInstallCapability(cmd.Context(), &managementv1.BulkCapabilityInstallRequest{
+					Name:           args[0],
+					Selector:       selector,
+					IgnoreWarnings: ignoreWarnings,
+					Cascade:        cascade,
+					Concurrency:    concurrency,
+				})
+				if err != nil {
+					return err
+				}
+				fmt.Println(cliutil.RenderBulkCapabilityResponse(resp))
+				return nil
+			}
 			for _, clusterID := range args[1:] {
-				resp, err := mgmtClient.InstallCapability(cmd.Context(), &managementv1.CapabilityInstallRequest{
+				req := &managementv1.CapabilityInstallRequest{
 					Name: args[0],
 					Target: &capabilityv1.InstallRequest{
 						Cluster: &corev1.Reference{
@@ -77,7 +102,17 @@ func BuildCapabilityInstallCmd() *cobra.Command {
 						},
 						IgnoreWarnings: ignoreWarnings,
 					},
-				})
+					Cascade: cascade,
+				}
+				if dryRun {
+					plan, err := mgmtClient.PlanCapabilityInstall(cmd.Context(), req)
+					if err != nil {
+						return err
+					}
+					fmt.Printf("Cluster %s:\n%s\n", clusterID, cliutil.RenderCapabilityPlan(plan))
+					continue
+				}
+				resp, err := mgmtClient.InstallCapability(cmd.Context(), req)
 				if err != nil {
 					return err
 				}
@@ -95,17 +130,23 @@ func BuildCapabilityInstallCmd() *cobra.Command {
 		},
 	}
 	cmd.Flags().BoolVar(&ignoreWarnings, "ignore-warnings", false, "Proceed with installation even if warnings are present")
+	cmd.Flags().BoolVar(&cascade, "cascade", false, "Also install any dependencies of the capability which are not installed")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show the capabilities which would be installed, without installing them")
+	cmd.Flags().StringSliceVar(&selectorLabels, "selector", nil, "Install on all clusters matching these key=value labels, instead of the given cluster IDs")
+	cmd.Flags().Int32Var(&concurrency, "concurrency", 0, "Maximum number of clusters to install on at once when using --selector (default 10)")
 	return cmd
 }
 
 func BuildCapabilityUninstallCmd() *cobra.Command {
 	var options capabilityv1.DefaultUninstallOptions
-	var follow bool
+	var follow, cascade, dryRun bool
+	var selectorLabels []string
+	var concurrency int32
 
 	cmd := &cobra.Command{
-		Use:   "uninstall <capability-name> <cluster-id> [cluster-id ...]",
+		Use:   "uninstall <capability-name> {<cluster-id> [cluster-id ...] | --selector key=value ...}",
 		Short: "Uninstall a capability from one or more clusters",
-		Args:  cobra.MinimumNArgs(2),
+		Args:  capabilityTargetArgs(&selectorLabels),
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			if len(args) == 0 {
 				return completeCapability(cmd, args, toComplete, filterNodeCountNonZero)
@@ -113,8 +154,29 @@ func BuildCapabilityUninstallCmd() *cobra.Command {
 			return completeClusters(cmd, args[1:], toComplete, filterHasCapability(args[0]))
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(selectorLabels) > 0 {
+				if dryRun {
+					return errors.New("--dry-run cannot be used with --selector")
+				}
+				selector, err := parseClusterSelector(selectorLabels)
+				if err != nil {
+					return err
+				}
+				resp, err := mgmtClient.BulkUninstallCapability(cmd.Context(), &managementv1.BulkCapabilityUninstallRequest{
+					Name:        args[0],
+					Selector:    selector,
+					Options:     options.ToStruct(),
+					Cascade:     cascade,
+					Concurrency: concurrency,
+				})
+				if err != nil {
+					return fmt.Errorf("uninstall failed: %w", err)
+				}
+				fmt.Println(cliutil.RenderBulkCapabilityResponse(resp))
+				return nil
+			}
 			for _, clusterID := range args[1:] {
-				_, err := mgmtClient.UninstallCapability(cmd.Context(), &managementv1.CapabilityUninstallRequest{
+				req := &managementv1.CapabilityUninstallRequest{
 					Name: args[0],
 					Target: &capabilityv1.UninstallRequest{
 						Cluster: &corev1.Reference{
@@ -122,14 +184,24 @@ func BuildCapabilityUninstallCmd() *cobra.Command {
 						},
 						Options: options.ToStruct(),
 					},
-				})
+					Cascade: cascade,
+				}
+				if dryRun {
+					plan, err := mgmtClient.PlanCapabilityUninstall(cmd.Context(), req)
+					if err != nil {
+						return err
+					}
+					fmt.Printf("Cluster %s:\n%s\n", clusterID, cliutil.RenderCapabilityPlan(plan))
+					continue
+				}
+				_, err := mgmtClient.UninstallCapability(cmd.Context(), req)
 				if err != nil {
 					return fmt.Errorf("uninstall failed: %w", err)
 				}
 
 				lg.Info("Uninstall request submitted successfully")
 			}
-			if !follow || len(args[1:]) > 1 {
+			if dryRun || !follow || len(args[1:]) > 1 {
 				return nil
 			}
 
@@ -141,6 +213,10 @@ func BuildCapabilityUninstallCmd() *cobra.Command {
 	cmd.Flags().BoolVar(&options.DeleteStoredData, "delete-stored-data", false, "Delete all stored data associated with the capability")
 	cmd.Flags().DurationVar((*time.Duration)(&options.InitialDelay), "initial-delay", 0, "Delay the uninstall operation by this amount of time, during which the operation can be canceled without incurring any data loss.")
 	cmd.Flags().BoolVar(&follow, "follow", true, "follow progress of uninstall task")
+	cmd.Flags().BoolVar(&cascade, "cascade", false, "Also uninstall any installed capabilities which depend on the capability")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show the capabilities which would be uninstalled, without uninstalling them")
+	cmd.Flags().StringSliceVar(&selectorLabels, "selector", nil, "Uninstall from all clusters matching these key=value labels, instead of the given cluster IDs")
+	cmd.Flags().Int32Var(&concurrency, "concurrency", 0, "Maximum number of clusters to uninstall from at once when using --selector (default 10)")
 	return cmd
 }
 
@@ -185,10 +261,16 @@ func BuildCapabilityCancelUninstallCmd() *cobra.Command {
 
 func BuildCapabilityStatusCmd() *cobra.Command {
 	var follow bool
+	var selectorLabels []string
 	cmd := &cobra.Command{
-		Use:   "status <capability-name> <cluster-id>",
+		Use:   "status <capability-name> {<cluster-id> | --selector key=value ...}",
 		Short: "Show the status of a capability, or the status of an in-progress uninstall operation",
-		Args:  cobra.ExactArgs(2),
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(selectorLabels) > 0 {
+				return cobra.ExactArgs(1)(cmd, args)
+			}
+			return cobra.ExactArgs(2)(cmd, args)
+		},
 		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
 			if len(args) == 0 {
 				return completeCapability(cmd, args, toComplete, filterNodeCountNonZero)
@@ -196,6 +278,21 @@ func BuildCapabilityStatusCmd() *cobra.Command {
 			return completeClusters(cmd, args[1:], toComplete, filterHasCapability(args[0]))
 		},
 		Run: func(cmd *cobra.Command, args []string) {
+			if len(selectorLabels) > 0 {
+				selector, err := parseClusterSelector(selectorLabels)
+				if err != nil {
+					lg.Fatal(err)
+				}
+				resp, err := mgmtClient.BulkCapabilityUninstallStatus(cmd.Context(), &managementv1.BulkCapabilityStatusRequest{
+					Name:     args[0],
+					Selector: selector,
+				})
+				if err != nil {
+					lg.Fatal(err)
+				}
+				fmt.Println(cliutil.RenderClusterTaskStatuses(resp))
+				return
+			}
 			cluster, err := mgmtClient.GetCluster(cmd.Context(), &corev1.Reference{
 				Id: args[1],
 			})
@@ -220,9 +317,36 @@ func BuildCapabilityStatusCmd() *cobra.Command {
 		},
 	}
 	cmd.Flags().BoolVar(&follow, "follow", false, "follow progress of uninstall task")
+	cmd.Flags().StringSliceVar(&selectorLabels, "selector", nil, "Show the uninstall status of all clusters matching these key=value labels")
 	return cmd
 }
 
+// Requires a capability name followed by one or more cluster IDs, or only a
+// capability name if a cluster selector is given.
+func capabilityTargetArgs(selectorLabels *[]string) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if len(*selectorLabels) > 0 {
+			if len(args) > 1 {
+				return errors.New("cluster IDs cannot be used with --selector")
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		}
+		return cobra.MinimumNArgs(2)(cmd, args)
+	}
+}
+
+func parseClusterSelector(labels []string) (*corev1.ClusterSelector, error) {
+	matchLabels, err := cliutil.ParseKeyValuePairs(labels)
+	if err != nil {
+		return nil, err
+	}
+	return &corev1.ClusterSelector{
+		LabelSelector: &corev1.LabelSelector{
+			MatchLabels: matchLabels,
+		},
+	}, nil
+}
+
 func logTaskProgress(ctx context.Context, cluster, name string) error {
 	lastLogTimestamp := time.Time{}
 	for {
@@ -235,29 +359,9 @@ func logTaskProgress(ctx context.Context, cluster, name string) error {
 		if err != nil {
 			return fmt.Errorf("failed to get status: %w", err)
 		}
-		allLogs := []corev1.TimestampedLog{}
-		for _, log := range status.GetLogs() {
-			allLogs = append(allLogs, log)
-		}
-		for _, tr := range status.GetTransitions() {
-			allLogs = append(allLogs, tr)
-		}
-		slices.SortFunc(allLogs, func(a, b corev1.TimestampedLog) bool {
-			return a.GetTimestamp().AsTime().Before(b.GetTimestamp().AsTime())
-		})
-		allLogs = lo.DropWhile(allLogs, func(t corev1.TimestampedLog) bool {
-			return !t.GetTimestamp().AsTime().After(lastLogTimestamp)
-		})
-		for i, log := range allLogs {
-			printStatusLog(log)
-			if i == len(allLogs)-1 {
-				lastLogTimestamp = log.GetTimestamp().AsTime()
-			}
-		}
+		lastLogTimestamp = printTaskLogs(status, lastLogTimestamp)
 
-		if status.State == corev1.TaskState_Completed ||
-			status.State == corev1.TaskState_Failed ||
-			status.State == corev1.TaskState_Canceled {
+		if task.IsDone(status.State) {
 			break
 		}
 		time.Sleep(500 * time.Millisecond)
@@ -265,6 +369,29 @@ func logTaskProgress(ctx context.Context, cluster, name string) error {
 	return nil
 }
 
+// Prints the logs and state transitions of a task which are newer than the
+// given timestamp, and returns the timestamp of the last printed entry.
+func printTaskLogs(status *corev1.TaskStatus, since time.Time) time.Time {
+	allLogs := []corev1.TimestampedLog{}
+	for _, log := range status.GetLogs() {
+		allLogs = append(allLogs, log)
+	}
+	for _, tr := range status.GetTransitions() {
+		allLogs = append(allLogs, tr)
+	}
+	slices.SortFunc(allLogs, func(a, b corev1.TimestampedLog) bool {
+		return a.GetTimestamp().AsTime().Before(b.GetTimestamp().AsTime())
+	})
+	allLogs = lo.DropWhile(allLogs, func(t corev1.TimestampedLog) bool {
+		return !t.GetTimestamp().AsTime().After(since)
+	})
+	for _, log := range allLogs {
+		printStatusLog(log)
+		since = log.GetTimestamp().AsTime()
+	}
+	return since
+}
+
 func printStatusLog(log corev1.TimestampedLog) {
 	timestamp := log.GetTimestamp().AsTime().Format(time.StampMilli)
 	msg := log.GetMsg()
@@ -3,6 +3,7 @@ package commands
 import (
 	"fmt"
 	"reflect"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
@@ -12,6 +13,7 @@ import (
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 func BuildClustersCmd() *cobra.Command {
@@ -26,6 +28,7 @@ func BuildClustersCmd() *cobra.Command {
 	clustersCmd.AddCommand(BuildClustersLabelCmd())
 	clustersCmd.AddCommand(BuildClustersRenameCmd())
 	clustersCmd.AddCommand(BuildClustersShowCmd())
+	clustersCmd.AddCommand(BuildClustersRotateKeyringCmd())
 	ConfigureManagementCommand(clustersCmd)
 	return clustersCmd
 }
@@ -237,6 +240,42 @@ func BuildClustersRenameCmd() *cobra.Command {
 	return cmd
 }
 
+func BuildClustersRotateKeyringCmd() *cobra.Command {
+	var gracePeriod time.Duration
+	cmd := &cobra.Command{
+		Use:   "rotate-keyring <cluster-id>",
+		Short: "Rotate a cluster's shared keys",
+		Long: `Replaces the keys shared between the gateway and a cluster's agent, without
+re-bootstrapping the agent. The agent must be connected to the gateway.
+The previous keys remain valid on both sides until the grace period ends.`,
+		Args: cobra.ExactArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 0 {
+				return completeClusters(cmd, args, toComplete)
+			}
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, err := mgmtClient.RotateClusterKeyring(cmd.Context(), &managementv1.RotateClusterKeyringRequest{
+				Cluster: &corev1.Reference{
+					Id: args[0],
+				},
+				GracePeriod: durationpb.New(gracePeriod),
+			})
+			if err != nil {
+				return err
+			}
+			lg.With(
+				"id", args[0],
+				"gracePeriod", gracePeriod,
+			).Info("Rotated cluster keyring")
+			return nil
+		},
+	}
+	cmd.Flags().DurationVar(&gracePeriod, "grace-period", 1*time.Hour, "How long the previous keys remain valid")
+	return cmd
+}
+
 func BuildClustersShowCmd() *cobra.Command {
 	var outputFormat string
 	cmd := &cobra.Command{
@@ -3,6 +3,7 @@ package commands
 import (
 	"fmt"
 	"os"
+	"path"
 	"strings"
 	"time"
 
@@ -210,3 +211,26 @@ func completeBootstrapTokens(cmd *cobra.Command, args []string, toComplete strin
 	}
 	return comps, cobra.ShellCompDirectiveNoFileComp
 }
+
+func completeTasks(cmd *cobra.Command, args []string, toComplete string, states ...corev1.TaskState) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	if err := managementPreRunE(cmd, nil); err != nil {
+		return nil, cobra.ShellCompDirectiveError | cobra.ShellCompDirectiveNoFileComp
+	}
+	list, err := mgmtClient.ListTasks(cmd.Context(), &managementv1.ListTasksRequest{
+		States: states,
+	})
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, t := range list.Items {
+		name := path.Join(t.GetRef().GetController(), t.GetRef().GetId())
+		if strings.HasPrefix(name, toComplete) {
+			names = append(names, name)
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
@@ -92,7 +92,19 @@ func BuildGatewayCmd() *cobra.Command {
 		lg.With(
 			"dir", gatewayConfig.Spec.Plugins.Dir,
 		).Info("loading plugins")
-		pluginLoader := plugins.NewPluginLoader(plugins.WithLogger(lg.Named("gateway")))
+		loaderOptions := []plugins.PluginLoaderOption{
+			plugins.WithLogger(lg.Named("gateway")),
+		}
+		if drainTimeout := gatewayConfig.Spec.Plugins.Reload.DrainTimeout; drainTimeout != "" {
+			d, err := time.ParseDuration(drainTimeout)
+			if err != nil {
+				lg.With(
+					zap.Error(err),
+				).Fatal("invalid plugin drain timeout")
+			}
+			loaderOptions = append(loaderOptions, plugins.WithDrainTimeout(d))
+		}
+		pluginLoader := plugins.NewPluginLoader(loaderOptions...)
 
 		lifecycler := config.NewLifecycler(objects)
 		g := gateway.NewGateway(ctx, gatewayConfig, pluginLoader,
@@ -102,6 +114,8 @@ func BuildGatewayCmd() *cobra.Command {
 		m := management.NewServer(ctx, &gatewayConfig.Spec.Management, g, pluginLoader,
 			management.WithCapabilitiesDataSource(g),
 			management.WithHealthStatusDataSource(g),
+			management.WithKeyringDataSource(g),
+			management.WithTasksDataSource(g),
 			management.WithLifecycler(lifecycler),
 		)
 
@@ -131,6 +145,14 @@ func BuildGatewayCmd() *cobra.Command {
 			}
 		}))
 
+		if gatewayConfig.Spec.Plugins.Reload.Enabled {
+			pluginLoader.Hook(hooks.OnLoadingCompleted(func(int) {
+				waitctx.AddOne(ctx)
+				defer waitctx.Done(ctx)
+				pluginLoader.WatchPlugins(ctx, gatewayConfig.Spec.Plugins, plugins.GatewayScheme)
+			}))
+		}
+
 		pluginLoader.LoadPlugins(ctx, gatewayConfig.Spec.Plugins, plugins.GatewayScheme)
 
 		style := chalk.Yellow.NewStyle().
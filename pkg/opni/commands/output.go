@@ -0,0 +1,141 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// printOutput prints msg in the given format, one of table, json or yaml.
+// renderTable is only called for the table format.
+func printOutput(format string, msg proto.Message, renderTable func() string) error {
+	switch format {
+	case "table":
+		fmt.Println(renderTable())
+	case "json":
+		fmt.Println(protojson.Format(msg))
+	case "yaml":
+		data, err := yaml.JSONToYAML([]byte(protojson.Format(msg)))
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(data))
+	default:
+		return fmt.Errorf("unknown output format: %s", format)
+	}
+	return nil
+}
+
+// readProtoFile reads msg from a YAML or JSON file. If path is "-", the
+// message is read from stdin.
+func readProtoFile(path string, msg proto.Message) error {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return err
+	}
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if err := protojson.Unmarshal(jsonData, msg); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+type protoDocument struct {
+	// File and index of the document within the file, for error messages
+	Source string
+	JSON   []byte
+}
+
+// readProtoDocuments reads all YAML or JSON documents from a file, or from
+// all .yaml, .yml and .json files in a directory, in order of file name.
+// Files may contain multiple YAML documents separated by "---". If path is
+// "-", documents are read from stdin. The documents are returned as JSON,
+// ready to be unmarshaled with protojson.
+func readProtoDocuments(path string) ([]protoDocument, error) {
+	var files []string
+	if path == "-" {
+		files = []string{path}
+	} else {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = []string{path}
+		} else {
+			entries, err := os.ReadDir(path)
+			if err != nil {
+				return nil, err
+			}
+			for _, entry := range entries {
+				switch strings.ToLower(filepath.Ext(entry.Name())) {
+				case ".yaml", ".yml", ".json":
+					if !entry.IsDir() {
+						files = append(files, filepath.Join(path, entry.Name()))
+					}
+				}
+			}
+			sort.Strings(files)
+		}
+	}
+
+	var docs []protoDocument
+	for _, file := range files {
+		var data []byte
+		var err error
+		if file == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(file)
+		}
+		if err != nil {
+			return nil, err
+		}
+		reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+		for i := 0; ; i++ {
+			doc, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", file, err)
+			}
+			if len(bytes.TrimSpace(doc)) == 0 {
+				continue
+			}
+			jsonData, err := yaml.YAMLToJSON(doc)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s (document %d): %w", file, i, err)
+			}
+			if string(jsonData) == "null" {
+				// document containing only comments
+				continue
+			}
+			docs = append(docs, protoDocument{
+				Source: fmt.Sprintf("%s (document %d)", file, i),
+				JSON:   jsonData,
+			})
+		}
+	}
+	return docs, nil
+}
@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("readProtoDocuments", Label("unit"), func() {
+	var dir string
+	write := func(name, contents string) string {
+		path := filepath.Join(dir, name)
+		Expect(os.WriteFile(path, []byte(contents), 0644)).To(Succeed())
+		return path
+	}
+	sources := func(docs []protoDocument) []string {
+		var s []string
+		for _, doc := range docs {
+			s = append(s, doc.Source)
+		}
+		return s
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+	})
+
+	It("should read a single JSON document", func() {
+		path := write("slo.json", `{"name": "a", "target": {"value": 99}}`)
+		docs, err := readProtoDocuments(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(docs).To(HaveLen(1))
+		Expect(docs[0].Source).To(Equal(path + " (document 0)"))
+		Expect(docs[0].JSON).To(MatchJSON(`{"name": "a", "target": {"value": 99}}`))
+	})
+
+	It("should read multiple YAML documents, skipping empty ones", func() {
+		path := write("slos.yaml", `
+name: a
+---
+# comments only
+---
+---
+name: b
+target:
+  value: 99.9
+`)
+		docs, err := readProtoDocuments(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sources(docs)).To(Equal([]string{
+			path + " (document 0)",
+			path + " (document 2)",
+		}))
+		Expect(docs[0].JSON).To(MatchJSON(`{"name": "a"}`))
+		Expect(docs[1].JSON).To(MatchJSON(`{"name": "b", "target": {"value": 99.9}}`))
+	})
+
+	It("should read all documents in a directory in order of file name", func() {
+		b := write("b.yml", "name: b\n")
+		a := write("a.yaml", "name: a1\n---\nname: a2\n")
+		c := write("c.JSON", `{"name": "c"}`)
+		write("README.md", "name: ignored\n")
+		Expect(os.Mkdir(filepath.Join(dir, "d.yaml"), 0755)).To(Succeed())
+
+		docs, err := readProtoDocuments(dir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sources(docs)).To(Equal([]string{
+			a + " (document 0)",
+			a + " (document 1)",
+			b + " (document 0)",
+			c + " (document 0)",
+		}))
+	})
+
+	It("should return an error for invalid or missing files", func() {
+		_, err := readProtoDocuments(filepath.Join(dir, "missing.yaml"))
+		Expect(err).To(MatchError(os.ErrNotExist))
+
+		path := write("invalid.yaml", "name: a\n---\nname: [b\n")
+		_, err = readProtoDocuments(path)
+		Expect(err).To(MatchError(ContainSubstring("document 1")))
+	})
+})
@@ -42,6 +42,8 @@ func BuildRoleBindingsCmd() *cobra.Command {
 func BuildRolesCreateCmd() *cobra.Command {
 	var clusterIDs []string
 	var matchLabelsStrings []string
+	var verbs []string
+	var resources []string
 	matchLabels := map[string]string{}
 	cmd := &cobra.Command{
 		Use:               "create <role-id>",
@@ -64,6 +66,8 @@ func BuildRolesCreateCmd() *cobra.Command {
 				MatchLabels: &corev1.LabelSelector{
 					MatchLabels: matchLabels,
 				},
+				Verbs:     verbs,
+				Resources: resources,
 			}
 			_, err := mgmtClient.CreateRole(cmd.Context(), role)
 			if err != nil {
@@ -74,6 +78,8 @@ func BuildRolesCreateCmd() *cobra.Command {
 	}
 	cmd.Flags().StringSliceVar(&clusterIDs, "cluster-ids", []string{}, "Explicit cluster IDs to allow")
 	cmd.Flags().StringSliceVar(&matchLabelsStrings, "match-labels", []string{}, "List of key=value cluster labels to match allowed clusters")
+	cmd.Flags().StringSliceVar(&verbs, "verbs", []string{}, "Allowed verbs (read, write, admin); defaults to read")
+	cmd.Flags().StringSliceVar(&resources, "resources", []string{}, "Resource kinds the role applies to (metrics, logs, alertconditions, slos, tokens, clusters, roles or *); defaults to metrics")
 	return cmd
 }
 
@@ -38,6 +38,12 @@ func managementPreRunE(cmd *cobra.Command, _ []string) error {
 		if err == nil {
 			objects := cliutil.LoadConfigObjectsOrDie(path, lg)
 			objects.Visit(func(obj *v1beta1.GatewayConfig) {
+				if clientAuth := obj.Spec.Management.ClientAuth; clientAuth != nil &&
+					!strings.HasPrefix(obj.Spec.Management.GRPCListenAddress, "unix://") {
+					// the tcp listener requires a client certificate
+					address = clientAuth.LocalListenAddress
+					return
+				}
 				address = strings.TrimPrefix(obj.Spec.Management.GRPCListenAddress, "tcp://")
 			})
 		}
@@ -0,0 +1,57 @@
+//go:build !noplugins
+
+package commands
+
+import (
+	"github.com/rancher/opni/plugins/alerting/pkg/apis/server/condition"
+	"github.com/rancher/opni/plugins/alerting/pkg/apis/server/endpoint"
+	"github.com/rancher/opni/plugins/alerting/pkg/apis/server/log"
+	"github.com/spf13/cobra"
+)
+
+var (
+	alertConditionsClient condition.AlertConditionsClient
+	alertEndpointsClient  endpoint.AlertEndpointsClient
+	alertLogsClient       log.AlertLogsClient
+)
+
+func ConfigureAlertingCommand(cmd *cobra.Command) {
+	if cmd.PersistentPreRunE == nil {
+		cmd.PersistentPreRunE = alertingPreRunE
+	} else {
+		oldPreRunE := cmd.PersistentPreRunE
+		cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+			if err := oldPreRunE(cmd, args); err != nil {
+				return err
+			}
+			return alertingPreRunE(cmd, args)
+		}
+	}
+}
+
+func alertingPreRunE(cmd *cobra.Command, args []string) error {
+	if managementListenAddress == "" {
+		panic("bug: managementListenAddress is empty")
+	}
+	cc, err := condition.NewClient(cmd.Context(),
+		condition.WithListenAddress(managementListenAddress))
+	if err != nil {
+		return err
+	}
+	alertConditionsClient = cc
+
+	ec, err := endpoint.NewClient(cmd.Context(),
+		endpoint.WithListenAddress(managementListenAddress))
+	if err != nil {
+		return err
+	}
+	alertEndpointsClient = ec
+
+	lc, err := log.NewClient(cmd.Context(),
+		log.WithListenAddress(managementListenAddress))
+	if err != nil {
+		return err
+	}
+	alertLogsClient = lc
+	return nil
+}
@@ -0,0 +1,37 @@
+//go:build !noplugins
+
+package commands
+
+import (
+	"github.com/rancher/opni/plugins/slo/pkg/apis/slo"
+	"github.com/spf13/cobra"
+)
+
+var sloClient slo.SLOClient
+
+func ConfigureSLOCommand(cmd *cobra.Command) {
+	if cmd.PersistentPreRunE == nil {
+		cmd.PersistentPreRunE = sloPreRunE
+	} else {
+		oldPreRunE := cmd.PersistentPreRunE
+		cmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+			if err := oldPreRunE(cmd, args); err != nil {
+				return err
+			}
+			return sloPreRunE(cmd, args)
+		}
+	}
+}
+
+func sloPreRunE(cmd *cobra.Command, args []string) error {
+	if managementListenAddress == "" {
+		panic("bug: managementListenAddress is empty")
+	}
+	c, err := slo.NewClient(cmd.Context(),
+		slo.WithListenAddress(managementListenAddress))
+	if err != nil {
+		return err
+	}
+	sloClient = c
+	return nil
+}
@@ -0,0 +1,589 @@
+//go:build !noplugins
+
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	cliutil "github.com/rancher/opni/pkg/opni/util"
+	"github.com/rancher/opni/plugins/slo/pkg/apis/slo"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func BuildSLOCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "slo",
+		Short: "Interact with SLO plugin APIs",
+	}
+
+	cmd.AddCommand(BuildSLOListCmd())
+	cmd.AddCommand(BuildSLOGetCmd())
+	cmd.AddCommand(BuildSLOCreateCmd())
+	cmd.AddCommand(BuildSLOUpdateCmd())
+	cmd.AddCommand(BuildSLOApplyCmd())
+	cmd.AddCommand(BuildSLODeleteCmd())
+	cmd.AddCommand(BuildSLOCloneCmd())
+	cmd.AddCommand(BuildSLOCloneToCmd())
+	cmd.AddCommand(BuildSLOStatusCmd())
+	cmd.AddCommand(BuildSLOPreviewCmd())
+	cmd.AddCommand(BuildSLOServicesCmd())
+	cmd.AddCommand(BuildSLOMetricsCmd())
+	cmd.AddCommand(BuildSLOEventsCmd())
+
+	ConfigureManagementCommand(cmd)
+	ConfigureSLOCommand(cmd)
+	return cmd
+}
+
+func BuildSLOListCmd() *cobra.Command {
+	var outputFormat string
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List SLOs",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			list, err := sloClient.ListSLOs(cmd.Context(), &emptypb.Empty{})
+			if err != nil {
+				return err
+			}
+			return printOutput(outputFormat, list, func() string {
+				states := map[string]string{}
+				for _, item := range list.GetItems() {
+					stat, err := sloClient.Status(cmd.Context(), &corev1.Reference{Id: item.GetId()})
+					if err != nil {
+						states[item.GetId()] = "-"
+						continue
+					}
+					states[item.GetId()] = stat.GetState().String()
+				}
+				return cliutil.RenderSLOList(list, states)
+			})
+		},
+	}
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table|json|yaml)")
+	return cmd
+}
+
+func BuildSLOGetCmd() *cobra.Command {
+	var outputFormat string
+	cmd := &cobra.Command{
+		Use:   "get <id>",
+		Short: "Show an SLO",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := sloClient.GetSLO(cmd.Context(), &corev1.Reference{Id: args[0]})
+			if err != nil {
+				return err
+			}
+			return printOutput(outputFormat, data, func() string {
+				return cliutil.RenderSLOList(&slo.ServiceLevelObjectiveList{
+					Items: []*slo.SLOData{data},
+				}, nil)
+			})
+		},
+	}
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "yaml", "Output format (table|json|yaml)")
+	return cmd
+}
+
+func BuildSLOCreateCmd() *cobra.Command {
+	var file string
+	cmd := &cobra.Command{
+		Use:   "create --file <path>",
+		Short: "Create an SLO from a YAML or JSON file",
+		Long: `Create an SLO from a YAML or JSON file. The file contains the SLO definition
+in the same format as the 'SLO' field in the output of 'opni slo get'.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			def := &slo.ServiceLevelObjective{}
+			if err := readProtoFile(file, def); err != nil {
+				return err
+			}
+			ref, err := sloClient.CreateSLO(cmd.Context(), &slo.CreateSLORequest{
+				Slo: def,
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Println(ref.GetId())
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&file, "file", "f", "", "Path to the SLO definition, or - to read from stdin")
+	cmd.MarkFlagRequired("file")
+	return cmd
+}
+
+func BuildSLOUpdateCmd() *cobra.Command {
+	var file string
+	cmd := &cobra.Command{
+		Use:   "update <id> --file <path>",
+		Short: "Replace an SLO with the definition in a YAML or JSON file",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			def := &slo.ServiceLevelObjective{}
+			if err := readProtoFile(file, def); err != nil {
+				return err
+			}
+			if _, err := sloClient.UpdateSLO(cmd.Context(), &slo.SLOData{
+				Id:  args[0],
+				SLO: def,
+			}); err != nil {
+				return err
+			}
+			lg.With(
+				"id", args[0],
+			).Info("SLO updated")
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&file, "file", "f", "", "Path to the SLO definition, or - to read from stdin")
+	cmd.MarkFlagRequired("file")
+	return cmd
+}
+
+func BuildSLOApplyCmd() *cobra.Command {
+	var path string
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "apply --file <path>",
+		Short: "Create or update SLOs from YAML or JSON files",
+		Long: `Create or update SLOs from YAML or JSON files.
+
+The path may be a file, or a directory containing .yaml, .yml or .json files.
+Files may contain multiple YAML documents separated by '---'. Each document
+has the same format as the output of 'opni slo get', for example:
+
+id: my-slo-id # optional
+SLO:
+  name: api-availability
+  datasource: monitoring
+  clusterId: my-cluster
+  serviceId: api
+  goodMetricName: http_requests_total
+  totalMetricName: http_requests_total
+  goodEvents:
+    - key: code
+      vals: ["200"]
+  sloPeriod: 30d
+  budgetingInterval: 300s
+  target:
+    value: 99.9
+
+If a document has an id, the SLO with that id is updated. Otherwise, the SLO
+with the same name, cluster and service is updated, or a new SLO is created
+if there is none. SLOs which are not in the files are left unchanged.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			docs, err := readProtoDocuments(path)
+			if err != nil {
+				return err
+			}
+			items := make([]*slo.SLOData, 0, len(docs))
+			for _, doc := range docs {
+				data := &slo.SLOData{}
+				if err := protojson.Unmarshal(doc.JSON, data); err != nil {
+					return fmt.Errorf("failed to parse %s: %w", doc.Source, err)
+				}
+				if err := data.Validate(); err != nil {
+					return fmt.Errorf("invalid SLO in %s: %w", doc.Source, err)
+				}
+				items = append(items, data)
+			}
+			existing, err := sloClient.ListSLOs(cmd.Context(), &emptypb.Empty{})
+			if err != nil {
+				return err
+			}
+
+			failed := 0
+			for i, data := range items {
+				if err := applySLO(cmd.Context(), data, existing.GetItems(), dryRun); err != nil {
+					lg.With(
+						"source", docs[i].Source,
+						"name", data.GetSLO().GetName(),
+						"error", err,
+					).Error("failed to apply SLO")
+					failed++
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("failed to apply %d of %d SLOs", failed, len(items))
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&path, "file", "f", "", "Path to a file or directory of SLO definitions, or - to read from stdin")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only print the changes that would be made")
+	cmd.MarkFlagRequired("file")
+	return cmd
+}
+
+// applySLO creates or updates a single SLO; see 'opni slo apply --help'.
+func applySLO(ctx context.Context, data *slo.SLOData, existing []*slo.SLOData, dryRun bool) error {
+	var current *slo.SLOData
+	if data.GetId() != "" {
+		for _, item := range existing {
+			if item.GetId() == data.GetId() {
+				current = item
+				break
+			}
+		}
+		if current == nil {
+			return fmt.Errorf("SLO %s not found", data.GetId())
+		}
+	} else {
+		for _, item := range existing {
+			if item.GetSLO().GetName() == data.GetSLO().GetName() &&
+				item.GetSLO().GetClusterId() == data.GetSLO().GetClusterId() &&
+				item.GetSLO().GetServiceId() == data.GetSLO().GetServiceId() {
+				if current != nil {
+					return fmt.Errorf("multiple SLOs named %q exist for service %s in cluster %s; set the id of the SLO to update",
+						data.GetSLO().GetName(), data.GetSLO().GetServiceId(), data.GetSLO().GetClusterId())
+				}
+				current = item
+			}
+		}
+	}
+
+	lg := lg.With(
+		"name", data.GetSLO().GetName(),
+		"cluster", data.GetSLO().GetClusterId(),
+	)
+	if dryRun {
+		lg = lg.With("dryRun", true)
+	}
+	switch {
+	case current == nil:
+		if dryRun {
+			lg.Info("SLO would be created")
+			return nil
+		}
+		ref, err := sloClient.CreateSLO(ctx, &slo.CreateSLORequest{
+			Slo: data.GetSLO(),
+		})
+		if err != nil {
+			return err
+		}
+		lg.With("id", ref.GetId()).Info("SLO created")
+	case proto.Equal(current.GetSLO(), data.GetSLO()):
+		lg.With("id", current.GetId()).Info("SLO unchanged")
+	default:
+		if dryRun {
+			lg.With("id", current.GetId()).Info("SLO would be updated")
+			return nil
+		}
+		if _, err := sloClient.UpdateSLO(ctx, &slo.SLOData{
+			Id:        current.GetId(),
+			SLO:       data.GetSLO(),
+			CreatedAt: current.GetCreatedAt(),
+		}); err != nil {
+			return err
+		}
+		lg.With("id", current.GetId()).Info("SLO updated")
+	}
+	return nil
+}
+
+func BuildSLODeleteCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "delete <id> [<id>...]",
+		Aliases: []string{"rm"},
+		Short:   "Delete SLOs",
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, id := range args {
+				if _, err := sloClient.DeleteSLO(cmd.Context(), &corev1.Reference{Id: id}); err != nil {
+					return fmt.Errorf("failed to delete SLO %s: %w", id, err)
+				}
+				lg.With(
+					"id", id,
+				).Info("SLO deleted")
+			}
+			return nil
+		},
+	}
+	return cmd
+}
+
+func BuildSLOCloneCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "clone <id>",
+		Short: "Create a copy of an SLO",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := sloClient.CloneSLO(cmd.Context(), &corev1.Reference{Id: args[0]})
+			if err != nil {
+				return err
+			}
+			fmt.Println(data.GetId())
+			return nil
+		},
+	}
+	return cmd
+}
+
+func BuildSLOCloneToCmd() *cobra.Command {
+	var clusters []string
+	cmd := &cobra.Command{
+		Use:   "clone-to <id> --clusters <cluster-id>[,<cluster-id>...]",
+		Short: "Copy an SLO to other clusters",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			refs := make([]*corev1.Reference, 0, len(clusters))
+			for _, id := range clusters {
+				refs = append(refs, &corev1.Reference{Id: id})
+			}
+			resp, err := sloClient.CloneToClusters(cmd.Context(), &slo.MultiClusterSLO{
+				CloneId:  &corev1.Reference{Id: args[0]},
+				Clusters: refs,
+			})
+			if err != nil {
+				return err
+			}
+			for _, failure := range resp.GetFailures() {
+				lg.Error(failure)
+			}
+			if n := len(resp.GetFailures()); n > 0 {
+				return fmt.Errorf("failed to clone SLO to %d of %d clusters", n, len(clusters))
+			}
+			lg.With(
+				"id", args[0],
+				"clusters", clusters,
+			).Info("SLO cloned")
+			return nil
+		},
+	}
+	cmd.Flags().StringSliceVar(&clusters, "clusters", nil, "Clusters to copy the SLO to")
+	cmd.MarkFlagRequired("clusters")
+	cmd.RegisterFlagCompletionFunc("clusters", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeClusters(cmd, args, toComplete)
+	})
+	return cmd
+}
+
+func BuildSLOStatusCmd() *cobra.Command {
+	var outputFormat string
+	cmd := &cobra.Command{
+		Use:   "status [<id>...]",
+		Short: "Show the state and remaining error budget of SLOs",
+		Long: `Show the state and remaining error budget of SLOs. If no ids are given, all
+SLOs are shown. The error budget is computed from the SLI over the SLO period.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var items []*slo.SLOData
+			if len(args) == 0 {
+				list, err := sloClient.ListSLOs(cmd.Context(), &emptypb.Empty{})
+				if err != nil {
+					return err
+				}
+				items = list.GetItems()
+			} else {
+				for _, id := range args {
+					data, err := sloClient.GetSLO(cmd.Context(), &corev1.Reference{Id: id})
+					if err != nil {
+						return fmt.Errorf("failed to get SLO %s: %w", id, err)
+					}
+					items = append(items, data)
+				}
+			}
+
+			rows := make([]cliutil.SLOStatusRow, 0, len(items))
+			values := make([]any, 0, len(items))
+			for _, data := range items {
+				row := cliutil.SLOStatusRow{
+					Id:    data.GetId(),
+					Name:  data.GetSLO().GetName(),
+					State: "-",
+				}
+				value := map[string]any{
+					"id":   data.GetId(),
+					"name": data.GetSLO().GetName(),
+				}
+				if stat, err := sloClient.Status(cmd.Context(), &corev1.Reference{Id: data.GetId()}); err == nil {
+					row.State = stat.GetState().String()
+					value["state"] = row.State
+					if budget, ok := cliutil.ErrorBudgetFromStatus(stat); ok {
+						row.Budget = &budget
+						value["sli"] = budget.SLI
+						value["objective"] = budget.Objective
+						value["errorBudgetRemaining"] = budget.Remaining
+					}
+				} else {
+					value["error"] = err.Error()
+				}
+				rows = append(rows, row)
+				values = append(values, value)
+			}
+
+			out, err := structpb.NewStruct(map[string]any{
+				"items": values,
+			})
+			if err != nil {
+				return err
+			}
+			return printOutput(outputFormat, out, func() string {
+				return cliutil.RenderSLOStatusList(rows)
+			})
+		},
+	}
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table|json|yaml)")
+	return cmd
+}
+
+func BuildSLOPreviewCmd() *cobra.Command {
+	var file string
+	var width int
+	var outputFormat string
+	cmd := &cobra.Command{
+		Use:   "preview <id> | --file <path>",
+		Short: "Preview the SLI and alert windows of an SLO over its period",
+		Long: `Preview the SLI and alert windows of an existing SLO, or of an SLO definition
+in a YAML or JSON file which has not been created yet. The remaining error
+budget is only shown for existing SLOs.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			def := &slo.ServiceLevelObjective{}
+			var budget *cliutil.ErrorBudget
+			switch {
+			case len(args) == 1 && file == "":
+				data, err := sloClient.GetSLO(cmd.Context(), &corev1.Reference{Id: args[0]})
+				if err != nil {
+					return err
+				}
+				def = data.GetSLO()
+				stat, err := sloClient.Status(cmd.Context(), &corev1.Reference{Id: args[0]})
+				if err != nil {
+					lg.With(
+						"id", args[0],
+						"error", err,
+					).Warn("failed to get SLO status")
+				} else if b, ok := cliutil.ErrorBudgetFromStatus(stat); ok {
+					budget = &b
+				}
+			case len(args) == 0 && file != "":
+				if err := readProtoFile(file, def); err != nil {
+					return err
+				}
+			default:
+				return errors.New("either an SLO id or --file must be given")
+			}
+			preview, err := sloClient.Preview(cmd.Context(), &slo.CreateSLORequest{
+				Slo: def,
+			})
+			if err != nil {
+				return err
+			}
+			return printOutput(outputFormat, preview, func() string {
+				return cliutil.RenderSLOPreview(preview.GetPlotVector(), budget, width)
+			})
+		},
+	}
+	cmd.Flags().StringVarP(&file, "file", "f", "", "Path to an SLO definition, or - to read from stdin")
+	cmd.Flags().IntVar(&width, "width", 80, "Width of the SLI sparkline")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table|json|yaml)")
+	return cmd
+}
+
+func BuildSLOServicesCmd() *cobra.Command {
+	var datasource, cluster string
+	var outputFormat string
+	cmd := &cobra.Command{
+		Use:   "services",
+		Short: "List services discovered in a cluster",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			list, err := sloClient.ListServices(cmd.Context(), &slo.ListServicesRequest{
+				Datasource: datasource,
+				ClusterId:  cluster,
+			})
+			if err != nil {
+				return err
+			}
+			return printOutput(outputFormat, list, func() string {
+				return cliutil.RenderSLOServiceList(list)
+			})
+		},
+	}
+	addSLODiscoveryFlags(cmd, &datasource, &cluster)
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table|json|yaml)")
+	return cmd
+}
+
+func BuildSLOMetricsCmd() *cobra.Command {
+	var datasource, cluster, service string
+	var outputFormat string
+	cmd := &cobra.Command{
+		Use:   "metrics",
+		Short: "List metrics which can be used in SLOs for a service",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			list, err := sloClient.ListMetrics(cmd.Context(), &slo.ListMetricsRequest{
+				Datasource: datasource,
+				ClusterId:  cluster,
+				ServiceId:  service,
+			})
+			if err != nil {
+				return err
+			}
+			return printOutput(outputFormat, list, func() string {
+				return cliutil.RenderSLOMetricGroupList(list)
+			})
+		},
+	}
+	addSLODiscoveryFlags(cmd, &datasource, &cluster)
+	cmd.Flags().StringVar(&service, "service", "", "Service ID")
+	cmd.MarkFlagRequired("service")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table|json|yaml)")
+	return cmd
+}
+
+func BuildSLOEventsCmd() *cobra.Command {
+	var datasource, cluster, service, metric string
+	var outputFormat string
+	cmd := &cobra.Command{
+		Use:   "events",
+		Short: "List the label values of a service's metric, for use as good or total events",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			list, err := sloClient.ListEvents(cmd.Context(), &slo.ListEventsRequest{
+				Datasource: datasource,
+				ClusterId:  cluster,
+				ServiceId:  service,
+				MetricId:   metric,
+			})
+			if err != nil {
+				return err
+			}
+			return printOutput(outputFormat, list, func() string {
+				return cliutil.RenderSLOEventList(list)
+			})
+		},
+	}
+	addSLODiscoveryFlags(cmd, &datasource, &cluster)
+	cmd.Flags().StringVar(&service, "service", "", "Service ID")
+	cmd.Flags().StringVar(&metric, "metric", "", "Metric ID")
+	cmd.MarkFlagRequired("service")
+	cmd.MarkFlagRequired("metric")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "Output format (table|json|yaml)")
+	return cmd
+}
+
+func addSLODiscoveryFlags(cmd *cobra.Command, datasource, cluster *string) {
+	cmd.Flags().StringVar(datasource, "datasource", "monitoring", "Datasource (monitoring|logging)")
+	cmd.Flags().StringVar(cluster, "cluster", "", "Cluster ID")
+	cmd.MarkFlagRequired("cluster")
+	cmd.RegisterFlagCompletionFunc("cluster", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeClusters(cmd, args, toComplete)
+	})
+}
+
+func init() {
+	AddCommandsToGroup(PluginAPIs, BuildSLOCmd())
+}
@@ -0,0 +1,231 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	managementv1 "github.com/rancher/opni/pkg/apis/management/v1"
+	cliutil "github.com/rancher/opni/pkg/opni/util"
+	"github.com/rancher/opni/pkg/task"
+)
+
+func BuildTasksCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "tasks",
+		Aliases: []string{"task"},
+		Short:   "Inspect and manage long-running tasks, such as capability uninstalls",
+	}
+	cmd.AddCommand(BuildTasksListCmd())
+	cmd.AddCommand(BuildTasksLogsCmd())
+	cmd.AddCommand(BuildTasksCancelCmd())
+	cmd.AddCommand(BuildTasksPruneCmd())
+	ConfigureManagementCommand(cmd)
+	return cmd
+}
+
+func BuildTasksListCmd() *cobra.Command {
+	var namespace, controller string
+	var states []string
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List tasks",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req := &managementv1.ListTasksRequest{
+				Namespace:  namespace,
+				Controller: controller,
+			}
+			for _, s := range states {
+				state, err := parseTaskState(s)
+				if err != nil {
+					return err
+				}
+				req.States = append(req.States, state)
+			}
+			list, err := mgmtClient.ListTasks(cmd.Context(), req)
+			if err != nil {
+				return err
+			}
+			fmt.Println(cliutil.RenderTaskList(list))
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&namespace, "plugin", "", "Only list tasks owned by the plugin with this module path")
+	cmd.Flags().StringVar(&controller, "controller", "", "Only list tasks from this controller (e.g. uninstall)")
+	cmd.Flags().StringSliceVar(&states, "state", nil, "Only list tasks in these states (pending, running, completed, failed, canceled)")
+	return cmd
+}
+
+func BuildTasksLogsCmd() *cobra.Command {
+	var namespace string
+	var follow bool
+	cmd := &cobra.Command{
+		Use:   "logs <controller>/<id>",
+		Short: "Show the logs and state transitions of a task",
+		Args:  cobra.ExactArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return completeTasks(cmd, args, toComplete)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ref, err := resolveTaskReference(cmd.Context(), namespace, args[0])
+			if err != nil {
+				return err
+			}
+			if !follow {
+				t, err := mgmtClient.GetTask(cmd.Context(), ref)
+				if err != nil {
+					return err
+				}
+				printTaskLogs(t.GetStatus(), time.Time{})
+				return nil
+			}
+			return followTask(cmd.Context(), ref)
+		},
+	}
+	cmd.Flags().StringVar(&namespace, "plugin", "", "Module path of the plugin which owns the task, if ambiguous")
+	cmd.Flags().BoolVarP(&follow, "follow", "f", false, "Follow the task's progress until it is done")
+	return cmd
+}
+
+func BuildTasksCancelCmd() *cobra.Command {
+	var namespace string
+	var follow bool
+	cmd := &cobra.Command{
+		Use:   "cancel <controller>/<id>",
+		Short: "Cancel a pending or running task",
+		Args:  cobra.ExactArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return completeTasks(cmd, args, toComplete, corev1.TaskState_Pending, corev1.TaskState_Running)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ref, err := resolveTaskReference(cmd.Context(), namespace, args[0])
+			if err != nil {
+				return err
+			}
+			if _, err := mgmtClient.CancelTask(cmd.Context(), ref); err != nil {
+				return err
+			}
+			lg.Info("Cancel request submitted successfully")
+			if !follow {
+				return nil
+			}
+			return followTask(cmd.Context(), ref)
+		},
+	}
+	cmd.Flags().StringVar(&namespace, "plugin", "", "Module path of the plugin which owns the task, if ambiguous")
+	cmd.Flags().BoolVar(&follow, "follow", true, "Follow the task's progress until it is done")
+	return cmd
+}
+
+func BuildTasksPruneCmd() *cobra.Command {
+	var namespace, controller string
+	var retention time.Duration
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Delete tasks which have been completed, failed, or canceled for longer than the retention period",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			pruned, err := mgmtClient.PruneTasks(cmd.Context(), &managementv1.PruneTasksRequest{
+				Retention:  durationpb.New(retention),
+				Namespace:  namespace,
+				Controller: controller,
+			})
+			if err != nil {
+				return err
+			}
+			if len(pruned.Items) == 0 {
+				lg.Info("No tasks to prune")
+				return nil
+			}
+			fmt.Println(cliutil.RenderTaskList(pruned))
+			lg.Infof("Pruned %d tasks", len(pruned.Items))
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&namespace, "plugin", "", "Only prune tasks owned by the plugin with this module path")
+	cmd.Flags().StringVar(&controller, "controller", "", "Only prune tasks from this controller (e.g. uninstall)")
+	cmd.Flags().DurationVar(&retention, "retention", 7*24*time.Hour, "How long to keep tasks after they are done")
+	return cmd
+}
+
+func parseTaskState(s string) (corev1.TaskState, error) {
+	for value, name := range corev1.TaskState_name {
+		if strings.EqualFold(name, s) {
+			return corev1.TaskState(value), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown task state %q", s)
+}
+
+// Resolves a task given as <controller>/<id> to a full reference. If the
+// namespace is not given, the task must be unique across all plugins.
+func resolveTaskReference(ctx context.Context, namespace, name string) (*managementv1.TaskReference, error) {
+	controller, id, ok := strings.Cut(name, "/")
+	if !ok || controller == "" || id == "" {
+		return nil, fmt.Errorf("invalid task %q (expected <controller>/<id>)", name)
+	}
+	if namespace != "" {
+		return &managementv1.TaskReference{
+			Namespace:  namespace,
+			Controller: controller,
+			Id:         id,
+		}, nil
+	}
+	list, err := mgmtClient.ListTasks(ctx, &managementv1.ListTasksRequest{
+		Controller: controller,
+	})
+	if err != nil {
+		return nil, err
+	}
+	matches := lo.Filter(list.Items, func(t *managementv1.Task, _ int) bool {
+		return t.GetRef().GetId() == id
+	})
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("task %q not found", name)
+	case 1:
+		return matches[0].GetRef(), nil
+	default:
+		plugins := lo.Map(matches, func(t *managementv1.Task, _ int) string {
+			return t.GetRef().GetNamespace()
+		})
+		return nil, fmt.Errorf("task %q exists in multiple plugins, use --plugin to select one of: %s", name, strings.Join(plugins, ", "))
+	}
+}
+
+// Prints the logs of a task as they are written, until the task is done.
+func followTask(ctx context.Context, ref *managementv1.TaskReference) error {
+	ctx, ca := context.WithCancel(ctx)
+	defer ca()
+	stream, err := mgmtClient.WatchTasks(ctx, &managementv1.ListTasksRequest{
+		Namespace:  ref.GetNamespace(),
+		Controller: ref.GetController(),
+	})
+	if err != nil {
+		return err
+	}
+	lastLogTimestamp := time.Time{}
+	for {
+		t, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		if t.GetRef().GetId() != ref.GetId() {
+			continue
+		}
+		lastLogTimestamp = printTaskLogs(t.GetStatus(), lastLogTimestamp)
+		if task.IsDone(t.GetStatus().GetState()) {
+			return nil
+		}
+	}
+}
+
+func init() {
+	AddCommandsToGroup(ManagementAPI, BuildTasksCmd())
+}
@@ -29,6 +29,10 @@ func BuildTokensCmd() *cobra.Command {
 func BuildTokensCreateCmd() *cobra.Command {
 	var ttl string
 	var labels []string
+	var maxUsages int64
+	var oneShot bool
+	var allowedClusterIds []string
+	var allowedLabels []string
 	tokensCreateCmd := &cobra.Command{
 		Use:   "create",
 		Short: "Create a bootstrap token",
@@ -43,8 +47,12 @@ func BuildTokensCreateCmd() *cobra.Command {
 			}
 			t, err := mgmtClient.CreateBootstrapToken(cmd.Context(),
 				&managementv1.CreateBootstrapTokenRequest{
-					Ttl:    durationpb.New(duration),
-					Labels: labelMap,
+					Ttl:               durationpb.New(duration),
+					Labels:            labelMap,
+					MaxUsages:         maxUsages,
+					OneShot:           oneShot,
+					AllowedClusterIds: allowedClusterIds,
+					AllowedLabels:     allowedLabels,
 				})
 			if err != nil {
 				lg.Fatal(err)
@@ -54,6 +62,10 @@ func BuildTokensCreateCmd() *cobra.Command {
 	}
 	tokensCreateCmd.Flags().StringVar(&ttl, "ttl", "300s", "Time to live")
 	tokensCreateCmd.Flags().StringSliceVar(&labels, "labels", []string{}, "Labels which will be auto-applied to any clusters created with this token")
+	tokensCreateCmd.Flags().Int64Var(&maxUsages, "max-usages", 0, "Maximum number of clusters which can join using this token (0 for unlimited)")
+	tokensCreateCmd.Flags().BoolVar(&oneShot, "one-shot", false, "Revoke the token after the first cluster joins using it")
+	tokensCreateCmd.Flags().StringSliceVar(&allowedClusterIds, "allowed-cluster-ids", []string{}, "Glob patterns matching the IDs of clusters allowed to join using this token")
+	tokensCreateCmd.Flags().StringSliceVar(&allowedLabels, "allowed-labels", []string{}, "Labels (key=value, where value may be a glob pattern) which clusters may request when joining")
 	return tokensCreateCmd
 }
 
@@ -2,6 +2,7 @@ package cliutil
 
 import (
 	"fmt"
+	"path"
 	"strings"
 	"time"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/prometheus/common/model"
 	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
 	managementv1 "github.com/rancher/opni/pkg/apis/management/v1"
+	"github.com/rancher/opni/pkg/task"
 	"github.com/rancher/opni/pkg/tokens"
 	"github.com/samber/lo"
 	"github.com/ttacon/chalk"
@@ -35,13 +37,24 @@ func RenderBootstrapTokenList(list *corev1.BootstrapTokenList) string {
 			token.HexID(),
 			token.EncodeHex(),
 			(time.Duration(t.GetMetadata().GetTtl()) * time.Second).String(),
-			t.GetMetadata().GetUsageCount(),
+			renderUsages(t.GetMetadata()),
 			strings.Join(JoinKeyValuePairs(t.GetMetadata().GetLabels()), "\n"),
 		})
 	}
 	return w.Render()
 }
 
+func renderUsages(md *corev1.BootstrapTokenMetadata) string {
+	usages := fmt.Sprint(md.GetUsageCount())
+	if limit := md.UsageLimit(); limit > 0 {
+		usages = fmt.Sprintf("%s/%d", usages, limit)
+	}
+	if md.GetOneShot() {
+		usages += " (one-shot)"
+	}
+	return usages
+}
+
 func RenderCertInfoChain(chain []*corev1.CertInfo) string {
 	w := table.NewWriter()
 	w.SetIndexColumn(1)
@@ -102,7 +115,7 @@ func RenderRole(role *corev1.Role) string {
 func RenderRoleList(list *corev1.RoleList) string {
 	w := table.NewWriter()
 	w.SetStyle(table.StyleColoredDark)
-	w.AppendHeader(table.Row{"ID", "SELECTOR", "CLUSTER IDS"})
+	w.AppendHeader(table.Row{"ID", "SELECTOR", "CLUSTER IDS", "VERBS", "RESOURCES"})
 	for _, role := range list.Items {
 		clusterIds := strings.Join(role.ClusterIDs, "\n")
 		if len(clusterIds) == 0 {
@@ -112,7 +125,13 @@ func RenderRoleList(list *corev1.RoleList) string {
 		if expressionStr == "" {
 			expressionStr = "(none)"
 		}
-		w.AppendRow(table.Row{role.Id, expressionStr, clusterIds})
+		verbs := lo.Map(role.EffectiveVerbs(), func(v corev1.Verb, _ int) string {
+			return string(v)
+		})
+		resources := lo.Map(role.EffectiveResources(), func(k corev1.ResourceKind, _ int) string {
+			return string(k)
+		})
+		w.AppendRow(table.Row{role.Id, expressionStr, clusterIds, strings.Join(verbs, "\n"), strings.Join(resources, "\n")})
 	}
 	return w.Render()
 }
@@ -217,18 +236,118 @@ func RenderAccessMatrix(am AccessMatrix) string {
 func RenderCapabilityList(list *managementv1.CapabilityList) string {
 	w := table.NewWriter()
 	w.SetStyle(table.StyleColoredDark)
-	w.AppendHeader(table.Row{"NAME", "SOURCE", "DRIVERS", "CLUSTERS"})
+	w.AppendHeader(table.Row{"NAME", "SOURCE", "DRIVERS", "DEPENDENCIES", "CLUSTERS"})
 	for _, c := range list.Items {
 		w.AppendRow(table.Row{
 			c.GetDetails().GetName(),
 			c.GetDetails().GetSource(),
 			strings.Join(c.GetDetails().GetDrivers(), ","),
+			strings.Join(c.GetDetails().GetDependencies(), ","),
 			c.GetNodeCount(),
 		})
 	}
 	return w.Render()
 }
 
+func RenderCapabilityPlan(plan *managementv1.CapabilityPlan) string {
+	w := table.NewWriter()
+	w.SetStyle(table.StyleColoredDark)
+	w.AppendHeader(table.Row{"STEP", "ACTION", "CAPABILITY", "REASON"})
+	for i, step := range plan.GetSteps() {
+		w.AppendRow(table.Row{
+			i + 1,
+			step.GetAction().String(),
+			step.GetName(),
+			step.GetReason(),
+		})
+	}
+	return w.Render()
+}
+
+func RenderBulkCapabilityResponse(resp *managementv1.BulkCapabilityResponse) string {
+	w := table.NewWriter()
+	w.SetStyle(table.StyleColoredDark)
+	w.AppendHeader(table.Row{"CLUSTER", "TASK", "SKIPPED"})
+	for _, item := range resp.GetItems() {
+		w.AppendRow(table.Row{
+			item.GetCluster().GetId(),
+			item.GetTask().GetId(),
+			item.GetSkipped(),
+		})
+	}
+	return w.Render()
+}
+
+func RenderClusterTaskStatuses(resp *managementv1.BulkCapabilityStatusResponse) string {
+	w := table.NewWriter()
+	w.SetStyle(table.StyleColoredDark)
+	w.AppendHeader(table.Row{"CLUSTER", "STATE", "PROGRESS", "ERROR"})
+	for _, item := range resp.GetItems() {
+		state, progress := "-", "-"
+		if status := item.GetStatus(); status != nil {
+			state = status.GetState().String()
+			if p := status.GetProgress(); p != nil {
+				progress = fmt.Sprintf("%d/%d", p.GetCurrent(), p.GetTotal())
+			}
+		}
+		w.AppendRow(table.Row{
+			item.GetCluster().GetId(),
+			state,
+			progress,
+			item.GetError(),
+		})
+	}
+	return w.Render()
+}
+
+func RenderTaskList(list *managementv1.TaskList) string {
+	w := table.NewWriter()
+	w.SetStyle(table.StyleColoredDark)
+	w.AppendHeader(table.Row{"PLUGIN", "CONTROLLER", "ID", "STATE", "PROGRESS", "LAST UPDATE"})
+	for _, t := range list.Items {
+		status := t.GetStatus()
+		state := status.GetState().String()
+		switch {
+		case status.GetCancelRequested() && !task.IsDone(status.GetState()):
+			state += " (canceling)"
+		case status.GetState() == corev1.TaskState_Pending && status.GetRetry().GetAttempts() > 0:
+			state += fmt.Sprintf(" (retry %d/%d)", status.GetRetry().GetAttempts(), status.GetRetryPolicy().GetMaxAttempts()-1)
+		}
+		progress := "-"
+		if p := status.GetProgress(); p != nil {
+			progress = fmt.Sprintf("%d/%d", p.GetCurrent(), p.GetTotal())
+		}
+		lastUpdate := "-"
+		if ts := lastTaskUpdate(status); !ts.IsZero() {
+			lastUpdate = fmt.Sprintf("%s ago", time.Since(ts).Round(time.Second))
+		}
+		w.AppendRow(table.Row{
+			path.Base(t.GetRef().GetNamespace()),
+			t.GetRef().GetController(),
+			t.GetRef().GetId(),
+			state,
+			progress,
+			lastUpdate,
+		})
+	}
+	return w.Render()
+}
+
+func lastTaskUpdate(status *corev1.TaskStatus) time.Time {
+	var last time.Time
+	for _, log := range status.GetLogs() {
+		if ts := log.GetTimestamp().AsTime(); ts.After(last) {
+			last = ts
+		}
+	}
+	for _, tr := range status.GetTransitions() {
+		if ts := tr.GetTimestamp().AsTime(); ts.After(last) {
+			last = ts
+		}
+	}
+	return last
+}
+
 func RenderMetricSamples(samples []*model.Sample) string {
 	w := table.NewWriter()
 	w.SetStyle(table.StyleColoredDark)
@@ -0,0 +1,159 @@
+//go:build !noplugins
+
+package cliutil
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	alertingv1 "github.com/rancher/opni/pkg/apis/alerting/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Returns the name of the field set in the message's oneof, or "-" if no
+// field is set.
+func oneofName(msg proto.Message, oneof string) string {
+	m := msg.ProtoReflect()
+	od := m.Descriptor().Oneofs().ByName(protoreflect.Name(oneof))
+	if od == nil {
+		return "-"
+	}
+	fd := m.WhichOneof(od)
+	if fd == nil {
+		return "-"
+	}
+	return string(fd.Name())
+}
+
+func formatTimestamp(ts *timestamppb.Timestamp) string {
+	if ts == nil {
+		return "-"
+	}
+	return ts.AsTime().Local().Format(time.RFC3339)
+}
+
+func RenderAlertConditionList(list *alertingv1.AlertConditionList, states map[string]string) string {
+	w := table.NewWriter()
+	w.SetStyle(table.StyleColoredDark)
+	header := table.Row{"ID", "NAME", "TYPE", "SEVERITY", "CLUSTER", "ENDPOINTS", "SILENCED UNTIL"}
+	if states != nil {
+		header = append(header, "STATE")
+	}
+	w.AppendHeader(header)
+	for _, item := range list.GetItems() {
+		cond := item.GetAlertCondition()
+		cluster := "-"
+		if ref := cond.GetClusterId(); ref != nil {
+			cluster = ref.GetId()
+		}
+		silencedUntil := "-"
+		if silence := cond.GetSilence(); silence != nil && silence.GetEndsAt().AsTime().After(time.Now()) {
+			silencedUntil = formatTimestamp(silence.GetEndsAt())
+		}
+		row := table.Row{
+			item.GetId().GetId(),
+			cond.GetName(),
+			oneofName(cond.GetAlertType(), "type"),
+			cond.GetSeverity().String(),
+			cluster,
+			len(cond.GetAttachedEndpoints().GetItems()),
+			silencedUntil,
+		}
+		if states != nil {
+			row = append(row, states[item.GetId().GetId()])
+		}
+		w.AppendRow(row)
+	}
+	return w.Render()
+}
+
+func RenderAlertEndpointList(list *alertingv1.AlertEndpointList) string {
+	w := table.NewWriter()
+	w.SetStyle(table.StyleColoredDark)
+	w.AppendHeader(table.Row{"ID", "NAME", "TYPE", "DESCRIPTION"})
+	for _, item := range list.GetItems() {
+		ep := item.GetEndpoint()
+		w.AppendRow(table.Row{
+			item.GetId().GetId(),
+			ep.GetName(),
+			oneofName(ep, "endpoint"),
+			ep.GetDescription(),
+		})
+	}
+	return w.Render()
+}
+
+func RenderAlertSilenceList(list *alertingv1.AlertConditionList) string {
+	w := table.NewWriter()
+	w.SetStyle(table.StyleColoredDark)
+	w.AppendHeader(table.Row{"CONDITION ID", "NAME", "SILENCE ID", "STARTS AT", "ENDS AT"})
+	for _, item := range list.GetItems() {
+		silence := item.GetAlertCondition().GetSilence()
+		w.AppendRow(table.Row{
+			item.GetId().GetId(),
+			item.GetAlertCondition().GetName(),
+			silence.GetSilenceId(),
+			formatTimestamp(silence.GetStartsAt()),
+			formatTimestamp(silence.GetEndsAt()),
+		})
+	}
+	return w.Render()
+}
+
+// RenderAlertTimeline renders the windows in the timeline, oldest first.
+// names maps condition ids to condition names.
+func RenderAlertTimeline(timeline *alertingv1.TimelineResponse, names map[string]string) string {
+	type row struct {
+		id     string
+		window *alertingv1.ActiveWindow
+	}
+	var rows []row
+	for id, windows := range timeline.GetItems() {
+		for _, window := range windows.GetWindows() {
+			rows = append(rows, row{id: id, window: window})
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].window.GetStart().AsTime().Before(rows[j].window.GetStart().AsTime())
+	})
+
+	w := table.NewWriter()
+	w.SetStyle(table.StyleColoredDark)
+	w.AppendHeader(table.Row{"CONDITION ID", "NAME", "TYPE", "START", "END"})
+	for _, r := range rows {
+		w.AppendRow(table.Row{
+			r.id,
+			names[r.id],
+			strings.TrimPrefix(r.window.GetType().String(), "Timeline_"),
+			formatTimestamp(r.window.GetStart()),
+			formatTimestamp(r.window.GetEnd()),
+		})
+	}
+	return w.Render()
+}
+
+func RenderAlertLogList(list *alertingv1.InformativeAlertLogList) string {
+	w := table.NewWriter()
+	w.SetStyle(table.StyleColoredDark)
+	w.AppendHeader(table.Row{"TIMESTAMP", "CONDITION ID", "NAME", "SEVERITY", "METADATA"})
+	for _, item := range list.GetItems() {
+		var metadata []string
+		for k, v := range item.GetLog().GetMetadata().AsMap() {
+			metadata = append(metadata, fmt.Sprintf("%s=%v", k, v))
+		}
+		sort.Strings(metadata)
+		w.AppendRow(table.Row{
+			formatTimestamp(item.GetLog().GetTimestamp()),
+			item.GetConditionId().GetId(),
+			item.GetCondition().GetName(),
+			item.GetCondition().GetSeverity().String(),
+			strings.Join(metadata, ","),
+		})
+	}
+	return w.Render()
+}
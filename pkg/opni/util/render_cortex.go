@@ -10,6 +10,8 @@ import (
 	"github.com/jedib0t/go-pretty/v6/text"
 	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
 	"github.com/rancher/opni/plugins/metrics/pkg/apis/cortexadmin"
+	"github.com/rancher/opni/plugins/metrics/pkg/apis/cortexops"
+	"github.com/rancher/opni/plugins/metrics/pkg/apis/node"
 	"github.com/samber/lo"
 	"golang.org/x/exp/slices"
 )
@@ -20,7 +22,7 @@ func RenderClusterListWithStats(list *corev1.ClusterList, status []*corev1.Healt
 	if stats == nil {
 		w.AppendHeader(table.Row{"ID", "LABELS", "CAPABILITIES", "STATUS"})
 	} else {
-		w.AppendHeader(table.Row{"ID", "LABELS", "CAPABILITIES", "STATUS", "NUM SERIES", "SAMPLE RATE", "RULE RATE"})
+		w.AppendHeader(table.Row{"ID", "LABELS", "CAPABILITIES", "STATUS", "NUM SERIES", "SAMPLE RATE", "RULE RATE", "REJECTED SAMPLES"})
 	}
 	for i, t := range list.Items {
 		labels := []string{}
@@ -39,10 +41,19 @@ func RenderClusterListWithStats(list *corev1.ClusterList, status []*corev1.Healt
 		if stats != nil {
 			for _, s := range stats.Items {
 				if string(s.UserID) == t.GetId() {
+					numSeries := fmt.Sprint(s.NumSeries)
+					if s.SeriesLimit > 0 {
+						numSeries += fmt.Sprintf(" (limit %d)", s.SeriesLimit)
+					}
+					sampleRate := fmt.Sprintf("%.1f/s", s.APIIngestionRate)
+					if s.IngestionRateLimit > 0 {
+						sampleRate += fmt.Sprintf(" (limit %.1f/s)", s.IngestionRateLimit)
+					}
 					row = append(row,
-						fmt.Sprint(s.NumSeries),
-						fmt.Sprintf("%.1f/s", s.APIIngestionRate),
+						numSeries,
+						sampleRate,
 						fmt.Sprintf("%.1f/s", s.RuleIngestionRate),
+						fmt.Sprint(s.RejectedSamples),
 					)
 					break
 				}
@@ -53,6 +64,34 @@ func RenderClusterListWithStats(list *corev1.ClusterList, status []*corev1.Healt
 	return w.Render()
 }
 
+func RenderRelabelRuleSetList(list *cortexops.RelabelRuleSetList) string {
+	w := table.NewWriter()
+	w.SetStyle(table.StyleColoredDark)
+	w.AppendHeader(table.Row{"ID", "CLUSTERS", "SELECTOR", "RULES"})
+	for _, rs := range list.GetItems() {
+		clusters := strings.Join(rs.GetSelector().GetClusterIDs(), ",")
+		labels := []string{}
+		for k, v := range rs.GetSelector().GetLabelSelector().GetMatchLabels() {
+			labels = append(labels, fmt.Sprintf("%s=%s", k, v))
+		}
+		for _, expr := range rs.GetSelector().GetLabelSelector().GetMatchExpressions() {
+			labels = append(labels, fmt.Sprintf("%s %s (%s)", expr.Key, expr.Operator, strings.Join(expr.Values, ",")))
+		}
+		slices.Sort(labels)
+		if clusters == "" && len(labels) == 0 {
+			clusters = "(all)"
+		}
+		actions := lo.Map(rs.GetRelabelConfigs(), func(c *node.RelabelConfig, _ int) string {
+			if c.GetAction() == "" {
+				return "replace"
+			}
+			return c.GetAction()
+		})
+		w.AppendRow(table.Row{rs.GetId(), clusters, strings.Join(labels, ","), strings.Join(actions, ",")})
+	}
+	return w.Render()
+}
+
 func RenderCortexClusterStatus(status *cortexadmin.CortexStatus) string {
 	tables := []string{
 		renderCortexServiceStatus(status),
@@ -0,0 +1,225 @@
+//go:build !noplugins
+
+package cliutil
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/rancher/opni/plugins/slo/pkg/apis/slo"
+)
+
+// ErrorBudget describes how much of an SLO's error budget is left, based on
+// the SLI over the SLO period.
+type ErrorBudget struct {
+	// SLI over the SLO period, in percent
+	SLI float64
+	// Objective, in percent
+	Objective float64
+	// Remaining error budget, in percent of the total budget. Negative if
+	// the SLO is breached.
+	Remaining float64
+}
+
+// ErrorBudgetFromStatus returns the error budget reported in an SLO's
+// status. The points of an SLO preview cannot be used for this, since
+// depending on the datasource they are not evaluated over the whole period.
+// If the SLO has no data, ok will be false.
+func ErrorBudgetFromStatus(status *slo.SLOStatus) (budget ErrorBudget, ok bool) {
+	b := status.GetBudget()
+	if b == nil {
+		return ErrorBudget{}, false
+	}
+	return ErrorBudget{
+		SLI:       b.GetSli(),
+		Objective: b.GetObjective(),
+		Remaining: b.GetRemaining(),
+	}, true
+}
+
+var sparklineTicks = []rune("▁▂▃▄▅▆▇█")
+
+// RenderSparkline renders the values as a sparkline at most width characters
+// long. Values are averaged into buckets if there are more values than
+// characters. NaN values are ignored, and buckets containing only NaN values
+// are rendered as spaces.
+func RenderSparkline(values []float64, width int) string {
+	if len(values) == 0 || width <= 0 {
+		return ""
+	}
+	if width > len(values) {
+		width = len(values)
+	}
+	buckets := make([]float64, width)
+	for i := range buckets {
+		start, end := i*len(values)/width, (i+1)*len(values)/width
+		sum, count := 0.0, 0
+		for _, v := range values[start:end] {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				continue
+			}
+			sum += v
+			count++
+		}
+		if count == 0 {
+			buckets[i] = math.NaN()
+		} else {
+			buckets[i] = sum / float64(count)
+		}
+	}
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, v := range buckets {
+		if math.IsNaN(v) {
+			continue
+		}
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+	}
+	var sb strings.Builder
+	for _, v := range buckets {
+		switch {
+		case math.IsNaN(v):
+			sb.WriteRune(' ')
+		case max == min:
+			sb.WriteRune(sparklineTicks[len(sparklineTicks)/2])
+		default:
+			idx := int((v - min) / (max - min) * float64(len(sparklineTicks)-1))
+			sb.WriteRune(sparklineTicks[idx])
+		}
+	}
+	return sb.String()
+}
+
+// RenderSLOPreview renders the SLI of an SLO preview as a sparkline, along
+// with the alert windows. The error budget is optional, since it is only
+// known for SLOs which have been created.
+func RenderSLOPreview(pv *slo.PlotVector, budget *ErrorBudget, width int) string {
+	values := make([]float64, 0, len(pv.GetItems()))
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, item := range pv.GetItems() {
+		v := item.GetSli()
+		values = append(values, v)
+		if !math.IsNaN(v) && !math.IsInf(v, 0) {
+			min = math.Min(min, v)
+			max = math.Max(max, v)
+		}
+	}
+
+	var sb strings.Builder
+	if math.IsInf(min, 0) {
+		sb.WriteString("No data\n")
+	} else {
+		items := pv.GetItems()
+		fmt.Fprintf(&sb, "SLI        %s\n", RenderSparkline(values, width))
+		fmt.Fprintf(&sb, "Range      %.4f%% - %.4f%%\n", min, max)
+		fmt.Fprintf(&sb, "Period     %s - %s\n",
+			formatTimestamp(items[0].GetTimestamp()), formatTimestamp(items[len(items)-1].GetTimestamp()))
+	}
+	fmt.Fprintf(&sb, "Objective  %.4f%%\n", pv.GetObjective()*100)
+	if budget != nil {
+		fmt.Fprintf(&sb, "Current    %.4f%%\n", budget.SLI)
+		fmt.Fprintf(&sb, "Budget     %.2f%% remaining\n", budget.Remaining)
+	}
+	if len(pv.GetWindows()) > 0 {
+		w := table.NewWriter()
+		w.SetStyle(table.StyleColoredDark)
+		w.AppendHeader(table.Row{"ALERT SEVERITY", "START", "END"})
+		for _, window := range pv.GetWindows() {
+			w.AppendRow(table.Row{window.GetSeverity(), formatTimestamp(window.GetStart()), formatTimestamp(window.GetEnd())})
+		}
+		sb.WriteString(w.Render())
+		sb.WriteString("\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func RenderSLOList(list *slo.ServiceLevelObjectiveList, states map[string]string) string {
+	w := table.NewWriter()
+	w.SetStyle(table.StyleColoredDark)
+	header := table.Row{"ID", "NAME", "CLUSTER", "SERVICE", "DATASOURCE", "TARGET", "PERIOD"}
+	if states != nil {
+		header = append(header, "STATE")
+	}
+	w.AppendHeader(header)
+	for _, item := range list.GetItems() {
+		s := item.GetSLO()
+		row := table.Row{
+			item.GetId(),
+			s.GetName(),
+			s.GetClusterId(),
+			s.GetServiceId(),
+			s.GetDatasource(),
+			fmt.Sprintf("%v%%", s.GetTarget().GetValue()),
+			s.GetSloPeriod(),
+		}
+		if states != nil {
+			row = append(row, states[item.GetId()])
+		}
+		w.AppendRow(row)
+	}
+	return w.Render()
+}
+
+type SLOStatusRow struct {
+	Id     string
+	Name   string
+	State  string
+	Budget *ErrorBudget
+}
+
+func RenderSLOStatusList(rows []SLOStatusRow) string {
+	w := table.NewWriter()
+	w.SetStyle(table.StyleColoredDark)
+	w.AppendHeader(table.Row{"ID", "NAME", "STATE", "SLI", "OBJECTIVE", "BUDGET REMAINING"})
+	for _, r := range rows {
+		sli, objective, remaining := "-", "-", "-"
+		if r.Budget != nil {
+			sli = fmt.Sprintf("%.4f%%", r.Budget.SLI)
+			objective = fmt.Sprintf("%.4f%%", r.Budget.Objective)
+			remaining = fmt.Sprintf("%.2f%%", r.Budget.Remaining)
+		}
+		w.AppendRow(table.Row{r.Id, r.Name, r.State, sli, objective, remaining})
+	}
+	return w.Render()
+}
+
+func RenderSLOServiceList(list *slo.ServiceList) string {
+	w := table.NewWriter()
+	w.SetStyle(table.StyleColoredDark)
+	w.AppendHeader(table.Row{"SERVICE", "CLUSTER"})
+	for _, svc := range list.GetItems() {
+		w.AppendRow(table.Row{svc.GetServiceId(), svc.GetClusterId()})
+	}
+	return w.Render()
+}
+
+func RenderSLOMetricGroupList(list *slo.MetricGroupList) string {
+	w := table.NewWriter()
+	w.SetStyle(table.StyleColoredDark)
+	w.AppendHeader(table.Row{"GROUP", "METRIC", "TYPE", "UNIT", "DESCRIPTION"})
+	groups := make([]string, 0, len(list.GetGroupNameToMetrics()))
+	for name := range list.GetGroupNameToMetrics() {
+		groups = append(groups, name)
+	}
+	sort.Strings(groups)
+	for _, group := range groups {
+		for _, m := range list.GetGroupNameToMetrics()[group].GetItems() {
+			md := m.GetMetadata()
+			w.AppendRow(table.Row{group, m.GetId(), md.GetType(), md.GetUnit(), md.GetDescription()})
+		}
+	}
+	return w.Render()
+}
+
+func RenderSLOEventList(list *slo.EventList) string {
+	w := table.NewWriter()
+	w.SetStyle(table.StyleColoredDark)
+	w.AppendHeader(table.Row{"KEY", "VALUES"})
+	for _, event := range list.GetItems() {
+		w.AppendRow(table.Row{event.GetKey(), strings.Join(event.GetVals(), ",")})
+	}
+	return w.Render()
+}
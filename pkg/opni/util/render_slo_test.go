@@ -0,0 +1,97 @@
+//go:build !noplugins
+
+package cliutil_test
+
+import (
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	cliutil "github.com/rancher/opni/pkg/opni/util"
+	"github.com/rancher/opni/pkg/test"
+	"github.com/rancher/opni/plugins/slo/pkg/apis/slo"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var _ = Describe("SLO rendering", Label(test.Unit), func() {
+	Context("RenderSparkline", func() {
+		It("should render each value as a tick scaled between the min and max", func() {
+			Expect(cliutil.RenderSparkline([]float64{0, 1, 2, 3, 4, 5, 6, 7}, 80)).To(Equal("▁▂▃▄▅▆▇█"))
+			Expect(cliutil.RenderSparkline([]float64{7, 0}, 80)).To(Equal("█▁"))
+		})
+		It("should average values into buckets if there are more values than characters", func() {
+			values := []float64{0, 0, 0, 0, 10, 10, 10, 10}
+			Expect(cliutil.RenderSparkline(values, 2)).To(Equal("▁█"))
+			Expect(cliutil.RenderSparkline(values, 4)).To(Equal("▁▁██"))
+
+			line := cliutil.RenderSparkline(make([]float64, 250), 80)
+			Expect(utf8.RuneCountInString(line)).To(Equal(80))
+		})
+		It("should render constant values in the middle", func() {
+			Expect(cliutil.RenderSparkline([]float64{99.9, 99.9, 99.9}, 80)).To(Equal("▅▅▅"))
+		})
+		It("should ignore NaN and infinite values", func() {
+			nan := math.NaN()
+			Expect(cliutil.RenderSparkline([]float64{0, nan, math.Inf(1), 7}, 80)).To(Equal("▁  █"))
+			Expect(cliutil.RenderSparkline([]float64{0, nan, 7, 7}, 2)).To(Equal("▁█"))
+			Expect(cliutil.RenderSparkline([]float64{nan, nan}, 80)).To(Equal("  "))
+		})
+		It("should render nothing without values or width", func() {
+			Expect(cliutil.RenderSparkline(nil, 80)).To(BeEmpty())
+			Expect(cliutil.RenderSparkline([]float64{1, 2}, 0)).To(BeEmpty())
+		})
+	})
+
+	Context("ErrorBudgetFromStatus", func() {
+		It("should return the error budget of the SLO status", func() {
+			budget, ok := cliutil.ErrorBudgetFromStatus(&slo.SLOStatus{
+				State: slo.SLOStatusState_Ok,
+				Budget: &slo.SLOErrorBudget{
+					Sli:       99.5,
+					Objective: 99,
+					Remaining: 50,
+				},
+			})
+			Expect(ok).To(BeTrue())
+			Expect(budget).To(Equal(cliutil.ErrorBudget{
+				SLI:       99.5,
+				Objective: 99,
+				Remaining: 50,
+			}))
+		})
+		It("should report a missing error budget", func() {
+			_, ok := cliutil.ErrorBudgetFromStatus(&slo.SLOStatus{State: slo.SLOStatusState_NoData})
+			Expect(ok).To(BeFalse())
+			_, ok = cliutil.ErrorBudgetFromStatus(nil)
+			Expect(ok).To(BeFalse())
+		})
+	})
+
+	Context("RenderSLOPreview", func() {
+		start := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+		pv := &slo.PlotVector{
+			Objective: 0.99,
+			Items: []*slo.DataPoint{
+				{Sli: 99, Timestamp: timestamppb.New(start)},
+				{Sli: 100, Timestamp: timestamppb.New(start.Add(time.Hour))},
+			},
+		}
+		It("should only show the error budget if it is given", func() {
+			out := cliutil.RenderSLOPreview(pv, nil, 80)
+			Expect(out).To(ContainSubstring("Range      99.0000% - 100.0000%"))
+			Expect(out).To(ContainSubstring("Objective  99.0000%"))
+			Expect(out).NotTo(ContainSubstring("Budget"))
+
+			out = cliutil.RenderSLOPreview(pv, &cliutil.ErrorBudget{SLI: 99.2, Objective: 99, Remaining: 20}, 80)
+			Expect(out).To(ContainSubstring("Current    99.2000%"))
+			Expect(out).To(ContainSubstring("Budget     20.00% remaining"))
+		})
+		It("should render previews without data", func() {
+			out := cliutil.RenderSLOPreview(&slo.PlotVector{Objective: 0.99}, nil, 80)
+			Expect(strings.Split(out, "\n")).To(Equal([]string{"No data", "Objective  99.0000%"}))
+		})
+	})
+})
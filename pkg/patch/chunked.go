@@ -0,0 +1,359 @@
+package patch
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/maphash"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// ChunkedDeltaPatcher generates patches by matching small chunks of the new
+// revision against an index of the old revision, and encoding the new
+// revision as a sequence of approximate copies from the old revision and
+// literal data. As in bsdiff, copies are stored as the byte-wise difference
+// between the old and new data, which is mostly zeros even where addresses in
+// a binary have shifted. The resulting patch is compressed with zstd.
+//
+// Compared to bsdiff, patches are generally larger, but generating them is
+// much faster and only requires memory proportional to the size of the inputs.
+type ChunkedDeltaPatcher struct{}
+
+var chunkedDeltaHeader = []byte("OPNICDC2")
+
+const (
+	opEnd byte = iota
+	opDiff
+	opInsert
+)
+
+const (
+	// Size of the chunks used to find matches between the two revisions.
+	matchChunkSize = 16
+	// Only chunks of the old revision starting at multiples of this stride
+	// are indexed. Matches of at least matchChunkSize+matchChunkStride-1 bytes
+	// are always found.
+	matchChunkStride = 4
+	// A match in a new location is only used if it is longer than the
+	// approximate match at the current location by at least this many bytes.
+	minMatchGain = 8
+)
+
+// chunkIndex maps chunks of the old revision to their offsets.
+type chunkIndex struct {
+	data    []byte
+	seed    maphash.Seed
+	offsets map[uint64]int
+}
+
+func newChunkIndex(data []byte) *chunkIndex {
+	idx := &chunkIndex{
+		data:    data,
+		seed:    maphash.MakeSeed(),
+		offsets: make(map[uint64]int, len(data)/matchChunkStride),
+	}
+	for offset := 0; offset+matchChunkSize <= len(data); offset += matchChunkStride {
+		sum := maphash.Bytes(idx.seed, data[offset:offset+matchChunkSize])
+		if _, ok := idx.offsets[sum]; !ok {
+			idx.offsets[sum] = offset
+		}
+	}
+	return idx
+}
+
+// lookup returns the offset of a chunk of the old revision equal to chunk.
+func (idx *chunkIndex) lookup(chunk []byte) (int, bool) {
+	offset, ok := idx.offsets[maphash.Bytes(idx.seed, chunk)]
+	if !ok || !bytes.Equal(idx.data[offset:offset+matchChunkSize], chunk) {
+		return 0, false
+	}
+	return offset, true
+}
+
+func (ChunkedDeltaPatcher) GeneratePatch(old io.Reader, new io.Reader, patchOut io.Writer) error {
+	oldData, err := io.ReadAll(old)
+	if err != nil {
+		return err
+	}
+	newData, err := io.ReadAll(new)
+	if err != nil {
+		return err
+	}
+
+	if _, err := patchOut.Write(chunkedDeltaHeader); err != nil {
+		return err
+	}
+	enc, err := zstd.NewWriter(patchOut, zstd.WithEncoderLevel(zstd.SpeedDefault))
+	if err != nil {
+		return err
+	}
+	w := &opWriter{w: bufio.NewWriter(enc)}
+	w.writeUvarint(uint64(len(newData)))
+	writeDeltas(w, oldData, newData)
+	w.writeByte(opEnd)
+
+	if w.err == nil {
+		w.err = w.w.Flush()
+	}
+	if err := enc.Close(); err != nil && w.err == nil {
+		w.err = err
+	}
+	return w.err
+}
+
+// writeDeltas encodes newData as a sequence of diff and insert operations
+// against oldData. This follows the structure of bsdiff, with the suffix
+// array search replaced by a lookup in a chunk index.
+func writeDeltas(w *opWriter, oldData, newData []byte) {
+	index := newChunkIndex(oldData)
+	// matches returns whether oldData[oldPos] and newData[newPos] are equal
+	matches := func(oldPos, newPos int) bool {
+		return oldPos >= 0 && oldPos < len(oldData) && oldData[oldPos] == newData[newPos]
+	}
+	matchLength := func(oldPos, newPos int) int {
+		n := 0
+		for oldPos+n < len(oldData) && newPos+n < len(newData) && oldData[oldPos+n] == newData[newPos+n] {
+			n++
+		}
+		return n
+	}
+	// search returns the offset and length of an exact match for the data at
+	// newPos, preferring the current alignment of the two revisions
+	search := func(newPos, offset int) (int, int) {
+		if newPos+matchChunkSize > len(newData) {
+			return 0, 0
+		}
+		chunk := newData[newPos : newPos+matchChunkSize]
+		if pos := newPos + offset; pos >= 0 && pos+matchChunkSize <= len(oldData) &&
+			bytes.Equal(oldData[pos:pos+matchChunkSize], chunk) {
+			return pos, matchLength(pos, newPos)
+		}
+		if pos, ok := index.lookup(chunk); ok {
+			return pos, matchLength(pos, newPos)
+		}
+		return 0, 0
+	}
+
+	var scan, pos, length, lastScan, lastPos, lastOffset int
+	for scan < len(newData) {
+		// find the next exact match which is better than continuing the
+		// approximate match at the current alignment
+		oldScore := 0
+		scan += length
+		for scsc := scan; scan < len(newData); scan++ {
+			pos, length = search(scan, lastOffset)
+			for ; scsc < scan+length; scsc++ {
+				if matches(scsc+lastOffset, scsc) {
+					oldScore++
+				}
+			}
+			if (length == oldScore && length != 0) || length > oldScore+minMatchGain {
+				break
+			}
+			if matches(scan+lastOffset, scan) {
+				oldScore--
+			}
+		}
+		if length == oldScore && scan != len(newData) {
+			continue
+		}
+
+		// extend the previous match forwards and the new match backwards, as
+		// long as at least half of the bytes match
+		var lenf, lenb int
+		for i, s, sf := 0, 0, 0; lastScan+i < scan && lastPos+i < len(oldData); {
+			if oldData[lastPos+i] == newData[lastScan+i] {
+				s++
+			}
+			i++
+			if s*2-i > sf*2-lenf {
+				sf, lenf = s, i
+			}
+		}
+		if scan < len(newData) {
+			for i, s, sb := 1, 0, 0; scan >= lastScan+i && pos >= i; i++ {
+				if oldData[pos-i] == newData[scan-i] {
+					s++
+				}
+				if s*2-i > sb*2-lenb {
+					sb, lenb = s, i
+				}
+			}
+		}
+		if overlap := lastScan + lenf - (scan - lenb); overlap > 0 {
+			// split the overlapping region where it best fits either match
+			s, ss, lens := 0, 0, 0
+			for i := 0; i < overlap; i++ {
+				if newData[lastScan+lenf-overlap+i] == oldData[lastPos+lenf-overlap+i] {
+					s++
+				}
+				if newData[scan-lenb+i] == oldData[pos-lenb+i] {
+					s--
+				}
+				if s > ss {
+					ss, lens = s, i+1
+				}
+			}
+			lenf += lens - overlap
+			lenb -= lens
+		}
+
+		w.writeDiff(lastPos, oldData[lastPos:lastPos+lenf], newData[lastScan:lastScan+lenf])
+		w.writeInsert(newData[lastScan+lenf : scan-lenb])
+
+		lastScan = scan - lenb
+		lastPos = pos - lenb
+		lastOffset = pos - scan
+	}
+}
+
+func (ChunkedDeltaPatcher) ApplyPatch(old io.Reader, patch io.Reader, newOut io.Writer) error {
+	oldData, err := io.ReadAll(old)
+	if err != nil {
+		return err
+	}
+	header := make([]byte, len(chunkedDeltaHeader))
+	if _, err := io.ReadFull(patch, header); err != nil {
+		return fmt.Errorf("failed to read patch header: %w", err)
+	}
+	if !bytes.Equal(header, chunkedDeltaHeader) {
+		return errors.New("invalid patch header")
+	}
+	dec, err := zstd.NewReader(patch)
+	if err != nil {
+		return err
+	}
+	defer dec.Close()
+	r := bufio.NewReader(dec)
+
+	newSize, err := binary.ReadUvarint(r)
+	if err != nil {
+		return fmt.Errorf("malformed patch: %w", err)
+	}
+	var written uint64
+	for {
+		op, err := r.ReadByte()
+		if err != nil {
+			return fmt.Errorf("malformed patch: %w", err)
+		}
+		switch op {
+		case opEnd:
+			if written != newSize {
+				return fmt.Errorf("malformed patch: expected %d bytes of output, got %d", newSize, written)
+			}
+			return nil
+		case opDiff:
+			offset, err := binary.ReadUvarint(r)
+			if err != nil {
+				return fmt.Errorf("malformed patch: %w", err)
+			}
+			length, err := binary.ReadUvarint(r)
+			if err != nil {
+				return fmt.Errorf("malformed patch: %w", err)
+			}
+			if offset > uint64(len(oldData)) || length > uint64(len(oldData))-offset {
+				return errors.New("malformed patch: diff out of range")
+			}
+			if length > newSize-written {
+				return errors.New("malformed patch: diff out of range")
+			}
+			if err := applyDiff(r, oldData[offset:offset+length], newOut); err != nil {
+				return err
+			}
+			written += length
+		case opInsert:
+			length, err := binary.ReadUvarint(r)
+			if err != nil {
+				return fmt.Errorf("malformed patch: %w", err)
+			}
+			if length > newSize-written {
+				return errors.New("malformed patch: insert out of range")
+			}
+			if _, err := io.CopyN(newOut, r, int64(length)); err != nil {
+				return fmt.Errorf("malformed patch: %w", err)
+			}
+			written += length
+		default:
+			return fmt.Errorf("malformed patch: unknown op %d", op)
+		}
+		if written > newSize {
+			return fmt.Errorf("malformed patch: expected %d bytes of output, got %d", newSize, written)
+		}
+	}
+}
+
+// applyDiff writes the sum of oldData and the diff read from r to out.
+func applyDiff(r io.Reader, oldData []byte, out io.Writer) error {
+	buf := make([]byte, 32<<10)
+	for len(oldData) > 0 {
+		n := len(buf)
+		if n > len(oldData) {
+			n = len(oldData)
+		}
+		if _, err := io.ReadFull(r, buf[:n]); err != nil {
+			return fmt.Errorf("malformed patch: %w", err)
+		}
+		for i := range buf[:n] {
+			buf[i] += oldData[i]
+		}
+		if _, err := out.Write(buf[:n]); err != nil {
+			return err
+		}
+		oldData = oldData[n:]
+	}
+	return nil
+}
+
+func (ChunkedDeltaPatcher) CheckFormat(reader io.ReaderAt) bool {
+	buf := make([]byte, len(chunkedDeltaHeader))
+	if _, err := reader.ReadAt(buf, 0); err == nil {
+		return bytes.Equal(buf, chunkedDeltaHeader)
+	}
+	return false
+}
+
+type opWriter struct {
+	w   *bufio.Writer
+	err error
+	buf [binary.MaxVarintLen64]byte
+}
+
+func (w *opWriter) writeByte(b byte) {
+	if w.err == nil {
+		w.err = w.w.WriteByte(b)
+	}
+}
+
+func (w *opWriter) writeUvarint(v uint64) {
+	if w.err == nil {
+		n := binary.PutUvarint(w.buf[:], v)
+		_, w.err = w.w.Write(w.buf[:n])
+	}
+}
+
+func (w *opWriter) writeDiff(offset int, oldData, newData []byte) {
+	if len(newData) == 0 {
+		return
+	}
+	w.writeByte(opDiff)
+	w.writeUvarint(uint64(offset))
+	w.writeUvarint(uint64(len(newData)))
+	for i := range newData {
+		w.writeByte(newData[i] - oldData[i])
+	}
+}
+
+func (w *opWriter) writeInsert(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	w.writeByte(opInsert)
+	w.writeUvarint(uint64(len(data)))
+	if w.err == nil {
+		_, w.err = w.w.Write(data)
+	}
+}
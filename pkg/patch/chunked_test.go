@@ -0,0 +1,140 @@
+package patch_test
+
+import (
+	"bytes"
+	"math/rand"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/spf13/afero"
+
+	"github.com/rancher/opni/pkg/config/v1beta1"
+	"github.com/rancher/opni/pkg/patch"
+	"github.com/rancher/opni/pkg/test"
+)
+
+var _ = Describe("Chunked Delta Patcher", Label("unit"), func() {
+	patcher := patch.ChunkedDeltaPatcher{}
+
+	generate := func(oldData, newData []byte) []byte {
+		out := new(bytes.Buffer)
+		Expect(patcher.GeneratePatch(bytes.NewReader(oldData), bytes.NewReader(newData), out)).To(Succeed())
+		return out.Bytes()
+	}
+	apply := func(oldData, p []byte) ([]byte, error) {
+		out := new(bytes.Buffer)
+		err := patcher.ApplyPatch(bytes.NewReader(oldData), bytes.NewReader(p), out)
+		return out.Bytes(), err
+	}
+
+	It("should generate patches that reproduce the new revision", func() {
+		for _, module := range []string{"test1", "test2"} {
+			oldBin, newBin := testBinaries[module]["v1"], testBinaries[module]["v2"]
+			p := generate(oldBin, newBin)
+			Expect(len(p)).To(BeNumerically("<", len(newBin)/2))
+			// should be comparable to bsdiff
+			Expect(len(p)).To(BeNumerically("<", testPatches[module].Len()*11/10))
+
+			result, err := apply(oldBin, p)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(Equal(newBin))
+		}
+	})
+	It("should handle empty and unrelated inputs", func() {
+		rng := rand.New(rand.NewSource(0))
+		random := make([]byte, 200<<10)
+		rng.Read(random)
+
+		for _, tc := range [][2][]byte{
+			{nil, nil},
+			{nil, random},
+			{random, nil},
+			{random[:100<<10], random[100<<10:]},
+			{random, append(append([]byte{}, random[50<<10:]...), random[:50<<10]...)},
+		} {
+			result, err := apply(tc[0], generate(tc[0], tc[1]))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(HaveLen(len(tc[1])))
+			if len(tc[1]) > 0 {
+				Expect(result).To(Equal(tc[1]))
+			}
+		}
+	})
+	It("should reuse data which has moved within the old revision", func() {
+		rng := rand.New(rand.NewSource(1))
+		oldData := make([]byte, 256<<10)
+		rng.Read(oldData)
+
+		By("shifting the data and changing bytes at regular intervals")
+		newData := append(make([]byte, 100), oldData...)
+		for i := 100; i < len(newData); i += 300 {
+			newData[i]++
+		}
+		p := generate(oldData, newData)
+		Expect(len(p)).To(BeNumerically("<", len(newData)/20))
+
+		result, err := apply(oldData, p)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(Equal(newData))
+	})
+	It("should be detected by its patch format", func() {
+		p := generate(testBinaries["test1"]["v1"], testBinaries["test1"]["v2"])
+		engine, ok := patch.NewPatcherFromFormat(bytes.NewReader(p))
+		Expect(ok).To(BeTrue())
+		Expect(engine).To(Equal(patch.ChunkedDeltaPatcher{}))
+
+		Expect(patcher.CheckFormat(bytes.NewReader(test1v1tov2Patch.Bytes()))).To(BeFalse())
+		Expect(patch.BsdiffPatcher{}.CheckFormat(bytes.NewReader(p))).To(BeFalse())
+	})
+	It("should reject invalid patches", func() {
+		oldBin, newBin := testBinaries["test1"]["v1"], testBinaries["test1"]["v2"]
+		p := generate(oldBin, newBin)
+
+		_, err := apply(oldBin, test1v1tov2Patch.Bytes())
+		Expect(err).To(MatchError("invalid patch header"))
+
+		_, err = apply(oldBin, p[:len(p)/2])
+		Expect(err).To(HaveOccurred())
+
+		By("applying the patch to the wrong revision")
+		_, err = apply(oldBin[:len(oldBin)/2], p)
+		Expect(err).To(HaveOccurred())
+	})
+	It("should report patch metrics when used by a cache", func() {
+		cache, err := patch.NewFilesystemCache(afero.NewMemMapFs(), v1beta1.FilesystemCacheSpec{
+			Dir: "/tmp",
+		}, patcher, test.Log)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cache.Archive(v1Manifest)).To(Succeed())
+		Expect(cache.Archive(v2Manifest)).To(Succeed())
+
+		before := cache.MetricsSnapshot()
+		from, to := v1Manifest.Items[0].Metadata.Digest, v2Manifest.Items[0].Metadata.Digest
+		p, err := cache.RequestPatch(from, to)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(patcher.CheckFormat(bytes.NewReader(p))).To(BeTrue())
+
+		after := cache.MetricsSnapshot()
+		Expect(after.PatchCalcCount).To(Equal(before.PatchCalcCount + 1))
+		Expect(after.PatchCalcTimeTotal).To(BeNumerically(">", before.PatchCalcTimeTotal))
+		Expect(after.PatchSizeBytesTotal).To(Equal(before.PatchSizeBytesTotal + int64(len(p))))
+		Expect(after.CompressionRatio()).To(BeNumerically(">", 1))
+
+		By("exporting the calculation time in seconds and in nanoseconds")
+		registry := prometheus.NewPedanticRegistry()
+		registry.MustRegister(cache.MetricsCollectors()...)
+		count, err := testutil.GatherAndCount(registry,
+			"opni_patch_calc_seconds_total",
+			"opni_patch_calc_nano_seconds_total",
+		)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(count).To(Equal(2))
+
+		By("requesting the same patch again")
+		_, err = cache.RequestPatch(from, to)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cache.MetricsSnapshot().PatchCalcCount).To(Equal(after.PatchCalcCount))
+	})
+})
@@ -14,6 +14,7 @@ import (
 
 	controlv1 "github.com/rancher/opni/pkg/apis/control/v1"
 	"github.com/rancher/opni/pkg/config/v1beta1"
+	"github.com/rancher/opni/pkg/plugins"
 	"github.com/spf13/afero"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/blake2b"
@@ -28,8 +29,9 @@ type PatchClient interface {
 
 type patchClient struct {
 	PatchClientOptions
-	fs pluginFs
-	lg *zap.SugaredLogger
+	fs       pluginFs
+	lg       *zap.SugaredLogger
+	verifier *plugins.Verifier
 }
 
 type PatchClientOptions struct {
@@ -67,6 +69,11 @@ func NewPatchClient(config v1beta1.PluginsSpec, lg *zap.SugaredLogger, opts ...P
 		return nil, fmt.Errorf("failed to stat plugin directory %s: %w", config.Dir, err)
 	}
 
+	verifier, err := plugins.NewVerifier(config.Signatures)
+	if err != nil {
+		return nil, err
+	}
+
 	tempDirBase, err := findTempDirBase(options.baseFs, config.Dir)
 	if err != nil {
 		return nil, err
@@ -85,7 +92,8 @@ func NewPatchClient(config v1beta1.PluginsSpec, lg *zap.SugaredLogger, opts ...P
 			dir:     config.Dir,
 			tempDir: tempDir,
 		},
-		lg: lg,
+		lg:       lg,
+		verifier: verifier,
 	}, nil
 }
 
@@ -255,7 +263,29 @@ func (pc *patchClient) Patch(patches *controlv1.PatchList) error {
 	return nil
 }
 
+// verify checks that the new plugin digest was signed by a trusted key.
+func (pc *patchClient) verify(entry *controlv1.PatchSpec) error {
+	digest, err := hex.DecodeString(entry.GetNewDigest())
+	if err != nil {
+		return internalErrf("invalid digest for plugin %s: %v", entry.Module, err)
+	}
+	lg := pc.lg.With(
+		"module", entry.GetModule(),
+		"digest", entry.GetNewDigest(),
+	)
+	if err := pc.verifier.Verify(lg, digest, entry.GetSignature()); err != nil {
+		lg.With(
+			zap.Error(err),
+		).Error("refusing to apply patch")
+		return status.Errorf(codes.PermissionDenied, "%s: %v", entry.Module, err)
+	}
+	return nil
+}
+
 func (pc *patchClient) doRename(entry *controlv1.PatchSpec) error {
+	if err := pc.verify(entry); err != nil {
+		return err
+	}
 	newFilename := string(entry.Data)
 	if _, err := pc.fs.Stat(newFilename); err == nil {
 		return unavailableErrf("could not rename plugin %s: destination %s already exists", entry.Module, entry.Filename)
@@ -269,6 +299,12 @@ func (pc *patchClient) doRename(entry *controlv1.PatchSpec) error {
 }
 
 func (pc *patchClient) doCreate(entry *controlv1.PatchSpec) error {
+	if err := pc.verify(entry); err != nil {
+		return err
+	}
+	if sum := blake2b.Sum256(entry.Data); hex.EncodeToString(sum[:]) != entry.GetNewDigest() {
+		return unavailableErrf("received corrupted data for plugin %s (checksum mismatch)", entry.Module)
+	}
 	pc.lg.With(
 		"path", entry.Filename,
 		"size", len(entry.Data),
@@ -281,6 +317,9 @@ func (pc *patchClient) doCreate(entry *controlv1.PatchSpec) error {
 }
 
 func (pc *patchClient) doUpdate(entry *controlv1.PatchSpec) error {
+	if err := pc.verify(entry); err != nil {
+		return err
+	}
 	pc.lg.With(
 		"filename", entry.Filename,
 		"size", len(entry.Data),
@@ -58,6 +58,7 @@ func op(ot optype, plugin string, version string, opts ...any) (retVal *controlv
 			Module:    testModules[plugin],
 			Data:      testBinaries[plugin][version],
 			NewDigest: testBinaryDigests[plugin][version],
+			Signature: sign(testBinaryDigests[plugin][version]),
 			Filename:  plugin,
 		}
 	case update:
@@ -67,6 +68,7 @@ func op(ot optype, plugin string, version string, opts ...any) (retVal *controlv
 			Data:      testPatches[plugin].Bytes(),
 			OldDigest: testBinaryDigests[plugin][version],
 			NewDigest: testBinaryDigests[plugin][opts[0].(string)],
+			Signature: sign(testBinaryDigests[plugin][opts[0].(string)]),
 			Filename:  plugin,
 		}
 	case remove:
@@ -82,6 +84,7 @@ func op(ot optype, plugin string, version string, opts ...any) (retVal *controlv
 			Module:    testModules[plugin],
 			OldDigest: testBinaryDigests[plugin][version],
 			NewDigest: testBinaryDigests[plugin][version],
+			Signature: sign(testBinaryDigests[plugin][version]),
 			Filename:  opts[0].(string),
 			Data:      []byte(opts[1].(string)),
 		}
@@ -172,7 +175,8 @@ var _ = Describe("Client", func() {
 			fsys = newFs("/plugins")
 			var err error
 			conf = v1beta1.PluginsSpec{
-				Dir: "/plugins",
+				Dir:        "/plugins",
+				Signatures: trustedKeys,
 			}
 			client, err = patch.NewPatchClient(conf, test.Log, patch.WithBaseFS(fsys))
 			Expect(err).NotTo(HaveOccurred())
@@ -331,7 +335,8 @@ var _ = Describe("Client", func() {
 		BeforeEach(func() {
 			fsys = newFs("/plugins")
 			conf = v1beta1.PluginsSpec{
-				Dir: "/plugins",
+				Dir:        "/plugins",
+				Signatures: trustedKeys,
 			}
 			var err error
 			client, err = patch.NewPatchClient(conf, test.Log, patch.WithBaseFS(fsys))
@@ -598,7 +603,8 @@ var _ = Describe("Client", func() {
 					Items: []*controlv1.PatchSpec{
 						op(update, test1, v1, v2),
 						op(update, test2, v1, v2, func(e *controlv1.PatchSpec) {
-							e.NewDigest = "foo"
+							e.NewDigest = testBinaryDigests[test1][v2]
+							e.Signature = sign(e.NewDigest)
 						}),
 					},
 				}
@@ -737,5 +743,73 @@ var _ = Describe("Client", func() {
 				Expect(client.Patch(patches)).To(Succeed())
 			})
 		})
+		When("receiving an unsigned plugin", func() {
+			It("should return a permission denied error", func() {
+				patches := &controlv1.PatchList{
+					Items: []*controlv1.PatchSpec{
+						op(create, test1, v1, func(e *controlv1.PatchSpec) {
+							e.Signature = nil
+						}),
+					},
+				}
+				err := client.Patch(patches)
+				Expect(err).To(HaveOccurred())
+				Expect(status.Code(err)).To(Equal(codes.PermissionDenied))
+
+				entries, err := fsys.ReadDir(conf.Dir)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(entries).To(BeEmpty())
+			})
+			It("should apply the patch if signatures are not enforced", func() {
+				conf.Signatures = v1beta1.SignatureSpec{
+					Mode: v1beta1.SignatureModeWarn,
+				}
+				client, err := patch.NewPatchClient(conf, test.Log, patch.WithBaseFS(fsys))
+				Expect(err).NotTo(HaveOccurred())
+
+				patches := &controlv1.PatchList{
+					Items: []*controlv1.PatchSpec{
+						op(create, test1, v1, func(e *controlv1.PatchSpec) {
+							e.Signature = nil
+						}),
+					},
+				}
+				Expect(client.Patch(patches)).To(Succeed())
+				Expect(b2sum(fsys, path.Join(conf.Dir, "test1"))).To(Equal(testBinaryDigests[test1][v1]))
+			})
+		})
+		When("receiving a plugin with an invalid signature", func() {
+			It("should return a permission denied error", func() {
+				patches := &controlv1.PatchList{
+					Items: []*controlv1.PatchSpec{
+						op(update, test1, v1, v2, func(e *controlv1.PatchSpec) {
+							e.Signature = sign(testBinaryDigests[test1][v1])
+						}),
+					},
+				}
+				Expect(client.Patch(&controlv1.PatchList{
+					Items: []*controlv1.PatchSpec{op(create, test1, v1)},
+				})).To(Succeed())
+
+				err := client.Patch(patches)
+				Expect(err).To(HaveOccurred())
+				Expect(status.Code(err)).To(Equal(codes.PermissionDenied))
+				Expect(b2sum(fsys, path.Join(conf.Dir, "test1"))).To(Equal(testBinaryDigests[test1][v1]))
+			})
+		})
+		When("receiving a create operation whose data does not match its digest", func() {
+			It("should return an unavailable error", func() {
+				patches := &controlv1.PatchList{
+					Items: []*controlv1.PatchSpec{
+						op(create, test1, v1, func(e *controlv1.PatchSpec) {
+							e.Data = testBinaries[test1][v2]
+						}),
+					},
+				}
+				err := client.Patch(patches)
+				Expect(err).To(HaveOccurred())
+				Expect(status.Code(err)).To(Equal(codes.Unavailable))
+			})
+		})
 	})
 })
@@ -122,10 +122,12 @@ func (p *FilesystemCache) generatePatch(oldDigest, newDigest string) ([]byte, er
 	if err != nil {
 		return nil, err
 	}
+	start := time.Now()
 	out := new(bytes.Buffer)
 	if err := p.patcher.GeneratePatch(bytes.NewReader(oldBin), bytes.NewReader(newBin), out); err != nil {
 		return nil, err
 	}
+	p.PatchGenerated(oldDigest, newDigest, time.Since(start), int64(out.Len()), int64(len(newBin)))
 	return out.Bytes(), nil
 }
 
@@ -160,7 +162,6 @@ func (p *FilesystemCache) RequestPatch(oldDigest, newDigest string) ([]byte, err
 					).Error("failed to write patch to disk")
 					return nil, err
 				}
-				p.IncPatchCalcSecsTotal(oldDigest, newDigest, float64(time.Since(start).Seconds()))
 				p.AddToTotalSizeBytes(oldDigest+"-to-"+newDigest, int64(len(patchData)))
 				p.AddToPatchCount(1)
 				return patchData, nil
@@ -39,6 +39,7 @@ func LeftJoinOn(gateway, agent *controlv1.PluginManifest) *controlv1.PatchList {
 				Filename:  ours.GetFilename(),
 				OldDigest: "",
 				NewDigest: ours.GetDigest(),
+				Signature: ours.GetSignature(),
 			})
 		} else {
 			// both sides have the plugin
@@ -50,6 +51,7 @@ func LeftJoinOn(gateway, agent *controlv1.PluginManifest) *controlv1.PatchList {
 					Filename:  ours.GetFilename(),
 					OldDigest: theirs.GetDigest(),
 					NewDigest: ours.GetDigest(),
+					Signature: ours.GetSignature(),
 				})
 			} else if ours.GetFilename() != theirs.GetFilename() {
 				// a plugin was renamed but the hash is the same
@@ -60,6 +62,7 @@ func LeftJoinOn(gateway, agent *controlv1.PluginManifest) *controlv1.PatchList {
 					Filename:  theirs.GetFilename(),
 					OldDigest: theirs.GetDigest(),
 					NewDigest: ours.GetDigest(),
+					Signature: ours.GetSignature(),
 				})
 			}
 		}
@@ -124,11 +127,19 @@ func GetFilesystemPlugins(dc plugins.DiscoveryConfig) (*controlv1.PluginArchive,
 				return
 			}
 			sum := hex.EncodeToString(hash.Sum(nil))
+			signature, err := plugins.ReadSignature(dc.Fs, md.BinaryPath)
+			if err != nil {
+				lg.With(
+					zap.Error(err),
+				).Error("failed to read plugin signature, skipping")
+				return
+			}
 			res.Items[i] = &controlv1.PluginArchiveEntry{
 				Metadata: &controlv1.PluginManifestEntry{
-					Module:   md.Module,
-					Filename: filepath.Base(md.BinaryPath),
-					Digest:   sum,
+					Module:    md.Module,
+					Filename:  filepath.Base(md.BinaryPath),
+					Digest:    sum,
+					Signature: signature,
 				},
 				Data: contents.Bytes(),
 			}
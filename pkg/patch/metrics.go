@@ -2,6 +2,7 @@ package patch
 
 import (
 	"sync/atomic"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -11,13 +12,24 @@ const newDigestLabel = "new_digest"
 const digestLabel = "digest"
 
 type CacheMetrics struct {
-	CacheMisses           int64
-	CacheHits             int64
-	PluginCount           int64
-	PatchCount            int64
-	TotalSizeBytes        int64
-	PatchCalcSecondsTotal int64
-	PatchCalcCount        int64
+	CacheMisses          int64
+	CacheHits            int64
+	PluginCount          int64
+	PatchCount           int64
+	TotalSizeBytes       int64
+	PatchCalcTimeTotal   time.Duration
+	PatchCalcCount       int64
+	PatchSizeBytesTotal  int64
+	PluginSizeBytesTotal int64
+}
+
+// CompressionRatio returns the ratio between the total size of the plugins
+// that patches were generated for and the total size of those patches.
+func (m CacheMetrics) CompressionRatio() float64 {
+	if m.PatchSizeBytesTotal == 0 {
+		return 0
+	}
+	return float64(m.PluginSizeBytesTotal) / float64(m.PatchSizeBytesTotal)
 }
 
 type CacheMetricsTracker struct {
@@ -29,8 +41,12 @@ type CacheMetricsTracker struct {
 	promPatchCount     prometheus.Gauge
 	promTotalSizeBytes *prometheus.GaugeVec
 
+	promPatchCalcSecsTotal    *prometheus.CounterVec
+	promPatchCalcCount        *prometheus.CounterVec
+	promPatchCompressionRatio *prometheus.GaugeVec
+
+	// Deprecated: replaced by promPatchCalcSecsTotal
 	promPatchCalcNanoSecsTotal *prometheus.CounterVec
-	promPatchCalcCount         *prometheus.CounterVec
 }
 
 func NewCacheMetricsTracker(constLabels map[string]string) CacheMetricsTracker {
@@ -70,12 +86,19 @@ func NewCacheMetricsTracker(constLabels map[string]string) CacheMetricsTracker {
 			ConstLabels: constLabels,
 			Help:        "Total size of the cache in bytes",
 		}, []string{digestLabel}),
+		promPatchCalcSecsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   "opni",
+			Subsystem:   "patch",
+			Name:        "calc_seconds_total",
+			ConstLabels: constLabels,
+			Help:        "Total time spent calculating patches in seconds",
+		}, []string{oldDigestLabel, newDigestLabel}),
 		promPatchCalcNanoSecsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
 			Namespace:   "opni",
 			Subsystem:   "patch",
 			Name:        "calc_nano_seconds_total",
 			ConstLabels: constLabels,
-			Help:        "Total time spent calculating patches in nanoseconds",
+			Help:        "Total time spent calculating patches in nanoseconds (deprecated, use opni_patch_calc_seconds_total)",
 		}, []string{oldDigestLabel, newDigestLabel}),
 		promPatchCalcCount: prometheus.NewCounterVec(prometheus.CounterOpts{
 			Namespace:   "opni",
@@ -84,6 +107,13 @@ func NewCacheMetricsTracker(constLabels map[string]string) CacheMetricsTracker {
 			ConstLabels: constLabels,
 			Help:        "Total number of patch calculations requested",
 		}, []string{oldDigestLabel, newDigestLabel}),
+		promPatchCompressionRatio: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   "opni",
+			Subsystem:   "patch",
+			Name:        "compression_ratio",
+			ConstLabels: constLabels,
+			Help:        "Ratio between the size of the new plugin revision and the size of the generated patch",
+		}, []string{oldDigestLabel, newDigestLabel}),
 	}
 }
 
@@ -126,14 +156,19 @@ func (c *CacheMetricsTracker) AddToTotalSizeBytes(digest string, value int64) {
 	c.promTotalSizeBytes.WithLabelValues(digest).Add(float64(value))
 }
 
-func (c *CacheMetricsTracker) IncPatchCalcSecsTotal(oldDigest, newDigest string, value float64) {
-	atomic.AddInt64(&c.metrics.PatchCalcSecondsTotal, int64(value))
-	c.promPatchCalcNanoSecsTotal.WithLabelValues(oldDigest, newDigest).Add(value)
-}
-
-func (c *CacheMetricsTracker) IncPatchCalcCount(oldDigest, newDigest string) {
+// PatchGenerated records the time taken to generate a patch, and the
+// compression ratio between the new plugin revision and the patch.
+func (c *CacheMetricsTracker) PatchGenerated(oldDigest, newDigest string, took time.Duration, patchSize, pluginSize int64) {
+	atomic.AddInt64((*int64)(&c.metrics.PatchCalcTimeTotal), int64(took))
 	atomic.AddInt64(&c.metrics.PatchCalcCount, 1)
+	atomic.AddInt64(&c.metrics.PatchSizeBytesTotal, patchSize)
+	atomic.AddInt64(&c.metrics.PluginSizeBytesTotal, pluginSize)
+	c.promPatchCalcSecsTotal.WithLabelValues(oldDigest, newDigest).Add(took.Seconds())
+	c.promPatchCalcNanoSecsTotal.WithLabelValues(oldDigest, newDigest).Add(float64(took.Nanoseconds()))
 	c.promPatchCalcCount.WithLabelValues(oldDigest, newDigest).Inc()
+	if patchSize > 0 {
+		c.promPatchCompressionRatio.WithLabelValues(oldDigest, newDigest).Set(float64(pluginSize) / float64(patchSize))
+	}
 }
 
 func (c *CacheMetricsTracker) MetricsCollectors() []prometheus.Collector {
@@ -143,6 +178,10 @@ func (c *CacheMetricsTracker) MetricsCollectors() []prometheus.Collector {
 		c.promPluginCount,
 		c.promPatchCount,
 		c.promTotalSizeBytes,
+		c.promPatchCalcSecsTotal,
+		c.promPatchCalcNanoSecsTotal,
+		c.promPatchCalcCount,
+		c.promPatchCompressionRatio,
 	}
 }
 
@@ -153,5 +192,10 @@ func (c *CacheMetricsTracker) MetricsSnapshot() CacheMetrics {
 		PluginCount:    atomic.LoadInt64(&c.metrics.PluginCount),
 		PatchCount:     atomic.LoadInt64(&c.metrics.PatchCount),
 		TotalSizeBytes: atomic.LoadInt64(&c.metrics.TotalSizeBytes),
+
+		PatchCalcTimeTotal:   time.Duration(atomic.LoadInt64((*int64)(&c.metrics.PatchCalcTimeTotal))),
+		PatchCalcCount:       atomic.LoadInt64(&c.metrics.PatchCalcCount),
+		PatchSizeBytesTotal:  atomic.LoadInt64(&c.metrics.PatchSizeBytesTotal),
+		PluginSizeBytesTotal: atomic.LoadInt64(&c.metrics.PluginSizeBytesTotal),
 	}
 }
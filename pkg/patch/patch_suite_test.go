@@ -2,7 +2,11 @@ package patch_test
 
 import (
 	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/hex"
+	"encoding/pem"
 	"os"
 	"sync"
 	"testing"
@@ -12,7 +16,9 @@ import (
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gexec"
 	controlv1 "github.com/rancher/opni/pkg/apis/control/v1"
+	"github.com/rancher/opni/pkg/config/v1beta1"
 	"github.com/rancher/opni/pkg/patch"
+	"github.com/rancher/opni/pkg/plugins"
 	"github.com/rancher/opni/pkg/test/testutil"
 	"github.com/spf13/afero"
 	"golang.org/x/crypto/blake2b"
@@ -53,6 +59,42 @@ var (
 
 var osfs = afero.Afero{Fs: afero.NewOsFs()}
 
+// key used to sign the test plugins, and the corresponding signature config
+var (
+	signingKey  ed25519.PrivateKey
+	trustedKeys v1beta1.SignatureSpec
+)
+
+func init() {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		panic(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		panic(err)
+	}
+	signingKey = priv
+	trustedKeys = v1beta1.SignatureSpec{
+		TrustedKeyData: [][]byte{pem.EncodeToMemory(&pem.Block{
+			Type:  "PUBLIC KEY",
+			Bytes: der,
+		})},
+		Mode: v1beta1.SignatureModeEnforce,
+	}
+}
+
+// sign returns the signature of a hex encoded plugin digest
+func sign(digest string) []byte {
+	return plugins.Sign(signingKey, testutil.Must(hex.DecodeString(digest)))
+}
+
+// signatureFile returns the contents of a signature file for a plugin binary
+func signatureFile(contents []byte) []byte {
+	sum := blake2b.Sum256(contents)
+	return []byte(base64.StdEncoding.EncodeToString(plugins.Sign(signingKey, sum[:])))
+}
+
 func b2sum(fs afero.Afero, filename string) string {
 	contents, err := fs.ReadFile(filename)
 	if err != nil {
@@ -35,6 +35,7 @@ func (BsdiffPatcher) CheckFormat(reader io.ReaderAt) bool {
 
 var allPatchEngines = []BinaryPatcher{
 	BsdiffPatcher{},
+	ChunkedDeltaPatcher{},
 }
 
 func NewPatcherFromFormat(reader io.ReaderAt) (BinaryPatcher, bool) {
@@ -15,6 +15,7 @@ import (
 	"github.com/rancher/opni/pkg/plugins"
 	"github.com/rancher/opni/pkg/storage"
 	"github.com/rancher/opni/pkg/util"
+	"github.com/samber/lo"
 	"github.com/spf13/afero"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
@@ -33,8 +34,10 @@ type FilesystemPluginSyncServer struct {
 	logger           *zap.SugaredLogger
 	config           v1beta1.PluginsSpec
 	loadMetadataOnce sync.Once
+	manifestMu       sync.RWMutex
 	manifest         *controlv1.PluginManifest
 	patchCache       Cache
+	verifier         *plugins.Verifier
 }
 
 type SyncServerOptions struct {
@@ -76,10 +79,17 @@ func NewFilesystemPluginSyncServer(
 	switch cfg.Cache.PatchEngine {
 	case v1beta1.PatchEngineBsdiff:
 		patchEngine = BsdiffPatcher{}
+	case v1beta1.PatchEngineChunked:
+		patchEngine = ChunkedDeltaPatcher{}
 	default:
 		return nil, fmt.Errorf("unknown patch engine: %s", cfg.Cache.PatchEngine)
 	}
 
+	verifier, err := plugins.NewVerifier(cfg.Signatures)
+	if err != nil {
+		return nil, err
+	}
+
 	var cache Cache
 	switch cfg.Cache.Backend {
 	case v1beta1.CacheBackendFilesystem:
@@ -97,6 +107,7 @@ func NewFilesystemPluginSyncServer(
 		config:            cfg,
 		logger:            lg,
 		patchCache:        cache,
+		verifier:          verifier,
 	}, nil
 }
 
@@ -129,6 +140,8 @@ func (f *FilesystemPluginSyncServer) RunGarbageCollection(ctx context.Context, s
 
 func (f *FilesystemPluginSyncServer) getPluginManifest() *controlv1.PluginManifest {
 	f.loadMetadataOnce.Do(f.loadPluginManifest)
+	f.manifestMu.RLock()
+	defer f.manifestMu.RUnlock()
 	return f.manifest
 }
 
@@ -136,6 +149,31 @@ func (f *FilesystemPluginSyncServer) loadPluginManifest() {
 	if f.manifest != nil {
 		panic("bug: tried to call loadPluginManifest twice")
 	}
+	manifest, err := f.buildPluginManifest()
+	if err != nil {
+		panic(err)
+	}
+	f.manifestMu.Lock()
+	f.manifest = manifest
+	f.manifestMu.Unlock()
+}
+
+// RefreshManifest rebuilds the plugin manifest from the plugins currently in
+// the plugin directory. Agents whose plugins no longer match the manifest
+// will be asked to sync when they next connect.
+func (f *FilesystemPluginSyncServer) RefreshManifest() error {
+	f.loadMetadataOnce.Do(f.loadPluginManifest)
+	manifest, err := f.buildPluginManifest()
+	if err != nil {
+		return err
+	}
+	f.manifestMu.Lock()
+	f.manifest = manifest
+	f.manifestMu.Unlock()
+	return nil
+}
+
+func (f *FilesystemPluginSyncServer) buildPluginManifest() (*controlv1.PluginManifest, error) {
 	md, err := GetFilesystemPlugins(plugins.DiscoveryConfig{
 		Dir:        f.config.Dir,
 		Fs:         f.fsys,
@@ -144,12 +182,24 @@ func (f *FilesystemPluginSyncServer) loadPluginManifest() {
 		QueryModes: len(f.filters) > 0,
 	})
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
+	// agents verify signatures before applying patches, but unverified
+	// plugins should not be offered to them in the first place
+	md.Items = lo.Filter(md.Items, func(entry *controlv1.PluginArchiveEntry, _ int) bool {
+		lg := f.logger.With("plugin", entry.GetMetadata().GetModule())
+		if err := f.verifier.Verify(lg, entry.GetMetadata().DigestBytes(), entry.GetMetadata().GetSignature()); err != nil {
+			lg.With(
+				zap.Error(err),
+			).Error("plugin signature verification failed, plugin will not be synced to agents")
+			return false
+		}
+		return true
+	})
 	if err := f.patchCache.Archive(md); err != nil {
-		panic(fmt.Sprintf("failed to archive plugin manifest: %v", err))
+		return nil, fmt.Errorf("failed to archive plugin manifest: %w", err)
 	}
-	f.manifest = md.ToManifest()
+	return md.ToManifest(), nil
 }
 
 func (f *FilesystemPluginSyncServer) SyncPluginManifest(
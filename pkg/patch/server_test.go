@@ -51,6 +51,7 @@ var _ = Describe("Filesystem Sync Server", Ordered, Label(test.Unit, test.Slow),
 					Dir: filepath.Join(tmpDir, "cache"),
 				},
 			},
+			Signatures: trustedKeys,
 		}, test.Log, patch.WithFs(fsys))
 	}
 
@@ -60,7 +61,9 @@ var _ = Describe("Filesystem Sync Server", Ordered, Label(test.Unit, test.Slow),
 			fsys.Mkdir(filepath.Join(tmpDir, "cache"), 0755)
 
 			Expect(fsys.WriteFile(filepath.Join(tmpDir, "plugins", "plugin_test1"), testBinaries["test1"]["v1"], 0644)).To(Succeed())
+			Expect(fsys.WriteFile(filepath.Join(tmpDir, "plugins", "plugin_test1.sig"), signatureFile(testBinaries["test1"]["v1"]), 0644)).To(Succeed())
 			Expect(fsys.WriteFile(filepath.Join(tmpDir, "plugins", "plugin_test2"), testBinaries["test2"]["v1"], 0644)).To(Succeed())
+			Expect(fsys.WriteFile(filepath.Join(tmpDir, "plugins", "plugin_test2.sig"), signatureFile(testBinaries["test2"]["v1"]), 0644)).To(Succeed())
 
 			var err error
 			srv, err = newServer()
@@ -96,7 +99,9 @@ var _ = Describe("Filesystem Sync Server", Ordered, Label(test.Unit, test.Slow),
 			fsys.Remove(filepath.Join(tmpDir, "plugins", "plugin_test2"))
 
 			Expect(fsys.WriteFile(filepath.Join(tmpDir, "plugins", "plugin_test1"), testBinaries["test1"]["v2"], 0644)).To(Succeed())
+			Expect(fsys.WriteFile(filepath.Join(tmpDir, "plugins", "plugin_test1.sig"), signatureFile(testBinaries["test1"]["v2"]), 0644)).To(Succeed())
 			Expect(fsys.WriteFile(filepath.Join(tmpDir, "plugins", "plugin_test2"), testBinaries["test2"]["v2"], 0644)).To(Succeed())
+			Expect(fsys.WriteFile(filepath.Join(tmpDir, "plugins", "plugin_test2.sig"), signatureFile(testBinaries["test2"]["v2"]), 0644)).To(Succeed())
 
 			var err error
 			srv, err = newServer()
@@ -473,6 +478,90 @@ var _ = Describe("Filesystem Sync Server", Ordered, Label(test.Unit, test.Slow),
 					Expect(err).To(HaveOccurred())
 				})
 			})
+			When("the trusted keys cannot be loaded", func() {
+				It("should return an error", func() {
+					_, err := patch.NewFilesystemPluginSyncServer(v1beta1.PluginsSpec{
+						Dir: tmpDir,
+						Cache: v1beta1.CacheSpec{
+							PatchEngine: v1beta1.PatchEngineBsdiff,
+							Backend:     v1beta1.CacheBackendFilesystem,
+							Filesystem: v1beta1.FilesystemCacheSpec{
+								Dir: filepath.Join(tmpDir, "cache"),
+							},
+						},
+						Signatures: v1beta1.SignatureSpec{
+							TrustedKeyData: [][]byte{[]byte("not a key")},
+						},
+					}, test.Log, patch.WithFs(fsys))
+					Expect(err).To(HaveOccurred())
+				})
+			})
+			When("the signature mode is unknown", func() {
+				It("should return an error", func() {
+					_, err := patch.NewFilesystemPluginSyncServer(v1beta1.PluginsSpec{
+						Dir: tmpDir,
+						Cache: v1beta1.CacheSpec{
+							PatchEngine: v1beta1.PatchEngineBsdiff,
+							Backend:     v1beta1.CacheBackendFilesystem,
+							Filesystem: v1beta1.FilesystemCacheSpec{
+								Dir: filepath.Join(tmpDir, "cache"),
+							},
+						},
+						Signatures: v1beta1.SignatureSpec{
+							Mode: "allow",
+						},
+					}, test.Log, patch.WithFs(fsys))
+					Expect(err).To(HaveOccurred())
+				})
+			})
+		})
+		When("plugins are not signed by a trusted key", func() {
+			It("should exclude them from the manifest", func() {
+				fsys := afero.Afero{Fs: test.NewModeAwareMemFs()}
+				pluginDir := filepath.Join(tmpDir, "unsigned", "plugins")
+				Expect(fsys.MkdirAll(pluginDir, 0755)).To(Succeed())
+
+				// test1 is unsigned, test2 is signed with the wrong binary
+				Expect(fsys.WriteFile(filepath.Join(pluginDir, "plugin_test1"), testBinaries["test1"]["v1"], 0644)).To(Succeed())
+				Expect(fsys.WriteFile(filepath.Join(pluginDir, "plugin_test2"), testBinaries["test2"]["v1"], 0644)).To(Succeed())
+				Expect(fsys.WriteFile(filepath.Join(pluginDir, "plugin_test2.sig"), signatureFile(testBinaries["test2"]["v2"]), 0644)).To(Succeed())
+
+				conf := v1beta1.PluginsSpec{
+					Dir: pluginDir,
+					Cache: v1beta1.CacheSpec{
+						PatchEngine: v1beta1.PatchEngineBsdiff,
+						Backend:     v1beta1.CacheBackendFilesystem,
+						Filesystem: v1beta1.FilesystemCacheSpec{
+							Dir: filepath.Join(tmpDir, "unsigned", "cache"),
+						},
+					},
+					Signatures: trustedKeys,
+				}
+				srv, err := patch.NewFilesystemPluginSyncServer(conf, test.Log, patch.WithFs(fsys))
+				Expect(err).NotTo(HaveOccurred())
+
+				manifest, err := srv.GetPluginManifest(context.Background(), &emptypb.Empty{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(manifest.Items).To(BeEmpty())
+
+				By("enforcing signatures if the mode is not set")
+				conf.Signatures.Mode = ""
+				srv, err = patch.NewFilesystemPluginSyncServer(conf, test.Log, patch.WithFs(fsys))
+				Expect(err).NotTo(HaveOccurred())
+
+				manifest, err = srv.GetPluginManifest(context.Background(), &emptypb.Empty{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(manifest.Items).To(BeEmpty())
+
+				By("only warning when signatures are not enforced")
+				conf.Signatures.Mode = v1beta1.SignatureModeWarn
+				srv, err = patch.NewFilesystemPluginSyncServer(conf, test.Log, patch.WithFs(fsys))
+				Expect(err).NotTo(HaveOccurred())
+
+				manifest, err = srv.GetPluginManifest(context.Background(), &emptypb.Empty{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(manifest.Items).To(HaveLen(2))
+			})
 		})
 	})
 })
@@ -4,8 +4,12 @@ import (
 	"context"
 	"reflect"
 
+	"github.com/rancher/opni/pkg/logger"
 	"github.com/rancher/opni/pkg/storage"
 	"github.com/samber/lo"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
@@ -15,21 +19,32 @@ type kvStoreServer struct {
 	store storage.KeyValueStore
 }
 
-func (s *kvStoreServer) Put(ctx context.Context, kv *KeyValue) (*emptypb.Empty, error) {
-	err := s.store.Put(ctx, kv.GetKey(), kv.GetValue())
+func (s *kvStoreServer) Put(ctx context.Context, kv *KeyValue) (*PutResponse, error) {
+	var revision int64
+	opts := []storage.PutOpt{
+		storage.WithRevisionOut(&revision),
+	}
+	if kv.Revision != nil {
+		opts = append(opts, storage.WithRevision(kv.GetRevision()))
+	}
+	err := s.store.Put(ctx, kv.GetKey(), kv.GetValue(), opts...)
 	if err != nil {
 		return nil, err
 	}
-	return &emptypb.Empty{}, nil
+	return &PutResponse{
+		Revision: revision,
+	}, nil
 }
 
 func (s *kvStoreServer) Get(ctx context.Context, key *Key) (*Value, error) {
-	data, err := s.store.Get(ctx, key.GetKey())
+	var revision int64
+	data, err := s.store.Get(ctx, key.GetKey(), storage.WithRevisionOut(&revision))
 	if err != nil {
 		return nil, err
 	}
 	return &Value{
-		Value: data,
+		Value:    data,
+		Revision: revision,
 	}, nil
 }
 
@@ -51,35 +66,95 @@ func (s *kvStoreServer) ListKeys(ctx context.Context, key *Key) (*KeyList, error
 	}, nil
 }
 
+func (s *kvStoreServer) Watch(key *Key, stream KeyValueStore_WatchServer) error {
+	eventC, err := s.store.Watch(stream.Context(), key.GetKey())
+	if err != nil {
+		return err
+	}
+	for event := range eventC {
+		msg := &WatchEvent{}
+		switch event.EventType {
+		case storage.WatchEventCreate:
+			msg.Type = WatchEventType_Created
+		case storage.WatchEventUpdate:
+			msg.Type = WatchEventType_Updated
+		case storage.WatchEventDelete:
+			msg.Type = WatchEventType_Deleted
+		}
+		if event.EventType != storage.WatchEventDelete {
+			msg.Current = &KeyRevision{
+				Key:      event.Current.Key,
+				Value:    event.Current.Value,
+				Revision: event.Current.Revision,
+			}
+		}
+		if event.EventType != storage.WatchEventCreate {
+			msg.Previous = &KeyRevision{
+				Key:      event.Previous.Key,
+				Value:    event.Previous.Value,
+				Revision: event.Previous.Revision,
+			}
+		}
+		if err := stream.Send(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type kvStoreClientImpl[T proto.Message] struct {
 	client KeyValueStoreClient
+	lg     *zap.SugaredLogger
 }
 
-func (c *kvStoreClientImpl[T]) Put(ctx context.Context, key string, value T) error {
+func (c *kvStoreClientImpl[T]) Put(ctx context.Context, key string, value T, opts ...storage.PutOpt) error {
+	options := storage.PutOptions{}
+	options.Apply(opts...)
+
 	wire, err := proto.Marshal(value)
 	if err != nil {
 		return err
 	}
 	kv := &KeyValue{
-		Key:   key,
-		Value: wire,
+		Key:      key,
+		Value:    wire,
+		Revision: options.Revision,
 	}
-	_, err = c.client.Put(ctx, kv)
-	return err
+	resp, err := c.client.Put(ctx, kv)
+	if err != nil {
+		if status.Code(err) == codes.Aborted {
+			return storage.ErrConflict
+		}
+		return err
+	}
+	if options.RevisionOut != nil {
+		*options.RevisionOut = resp.GetRevision()
+	}
+	return nil
 }
 
-func (c *kvStoreClientImpl[T]) Get(ctx context.Context, key string) (T, error) {
+func (c *kvStoreClientImpl[T]) Get(ctx context.Context, key string, opts ...storage.GetOpt) (T, error) {
+	options := storage.GetOptions{}
+	options.Apply(opts...)
+
 	value, err := c.client.Get(ctx, &Key{
 		Key: key,
 	})
 	if err != nil {
 		return lo.Empty[T](), err
 	}
+	if options.RevisionOut != nil {
+		*options.RevisionOut = value.GetRevision()
+	}
+
+	return unmarshalValue[T](value.GetValue())
+}
 
+func unmarshalValue[T proto.Message](data []byte) (T, error) {
 	var t T
 	tType := reflect.TypeOf(t)
 	rt := reflect.New(tType.Elem()).Interface().(T)
-	err = proto.Unmarshal(value.GetValue(), rt)
+	err := proto.Unmarshal(data, rt)
 	if err != nil {
 		return t, err
 	}
@@ -103,8 +178,72 @@ func (c *kvStoreClientImpl[T]) ListKeys(ctx context.Context, prefix string) ([]s
 	return resp.Items, nil
 }
 
+func (c *kvStoreClientImpl[T]) Watch(ctx context.Context, prefix string) (<-chan storage.WatchEvent[storage.KeyRevision[T]], error) {
+	stream, err := c.client.Watch(ctx, &Key{
+		Key: prefix,
+	})
+	if err != nil {
+		return nil, err
+	}
+	eventC := make(chan storage.WatchEvent[storage.KeyRevision[T]], 64)
+	go func() {
+		defer close(eventC)
+		for {
+			msg, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			event := storage.WatchEvent[storage.KeyRevision[T]]{}
+			switch msg.GetType() {
+			case WatchEventType_Created:
+				event.EventType = storage.WatchEventCreate
+			case WatchEventType_Updated:
+				event.EventType = storage.WatchEventUpdate
+			case WatchEventType_Deleted:
+				event.EventType = storage.WatchEventDelete
+			}
+			if event.Current, err = toKeyRevision[T](msg.GetCurrent()); err != nil {
+				c.lg.With(
+					"key", msg.GetCurrent().GetKey(),
+					"error", err,
+				).Warn("skipping watch event: failed to unmarshal current value")
+				continue
+			}
+			if event.Previous, err = toKeyRevision[T](msg.GetPrevious()); err != nil {
+				c.lg.With(
+					"key", msg.GetPrevious().GetKey(),
+					"error", err,
+				).Warn("skipping watch event: failed to unmarshal previous value")
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case eventC <- event:
+			}
+		}
+	}()
+	return eventC, nil
+}
+
+func toKeyRevision[T proto.Message](kr *KeyRevision) (storage.KeyRevision[T], error) {
+	if kr == nil {
+		return storage.KeyRevision[T]{}, nil
+	}
+	value, err := unmarshalValue[T](kr.GetValue())
+	if err != nil {
+		return storage.KeyRevision[T]{}, err
+	}
+	return storage.KeyRevision[T]{
+		Key:      kr.GetKey(),
+		Value:    value,
+		Revision: kr.GetRevision(),
+	}, nil
+}
+
 func NewKVStoreClient[T proto.Message](client KeyValueStoreClient) storage.KeyValueStoreT[T] {
 	return &kvStoreClientImpl[T]{
 		client: client,
+		lg:     logger.NewPluginLogger().Named("kvstore"),
 	}
 }
@@ -35,6 +35,9 @@ func (dc DiscoveryConfig) Discover() []meta.PluginMeta {
 	var result []meta.PluginMeta
 PLUGINS:
 	for _, path := range paths {
+		if filepath.Ext(path) == SignatureExt {
+			continue
+		}
 		f, err := dc.Fs.Open(path)
 		if err != nil {
 			if dc.Logger != nil {
@@ -12,7 +12,9 @@
 // found in pkg/plugins/types.
 //
 // Load hooks will be invoked exactly once per plugin, per hook, in a separate
-// goroutine. All load hooks for a particular event are run in parallel.
+// goroutine. All load hooks for a particular event are run in parallel. A
+// plugin which is reloaded is considered to be a new plugin, so load hooks
+// will be invoked again for the new version.
 //
 // Load hooks should not block for an extended period of time. When a plugin
 // is loaded, it will block until all hooks have completed (returned). Blocking
@@ -29,4 +31,22 @@
 //
 // There are no restrictions related to blocking in an OnLoadingCompleted hook
 // callback. It is valid to block indefinitely, e.g. to start a server.
+//
+// # PluginUnloadHook
+//
+// This hook is invoked when a plugin is unloaded after loading has completed,
+// either because its binary was removed, or because it is being replaced by
+// a new version. In the latter case, unload hooks for the old version are
+// invoked before load hooks for the new version.
+//
+// Use the OnUnload method to construct a new PluginUnloadHook. Unload hooks
+// are invoked synchronously, and should remove anything that was registered
+// for the plugin by load hooks.
+//
+// # PluginEventHook
+//
+// This hook is invoked asynchronously whenever a plugin is added, reloaded or
+// removed after loading has completed, or if a reload fails.
+//
+// Use the OnPluginEvent method to construct a new PluginEventHook.
 package hooks
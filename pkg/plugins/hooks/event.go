@@ -0,0 +1,55 @@
+package hooks
+
+import "github.com/rancher/opni/pkg/plugins/meta"
+
+type PluginEventType int
+
+const (
+	// A new plugin was loaded after loading had completed
+	PluginAdded PluginEventType = iota
+	// A plugin was replaced by a new version
+	PluginReloaded
+	// A plugin was unloaded because its binary was removed
+	PluginRemoved
+	// A new version of a plugin could not be loaded. The previous version,
+	// if any, remains loaded.
+	PluginReloadFailed
+)
+
+func (t PluginEventType) String() string {
+	switch t {
+	case PluginAdded:
+		return "Added"
+	case PluginReloaded:
+		return "Reloaded"
+	case PluginRemoved:
+		return "Removed"
+	case PluginReloadFailed:
+		return "ReloadFailed"
+	default:
+		return "Unknown"
+	}
+}
+
+type PluginEvent struct {
+	Type   PluginEventType
+	Plugin meta.PluginMeta
+	// Set if Type is PluginReloadFailed
+	Error error
+}
+
+type PluginEventHook interface {
+	Invoke(event PluginEvent)
+}
+
+type onPluginEventHook func(PluginEvent)
+
+func (h onPluginEventHook) Invoke(event PluginEvent) {
+	h(event)
+}
+
+// Invokes the provided callback function whenever a plugin is added,
+// reloaded or removed after loading has completed.
+func OnPluginEvent(fn func(PluginEvent)) PluginEventHook {
+	return onPluginEventHook(fn)
+}
@@ -45,12 +45,19 @@ func OnLoadM[T any](fn func(T, meta.PluginMeta)) PluginLoadHook {
 	})
 }
 
+type onceKey struct {
+	module string
+	conn   *grpc.ClientConn
+}
+
 // Like OnLoadM[T], but adds the grpc client connection to the callback.
 func OnLoadMC[T any](fn func(T, meta.PluginMeta, *grpc.ClientConn)) PluginLoadHook {
-	once := gsync.Map[string, bool]{}
+	// a reloaded plugin has a new client connection, and is treated as a
+	// separate plugin
+	once := gsync.Map[onceKey, bool]{}
 	return &onLoadHook[T]{
 		callback: func(t T, md meta.PluginMeta, cc *grpc.ClientConn) {
-			if _, loaded := once.LoadOrStore(md.Module, true); !loaded {
+			if _, loaded := once.LoadOrStore(onceKey{md.Module, cc}, true); !loaded {
 				fn(t, md, cc)
 			}
 		},
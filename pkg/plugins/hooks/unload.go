@@ -0,0 +1,21 @@
+package hooks
+
+import "github.com/rancher/opni/pkg/plugins/meta"
+
+type PluginUnloadHook interface {
+	Invoke(md meta.PluginMeta)
+}
+
+type onUnloadHook func(meta.PluginMeta)
+
+func (h onUnloadHook) Invoke(md meta.PluginMeta) {
+	h(md)
+}
+
+// Invokes the provided callback function when a plugin is unloaded, either
+// because its binary was removed, or because it is being replaced by a new
+// version. The callback should remove anything that was registered by load
+// hooks for the plugin.
+func OnUnload(fn func(meta.PluginMeta)) PluginUnloadHook {
+	return onUnloadHook(fn)
+}
@@ -9,11 +9,14 @@ import (
 
 	"github.com/hashicorp/go-plugin"
 	"github.com/samber/lo"
+	"github.com/spf13/afero"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/atomic"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/blake2b"
+	"google.golang.org/grpc"
 
 	controlv1 "github.com/rancher/opni/pkg/apis/control/v1"
 	"github.com/rancher/opni/pkg/config/v1beta1"
@@ -41,9 +44,11 @@ func (noopLoader) Hook(h any) {
 var NoopLoader = noopLoader{}
 
 type activePlugin struct {
-	md     meta.PluginMeta
-	client *plugin.GRPCClient
-	raw    any
+	md       meta.PluginMeta
+	client   *plugin.GRPCClient
+	raw      any
+	process  *plugin.Client
+	inflight *inflightRequests
 }
 
 type hook[T any] struct {
@@ -56,14 +61,18 @@ type PluginLoader struct {
 
 	hooksMu        sync.RWMutex
 	pluginsMu      sync.RWMutex
+	reloadMu       sync.Mutex
 	loadHooks      []hook[hooks.PluginLoadHook]
 	completedHooks []hook[hooks.LoadingCompletedHook]
+	unloadHooks    []hook[hooks.PluginUnloadHook]
+	eventHooks     []hook[hooks.PluginEventHook]
 	activePlugins  []activePlugin
 	completed      *atomic.Bool
 }
 
 type PluginLoaderOptions struct {
-	logger *zap.SugaredLogger
+	logger       *zap.SugaredLogger
+	drainTimeout time.Duration
 }
 
 type PluginLoaderOption func(*PluginLoaderOptions)
@@ -80,8 +89,18 @@ func WithLogger(lg *zap.SugaredLogger) PluginLoaderOption {
 	}
 }
 
+// WithDrainTimeout sets how long a plugin which is being replaced or removed
+// is given to finish handling in-flight requests before it is stopped.
+func WithDrainTimeout(timeout time.Duration) PluginLoaderOption {
+	return func(o *PluginLoaderOptions) {
+		o.drainTimeout = timeout
+	}
+}
+
 func NewPluginLoader(opts ...PluginLoaderOption) *PluginLoader {
-	options := PluginLoaderOptions{}
+	options := PluginLoaderOptions{
+		drainTimeout: 30 * time.Second,
+	}
 	options.apply(opts...)
 	if options.logger == nil {
 		options.logger = logger.New().Named("pluginloader")
@@ -132,6 +151,16 @@ func (p *PluginLoader) Hook(h any) {
 		if p.completed.Load() {
 			go h.Invoke(len(p.activePlugins))
 		}
+	case hooks.PluginUnloadHook:
+		p.unloadHooks = append(p.unloadHooks, hook[hooks.PluginUnloadHook]{
+			hook:   h,
+			caller: caller,
+		})
+	case hooks.PluginEventHook:
+		p.eventHooks = append(p.eventHooks, hook[hooks.PluginEventHook]{
+			hook:   h,
+			caller: caller,
+		})
 	}
 }
 
@@ -139,24 +168,65 @@ func (p *PluginLoader) Hook(h any) {
 // the plugin being loaded and will block until all load hooks have completed.
 func (p *PluginLoader) LoadOne(ctx context.Context, md meta.PluginMeta, cc *plugin.ClientConfig) {
 	p.ensureNotCompleted()
-	tracer := otel.Tracer("pluginloader")
-	tc, span := tracer.Start(ctx, "LoadOne",
-		trace.WithAttributes(attribute.String("plugin", md.Module)))
-	defer span.End()
-
 	lg := p.logger.With(
 		zap.String("plugin", md.Module),
 	)
 	lg.Info("loading plugin")
 
-	client := plugin.NewClient(cc)
-	rpcClient, err := client.Client()
+	loaded, err := p.start(md, cc)
 	if err != nil {
 		lg.With(
 			zap.Error(err),
 		).Error("failed to load plugin")
 		return
 	}
+	p.activate(ctx, loaded)
+}
+
+// loadedPlugin is a plugin process which has been started, but whose load
+// hooks have not yet been invoked.
+type loadedPlugin struct {
+	md        meta.PluginMeta
+	cc        *plugin.ClientConfig
+	process   *plugin.Client
+	rpcClient plugin.ClientProtocol
+	inflight  *inflightRequests
+}
+
+func (p *PluginLoader) start(md meta.PluginMeta, cc *plugin.ClientConfig) (*loadedPlugin, error) {
+	inflight := &inflightRequests{}
+	cc.GRPCDialOptions = append(cc.GRPCDialOptions,
+		grpc.WithChainUnaryInterceptor(inflight.UnaryClientInterceptor()),
+		grpc.WithChainStreamInterceptor(inflight.StreamClientInterceptor()),
+	)
+	client := plugin.NewClient(cc)
+	rpcClient, err := client.Client()
+	if err != nil {
+		client.Kill()
+		return nil, err
+	}
+	return &loadedPlugin{
+		md:        md,
+		cc:        cc,
+		process:   client,
+		rpcClient: rpcClient,
+		inflight:  inflight,
+	}, nil
+}
+
+// activate invokes load hooks for each interface implemented by the plugin,
+// and adds it to the list of active plugins. It blocks until all load hooks
+// have completed.
+func (p *PluginLoader) activate(ctx context.Context, lp *loadedPlugin) {
+	md, cc, rpcClient := lp.md, lp.cc, lp.rpcClient
+	tracer := otel.Tracer("pluginloader")
+	tc, span := tracer.Start(ctx, "LoadOne",
+		trace.WithAttributes(attribute.String("plugin", md.Module)))
+	defer span.End()
+
+	lg := p.logger.With(
+		zap.String("plugin", md.Module),
+	)
 	lg.With(
 		"interfaces", lo.Keys(cc.Plugins),
 	).Debug("checking if plugin implements any interfaces in the scheme")
@@ -206,9 +276,11 @@ func (p *PluginLoader) LoadOne(ctx context.Context, md meta.PluginMeta, cc *plug
 
 			p.pluginsMu.Lock()
 			p.activePlugins = append(p.activePlugins, activePlugin{
-				md:     md,
-				client: c,
-				raw:    raw,
+				md:       md,
+				client:   c,
+				raw:      raw,
+				process:  lp.process,
+				inflight: lp.inflight,
 			})
 			p.pluginsMu.Unlock()
 		}
@@ -251,52 +323,40 @@ func WithManifest(manifest *controlv1.PluginManifest) LoadOption {
 // is called, it is unsafe to call LoadPlugins() or LoadOne() again for this
 // plugin loader, although new hooks can still be added and will be invoked
 // immediately according to the current state of the plugin loader.
+//
+// Plugins whose signatures cannot be verified using the trusted keys in the
+// plugin configuration are not loaded, unless the signature mode is "warn" or
+// "disabled".
 func (p *PluginLoader) LoadPlugins(ctx context.Context, conf v1beta1.PluginsSpec, scheme meta.Scheme, opts ...LoadOption) {
 	options := LoadOptions{}
 	options.apply(opts...)
 
-	verifyManifest := (options.manifest != nil)
-	secureConfigs := make(map[string]*plugin.SecureConfig)
-	if verifyManifest {
-		for _, entry := range options.manifest.Items {
-			secureConfigs[entry.Module] = &plugin.SecureConfig{
-				Checksum: entry.DigestBytes(),
-				Hash:     entry.DigestHash(),
-			}
-		}
-	}
-
 	tc, span := otel.Tracer("pluginloader").Start(ctx, "LoadPlugins")
 
 	wg := &sync.WaitGroup{}
 
-	dc := DiscoveryConfig{
-		Dir:    conf.Dir,
-		Logger: p.logger,
-	}
-	plugins := dc.Discover()
-
-	for _, md := range plugins {
-		md := md
-		clientOpts := options.clientOptions
-		if verifyManifest {
-			if secureConfig, ok := secureConfigs[md.Module]; ok {
-				clientOpts = append(clientOpts, WithSecureConfig(secureConfig))
-			} else {
-				p.logger.With(
-					"module", md.Module,
-					"path", md.BinaryPath,
-				).Warn("plugin is not present in manifest, skipping")
+	verifier, err := NewVerifier(conf.Signatures)
+	if err != nil {
+		p.logger.With(
+			zap.Error(err),
+		).Error("failed to load trusted plugin signing keys, no plugins will be loaded")
+	} else {
+		dc := DiscoveryConfig{
+			Dir:    conf.Dir,
+			Logger: p.logger,
+		}
+		for _, md := range dc.Discover() {
+			md := md
+			cc, ok := p.secureClientConfig(md, scheme, options, verifier)
+			if !ok {
 				continue
 			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				p.LoadOne(tc, md, cc)
+			}()
 		}
-		cc := ClientConfig(md, scheme, clientOpts...)
-
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			p.LoadOne(tc, md, cc)
-		}()
 	}
 	go func() {
 		defer span.End()
@@ -305,6 +365,53 @@ func (p *PluginLoader) LoadPlugins(ctx context.Context, conf v1beta1.PluginsSpec
 	}()
 }
 
+// secureClientConfig verifies the plugin's signature, and returns a client
+// config which will only start the plugin if its checksum matches the
+// verified digest. If a manifest was provided, the plugin must be present in
+// the manifest, and the signature and digest are taken from the manifest.
+// Otherwise, they are read from the plugin directory.
+func (p *PluginLoader) secureClientConfig(md meta.PluginMeta, scheme meta.Scheme, options LoadOptions, verifier *Verifier) (*plugin.ClientConfig, bool) {
+	lg := p.logger.With(
+		"module", md.Module,
+		"path", md.BinaryPath,
+	)
+	hash, _ := blake2b.New256(nil)
+	secureConfig := &plugin.SecureConfig{
+		Hash: hash,
+	}
+	if options.manifest != nil {
+		var entry *controlv1.PluginManifestEntry
+		for _, e := range options.manifest.Items {
+			if e.Module == md.Module {
+				entry = e
+				break
+			}
+		}
+		if entry == nil {
+			lg.Warn("plugin is not present in manifest, skipping")
+			return nil, false
+		}
+		if err := verifier.Verify(lg, entry.DigestBytes(), entry.GetSignature()); err != nil {
+			lg.With(
+				zap.Error(err),
+			).Error("plugin signature verification failed, skipping")
+			return nil, false
+		}
+		secureConfig.Checksum = entry.DigestBytes()
+	} else {
+		digest, _, err := verifier.VerifyFile(lg, afero.NewOsFs(), md.BinaryPath)
+		if err != nil {
+			lg.With(
+				zap.Error(err),
+			).Error("plugin signature verification failed, skipping")
+			return nil, false
+		}
+		secureConfig.Checksum = digest
+	}
+	clientOpts := append(options.clientOptions, WithSecureConfig(secureConfig))
+	return ClientConfig(md, scheme, clientOpts...), true
+}
+
 // Complete marks the plugin loader as completed. This function will be called
 // automatically by LoadPlugins(), although it can be called manually if
 // LoadPlugins() is not used. It is not safe to call this function and
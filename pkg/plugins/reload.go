@@ -0,0 +1,244 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-plugin"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+
+	"github.com/rancher/opni/pkg/plugins/hooks"
+	"github.com/rancher/opni/pkg/plugins/meta"
+)
+
+// inflightRequests counts the unary requests and streams which have been sent
+// to a plugin but have not yet completed, including the plugin streams spliced
+// into agent streams.
+type inflightRequests struct {
+	count atomic.Int64
+}
+
+func (r *inflightRequests) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		r.count.Inc()
+		defer r.count.Dec()
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func (r *inflightRequests) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(
+		ctx context.Context,
+		desc *grpc.StreamDesc,
+		cc *grpc.ClientConn,
+		method string,
+		streamer grpc.Streamer,
+		opts ...grpc.CallOption,
+	) (grpc.ClientStream, error) {
+		r.count.Inc()
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			r.count.Dec()
+			return nil, err
+		}
+		stream := &inflightStream{
+			ClientStream: cs,
+		}
+		stream.done = func() {
+			stream.once.Do(func() { r.count.Dec() })
+		}
+		// streams end when their context is done, or when an error (including
+		// io.EOF) is received
+		go func() {
+			<-cs.Context().Done()
+			stream.done()
+		}()
+		return stream, nil
+	}
+}
+
+type inflightStream struct {
+	grpc.ClientStream
+	once sync.Once
+	done func()
+}
+
+func (s *inflightStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		s.done()
+	}
+	return err
+}
+
+// wait blocks until there are no in-flight requests, or the context is done.
+// Returns false if the context was done first.
+func (r *inflightRequests) wait(ctx context.Context) bool {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for r.count.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+	return true
+}
+
+// Reload replaces a loaded plugin with a new version, or loads a new plugin
+// if no plugin with the same module is loaded. It can only be used after
+// loading has completed.
+//
+// The new version is started before the old version is unloaded. If the new
+// version fails to start, the old version remains loaded and an error is
+// returned. Otherwise, unload hooks are invoked for the old version, load
+// hooks are invoked for the new version, and the old version is stopped in
+// the background once its in-flight requests have completed, or the drain
+// timeout has elapsed.
+func (p *PluginLoader) Reload(ctx context.Context, md meta.PluginMeta, cc *plugin.ClientConfig) error {
+	if !p.completed.Load() {
+		return fmt.Errorf("plugins cannot be reloaded until loading has completed")
+	}
+	p.reloadMu.Lock()
+	defer p.reloadMu.Unlock()
+
+	lg := p.logger.With(
+		zap.String("plugin", md.Module),
+	)
+	lg.Info("reloading plugin")
+
+	loaded, err := p.start(md, cc)
+	if err != nil {
+		lg.With(
+			zap.Error(err),
+		).Error("failed to start new plugin version, keeping the current version")
+		p.emit(hooks.PluginEvent{
+			Type:   hooks.PluginReloadFailed,
+			Plugin: md,
+			Error:  err,
+		})
+		return err
+	}
+
+	previous := p.deactivate(md.Module)
+	p.activate(ctx, loaded)
+	if len(previous) > 0 {
+		p.emit(hooks.PluginEvent{
+			Type:   hooks.PluginReloaded,
+			Plugin: md,
+		})
+		go p.drain(lg, previous)
+	} else {
+		p.emit(hooks.PluginEvent{
+			Type:   hooks.PluginAdded,
+			Plugin: md,
+		})
+	}
+	return nil
+}
+
+// Unload unloads the plugin with the given module, if it is loaded. Unload
+// hooks are invoked before it returns, and the plugin is stopped in the
+// background once its in-flight requests have completed, or the drain
+// timeout has elapsed.
+func (p *PluginLoader) Unload(module string) {
+	p.reloadMu.Lock()
+	defer p.reloadMu.Unlock()
+
+	previous := p.deactivate(module)
+	if len(previous) == 0 {
+		return
+	}
+	lg := p.logger.With(
+		zap.String("plugin", module),
+	)
+	lg.Info("unloading plugin")
+	p.emit(hooks.PluginEvent{
+		Type:   hooks.PluginRemoved,
+		Plugin: previous[0].md,
+	})
+	go p.drain(lg, previous)
+}
+
+// LoadedPlugins returns the metadata of all currently loaded plugins.
+func (p *PluginLoader) LoadedPlugins() []meta.PluginMeta {
+	p.pluginsMu.RLock()
+	defer p.pluginsMu.RUnlock()
+	var result []meta.PluginMeta
+	seen := map[string]struct{}{}
+	for _, ap := range p.activePlugins {
+		if _, ok := seen[ap.md.Module]; ok {
+			continue
+		}
+		seen[ap.md.Module] = struct{}{}
+		result = append(result, ap.md)
+	}
+	return result
+}
+
+// deactivate removes all active plugins with the given module and invokes
+// unload hooks for them. Returns the removed plugins.
+func (p *PluginLoader) deactivate(module string) []activePlugin {
+	p.pluginsMu.Lock()
+	var removed []activePlugin
+	remaining := p.activePlugins[:0]
+	for _, ap := range p.activePlugins {
+		if ap.md.Module == module {
+			removed = append(removed, ap)
+		} else {
+			remaining = append(remaining, ap)
+		}
+	}
+	p.activePlugins = remaining
+	p.pluginsMu.Unlock()
+
+	if len(removed) == 0 {
+		return nil
+	}
+	p.hooksMu.RLock()
+	defer p.hooksMu.RUnlock()
+	for _, h := range p.unloadHooks {
+		h.hook.Invoke(removed[0].md)
+	}
+	return removed
+}
+
+// drain waits for in-flight requests to the given plugins to complete, then
+// stops the plugin process.
+func (p *PluginLoader) drain(lg *zap.SugaredLogger, previous []activePlugin) {
+	ctx, ca := context.WithTimeout(context.Background(), p.drainTimeout)
+	defer ca()
+	for _, ap := range previous {
+		if ap.inflight != nil && !ap.inflight.wait(ctx) {
+			lg.With(
+				"timeout", p.drainTimeout,
+			).Warn("timed out waiting for in-flight requests to complete")
+			break
+		}
+	}
+	// all entries for the same module share a single process
+	if process := previous[0].process; process != nil {
+		process.Kill()
+	}
+	lg.Debug("stopped previous plugin version")
+}
+
+func (p *PluginLoader) emit(event hooks.PluginEvent) {
+	p.hooksMu.RLock()
+	defer p.hooksMu.RUnlock()
+	for _, h := range p.eventHooks {
+		go h.hook.Invoke(event)
+	}
+}
@@ -0,0 +1,179 @@
+package plugins
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/afero"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/rancher/opni/pkg/config/v1beta1"
+)
+
+// Plugin signatures are stored next to the plugin binary, in a file with the
+// same name and this extension. The file contains a base64 encoded ed25519
+// signature of the plugin's BLAKE2b-256 digest (the same digest used in
+// plugin manifests).
+const SignatureExt = ".sig"
+
+var (
+	ErrSignatureMissing = errors.New("plugin is not signed")
+	ErrSignatureInvalid = errors.New("plugin signature is not valid")
+)
+
+// Verifier checks plugin signatures against a set of trusted keys.
+type Verifier struct {
+	keys []ed25519.PublicKey
+	mode v1beta1.SignatureMode
+}
+
+// NewVerifier loads the trusted keys from the signature configuration.
+func NewVerifier(spec v1beta1.SignatureSpec) (*Verifier, error) {
+	v := &Verifier{
+		mode: spec.Mode,
+	}
+	switch v.mode {
+	case "":
+		v.mode = v1beta1.SignatureModeEnforce
+	case v1beta1.SignatureModeWarn, v1beta1.SignatureModeEnforce, v1beta1.SignatureModeDisabled:
+	default:
+		return nil, fmt.Errorf("unknown plugin signature mode %q", spec.Mode)
+	}
+	keyData := append([][]byte{}, spec.TrustedKeyData...)
+	for _, path := range spec.TrustedKeys {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read trusted key %s: %w", path, err)
+		}
+		keyData = append(keyData, data)
+	}
+	for _, data := range keyData {
+		keys, err := parsePublicKeys(data)
+		if err != nil {
+			return nil, err
+		}
+		v.keys = append(v.keys, keys...)
+	}
+	return v, nil
+}
+
+func parsePublicKeys(data []byte) ([]ed25519.PublicKey, error) {
+	var keys []ed25519.PublicKey
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "PUBLIC KEY" {
+			continue
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse trusted key: %w", err)
+		}
+		edKey, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("trusted key is not an ed25519 key (got %T)", key)
+		}
+		keys = append(keys, edKey)
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no PEM encoded public keys found in trusted key data")
+	}
+	return keys, nil
+}
+
+// Verify checks that the signature of a plugin digest was made by one of the
+// trusted keys. Verification failures are only returned if signatures are
+// enforced; otherwise they are logged to lg, which should identify the plugin.
+func (v *Verifier) Verify(lg *zap.SugaredLogger, digest, signature []byte) error {
+	if v.mode == v1beta1.SignatureModeDisabled {
+		return nil
+	}
+	err := v.verify(digest, signature)
+	if err != nil && v.mode == v1beta1.SignatureModeWarn {
+		lg.With(
+			zap.Error(err),
+		).Warn("plugin signature verification failed, but signatures are not enforced")
+		return nil
+	}
+	return err
+}
+
+func (v *Verifier) verify(digest, signature []byte) error {
+	if len(signature) == 0 {
+		return ErrSignatureMissing
+	}
+	if len(v.keys) == 0 {
+		return fmt.Errorf("%w: no trusted keys are configured", ErrSignatureInvalid)
+	}
+	for _, key := range v.keys {
+		if ed25519.Verify(key, digest, signature) {
+			return nil
+		}
+	}
+	return ErrSignatureInvalid
+}
+
+// VerifyFile computes the digest of the plugin binary at the given path, and
+// verifies it against the plugin's signature file. It returns the digest and
+// signature, which will be nil if the plugin is not signed.
+func (v *Verifier) VerifyFile(lg *zap.SugaredLogger, fsys afero.Fs, path string) (digest []byte, signature []byte, err error) {
+	digest, err = fileDigest(fsys, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	signature, err = ReadSignature(fsys, path)
+	if err != nil && v.mode == v1beta1.SignatureModeEnforce {
+		return nil, nil, err
+	}
+	if err := v.Verify(lg, digest, signature); err != nil {
+		return nil, nil, err
+	}
+	return digest, signature, nil
+}
+
+func fileDigest(fsys afero.Fs, path string) ([]byte, error) {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	hash, _ := blake2b.New256(nil)
+	if _, err := io.Copy(hash, f); err != nil {
+		return nil, err
+	}
+	return hash.Sum(nil), nil
+}
+
+// ReadSignature reads the signature file for the plugin binary at the given
+// path. It returns nil if the plugin has no signature file.
+func ReadSignature(fsys afero.Fs, path string) ([]byte, error) {
+	data, err := afero.ReadFile(fsys, path+SignatureExt)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	signature, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSignatureInvalid, err)
+	}
+	return signature, nil
+}
+
+// Sign signs a plugin digest with the given key. The result should be base64
+// encoded and written to the plugin's signature file.
+func Sign(key ed25519.PrivateKey, digest []byte) []byte {
+	return ed25519.Sign(key, digest)
+}
@@ -0,0 +1,154 @@
+package plugins
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/rancher/opni/pkg/config/v1beta1"
+	"github.com/rancher/opni/pkg/plugins/meta"
+)
+
+const DefaultReloadInterval = 10 * time.Second
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+	// modification time of the plugin's signature file, if any
+	sigModTime time.Time
+}
+
+// WatchPlugins polls the plugin directory for changes and reloads plugins
+// whose binaries were added or modified, and unloads plugins whose binaries
+// were removed. A binary is only reloaded once it has not changed for one
+// polling interval, so that partially written files are not loaded. This
+// function blocks until the context is done, and must only be called after
+// loading has completed.
+//
+// Plugin signatures are verified in the same way as LoadPlugins. A manifest
+// given by WithManifest only describes the plugins as they were when loading
+// started, so it is ignored: the checksum of a changed plugin is always
+// computed from the new binary, and verified against its signature file.
+func (p *PluginLoader) WatchPlugins(ctx context.Context, conf v1beta1.PluginsSpec, scheme meta.Scheme, opts ...LoadOption) {
+	options := LoadOptions{}
+	options.apply(opts...)
+	options.manifest = nil
+
+	interval := DefaultReloadInterval
+	if conf.Reload.Interval != "" {
+		d, err := time.ParseDuration(conf.Reload.Interval)
+		if err != nil {
+			p.logger.With(
+				zap.Error(err),
+				"interval", conf.Reload.Interval,
+			).Warn("invalid plugin reload interval, using default")
+		} else {
+			interval = d
+		}
+	}
+
+	verifier, err := NewVerifier(conf.Signatures)
+	if err != nil {
+		p.logger.With(
+			zap.Error(err),
+		).Error("failed to load trusted plugin signing keys, plugins will not be reloaded")
+		return
+	}
+
+	lg := p.logger.With(
+		"dir", conf.Dir,
+		"interval", interval,
+	)
+	lg.Info("watching plugin directory for changes")
+
+	// modules of the plugins which have been loaded, by path
+	modules := map[string]string{}
+	for _, md := range p.LoadedPlugins() {
+		modules[md.BinaryPath] = md.Module
+	}
+	known := p.statPlugins(conf.Dir)
+	pending := map[string]fileState{}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		current := p.statPlugins(conf.Dir)
+		for path, state := range current {
+			if prev, ok := known[path]; ok && prev == state {
+				delete(pending, path)
+				continue
+			}
+			if prev, ok := pending[path]; !ok || prev != state {
+				// wait until the file stops changing
+				pending[path] = state
+				continue
+			}
+			delete(pending, path)
+			known[path] = state
+
+			md, err := meta.ReadPath(path)
+			if err != nil {
+				lg.With(
+					zap.Error(err),
+					"plugin", path,
+				).Error("failed to read plugin metadata")
+				continue
+			}
+			if prevModule, ok := modules[path]; ok && prevModule != md.Module {
+				p.Unload(prevModule)
+			}
+			cc, ok := p.secureClientConfig(md, scheme, options, verifier)
+			if !ok {
+				continue
+			}
+			if err := p.Reload(ctx, md, cc); err == nil {
+				modules[path] = md.Module
+			}
+		}
+		for path := range known {
+			if _, ok := current[path]; ok {
+				continue
+			}
+			delete(known, path)
+			delete(pending, path)
+			if module, ok := modules[path]; ok {
+				delete(modules, path)
+				p.Unload(module)
+			}
+		}
+	}
+}
+
+func (p *PluginLoader) statPlugins(dir string) map[string]fileState {
+	states := map[string]fileState{}
+	paths, err := filepath.Glob(filepath.Join(dir, DefaultPluginGlob))
+	if err != nil {
+		panic(err)
+	}
+	for _, path := range paths {
+		if filepath.Ext(path) == SignatureExt {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		state := fileState{
+			modTime: info.ModTime(),
+			size:    info.Size(),
+		}
+		if sigInfo, err := os.Stat(path + SignatureExt); err == nil {
+			state.sigModTime = sigInfo.ModTime()
+		}
+		states[path] = state
+	}
+	return states
+}
@@ -0,0 +1,149 @@
+package rbac
+
+import (
+	"context"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"strings"
+
+	"golang.org/x/exp/slices"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+const (
+	// SubjectMetadataKey is the grpc metadata key (and http header) holding the
+	// subject on whose behalf a request is made. It is only honored when set
+	// by a trusted authenticating proxy, or by the gateway itself on the local
+	// listener.
+	SubjectMetadataKey = "x-opni-rbac-subject"
+
+	// LocalSubject is the subject of requests made over the local management
+	// socket by the gateway's own components, such as plugins, or by users
+	// with access to the gateway's filesystem. It is allowed every request.
+	LocalSubject = "system:local"
+
+	reservedSubjectPrefix = "system:"
+)
+
+// LocalAuthInfo is the auth info of connections accepted on a unix socket by
+// the credentials returned by NewLocalOrTLSCredentials.
+type LocalAuthInfo struct {
+	credentials.CommonAuthInfo
+}
+
+func (LocalAuthInfo) AuthType() string {
+	return "local"
+}
+
+type localOrTLSCredentials struct {
+	credentials.TransportCredentials
+}
+
+// NewLocalOrTLSCredentials returns server transport credentials which perform
+// a TLS handshake on network connections, and accept connections on unix
+// sockets without one. Connections on unix sockets are considered
+// authenticated by the permissions of the socket, and are given LocalAuthInfo.
+func NewLocalOrTLSCredentials(tlsCreds credentials.TransportCredentials) credentials.TransportCredentials {
+	return &localOrTLSCredentials{
+		TransportCredentials: tlsCreds,
+	}
+}
+
+func (c *localOrTLSCredentials) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	if conn.LocalAddr().Network() == "unix" {
+		return conn, LocalAuthInfo{
+			CommonAuthInfo: credentials.CommonAuthInfo{
+				SecurityLevel: credentials.PrivacyAndIntegrity,
+			},
+		}, nil
+	}
+	return c.TransportCredentials.ServerHandshake(conn)
+}
+
+func (c *localOrTLSCredentials) Clone() credentials.TransportCredentials {
+	return &localOrTLSCredentials{
+		TransportCredentials: c.TransportCredentials.Clone(),
+	}
+}
+
+// SubjectFromIncomingContext returns the authenticated subject of an incoming
+// grpc request. The subject is the common name of the client's verified TLS
+// certificate. If the client is one of the trusted proxies, the subject set by
+// the proxy in the request metadata is used instead. Requests on a local
+// listener are made by LocalSubject, unless the subject is set in the request
+// metadata by the gateway's HTTP API.
+func SubjectFromIncomingContext(ctx context.Context, trustedProxies []string) (string, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	switch info := p.AuthInfo.(type) {
+	case LocalAuthInfo:
+		if subject, ok := subjectFromMetadata(ctx); ok {
+			return subject, true
+		}
+		return LocalSubject, true
+	case credentials.TLSInfo:
+		name, ok := verifiedCommonName(info.State.VerifiedChains)
+		if !ok {
+			return "", false
+		}
+		if slices.Contains(trustedProxies, name) {
+			if subject, ok := subjectFromMetadata(ctx); ok {
+				return subject, true
+			}
+		}
+		return validSubject(name)
+	default:
+		return "", false
+	}
+}
+
+// SubjectFromHTTPRequest returns the authenticated subject of an incoming http
+// request, in the same way as SubjectFromIncomingContext.
+func SubjectFromHTTPRequest(r *http.Request, trustedProxies []string) (string, bool) {
+	if r.TLS == nil {
+		return "", false
+	}
+	name, ok := verifiedCommonName(r.TLS.VerifiedChains)
+	if !ok {
+		return "", false
+	}
+	if slices.Contains(trustedProxies, name) {
+		if subject := r.Header.Get(SubjectMetadataKey); subject != "" {
+			return validSubject(subject)
+		}
+	}
+	return validSubject(name)
+}
+
+func subjectFromMetadata(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+	values := md.Get(SubjectMetadataKey)
+	if len(values) != 1 || values[0] == "" {
+		return "", false
+	}
+	return validSubject(values[0])
+}
+
+func verifiedCommonName(chains [][]*x509.Certificate) (string, bool) {
+	if len(chains) == 0 || len(chains[0]) == 0 {
+		return "", false
+	}
+	return chains[0][0].Subject.CommonName, chains[0][0].Subject.CommonName != ""
+}
+
+// Subjects with the reserved prefix can't be claimed by clients, so that
+// nobody can impersonate the gateway's own components.
+func validSubject(subject string) (string, bool) {
+	if subject == "" || strings.HasPrefix(subject, reservedSubjectPrefix) {
+		return "", false
+	}
+	return subject, true
+}
@@ -0,0 +1,94 @@
+package rbac_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/rancher/opni/pkg/rbac"
+	"github.com/rancher/opni/pkg/test"
+)
+
+var _ = Describe("Identity", Label("unit"), func() {
+	var chains [][]*x509.Certificate
+	BeforeEach(func() {
+		cert, err := tls.X509KeyPair(test.TestData("localhost.crt"), test.TestData("localhost.key"))
+		Expect(err).NotTo(HaveOccurred())
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		Expect(err).NotTo(HaveOccurred())
+		chains = [][]*x509.Certificate{{leaf}}
+	})
+	incomingContext := func(authInfo credentials.AuthInfo, subject ...string) context.Context {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{AuthInfo: authInfo})
+		if len(subject) > 0 {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(rbac.SubjectMetadataKey, subject[0]))
+		}
+		return ctx
+	}
+	tlsInfo := func() credentials.AuthInfo {
+		return credentials.TLSInfo{
+			State: tls.ConnectionState{VerifiedChains: chains},
+		}
+	}
+
+	DescribeTable("authenticating grpc requests",
+		func(ctx func() context.Context, trustedProxies []string, expected string) {
+			subject, ok := rbac.SubjectFromIncomingContext(ctx(), trustedProxies)
+			Expect(ok).To(Equal(expected != ""))
+			Expect(subject).To(Equal(expected))
+		},
+		Entry("no peer", context.Background, nil, ""),
+		Entry("insecure connection", func() context.Context {
+			return incomingContext(nil, "user")
+		}, nil, ""),
+		Entry("client certificate", func() context.Context {
+			return incomingContext(tlsInfo())
+		}, nil, "leaf"),
+		Entry("unverified client certificate", func() context.Context {
+			return incomingContext(credentials.TLSInfo{}, "user")
+		}, []string{"leaf"}, ""),
+		Entry("subject set by an untrusted client", func() context.Context {
+			return incomingContext(tlsInfo(), "user")
+		}, nil, "leaf"),
+		Entry("subject set by a trusted proxy", func() context.Context {
+			return incomingContext(tlsInfo(), "user")
+		}, []string{"leaf"}, "user"),
+		Entry("reserved subject set by a trusted proxy", func() context.Context {
+			return incomingContext(tlsInfo(), rbac.LocalSubject)
+		}, []string{"leaf"}, "leaf"),
+		Entry("local connection", func() context.Context {
+			return incomingContext(rbac.LocalAuthInfo{})
+		}, nil, rbac.LocalSubject),
+		Entry("subject set on a local connection", func() context.Context {
+			return incomingContext(rbac.LocalAuthInfo{}, "user")
+		}, nil, "user"),
+	)
+
+	DescribeTable("authenticating http requests",
+		func(verified bool, header string, trustedProxies []string, expected string) {
+			req, err := http.NewRequest(http.MethodGet, "https://localhost", nil)
+			Expect(err).NotTo(HaveOccurred())
+			if verified {
+				req.TLS = &tls.ConnectionState{VerifiedChains: chains}
+			}
+			if header != "" {
+				req.Header.Set(rbac.SubjectMetadataKey, header)
+			}
+			subject, ok := rbac.SubjectFromHTTPRequest(req, trustedProxies)
+			Expect(ok).To(Equal(expected != ""))
+			Expect(subject).To(Equal(expected))
+		},
+		Entry("no client certificate", false, "user", []string{"leaf"}, ""),
+		Entry("client certificate", true, "", nil, "leaf"),
+		Entry("subject set by an untrusted client", true, "user", nil, "leaf"),
+		Entry("subject set by a trusted proxy", true, "user", []string{"leaf"}, "user"),
+		Entry("reserved subject set by a trusted proxy", true, rbac.LocalSubject, []string{"leaf"}, ""),
+	)
+})
@@ -0,0 +1,197 @@
+package rbac
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Permission is the verb and resource kind a subject needs to call a method.
+type Permission struct {
+	Verb     corev1.Verb
+	Resource corev1.ResourceKind
+}
+
+// PermissionResolver returns the permission required to call the grpc method
+// with the given full name. If no permission is required, it returns nil.
+type PermissionResolver func(fullMethod string) *Permission
+
+// TargetResolver returns the clusters a request to the grpc method with the
+// given full name acts on.
+type TargetResolver func(ctx context.Context, fullMethod string, req any) (Targets, error)
+
+type AuthorizerOptions struct {
+	trustedProxies []string
+	resolveTargets TargetResolver
+}
+
+type AuthorizerOption func(*AuthorizerOptions)
+
+func (o *AuthorizerOptions) apply(opts ...AuthorizerOption) {
+	for _, op := range opts {
+		op(o)
+	}
+}
+
+// WithTrustedProxies sets the common names of the client certificates of
+// authenticating proxies, which may set the subject of their requests.
+func WithTrustedProxies(names ...string) AuthorizerOption {
+	return func(o *AuthorizerOptions) {
+		o.trustedProxies = append(o.trustedProxies, names...)
+	}
+}
+
+// WithTargetResolver sets the function used to find the clusters a request
+// acts on. Defaults to TargetsFromMessage.
+func WithTargetResolver(resolve TargetResolver) AuthorizerOption {
+	return func(o *AuthorizerOptions) {
+		o.resolveTargets = resolve
+	}
+}
+
+// Authorizer enforces the permissions of the authenticated subjects of
+// incoming requests. Requests without an authenticated subject are denied.
+type Authorizer struct {
+	AuthorizerOptions
+	provider Provider
+	resolve  PermissionResolver
+}
+
+func NewAuthorizer(provider Provider, resolve PermissionResolver, opts ...AuthorizerOption) *Authorizer {
+	options := AuthorizerOptions{
+		resolveTargets: func(_ context.Context, _ string, req any) (Targets, error) {
+			return TargetsFromMessage(req)
+		},
+	}
+	options.apply(opts...)
+	return &Authorizer{
+		AuthorizerOptions: options,
+		provider:          provider,
+		resolve:           resolve,
+	}
+}
+
+func (a *Authorizer) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := a.Authorize(ctx, info.FullMethod, req); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor checks the subject's roles when the stream is
+// opened, and the clusters the stream acts on when the first message is
+// received.
+func (a *Authorizer) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := a.Authorize(ss.Context(), info.FullMethod, nil); err != nil {
+			return err
+		}
+		return handler(srv, &authorizingServerStream{
+			ServerStream: ss,
+			authorize: func(m any) error {
+				return a.Authorize(ss.Context(), info.FullMethod, m)
+			},
+		})
+	}
+}
+
+type authorizingServerStream struct {
+	grpc.ServerStream
+	authorize func(any) error
+	once      sync.Once
+}
+
+func (s *authorizingServerStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	var err error
+	s.once.Do(func() {
+		err = s.authorize(m)
+	})
+	return err
+}
+
+// Authorize checks that the authenticated subject of the incoming context is
+// allowed to call the grpc method with the given full name. If req is not
+// nil, the subject must also be allowed to act on the clusters targeted by
+// the request.
+func (a *Authorizer) Authorize(ctx context.Context, fullMethod string, req any) error {
+	subject, ok := SubjectFromIncomingContext(ctx, a.trustedProxies)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "request has no authenticated subject")
+	}
+	return a.AuthorizeSubject(ctx, subject, fullMethod, req)
+}
+
+// AuthorizeHTTPRequest checks that the authenticated subject of an incoming
+// http request is allowed to make the request, which is handled by the grpc
+// method with the given full name.
+func (a *Authorizer) AuthorizeHTTPRequest(r *http.Request, fullMethod string, req any) error {
+	subject, ok := SubjectFromHTTPRequest(r, a.trustedProxies)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "request has no authenticated subject")
+	}
+	return a.AuthorizeSubject(r.Context(), subject, fullMethod, req)
+}
+
+// TrustedProxies returns the common names of the trusted authenticating
+// proxies.
+func (a *Authorizer) TrustedProxies() []string {
+	return a.trustedProxies
+}
+
+// AuthorizeSubject checks that the subject, which must already have been
+// authenticated, is allowed to make the request.
+func (a *Authorizer) AuthorizeSubject(ctx context.Context, subject string, fullMethod string, req any) error {
+	if subject == LocalSubject {
+		return nil
+	}
+	perm := a.resolve(fullMethod)
+	if perm == nil {
+		return nil
+	}
+	sar := &corev1.SubjectAccessRequest{
+		Subject:  subject,
+		Verb:     string(perm.Verb),
+		Resource: string(perm.Resource),
+	}
+	if req != nil && perm.Resource.ClusterScoped() {
+		targets, err := a.resolveTargets(ctx, fullMethod, req)
+		if err != nil {
+			if _, ok := status.FromError(err); ok {
+				return err
+			}
+			return status.Errorf(codes.Internal, "failed to find the clusters targeted by the request: %v", err)
+		}
+		sar.Clusters = targets.Clusters
+		// Changes to resources which can't be attributed to clusters require
+		// a role which applies to every cluster.
+		sar.AllClusters = targets.AllClusters || (len(targets.Clusters) == 0 && perm.Verb != corev1.VerbRead)
+	}
+	allowed, err := a.provider.Authorize(ctx, sar)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to authorize subject: %v", err)
+	}
+	if !allowed {
+		switch {
+		case sar.AllClusters:
+			return status.Errorf(codes.PermissionDenied, "subject %q is not allowed to %s %s in all clusters",
+				subject, perm.Verb, perm.Resource)
+		case len(sar.Clusters) > 0:
+			return status.Errorf(codes.PermissionDenied, "subject %q is not allowed to %s %s in clusters %v",
+				subject, perm.Verb, perm.Resource, sar.Clusters)
+		default:
+			return status.Errorf(codes.PermissionDenied, "subject %q is not allowed to %s %s",
+				subject, perm.Verb, perm.Resource)
+		}
+	}
+	return nil
+}
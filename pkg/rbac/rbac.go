@@ -12,7 +12,13 @@ const (
 )
 
 type Provider interface {
+	// SubjectAccess returns the clusters the subject may access with the
+	// requested verb on the requested resource kind.
 	SubjectAccess(context.Context, *corev1.SubjectAccessRequest) (*corev1.ReferenceList, error)
+	// Authorize reports whether the roles bound to the subject allow the
+	// requested verb on the requested resource kind in each of the requested
+	// clusters, or in every cluster if requested.
+	Authorize(context.Context, *corev1.SubjectAccessRequest) (bool, error)
 }
 
 func AuthorizedUserID(c *gin.Context) (string, bool) {
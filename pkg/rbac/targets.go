@@ -0,0 +1,121 @@
+package rbac
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/samber/lo"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Targets are the clusters a request acts on.
+type Targets struct {
+	Clusters []string
+	// AllClusters is set if the request acts on resources which are not
+	// limited to a known set of clusters, such as every cluster matching a
+	// label selector.
+	AllClusters bool
+}
+
+// Add adds the clusters and flags of other to t.
+func (t *Targets) Add(other Targets) {
+	if clusters := append(t.Clusters, other.Clusters...); len(clusters) > 0 {
+		t.Clusters = lo.Uniq(clusters)
+		sort.Strings(t.Clusters)
+	}
+	t.AllClusters = t.AllClusters || other.AllClusters
+}
+
+// Names of fields holding cluster IDs, either as strings or as references.
+var clusterFieldNames = map[string]struct{}{
+	"cluster":    {},
+	"clusterId":  {},
+	"clusterID":  {},
+	"clusterIds": {},
+	"clusterIDs": {},
+	"clusters":   {},
+	"toClusters": {},
+	"tenants":    {},
+}
+
+// TargetsFromMessage finds the clusters referenced by the fields of a request
+// message, which is either a proto.Message or a dynamic message which can be
+// marshaled to JSON. Cluster selectors which are not limited to a list of
+// cluster IDs target every cluster.
+func TargetsFromMessage(msg any) (Targets, error) {
+	var data []byte
+	var err error
+	switch m := msg.(type) {
+	case proto.Message:
+		data, err = protojson.Marshal(m)
+	case json.Marshaler:
+		data, err = m.MarshalJSON()
+	default:
+		return Targets{}, fmt.Errorf("unsupported message type %T", msg)
+	}
+	if err != nil {
+		return Targets{}, err
+	}
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return Targets{}, err
+	}
+	var targets Targets
+	collectTargets(value, &targets)
+	targets.Add(Targets{})
+	return targets, nil
+}
+
+func collectTargets(value any, targets *Targets) {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if _, ok := clusterFieldNames[key]; ok {
+				targets.Clusters = append(targets.Clusters, clusterIDs(field)...)
+				continue
+			}
+			if key == "selector" {
+				if sel, ok := field.(map[string]any); ok && selectsAnyCluster(sel) {
+					targets.AllClusters = true
+				}
+			}
+			collectTargets(field, targets)
+		}
+	case []any:
+		for _, item := range v {
+			collectTargets(item, targets)
+		}
+	}
+}
+
+func clusterIDs(value any) []string {
+	switch v := value.(type) {
+	case string:
+		if v != "" {
+			return []string{v}
+		}
+	case map[string]any:
+		if id, ok := v["id"].(string); ok && id != "" {
+			return []string{id}
+		}
+	case []any:
+		var ids []string
+		for _, item := range v {
+			ids = append(ids, clusterIDs(item)...)
+		}
+		return ids
+	}
+	return nil
+}
+
+// selectsAnyCluster reports whether a cluster selector can match clusters
+// other than the ones it lists by ID.
+func selectsAnyCluster(sel map[string]any) bool {
+	if labels, ok := sel["labelSelector"].(map[string]any); ok && len(labels) > 0 {
+		return true
+	}
+	ids, _ := sel["clusterIDs"].([]any)
+	return len(ids) == 0
+}
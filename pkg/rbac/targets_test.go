@@ -0,0 +1,66 @@
+package rbac_test
+
+import (
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/proto"
+
+	capabilityv1 "github.com/rancher/opni/pkg/apis/capability/v1"
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	managementv1 "github.com/rancher/opni/pkg/apis/management/v1"
+	"github.com/rancher/opni/pkg/rbac"
+)
+
+var _ = Describe("Targets", Label("unit"), func() {
+	DescribeTable("finding the clusters targeted by a request",
+		func(msg proto.Message, expected rbac.Targets) {
+			targets, err := rbac.TargetsFromMessage(msg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(targets).To(Equal(expected))
+		},
+		Entry("no clusters", &managementv1.ListClustersRequest{}, rbac.Targets{}),
+		Entry("cluster reference", &managementv1.EditClusterRequest{
+			Cluster: &corev1.Reference{Id: "c1"},
+		}, rbac.Targets{Clusters: []string{"c1"}}),
+		Entry("nested cluster reference", &managementv1.CapabilityInstallRequest{
+			Name: "metrics",
+			Target: &capabilityv1.InstallRequest{
+				Cluster: &corev1.Reference{Id: "c1"},
+			},
+		}, rbac.Targets{Clusters: []string{"c1"}}),
+		Entry("selector with cluster ids", &managementv1.BulkCapabilityInstallRequest{
+			Selector: &corev1.ClusterSelector{
+				ClusterIDs: []string{"c2", "c1", "c2"},
+			},
+		}, rbac.Targets{Clusters: []string{"c1", "c2"}}),
+		Entry("selector with labels", &managementv1.BulkCapabilityInstallRequest{
+			Selector: &corev1.ClusterSelector{
+				ClusterIDs: []string{"c1"},
+				LabelSelector: &corev1.LabelSelector{
+					MatchLabels: map[string]string{"foo": "bar"},
+				},
+			},
+		}, rbac.Targets{Clusters: []string{"c1"}, AllClusters: true}),
+		Entry("empty selector", &managementv1.BulkCapabilityInstallRequest{
+			Selector: &corev1.ClusterSelector{},
+		}, rbac.Targets{AllClusters: true}),
+	)
+	It("should find clusters in dynamic messages", func() {
+		md, err := desc.LoadMessageDescriptorForMessage(&capabilityv1.InstallRequest{})
+		Expect(err).NotTo(HaveOccurred())
+		msg := dynamic.NewMessage(md)
+		Expect(msg.ConvertFrom(&capabilityv1.InstallRequest{
+			Cluster: &corev1.Reference{Id: "c1"},
+		})).To(Succeed())
+
+		targets, err := rbac.TargetsFromMessage(msg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(targets).To(Equal(rbac.Targets{Clusters: []string{"c1"}}))
+	})
+	It("should reject unsupported messages", func() {
+		_, err := rbac.TargetsFromMessage("c1")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -13,6 +13,9 @@ type ModuleContext struct {
 	Log    *zap.SugaredLogger
 	Client managementv1.ManagementClient
 	Reg    prometheus.Registerer
+	// Directory in which the module's state is persisted (see State). If
+	// empty, state is kept in memory only.
+	StateDir string
 }
 
 type Module interface {
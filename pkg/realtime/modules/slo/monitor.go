@@ -3,17 +3,50 @@ package slo
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"math"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
 	managementv1 "github.com/rancher/opni/pkg/apis/management/v1"
+	"github.com/rancher/opni/pkg/metrics/unmarshal"
+	"github.com/rancher/opni/pkg/slo/shared"
 	"github.com/rancher/opni/plugins/metrics/pkg/apis/cortexadmin"
 	"github.com/rancher/opni/plugins/slo/pkg/apis/slo"
+	sloimpl "github.com/rancher/opni/plugins/slo/pkg/slo"
 	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
 )
 
+const (
+	defaultEvaluationInterval = time.Minute
+	queryTimeout              = 30 * time.Second
+
+	// Burn rates at or above these thresholds put the SLO in the Warning
+	// state. These are the thresholds commonly used for multi-window
+	// burn rate alerts, at which 2% (1h) or 5% (6h) of a 30 day error budget
+	// would be consumed within the window.
+	fastBurnRateThreshold = 14.4
+	slowBurnRateThreshold = 6
+)
+
+var (
+	fastBurnRateWindow = time.Hour
+	slowBurnRateWindow = 6 * time.Hour
+)
+
+// monitor continuously evaluates a single SLO. Instead of querying the
+// SLI over the whole SLO period each time, the monitor queries the number
+// of good and total events since its last evaluation and keeps a history of
+// these samples covering the SLO period, from which the SLI, remaining error
+// budget and burn rates are computed.
+//
+// SLOs of other datasources are evaluated by the SLO plugin, and the monitor
+// only reports their status.
 type monitor struct {
 	slo               *slo.SLOData
 	mgmtClient        managementv1.ManagementClient
@@ -21,102 +54,361 @@ type monitor struct {
 	sloClient         slo.SLOClient
 	logger            *zap.SugaredLogger
 
-	sloStatus *prometheus.GaugeVec
+	sloStatus       *prometheus.GaugeVec
+	sli             prometheus.Gauge
+	objective       prometheus.Gauge
+	budgetRemaining prometheus.Gauge
+	burnRate        *prometheus.GaugeVec
 }
 
 func (t *monitor) InitMetrics() []prometheus.Collector {
-	// TODO(yingbei): add/update metrics here as needed
+	// all SLOs share the same metrics, distinguished by these labels
+	constLabels := prometheus.Labels{
+		"slo_id":     t.slo.GetId(),
+		"slo_name":   t.slo.GetSLO().GetName(),
+		"cluster_id": t.slo.GetSLO().GetClusterId(),
+		"service":    t.slo.GetSLO().GetServiceId(),
+	}
 	t.sloStatus = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Namespace: "opni",
-		Subsystem: "rt",
-		Name:      "slo_status",
-		Help:      "SLO status",
-	}, []string{
-		"metric_name",
-		"target",
-		"desc",
+		Namespace:   "opni",
+		Subsystem:   "rt",
+		Name:        "slo_status",
+		Help:        "SLO status (1 for the current state, 0 otherwise)",
+		ConstLabels: constLabels,
+	}, []string{"state"})
+	t.sli = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   "opni",
+		Subsystem:   "rt",
+		Name:        "slo_sli_ratio",
+		Help:        "Ratio of good events to total events over the SLO period",
+		ConstLabels: constLabels,
+	})
+	t.objective = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   "opni",
+		Subsystem:   "rt",
+		Name:        "slo_objective_ratio",
+		Help:        "SLO objective",
+		ConstLabels: constLabels,
+	})
+	t.budgetRemaining = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace:   "opni",
+		Subsystem:   "rt",
+		Name:        "slo_error_budget_remaining_ratio",
+		Help:        "Fraction of the error budget remaining over the SLO period (negative if exceeded)",
+		ConstLabels: constLabels,
 	})
+	t.burnRate = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace:   "opni",
+		Subsystem:   "rt",
+		Name:        "slo_burn_rate_ratio",
+		Help:        "Rate at which the error budget is being consumed, relative to the rate which would exactly exhaust it over the SLO period",
+		ConstLabels: constLabels,
+	}, []string{"window"})
 	return []prometheus.Collector{
 		t.sloStatus,
+		t.sli,
+		t.objective,
+		t.budgetRemaining,
+		t.burnRate,
 	}
 }
 
+// sloState is persisted between evaluations.
 type sloState struct {
-	Time int64 `json:"time"`
+	// Queries used to collect the samples, with a placeholder for the range.
+	// If the SLO's queries change, existing samples are discarded.
+	Queries string `json:"queries"`
+	// Samples in chronological order
+	Samples []eventSample `json:"samples"`
 }
 
-type queryResponse struct {
-	data struct {
-		result []model.Sample `json:"result"`
-	} `json:"data"`
+// eventSample holds the number of good and total events in the window
+// ending at Time, which is Window seconds long.
+type eventSample struct {
+	Time   int64   `json:"time"`
+	Window int64   `json:"window"`
+	Good   float64 `json:"good"`
+	Total  float64 `json:"total"`
+}
+
+type sloSummary struct {
+	Status          slo.SLOStatusState
+	SLI             float64
+	BudgetRemaining float64
+	BurnRates       map[string]float64
 }
 
 func (t *monitor) Run(ctx context.Context, state io.ReadWriter) {
-	// TODO(yingbei): implement
+	interval := defaultEvaluationInterval
+	if bi := t.slo.GetSLO().GetBudgetingInterval(); bi != nil && bi.AsDuration() > 0 {
+		interval = bi.AsDuration()
+	}
+	t.objective.Set(t.slo.GetSLO().GetTarget().GetValue() / 100)
+	if ds := t.slo.GetSLO().GetDatasource(); ds != shared.MonitoringDatasource {
+		t.logger.Debugf("SLI samples are not supported for %s SLOs, reporting the status of the SLO plugin", ds)
+		t.runStatus(ctx, interval)
+		return
+	}
+	period, err := model.ParseDuration(t.slo.GetSLO().GetSloPeriod())
+	if err != nil {
+		t.logger.With(
+			zap.Error(err),
+		).Error("invalid SLO period")
+		t.updateMetrics(unknownSummary(slo.SLOStatusState_InternalError))
+		<-ctx.Done()
+		return
+	}
+	goodQuery, totalQuery, err := t.eventsQueries("$window")
+	if err != nil {
+		t.logger.With(
+			zap.Error(err),
+		).Error("invalid SLO queries")
+		t.updateMetrics(unknownSummary(slo.SLOStatusState_InternalError))
+		<-ctx.Done()
+		return
+	}
+
+	s := &sloState{}
+	if err := json.NewDecoder(state).Decode(s); err != nil && !errors.Is(err, io.EOF) {
+		t.logger.With(
+			zap.Error(err),
+		).Warn("failed to load SLO state, starting over")
+		s = &sloState{}
+	}
+	if queries := goodQuery + "\n" + totalQuery; s.Queries != queries {
+		s = &sloState{Queries: queries}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		now := time.Now()
+		if err := t.evaluate(ctx, s, now, time.Duration(period)); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			t.logger.With(
+				zap.Error(err),
+			).Warn("failed to evaluate SLO")
+		} else if err := json.NewEncoder(state).Encode(s); err != nil {
+			t.logger.With(
+				zap.Error(err),
+			).Warn("failed to save SLO state")
+		}
+		t.updateMetrics(s.summarize(now, time.Duration(period), t.slo.GetSLO().GetTarget().GetValue()/100))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Queries the events since the last sample (or the start of the SLO period)
+// and adds them to the state.
+func (t *monitor) evaluate(ctx context.Context, s *sloState, now time.Time, period time.Duration) error {
+	from := now.Add(-period)
+	if created := t.slo.GetCreatedAt(); created != nil && created.AsTime().After(from) {
+		from = created.AsTime()
+	}
+	if len(s.Samples) > 0 {
+		if last := time.Unix(s.Samples[len(s.Samples)-1].Time, 0); last.After(from) {
+			from = last
+		}
+	}
+	window := now.Sub(from).Truncate(time.Second)
+	if window < time.Second {
+		return nil
+	}
+	goodQuery, totalQuery, err := t.eventsQueries(model.Duration(window).String())
+	if err != nil {
+		return err
+	}
+	// the queries return per-second rates over the window
+	good, err := t.query(ctx, goodQuery)
+	if err != nil {
+		return fmt.Errorf("failed to query good events: %w", err)
+	}
+	total, err := t.query(ctx, totalQuery)
+	if err != nil {
+		return fmt.Errorf("failed to query total events: %w", err)
+	}
+	s.add(eventSample{
+		Time:   now.Unix(),
+		Window: int64(window / time.Second),
+		Good:   good * window.Seconds(),
+		Total:  total * window.Seconds(),
+	}, now.Add(-period))
+	return nil
+}
 
-	s := sloState{
-		Time: time.Now().Unix(),
+func (t *monitor) query(ctx context.Context, query string) (float64, error) {
+	ctx, ca := context.WithTimeout(ctx, queryTimeout)
+	defer ca()
+	resp, err := t.cortexAdminClient.Query(ctx, &cortexadmin.QueryRequest{
+		Tenants: []string{t.slo.GetSLO().GetClusterId()},
+		Query:   query,
+	})
+	if err != nil {
+		return 0, err
+	}
+	qr, err := unmarshal.UnmarshalPrometheusResponse(resp.GetData())
+	if err != nil {
+		return 0, err
+	}
+	vector, err := qr.GetVector()
+	if err != nil {
+		return 0, err
+	}
+	var sum float64
+	for _, sample := range *vector {
+		if v := float64(sample.Value); !math.IsNaN(v) {
+			sum += v
+		}
 	}
+	return sum, nil
+}
 
-	json.NewEncoder(state).Encode(s)
-	json.NewDecoder(state).Decode(&s)
+// Returns the good and total events queries over the given window, built
+// the same way as the SLO plugin's, so that both agree on the SLI.
+func (t *monitor) eventsQueries(window string) (good, total string, err error) {
+	// SLODataToStruct modifies the events of SLOs with identical metrics
+	sloStruct := sloimpl.SLODataToStruct(proto.Clone(t.slo).(*slo.SLOData))
+	good, err = sloStruct.RawGoodEventsQuery(window)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to build good events query: %w", err)
+	}
+	total, err = sloStruct.RawTotalEventsQuery(window)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to build total events query: %w", err)
+	}
+	return good, total, nil
+}
 
-	// example code:
+// runStatus periodically reports the status of the SLO as evaluated by the
+// SLO plugin, for datasources which the monitor cannot sample itself.
+func (t *monitor) runStatus(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 	for {
-		// get current value of the SLI metric
-		// query range: say last 1 min, it needs a start time, end time, step
-
-		// var query = "opni_rt_slo_status"
-		// query = t.slo.Name
-		// resp, err := t.cortexAdminClient.QueryRange(context.Background(), &cortexadmin.QueryRequest{
-		// 	Tenants: []string{t.slo.ClusterId},
-		// 	Query:   query,
-		// })
-		// if err != nil {
-
-		// }
-		// qr := queryResponse{}
-		// json.Unmarshal(resp.Data, &qr)
-		// var sliMetric = qr.data.result[0].value
-		// var sloMetric = 0.0
-		// // apply formulars, for now we can suport sum, average, percentile(99%)?
-		// if t.slo.FormulaId == "1" {
-		// 	sloMetric = sum(sliMetric)
-		// } else {
-		// 	sloMetric = mean(sliMetric)
-		// }
-
-		// //comapre to targets, assuming do smaller( < ) , and calculate error budget accordingly
-		// //TODO: slo need a start date or created date
-		// for _, threshold := range t.slo.Targets { // Timewindow = [[7days, 99.5%], [300days, 99.95%]], Targets = [200ms, 200ms]
-		// 	var timeWindow, percentage = t.slo.TimeWindow[0], t.slo.TimeWindow[1]
-		// 	var totalErrorBudget = timeWindow * (1 - percentage) * 24 * 60 * 60 // seconds
-		// 	var remainingBudget float64
-		// 	if sloMetric < threshold {
-		// 		// a Normal case
-		// 	} else {
-		// 		// an Error case that reduce available error budget
-		// 		t.sloStatus.errorBudget -= 60 // seconds, value depends on the scrape interval
-		// 		remainingBudget = t.sloStatus.errorBudget
-
-		// 		// TODO: Warnings depends on remainingBudget
-		// 		// hard warning threshold, optional now
-		// 		if remainingBudget < warningThreshold {
-		// 			// do somehthing warning
-		// 		}
-		// 		//burning rate
-		// 		// if remainingBudget/totalErrorBudget < (currentTime-startTime)/timeWindow {
-
-		// 		// }
-		// 	}
-		// }
-
-		// t.sloStatus.With(prometheus.Labels{
-		// 	"metric_name": "foo",
-		// 	"target":      strconv.FormatUint(t.slo.Targets[0].ValueX100, 10),
-		// 	"desc":        "todo",
-		// }).Set(0)
-
-		// time.Sleep(1)
+		summary := unknownSummary(slo.SLOStatusState_InternalError)
+		qctx, ca := context.WithTimeout(ctx, queryTimeout)
+		status, err := t.sloClient.Status(qctx, &corev1.Reference{Id: t.slo.GetId()})
+		ca()
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			t.logger.With(
+				zap.Error(err),
+			).Warn("failed to get SLO status")
+		} else {
+			summary.Status = status.GetState()
+		}
+		t.updateMetrics(summary)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Returns a summary with the given status, for SLOs whose SLI is unknown.
+func unknownSummary(status slo.SLOStatusState) sloSummary {
+	return sloSummary{
+		Status:          status,
+		SLI:             math.NaN(),
+		BudgetRemaining: math.NaN(),
+	}
+}
+
+// Adds a sample and drops samples which ended before the cutoff.
+func (s *sloState) add(sample eventSample, cutoff time.Time) {
+	s.Samples = append(s.Samples, sample)
+	i := 0
+	for i < len(s.Samples) && s.Samples[i].Time <= cutoff.Unix() {
+		i++
+	}
+	s.Samples = s.Samples[i:]
+}
+
+// Returns the number of good and total events within the given window
+// before now. Samples which only partially overlap the window, such as the
+// first sample after the monitor starts, are prorated assuming their events
+// are evenly distributed.
+func (s *sloState) events(now time.Time, window time.Duration) (good, total float64) {
+	cutoff := now.Add(-window).Unix()
+	for i := len(s.Samples) - 1; i >= 0 && s.Samples[i].Time > cutoff; i-- {
+		sample := s.Samples[i]
+		fraction := 1.0
+		if start := sample.Time - sample.Window; sample.Window > 0 && start < cutoff {
+			fraction = float64(sample.Time-cutoff) / float64(sample.Window)
+		}
+		good += sample.Good * fraction
+		total += sample.Total * fraction
+	}
+	return
+}
+
+func (s *sloState) summarize(now time.Time, period time.Duration, objective float64) sloSummary {
+	summary := sloSummary{
+		Status:          slo.SLOStatusState_Ok,
+		SLI:             math.NaN(),
+		BudgetRemaining: 1,
+		BurnRates:       map[string]float64{},
+	}
+	errorBudget := 1 - objective
+	burnRate := func(good, total float64) float64 {
+		if total <= 0 {
+			return 0
+		}
+		errorRate := math.Max(0, 1-good/total)
+		if errorBudget <= 0 {
+			if errorRate > 0 {
+				return math.Inf(1)
+			}
+			return 0
+		}
+		return errorRate / errorBudget
+	}
+
+	good, total := s.events(now, period)
+	if total <= 0 {
+		summary.Status = slo.SLOStatusState_NoData
+	} else {
+		summary.SLI = math.Min(1, good/total)
+		summary.BudgetRemaining = 1 - burnRate(good, total)
+	}
+	summary.BurnRates[model.Duration(period).String()] = burnRate(good, total)
+	fast := burnRate(s.events(now, fastBurnRateWindow))
+	slow := burnRate(s.events(now, slowBurnRateWindow))
+	summary.BurnRates[model.Duration(fastBurnRateWindow).String()] = fast
+	summary.BurnRates[model.Duration(slowBurnRateWindow).String()] = slow
+
+	switch {
+	case summary.Status == slo.SLOStatusState_NoData:
+	case summary.BudgetRemaining <= 0:
+		summary.Status = slo.SLOStatusState_Breaching
+	case fast >= fastBurnRateThreshold || slow >= slowBurnRateThreshold:
+		summary.Status = slo.SLOStatusState_Warning
+	}
+	return summary
+}
+
+func (t *monitor) updateMetrics(summary sloSummary) {
+	for value, name := range slo.SLOStatusState_name {
+		if slo.SLOStatusState(value) == summary.Status {
+			t.sloStatus.WithLabelValues(name).Set(1)
+		} else {
+			t.sloStatus.WithLabelValues(name).Set(0)
+		}
+	}
+	t.sli.Set(summary.SLI)
+	t.budgetRemaining.Set(summary.BudgetRemaining)
+	for window, rate := range summary.BurnRates {
+		t.burnRate.WithLabelValues(window).Set(rate)
 	}
 }
@@ -0,0 +1,230 @@
+package slo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	"github.com/rancher/opni/pkg/realtime/modules"
+	"github.com/rancher/opni/pkg/slo/shared"
+	"github.com/rancher/opni/plugins/slo/pkg/apis/slo"
+)
+
+type fakeSLOClient struct {
+	slo.SLOClient
+	status chan *slo.SLOStatus
+}
+
+func (c *fakeSLOClient) Status(ctx context.Context, _ *corev1.Reference, _ ...grpc.CallOption) (*slo.SLOStatus, error) {
+	var status *slo.SLOStatus
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case status = <-c.status:
+	}
+	if status == nil {
+		return nil, errors.New("unavailable")
+	}
+	return status, nil
+}
+
+var _ = Describe("SLO Monitor", Label("unit"), func() {
+	period := 24 * time.Hour
+	now := time.Unix(1_700_000_000, 0)
+
+	// one sample per hour over the last n hours, each with the given number
+	// of good events out of 1000
+	hourly := func(n int, good float64) *sloState {
+		s := &sloState{}
+		for i := n - 1; i >= 0; i-- {
+			s.add(eventSample{
+				Time:   now.Add(-time.Duration(i) * time.Hour).Unix(),
+				Window: int64(time.Hour / time.Second),
+				Good:   good,
+				Total:  1000,
+			}, now.Add(-period))
+		}
+		return s
+	}
+
+	It("should build the same event queries as the SLO plugin", func() {
+		t := &monitor{
+			slo: &slo.SLOData{
+				Id: "slo-1",
+				SLO: &slo.ServiceLevelObjective{
+					Name:            "api",
+					ServiceId:       "api",
+					GoodMetricName:  "http_requests_total",
+					TotalMetricName: "http_requests_total",
+					GoodEvents:      []*slo.Event{{Key: "code", Vals: []string{"200", "201"}}},
+					TotalEvents:     []*slo.Event{{Key: "method", Vals: []string{"GET"}}},
+					SloPeriod:       "30d",
+					Target:          &slo.Target{Value: 99},
+				},
+			},
+		}
+		good, total, err := t.eventsQueries("5m")
+		Expect(err).NotTo(HaveOccurred())
+		// good events are restricted to the total events of the same metric
+		Expect(good).To(Equal(`sum(rate(http_requests_total{job="api",code=~"200|201",method=~"GET"}[5m]))`))
+		Expect(total).To(Equal(`sum(rate(http_requests_total{job="api",method=~"GET"}[5m]))`))
+		Expect(t.slo.GetSLO().GetGoodEvents()).To(HaveLen(1))
+	})
+
+	It("should drop samples outside of the SLO period", func() {
+		s := hourly(30, 1000)
+		Expect(s.Samples).To(HaveLen(24))
+		Expect(s.Samples[0].Time).To(Equal(now.Add(-23 * time.Hour).Unix()))
+	})
+
+	It("should prorate samples which partially overlap a window", func() {
+		s := &sloState{}
+		// the first sample after the monitor starts covers the whole period
+		s.add(eventSample{
+			Time:   now.Add(-30 * time.Minute).Unix(),
+			Window: int64(period / time.Second),
+			Good:   24000 - 240,
+			Total:  24000,
+		}, now.Add(-period))
+		s.add(eventSample{
+			Time:   now.Unix(),
+			Window: int64(30 * time.Minute / time.Second),
+			Good:   500,
+			Total:  500,
+		}, now.Add(-period))
+
+		good, total := s.events(now, time.Hour)
+		Expect(total).To(BeNumerically("~", 1000, 1e-9))
+		Expect(good).To(BeNumerically("~", 995, 1e-9))
+		good, total = s.events(now, period)
+		Expect(total).To(BeNumerically("~", 24000-500+500, 1e-9))
+		Expect(good).To(BeNumerically("~", 24000-500+500-235, 1e-9))
+
+		summary := s.summarize(now, period, 0.99)
+		Expect(summary.BurnRates["1h"]).To(BeNumerically("~", 0.5, 1e-9))
+		Expect(summary.Status).To(Equal(slo.SLOStatusState_Ok))
+	})
+
+	It("should report no data when there are no events", func() {
+		summary := (&sloState{}).summarize(now, period, 0.99)
+		Expect(summary.Status).To(Equal(slo.SLOStatusState_NoData))
+		Expect(math.IsNaN(summary.SLI)).To(BeTrue())
+		Expect(summary.BudgetRemaining).To(Equal(1.0))
+	})
+
+	It("should compute the SLI, error budget and burn rates", func() {
+		s := hourly(24, 995)
+		summary := s.summarize(now, period, 0.99)
+		Expect(summary.Status).To(Equal(slo.SLOStatusState_Ok))
+		Expect(summary.SLI).To(BeNumerically("~", 0.995, 1e-9))
+		Expect(summary.BudgetRemaining).To(BeNumerically("~", 0.5, 1e-9))
+		Expect(summary.BurnRates).To(HaveLen(3))
+		Expect(summary.BurnRates["1d"]).To(BeNumerically("~", 0.5, 1e-9))
+		Expect(summary.BurnRates["1h"]).To(BeNumerically("~", 0.5, 1e-9))
+		Expect(summary.BurnRates["6h"]).To(BeNumerically("~", 0.5, 1e-9))
+	})
+
+	It("should warn when the error budget is burning quickly", func() {
+		s := hourly(24, 1000)
+		later := now.Add(61 * time.Minute)
+		s.add(eventSample{
+			Time:   later.Unix(),
+			Window: int64(61 * time.Minute / time.Second),
+			Good:   800,
+			Total:  1000,
+		}, later.Add(-period))
+		summary := s.summarize(later, period, 0.99)
+		Expect(summary.BurnRates["1h"]).To(BeNumerically("~", 20, 1e-9))
+		Expect(summary.BudgetRemaining).To(BeNumerically(">", 0))
+		Expect(summary.Status).To(Equal(slo.SLOStatusState_Warning))
+	})
+
+	It("should report a breach when the error budget is exhausted", func() {
+		s := hourly(24, 980)
+		summary := s.summarize(now, period, 0.99)
+		Expect(summary.BudgetRemaining).To(BeNumerically("~", -1, 1e-9))
+		Expect(summary.Status).To(Equal(slo.SLOStatusState_Breaching))
+	})
+
+	It("should report the status of SLOs of other datasources from the SLO plugin", func() {
+		client := &fakeSLOClient{status: make(chan *slo.SLOStatus)}
+		t := &monitor{
+			slo: &slo.SLOData{
+				Id: "slo-1",
+				SLO: &slo.ServiceLevelObjective{
+					Datasource:        shared.LoggingDatasource,
+					Target:            &slo.Target{Value: 99},
+					BudgetingInterval: durationpb.New(10 * time.Millisecond),
+				},
+			},
+			sloClient: client,
+			logger:    zap.NewNop().Sugar(),
+		}
+		t.InitMetrics()
+		ctx, ca := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			t.Run(ctx, nil)
+		}()
+		DeferCleanup(func() {
+			ca()
+			<-done
+		})
+
+		client.status <- &slo.SLOStatus{State: slo.SLOStatusState_Warning}
+		Eventually(func() float64 {
+			return testutil.ToFloat64(t.sloStatus.WithLabelValues("Warning"))
+		}).Should(Equal(1.0))
+		Expect(testutil.ToFloat64(t.sloStatus.WithLabelValues("Ok"))).To(Equal(0.0))
+		Expect(math.IsNaN(testutil.ToFloat64(t.sli))).To(BeTrue())
+		Expect(testutil.ToFloat64(t.objective)).To(Equal(0.99))
+
+		By("reporting an internal error if the status is unavailable")
+		client.status <- nil
+		Eventually(func() float64 {
+			return testutil.ToFloat64(t.sloStatus.WithLabelValues("InternalError"))
+		}).Should(Equal(1.0))
+		Expect(testutil.ToFloat64(t.sloStatus.WithLabelValues("Warning"))).To(Equal(0.0))
+	})
+
+	It("should persist state", func() {
+		dir := GinkgoT().TempDir()
+		mc := &modules.ModuleContext{StateDir: dir}
+		state := mc.State("slo-1")
+
+		loaded := &sloState{}
+		Expect(json.NewDecoder(state).Decode(loaded)).To(MatchError(io.EOF))
+
+		s := hourly(2, 999)
+		Expect(json.NewEncoder(state).Encode(s)).To(Succeed())
+		Expect(json.NewDecoder(mc.State("slo-1")).Decode(loaded)).To(Succeed())
+		Expect(loaded).To(Equal(s))
+
+		By("overwriting the state")
+		s.Samples = s.Samples[1:]
+		Expect(json.NewEncoder(state).Encode(s)).To(Succeed())
+		loaded = &sloState{}
+		Expect(json.NewDecoder(state).Decode(loaded)).To(Succeed())
+		Expect(loaded.Samples).To(HaveLen(1))
+		loaded = &sloState{}
+		Expect(json.NewDecoder(mc.State("slo-1")).Decode(loaded)).To(Succeed())
+		Expect(loaded.Samples).To(HaveLen(1))
+
+		By("deleting the state")
+		Expect(mc.DeleteState("slo-1")).To(Succeed())
+		Expect(json.NewDecoder(mc.State("slo-1")).Decode(&sloState{})).To(MatchError(io.EOF))
+		Expect(mc.DeleteState("slo-1")).To(Succeed())
+	})
+})
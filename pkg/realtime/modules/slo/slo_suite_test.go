@@ -0,0 +1,13 @@
+package slo
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestSlo(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Realtime SLO Module Suite")
+}
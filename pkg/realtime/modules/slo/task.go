@@ -7,6 +7,7 @@ import (
 	"github.com/kralicky/gpkg/sync"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rancher/opni/plugins/slo/pkg/apis/slo"
+	"go.uber.org/zap"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -18,6 +19,7 @@ type task interface {
 type runningTask struct {
 	task   task
 	cancel context.CancelFunc
+	done   chan struct{}
 }
 
 type newTaskFunc func(*slo.SLOData) task
@@ -26,30 +28,36 @@ func (m *module) manageTasks(ctx context.Context, newTask newTaskFunc) {
 	tasks := sync.Map[string, *runningTask]{}
 
 	start := func(slo *slo.SLOData) {
-		_, tca := context.WithCancel(ctx)
+		tctx, tca := context.WithCancel(ctx)
 		running := &runningTask{
 			task:   newTask(slo),
 			cancel: tca,
+			done:   make(chan struct{}),
 		}
 		tasks.Store(slo.GetId(), running)
 		metrics := running.task.InitMetrics()
 		go func() {
+			defer close(running.done)
 			for _, metric := range metrics {
-				m.mc.Reg.Register(metric)
+				if err := m.mc.Reg.Register(metric); err != nil {
+					m.mc.Log.With(
+						"id", slo.GetId(),
+						zap.Error(err),
+					).Warn("failed to register SLO metrics")
+				}
 			}
-			// running.task.Run(tctx, &stateReadWriter{
-			// 	ctx:       tctx,
-			// 	id:        slo.GetId(),
-			// 	sloClient: m.sloClient,
-			// })
+			running.task.Run(tctx, m.mc.State(slo.GetId()))
 			for _, metric := range metrics {
 				m.mc.Reg.Unregister(metric)
 			}
 		}()
 	}
+	// stop waits for the task to exit, so that its metrics are unregistered
+	// before a replacement task registers its own.
 	stop := func(slo *slo.SLOData) {
 		if value, ok := tasks.LoadAndDelete(slo.GetId()); ok {
 			value.cancel()
+			<-value.done
 		}
 	}
 
@@ -64,6 +72,12 @@ func (m *module) manageTasks(ctx context.Context, newTask newTaskFunc) {
 				start(clone)
 			case sloRemoved:
 				stop(clone)
+				if err := m.mc.DeleteState(clone.GetId()); err != nil {
+					m.mc.Log.With(
+						"id", clone.GetId(),
+						zap.Error(err),
+					).Warn("failed to delete SLO state")
+				}
 			case sloUpdated:
 				stop(clone)
 				start(clone)
@@ -0,0 +1,91 @@
+package modules
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// State returns a store for state identified by key which should persist
+// across restarts of the realtime server. Each call to Write replaces the
+// stored state with the contents of the written buffer. Reads return the
+// most recently stored state followed by io.EOF, after which the next read
+// starts over from the beginning.
+//
+// If the module has no state directory, the state is kept in memory only.
+func (mc *ModuleContext) State(key string) io.ReadWriter {
+	s := &stateReadWriter{}
+	if mc.StateDir != "" {
+		s.path = filepath.Join(mc.StateDir, url.PathEscape(key)+".json")
+	}
+	return s
+}
+
+// DeleteState removes the state identified by key, if it exists.
+func (mc *ModuleContext) DeleteState(key string) error {
+	if mc.StateDir == "" {
+		return nil
+	}
+	err := os.Remove(filepath.Join(mc.StateDir, url.PathEscape(key)+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+type stateReadWriter struct {
+	mu     sync.Mutex
+	path   string
+	data   []byte
+	loaded bool
+	reader *bytes.Reader
+}
+
+func (s *stateReadWriter) Read(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.loaded && s.path != "" {
+		data, err := os.ReadFile(s.path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return 0, err
+		}
+		s.data = data
+	}
+	s.loaded = true
+	if s.reader == nil {
+		s.reader = bytes.NewReader(s.data)
+	}
+	n, err := s.reader.Read(p)
+	if err == io.EOF {
+		s.reader = nil
+	}
+	return n, err
+}
+
+func (s *stateReadWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data = append([]byte(nil), p...)
+	s.loaded = true
+	s.reader = nil
+	if s.path == "" {
+		return len(p), nil
+	}
+	// write to a temporary file first, so that the previous state is kept
+	// if the write fails partway through
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return 0, err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, p, 0o600); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -3,6 +3,7 @@ package realtime
 import (
 	"context"
 	"net/http"
+	"path/filepath"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -94,11 +95,16 @@ func (rt *RealtimeServer) Start(ctx context.Context) error {
 		lg.Info("Starting RT module")
 
 		mod := mod
+		var stateDir string
+		if rt.config.StateDir != "" {
+			stateDir = filepath.Join(rt.config.StateDir, mod.Name())
+		}
 		go func() {
 			if err := mod.Run(ctx, &modules.ModuleContext{
-				Log:    rt.logger.Named(mod.Name()),
-				Client: rt.mgmtClient,
-				Reg:    reg,
+				Log:      rt.logger.Named(mod.Name()),
+				Client:   rt.mgmtClient,
+				Reg:      reg,
+				StateDir: stateDir,
 			}); err != nil {
 				lg.With(
 					zap.Error(err),
@@ -35,8 +35,15 @@ func (r *Reconciler) configMap() (resources.Resource, error) {
 						Dir: "/var/lib/opni/plugin-cache",
 					},
 				},
+				Signatures: cfgv1beta1.SignatureSpec{
+					Mode: r.gw.Spec.PluginSignatures.Mode,
+					TrustedKeyData: lo.Map(r.gw.Spec.PluginSignatures.TrustedKeys, func(key string, _ int) []byte {
+						return []byte(key)
+					}),
+				},
 			},
-			Hostname: r.gw.Spec.Hostname,
+			Hostname:   r.gw.Spec.Hostname,
+			Management: r.gw.Spec.Management,
 			Cortex: cfgv1beta1.CortexSpec{
 				Management: cfgv1beta1.ClusterManagementSpec{
 					ClusterDriver: "opni-manager",
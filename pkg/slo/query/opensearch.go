@@ -3,4 +3,14 @@ package query
 /*
 Make sure pre-configured metrics can be exported as valid opensearch / monitors
 to the SLO api.
+
+Log based SLOs count the log documents of a service, the queries themselves are
+built by the logging plugin.
 */
+
+const (
+	// LogMetricId is the only "metric" exposed by the logging datasource,
+	// it refers to the stream of log documents emitted by a service
+	LogMetricId    = "log-events"
+	LogMetricGroup = "logs"
+)
@@ -41,6 +41,121 @@ func KeyValueStoreTestSuite[T storage.KeyValueStoreBroker](
 				Expect(keys[0]).To(Equal("foo"))
 			})
 		})
+		When("watching keys", func() {
+			var ctx context.Context
+			var ca context.CancelFunc
+			var wc <-chan storage.WatchEvent[storage.KeyRevision[[]byte]]
+			BeforeAll(func() {
+				ctx, ca = context.WithCancel(context.Background())
+				var err error
+				wc, err = ts.Watch(ctx, "watch/")
+				Expect(err).NotTo(HaveOccurred())
+			})
+			AfterAll(func() {
+				ca()
+			})
+			It("should receive an event when a key is created", func() {
+				Expect(ts.Put(context.Background(), "watch/a", []byte("1"))).To(Succeed())
+				var event storage.WatchEvent[storage.KeyRevision[[]byte]]
+				Eventually(wc, 10*time.Second).Should(Receive(&event))
+				Expect(event.EventType).To(Equal(storage.WatchEventCreate))
+				Expect(event.Current.Key).To(Equal("watch/a"))
+				Expect(event.Current.Value).To(Equal([]byte("1")))
+			})
+			It("should receive an event when a key is updated", func() {
+				Expect(ts.Put(context.Background(), "watch/a", []byte("2"))).To(Succeed())
+				var event storage.WatchEvent[storage.KeyRevision[[]byte]]
+				Eventually(wc, 10*time.Second).Should(Receive(&event))
+				Expect(event.EventType).To(Equal(storage.WatchEventUpdate))
+				Expect(event.Current.Key).To(Equal("watch/a"))
+				Expect(event.Current.Value).To(Equal([]byte("2")))
+				Expect(event.Previous.Key).To(Equal("watch/a"))
+				Expect(event.Previous.Value).To(Equal([]byte("1")))
+			})
+			It("should not receive events for keys outside the prefix", func() {
+				Expect(ts.Put(context.Background(), "other", []byte("1"))).To(Succeed())
+				Consistently(wc, 1*time.Second).ShouldNot(Receive())
+				Expect(ts.Delete(context.Background(), "other")).To(Succeed())
+				Consistently(wc, 1*time.Second).ShouldNot(Receive())
+			})
+			It("should not receive events for keys sharing the prefix without its separator", func() {
+				Expect(ts.Put(context.Background(), "watch2", []byte("1"))).To(Succeed())
+				Consistently(wc, 1*time.Second).ShouldNot(Receive())
+
+				keys, err := ts.ListKeys(context.Background(), "watch/")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(keys).To(ConsistOf("watch/a"))
+
+				Expect(ts.Delete(context.Background(), "watch2")).To(Succeed())
+				Consistently(wc, 1*time.Second).ShouldNot(Receive())
+			})
+			It("should receive an event when a key is deleted", func() {
+				Expect(ts.Delete(context.Background(), "watch/a")).To(Succeed())
+				var event storage.WatchEvent[storage.KeyRevision[[]byte]]
+				Eventually(wc, 10*time.Second).Should(Receive(&event))
+				Expect(event.EventType).To(Equal(storage.WatchEventDelete))
+				Expect(event.Previous.Key).To(Equal("watch/a"))
+				Expect(event.Previous.Value).To(Equal([]byte("2")))
+			})
+			It("should close the channel when the context is canceled", func() {
+				ca()
+				Eventually(wc, 10*time.Second).Should(BeClosed())
+			})
+		})
+		When("using revisions", func() {
+			var revision int64
+			It("should only create a key with revision 0 if it does not exist", func() {
+				Expect(ts.Put(context.Background(), "cas", []byte("1"),
+					storage.WithRevision(0),
+					storage.WithRevisionOut(&revision),
+				)).To(Succeed())
+				Expect(revision).To(BeNumerically(">", 0))
+
+				err := ts.Put(context.Background(), "cas", []byte("2"), storage.WithRevision(0))
+				Expect(err).To(MatchError(storage.ErrConflict))
+			})
+			It("should return the revision of a key", func() {
+				var getRevision int64
+				value, err := ts.Get(context.Background(), "cas", storage.WithRevisionOut(&getRevision))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(value).To(Equal([]byte("1")))
+				Expect(getRevision).To(Equal(revision))
+			})
+			It("should update a key if the revision matches", func() {
+				var newRevision int64
+				Expect(ts.Put(context.Background(), "cas", []byte("2"),
+					storage.WithRevision(revision),
+					storage.WithRevisionOut(&newRevision),
+				)).To(Succeed())
+				Expect(newRevision).To(BeNumerically(">", revision))
+
+				var getRevision int64
+				value, err := ts.Get(context.Background(), "cas", storage.WithRevisionOut(&getRevision))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(value).To(Equal([]byte("2")))
+				Expect(getRevision).To(Equal(newRevision))
+			})
+			It("should not update a key if the revision does not match", func() {
+				err := ts.Put(context.Background(), "cas", []byte("3"), storage.WithRevision(revision))
+				Expect(err).To(MatchError(storage.ErrConflict))
+
+				value, err := ts.Get(context.Background(), "cas")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(value).To(Equal([]byte("2")))
+			})
+			It("should not update a deleted key", func() {
+				var getRevision int64
+				_, err := ts.Get(context.Background(), "cas", storage.WithRevisionOut(&getRevision))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ts.Delete(context.Background(), "cas")).To(Succeed())
+
+				err = ts.Put(context.Background(), "cas", []byte("3"), storage.WithRevision(getRevision))
+				Expect(err).To(MatchError(storage.ErrConflict))
+
+				Expect(ts.Put(context.Background(), "cas", []byte("3"), storage.WithRevision(0))).To(Succeed())
+				Expect(ts.Delete(context.Background(), "cas")).To(Succeed())
+			})
+		})
 		It("should delete keys", func() {
 			all, err := ts.ListKeys(context.Background(), "")
 			Expect(err).NotTo(HaveOccurred())
@@ -22,11 +22,15 @@ func (c *CRDStore) CreateToken(ctx context.Context, ttl time.Duration, opts ...s
 
 	token := tokens.NewToken().ToBootstrapToken()
 	token.Metadata = &corev1.BootstrapTokenMetadata{
-		LeaseID:      -1,
-		Ttl:          int64(ttl.Seconds()),
-		UsageCount:   0,
-		Labels:       options.Labels,
-		Capabilities: options.Capabilities,
+		LeaseID:           -1,
+		Ttl:               int64(ttl.Seconds()),
+		UsageCount:        0,
+		Labels:            options.Labels,
+		Capabilities:      options.Capabilities,
+		MaxUsages:         options.MaxUsages,
+		OneShot:           options.OneShot,
+		AllowedClusterIds: options.AllowedClusterIds,
+		AllowedLabels:     options.AllowedLabels,
 	}
 	err := c.client.Create(ctx, &corev1beta1.BootstrapToken{
 		ObjectMeta: metav1.ObjectMeta{
@@ -8,6 +8,10 @@ import (
 var ErrNotFound = &NotFoundError{}
 var ErrAlreadyExists = &AlreadyExistsError{}
 
+// ErrConflict is returned when a write specifies a revision which does not
+// match the current revision of the object.
+var ErrConflict = &ConflictError{}
+
 type NotFoundError struct{}
 
 func (e *NotFoundError) Error() string {
@@ -27,3 +31,13 @@ func (e *AlreadyExistsError) Error() string {
 func (e *AlreadyExistsError) GRPCStatus() *status.Status {
 	return status.New(codes.AlreadyExists, e.Error())
 }
+
+type ConflictError struct{}
+
+func (e *ConflictError) Error() string {
+	return "conflict"
+}
+
+func (e *ConflictError) GRPCStatus() *status.Status {
+	return status.New(codes.Aborted, e.Error())
+}
@@ -9,6 +9,7 @@ import (
 	"path"
 	"strings"
 
+	"go.etcd.io/etcd/api/v3/mvccpb"
 	clientv3 "go.etcd.io/etcd/client/v3"
 
 	"github.com/rancher/opni/pkg/storage"
@@ -20,18 +21,46 @@ type genericKeyValueStore struct {
 	prefix string
 }
 
-func (s *genericKeyValueStore) Put(ctx context.Context, key string, value []byte) error {
+func (s *genericKeyValueStore) Put(ctx context.Context, key string, value []byte, opts ...storage.PutOpt) error {
+	options := storage.PutOptions{}
+	options.Apply(opts...)
+
 	if err := validateKey(key); err != nil {
 		return err
 	}
-	_, err := s.client.Put(ctx, path.Join(s.prefix, key), base64.StdEncoding.EncodeToString(value))
-	if err != nil {
-		return err
+	qualifiedKey := path.Join(s.prefix, key)
+	putOp := clientv3.OpPut(qualifiedKey, base64.StdEncoding.EncodeToString(value))
+	var revision int64
+	if options.Revision != nil {
+		// a mod revision of 0 compares equal to a key that does not exist
+		resp, err := s.client.Txn(ctx).
+			If(clientv3.Compare(clientv3.ModRevision(qualifiedKey), "=", *options.Revision)).
+			Then(putOp).
+			Commit()
+		if err != nil {
+			return err
+		}
+		if !resp.Succeeded {
+			return storage.ErrConflict
+		}
+		revision = resp.Header.Revision
+	} else {
+		resp, err := s.client.Do(ctx, putOp)
+		if err != nil {
+			return err
+		}
+		revision = resp.Put().Header.Revision
+	}
+	if options.RevisionOut != nil {
+		*options.RevisionOut = revision
 	}
 	return nil
 }
 
-func (s *genericKeyValueStore) Get(ctx context.Context, key string) ([]byte, error) {
+func (s *genericKeyValueStore) Get(ctx context.Context, key string, opts ...storage.GetOpt) ([]byte, error) {
+	options := storage.GetOptions{}
+	options.Apply(opts...)
+
 	if err := validateKey(key); err != nil {
 		return nil, err
 	}
@@ -42,6 +71,9 @@ func (s *genericKeyValueStore) Get(ctx context.Context, key string) ([]byte, err
 	if len(resp.Kvs) == 0 {
 		return nil, storage.ErrNotFound
 	}
+	if options.RevisionOut != nil {
+		*options.RevisionOut = resp.Kvs[0].ModRevision
+	}
 	return base64.StdEncoding.DecodeString(string(resp.Kvs[0].Value))
 }
 
@@ -60,7 +92,7 @@ func (s *genericKeyValueStore) Delete(ctx context.Context, key string) error {
 }
 
 func (s *genericKeyValueStore) ListKeys(ctx context.Context, prefix string) ([]string, error) {
-	resp, err := s.client.Get(ctx, path.Join(s.prefix, prefix),
+	resp, err := s.client.Get(ctx, s.qualifiedPrefix(prefix),
 		clientv3.WithPrefix(),
 		clientv3.WithKeysOnly(),
 	)
@@ -74,6 +106,73 @@ func (s *genericKeyValueStore) ListKeys(ctx context.Context, prefix string) ([]s
 	return keys, nil
 }
 
+func (s *genericKeyValueStore) Watch(ctx context.Context, prefix string) (<-chan storage.WatchEvent[storage.KeyRevision[[]byte]], error) {
+	wc := s.client.Watch(ctx, s.qualifiedPrefix(prefix),
+		clientv3.WithPrefix(),
+		clientv3.WithPrevKV(),
+	)
+	eventC := make(chan storage.WatchEvent[storage.KeyRevision[[]byte]], 64)
+	go func() {
+		defer close(eventC)
+		for resp := range wc {
+			if resp.Err() != nil {
+				return
+			}
+			for _, ev := range resp.Events {
+				event := storage.WatchEvent[storage.KeyRevision[[]byte]]{}
+				switch {
+				case ev.Type == mvccpb.DELETE:
+					event.EventType = storage.WatchEventDelete
+				case ev.IsCreate():
+					event.EventType = storage.WatchEventCreate
+				default:
+					event.EventType = storage.WatchEventUpdate
+				}
+				key := strings.TrimPrefix(string(ev.Kv.Key), s.prefix+"/")
+				if ev.Type == mvccpb.PUT {
+					value, err := base64.StdEncoding.DecodeString(string(ev.Kv.Value))
+					if err != nil {
+						continue
+					}
+					event.Current = storage.KeyRevision[[]byte]{
+						Key:      key,
+						Value:    value,
+						Revision: ev.Kv.ModRevision,
+					}
+				}
+				if ev.PrevKv != nil {
+					value, err := base64.StdEncoding.DecodeString(string(ev.PrevKv.Value))
+					if err != nil {
+						continue
+					}
+					event.Previous = storage.KeyRevision[[]byte]{
+						Key:      key,
+						Value:    value,
+						Revision: ev.PrevKv.ModRevision,
+					}
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case eventC <- event:
+				}
+			}
+		}
+	}()
+	return eventC, nil
+}
+
+// qualifiedPrefix joins the store's prefix with a key prefix. Unlike
+// path.Join, it keeps a trailing slash, so that a prefix like "foo/" only
+// matches keys under "foo/" and not keys such as "foo2".
+func (s *genericKeyValueStore) qualifiedPrefix(prefix string) string {
+	qualified := path.Join(s.prefix, prefix)
+	if strings.HasSuffix(prefix, "/") && !strings.HasSuffix(qualified, "/") {
+		qualified += "/"
+	}
+	return qualified
+}
+
 func validateKey(key string) error {
 	// etcd will check keys, but we need to check if the key is empty ourselves
 	// since we always prepend a prefix to the key
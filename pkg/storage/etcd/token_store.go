@@ -28,10 +28,14 @@ func (e *EtcdStore) CreateToken(ctx context.Context, ttl time.Duration, opts ...
 	}
 	token := tokens.NewToken().ToBootstrapToken()
 	token.Metadata = &corev1.BootstrapTokenMetadata{
-		LeaseID:      int64(lease.ID),
-		UsageCount:   0,
-		Labels:       options.Labels,
-		Capabilities: options.Capabilities,
+		LeaseID:           int64(lease.ID),
+		UsageCount:        0,
+		Labels:            options.Labels,
+		Capabilities:      options.Capabilities,
+		MaxUsages:         options.MaxUsages,
+		OneShot:           options.OneShot,
+		AllowedClusterIds: options.AllowedClusterIds,
+		AllowedLabels:     options.AllowedLabels,
 	}
 	data, err := protojson.Marshal(token)
 	if err != nil {
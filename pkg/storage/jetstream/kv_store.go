@@ -14,12 +14,38 @@ type jetstreamKeyValueStore struct {
 	kv nats.KeyValue
 }
 
-func (j jetstreamKeyValueStore) Put(ctx context.Context, key string, value []byte) error {
-	_, err := j.kv.Put(key, value)
-	return err
+func (j jetstreamKeyValueStore) Put(ctx context.Context, key string, value []byte, opts ...storage.PutOpt) error {
+	options := storage.PutOptions{}
+	options.Apply(opts...)
+
+	var revision uint64
+	var err error
+	switch {
+	case options.Revision == nil:
+		revision, err = j.kv.Put(key, value)
+	case *options.Revision == 0:
+		revision, err = j.kv.Create(key, value)
+	default:
+		revision, err = j.kv.Update(key, value, uint64(*options.Revision))
+	}
+	if err != nil {
+		// both Create and Update fail with this error if the last revision
+		// of the key does not match
+		if errors.Is(err, nats.ErrKeyExists) {
+			return storage.ErrConflict
+		}
+		return err
+	}
+	if options.RevisionOut != nil {
+		*options.RevisionOut = int64(revision)
+	}
+	return nil
 }
 
-func (j jetstreamKeyValueStore) Get(ctx context.Context, key string) ([]byte, error) {
+func (j jetstreamKeyValueStore) Get(ctx context.Context, key string, opts ...storage.GetOpt) ([]byte, error) {
+	options := storage.GetOptions{}
+	options.Apply(opts...)
+
 	resp, err := j.kv.Get(key)
 	if err != nil {
 		if errors.Is(err, nats.ErrKeyNotFound) {
@@ -27,6 +53,9 @@ func (j jetstreamKeyValueStore) Get(ctx context.Context, key string) ([]byte, er
 		}
 		return nil, err
 	}
+	if options.RevisionOut != nil {
+		*options.RevisionOut = int64(resp.Revision())
+	}
 	return resp.Value(), nil
 }
 
@@ -52,3 +81,89 @@ func (j jetstreamKeyValueStore) ListKeys(ctx context.Context, prefix string) ([]
 		return strings.HasPrefix(key, prefix)
 	}), nil
 }
+
+func (j jetstreamKeyValueStore) Watch(ctx context.Context, prefix string) (<-chan storage.WatchEvent[storage.KeyRevision[[]byte]], error) {
+	w, err := j.kv.WatchAll(nats.Context(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	// consume the initial values, which are only used to fill in the previous
+	// values of later events
+	latest := map[string]storage.KeyRevision[[]byte]{}
+INITIAL:
+	for {
+		select {
+		case <-ctx.Done():
+			w.Stop()
+			return nil, ctx.Err()
+		case entry, ok := <-w.Updates():
+			if !ok {
+				return nil, errors.New("watcher closed unexpectedly")
+			}
+			if entry == nil {
+				break INITIAL
+			}
+			if entry.Operation() == nats.KeyValuePut && strings.HasPrefix(entry.Key(), prefix) {
+				latest[entry.Key()] = toKeyRevision(entry)
+			}
+		}
+	}
+
+	eventC := make(chan storage.WatchEvent[storage.KeyRevision[[]byte]], 64)
+	go func() {
+		defer close(eventC)
+		defer w.Stop()
+		for {
+			var entry nats.KeyValueEntry
+			select {
+			case <-ctx.Done():
+				return
+			case e, ok := <-w.Updates():
+				if !ok {
+					return
+				}
+				entry = e
+			}
+			if entry == nil || !strings.HasPrefix(entry.Key(), prefix) {
+				continue
+			}
+			event := storage.WatchEvent[storage.KeyRevision[[]byte]]{}
+			previous, known := latest[entry.Key()]
+			switch entry.Operation() {
+			case nats.KeyValuePut:
+				if known {
+					event.EventType = storage.WatchEventUpdate
+					event.Previous = previous
+				} else {
+					event.EventType = storage.WatchEventCreate
+				}
+				event.Current = toKeyRevision(entry)
+				latest[entry.Key()] = event.Current
+			case nats.KeyValueDelete, nats.KeyValuePurge:
+				if !known {
+					continue
+				}
+				event.EventType = storage.WatchEventDelete
+				event.Previous = previous
+				delete(latest, entry.Key())
+			default:
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case eventC <- event:
+			}
+		}
+	}()
+	return eventC, nil
+}
+
+func toKeyRevision(entry nats.KeyValueEntry) storage.KeyRevision[[]byte] {
+	return storage.KeyRevision[[]byte]{
+		Key:      entry.Key(),
+		Value:    entry.Value(),
+		Revision: int64(entry.Revision()),
+	}
+}
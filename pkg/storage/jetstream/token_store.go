@@ -21,11 +21,15 @@ func (s *JetStreamStore) CreateToken(ctx context.Context, ttl time.Duration, opt
 
 	token := tokens.NewToken().ToBootstrapToken()
 	token.Metadata = &corev1.BootstrapTokenMetadata{
-		LeaseID:      -1,
-		Ttl:          int64(ttl.Seconds()),
-		UsageCount:   0,
-		Labels:       options.Labels,
-		Capabilities: options.Capabilities,
+		LeaseID:           -1,
+		Ttl:               int64(ttl.Seconds()),
+		UsageCount:        0,
+		Labels:            options.Labels,
+		Capabilities:      options.Capabilities,
+		MaxUsages:         options.MaxUsages,
+		OneShot:           options.OneShot,
+		AllowedClusterIds: options.AllowedClusterIds,
+		AllowedLabels:     options.AllowedLabels,
 	}
 	data, err := protojson.Marshal(token)
 	if err != nil {
@@ -2,7 +2,10 @@ package storage
 
 import (
 	"context"
+	"strings"
 	"sync"
+
+	"google.golang.org/protobuf/proto"
 )
 
 type KeyValueStoreLocker[T any] interface {
@@ -49,12 +52,12 @@ type kvStorePrefixImpl[T any] struct {
 	prefix string
 }
 
-func (s *kvStorePrefixImpl[T]) Put(ctx context.Context, key string, value T) error {
-	return s.base.Put(ctx, s.prefix+key, value)
+func (s *kvStorePrefixImpl[T]) Put(ctx context.Context, key string, value T, opts ...PutOpt) error {
+	return s.base.Put(ctx, s.prefix+key, value, opts...)
 }
 
-func (s *kvStorePrefixImpl[T]) Get(ctx context.Context, key string) (T, error) {
-	return s.base.Get(ctx, s.prefix+key)
+func (s *kvStorePrefixImpl[T]) Get(ctx context.Context, key string, opts ...GetOpt) (T, error) {
+	return s.base.Get(ctx, s.prefix+key, opts...)
 }
 
 func (s *kvStorePrefixImpl[T]) Delete(ctx context.Context, key string) error {
@@ -65,6 +68,27 @@ func (s *kvStorePrefixImpl[T]) ListKeys(ctx context.Context, prefix string) ([]s
 	return s.base.ListKeys(ctx, s.prefix+prefix)
 }
 
+func (s *kvStorePrefixImpl[T]) Watch(ctx context.Context, prefix string) (<-chan WatchEvent[KeyRevision[T]], error) {
+	wc, err := s.base.Watch(ctx, s.prefix+prefix)
+	if err != nil {
+		return nil, err
+	}
+	eventC := make(chan WatchEvent[KeyRevision[T]], cap(wc))
+	go func() {
+		defer close(eventC)
+		for event := range wc {
+			event.Current.Key = strings.TrimPrefix(event.Current.Key, s.prefix)
+			event.Previous.Key = strings.TrimPrefix(event.Previous.Key, s.prefix)
+			select {
+			case <-ctx.Done():
+				return
+			case eventC <- event:
+			}
+		}
+	}()
+	return eventC, nil
+}
+
 func NewKeyValueStoreWithPrefix[T any](base KeyValueStoreT[T], prefix string) KeyValueStoreT[T] {
 	return &kvStorePrefixImpl[T]{
 		base:   base,
@@ -101,3 +125,93 @@ func NewValueStore[T any](base KeyValueStoreT[T], key string) ValueStoreT[T] {
 		key:  key,
 	}
 }
+
+type protoKeyValueStoreImpl[T proto.Message] struct {
+	base KeyValueStore
+}
+
+func (s *protoKeyValueStoreImpl[T]) Put(ctx context.Context, key string, value T, opts ...PutOpt) error {
+	data, err := proto.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return s.base.Put(ctx, key, data, opts...)
+}
+
+func (s *protoKeyValueStoreImpl[T]) Get(ctx context.Context, key string, opts ...GetOpt) (T, error) {
+	data, err := s.base.Get(ctx, key, opts...)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return unmarshalProto[T](data)
+}
+
+func (s *protoKeyValueStoreImpl[T]) Delete(ctx context.Context, key string) error {
+	return s.base.Delete(ctx, key)
+}
+
+func (s *protoKeyValueStoreImpl[T]) ListKeys(ctx context.Context, prefix string) ([]string, error) {
+	return s.base.ListKeys(ctx, prefix)
+}
+
+func (s *protoKeyValueStoreImpl[T]) Watch(ctx context.Context, prefix string) (<-chan WatchEvent[KeyRevision[T]], error) {
+	wc, err := s.base.Watch(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	eventC := make(chan WatchEvent[KeyRevision[T]], cap(wc))
+	go func() {
+		defer close(eventC)
+		for event := range wc {
+			current, err := unmarshalProto[T](event.Current.Value)
+			if err != nil {
+				continue
+			}
+			previous, err := unmarshalProto[T](event.Previous.Value)
+			if err != nil {
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case eventC <- WatchEvent[KeyRevision[T]]{
+				EventType: event.EventType,
+				Current: KeyRevision[T]{
+					Key:      event.Current.Key,
+					Value:    current,
+					Revision: event.Current.Revision,
+				},
+				Previous: KeyRevision[T]{
+					Key:      event.Previous.Key,
+					Value:    previous,
+					Revision: event.Previous.Revision,
+				},
+			}:
+			}
+		}
+	}()
+	return eventC, nil
+}
+
+// unmarshalProto decodes a value of type T. A nil value (e.g. the previous
+// value of a newly created key) decodes to the zero value of T.
+func unmarshalProto[T proto.Message](data []byte) (T, error) {
+	var t T
+	if data == nil {
+		return t, nil
+	}
+	t = t.ProtoReflect().Type().New().Interface().(T)
+	if err := proto.Unmarshal(data, t); err != nil {
+		return t, err
+	}
+	return t, nil
+}
+
+// NewProtoKeyValueStore returns a store which encodes values of type T in
+// the given key-value store using their protobuf wire format.
+func NewProtoKeyValueStore[T proto.Message](base KeyValueStore) KeyValueStoreT[T] {
+	return &protoKeyValueStoreImpl[T]{
+		base: base,
+	}
+}
@@ -3,8 +3,12 @@ package storage
 import corev1 "github.com/rancher/opni/pkg/apis/core/v1"
 
 type TokenCreateOptions struct {
-	Labels       map[string]string
-	Capabilities []*corev1.TokenCapability
+	Labels            map[string]string
+	Capabilities      []*corev1.TokenCapability
+	MaxUsages         int64
+	OneShot           bool
+	AllowedClusterIds []string
+	AllowedLabels     []string
 }
 
 func NewTokenCreateOptions() TokenCreateOptions {
@@ -34,7 +38,97 @@ func WithCapabilities(capabilities []*corev1.TokenCapability) TokenCreateOption
 	}
 }
 
+func WithMaxUsages(maxUsages int64) TokenCreateOption {
+	return func(o *TokenCreateOptions) {
+		o.MaxUsages = maxUsages
+	}
+}
+
+func WithOneShot(oneShot bool) TokenCreateOption {
+	return func(o *TokenCreateOptions) {
+		o.OneShot = oneShot
+	}
+}
+
+func WithAllowedClusterIds(patterns []string) TokenCreateOption {
+	return func(o *TokenCreateOptions) {
+		o.AllowedClusterIds = patterns
+	}
+}
+
+func WithAllowedLabels(patterns []string) TokenCreateOption {
+	return func(o *TokenCreateOptions) {
+		o.AllowedLabels = patterns
+	}
+}
+
 type AlertFilterOptions struct {
 	Labels map[string]string
 	Range  *corev1.TimeRange
 }
+
+type PutOptions struct {
+	// If set, the put only succeeds if the current revision of the key
+	// matches. A revision of 0 requires that the key does not exist.
+	Revision *int64
+	// If set, receives the revision of the key after the put.
+	RevisionOut *int64
+}
+
+type GetOptions struct {
+	// If set, receives the revision of the key.
+	RevisionOut *int64
+}
+
+type PutOpt interface {
+	ApplyPutOption(*PutOptions)
+}
+
+type GetOpt interface {
+	ApplyGetOption(*GetOptions)
+}
+
+func (o *PutOptions) Apply(opts ...PutOpt) {
+	for _, op := range opts {
+		op.ApplyPutOption(o)
+	}
+}
+
+func (o *GetOptions) Apply(opts ...GetOpt) {
+	for _, op := range opts {
+		op.ApplyGetOption(o)
+	}
+}
+
+type revisionOpt int64
+
+func (r revisionOpt) ApplyPutOption(o *PutOptions) {
+	rev := int64(r)
+	o.Revision = &rev
+}
+
+// WithRevision makes a put conditional on the current revision of the key.
+// Puts whose revision does not match fail with ErrConflict.
+func WithRevision(rev int64) PutOpt {
+	return revisionOpt(rev)
+}
+
+type revisionOutOpt struct {
+	out *int64
+}
+
+func (r revisionOutOpt) ApplyPutOption(o *PutOptions) {
+	o.RevisionOut = r.out
+}
+
+func (r revisionOutOpt) ApplyGetOption(o *GetOptions) {
+	o.RevisionOut = r.out
+}
+
+// WithRevisionOut stores the revision of the key in out, after a get or put.
+func WithRevisionOut(out *int64) interface {
+	PutOpt
+	GetOpt
+} {
+	return revisionOutOpt{out: out}
+}
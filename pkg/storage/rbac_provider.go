@@ -2,13 +2,17 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 
 	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
 	"github.com/rancher/opni/pkg/logger"
 	"github.com/rancher/opni/pkg/rbac"
+	"github.com/rancher/opni/pkg/util"
 	"go.uber.org/zap"
+	"golang.org/x/exp/slices"
+	"google.golang.org/grpc/codes"
 )
 
 type rbacProvider struct {
@@ -30,39 +34,24 @@ func (p *rbacProvider) SubjectAccess(
 	// Look up all role bindings which exist for this user, then look up the roles
 	// referenced by those role bindings. Aggregate the resulting tenant IDs from
 	// the roles and filter out any duplicates.
-	rbs, err := p.store.ListRoleBindings(ctx)
+	roles, err := p.grantingRoles(ctx, req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list role bindings: %w", err)
+		return nil, err
 	}
 	allowedClusters := map[string]struct{}{}
 	// All applicable role bindings for this user are ORed together
-	for _, roleBinding := range rbs.Items {
-		appliesToUser := false
-		for _, s := range roleBinding.Subjects {
-			if s == req.Subject {
-				appliesToUser = true
+	for _, role := range roles {
+		if role.AppliesToAllClusters() {
+			all, err := p.store.ListClusters(ctx, nil, corev1.MatchOptions_Default)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list clusters: %w", err)
+			}
+			for _, cluster := range all.Items {
+				allowedClusters[cluster.Id] = struct{}{}
 			}
-		}
-		if !appliesToUser {
-			continue
-		}
-		if taints := roleBinding.Taints; len(taints) > 0 {
-			p.logger.With(
-				"roleBinding", roleBinding.Id,
-				"role", roleBinding.Id,
-				"taints", roleBinding.Taints,
-			).Warn("skipping tainted role binding")
-			continue
-		}
-		role, err := p.store.GetRole(ctx, roleBinding.RoleReference())
-		if err != nil {
-			p.logger.With(
-				zap.Error(err),
-				"roleBinding", roleBinding.Id,
-				"role", roleBinding.RoleId,
-			).Warn("error looking up role")
 			continue
 		}
+
 		// Add explicitly-allowed clusters to the list
 		for _, clusterID := range role.ClusterIDs {
 			allowedClusters[clusterID] = struct{}{}
@@ -91,3 +80,119 @@ func (p *rbacProvider) SubjectAccess(
 		Items: sortedReferences,
 	}, nil
 }
+
+func (p *rbacProvider) Authorize(
+	ctx context.Context,
+	req *corev1.SubjectAccessRequest,
+) (bool, error) {
+	roles, err := p.grantingRoles(ctx, req)
+	if err != nil {
+		return false, err
+	}
+	if len(roles) == 0 {
+		return false, nil
+	}
+	if req.GetAllClusters() {
+		for _, role := range roles {
+			if role.AppliesToAllClusters() {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	// Each cluster the request targets must be allowed by at least one role
+	for _, clusterID := range req.GetClusters() {
+		allowed, err := p.rolesAllowCluster(ctx, roles, clusterID)
+		if err != nil || !allowed {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// rolesAllowCluster reports whether any of the roles applies to the cluster,
+// either by ID or by matching its labels.
+func (p *rbacProvider) rolesAllowCluster(
+	ctx context.Context,
+	roles []*corev1.Role,
+	clusterID string,
+) (bool, error) {
+	var selectors []*corev1.LabelSelector
+	for _, role := range roles {
+		if role.AppliesToAllClusters() || slices.Contains(role.GetClusterIDs(), clusterID) {
+			return true, nil
+		}
+		if !role.GetMatchLabels().IsEmpty() {
+			selectors = append(selectors, role.GetMatchLabels())
+		}
+	}
+	if len(selectors) == 0 {
+		return false, nil
+	}
+	cluster, err := p.store.GetCluster(ctx, &corev1.Reference{Id: clusterID})
+	if err != nil {
+		if errors.Is(err, ErrNotFound) || util.StatusCode(err) == codes.NotFound {
+			// only roles naming the cluster explicitly apply to clusters which
+			// do not exist (yet)
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get cluster: %w", err)
+	}
+	for _, selector := range selectors {
+		matches := NewSelectorPredicate(&corev1.ClusterSelector{
+			LabelSelector: selector,
+			MatchOptions:  corev1.MatchOptions_EmptySelectorMatchesNone,
+		})
+		if matches(cluster) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// grantingRoles returns the roles bound to the subject which allow the
+// requested verb on the requested resource kind.
+func (p *rbacProvider) grantingRoles(
+	ctx context.Context,
+	req *corev1.SubjectAccessRequest,
+) ([]*corev1.Role, error) {
+	rbs, err := p.store.ListRoleBindings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list role bindings: %w", err)
+	}
+	verb, resource := req.RequestedVerb(), req.RequestedResource()
+	var roles []*corev1.Role
+	for _, roleBinding := range rbs.Items {
+		appliesToUser := false
+		for _, s := range roleBinding.Subjects {
+			if s == req.Subject {
+				appliesToUser = true
+			}
+		}
+		if !appliesToUser {
+			continue
+		}
+		if taints := roleBinding.Taints; len(taints) > 0 {
+			p.logger.With(
+				"roleBinding", roleBinding.Id,
+				"role", roleBinding.Id,
+				"taints", roleBinding.Taints,
+			).Warn("skipping tainted role binding")
+			continue
+		}
+		role, err := p.store.GetRole(ctx, roleBinding.RoleReference())
+		if err != nil {
+			p.logger.With(
+				zap.Error(err),
+				"roleBinding", roleBinding.Id,
+				"role", roleBinding.RoleId,
+			).Warn("error looking up role")
+			continue
+		}
+		if !role.Grants(verb, resource) {
+			continue
+		}
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
@@ -78,4 +78,50 @@ var _ = Describe("RBAC Provider", Ordered, Label("unit"), func() {
 		}
 		Expect(ids).To(Equal(expected))
 	}, entries)
+
+	DescribeTable("Authorize", func(objects rbacObjects, subject string, targets []string, allClusters bool, expected bool) {
+		rbacStore = test.NewTestRBACStore(ctrl)
+		clusterStore := test.NewTestClusterStore(ctrl)
+		for _, cluster := range clusters {
+			err := clusterStore.CreateCluster(context.Background(), cluster)
+			Expect(err).NotTo(HaveOccurred())
+		}
+		provider := storage.NewRBACProvider(struct {
+			storage.RBACStore
+			storage.ClusterStore
+		}{
+			RBACStore:    rbacStore,
+			ClusterStore: clusterStore,
+		})
+
+		for _, obj := range objects.roles {
+			err := rbacStore.CreateRole(context.Background(), obj())
+			Expect(err).NotTo(HaveOccurred())
+		}
+		for _, obj := range objects.roleBindings {
+			err := rbacStore.CreateRoleBinding(context.Background(), obj())
+			Expect(err).NotTo(HaveOccurred())
+		}
+		allowed, err := provider.Authorize(context.Background(), &corev1.SubjectAccessRequest{
+			Subject:     subject,
+			Clusters:    targets,
+			AllClusters: allClusters,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(allowed).To(Equal(expected))
+	},
+		Entry("no roles", rbacs(), "u1", []string{"c1"}, false, false),
+		Entry("no clusters", rbacs(role("r1", "c1"), rb("rb1", "r1", "u1")), "u1", nil, false, true),
+		Entry("cluster id", rbacs(role("r1", "c1"), rb("rb1", "r1", "u1")), "u1", []string{"c1"}, false, true),
+		Entry("other cluster id", rbacs(role("r1", "c1"), rb("rb1", "r1", "u1")), "u1", []string{"c2"}, false, false),
+		Entry("some clusters allowed", rbacs(role("r1", "c1"), rb("rb1", "r1", "u1")), "u1", []string{"c1", "c2"}, false, false),
+		Entry("clusters allowed by different roles", rbacs(role("r1", "c1"), role("r2", "c2"), rb("rb1", "r1", "u1"), rb("rb2", "r2", "u1")), "u1", []string{"c1", "c2"}, false, true),
+		Entry("matching selector", rbacs(role("r1", matchLabels("foo", "bar")), rb("rb1", "r1", "u1")), "u1", []string{"c2"}, false, true),
+		Entry("non-matching selector", rbacs(role("r1", matchLabels("foo", "bar")), rb("rb1", "r1", "u1")), "u1", []string{"c3"}, false, false),
+		Entry("unknown cluster with selector", rbacs(role("r1", matchLabels("foo", "bar")), rb("rb1", "r1", "u1")), "u1", []string{"c6"}, false, false),
+		Entry("unknown cluster by id", rbacs(role("r1", matchLabels("foo", "bar")), role("r2", "c6"), rb("rb1", "r1", "u1"), rb("rb2", "r2", "u1")), "u1", []string{"c6"}, false, true),
+		Entry("all clusters with cluster ids", rbacs(role("r1", "c1", "c2", "c3", "c4", "c5"), rb("rb1", "r1", "u1")), "u1", nil, true, false),
+		Entry("all clusters with selector", rbacs(role("r1", matchExprs("foo Exists")), rb("rb1", "r1", "u1")), "u1", nil, true, false),
+		Entry("all clusters with wildcard", rbacs(role("r1", corev1.AllClustersID), rb("rb1", "r1", "u1")), "u1", []string{"c1", "c6"}, true, true),
+	)
 })
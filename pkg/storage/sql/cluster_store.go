@@ -0,0 +1,305 @@
+package sql
+
+import (
+	"context"
+	dbsql "database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	"github.com/rancher/opni/pkg/storage"
+)
+
+func (s *SQLStore) CreateCluster(ctx context.Context, cluster *corev1.Cluster) error {
+	cluster.SetResourceVersion("")
+	cluster.SetCreationTimestamp(time.Now().Truncate(time.Second))
+
+	data, err := protojson.Marshal(cluster)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cluster: %w", err)
+	}
+	var rev int64
+	err = s.withTx(ctx, func(tx *dbsql.Tx) (err error) {
+		rev, err = s.nextRevision(ctx, tx)
+		if err != nil {
+			return err
+		}
+		err = checkRowsAffected(tx.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s (id, data, revision) VALUES ($1, $2, $3) ON CONFLICT (id) DO NOTHING`, s.table("clusters")),
+			cluster.Id, string(data), rev))
+		if errors.Is(err, storage.ErrNotFound) {
+			return storage.ErrAlreadyExists
+		}
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrAlreadyExists) {
+			return err
+		}
+		return fmt.Errorf("failed to create cluster: %w", err)
+	}
+	cluster.SetResourceVersion(fmt.Sprint(rev))
+	return nil
+}
+
+func (s *SQLStore) DeleteCluster(ctx context.Context, ref *corev1.Reference) error {
+	err := checkRowsAffected(s.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, s.table("clusters")), ref.Id))
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("failed to delete cluster: %w", err)
+	}
+	return err
+}
+
+func (s *SQLStore) GetCluster(ctx context.Context, ref *corev1.Reference) (*corev1.Cluster, error) {
+	row := s.db.QueryRowContext(ctx, fmt.Sprintf(`SELECT data, revision FROM %s WHERE id = $1`, s.table("clusters")), ref.Id)
+	cluster, err := scanCluster(row)
+	if err != nil {
+		if errors.Is(err, dbsql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get cluster: %w", err)
+	}
+	return cluster, nil
+}
+
+func (s *SQLStore) UpdateCluster(ctx context.Context, ref *corev1.Reference, mutator storage.ClusterMutator) (*corev1.Cluster, error) {
+	var cluster *corev1.Cluster
+	err := retryOnConflict(ctx, func() error {
+		var err error
+		cluster, err = s.GetCluster(ctx, ref)
+		if err != nil {
+			return err
+		}
+		prevRevision, err := strconv.ParseInt(cluster.GetResourceVersion(), 10, 64)
+		if err != nil {
+			return fmt.Errorf("internal error: cluster has invalid resource version: %w", err)
+		}
+		mutator(cluster)
+		cluster.SetResourceVersion("")
+		data, err := protojson.Marshal(cluster)
+		if err != nil {
+			return fmt.Errorf("failed to marshal cluster: %w", err)
+		}
+		return s.withTx(ctx, func(tx *dbsql.Tx) error {
+			rev, err := s.nextRevision(ctx, tx)
+			if err != nil {
+				return err
+			}
+			err = checkRowsAffected(tx.ExecContext(ctx, fmt.Sprintf(`UPDATE %s SET data = $1, revision = $2 WHERE id = $3 AND revision = $4`, s.table("clusters")),
+				string(data), rev, ref.Id, prevRevision))
+			if errors.Is(err, storage.ErrNotFound) {
+				return errConflict
+			}
+			if err != nil {
+				return err
+			}
+			cluster.SetResourceVersion(fmt.Sprint(rev))
+			return nil
+		})
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to update cluster: %w", err)
+	}
+	return cluster, nil
+}
+
+func (s *SQLStore) ListClusters(ctx context.Context, matchLabels *corev1.LabelSelector, matchOptions corev1.MatchOptions) (*corev1.ClusterList, error) {
+	all, err := s.listAllClusters(ctx)
+	if err != nil {
+		return nil, err
+	}
+	selectorPredicate := storage.NewSelectorPredicate(&corev1.ClusterSelector{
+		LabelSelector: matchLabels,
+		MatchOptions:  matchOptions,
+	})
+	clusters := []*corev1.Cluster{}
+	for _, cluster := range all {
+		if selectorPredicate(cluster) {
+			clusters = append(clusters, cluster)
+		}
+	}
+	return &corev1.ClusterList{
+		Items: clusters,
+	}, nil
+}
+
+func (s *SQLStore) listAllClusters(ctx context.Context) ([]*corev1.Cluster, error) {
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`SELECT data, revision FROM %s ORDER BY id`, s.table("clusters")))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list clusters: %w", err)
+	}
+	defer rows.Close()
+	var clusters []*corev1.Cluster
+	for rows.Next() {
+		cluster, err := scanCluster(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list clusters: %w", err)
+		}
+		clusters = append(clusters, cluster)
+	}
+	return clusters, rows.Err()
+}
+
+func scanCluster(row scanner) (*corev1.Cluster, error) {
+	var data string
+	var rev int64
+	if err := row.Scan(&data, &rev); err != nil {
+		return nil, err
+	}
+	cluster := &corev1.Cluster{}
+	if err := protojson.Unmarshal([]byte(data), cluster); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal cluster: %w", err)
+	}
+	cluster.SetResourceVersion(fmt.Sprint(rev))
+	return cluster, nil
+}
+
+func (s *SQLStore) WatchCluster(ctx context.Context, cluster *corev1.Cluster) (<-chan storage.WatchEvent[*corev1.Cluster], error) {
+	eventC := make(chan storage.WatchEvent[*corev1.Cluster], 10)
+
+	go func() {
+		ticker := time.NewTicker(s.PollInterval)
+		defer ticker.Stop()
+		current := cluster
+		for {
+			var event *storage.WatchEvent[*corev1.Cluster]
+			latest, err := s.GetCluster(ctx, cluster.Reference())
+			switch {
+			case err == nil:
+				if current == nil || latest.GetResourceVersion() != current.GetResourceVersion() {
+					event = &storage.WatchEvent[*corev1.Cluster]{
+						EventType: storage.WatchEventUpdate,
+						Current:   latest,
+						Previous:  current,
+					}
+					current = latest
+				}
+			case errors.Is(err, storage.ErrNotFound):
+				if current != nil {
+					event = &storage.WatchEvent[*corev1.Cluster]{
+						EventType: storage.WatchEventDelete,
+						Previous:  current,
+					}
+					current = nil
+				}
+			default:
+				if ctx.Err() == nil {
+					s.logger.With(
+						"cluster", cluster.Id,
+						"error", err,
+					).Warn("failed to poll cluster")
+				}
+			}
+			if event != nil {
+				select {
+				case <-ctx.Done():
+					return
+				case eventC <- *event:
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return eventC, nil
+}
+
+func (s *SQLStore) WatchClusters(ctx context.Context, knownClusters []*corev1.Cluster) (<-chan storage.WatchEvent[*corev1.Cluster], error) {
+	knownClusterMap := make(map[string]*corev1.Cluster, len(knownClusters))
+	for _, cluster := range knownClusters {
+		knownClusterMap[cluster.Id] = cluster
+	}
+
+	all, err := s.listAllClusters(ctx)
+	if err != nil {
+		return nil, err
+	}
+	initialEvents := diffClusters(knownClusterMap, all)
+
+	bufSize := 100
+	for len(initialEvents) > bufSize {
+		bufSize *= 2
+	}
+	eventC := make(chan storage.WatchEvent[*corev1.Cluster], bufSize)
+	// send create or update events for unknown clusters
+	for _, event := range initialEvents {
+		eventC <- event
+	}
+
+	go func() {
+		ticker := time.NewTicker(s.PollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			all, err := s.listAllClusters(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					s.logger.With(
+						"error", err,
+					).Warn("failed to poll clusters")
+				}
+				continue
+			}
+			for _, event := range diffClusters(knownClusterMap, all) {
+				select {
+				case <-ctx.Done():
+					return
+				case eventC <- event:
+				}
+			}
+		}
+	}()
+
+	return eventC, nil
+}
+
+// diffClusters computes the events which bring the known clusters up to date
+// with the latest clusters, and updates the known clusters accordingly.
+func diffClusters(known map[string]*corev1.Cluster, latest []*corev1.Cluster) []storage.WatchEvent[*corev1.Cluster] {
+	var events []storage.WatchEvent[*corev1.Cluster]
+	seen := make(map[string]struct{}, len(latest))
+	for _, cluster := range latest {
+		seen[cluster.Id] = struct{}{}
+		prev, ok := known[cluster.Id]
+		switch {
+		case !ok:
+			events = append(events, storage.WatchEvent[*corev1.Cluster]{
+				EventType: storage.WatchEventCreate,
+				Current:   cluster,
+			})
+		case prev.GetResourceVersion() != cluster.GetResourceVersion():
+			events = append(events, storage.WatchEvent[*corev1.Cluster]{
+				EventType: storage.WatchEventUpdate,
+				Current:   cluster,
+				Previous:  prev,
+			})
+		default:
+			continue
+		}
+		known[cluster.Id] = cluster
+	}
+	for id, prev := range known {
+		if _, ok := seen[id]; !ok {
+			events = append(events, storage.WatchEvent[*corev1.Cluster]{
+				EventType: storage.WatchEventDelete,
+				Previous:  prev,
+			})
+			delete(known, id)
+		}
+	}
+	return events
+}
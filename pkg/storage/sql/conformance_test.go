@@ -0,0 +1,42 @@
+package sql_test
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/rancher/opni/pkg/config/v1beta1"
+	"github.com/rancher/opni/pkg/storage/conformance"
+	"github.com/rancher/opni/pkg/storage/sql"
+	"github.com/rancher/opni/pkg/util/future"
+)
+
+func TestSQL(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "SQL Storage Suite")
+}
+
+var store = future.New[*sql.SQLStore]()
+
+var _ = BeforeSuite(func() {
+	ctx, ca := context.WithCancel(context.Background())
+	s, err := sql.NewSQLStore(ctx, &v1beta1.SQLStorageSpec{
+		Driver: sql.DriverSQLite,
+		DSN:    fmt.Sprintf("file:%s?_busy_timeout=5000", filepath.Join(GinkgoT().TempDir(), "opni.db")),
+	}, sql.WithPollInterval(100*time.Millisecond))
+	Expect(err).NotTo(HaveOccurred())
+	store.Set(s)
+
+	DeferCleanup(ca)
+})
+
+var _ = Describe("Token Store", Ordered, Label("integration", "slow"), conformance.TokenStoreTestSuite(store))
+var _ = Describe("Cluster Store", Ordered, Label("integration", "slow"), conformance.ClusterStoreTestSuite(store))
+var _ = Describe("RBAC Store", Ordered, Label("integration", "slow"), conformance.RBACStoreTestSuite(store))
+var _ = Describe("Keyring Store", Ordered, Label("integration", "slow"), conformance.KeyringStoreTestSuite(store))
+var _ = Describe("KV Store", Ordered, Label("integration", "slow"), conformance.KeyValueStoreTestSuite(store))
@@ -0,0 +1,57 @@
+package sql
+
+import (
+	"context"
+	dbsql "database/sql"
+	"errors"
+	"fmt"
+
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	"github.com/rancher/opni/pkg/keyring"
+	"github.com/rancher/opni/pkg/storage"
+)
+
+type sqlKeyringStore struct {
+	store     *SQLStore
+	namespace string
+	ref       *corev1.Reference
+}
+
+func (ks *sqlKeyringStore) Put(ctx context.Context, keyring keyring.Keyring) error {
+	k, err := keyring.Marshal()
+	if err != nil {
+		return fmt.Errorf("failed to marshal keyring: %w", err)
+	}
+	_, err = ks.store.db.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s (namespace, id, data) VALUES ($1, $2, $3) ON CONFLICT (namespace, id) DO UPDATE SET data = excluded.data`, ks.store.table("keyrings")),
+		ks.namespace, ks.ref.Id, k)
+	if err != nil {
+		return fmt.Errorf("failed to put keyring: %w", err)
+	}
+	return nil
+}
+
+func (ks *sqlKeyringStore) Get(ctx context.Context) (keyring.Keyring, error) {
+	var data []byte
+	err := ks.store.db.QueryRowContext(ctx, fmt.Sprintf(`SELECT data FROM %s WHERE namespace = $1 AND id = $2`, ks.store.table("keyrings")),
+		ks.namespace, ks.ref.Id).Scan(&data)
+	if err != nil {
+		if errors.Is(err, dbsql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get keyring: %w", err)
+	}
+	k, err := keyring.Unmarshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal keyring: %w", err)
+	}
+	return k, nil
+}
+
+func (ks *sqlKeyringStore) Delete(ctx context.Context) error {
+	err := checkRowsAffected(ks.store.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE namespace = $1 AND id = $2`, ks.store.table("keyrings")),
+		ks.namespace, ks.ref.Id))
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("failed to delete keyring: %w", err)
+	}
+	return err
+}
@@ -0,0 +1,203 @@
+package sql
+
+import (
+	"context"
+	dbsql "database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rancher/opni/pkg/storage"
+)
+
+type sqlKeyValueStore struct {
+	store     *SQLStore
+	namespace string
+}
+
+func (s *sqlKeyValueStore) Put(ctx context.Context, key string, value []byte, opts ...storage.PutOpt) error {
+	options := storage.PutOptions{}
+	options.Apply(opts...)
+
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	var rev int64
+	err := s.store.withTx(ctx, func(tx *dbsql.Tx) (err error) {
+		rev, err = s.store.nextRevision(ctx, tx)
+		if err != nil {
+			return err
+		}
+		switch {
+		case options.Revision == nil:
+			_, err = tx.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s (namespace, name, value, revision) VALUES ($1, $2, $3, $4) ON CONFLICT (namespace, name) DO UPDATE SET value = excluded.value, revision = excluded.revision`, s.store.table("kv")),
+				s.namespace, key, value, rev)
+		case *options.Revision == 0:
+			err = checkRowsAffected(tx.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s (namespace, name, value, revision) VALUES ($1, $2, $3, $4) ON CONFLICT (namespace, name) DO NOTHING`, s.store.table("kv")),
+				s.namespace, key, value, rev))
+		default:
+			err = checkRowsAffected(tx.ExecContext(ctx, fmt.Sprintf(`UPDATE %s SET value = $1, revision = $2 WHERE namespace = $3 AND name = $4 AND revision = $5`, s.store.table("kv")),
+				value, rev, s.namespace, key, *options.Revision))
+		}
+		if errors.Is(err, storage.ErrNotFound) {
+			return storage.ErrConflict
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if options.RevisionOut != nil {
+		*options.RevisionOut = rev
+	}
+	return nil
+}
+
+func (s *sqlKeyValueStore) Get(ctx context.Context, key string, opts ...storage.GetOpt) ([]byte, error) {
+	options := storage.GetOptions{}
+	options.Apply(opts...)
+
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	var value []byte
+	var rev int64
+	err := s.store.db.QueryRowContext(ctx, fmt.Sprintf(`SELECT value, revision FROM %s WHERE namespace = $1 AND name = $2`, s.store.table("kv")),
+		s.namespace, key).Scan(&value, &rev)
+	if err != nil {
+		if errors.Is(err, dbsql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, err
+	}
+	if options.RevisionOut != nil {
+		*options.RevisionOut = rev
+	}
+	return value, nil
+}
+
+func (s *sqlKeyValueStore) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	return checkRowsAffected(s.store.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE namespace = $1 AND name = $2`, s.store.table("kv")),
+		s.namespace, key))
+}
+
+func (s *sqlKeyValueStore) ListKeys(ctx context.Context, prefix string) ([]string, error) {
+	rows, err := s.store.db.QueryContext(ctx, fmt.Sprintf(`SELECT name FROM %s WHERE namespace = $1 ORDER BY name`, s.store.table("kv")),
+		s.namespace)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	keys := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		// filtered here rather than with LIKE, which would need escaping
+		if strings.HasPrefix(name, prefix) {
+			keys = append(keys, name)
+		}
+	}
+	return keys, rows.Err()
+}
+
+// Watch polls the namespace for changes.
+func (s *sqlKeyValueStore) Watch(ctx context.Context, prefix string) (<-chan storage.WatchEvent[storage.KeyRevision[[]byte]], error) {
+	known, err := s.listRevisions(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	eventC := make(chan storage.WatchEvent[storage.KeyRevision[[]byte]], 64)
+	go func() {
+		defer close(eventC)
+		ticker := time.NewTicker(s.store.PollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			latest, err := s.listRevisions(ctx, prefix)
+			if err != nil {
+				if ctx.Err() == nil {
+					s.store.logger.With(
+						"namespace", s.namespace,
+						"error", err,
+					).Warn("failed to poll keys")
+				}
+				continue
+			}
+			for _, event := range diffRevisions(known, latest) {
+				select {
+				case <-ctx.Done():
+					return
+				case eventC <- event:
+				}
+			}
+			known = latest
+		}
+	}()
+	return eventC, nil
+}
+
+func (s *sqlKeyValueStore) listRevisions(ctx context.Context, prefix string) (map[string]storage.KeyRevision[[]byte], error) {
+	rows, err := s.store.db.QueryContext(ctx, fmt.Sprintf(`SELECT name, value, revision FROM %s WHERE namespace = $1`, s.store.table("kv")),
+		s.namespace)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	revisions := map[string]storage.KeyRevision[[]byte]{}
+	for rows.Next() {
+		var kr storage.KeyRevision[[]byte]
+		if err := rows.Scan(&kr.Key, &kr.Value, &kr.Revision); err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(kr.Key, prefix) {
+			revisions[kr.Key] = kr
+		}
+	}
+	return revisions, rows.Err()
+}
+
+func diffRevisions(known, latest map[string]storage.KeyRevision[[]byte]) []storage.WatchEvent[storage.KeyRevision[[]byte]] {
+	var events []storage.WatchEvent[storage.KeyRevision[[]byte]]
+	for key, current := range latest {
+		prev, ok := known[key]
+		switch {
+		case !ok:
+			events = append(events, storage.WatchEvent[storage.KeyRevision[[]byte]]{
+				EventType: storage.WatchEventCreate,
+				Current:   current,
+			})
+		case prev.Revision != current.Revision:
+			events = append(events, storage.WatchEvent[storage.KeyRevision[[]byte]]{
+				EventType: storage.WatchEventUpdate,
+				Current:   current,
+				Previous:  prev,
+			})
+		}
+	}
+	for key, prev := range known {
+		if _, ok := latest[key]; !ok {
+			events = append(events, storage.WatchEvent[storage.KeyRevision[[]byte]]{
+				EventType: storage.WatchEventDelete,
+				Previous:  prev,
+			})
+		}
+	}
+	return events
+}
+
+func validateKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("key cannot be empty")
+	}
+	return nil
+}
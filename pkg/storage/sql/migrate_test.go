@@ -0,0 +1,77 @@
+package sql_test
+
+import (
+	"context"
+	dbsql "database/sql"
+	"fmt"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/rancher/opni/pkg/config/v1beta1"
+	"github.com/rancher/opni/pkg/storage"
+	"github.com/rancher/opni/pkg/storage/sql"
+)
+
+var _ = Describe("Migrations", Label("unit"), func() {
+	var dsn string
+	BeforeEach(func() {
+		dsn = fmt.Sprintf("file:%s?_busy_timeout=5000", filepath.Join(GinkgoT().TempDir(), "opni.db"))
+	})
+	newStore := func() (*sql.SQLStore, error) {
+		ctx, ca := context.WithCancel(context.Background())
+		DeferCleanup(ca)
+		return sql.NewSQLStore(ctx, &v1beta1.SQLStorageSpec{
+			Driver: sql.DriverSQLite,
+			DSN:    dsn,
+		})
+	}
+	schemaVersion := func() int {
+		db, err := dbsql.Open(sql.DriverSQLite, dsn)
+		Expect(err).NotTo(HaveOccurred())
+		defer db.Close()
+		var version int
+		Expect(db.QueryRow(`SELECT MAX(version) FROM opni_schema_migrations`).Scan(&version)).To(Succeed())
+		return version
+	}
+
+	It("should only apply migrations once", func() {
+		_, err := newStore()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(schemaVersion()).To(Equal(2))
+
+		_, err = newStore()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(schemaVersion()).To(Equal(2))
+	})
+
+	It("should add revisions to existing key-value entries", func() {
+		db, err := dbsql.Open(sql.DriverSQLite, dsn)
+		Expect(err).NotTo(HaveOccurred())
+		for _, stmt := range []string{
+			`CREATE TABLE opni_schema_migrations (version INTEGER PRIMARY KEY)`,
+			`INSERT INTO opni_schema_migrations (version) VALUES (1)`,
+			`CREATE TABLE opni_revision (id INTEGER PRIMARY KEY, value BIGINT NOT NULL)`,
+			`INSERT INTO opni_revision (id, value) VALUES (1, 0)`,
+			`CREATE TABLE opni_kv (namespace TEXT NOT NULL, name TEXT NOT NULL, value BLOB NOT NULL, PRIMARY KEY (namespace, name))`,
+			`INSERT INTO opni_kv (namespace, name, value) VALUES ('test', 'foo', x'626172')`,
+		} {
+			_, err := db.Exec(stmt)
+			Expect(err).NotTo(HaveOccurred())
+		}
+		Expect(db.Close()).To(Succeed())
+
+		s, err := newStore()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(schemaVersion()).To(Equal(2))
+
+		kv := s.KeyValueStore("test")
+		var rev int64
+		value, err := kv.Get(context.Background(), "foo", storage.WithRevisionOut(&rev))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(Equal([]byte("bar")))
+		Expect(rev).To(BeNumerically(">", 0))
+		Expect(kv.Put(context.Background(), "foo", []byte("baz"), storage.WithRevision(rev))).To(Succeed())
+	})
+})
@@ -0,0 +1,124 @@
+package sql
+
+import (
+	"context"
+	dbsql "database/sql"
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	"github.com/rancher/opni/pkg/storage"
+)
+
+func (s *SQLStore) CreateRole(ctx context.Context, role *corev1.Role) error {
+	return s.createObject(ctx, "roles", role.Id, role)
+}
+
+func (s *SQLStore) DeleteRole(ctx context.Context, ref *corev1.Reference) error {
+	return s.deleteObject(ctx, "roles", ref.Id)
+}
+
+func (s *SQLStore) GetRole(ctx context.Context, ref *corev1.Reference) (*corev1.Role, error) {
+	role := &corev1.Role{}
+	if err := s.getObject(ctx, "roles", ref.Id, role); err != nil {
+		return nil, err
+	}
+	return role, nil
+}
+
+func (s *SQLStore) CreateRoleBinding(ctx context.Context, rb *corev1.RoleBinding) error {
+	return s.createObject(ctx, "rolebindings", rb.Id, rb)
+}
+
+func (s *SQLStore) DeleteRoleBinding(ctx context.Context, ref *corev1.Reference) error {
+	return s.deleteObject(ctx, "rolebindings", ref.Id)
+}
+
+func (s *SQLStore) GetRoleBinding(ctx context.Context, ref *corev1.Reference) (*corev1.RoleBinding, error) {
+	rb := &corev1.RoleBinding{}
+	if err := s.getObject(ctx, "rolebindings", ref.Id, rb); err != nil {
+		return nil, err
+	}
+	if err := storage.ApplyRoleBindingTaints(ctx, s, rb); err != nil {
+		return nil, err
+	}
+	return rb, nil
+}
+
+func (s *SQLStore) ListRoles(ctx context.Context) (*corev1.RoleList, error) {
+	roles, err := listObjects(ctx, s, "roles", func() *corev1.Role { return &corev1.Role{} })
+	if err != nil {
+		return nil, err
+	}
+	return &corev1.RoleList{
+		Items: roles,
+	}, nil
+}
+
+func (s *SQLStore) ListRoleBindings(ctx context.Context) (*corev1.RoleBindingList, error) {
+	rbs, err := listObjects(ctx, s, "rolebindings", func() *corev1.RoleBinding { return &corev1.RoleBinding{} })
+	if err != nil {
+		return nil, err
+	}
+	for _, rb := range rbs {
+		if err := storage.ApplyRoleBindingTaints(ctx, s, rb); err != nil {
+			return nil, err
+		}
+	}
+	return &corev1.RoleBindingList{
+		Items: rbs,
+	}, nil
+}
+
+func (s *SQLStore) createObject(ctx context.Context, table string, id string, obj proto.Message) error {
+	data, err := protojson.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	err = checkRowsAffected(s.db.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s (id, data) VALUES ($1, $2) ON CONFLICT (id) DO NOTHING`, s.table(table)),
+		id, string(data)))
+	if errors.Is(err, storage.ErrNotFound) {
+		return storage.ErrAlreadyExists
+	}
+	return err
+}
+
+func (s *SQLStore) deleteObject(ctx context.Context, table string, id string) error {
+	return checkRowsAffected(s.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, s.table(table)), id))
+}
+
+func (s *SQLStore) getObject(ctx context.Context, table string, id string, obj proto.Message) error {
+	var data string
+	err := s.db.QueryRowContext(ctx, fmt.Sprintf(`SELECT data FROM %s WHERE id = $1`, s.table(table)), id).Scan(&data)
+	if err != nil {
+		if errors.Is(err, dbsql.ErrNoRows) {
+			return storage.ErrNotFound
+		}
+		return err
+	}
+	return protojson.Unmarshal([]byte(data), obj)
+}
+
+func listObjects[T proto.Message](ctx context.Context, s *SQLStore, table string, newObj func() T) ([]T, error) {
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`SELECT data FROM %s ORDER BY id`, s.table(table)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []T{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		obj := newObj()
+		if err := protojson.Unmarshal([]byte(data), obj); err != nil {
+			return nil, err
+		}
+		items = append(items, obj)
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,319 @@
+package sql
+
+import (
+	"context"
+	dbsql "database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/lestrrat-go/backoff/v2"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+	"go.uber.org/zap"
+
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	"github.com/rancher/opni/pkg/config/v1beta1"
+	"github.com/rancher/opni/pkg/logger"
+	"github.com/rancher/opni/pkg/storage"
+)
+
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite3"
+)
+
+var errConflict = errors.New("the object has been modified")
+
+// dialect holds the few differences between the supported databases. Queries
+// use $N placeholders, which both PostgreSQL and SQLite understand.
+type dialect struct {
+	blobType string
+}
+
+var dialects = map[string]dialect{
+	DriverPostgres: {blobType: "BYTEA"},
+	DriverSQLite:   {blobType: "BLOB"},
+}
+
+// SQLStore implements storage.Backend on top of a SQL database.
+//
+// Objects are stored as json-encoded protobuf messages. Writes to tokens,
+// clusters and key-value stores increment a single revision counter, which is
+// used as the resource version or revision of the written object. Watches
+// poll the database.
+type SQLStore struct {
+	SQLStoreOptions
+	db      *dbsql.DB
+	dialect dialect
+	logger  *zap.SugaredLogger
+}
+
+var _ storage.Backend = (*SQLStore)(nil)
+
+type SQLStoreOptions struct {
+	TablePrefix  string
+	PollInterval time.Duration
+	GCInterval   time.Duration
+}
+
+type SQLStoreOption func(*SQLStoreOptions)
+
+func (o *SQLStoreOptions) apply(opts ...SQLStoreOption) {
+	for _, op := range opts {
+		op(o)
+	}
+}
+
+// WithTablePrefix sets the prefix of the tables created by the store.
+func WithTablePrefix(prefix string) SQLStoreOption {
+	return func(o *SQLStoreOptions) {
+		o.TablePrefix = prefix
+	}
+}
+
+// WithPollInterval sets how often cluster watches poll the database.
+func WithPollInterval(interval time.Duration) SQLStoreOption {
+	return func(o *SQLStoreOptions) {
+		o.PollInterval = interval
+	}
+}
+
+// WithGCInterval sets how often expired tokens are deleted.
+func WithGCInterval(interval time.Duration) SQLStoreOption {
+	return func(o *SQLStoreOptions) {
+		o.GCInterval = interval
+	}
+}
+
+func NewSQLStore(ctx context.Context, conf *v1beta1.SQLStorageSpec, opts ...SQLStoreOption) (*SQLStore, error) {
+	options := SQLStoreOptions{
+		TablePrefix:  "opni",
+		PollInterval: 1 * time.Second,
+		GCInterval:   1 * time.Minute,
+	}
+	options.apply(opts...)
+
+	driver := conf.Driver
+	if driver == "" {
+		driver = DriverPostgres
+	}
+	d, ok := dialects[driver]
+	if !ok {
+		return nil, fmt.Errorf("unsupported sql driver %q", driver)
+	}
+	dsn := conf.DSN
+	if conf.DSNPath != "" {
+		data, err := os.ReadFile(conf.DSNPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read dsn: %w", err)
+		}
+		dsn = strings.TrimSpace(string(data))
+	}
+	if dsn == "" {
+		return nil, errors.New("no dsn configured")
+	}
+
+	db, err := dbsql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	if driver == DriverSQLite {
+		// sqlite only supports a single writer
+		db.SetMaxOpenConns(1)
+	}
+	lg := logger.New(logger.WithLogLevel(zap.WarnLevel)).Named("sql")
+
+	ctrl := backoff.Exponential(
+		backoff.WithMaxRetries(0),
+		backoff.WithMinInterval(10*time.Millisecond),
+		backoff.WithMaxInterval(10*time.Millisecond<<9),
+		backoff.WithMultiplier(2.0),
+	).Start(ctx)
+	for {
+		if err := db.PingContext(ctx); err == nil {
+			break
+		} else {
+			lg.With(zap.Error(err)).Warn("database is not reachable yet")
+		}
+		select {
+		case <-ctrl.Done():
+			db.Close()
+			return nil, ctx.Err()
+		case <-ctrl.Next():
+		}
+	}
+
+	store := &SQLStore{
+		SQLStoreOptions: options,
+		db:              db,
+		dialect:         d,
+		logger:          lg,
+	}
+	if err := store.migrate(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		db.Close()
+	}()
+	go store.runTokenGC(ctx)
+
+	return store, nil
+}
+
+func (s *SQLStore) table(name string) string {
+	return fmt.Sprintf("%s_%s", s.TablePrefix, name)
+}
+
+// migrations returns the statements of each schema version, in order. The
+// number of applied migrations is recorded in the schema_migrations table, so
+// released migrations must never be changed; add a new one instead.
+func (s *SQLStore) migrations() [][]string {
+	return [][]string{
+		// 1: initial schema
+		{
+			fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (id INTEGER PRIMARY KEY, value BIGINT NOT NULL)`, s.table("revision")),
+			fmt.Sprintf(`INSERT INTO %s (id, value) VALUES (1, 0) ON CONFLICT (id) DO NOTHING`, s.table("revision")),
+			fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (id TEXT PRIMARY KEY, data TEXT NOT NULL, revision BIGINT NOT NULL, expires_at BIGINT NOT NULL)`, s.table("tokens")),
+			fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (id TEXT PRIMARY KEY, data TEXT NOT NULL, revision BIGINT NOT NULL)`, s.table("clusters")),
+			fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (id TEXT PRIMARY KEY, data TEXT NOT NULL)`, s.table("roles")),
+			fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (id TEXT PRIMARY KEY, data TEXT NOT NULL)`, s.table("rolebindings")),
+			fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (namespace TEXT NOT NULL, id TEXT NOT NULL, data %s NOT NULL, PRIMARY KEY (namespace, id))`, s.table("keyrings"), s.dialect.blobType),
+			fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (namespace TEXT NOT NULL, name TEXT NOT NULL, value %s NOT NULL, PRIMARY KEY (namespace, name))`, s.table("kv"), s.dialect.blobType),
+		},
+		// 2: revisions of key-value entries. Existing entries get a new revision,
+		// since a revision of 0 means that the key does not exist.
+		{
+			fmt.Sprintf(`ALTER TABLE %s ADD COLUMN revision BIGINT NOT NULL DEFAULT 0`, s.table("kv")),
+			fmt.Sprintf(`UPDATE %s SET value = value + 1 WHERE id = 1`, s.table("revision")),
+			fmt.Sprintf(`UPDATE %s SET revision = (SELECT value FROM %s WHERE id = 1)`, s.table("kv"), s.table("revision")),
+		},
+	}
+}
+
+// migrate applies the migrations which have not been applied yet, each in its
+// own transaction. If several gateways migrate the same database at once, only
+// one of them can record a given version; the others see it as applied.
+func (s *SQLStore) migrate(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (version INTEGER PRIMARY KEY)`, s.table("schema_migrations"))); err != nil {
+		return err
+	}
+	for i, statements := range s.migrations() {
+		version := i + 1
+		current, err := s.schemaVersion(ctx)
+		if err != nil {
+			return err
+		}
+		if current >= version {
+			continue
+		}
+		err = s.withTx(ctx, func(tx *dbsql.Tx) error {
+			if _, err := tx.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s (version) VALUES ($1)`, s.table("schema_migrations")), version); err != nil {
+				return err
+			}
+			for _, stmt := range statements {
+				if _, err := tx.ExecContext(ctx, stmt); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			if current, verr := s.schemaVersion(ctx); verr == nil && current >= version {
+				continue
+			}
+			return fmt.Errorf("migration %d failed: %w", version, err)
+		}
+		s.logger.With("version", version).Info("applied database migration")
+	}
+	return nil
+}
+
+func (s *SQLStore) schemaVersion(ctx context.Context) (int, error) {
+	var version dbsql.NullInt64
+	if err := s.db.QueryRowContext(ctx, fmt.Sprintf(`SELECT MAX(version) FROM %s`, s.table("schema_migrations"))).Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return int(version.Int64), nil
+}
+
+// withTx runs fn in a transaction, which is committed if fn succeeds.
+func (s *SQLStore) withTx(ctx context.Context, fn func(tx *dbsql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// nextRevision increments the revision counter. The counter row stays locked
+// until the transaction completes, so revisions are assigned in commit order.
+func (s *SQLStore) nextRevision(ctx context.Context, tx *dbsql.Tx) (int64, error) {
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(`UPDATE %s SET value = value + 1 WHERE id = 1`, s.table("revision"))); err != nil {
+		return 0, fmt.Errorf("failed to increment revision: %w", err)
+	}
+	var rev int64
+	if err := tx.QueryRowContext(ctx, fmt.Sprintf(`SELECT value FROM %s WHERE id = 1`, s.table("revision"))).Scan(&rev); err != nil {
+		return 0, fmt.Errorf("failed to read revision: %w", err)
+	}
+	return rev, nil
+}
+
+// retryOnConflict calls fn until it does not return errConflict.
+func retryOnConflict(ctx context.Context, fn func() error) error {
+	b := backoff.Exponential(
+		backoff.WithMaxRetries(0),
+		backoff.WithMinInterval(1*time.Millisecond),
+		backoff.WithMaxInterval(128*time.Millisecond),
+		backoff.WithMultiplier(2),
+	).Start(ctx)
+	for {
+		err := fn()
+		if !errors.Is(err, errConflict) {
+			return err
+		}
+		select {
+		case <-b.Done():
+			return fmt.Errorf("%w: %v", err, ctx.Err())
+		case <-b.Next():
+		}
+	}
+}
+
+func checkRowsAffected(res dbsql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return storage.ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLStore) KeyringStore(namespace string, ref *corev1.Reference) storage.KeyringStore {
+	return &sqlKeyringStore{
+		store:     s,
+		namespace: namespace,
+		ref:       ref,
+	}
+}
+
+func (s *SQLStore) KeyValueStore(namespace string) storage.KeyValueStore {
+	return &sqlKeyValueStore{
+		store:     s,
+		namespace: namespace,
+	}
+}
@@ -0,0 +1,182 @@
+package sql
+
+import (
+	"context"
+	dbsql "database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	"github.com/rancher/opni/pkg/storage"
+	"github.com/rancher/opni/pkg/tokens"
+)
+
+func (s *SQLStore) CreateToken(ctx context.Context, ttl time.Duration, opts ...storage.TokenCreateOption) (*corev1.BootstrapToken, error) {
+	options := storage.NewTokenCreateOptions()
+	options.Apply(opts...)
+
+	token := tokens.NewToken().ToBootstrapToken()
+	token.Metadata = &corev1.BootstrapTokenMetadata{
+		LeaseID:           -1,
+		Ttl:               int64(ttl.Seconds()),
+		UsageCount:        0,
+		Labels:            options.Labels,
+		Capabilities:      options.Capabilities,
+		MaxUsages:         options.MaxUsages,
+		OneShot:           options.OneShot,
+		AllowedClusterIds: options.AllowedClusterIds,
+		AllowedLabels:     options.AllowedLabels,
+	}
+	data, err := protojson.Marshal(token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal token: %w", err)
+	}
+	expiresAt := time.Now().Add(ttl).Unix()
+	var rev int64
+	err = s.withTx(ctx, func(tx *dbsql.Tx) (err error) {
+		rev, err = s.nextRevision(ctx, tx)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s (id, data, revision, expires_at) VALUES ($1, $2, $3, $4)`, s.table("tokens")),
+			token.TokenID, string(data), rev, expiresAt)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token: %w", err)
+	}
+	token.SetResourceVersion(fmt.Sprint(rev))
+	return token, nil
+}
+
+func (s *SQLStore) DeleteToken(ctx context.Context, ref *corev1.Reference) error {
+	if _, err := s.GetToken(ctx, ref); err != nil {
+		return err
+	}
+	err := checkRowsAffected(s.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, s.table("tokens")), ref.Id))
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("failed to delete token: %w", err)
+	}
+	return err
+}
+
+func (s *SQLStore) GetToken(ctx context.Context, ref *corev1.Reference) (*corev1.BootstrapToken, error) {
+	row := s.db.QueryRowContext(ctx, fmt.Sprintf(`SELECT data, revision, expires_at FROM %s WHERE id = $1 AND expires_at > $2`, s.table("tokens")),
+		ref.Id, time.Now().Unix())
+	token, err := scanToken(row)
+	if err != nil {
+		if errors.Is(err, dbsql.ErrNoRows) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get token: %w", err)
+	}
+	return token, nil
+}
+
+func (s *SQLStore) UpdateToken(ctx context.Context, ref *corev1.Reference, mutator storage.TokenMutator) (*corev1.BootstrapToken, error) {
+	var token *corev1.BootstrapToken
+	err := retryOnConflict(ctx, func() error {
+		var err error
+		token, err = s.GetToken(ctx, ref)
+		if err != nil {
+			return err
+		}
+		prevRevision, err := strconv.ParseInt(token.GetResourceVersion(), 10, 64)
+		if err != nil {
+			return fmt.Errorf("internal error: token has invalid resource version: %w", err)
+		}
+		mutator(token)
+		token.SetResourceVersion("")
+		data, err := protojson.Marshal(token)
+		if err != nil {
+			return fmt.Errorf("failed to marshal token: %w", err)
+		}
+		return s.withTx(ctx, func(tx *dbsql.Tx) error {
+			rev, err := s.nextRevision(ctx, tx)
+			if err != nil {
+				return err
+			}
+			err = checkRowsAffected(tx.ExecContext(ctx, fmt.Sprintf(`UPDATE %s SET data = $1, revision = $2 WHERE id = $3 AND revision = $4`, s.table("tokens")),
+				string(data), rev, ref.Id, prevRevision))
+			if errors.Is(err, storage.ErrNotFound) {
+				return errConflict
+			}
+			if err != nil {
+				return err
+			}
+			token.SetResourceVersion(fmt.Sprint(rev))
+			return nil
+		})
+	})
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to update token: %w", err)
+	}
+	return token, nil
+}
+
+func (s *SQLStore) ListTokens(ctx context.Context) ([]*corev1.BootstrapToken, error) {
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`SELECT data, revision, expires_at FROM %s WHERE expires_at > $1`, s.table("tokens")),
+		time.Now().Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tokens: %w", err)
+	}
+	defer rows.Close()
+	items := []*corev1.BootstrapToken{}
+	for rows.Next() {
+		token, err := scanToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tokens: %w", err)
+		}
+		items = append(items, token)
+	}
+	return items, rows.Err()
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanToken(row scanner) (*corev1.BootstrapToken, error) {
+	var data string
+	var rev, expiresAt int64
+	if err := row.Scan(&data, &rev, &expiresAt); err != nil {
+		return nil, err
+	}
+	token := &corev1.BootstrapToken{}
+	if err := protojson.Unmarshal([]byte(data), token); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal token: %w", err)
+	}
+	if token.Metadata == nil {
+		token.Metadata = &corev1.BootstrapTokenMetadata{}
+	}
+	token.Metadata.Ttl = int64(math.Round(time.Until(time.Unix(expiresAt, 0)).Seconds()))
+	token.SetResourceVersion(fmt.Sprint(rev))
+	return token, nil
+}
+
+// runTokenGC periodically deletes expired tokens, which are otherwise only
+// hidden from reads.
+func (s *SQLStore) runTokenGC(ctx context.Context) {
+	ticker := time.NewTicker(s.GCInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, err := s.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE expires_at <= $1`, s.table("tokens")), time.Now().Unix())
+			if err != nil && ctx.Err() == nil {
+				s.logger.With(zap.Error(err)).Warn("failed to delete expired tokens")
+			}
+		}
+	}
+}
@@ -57,10 +57,22 @@ type KeyringStore interface {
 }
 
 type KeyValueStoreT[T any] interface {
-	Put(ctx context.Context, key string, value T) error
-	Get(ctx context.Context, key string) (T, error)
+	Put(ctx context.Context, key string, value T, opts ...PutOpt) error
+	Get(ctx context.Context, key string, opts ...GetOpt) (T, error)
 	Delete(ctx context.Context, key string) error
 	ListKeys(ctx context.Context, prefix string) ([]string, error)
+	// Watch streams events for changes to keys with the given prefix, starting
+	// from the time the watch is created. The channel is closed when ctx is
+	// canceled or the watch can no longer continue.
+	Watch(ctx context.Context, prefix string) (<-chan WatchEvent[KeyRevision[T]], error)
+}
+
+// KeyRevision is a key and the value it held at some point in time.
+type KeyRevision[T any] struct {
+	Key   string
+	Value T
+	// Revision of the key, if the backend tracks revisions. Otherwise zero.
+	Revision int64
 }
 
 type KeyValueStore KeyValueStoreT[[]byte]
@@ -75,6 +87,7 @@ type KeyValueStoreBroker interface {
 
 // A store that can be used to compute subject access rules
 type SubjectAccessCapableStore interface {
+	GetCluster(ctx context.Context, ref *corev1.Reference) (*corev1.Cluster, error)
 	ListClusters(ctx context.Context, matchLabels *corev1.LabelSelector, matchOptions corev1.MatchOptions) (*corev1.ClusterList, error)
 	GetRole(ctx context.Context, ref *corev1.Reference) (*corev1.Role, error)
 	ListRoleBindings(ctx context.Context) (*corev1.RoleBindingList, error)
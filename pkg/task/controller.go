@@ -9,6 +9,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/lestrrat-go/backoff/v2"
 	"github.com/qmuntal/stateless"
 	"github.com/samber/lo"
 	"go.uber.org/zap"
@@ -28,28 +29,32 @@ type Controller struct {
 	// this context is kept around so that we can run operations scoped to the
 	// lifetime of the controller (e.g. interacting with the kv store)
 	// inside tasks that have been canceled.
-	ctx     context.Context
-	name    string
-	store   KVStore
-	locks   util.LockMap[string, *sync.Mutex]
-	runner  TaskRunner
-	logger  *zap.SugaredLogger
-	tasks   map[string]context.CancelFunc
-	tasksMu sync.Mutex
+	ctx         context.Context
+	name        string
+	store       KVStore
+	locks       util.LockMap[string, *sync.Mutex]
+	runner      TaskRunner
+	logger      *zap.SugaredLogger
+	tasks       map[string]context.CancelFunc
+	tasksMu     sync.Mutex
+	schedules   map[string]context.CancelFunc
+	schedulesMu sync.Mutex
 }
 
 // Creates a new task controller, and resumes any tasks that have state saved
 // in the key-value store if they did not already complete. Saved state for
-// completed tasks will be cleaned.
+// completed tasks will be cleaned, unless the task is a recurring task (see
+// ScheduleTask).
 func NewController(ctx context.Context, name string, store KVStore, runner TaskRunner) (*Controller, error) {
 	ctrl := &Controller{
-		ctx:    ctx,
-		name:   name,
-		store:  storage.NewKeyValueStoreWithPrefix(store, fmt.Sprintf("/tasks/%s/", name)),
-		locks:  util.NewLockMap[string, *sync.Mutex](),
-		runner: runner,
-		logger: logger.New().Named("tasks"),
-		tasks:  make(map[string]context.CancelFunc),
+		ctx:       ctx,
+		name:      name,
+		store:     storage.NewKeyValueStoreWithPrefix(store, keyPrefix+name+"/"),
+		locks:     util.NewLockMap[string, *sync.Mutex](),
+		runner:    runner,
+		logger:    logger.New().Named("tasks"),
+		tasks:     make(map[string]context.CancelFunc),
+		schedules: make(map[string]context.CancelFunc),
 	}
 	existingTasks, err := ctrl.ListTasks()
 	if err != nil {
@@ -71,6 +76,17 @@ func NewController(ctx context.Context, name string, store KVStore, runner TaskR
 			}
 			switch status.State {
 			case StatePending, StateRunning:
+				if status.GetCancelRequested() {
+					if err := ctrl.cancelStoredTask(id); err != nil {
+						ctrl.logger.With(
+							zap.String("id", id),
+							zap.Error(err),
+						).Warn("failed to cancel task (will retry)")
+						time.Sleep(time.Second)
+						continue
+					}
+					break
+				}
 				if err := ctrl.LaunchTask(id, WithJsonMetadata(status.GetMetadata())); err != nil {
 					ctrl.logger.With(
 						zap.String("id", id),
@@ -80,6 +96,10 @@ func NewController(ctx context.Context, name string, store KVStore, runner TaskR
 					continue
 				}
 			case StateCompleted, StateFailed, StateCanceled:
+				if status.GetSchedule() != nil {
+					// keep the time of the last run for the scheduler
+					break
+				}
 				// clean up the old task state
 				rw := storage.NewValueStoreLocker(storage.NewValueStore(ctrl.store, id), ctrl.locks.Get(id))
 				rw.Lock()
@@ -96,14 +116,85 @@ func NewController(ctx context.Context, name string, store KVStore, runner TaskR
 			break
 		}
 	}
+	go ctrl.watchCancelRequests()
 	return ctrl, nil
 }
 
+// Cancels tasks for which cancellation was requested through the store (see
+// RequestCancel), until the controller's context is done. If the watch keeps
+// failing, it is retried with exponential backoff. If the store does not
+// support watches at all, cancellation requests are only applied when the
+// controller is next started.
+func (c *Controller) watchCancelRequests() {
+	p := backoff.Exponential(
+		backoff.WithMaxRetries(0),
+		backoff.WithMinInterval(time.Second),
+		backoff.WithMaxInterval(5*time.Minute),
+		backoff.WithMultiplier(2.0),
+	)
+	b := p.Start(c.ctx)
+	for backoff.Continue(b) {
+		start := time.Now()
+		eventC, err := c.store.Watch(c.ctx, "")
+		if err != nil {
+			if util.StatusCode(err) == codes.Unimplemented {
+				c.logger.Warn("task store does not support watches, cancellation requests will be applied when the controller is restarted")
+				return
+			}
+			c.logger.With(
+				zap.Error(err),
+			).Warn("failed to watch for task cancellation requests (will retry)")
+			continue
+		}
+		for event := range eventC {
+			if event.EventType == storage.WatchEventDelete {
+				continue
+			}
+			if event.Current.Value.GetCancelRequested() && !event.Previous.Value.GetCancelRequested() {
+				id := path.Base(event.Current.Key)
+				c.logger.With(
+					zap.String("id", id),
+				).Info("task cancellation requested")
+				c.CancelTask(id)
+			}
+		}
+		// only keep backing off if the watch ended right away
+		if time.Since(start) > time.Minute {
+			b = p.Start(c.ctx)
+		}
+	}
+}
+
+// Moves a task that is not running to the Canceled state.
+func (c *Controller) cancelStoredTask(id string) error {
+	rw := storage.NewValueStoreLocker(storage.NewValueStore(c.store, id), c.locks.Get(id))
+	rw.Lock()
+	defer rw.Unlock()
+	status, err := rw.Get(c.ctx)
+	if err != nil {
+		return err
+	}
+	now := timestamppb.Now()
+	status.State = StateCanceled
+	status.Transitions = append(status.Transitions, &corev1.StateTransition{
+		State:     StateCanceled,
+		Timestamp: now,
+	})
+	status.Logs = append(status.Logs, &corev1.LogEntry{
+		Msg:       "internal: task canceled while the controller was not running",
+		Level:     int32(zapcore.WarnLevel),
+		Timestamp: now,
+	})
+	return rw.Put(c.ctx, status)
+}
+
 type NewTaskOptions struct {
 	metadata       any
 	jsonMetadata   *string
 	statusCallback chan *Status
 	stateCallback  chan State
+	retryPolicy    *corev1.TaskRetryPolicy
+	schedule       *corev1.TaskSchedule
 }
 
 type NewTaskOption func(*NewTaskOptions)
@@ -149,6 +240,27 @@ func WithStateCallback(ch chan State) NewTaskOption {
 	}
 }
 
+// If the task fails, it will be retried according to the given policy before
+// being moved to the Failed state. Retries are delayed with exponential
+// backoff. The policy and the number of attempts are saved with the task's
+// status, so retries continue where they left off if the controller is
+// restarted. Errors wrapped with Permanent are never retried.
+func WithRetryPolicy(policy *corev1.TaskRetryPolicy) NewTaskOption {
+	return func(o *NewTaskOptions) {
+		o.retryPolicy = policy
+	}
+}
+
+// Set by the scheduler when launching a run of a recurring task.
+func withScheduledRun(spec string, at time.Time) NewTaskOption {
+	return func(o *NewTaskOptions) {
+		o.schedule = &corev1.TaskSchedule{
+			Spec:    spec,
+			LastRun: timestamppb.New(at),
+		}
+	}
+}
+
 func (c *Controller) LaunchTask(id string, opts ...NewTaskOption) error {
 	if err := c.ctx.Err(); err != nil {
 		return fmt.Errorf("cannot launch task: %w (controller context)", err)
@@ -197,8 +309,10 @@ func (c *Controller) LaunchTask(id string, opts ...NewTaskOption) error {
 		if err != nil {
 			if util.StatusCode(err) == codes.NotFound {
 				ts = &corev1.TaskStatus{
-					State:    state.(corev1.TaskState),
-					Metadata: mdJson,
+					State:       state.(corev1.TaskState),
+					Metadata:    mdJson,
+					RetryPolicy: options.retryPolicy,
+					Schedule:    options.schedule,
 				}
 			} else {
 				return err
@@ -218,6 +332,25 @@ func (c *Controller) LaunchTask(id string, opts ...NewTaskOption) error {
 	sm := stateless.NewStateMachineWithExternalStorage(accessor, mutator, stateless.FiringQueued)
 
 	sm.SetTriggerParameters(corev1.TaskTrigger_Error, reflect.TypeOf((*error)(nil)).Elem())
+
+	// Runs the Pending state when the task is first started, and each time it
+	// is retried after failing.
+	onPending := func(taskCtx context.Context) error {
+		err := t.waitForRetry(taskCtx)
+		if err == nil {
+			err = c.runner.OnTaskPending(taskCtx, t)
+		}
+		if err != nil {
+			if taskCtx.Err() != nil && taskCtx.Err() == err {
+				return sm.Fire(corev1.TaskTrigger_Cancel)
+			}
+			if t.scheduleRetry(err) {
+				return sm.Fire(corev1.TaskTrigger_Retry)
+			}
+			return sm.Fire(corev1.TaskTrigger_Error, err)
+		}
+		return sm.FireCtx(taskCtx, corev1.TaskTrigger_Start)
+	}
 	sm.OnTransitioning(func(ctx context.Context, transition stateless.Transition) {
 		// applies to all OnEntry handlers, but not OnActive
 		t.logTransition(&corev1.StateTransition{
@@ -228,26 +361,26 @@ func (c *Controller) LaunchTask(id string, opts ...NewTaskOption) error {
 	sm.Configure(corev1.TaskState_Pending).
 		Permit(corev1.TaskTrigger_Start, corev1.TaskState_Running).
 		Permit(corev1.TaskTrigger_Cancel, corev1.TaskState_Canceled).
-		OnActive(func(taskCtx context.Context) error {
-			err := c.runner.OnTaskPending(taskCtx, t)
-			if err != nil {
-				if taskCtx.Err() != nil && taskCtx.Err() == err {
-					return sm.Fire(corev1.TaskTrigger_Cancel)
-				}
-				return sm.Fire(corev1.TaskTrigger_Error, err)
-			}
-			return sm.FireCtx(taskCtx, corev1.TaskTrigger_Start)
+		Permit(corev1.TaskTrigger_Error, corev1.TaskState_Failed).
+		PermitReentry(corev1.TaskTrigger_Retry).
+		OnActive(onPending).
+		OnEntry(func(taskCtx context.Context, _ ...any) error {
+			return onPending(taskCtx)
 		})
 	sm.Configure(corev1.TaskState_Running).
 		Permit(corev1.TaskTrigger_End, corev1.TaskState_Completed).
 		Permit(corev1.TaskTrigger_Cancel, corev1.TaskState_Canceled).
 		Permit(corev1.TaskTrigger_Error, corev1.TaskState_Failed).
+		Permit(corev1.TaskTrigger_Retry, corev1.TaskState_Pending).
 		OnEntry(func(taskCtx context.Context, _ ...any) error {
 			err := c.runner.OnTaskRunning(taskCtx, t)
 			if err != nil {
 				if taskCtx.Err() != nil && taskCtx.Err() == err {
 					return sm.Fire(corev1.TaskTrigger_Cancel)
 				}
+				if t.scheduleRetry(err) {
+					return sm.Fire(corev1.TaskTrigger_Retry)
+				}
 				return sm.Fire(corev1.TaskTrigger_Error, err)
 			}
 			return sm.Fire(corev1.TaskTrigger_End)
@@ -309,6 +442,7 @@ func (c *Controller) LaunchTask(id string, opts ...NewTaskOption) error {
 		// │     State │ Progress    │ Metadata    │ Logs        │ Transitions │
 		// ├───────────┼─────────────┼─────────────┼─────────────┼─────────────┤
 		// │   Running │ Keep        │ Keep        │ Keep        │ Keep        │
+		// │ Pending*  │ Keep        │ Keep        │ Keep        │ Keep        │
 		// │    Failed │ Clear       │ Reset       │ Keep+Append │ Keep        │
 		// │  Canceled │ Keep        │ Reset       │ Clear       │ Clear       │
 		// ├ ─ ─ ─ ─ ─ ┼ ─ ─ ─ ─ ─ ─ ┼ ─ ─ ─ ─ ─ ─ ┼ ─ ─ ─ ─ ─ ─ ┼ ─ ─ ─ ─ ─ ─ ┤
@@ -316,16 +450,40 @@ func (c *Controller) LaunchTask(id string, opts ...NewTaskOption) error {
 		// │   Pending │ Clear       │ Reset       │ Clear       │ Clear       │
 		// │ Completed │ Clear       │ Reset       │ Clear       │ Clear       │
 		// └───────────┴─────────────┴─────────────┴─────────────┴─────────────┘
+		// * Pending while waiting to be retried after a failed attempt.
+		//
+		// The retry policy and schedule are replaced if given in the options.
+		// Otherwise, they are kept only for resumed (Running or retrying)
+		// tasks, along with the retry status. Runs of recurring tasks always
+		// start with a clean status, as if the previous run had completed.
 		rw.Lock()
 		prev, err := rw.Get(c.ctx)
 		if err != nil {
 			rw.Unlock()
 			return err
 		}
+		prevState := state.(corev1.TaskState)
+		resuming := prevState == corev1.TaskState_Running ||
+			(prevState == corev1.TaskState_Pending && prev.GetRetry().GetAttempts() > 0)
+		if options.schedule != nil && IsDone(prevState) {
+			prevState = corev1.TaskState_Completed
+		}
 		prev.State = corev1.TaskState_Pending
-		switch state.(corev1.TaskState) {
-		case corev1.TaskState_Running:
-		case corev1.TaskState_Failed:
+		prev.CancelRequested = false
+		if !resuming {
+			prev.Retry = nil
+			prev.RetryPolicy = nil
+			prev.Schedule = nil
+		}
+		if options.retryPolicy != nil {
+			prev.RetryPolicy = options.retryPolicy
+		}
+		if options.schedule != nil {
+			prev.Schedule = options.schedule
+		}
+		switch {
+		case resuming:
+		case prevState == corev1.TaskState_Failed:
 			prev.Progress = nil
 			prev.Metadata = mdJson
 			prev.Logs = append(prev.Logs, &corev1.LogEntry{
@@ -333,7 +491,7 @@ func (c *Controller) LaunchTask(id string, opts ...NewTaskOption) error {
 				Level:     int32(zapcore.InfoLevel),
 				Timestamp: timestamppb.Now(),
 			})
-		case corev1.TaskState_Canceled:
+		case prevState == corev1.TaskState_Canceled:
 			prev.Metadata = mdJson
 			prev.Logs = nil
 			prev.Transitions = nil
@@ -0,0 +1,211 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	"github.com/rancher/opni/pkg/storage"
+)
+
+// Controllers store the status of their tasks in a key-value store under
+// this prefix, followed by the controller name and the task id.
+const keyPrefix = "/tasks/"
+
+// The functions below operate on the raw key-value store a task controller
+// was given, so that the tasks of every controller using that store can be
+// inspected and managed from outside of the process running the controller.
+
+// Entry is the last known status of a task, along with the name of the
+// controller that owns it.
+type Entry struct {
+	Controller string
+	Id         string
+	Status     *corev1.TaskStatus
+}
+
+func taskKey(controller, id string) string {
+	return keyPrefix + controller + "/" + id
+}
+
+func parseTaskKey(key string) (controller, id string, ok bool) {
+	parts := strings.Split(strings.Trim(key, "/"), "/")
+	if len(parts) != 3 || parts[0] != strings.Trim(keyPrefix, "/") {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
+
+// IsDone returns true if the task is in one of the completed states
+// (Completed, Failed, or Canceled).
+func IsDone(state State) bool {
+	switch state {
+	case StateCompleted, StateFailed, StateCanceled:
+		return true
+	}
+	return false
+}
+
+// EndTime returns the time at which the task entered its current state, if
+// the task is done. If the transition was not recorded, the zero time is
+// returned.
+func EndTime(ts *corev1.TaskStatus) (time.Time, bool) {
+	if !IsDone(ts.GetState()) {
+		return time.Time{}, false
+	}
+	transitions := ts.GetTransitions()
+	for i := len(transitions) - 1; i >= 0; i-- {
+		if transitions[i].GetState() == ts.GetState() {
+			return transitions[i].GetTimestamp().AsTime(), true
+		}
+	}
+	return time.Time{}, true
+}
+
+// ListAll returns the status of every task in the store, across all
+// controllers.
+func ListAll(ctx context.Context, store storage.KeyValueStore) ([]Entry, error) {
+	keys, err := store.ListKeys(ctx, keyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(keys))
+	for _, key := range keys {
+		controller, id, ok := parseTaskKey(key)
+		if !ok {
+			continue
+		}
+		entry, err := Get(ctx, store, controller, id)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				// deleted since listing
+				continue
+			}
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// Get returns the status of a single task.
+func Get(ctx context.Context, store storage.KeyValueStore, controller, id string) (Entry, error) {
+	data, err := store.Get(ctx, taskKey(controller, id))
+	if err != nil {
+		return Entry{}, err
+	}
+	ts := &corev1.TaskStatus{}
+	if err := proto.Unmarshal(data, ts); err != nil {
+		return Entry{}, fmt.Errorf("failed to decode status of task %s: %w", path.Join(controller, id), err)
+	}
+	return Entry{
+		Controller: controller,
+		Id:         id,
+		Status:     ts,
+	}, nil
+}
+
+// Watch streams the status of tasks in the store each time they are updated.
+// Deleted tasks are not reported. The channel is closed when ctx is canceled
+// or the underlying watch ends.
+func Watch(ctx context.Context, store storage.KeyValueStore) (<-chan Entry, error) {
+	eventC, err := store.Watch(ctx, keyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	entryC := make(chan Entry, cap(eventC))
+	go func() {
+		defer close(entryC)
+		for event := range eventC {
+			if event.EventType == storage.WatchEventDelete {
+				continue
+			}
+			controller, id, ok := parseTaskKey(event.Current.Key)
+			if !ok {
+				continue
+			}
+			ts := &corev1.TaskStatus{}
+			if err := proto.Unmarshal(event.Current.Value, ts); err != nil {
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case entryC <- Entry{Controller: controller, Id: id, Status: ts}:
+			}
+		}
+	}()
+	return entryC, nil
+}
+
+// RequestCancel marks a task as canceled in the store. The controller which
+// owns the task will cancel it if it is running, or when it is next started.
+// As with Controller.CancelTask, this is best-effort.
+func RequestCancel(ctx context.Context, store storage.KeyValueStore, controller, id string) error {
+	key := taskKey(controller, id)
+	for {
+		var revision int64
+		data, err := store.Get(ctx, key, storage.WithRevisionOut(&revision))
+		if err != nil {
+			return err
+		}
+		ts := &corev1.TaskStatus{}
+		if err := proto.Unmarshal(data, ts); err != nil {
+			return fmt.Errorf("failed to decode status of task %s: %w", path.Join(controller, id), err)
+		}
+		if IsDone(ts.GetState()) {
+			return status.Errorf(codes.FailedPrecondition, "task is already %s", ts.GetState())
+		}
+		if ts.GetCancelRequested() {
+			return nil
+		}
+		ts.CancelRequested = true
+		data, err = proto.Marshal(ts)
+		if err != nil {
+			return err
+		}
+		err = store.Put(ctx, key, data, storage.WithRevision(revision))
+		if errors.Is(err, storage.ErrConflict) {
+			continue
+		}
+		return err
+	}
+}
+
+// Prune deletes tasks that have been done for longer than the retention
+// period, and returns the deleted tasks. The filter, if not nil, limits which
+// tasks are considered. Recurring tasks are never pruned, since their status
+// holds the time of their last run.
+func Prune(ctx context.Context, store storage.KeyValueStore, retention time.Duration, filter func(Entry) bool) ([]Entry, error) {
+	entries, err := ListAll(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	cutoff := time.Now().Add(-retention)
+	var pruned []Entry
+	for _, entry := range entries {
+		if filter != nil && !filter(entry) {
+			continue
+		}
+		if entry.Status.GetSchedule() != nil {
+			continue
+		}
+		endTime, ok := EndTime(entry.Status)
+		if !ok || endTime.After(cutoff) {
+			continue
+		}
+		if err := store.Delete(ctx, taskKey(entry.Controller, entry.Id)); err != nil {
+			return pruned, err
+		}
+		pruned = append(pruned, entry)
+	}
+	return pruned, nil
+}
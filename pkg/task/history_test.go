@@ -0,0 +1,152 @@
+package task_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/exp/slices"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	"github.com/rancher/opni/pkg/storage"
+	"github.com/rancher/opni/pkg/task"
+	"github.com/rancher/opni/pkg/test"
+)
+
+var _ = Describe("Task History", Label("unit"), func() {
+	var store storage.KeyValueStore
+
+	put := func(key string, state corev1.TaskState, endedAgo time.Duration) {
+		ts := &corev1.TaskStatus{
+			State: state,
+			Transitions: []*corev1.StateTransition{
+				{
+					State:     corev1.TaskState_Pending,
+					Timestamp: timestamppb.New(time.Now().Add(-endedAgo - time.Minute)),
+				},
+				{
+					State:     state,
+					Timestamp: timestamppb.New(time.Now().Add(-endedAgo)),
+				},
+			},
+		}
+		data, err := proto.Marshal(ts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(store.Put(context.Background(), key, data)).To(Succeed())
+	}
+	get := func(key string) *corev1.TaskStatus {
+		data, err := store.Get(context.Background(), key)
+		Expect(err).NotTo(HaveOccurred())
+		ts := &corev1.TaskStatus{}
+		Expect(proto.Unmarshal(data, ts)).To(Succeed())
+		return ts
+	}
+
+	BeforeEach(func() {
+		store = test.NewTestKeyValueStore(ctrl, slices.Clone[[]byte])
+		put("/tasks/uninstall/a", corev1.TaskState_Running, 0)
+		put("/tasks/uninstall/b", corev1.TaskState_Completed, 2*time.Hour)
+		put("/tasks/other/c", corev1.TaskState_Failed, 10*time.Minute)
+		put("/unrelated", corev1.TaskState_Completed, 0)
+	})
+
+	It("should list tasks across all controllers", func() {
+		entries, err := task.ListAll(context.Background(), store)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(3))
+		ids := map[string]corev1.TaskState{}
+		for _, e := range entries {
+			ids[e.Controller+"/"+e.Id] = e.Status.GetState()
+		}
+		Expect(ids).To(Equal(map[string]corev1.TaskState{
+			"uninstall/a": corev1.TaskState_Running,
+			"uninstall/b": corev1.TaskState_Completed,
+			"other/c":     corev1.TaskState_Failed,
+		}))
+
+		entry, err := task.Get(context.Background(), store, "other", "c")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entry.Status.GetState()).To(Equal(corev1.TaskState_Failed))
+
+		_, err = task.Get(context.Background(), store, "other", "d")
+		Expect(status.Code(err)).To(Equal(codes.NotFound))
+	})
+
+	It("should stream task updates", func() {
+		ctx, ca := context.WithCancel(context.Background())
+		defer ca()
+		entryC, err := task.Watch(ctx, store)
+		Expect(err).NotTo(HaveOccurred())
+
+		put("/unrelated", corev1.TaskState_Running, 0)
+		put("/tasks/uninstall/a", corev1.TaskState_Completed, 0)
+		var entry task.Entry
+		Eventually(entryC).Should(Receive(&entry))
+		Expect(entry.Controller).To(Equal("uninstall"))
+		Expect(entry.Id).To(Equal("a"))
+		Expect(entry.Status.GetState()).To(Equal(corev1.TaskState_Completed))
+
+		Expect(store.Delete(context.Background(), "/tasks/uninstall/a")).To(Succeed())
+		Consistently(entryC, 100*time.Millisecond).ShouldNot(Receive())
+	})
+
+	It("should request cancellation of running tasks", func() {
+		Expect(task.RequestCancel(context.Background(), store, "uninstall", "a")).To(Succeed())
+		Expect(get("/tasks/uninstall/a").GetCancelRequested()).To(BeTrue())
+
+		By("requesting cancellation again")
+		Expect(task.RequestCancel(context.Background(), store, "uninstall", "a")).To(Succeed())
+
+		By("requesting cancellation of a completed task")
+		err := task.RequestCancel(context.Background(), store, "uninstall", "b")
+		Expect(status.Code(err)).To(Equal(codes.FailedPrecondition))
+		Expect(get("/tasks/uninstall/b").GetCancelRequested()).To(BeFalse())
+
+		err = task.RequestCancel(context.Background(), store, "uninstall", "d")
+		Expect(status.Code(err)).To(Equal(codes.NotFound))
+	})
+
+	It("should prune tasks that ended before the retention period", func() {
+		pruned, err := task.Prune(context.Background(), store, time.Hour, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pruned).To(HaveLen(1))
+		Expect(pruned[0].Id).To(Equal("b"))
+
+		pruned, err = task.Prune(context.Background(), store, 0, func(e task.Entry) bool {
+			return e.Controller == "uninstall"
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pruned).To(BeEmpty())
+
+		pruned, err = task.Prune(context.Background(), store, 0, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pruned).To(HaveLen(1))
+		Expect(pruned[0].Id).To(Equal("c"))
+
+		entries, err := task.ListAll(context.Background(), store)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Id).To(Equal("a"))
+	})
+
+	It("should not prune recurring tasks", func() {
+		ts := get("/tasks/uninstall/b")
+		ts.Schedule = &corev1.TaskSchedule{
+			Spec:    "@hourly",
+			LastRun: timestamppb.New(time.Now().Add(-2 * time.Hour)),
+		}
+		data, err := proto.Marshal(ts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(store.Put(context.Background(), "/tasks/uninstall/b", data)).To(Succeed())
+
+		pruned, err := task.Prune(context.Background(), store, time.Hour, nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pruned).To(BeEmpty())
+		Expect(get("/tasks/uninstall/b").GetSchedule().GetSpec()).To(Equal("@hourly"))
+	})
+})
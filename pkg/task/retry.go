@@ -0,0 +1,133 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+)
+
+const (
+	defaultInitialBackoff    = 1 * time.Second
+	defaultMaxBackoff        = 5 * time.Minute
+	defaultBackoffMultiplier = 2.0
+)
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent wraps an error returned by a TaskRunner to indicate that the task
+// cannot succeed by being retried. The task will be moved to the Failed state
+// regardless of its retry policy.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent returns true if the error, or any error it wraps, was created
+// by Permanent.
+func IsPermanent(err error) bool {
+	var pe *permanentError
+	return errors.As(err, &pe)
+}
+
+// RetryBackoff returns the delay before the given retry attempt (starting
+// at 1) according to the policy.
+func RetryBackoff(policy *corev1.TaskRetryPolicy, attempt int32) time.Duration {
+	initial := defaultInitialBackoff
+	if policy.GetInitialBackoff() != nil {
+		initial = policy.GetInitialBackoff().AsDuration()
+	}
+	maxBackoff := defaultMaxBackoff
+	if policy.GetMaxBackoff() != nil {
+		maxBackoff = policy.GetMaxBackoff().AsDuration()
+	}
+	multiplier := policy.GetMultiplier()
+	if multiplier < 1 {
+		multiplier = defaultBackoffMultiplier
+	}
+	delay := float64(initial) * math.Pow(multiplier, float64(attempt-1))
+	if delay > float64(maxBackoff) {
+		return maxBackoff
+	}
+	return time.Duration(delay)
+}
+
+// Records a failed attempt and schedules the next one, if the task's retry
+// policy allows it. Returns false if the task should fail instead.
+func (t *Task) scheduleRetry(err error) bool {
+	if IsPermanent(err) {
+		return false
+	}
+	t.status.Lock()
+	defer t.status.Unlock()
+	status := t.getStatus()
+	if status == nil {
+		return false
+	}
+	policy := status.GetRetryPolicy()
+	attempts := status.GetRetry().GetAttempts() + 1
+	if attempts >= policy.GetMaxAttempts() {
+		return false
+	}
+	delay := RetryBackoff(policy, attempts)
+	now := time.Now()
+	msg := fmt.Sprintf("attempt %d of %d failed: %v (retrying in %s)", attempts, policy.GetMaxAttempts(), err, delay)
+	t.logger.With(
+		zap.Error(err),
+		zap.Int32("attempt", attempts),
+	).Warnf("task failed (retrying in %s)", delay)
+	status.Retry = &corev1.TaskRetryStatus{
+		Attempts:    attempts,
+		NextAttempt: timestamppb.New(now.Add(delay)),
+		LastError:   err.Error(),
+	}
+	status.Logs = append(status.Logs, &corev1.LogEntry{
+		Msg:       msg,
+		Level:     int32(zapcore.WarnLevel),
+		Timestamp: timestamppb.New(now),
+	})
+	t.putStatus(status)
+	return true
+}
+
+// Blocks until the next attempt of a task that is being retried is due, or
+// until ctx is done. Returns immediately if the task is not being retried.
+func (t *Task) waitForRetry(ctx context.Context) error {
+	t.status.Lock()
+	next := t.getStatus().GetRetry().GetNextAttempt()
+	t.status.Unlock()
+	if next == nil {
+		return nil
+	}
+	delay := time.Until(next.AsTime())
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
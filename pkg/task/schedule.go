@@ -0,0 +1,186 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+
+	"github.com/rancher/opni/pkg/util"
+)
+
+// Schedule determines when a recurring task should run.
+type Schedule interface {
+	// Returns the first time after t at which the task should run, or the
+	// zero time if the schedule has no more runs.
+	Next(t time.Time) time.Time
+	// Returns the schedule in a format accepted by ParseSchedule.
+	String() string
+}
+
+type intervalSchedule struct {
+	interval time.Duration
+}
+
+// Every returns a schedule which runs at a fixed interval. Panics if the
+// interval is not positive.
+func Every(interval time.Duration) Schedule {
+	if interval <= 0 {
+		panic("schedule interval must be positive")
+	}
+	return intervalSchedule{interval: interval}
+}
+
+func (s intervalSchedule) Next(t time.Time) time.Time {
+	return t.Add(s.interval)
+}
+
+func (s intervalSchedule) String() string {
+	return "@every " + s.interval.String()
+}
+
+// ParseSchedule parses a schedule in one of the following formats:
+//   - a standard 5-field cron expression (minute, hour, day of month, month,
+//     day of week), e.g. "30 3 * * 1-5". Fields may contain lists, ranges,
+//     steps, and three-letter month and day names.
+//   - "@every <duration>", e.g. "@every 1h30m"
+//   - one of @yearly, @annually, @monthly, @weekly, @daily, @midnight or
+//     @hourly
+//
+// Cron expressions are evaluated in the time zone of the time passed to Next,
+// unless the expression is prefixed with "TZ=<location> ".
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "@every ") {
+		// parsed here instead of by the cron library, which rounds intervals
+		// to the second and accepts intervals that are not positive
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("invalid schedule %q: interval must be positive", spec)
+		}
+		return Every(d), nil
+	}
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+	}
+	return cronSchedule{
+		spec:     spec,
+		schedule: schedule,
+	}, nil
+}
+
+type cronSchedule struct {
+	spec     string
+	schedule cron.Schedule
+}
+
+func (s cronSchedule) Next(t time.Time) time.Time {
+	return s.schedule.Next(t)
+}
+
+func (s cronSchedule) String() string {
+	return s.spec
+}
+
+// ScheduleTask launches the task with the given id each time the schedule
+// fires, until the controller's context is done or UnscheduleTask is called.
+// The options are applied to each run.
+//
+// The time of the last run is saved with the task's status, so if a run was
+// missed while the controller was not running, the task will be run as soon
+// as it is scheduled again. If the previous run has not finished by the time
+// the next one is due, the next run is skipped. Scheduling a task with an id
+// that is already scheduled replaces the previous schedule.
+func (c *Controller) ScheduleTask(id string, schedule Schedule, opts ...NewTaskOption) error {
+	if err := c.ctx.Err(); err != nil {
+		return fmt.Errorf("cannot schedule task: %w (controller context)", err)
+	}
+	c.schedulesMu.Lock()
+	defer c.schedulesMu.Unlock()
+	if cancel, ok := c.schedules[id]; ok {
+		cancel()
+	}
+	ctx, cancel := context.WithCancel(c.ctx)
+	c.schedules[id] = cancel
+	go c.runSchedule(ctx, id, schedule, opts)
+	return nil
+}
+
+// UnscheduleTask stops launching new runs of a recurring task. If a run is
+// in progress, it is not canceled.
+func (c *Controller) UnscheduleTask(id string) {
+	c.schedulesMu.Lock()
+	defer c.schedulesMu.Unlock()
+	if cancel, ok := c.schedules[id]; ok {
+		cancel()
+	}
+	delete(c.schedules, id)
+}
+
+func (c *Controller) runSchedule(ctx context.Context, id string, schedule Schedule, opts []NewTaskOption) {
+	lg := c.logger.With(
+		zap.String("id", id),
+		zap.String("schedule", schedule.String()),
+	)
+	started := time.Now()
+	var skipped time.Time
+	for {
+		status, err := c.TaskStatus(id)
+		if err != nil && util.StatusCode(err) != codes.NotFound {
+			lg.With(
+				zap.Error(err),
+			).Warn("failed to look up task (will retry)")
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		from := started
+		if lastRun := status.GetSchedule().GetLastRun(); lastRun != nil {
+			from = lastRun.AsTime()
+		}
+		if skipped.After(from) {
+			from = skipped
+		}
+		next := schedule.Next(from)
+		if next.IsZero() {
+			lg.Warn("schedule has no upcoming runs")
+			return
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		status, err = c.TaskStatus(id)
+		if err == nil && !IsDone(status.GetState()) {
+			lg.With(
+				zap.String("state", status.GetState().String()),
+			).Warn("previous run has not finished, skipping scheduled run")
+			skipped = time.Now()
+			continue
+		}
+		runOpts := append(opts[:len(opts):len(opts)], withScheduledRun(schedule.String(), time.Now()))
+		if err := c.LaunchTask(id, runOpts...); err != nil {
+			lg.With(
+				zap.Error(err),
+			).Warn("failed to launch scheduled run")
+			skipped = time.Now()
+			continue
+		}
+	}
+}
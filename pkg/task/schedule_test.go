@@ -0,0 +1,167 @@
+package task_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	"github.com/rancher/opni/pkg/task"
+	"github.com/rancher/opni/pkg/test"
+	"github.com/rancher/opni/pkg/util"
+)
+
+var _ = Describe("Schedules", Label("unit"), func() {
+	// Saturday
+	base := time.Date(2023, time.January, 7, 10, 7, 30, 0, time.UTC)
+
+	DescribeTable("computing the next run",
+		func(spec string, expected time.Time) {
+			schedule, err := task.ParseSchedule(spec)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(schedule.String()).To(Equal(spec))
+			Expect(schedule.Next(base)).To(Equal(expected))
+		},
+		Entry("every minute", "* * * * *", time.Date(2023, time.January, 7, 10, 8, 0, 0, time.UTC)),
+		Entry("steps", "*/15 * * * *", time.Date(2023, time.January, 7, 10, 15, 0, 0, time.UTC)),
+		Entry("daily", "0 3 * * *", time.Date(2023, time.January, 8, 3, 0, 0, 0, time.UTC)),
+		Entry("day of week names", "30 9 * * mon-fri", time.Date(2023, time.January, 9, 9, 30, 0, 0, time.UTC)),
+		Entry("sunday by name", "0 12 * * sun", time.Date(2023, time.January, 8, 12, 0, 0, 0, time.UTC)),
+		Entry("lists", "0 0 1,15 * *", time.Date(2023, time.January, 15, 0, 0, 0, 0, time.UTC)),
+		Entry("month names", "0 0 1 mar *", time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC)),
+		Entry("day of month or day of week", "0 0 20 * fri", time.Date(2023, time.January, 13, 0, 0, 0, 0, time.UTC)),
+		Entry("leap days", "0 0 29 2 *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)),
+		Entry("@hourly", "@hourly", time.Date(2023, time.January, 7, 11, 0, 0, 0, time.UTC)),
+		Entry("@weekly", "@weekly", time.Date(2023, time.January, 8, 0, 0, 0, 0, time.UTC)),
+		Entry("@every", "@every 1h30m0s", base.Add(90*time.Minute)),
+	)
+
+	DescribeTable("invalid schedules",
+		func(spec string) {
+			_, err := task.ParseSchedule(spec)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry(nil, ""),
+		Entry(nil, "* * * *"),
+		Entry(nil, "60 * * * *"),
+		Entry(nil, "* 24 * * *"),
+		Entry(nil, "* * 0 * *"),
+		Entry(nil, "5-1 * * * *"),
+		Entry(nil, "*/0 * * * *"),
+		Entry(nil, "* * * foo *"),
+		Entry(nil, "@fortnightly"),
+		Entry(nil, "@every"),
+		Entry(nil, "@every -1m"),
+	)
+
+	It("should not find runs for impossible dates", func() {
+		schedule, err := task.ParseSchedule("0 0 30 2 *")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(schedule.Next(base)).To(BeZero())
+	})
+})
+
+var _ = Describe("Scheduled Tasks", Ordered, func() {
+	var store task.KVStore
+
+	BeforeAll(func() {
+		store = test.NewTestKeyValueStore(ctrl, util.ProtoClone[*corev1.TaskStatus])
+	})
+
+	lastRun := func(id string) time.Time {
+		status, err := store.Get(context.Background(), "/tasks/scheduled/"+id)
+		if err != nil || status.GetSchedule().GetLastRun() == nil {
+			return time.Time{}
+		}
+		return status.GetSchedule().GetLastRun().AsTime()
+	}
+
+	It("should run tasks on a schedule", func() {
+		runner := &FlakyTaskRunner{}
+		controller, err := task.NewController(context.Background(), "scheduled", store, runner)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(controller.ScheduleTask("sched1", task.Every(100*time.Millisecond))).To(Succeed())
+		Consistently(runner.Attempts, 50*time.Millisecond, 10*time.Millisecond).Should(BeZero())
+		Eventually(runner.Attempts).Should(BeNumerically(">=", 2))
+
+		status, err := store.Get(context.Background(), "/tasks/scheduled/sched1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.GetSchedule().GetSpec()).To(Equal("@every 100ms"))
+		Expect(lastRun("sched1")).To(BeTemporally("~", time.Now(), time.Second))
+
+		By("unscheduling the task")
+		controller.UnscheduleTask("sched1")
+		attempts := runner.Attempts()
+		Consistently(runner.Attempts, 300*time.Millisecond).Should(Equal(attempts))
+	})
+
+	It("should keep the last run time when the controller is restarted", func() {
+		runner := &FlakyTaskRunner{}
+		ctx, ca := context.WithCancel(context.Background())
+		defer ca()
+		_, err := task.NewController(ctx, "scheduled", store, runner)
+		Expect(err).NotTo(HaveOccurred())
+
+		status, err := store.Get(context.Background(), "/tasks/scheduled/sched1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.GetState()).To(Equal(corev1.TaskState_Completed))
+		Expect(status.GetSchedule()).NotTo(BeNil())
+	})
+
+	It("should run missed tasks as soon as they are scheduled", func() {
+		Expect(store.Put(context.Background(), "/tasks/scheduled/sched2", &corev1.TaskStatus{
+			State: corev1.TaskState_Completed,
+			Schedule: &corev1.TaskSchedule{
+				Spec:    "@every 30m0s",
+				LastRun: timestamppb.New(time.Now().Add(-time.Hour)),
+			},
+		})).To(Succeed())
+
+		runner := &FlakyTaskRunner{}
+		ctx, ca := context.WithCancel(context.Background())
+		defer ca()
+		controller, err := task.NewController(ctx, "scheduled", store, runner)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(controller.ScheduleTask("sched2", task.Every(30*time.Minute))).To(Succeed())
+
+		Eventually(runner.Attempts).Should(BeEquivalentTo(1))
+		Eventually(func() time.Time {
+			return lastRun("sched2")
+		}).Should(BeTemporally("~", time.Now(), time.Second))
+		Consistently(runner.Attempts, 200*time.Millisecond).Should(BeEquivalentTo(1))
+	})
+
+	It("should skip runs while the previous run has not finished", func() {
+		input := make(chan any)
+		ctx, ca := context.WithCancel(context.Background())
+		defer ca()
+		controller, err := task.NewController(ctx, "scheduled", store, &SampleTaskRunner{
+			Input: input,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		endState := make(chan task.State, 10)
+		Expect(controller.ScheduleTask("sched3", task.Every(50*time.Millisecond), task.WithMetadata(SampleTaskConfig{
+			Limit: 1,
+		}), task.WithStateCallback(endState))).To(Succeed())
+
+		Eventually(func() time.Time {
+			return lastRun("sched3")
+		}).ShouldNot(BeZero())
+		first := lastRun("sched3")
+
+		By("waiting for several scheduled runs to be skipped")
+		time.Sleep(200 * time.Millisecond)
+		Expect(lastRun("sched3")).To(Equal(first))
+
+		By("finishing the current run")
+		Eventually(input).Should(BeSent("foo"))
+		Eventually(endState).Should(Receive(Equal(corev1.TaskState_Completed)))
+		Eventually(func() time.Time {
+			return lastRun("sched3")
+		}).Should(BeTemporally(">", first))
+	})
+})
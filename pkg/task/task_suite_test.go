@@ -3,6 +3,8 @@ package task_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync/atomic"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -75,3 +77,36 @@ func (a *SampleTaskRunner) OnTaskCompleted(ctx context.Context, ti task.ActiveTa
 		ti.AddLogEntry(zapcore.WarnLevel, "canceled")
 	}
 }
+
+// FlakyTaskRunner fails a number of times before succeeding.
+type FlakyTaskRunner struct {
+	// Number of attempts which fail before the task succeeds
+	Failures int32
+	// If set, failures are wrapped with task.Permanent
+	Permanent bool
+
+	attempts atomic.Int32
+}
+
+func (r *FlakyTaskRunner) Attempts() int32 {
+	return r.attempts.Load()
+}
+
+func (r *FlakyTaskRunner) OnTaskPending(ctx context.Context, ti task.ActiveTask) error {
+	return nil
+}
+
+func (r *FlakyTaskRunner) OnTaskRunning(ctx context.Context, ti task.ActiveTask) error {
+	n := r.attempts.Add(1)
+	if n <= r.Failures {
+		err := fmt.Errorf("attempt %d failed", n)
+		if r.Permanent {
+			return task.Permanent(err)
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *FlakyTaskRunner) OnTaskCompleted(ctx context.Context, ti task.ActiveTask, state task.State, args ...any) {
+}
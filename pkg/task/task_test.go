@@ -2,12 +2,19 @@ package task_test
 
 import (
 	"context"
+	"errors"
+	"sync/atomic"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	"github.com/rancher/opni/pkg/storage"
 	"github.com/rancher/opni/pkg/util"
 
 	"github.com/rancher/opni/pkg/task"
@@ -173,4 +180,182 @@ var _ = Describe("Task", Ordered, func() {
 			Expect(status.Progress.GetTotal()).To(BeEquivalentTo(2))
 		})
 	})
+	When("cancellation of a task is requested through the store", func() {
+		It("should cancel the running task", func() {
+			input := make(chan any)
+			controller := newController(context.Background(), input)
+			endState := make(chan task.State, 1)
+			err := controller.LaunchTask("test5", task.WithMetadata(SampleTaskConfig{
+				Limit: 2,
+			}), task.WithStateCallback(endState))
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(input).Should(BeSent("foo"))
+
+			By("requesting cancellation")
+			status, err := store.Get(context.Background(), "/tasks/test/test5")
+			Expect(err).NotTo(HaveOccurred())
+			status.CancelRequested = true
+			Expect(store.Put(context.Background(), "/tasks/test/test5", status)).To(Succeed())
+
+			select {
+			case state := <-endState:
+				Expect(state).To(Equal(corev1.TaskState_Canceled))
+			case <-time.After(10 * time.Second):
+				Fail("timeout waiting for task to cancel")
+			}
+
+			By("restarting the task by using the same ID")
+			err = controller.LaunchTask("test5", task.WithMetadata(SampleTaskConfig{
+				Limit: 2,
+			}), task.WithStateCallback(endState))
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(input).Should(BeSent("bar"))
+			select {
+			case state := <-endState:
+				Expect(state).To(Equal(corev1.TaskState_Completed))
+			case <-time.After(10 * time.Second):
+				Fail("timeout waiting for task to complete")
+			}
+			status, err = store.Get(context.Background(), "/tasks/test/test5")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status.CancelRequested).To(BeFalse())
+		})
+		It("should not resume the task when the controller is restarted", func() {
+			Expect(store.Put(context.Background(), "/tasks/test/test6", &corev1.TaskStatus{
+				State:           corev1.TaskState_Running,
+				CancelRequested: true,
+			})).To(Succeed())
+
+			input := make(chan any)
+			newController(context.Background(), input)
+
+			status, err := store.Get(context.Background(), "/tasks/test/test6")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status.State).To(Equal(corev1.TaskState_Canceled))
+			Expect(status.Transitions).To(HaveLen(1))
+			Expect(status.Logs).To(HaveLen(1))
+			Consistently(input).ShouldNot(BeSent("foo"))
+		})
+	})
+	When("a task has a retry policy", func() {
+		policy := &corev1.TaskRetryPolicy{
+			MaxAttempts:    3,
+			InitialBackoff: durationpb.New(10 * time.Millisecond),
+		}
+		newRetryController := func(runner task.TaskRunner) *task.Controller {
+			controller, err := task.NewController(context.Background(), "retry", store, runner)
+			Expect(err).NotTo(HaveOccurred())
+			return controller
+		}
+		It("should retry failed attempts", func() {
+			runner := &FlakyTaskRunner{Failures: 2}
+			controller := newRetryController(runner)
+			endState := make(chan task.State, 1)
+			err := controller.LaunchTask("retry1", task.WithRetryPolicy(policy), task.WithStateCallback(endState))
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(endState).Should(Receive(Equal(corev1.TaskState_Completed)))
+			Expect(runner.Attempts()).To(BeEquivalentTo(3))
+
+			status, err := store.Get(context.Background(), "/tasks/retry/retry1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status.GetRetry().GetAttempts()).To(BeEquivalentTo(2))
+			Expect(status.GetRetry().GetLastError()).To(Equal("attempt 2 failed"))
+			Expect(status.GetLogs()).To(HaveLen(2))
+			Expect(status.GetLogs()[0].GetMsg()).To(HavePrefix("attempt 1 of 3 failed: attempt 1 failed"))
+		})
+		It("should fail once the maximum number of attempts is reached", func() {
+			runner := &FlakyTaskRunner{Failures: 5}
+			controller := newRetryController(runner)
+			endState := make(chan task.State, 1)
+			err := controller.LaunchTask("retry2", task.WithRetryPolicy(policy), task.WithStateCallback(endState))
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(endState).Should(Receive(Equal(corev1.TaskState_Failed)))
+			Expect(runner.Attempts()).To(BeEquivalentTo(3))
+
+			By("restarting the task by using the same ID")
+			err = controller.LaunchTask("retry2", task.WithStateCallback(endState))
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(endState).Should(Receive(Equal(corev1.TaskState_Failed)))
+			Expect(runner.Attempts()).To(BeEquivalentTo(4))
+			status, err := store.Get(context.Background(), "/tasks/retry/retry2")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status.GetRetryPolicy()).To(BeNil())
+			Expect(status.GetRetry()).To(BeNil())
+		})
+		It("should not retry permanent errors", func() {
+			runner := &FlakyTaskRunner{Failures: 1, Permanent: true}
+			controller := newRetryController(runner)
+			endState := make(chan task.State, 1)
+			err := controller.LaunchTask("retry3", task.WithRetryPolicy(policy), task.WithStateCallback(endState))
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(endState).Should(Receive(Equal(corev1.TaskState_Failed)))
+			Expect(runner.Attempts()).To(BeEquivalentTo(1))
+		})
+		It("should continue retrying when the controller is restarted", func() {
+			nextAttempt := time.Now().Add(500 * time.Millisecond)
+			Expect(store.Put(context.Background(), "/tasks/retry/retry4", &corev1.TaskStatus{
+				State:       corev1.TaskState_Pending,
+				RetryPolicy: policy,
+				Retry: &corev1.TaskRetryStatus{
+					Attempts:    2,
+					NextAttempt: timestamppb.New(nextAttempt),
+					LastError:   "attempt 2 failed",
+				},
+			})).To(Succeed())
+
+			runner := &FlakyTaskRunner{}
+			newRetryController(runner)
+			Consistently(runner.Attempts, 250*time.Millisecond).Should(BeZero())
+			Eventually(func() corev1.TaskState {
+				status, err := store.Get(context.Background(), "/tasks/retry/retry4")
+				Expect(err).NotTo(HaveOccurred())
+				return status.GetState()
+			}).Should(Equal(corev1.TaskState_Completed))
+			Expect(runner.Attempts()).To(BeEquivalentTo(1))
+			Expect(time.Now()).To(BeTemporally(">=", nextAttempt))
+
+			status, err := store.Get(context.Background(), "/tasks/retry/retry4")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(status.GetRetry().GetAttempts()).To(BeEquivalentTo(2))
+		})
+	})
+})
+
+type failingWatchStore struct {
+	task.KVStore
+	err     error
+	watches atomic.Int32
+}
+
+func (s *failingWatchStore) Watch(context.Context, string) (<-chan storage.WatchEvent[storage.KeyRevision[*corev1.TaskStatus]], error) {
+	s.watches.Add(1)
+	return nil, s.err
+}
+
+var _ = Describe("Cancellation Requests", func() {
+	newStore := func(err error) *failingWatchStore {
+		return &failingWatchStore{
+			KVStore: test.NewTestKeyValueStore(ctrl, util.ProtoClone[*corev1.TaskStatus]),
+			err:     err,
+		}
+	}
+	startController := func(store task.KVStore) {
+		ctx, ca := context.WithCancel(context.Background())
+		DeferCleanup(ca)
+		_, err := task.NewController(ctx, "test", store, &SampleTaskRunner{})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	It("should back off when the watch fails", func() {
+		store := newStore(errors.New("watch failed"))
+		startController(store)
+		// attempts at 0s, 1s, 3s
+		Consistently(store.watches.Load, 2500*time.Millisecond).Should(BeNumerically("<=", 2))
+	})
+	It("should stop watching if the store does not support watches", func() {
+		store := newStore(status.Error(codes.Unimplemented, "unimplemented"))
+		startController(store)
+		Eventually(store.watches.Load).Should(BeEquivalentTo(1))
+		Consistently(store.watches.Load, 1500*time.Millisecond).Should(BeEquivalentTo(1))
+	})
 })
@@ -516,8 +516,18 @@ func (e *Environment) StartEmbeddedJetstream() (*nats.Conn, error) {
 	opts := natstest.DefaultTestOptions
 	opts.Port = ports[0]
 
+	// use a new store directory, so that streams don't persist between tests
+	if e.tempDir == "" {
+		e.tempDir, err = os.MkdirTemp("", "opni-test-*")
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	e.embeddedJS = natstest.RunServer(&opts)
-	e.embeddedJS.EnableJetStream(nil)
+	e.embeddedJS.EnableJetStream(&natsserver.JetStreamConfig{
+		StoreDir: path.Join(e.tempDir, "jetstream-embedded"),
+	})
 	if !e.embeddedJS.ReadyForConnections(2 * time.Second) {
 		return nil, errors.New("starting nats server: timeout")
 	}
@@ -1437,6 +1447,8 @@ func (e *Environment) startGateway() {
 	m := management.NewServer(e.ctx, &e.gatewayConfig.Spec.Management, g, pluginLoader,
 		management.WithCapabilitiesDataSource(g),
 		management.WithHealthStatusDataSource(g),
+		management.WithKeyringDataSource(g),
+		management.WithTasksDataSource(g),
 		management.WithLifecycler(lifecycler),
 	)
 
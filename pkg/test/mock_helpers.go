@@ -43,6 +43,8 @@ type CapabilityInfo struct {
 	CanInstall        bool
 	InstallerTemplate string
 	Storage           storage.ClusterStore
+	Dependencies      []string
+	Conflicts         []string
 }
 
 func (ci *CapabilityInfo) canInstall() error {
@@ -60,9 +62,11 @@ func NewTestCapabilityBackend(
 	client.EXPECT().
 		Info(gomock.Any(), gomock.Any(), gomock.Any()).
 		Return(&capabilityv1.Details{
-			Name:    capBackend.Name,
-			Source:  "mock",
-			Drivers: []string{"test"},
+			Name:         capBackend.Name,
+			Source:       "mock",
+			Drivers:      []string{"test"},
+			Dependencies: capBackend.Dependencies,
+			Conflicts:    capBackend.Conflicts,
 		}, nil).
 		AnyTimes()
 	client.EXPECT().
@@ -73,21 +77,23 @@ func NewTestCapabilityBackend(
 		AnyTimes()
 	client.EXPECT().
 		Install(gomock.Any(), gomock.Any(), gomock.Any()).
-		DoAndReturn(func(ctx context.Context, req *capabilityv1.InstallRequest, _ ...grpc.CallOption) (*emptypb.Empty, error) {
+		DoAndReturn(func(ctx context.Context, req *capabilityv1.InstallRequest, _ ...grpc.CallOption) (*capabilityv1.InstallResponse, error) {
 			_, err := capBackend.Storage.UpdateCluster(ctx, req.Cluster,
-				storage.NewAddCapabilityMutator[*corev1.Cluster](capabilities.Cluster("test")),
+				storage.NewAddCapabilityMutator[*corev1.Cluster](capabilities.Cluster(capBackend.Name)),
 			)
 			if err != nil {
 				return nil, err
 			}
-			return &emptypb.Empty{}, nil
+			return &capabilityv1.InstallResponse{
+				Status: capabilityv1.InstallResponseStatus_Success,
+			}, nil
 		}).
 		AnyTimes()
 	client.EXPECT().
 		Uninstall(gomock.Any(), gomock.Any(), gomock.Any()).
 		DoAndReturn(func(ctx context.Context, req *capabilityv1.UninstallRequest, _ ...grpc.CallOption) (*emptypb.Empty, error) {
 			_, err := capBackend.Storage.UpdateCluster(ctx, req.Cluster,
-				storage.NewRemoveCapabilityMutator[*corev1.Cluster](capabilities.Cluster("test")))
+				storage.NewRemoveCapabilityMutator[*corev1.Cluster](capabilities.Cluster(capBackend.Name)))
 			if err != nil {
 				return nil, err
 			}
@@ -102,7 +108,7 @@ func NewTestCapabilityBackend(
 				return nil, err
 			}
 			for _, cap := range c.GetCapabilities() {
-				if cap.Name == "test" {
+				if cap.Name == capBackend.Name {
 					if cap.DeletionTimestamp != nil {
 						return &corev1.TaskStatus{
 							State: corev1.TaskState_Running,
@@ -510,26 +516,74 @@ func NewTestKeyValueStoreBroker(ctrl *gomock.Controller) storage.KeyValueStoreBr
 func NewTestKeyValueStore[T any](ctrl *gomock.Controller, clone func(T) T) storage.KeyValueStoreT[T] {
 	mockKvStore := mock_storage.NewMockKeyValueStoreT[T](ctrl)
 	mu := sync.Mutex{}
-	kvs := map[string]T{}
+	kvs := map[string]storage.KeyRevision[T]{}
+	var revision int64
+	type watcher struct {
+		ctx    context.Context
+		prefix string
+		eventC chan storage.WatchEvent[storage.KeyRevision[T]]
+	}
+	watchers := map[*watcher]struct{}{}
+	// must be called with mu held
+	notify := func(event storage.WatchEvent[storage.KeyRevision[T]]) {
+		key := event.Current.Key
+		if event.EventType == storage.WatchEventDelete {
+			key = event.Previous.Key
+		}
+		for w := range watchers {
+			if !strings.HasPrefix(key, w.prefix) {
+				continue
+			}
+			select {
+			case <-w.ctx.Done():
+			case w.eventC <- event:
+			}
+		}
+	}
 	mockKvStore.EXPECT().
-		Put(gomock.Any(), gomock.Any(), gomock.Any()).
-		DoAndReturn(func(_ context.Context, key string, value T) error {
+		Put(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, key string, value T, opts ...storage.PutOpt) error {
+			options := storage.PutOptions{}
+			options.Apply(opts...)
 			mu.Lock()
 			defer mu.Unlock()
-			kvs[key] = clone(value)
+			prev, ok := kvs[key]
+			if options.Revision != nil && *options.Revision != prev.Revision {
+				return storage.ErrConflict
+			}
+			revision++
+			current := storage.KeyRevision[T]{Key: key, Value: clone(value), Revision: revision}
+			kvs[key] = current
+			if options.RevisionOut != nil {
+				*options.RevisionOut = revision
+			}
+			event := storage.WatchEvent[storage.KeyRevision[T]]{
+				EventType: storage.WatchEventCreate,
+				Current:   storage.KeyRevision[T]{Key: key, Value: clone(value), Revision: revision},
+			}
+			if ok {
+				event.EventType = storage.WatchEventUpdate
+				event.Previous = prev
+			}
+			notify(event)
 			return nil
 		}).
 		AnyTimes()
 	mockKvStore.EXPECT().
-		Get(gomock.Any(), gomock.Any()).
-		DoAndReturn(func(_ context.Context, key string) (T, error) {
+		Get(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, key string, opts ...storage.GetOpt) (T, error) {
+			options := storage.GetOptions{}
+			options.Apply(opts...)
 			mu.Lock()
 			defer mu.Unlock()
 			v, ok := kvs[key]
 			if !ok {
 				return lo.Empty[T](), storage.ErrNotFound
 			}
-			return clone(v), nil
+			if options.RevisionOut != nil {
+				*options.RevisionOut = v.Revision
+			}
+			return clone(v.Value), nil
 		}).
 		AnyTimes()
 	mockKvStore.EXPECT().
@@ -537,7 +591,14 @@ func NewTestKeyValueStore[T any](ctrl *gomock.Controller, clone func(T) T) stora
 		DoAndReturn(func(_ context.Context, key string) error {
 			mu.Lock()
 			defer mu.Unlock()
+			prev, ok := kvs[key]
 			delete(kvs, key)
+			if ok {
+				notify(storage.WatchEvent[storage.KeyRevision[T]]{
+					EventType: storage.WatchEventDelete,
+					Previous:  prev,
+				})
+			}
 			return nil
 		}).
 		AnyTimes()
@@ -555,6 +616,27 @@ func NewTestKeyValueStore[T any](ctrl *gomock.Controller, clone func(T) T) stora
 			return keys, nil
 		}).
 		AnyTimes()
+	mockKvStore.EXPECT().
+		Watch(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, prefix string) (<-chan storage.WatchEvent[storage.KeyRevision[T]], error) {
+			mu.Lock()
+			defer mu.Unlock()
+			w := &watcher{
+				ctx:    ctx,
+				prefix: prefix,
+				eventC: make(chan storage.WatchEvent[storage.KeyRevision[T]], 64),
+			}
+			watchers[w] = struct{}{}
+			go func() {
+				<-ctx.Done()
+				mu.Lock()
+				defer mu.Unlock()
+				delete(watchers, w)
+				close(w.eventC)
+			}()
+			return w.eventC, nil
+		}).
+		AnyTimes()
 	return mockKvStore
 }
 
@@ -692,11 +774,15 @@ func NewTestTokenStore(ctx context.Context, ctrl *gomock.Controller) storage.Tok
 			t := tokens.NewToken().ToBootstrapToken()
 			lease := leaseStore.New(t.TokenID, ttl)
 			t.Metadata = &corev1.BootstrapTokenMetadata{
-				LeaseID:      int64(lease.ID),
-				Ttl:          int64(ttl),
-				UsageCount:   0,
-				Labels:       options.Labels,
-				Capabilities: options.Capabilities,
+				LeaseID:           int64(lease.ID),
+				Ttl:               int64(ttl),
+				UsageCount:        0,
+				Labels:            options.Labels,
+				Capabilities:      options.Capabilities,
+				MaxUsages:         options.MaxUsages,
+				OneShot:           options.OneShot,
+				AllowedClusterIds: options.AllowedClusterIds,
+				AllowedLabels:     options.AllowedLabels,
 			}
 			tks[t.TokenID] = t
 			return t, nil
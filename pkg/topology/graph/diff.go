@@ -0,0 +1,199 @@
+package graph
+
+import (
+	"reflect"
+	"sort"
+
+	kgraph "github.com/steveteuber/kubectl-graph/pkg/graph"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Fields of a node which are compared when diffing graphs
+const (
+	FieldLabels          = "labels"
+	FieldAnnotations     = "annotations"
+	FieldOwnerReferences = "ownerReferences"
+	FieldGeneration      = "generation"
+	FieldDeletion        = "deletionTimestamp"
+)
+
+// NodeChange is a node which exists in both graphs, but with different
+// metadata.
+type NodeChange struct {
+	Old, New *kgraph.Node
+	// Names of the fields which differ between the old and new node
+	Fields []string
+}
+
+// EdgeChange is a relationship which exists in both graphs, but with a
+// different label or attributes.
+type EdgeChange struct {
+	Old, New *kgraph.Relationship
+}
+
+// GraphDiff contains the nodes and relationships which were added, removed
+// or changed between two kubectl graphs.
+type GraphDiff struct {
+	AddedNodes   []*kgraph.Node
+	RemovedNodes []*kgraph.Node
+	ChangedNodes []NodeChange
+
+	AddedEdges   []*kgraph.Relationship
+	RemovedEdges []*kgraph.Relationship
+	ChangedEdges []EdgeChange
+}
+
+func (d *GraphDiff) IsEmpty() bool {
+	return len(d.AddedNodes) == 0 &&
+		len(d.RemovedNodes) == 0 &&
+		len(d.ChangedNodes) == 0 &&
+		len(d.AddedEdges) == 0 &&
+		len(d.RemovedEdges) == 0 &&
+		len(d.ChangedEdges) == 0
+}
+
+type edgeKey struct {
+	from, to types.UID
+}
+
+// Diff compares two kubectl graphs. Nodes are matched by their kubernetes
+// UID, and relationships by the UIDs of the nodes they connect. Either
+// graph may be nil, in which case it is treated as empty.
+func Diff(before, after *kgraph.Graph) *GraphDiff {
+	diff := &GraphDiff{}
+	oldNodes, newNodes := nodesOf(before), nodesOf(after)
+	for uid, newNode := range newNodes {
+		oldNode, ok := oldNodes[uid]
+		if !ok {
+			diff.AddedNodes = append(diff.AddedNodes, newNode)
+			continue
+		}
+		if fields := changedFields(oldNode, newNode); len(fields) > 0 {
+			diff.ChangedNodes = append(diff.ChangedNodes, NodeChange{
+				Old:    oldNode,
+				New:    newNode,
+				Fields: fields,
+			})
+		}
+	}
+	for uid, oldNode := range oldNodes {
+		if _, ok := newNodes[uid]; !ok {
+			diff.RemovedNodes = append(diff.RemovedNodes, oldNode)
+		}
+	}
+
+	oldEdges, newEdges := edgesOf(before), edgesOf(after)
+	for key, newEdge := range newEdges {
+		oldEdge, ok := oldEdges[key]
+		if !ok {
+			diff.AddedEdges = append(diff.AddedEdges, newEdge)
+			continue
+		}
+		if oldEdge.Label != newEdge.Label || !attrsEqual(oldEdge.Attr, newEdge.Attr) {
+			diff.ChangedEdges = append(diff.ChangedEdges, EdgeChange{
+				Old: oldEdge,
+				New: newEdge,
+			})
+		}
+	}
+	for key, oldEdge := range oldEdges {
+		if _, ok := newEdges[key]; !ok {
+			diff.RemovedEdges = append(diff.RemovedEdges, oldEdge)
+		}
+	}
+
+	diff.sort()
+	return diff
+}
+
+func nodesOf(g *kgraph.Graph) map[types.UID]*kgraph.Node {
+	nodes := map[types.UID]*kgraph.Node{}
+	if g == nil {
+		return nodes
+	}
+	for _, node := range g.Nodes {
+		if node != nil {
+			nodes[node.UID] = node
+		}
+	}
+	return nodes
+}
+
+func edgesOf(g *kgraph.Graph) map[edgeKey]*kgraph.Relationship {
+	edges := map[edgeKey]*kgraph.Relationship{}
+	if g == nil {
+		return edges
+	}
+	for _, rels := range g.Relationships {
+		for _, rel := range rels {
+			if rel != nil {
+				edges[edgeKey{from: rel.From, to: rel.To}] = rel
+			}
+		}
+	}
+	return edges
+}
+
+func changedFields(before, after *kgraph.Node) []string {
+	var fields []string
+	if !attrsEqual(before.Labels, after.Labels) {
+		fields = append(fields, FieldLabels)
+	}
+	if !attrsEqual(before.Annotations, after.Annotations) {
+		fields = append(fields, FieldAnnotations)
+	}
+	if !(len(before.OwnerReferences) == 0 && len(after.OwnerReferences) == 0) &&
+		!reflect.DeepEqual(before.OwnerReferences, after.OwnerReferences) {
+		fields = append(fields, FieldOwnerReferences)
+	}
+	if before.Generation != after.Generation {
+		fields = append(fields, FieldGeneration)
+	}
+	if (before.DeletionTimestamp == nil) != (after.DeletionTimestamp == nil) {
+		fields = append(fields, FieldDeletion)
+	}
+	return fields
+}
+
+// attrsEqual compares two string maps, treating nil and empty maps as equal.
+func attrsEqual(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if bv, ok := b[k]; !ok || bv != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *GraphDiff) sort() {
+	sortNodes := func(nodes []*kgraph.Node) {
+		sort.Slice(nodes, func(i, j int) bool {
+			return nodes[i].UID < nodes[j].UID
+		})
+	}
+	sortEdges := func(edges []*kgraph.Relationship) {
+		sort.Slice(edges, func(i, j int) bool {
+			if edges[i].From != edges[j].From {
+				return edges[i].From < edges[j].From
+			}
+			return edges[i].To < edges[j].To
+		})
+	}
+	sortNodes(d.AddedNodes)
+	sortNodes(d.RemovedNodes)
+	sort.Slice(d.ChangedNodes, func(i, j int) bool {
+		return d.ChangedNodes[i].New.UID < d.ChangedNodes[j].New.UID
+	})
+	sortEdges(d.AddedEdges)
+	sortEdges(d.RemovedEdges)
+	sort.Slice(d.ChangedEdges, func(i, j int) bool {
+		a, b := d.ChangedEdges[i].New, d.ChangedEdges[j].New
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+}
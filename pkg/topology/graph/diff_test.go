@@ -0,0 +1,105 @@
+package graph_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rancher/opni/pkg/test"
+	"github.com/rancher/opni/pkg/topology/graph"
+	kgraph "github.com/steveteuber/kubectl-graph/pkg/graph"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("Graph diffs", Label(test.Unit), func() {
+	var before, after *kgraph.Graph
+
+	load := func() *kgraph.Graph {
+		var g *kgraph.Graph
+		Expect(json.Unmarshal(test.TestData("topology/graph.json"), &g)).To(Succeed())
+		return g
+	}
+
+	BeforeEach(func() {
+		before, after = load(), load()
+	})
+
+	anyNode := func(g *kgraph.Graph) *kgraph.Node {
+		for _, node := range g.NodeList() {
+			if len(node.Labels) > 0 {
+				return node
+			}
+		}
+		Fail("no nodes with labels")
+		return nil
+	}
+
+	It("should find no changes between identical graphs", func() {
+		Expect(graph.Diff(before, after).IsEmpty()).To(BeTrue())
+	})
+
+	It("should treat nil graphs as empty", func() {
+		diff := graph.Diff(nil, after)
+		Expect(diff.AddedNodes).To(HaveLen(len(after.Nodes)))
+		Expect(diff.AddedEdges).To(HaveLen(len(after.RelationshipList())))
+		Expect(diff.RemovedNodes).To(BeEmpty())
+
+		diff = graph.Diff(before, nil)
+		Expect(diff.RemovedNodes).To(HaveLen(len(before.Nodes)))
+		Expect(diff.AddedNodes).To(BeEmpty())
+	})
+
+	It("should find added and removed nodes and edges", func() {
+		removed := anyNode(after)
+		delete(after.Nodes, removed.UID)
+		var removedEdges []*kgraph.Relationship
+		for uid, rels := range after.Relationships {
+			var kept []*kgraph.Relationship
+			for _, rel := range rels {
+				if rel.From == removed.UID || rel.To == removed.UID {
+					removedEdges = append(removedEdges, rel)
+					continue
+				}
+				kept = append(kept, rel)
+			}
+			after.Relationships[uid] = kept
+		}
+
+		added := &kgraph.Node{}
+		added.Kind = "ConfigMap"
+		added.Name = "added"
+		added.UID = types.UID("00000000-0000-0000-0000-000000000000")
+		after.Nodes[added.UID] = added
+		addedEdge := &kgraph.Relationship{From: added.UID, To: anyNode(after).UID, Label: "Test"}
+		after.Relationships[added.UID] = []*kgraph.Relationship{addedEdge}
+
+		diff := graph.Diff(before, after)
+		Expect(diff.AddedNodes).To(ConsistOf(added))
+		Expect(diff.RemovedNodes).To(ConsistOf(before.Nodes[removed.UID]))
+		Expect(diff.AddedEdges).To(ConsistOf(addedEdge))
+		Expect(diff.RemovedEdges).To(HaveLen(len(removedEdges)))
+		Expect(diff.ChangedNodes).To(BeEmpty())
+		Expect(diff.ChangedEdges).To(BeEmpty())
+	})
+
+	It("should find changed nodes and edges", func() {
+		node := anyNode(after)
+		node.Labels = map[string]string{"changed": "true"}
+		node.Generation++
+
+		edge := after.RelationshipList()[0]
+		edge.Attr = map[string]string{"style": "dashed"}
+
+		diff := graph.Diff(before, after)
+		Expect(diff.AddedNodes).To(BeEmpty())
+		Expect(diff.RemovedNodes).To(BeEmpty())
+		Expect(diff.ChangedNodes).To(HaveLen(1))
+		Expect(diff.ChangedNodes[0].New).To(Equal(node))
+		Expect(diff.ChangedNodes[0].Old.UID).To(Equal(node.UID))
+		Expect(diff.ChangedNodes[0].Fields).To(ConsistOf(graph.FieldLabels, graph.FieldGeneration))
+
+		Expect(diff.ChangedEdges).To(HaveLen(1))
+		Expect(diff.ChangedEdges[0].New).To(Equal(edge))
+		Expect(diff.ChangedEdges[0].Old.Attr).To(BeEmpty())
+	})
+})
@@ -0,0 +1,82 @@
+package graph
+
+import (
+	"strings"
+
+	kgraph "github.com/steveteuber/kubectl-graph/pkg/graph"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Filter selects a subset of the objects in a kubectl graph. Empty fields
+// match all objects.
+type Filter struct {
+	// Namespaces to include. Cluster-scoped objects are excluded, except
+	// for the Namespace objects themselves.
+	Namespaces []string
+	// Kinds to include, matched case insensitively
+	Kinds []string
+	// Label selector, in the usual kubernetes syntax (e.g. "app=foo,tier!=db")
+	LabelSelector string
+}
+
+func (f Filter) IsEmpty() bool {
+	return len(f.Namespaces) == 0 && len(f.Kinds) == 0 && f.LabelSelector == ""
+}
+
+// Apply returns a new graph containing only the objects which match the
+// filter, and the relationships between them. Returns an error if the
+// label selector is invalid.
+func (f Filter) Apply(g *kgraph.Graph) (*kgraph.Graph, error) {
+	selector := labels.Everything()
+	if f.LabelSelector != "" {
+		var err error
+		selector, err = labels.Parse(f.LabelSelector)
+		if err != nil {
+			return nil, err
+		}
+	}
+	filtered := &kgraph.Graph{
+		Nodes:         map[types.UID]*kgraph.Node{},
+		Relationships: map[types.UID][]*kgraph.Relationship{},
+	}
+	for uid, node := range g.Nodes {
+		if f.matchNamespace(node) && f.matchKind(node) && selector.Matches(labels.Set(node.Labels)) {
+			filtered.Nodes[uid] = node
+		}
+	}
+	for uid, rels := range g.Relationships {
+		for _, rel := range rels {
+			_, from := filtered.Nodes[rel.From]
+			_, to := filtered.Nodes[rel.To]
+			if from && to {
+				filtered.Relationships[uid] = append(filtered.Relationships[uid], rel)
+			}
+		}
+	}
+	return filtered, nil
+}
+
+func (f Filter) matchNamespace(node *kgraph.Node) bool {
+	if len(f.Namespaces) == 0 {
+		return true
+	}
+	for _, ns := range f.Namespaces {
+		if node.Namespace == ns || (node.Kind == "Namespace" && node.Name == ns) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f Filter) matchKind(node *kgraph.Node) bool {
+	if len(f.Kinds) == 0 {
+		return true
+	}
+	for _, kind := range f.Kinds {
+		if strings.EqualFold(node.Kind, kind) {
+			return true
+		}
+	}
+	return false
+}
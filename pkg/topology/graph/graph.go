@@ -64,5 +64,34 @@ func TraverseTopology(lg *zap.SugaredLogger, f cmdutil.Factory) (*kgraph.Graph,
 		lg.Error(err)
 		return nil, err
 	}
+	LinkPodsToNodes(kubegraph, objs)
 	return kubegraph, nil
 }
+
+// LinkPodsToNodes adds a relationship from each node to the pods scheduled
+// on it, which kubectl-graph does not record, so that workloads can be found
+// from the node they run on.
+func LinkPodsToNodes(kubegraph *kgraph.Graph, objs []*unstructured.Unstructured) {
+	nodesByName := map[string]*kgraph.Node{}
+	for _, obj := range objs {
+		if obj.GetAPIVersion() != "v1" || obj.GetKind() != "Node" {
+			continue
+		}
+		if node, ok := kubegraph.Nodes[obj.GetUID()]; ok {
+			nodesByName[obj.GetName()] = node
+		}
+	}
+	for _, obj := range objs {
+		if obj.GetAPIVersion() != "v1" || obj.GetKind() != "Pod" {
+			continue
+		}
+		nodeName, _, _ := unstructured.NestedString(obj.Object, "spec", "nodeName")
+		node, ok := nodesByName[nodeName]
+		if !ok {
+			continue
+		}
+		if pod, ok := kubegraph.Nodes[obj.GetUID()]; ok {
+			kubegraph.Relationship(node, "Pod", pod)
+		}
+	}
+}
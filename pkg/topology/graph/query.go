@@ -0,0 +1,248 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gonum.org/v1/gonum/graph"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var (
+	ErrObjectNotFound     = errors.New("object not found in topology")
+	ErrAmbiguousReference = errors.New("object reference matches more than one object")
+)
+
+// Edges in a kubectl graph point from an object to the objects it owns,
+// selects or contains, e.g. Deployment -> ReplicaSet -> Pod -> Container,
+// Service -> Endpoints -> Pod, or Node -> Pod (see LinkPodsToNodes).
+type Direction int
+
+const (
+	// Both upstream and downstream objects
+	Both Direction = iota
+	// Objects which own, select or contain the object
+	Upstream
+	// Objects which the object owns, selects or contains
+	Downstream
+)
+
+// Kinds which group otherwise unrelated objects together. Undirected
+// traversals do not pass through them.
+var sharedKinds = map[string]struct{}{
+	"Cluster":   {},
+	"Namespace": {},
+	"Node":      {},
+}
+
+var workloadKinds = map[string]struct{}{
+	"Pod":         {},
+	"ReplicaSet":  {},
+	"Deployment":  {},
+	"StatefulSet": {},
+	"DaemonSet":   {},
+	"Job":         {},
+	"CronJob":     {},
+}
+
+func IsWorkload(node *ScientificKubeNode) bool {
+	_, ok := workloadKinds[node.Kind]
+	return ok
+}
+
+// ObjectReference identifies an object in the topology graph, either by its
+// UID, or by its kind, namespace and name.
+type ObjectReference struct {
+	UID       types.UID
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+func (r ObjectReference) String() string {
+	if r.UID != "" {
+		return string(r.UID)
+	}
+	if r.Namespace == "" {
+		return fmt.Sprintf("%s/%s", r.Kind, r.Name)
+	}
+	return fmt.Sprintf("%s/%s/%s", r.Kind, r.Namespace, r.Name)
+}
+
+// FindNode returns the node referenced by ref. Kinds are matched case
+// insensitively. If the kind is empty, ref must match exactly one object
+// with the given namespace and name.
+func (s *ScientificKubeGraph) FindNode(ref ObjectReference) (*ScientificKubeNode, error) {
+	if ref.UID != "" {
+		if id, ok := s.KubernetesIdsToGonumIds[ref.UID]; ok {
+			if node, ok := s.Node(id).(*ScientificKubeNode); ok {
+				return node, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, ref)
+	}
+	var found *ScientificKubeNode
+	nodes := s.Nodes()
+	for nodes.Next() {
+		node := nodes.Node().(*ScientificKubeNode)
+		if node.Name != ref.Name || node.Namespace != ref.Namespace {
+			continue
+		}
+		if ref.Kind != "" && !strings.EqualFold(node.Kind, ref.Kind) {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("%w: %s", ErrAmbiguousReference, ref)
+		}
+		found = node
+	}
+	if found == nil {
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, ref)
+	}
+	return found, nil
+}
+
+// Neighbor is an object reachable from another object in the graph.
+type Neighbor struct {
+	Node *ScientificKubeNode
+	// Number of edges between the objects
+	Depth int
+	// Whether the object is upstream or downstream of the other object
+	Direction Direction
+}
+
+// Neighbors returns the objects upstream and/or downstream of the given
+// node, up to the given depth, ordered by depth. Objects which are both
+// upstream and downstream of the node are reported in both directions.
+func (s *ScientificKubeGraph) Neighbors(node *ScientificKubeNode, depth int, dir Direction) []Neighbor {
+	var neighbors []Neighbor
+	if dir == Both || dir == Upstream {
+		neighbors = append(neighbors, s.walk(node, depth, Upstream)...)
+	}
+	if dir == Both || dir == Downstream {
+		neighbors = append(neighbors, s.walk(node, depth, Downstream)...)
+	}
+	sort.SliceStable(neighbors, func(i, j int) bool {
+		return neighbors[i].Depth < neighbors[j].Depth
+	})
+	return neighbors
+}
+
+func (s *ScientificKubeGraph) walk(start *ScientificKubeNode, depth int, dir Direction) []Neighbor {
+	var neighbors []Neighbor
+	visited := map[int64]struct{}{start.ID(): {}}
+	frontier := []graph.Node{start}
+	for d := 1; d <= depth && len(frontier) > 0; d++ {
+		var next []graph.Node
+		for _, n := range frontier {
+			for _, adj := range s.adjacent(n, dir) {
+				if _, ok := visited[adj.ID()]; ok {
+					continue
+				}
+				visited[adj.ID()] = struct{}{}
+				neighbors = append(neighbors, Neighbor{
+					Node:      adj.(*ScientificKubeNode),
+					Depth:     d,
+					Direction: dir,
+				})
+				next = append(next, adj)
+			}
+		}
+		frontier = next
+	}
+	return neighbors
+}
+
+// adjacent returns the nodes adjacent to n in the given direction, sorted
+// by ID so that traversals are deterministic.
+func (s *ScientificKubeGraph) adjacent(n graph.Node, dir Direction) []graph.Node {
+	var nodes []graph.Node
+	if dir == Both || dir == Downstream {
+		nodes = append(nodes, graph.NodesOf(s.From(n.ID()))...)
+	}
+	if dir == Both || dir == Upstream {
+		nodes = append(nodes, graph.NodesOf(s.To(n.ID()))...)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].ID() < nodes[j].ID()
+	})
+	return nodes
+}
+
+// ShortestPath returns the nodes on a shortest path between two nodes,
+// including both ends, or nil if they are not connected. If directed is
+// false, edges may be followed in either direction, but paths do not pass
+// through clusters, namespaces or nodes, since nearly every object would
+// otherwise be connected through them.
+func (s *ScientificKubeGraph) ShortestPath(from, to *ScientificKubeNode, directed bool) []*ScientificKubeNode {
+	dir := Both
+	if directed {
+		dir = Downstream
+	}
+	prev := map[int64]graph.Node{from.ID(): nil}
+	queue := []graph.Node{from}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		if n.ID() == to.ID() {
+			var path []*ScientificKubeNode
+			for ; n != nil; n = prev[n.ID()] {
+				path = append([]*ScientificKubeNode{n.(*ScientificKubeNode)}, path...)
+			}
+			return path
+		}
+		for _, adj := range s.adjacent(n, dir) {
+			if _, ok := prev[adj.ID()]; ok {
+				continue
+			}
+			prev[adj.ID()] = n
+			if _, shared := sharedKinds[adj.(*ScientificKubeNode).Kind]; shared && !directed && adj.ID() != to.ID() {
+				continue
+			}
+			queue = append(queue, adj)
+		}
+	}
+	return nil
+}
+
+// BlastRadius returns the objects which are likely to be affected if the
+// given object fails, i.e. all objects connected to it in either direction.
+// Connections through clusters, namespaces and nodes are not followed,
+// since these group objects which are otherwise unrelated, unless the
+// failing object is itself one of them. The failing object is not included.
+func (s *ScientificKubeGraph) BlastRadius(node *ScientificKubeNode) []*ScientificKubeNode {
+	var affected []*ScientificKubeNode
+	visited := map[int64]struct{}{node.ID(): {}}
+	queue := []graph.Node{node}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, adj := range s.adjacent(n, Both) {
+			if _, ok := visited[adj.ID()]; ok {
+				continue
+			}
+			visited[adj.ID()] = struct{}{}
+			if _, shared := sharedKinds[adj.(*ScientificKubeNode).Kind]; shared {
+				continue
+			}
+			affected = append(affected, adj.(*ScientificKubeNode))
+			queue = append(queue, adj)
+		}
+	}
+	return affected
+}
+
+// EdgesBetween returns all edges in the graph between the given nodes.
+func (s *ScientificKubeGraph) EdgesBetween(nodes []*ScientificKubeNode) []*ScientificKubeEdge {
+	var edges []*ScientificKubeEdge
+	for _, u := range nodes {
+		for _, v := range nodes {
+			if e, ok := s.Edge(u.ID(), v.ID()).(*ScientificKubeEdge); ok {
+				edges = append(edges, e)
+			}
+		}
+	}
+	return edges
+}
@@ -0,0 +1,195 @@
+package graph_test
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rancher/opni/pkg/test"
+	"github.com/rancher/opni/pkg/topology/graph"
+	kgraph "github.com/steveteuber/kubectl-graph/pkg/graph"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("Graph queries", Ordered, Label(test.Unit), func() {
+	var g *graph.ScientificKubeGraph
+
+	object := func(kind, uid, name, nodeName string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("v1")
+		obj.SetKind(kind)
+		obj.SetUID(types.UID(uid))
+		obj.SetName(name)
+		if nodeName != "" {
+			Expect(unstructured.SetNestedField(obj.Object, nodeName, "spec", "nodeName")).To(Succeed())
+		}
+		return obj
+	}
+
+	BeforeAll(func() {
+		var kg *kgraph.Graph
+		Expect(json.Unmarshal(test.TestData("topology/graph.json"), &kg)).To(Succeed())
+		relationships := len(kg.RelationshipList())
+		graph.LinkPodsToNodes(kg, []*unstructured.Unstructured{
+			object("Node", "35167464-449b-4e35-abdd-ee68ca7757d6", "ip-192-168-65-34.us-east-2.compute.internal", ""),
+			object("Node", "25be0c8d-221f-4f47-be70-59ffcaf7adc3", "ip-192-168-187-121.us-east-2.compute.internal", ""),
+			object("Pod", "b0f24f06-8208-47cd-8e9c-7d8b02abd421", "aws-node-k7j87", "ip-192-168-65-34.us-east-2.compute.internal"),
+			object("Pod", "1645196f-9c5e-4085-b56d-53fb698872d4", "kube-proxy-xplql", "ip-192-168-65-34.us-east-2.compute.internal"),
+			object("Pod", "01abb36e-53c0-449b-aab3-b857c6c3603b", "coredns-5db97b446d-zh7v7", "ip-192-168-65-34.us-east-2.compute.internal"),
+			object("Pod", "cedb324d-cd96-4f21-9860-c7da567dd5ec", "etcd-0", "ip-192-168-187-121.us-east-2.compute.internal"),
+			object("Pod", "519f6f98-5623-4ac4-808c-67f6500d501b", "opni-gateway-b89c4f5c4-cg6p7", "ip-192-168-187-121.us-east-2.compute.internal"),
+			// not scheduled, or scheduled on an unknown node
+			object("Pod", "b0d4e332-a046-4956-aa87-ebde83cf82ad", "cert-manager-b4d6fd99b-kshhf", ""),
+			object("Pod", "9d1dde03-34a0-409d-8193-9b2594838ace", "opni-kube-prometheus-stack-operator-b6bcf6b4d-nnbql", "missing"),
+		})
+		Expect(kg.RelationshipList()).To(HaveLen(relationships + 5))
+		g = graph.NewScientificKubeGraph()
+		Expect(g.FromKubectlGraph(kg)).To(Succeed())
+	})
+
+	find := func(kind, namespace, name string) *graph.ScientificKubeNode {
+		node, err := g.FindNode(graph.ObjectReference{
+			Kind:      kind,
+			Namespace: namespace,
+			Name:      name,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		return node
+	}
+	kinds := func(nodes []*graph.ScientificKubeNode) []string {
+		var k []string
+		for _, n := range nodes {
+			k = append(k, n.Kind)
+		}
+		return k
+	}
+
+	Context("finding objects", func() {
+		It("should find objects by kind, namespace and name", func() {
+			node := find("deployment", "kube-system", "coredns")
+			Expect(node.Kind).To(Equal("Deployment"))
+
+			By("finding the same object by uid")
+			byUID, err := g.FindNode(graph.ObjectReference{UID: node.UID})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(byUID).To(BeIdenticalTo(node))
+		})
+		It("should return an error for missing or ambiguous references", func() {
+			_, err := g.FindNode(graph.ObjectReference{Kind: "Deployment", Namespace: "kube-system", Name: "missing"})
+			Expect(err).To(MatchError(graph.ErrObjectNotFound))
+			_, err = g.FindNode(graph.ObjectReference{UID: "missing"})
+			Expect(err).To(MatchError(graph.ErrObjectNotFound))
+
+			// both a Service and Endpoints
+			_, err = g.FindNode(graph.ObjectReference{Namespace: "kube-system", Name: "kube-dns"})
+			Expect(err).To(MatchError(graph.ErrAmbiguousReference))
+		})
+	})
+
+	Context("neighbors", func() {
+		It("should find downstream objects up to a depth", func() {
+			deployment := find("Deployment", "kube-system", "coredns")
+			neighbors := g.Neighbors(deployment, 2, graph.Downstream)
+			Expect(neighbors).NotTo(BeEmpty())
+			Expect(neighbors[0].Node.Kind).To(Equal("ReplicaSet"))
+			for _, n := range neighbors {
+				Expect(n.Direction).To(Equal(graph.Downstream))
+				switch n.Depth {
+				case 1:
+					Expect(n.Node.Kind).To(Equal("ReplicaSet"))
+				case 2:
+					Expect(n.Node.Kind).To(Equal("Pod"))
+				default:
+					Fail("unexpected depth")
+				}
+			}
+		})
+		It("should find the pods scheduled on a node", func() {
+			node := find("Node", "", "ip-192-168-187-121.us-east-2.compute.internal")
+			var pods []*graph.ScientificKubeNode
+			for _, n := range g.Neighbors(node, 1, graph.Downstream) {
+				pods = append(pods, n.Node)
+			}
+			Expect(pods).To(ConsistOf(
+				find("Pod", "opni", "etcd-0"),
+				find("Pod", "opni", "opni-gateway-b89c4f5c4-cg6p7"),
+			))
+		})
+		It("should find upstream objects", func() {
+			endpoints := find("Endpoints", "kube-system", "kube-dns")
+			neighbors := g.Neighbors(endpoints, 2, graph.Upstream)
+			var names []string
+			for _, n := range neighbors {
+				Expect(n.Direction).To(Equal(graph.Upstream))
+				names = append(names, n.Node.Kind+"/"+n.Node.Name)
+			}
+			Expect(names).To(ContainElements("Service/kube-dns", "Namespace/kube-system"))
+		})
+		It("should find objects in both directions", func() {
+			endpoints := find("Endpoints", "kube-system", "kube-dns")
+			neighbors := g.Neighbors(endpoints, 1, graph.Both)
+			var dirs []graph.Direction
+			for _, n := range neighbors {
+				Expect(n.Depth).To(Equal(1))
+				dirs = append(dirs, n.Direction)
+			}
+			Expect(dirs).To(ContainElements(graph.Upstream, graph.Downstream))
+		})
+	})
+
+	Context("shortest paths", func() {
+		It("should find undirected paths", func() {
+			service := find("Service", "kube-system", "kube-dns")
+			deployment := find("Deployment", "kube-system", "coredns")
+			path := g.ShortestPath(service, deployment, false)
+			Expect(kinds(path)).To(Equal([]string{"Service", "Endpoints", "Pod", "ReplicaSet", "Deployment"}))
+			Expect(g.EdgesBetween(path)).To(HaveLen(4))
+		})
+		It("should only follow edges forward in directed paths", func() {
+			service := find("Service", "kube-system", "kube-dns")
+			deployment := find("Deployment", "kube-system", "coredns")
+			Expect(g.ShortestPath(service, deployment, true)).To(BeNil())
+			Expect(kinds(g.ShortestPath(deployment, service, true))).To(BeNil())
+
+			path := g.ShortestPath(service, find("Endpoints", "kube-system", "kube-dns"), true)
+			Expect(kinds(path)).To(Equal([]string{"Service", "Endpoints"}))
+		})
+		It("should return a path to the same object", func() {
+			service := find("Service", "kube-system", "kube-dns")
+			Expect(g.ShortestPath(service, service, true)).To(Equal([]*graph.ScientificKubeNode{service}))
+		})
+	})
+
+	Context("blast radius", func() {
+		It("should find workloads affected by a failing service", func() {
+			service := find("Service", "kube-system", "kube-dns")
+			affected := g.BlastRadius(service)
+			Expect(affected).To(ContainElements(
+				find("Endpoints", "kube-system", "kube-dns"),
+				find("Deployment", "kube-system", "coredns"),
+			))
+			for _, n := range affected {
+				Expect(n.Kind).NotTo(BeElementOf("Namespace", "Cluster", "Node"))
+				Expect(n.Namespace).To(Equal("kube-system"))
+			}
+		})
+		It("should find workloads affected by a failing node", func() {
+			node := find("Node", "", "ip-192-168-65-34.us-east-2.compute.internal")
+			affected := g.BlastRadius(node)
+			Expect(affected).To(ContainElements(
+				find("Pod", "kube-system", "aws-node-k7j87"),
+				find("DaemonSet", "kube-system", "aws-node"),
+				find("Pod", "kube-system", "kube-proxy-xplql"),
+				find("Deployment", "kube-system", "coredns"),
+				find("Service", "kube-system", "kube-dns"),
+				find("Service", "kube-system", "opni-kube-prometheus-stack-kubelet"),
+			))
+			for _, n := range affected {
+				Expect(n.Kind).NotTo(BeElementOf("Namespace", "Cluster", "Node"))
+				// workloads on other nodes are unaffected
+				Expect(n.Namespace).To(Equal("kube-system"))
+			}
+		})
+	})
+})
@@ -1,6 +1,15 @@
 package graph
 
-import "gonum.org/v1/gonum/graph/encoding/dot"
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gonum.org/v1/gonum/graph/encoding/dot"
+)
 
 // From : https://pkg.go.dev/gonum.org/v1/gonum/graph/encoding/dot
 // the Dot encoding depends on the gonum Node, Attributer, Porter,
@@ -10,3 +19,142 @@ func (s *ScientificKubeGraph) RenderDOT() ([]byte, error) {
 	// TODO (topology) :implement dot  encoding  interfaces
 	return dot.Marshal(s, "name-string", "prefix-string", "indent-string")
 }
+
+// JSONGraph is a stable node/edge representation of a topology graph,
+// suitable for front-end graph libraries.
+type JSONGraph struct {
+	Nodes []JSONNode `json:"nodes"`
+	Edges []JSONEdge `json:"edges"`
+}
+
+type JSONNode struct {
+	// Kubernetes UID of the object
+	ID         string            `json:"id"`
+	APIVersion string            `json:"apiVersion,omitempty"`
+	Kind       string            `json:"kind"`
+	Namespace  string            `json:"namespace,omitempty"`
+	Name       string            `json:"name"`
+	Labels     map[string]string `json:"labels,omitempty"`
+}
+
+type JSONEdge struct {
+	// IDs of the source and target nodes
+	Source     string            `json:"source"`
+	Target     string            `json:"target"`
+	Label      string            `json:"label,omitempty"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+func (s *ScientificKubeGraph) ToJSONGraph() JSONGraph {
+	g := JSONGraph{
+		Nodes: []JSONNode{},
+		Edges: []JSONEdge{},
+	}
+	for _, n := range s.sortedNodes() {
+		g.Nodes = append(g.Nodes, JSONNode{
+			ID:         string(n.UID),
+			APIVersion: n.APIVersion,
+			Kind:       n.Kind,
+			Namespace:  n.Namespace,
+			Name:       n.Name,
+			Labels:     n.Labels,
+		})
+	}
+	for _, e := range s.sortedEdges() {
+		g.Edges = append(g.Edges, JSONEdge{
+			Source:     string(e.F.(*ScientificKubeNode).UID),
+			Target:     string(e.T.(*ScientificKubeNode).UID),
+			Label:      e.Label,
+			Attributes: e.Attributes,
+		})
+	}
+	return g
+}
+
+func (s *ScientificKubeGraph) RenderJSON() ([]byte, error) {
+	return json.Marshal(s.ToJSONGraph())
+}
+
+// RenderMermaid renders the graph as a mermaid flowchart.
+func (s *ScientificKubeGraph) RenderMermaid() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("flowchart LR\n")
+	ids := map[int64]string{}
+	for i, n := range s.sortedNodes() {
+		ids[n.ID()] = fmt.Sprintf("n%d", i)
+		fmt.Fprintf(&buf, "  %s[\"%s<br/>%s\"]\n", ids[n.ID()], mermaidEscape(n.Kind), mermaidEscape(qualifiedName(n)))
+	}
+	for _, e := range s.sortedEdges() {
+		from, to := ids[e.F.ID()], ids[e.T.ID()]
+		if e.Label == "" {
+			fmt.Fprintf(&buf, "  %s --> %s\n", from, to)
+		} else {
+			fmt.Fprintf(&buf, "  %s -->|\"%s\"| %s\n", from, mermaidEscape(e.Label), to)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(
+		`"`, "#quot;",
+		"<", "#lt;",
+		">", "#gt;",
+	).Replace(s)
+}
+
+func qualifiedName(n *ScientificKubeNode) string {
+	if n.Namespace == "" {
+		return n.Name
+	}
+	return n.Namespace + "/" + n.Name
+}
+
+// kindColor returns a color which is unique to the object's kind
+func kindColor(kind string) string {
+	hash := md5.Sum([]byte(kind))
+	return fmt.Sprintf("#%x", hash[:3])
+}
+
+// sortedNodes returns the nodes of the graph ordered by kind, namespace and
+// name, so that rendered output is stable.
+func (s *ScientificKubeGraph) sortedNodes() []*ScientificKubeNode {
+	var nodes []*ScientificKubeNode
+	it := s.Nodes()
+	for it.Next() {
+		nodes = append(nodes, it.Node().(*ScientificKubeNode))
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodeLess(nodes[i], nodes[j])
+	})
+	return nodes
+}
+
+func nodeLess(a, b *ScientificKubeNode) bool {
+	if a.Kind != b.Kind {
+		return a.Kind < b.Kind
+	}
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	if a.Name != b.Name {
+		return a.Name < b.Name
+	}
+	return a.UID < b.UID
+}
+
+func (s *ScientificKubeGraph) sortedEdges() []*ScientificKubeEdge {
+	var edges []*ScientificKubeEdge
+	it := s.Edges()
+	for it.Next() {
+		edges = append(edges, it.Edge().(*ScientificKubeEdge))
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		fi, fj := edges[i].F.(*ScientificKubeNode), edges[j].F.(*ScientificKubeNode)
+		if fi != fj {
+			return nodeLess(fi, fj)
+		}
+		return nodeLess(edges[i].T.(*ScientificKubeNode), edges[j].T.(*ScientificKubeNode))
+	})
+	return edges
+}
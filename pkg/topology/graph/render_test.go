@@ -0,0 +1,125 @@
+package graph_test
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rancher/opni/pkg/test"
+	"github.com/rancher/opni/pkg/topology/graph"
+	kgraph "github.com/steveteuber/kubectl-graph/pkg/graph"
+)
+
+var _ = Describe("Graph rendering", Label(test.Unit), func() {
+	var kg *kgraph.Graph
+
+	BeforeEach(func() {
+		kg = nil
+		Expect(json.Unmarshal(test.TestData("topology/graph.json"), &kg)).To(Succeed())
+	})
+
+	build := func(g *kgraph.Graph) *graph.ScientificKubeGraph {
+		sg := graph.NewScientificKubeGraph()
+		Expect(sg.FromKubectlGraph(g)).To(Succeed())
+		return sg
+	}
+
+	Context("filters", func() {
+		It("should filter objects by namespace", func() {
+			filtered, err := graph.Filter{Namespaces: []string{"kube-system"}}.Apply(kg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(filtered.Nodes).NotTo(BeEmpty())
+			for _, n := range filtered.Nodes {
+				if n.Kind == "Namespace" {
+					Expect(n.Name).To(Equal("kube-system"))
+				} else {
+					Expect(n.Namespace).To(Equal("kube-system"))
+				}
+			}
+			for _, r := range filtered.RelationshipList() {
+				Expect(filtered.Nodes).To(HaveKey(r.From))
+				Expect(filtered.Nodes).To(HaveKey(r.To))
+			}
+		})
+		It("should filter objects by kind", func() {
+			filtered, err := graph.Filter{Kinds: []string{"service", "Endpoints"}}.Apply(kg)
+			Expect(err).NotTo(HaveOccurred())
+			for _, n := range filtered.Nodes {
+				Expect(n.Kind).To(BeElementOf("Service", "Endpoints"))
+			}
+			Expect(filtered.RelationshipList()).NotTo(BeEmpty())
+			for _, r := range filtered.RelationshipList() {
+				Expect(r.Label).To(Equal("Endpoints"))
+			}
+		})
+		It("should filter objects by label", func() {
+			filtered, err := graph.Filter{LabelSelector: "app.kubernetes.io/name=opni-gateway"}.Apply(kg)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(filtered.Nodes).NotTo(BeEmpty())
+			for _, n := range filtered.Nodes {
+				Expect(n.Labels).To(HaveKeyWithValue("app.kubernetes.io/name", "opni-gateway"))
+			}
+
+			_, err = graph.Filter{LabelSelector: "a=b=c"}.Apply(kg)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	It("should render a stable JSON graph", func() {
+		g := build(kg)
+		data, err := g.RenderJSON()
+		Expect(err).NotTo(HaveOccurred())
+		var out graph.JSONGraph
+		Expect(json.Unmarshal(data, &out)).To(Succeed())
+		Expect(out.Nodes).To(HaveLen(len(kg.Nodes)))
+		Expect(out.Edges).To(HaveLen(len(kg.RelationshipList())))
+		ids := map[string]bool{}
+		for _, n := range out.Nodes {
+			ids[n.ID] = true
+		}
+		for _, e := range out.Edges {
+			Expect(ids).To(HaveKey(e.Source))
+			Expect(ids).To(HaveKey(e.Target))
+		}
+
+		By("rendering the same output for a separately built graph")
+		again, err := build(kg).RenderJSON()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(again).To(Equal(data))
+	})
+
+	It("should render mermaid flowcharts", func() {
+		filtered, err := graph.Filter{Namespaces: []string{"kube-system"}, Kinds: []string{"Deployment", "ReplicaSet"}}.Apply(kg)
+		Expect(err).NotTo(HaveOccurred())
+		data, err := build(filtered).RenderMermaid()
+		Expect(err).NotTo(HaveOccurred())
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		Expect(lines[0]).To(Equal("flowchart LR"))
+		Expect(string(data)).To(ContainSubstring(`["Deployment<br/>kube-system/coredns"]`))
+		Expect(string(data)).To(MatchRegexp(`n\d+ -->\|"ReplicaSet"\| n\d+`))
+		Expect(lines).To(HaveLen(1 + len(filtered.Nodes) + len(filtered.RelationshipList())))
+	})
+
+	It("should render SVG images", func() {
+		data, err := build(kg).RenderSVG()
+		Expect(err).NotTo(HaveOccurred())
+		var svg struct {
+			XMLName xml.Name
+			Groups  []struct {
+				Title string `xml:"title"`
+			} `xml:"g"`
+			Lines []struct{} `xml:"line"`
+		}
+		Expect(xml.Unmarshal(data, &svg)).To(Succeed())
+		Expect(svg.XMLName.Local).To(Equal("svg"))
+		Expect(svg.Groups).To(HaveLen(len(kg.Nodes)))
+		Expect(svg.Lines).To(HaveLen(len(kg.RelationshipList())))
+
+		By("rendering an empty graph")
+		data, err = build(&kgraph.Graph{}).RenderSVG()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(xml.Unmarshal(data, &svg)).To(Succeed())
+	})
+})
@@ -0,0 +1,190 @@
+package graph
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"sort"
+)
+
+const (
+	svgMargin     = 20
+	svgNodeWidth  = 160
+	svgNodeHeight = 40
+	svgNodeGap    = 20
+	svgLayerGap   = 60
+	svgMaxLabel   = 22
+)
+
+type point struct {
+	x, y int
+}
+
+// RenderSVG renders the graph as an SVG image. Objects are placed in
+// layers, so that each object is drawn below the objects which own,
+// select or contain it.
+func (s *ScientificKubeGraph) RenderSVG() ([]byte, error) {
+	layers := s.layers()
+	width := 0
+	for _, layer := range layers {
+		if w := len(layer)*(svgNodeWidth+svgNodeGap) - svgNodeGap; w > width {
+			width = w
+		}
+	}
+	width += 2 * svgMargin
+	height := 2*svgMargin + len(layers)*(svgNodeHeight+svgLayerGap) - svgLayerGap
+	if len(layers) == 0 {
+		height = 2 * svgMargin
+	}
+
+	// center each layer horizontally
+	positions := map[int64]point{}
+	for i, layer := range layers {
+		layerWidth := len(layer)*(svgNodeWidth+svgNodeGap) - svgNodeGap
+		x := (width - layerWidth) / 2
+		y := svgMargin + i*(svgNodeHeight+svgLayerGap)
+		for j, n := range layer {
+			positions[n.ID()] = point{x: x + j*(svgNodeWidth+svgNodeGap), y: y}
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="10">`+"\n",
+		width, height, width, height)
+	buf.WriteString(`<defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="6" markerHeight="6" orient="auto-start-reverse"><path d="M 0 0 L 10 5 L 0 10 z" fill="#9e9e9e"/></marker></defs>` + "\n")
+
+	for _, e := range s.sortedEdges() {
+		from, to := positions[e.F.ID()], positions[e.T.ID()]
+		x1, y1 := from.x+svgNodeWidth/2, from.y+svgNodeHeight
+		x2, y2 := to.x+svgNodeWidth/2, to.y
+		if to.y < from.y {
+			// edges which point back up (only in cyclic graphs)
+			y1, y2 = from.y, to.y+svgNodeHeight
+		}
+		fmt.Fprintf(&buf, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#9e9e9e" marker-end="url(#arrow)"><title>%s</title></line>`+"\n",
+			x1, y1, x2, y2, html.EscapeString(e.Label))
+	}
+
+	for _, layer := range layers {
+		for _, n := range layer {
+			p := positions[n.ID()]
+			fmt.Fprintf(&buf, `<g><title>%s</title>`, html.EscapeString(n.Kind+" "+qualifiedName(n)))
+			fmt.Fprintf(&buf, `<rect x="%d" y="%d" width="%d" height="%d" rx="4" fill="%s5e" stroke="%s"/>`,
+				p.x, p.y, svgNodeWidth, svgNodeHeight, kindColor(n.Kind), kindColor(n.Kind))
+			fmt.Fprintf(&buf, `<text x="%d" y="%d" text-anchor="middle" font-weight="bold">%s</text>`,
+				p.x+svgNodeWidth/2, p.y+16, html.EscapeString(n.Kind))
+			fmt.Fprintf(&buf, `<text x="%d" y="%d" text-anchor="middle">%s</text></g>`+"\n",
+				p.x+svgNodeWidth/2, p.y+30, html.EscapeString(truncate(n.Name, svgMaxLabel)))
+		}
+	}
+	buf.WriteString("</svg>\n")
+	return buf.Bytes(), nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-3] + "..."
+}
+
+// layers assigns each node to a layer one below the lowest of its
+// predecessors, then orders the nodes within each layer by the average
+// position of their predecessors to reduce edge crossings.
+func (s *ScientificKubeGraph) layers() [][]*ScientificKubeNode {
+	nodes := s.sortedNodes()
+
+	// topological order; if only nodes on cycles remain, the first of them
+	// in sorted order is treated as if it had no predecessors
+	inDegree := map[int64]int{}
+	for _, n := range nodes {
+		inDegree[n.ID()] = s.To(n.ID()).Len()
+	}
+	var order []*ScientificKubeNode
+	visited := map[int64]bool{}
+	for len(order) < len(nodes) {
+		progress := false
+		for _, n := range nodes {
+			if visited[n.ID()] || inDegree[n.ID()] > 0 {
+				continue
+			}
+			visited[n.ID()] = true
+			order = append(order, n)
+			progress = true
+			succ := s.From(n.ID())
+			for succ.Next() {
+				inDegree[succ.Node().ID()]--
+			}
+		}
+		if !progress {
+			// break a cycle at the first remaining node
+			for _, n := range nodes {
+				if !visited[n.ID()] {
+					inDegree[n.ID()] = 0
+					break
+				}
+			}
+		}
+	}
+
+	// edges from nodes later in the order close a cycle, and are ignored
+	position := map[int64]int{}
+	for i, n := range order {
+		position[n.ID()] = i
+	}
+	rank := map[int64]int{}
+	maxRank := 0
+	for _, n := range order {
+		pred := s.To(n.ID())
+		for pred.Next() {
+			p := pred.Node().ID()
+			if position[p] < position[n.ID()] && rank[p]+1 > rank[n.ID()] {
+				rank[n.ID()] = rank[p] + 1
+			}
+		}
+		if rank[n.ID()] > maxRank {
+			maxRank = rank[n.ID()]
+		}
+	}
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	layers := make([][]*ScientificKubeNode, maxRank+1)
+	for _, n := range nodes {
+		layers[rank[n.ID()]] = append(layers[rank[n.ID()]], n)
+	}
+
+	index := map[int64]int{}
+	for _, layer := range layers {
+		for i, n := range layer {
+			index[n.ID()] = i
+		}
+	}
+	for i := 1; i < len(layers); i++ {
+		layer := layers[i]
+		center := map[int64]float64{}
+		for _, n := range layer {
+			sum, count := 0, 0
+			pred := s.To(n.ID())
+			for pred.Next() {
+				if rank[pred.Node().ID()] == i-1 {
+					sum += index[pred.Node().ID()]
+					count++
+				}
+			}
+			if count == 0 {
+				center[n.ID()] = float64(index[n.ID()])
+			} else {
+				center[n.ID()] = float64(sum) / float64(count)
+			}
+		}
+		sort.SliceStable(layer, func(a, b int) bool {
+			return center[layer[a].ID()] < center[layer[b].ID()]
+		})
+		for j, n := range layer {
+			index[n.ID()] = j
+		}
+	}
+	return layers
+}
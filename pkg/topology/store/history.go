@@ -0,0 +1,211 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	"golang.org/x/exp/slices"
+)
+
+// DefaultSnapshotRetention is how long versioned topology snapshots are kept
+// if no retention is configured.
+const DefaultSnapshotRetention = 24 * time.Hour
+
+var ErrNoSnapshots = errors.New("no topology snapshots found")
+
+// Snapshot identifies a versioned copy of a cluster's topology, taken at
+// the time it was pushed to the gateway.
+type Snapshot struct {
+	Key       string
+	Timestamp time.Time
+	Info      *nats.ObjectInfo
+}
+
+// NewSnapshotKey returns the object name of the snapshot of the cluster's
+// topology taken at the given time.
+func NewSnapshotKey(clusterId *corev1.Reference, ts time.Time) string {
+	return fmt.Sprintf("%s@%d", NewClusterKey(clusterId), ts.UnixNano())
+}
+
+// ParseSnapshotKey returns the timestamp encoded in a snapshot key of the
+// given cluster, or false if the key does not belong to one of its snapshots.
+func ParseSnapshotKey(clusterId *corev1.Reference, key string) (time.Time, bool) {
+	prefix := NewClusterKey(clusterId) + "@"
+	if !strings.HasPrefix(key, prefix) {
+		return time.Time{}, false
+	}
+	nanos, err := strconv.ParseInt(strings.TrimPrefix(key, prefix), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(0, nanos), true
+}
+
+// ListSnapshots returns all snapshots of the cluster's topology, sorted
+// from oldest to newest.
+func ListSnapshots(obj nats.ObjectStore, clusterId *corev1.Reference) ([]Snapshot, error) {
+	infos, err := obj.List()
+	if err != nil {
+		if errors.Is(err, nats.ErrNoObjectsFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var snapshots []Snapshot
+	for _, info := range infos {
+		if info.Deleted {
+			continue
+		}
+		if ts, ok := ParseSnapshotKey(clusterId, info.Name); ok {
+			snapshots = append(snapshots, Snapshot{
+				Key:       info.Name,
+				Timestamp: ts,
+				Info:      info,
+			})
+		}
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Timestamp.Before(snapshots[j].Timestamp)
+	})
+	return snapshots, nil
+}
+
+// SnapshotAt returns the most recent snapshot taken at or before ts. If
+// every snapshot was taken after ts, the oldest snapshot is returned
+// instead. Returns ErrNoSnapshots if the cluster has no snapshots.
+func SnapshotAt(snapshots []Snapshot, ts time.Time) (Snapshot, error) {
+	if len(snapshots) == 0 {
+		return Snapshot{}, ErrNoSnapshots
+	}
+	idx := sort.Search(len(snapshots), func(i int) bool {
+		return snapshots[i].Timestamp.After(ts)
+	})
+	if idx == 0 {
+		return snapshots[0], nil
+	}
+	return snapshots[idx-1], nil
+}
+
+// PruneSnapshots deletes snapshots of the cluster's topology that are older
+// than the retention window. The newest snapshot taken before the start of
+// the window is kept, since it still describes the state of the cluster at
+// that time. Returns the number of deleted snapshots.
+func PruneSnapshots(
+	obj nats.ObjectStore,
+	clusterId *corev1.Reference,
+	retention time.Duration,
+	now time.Time,
+) (int, error) {
+	snapshots, err := ListSnapshots(obj, clusterId)
+	if err != nil {
+		return 0, err
+	}
+	return pruneSnapshots(obj, snapshots, retention, now)
+}
+
+// pruneSnapshots deletes the snapshots in the list which are outside of the
+// retention window, see PruneSnapshots. Since the list is sorted, the deleted
+// snapshots are always the first n in the list.
+func pruneSnapshots(obj nats.ObjectStore, snapshots []Snapshot, retention time.Duration, now time.Time) (int, error) {
+	cutoff := now.Add(-retention)
+	deleted := 0
+	for i := 0; i < len(snapshots)-1; i++ {
+		if !snapshots[i+1].Timestamp.Before(cutoff) {
+			break
+		}
+		if err := obj.Delete(snapshots[i].Key); err != nil && !errors.Is(err, nats.ErrObjectNotFound) {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// SnapshotIndex keeps track of the snapshots of each cluster, so that the
+// object store only needs to be listed the first time a cluster's snapshots
+// are accessed. Snapshots must be added and pruned through the index for it
+// to stay up to date.
+type SnapshotIndex struct {
+	obj       nats.ObjectStore
+	mu        sync.Mutex
+	snapshots map[string][]Snapshot
+}
+
+func NewSnapshotIndex(obj nats.ObjectStore) *SnapshotIndex {
+	return &SnapshotIndex{
+		obj:       obj,
+		snapshots: make(map[string][]Snapshot),
+	}
+}
+
+// List returns the snapshots of the cluster's topology, sorted from oldest
+// to newest.
+func (x *SnapshotIndex) List(clusterId *corev1.Reference) ([]Snapshot, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	snapshots, err := x.list(clusterId)
+	if err != nil {
+		return nil, err
+	}
+	return slices.Clone(snapshots), nil
+}
+
+func (x *SnapshotIndex) list(clusterId *corev1.Reference) ([]Snapshot, error) {
+	key := NewClusterKey(clusterId)
+	if snapshots, ok := x.snapshots[key]; ok {
+		return snapshots, nil
+	}
+	snapshots, err := ListSnapshots(x.obj, clusterId)
+	if err != nil {
+		return nil, err
+	}
+	x.snapshots[key] = snapshots
+	return snapshots, nil
+}
+
+// Put stores a snapshot of the cluster's topology taken at the given time.
+func (x *SnapshotIndex) Put(clusterId *corev1.Reference, meta *nats.ObjectMeta, data io.Reader, ts time.Time) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	snapshots, err := x.list(clusterId)
+	if err != nil {
+		return err
+	}
+	meta.Name = NewSnapshotKey(clusterId, ts)
+	info, err := x.obj.Put(meta, data)
+	if err != nil {
+		return err
+	}
+	snapshot := Snapshot{
+		Key:       info.Name,
+		Timestamp: ts,
+		Info:      info,
+	}
+	idx := sort.Search(len(snapshots), func(i int) bool {
+		return snapshots[i].Timestamp.After(ts)
+	})
+	x.snapshots[NewClusterKey(clusterId)] = slices.Insert(snapshots, idx, snapshot)
+	return nil
+}
+
+// Prune deletes the cluster's snapshots which are outside of the retention
+// window, see PruneSnapshots.
+func (x *SnapshotIndex) Prune(clusterId *corev1.Reference, retention time.Duration, now time.Time) (int, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	snapshots, err := x.list(clusterId)
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := pruneSnapshots(x.obj, snapshots, retention, now)
+	x.snapshots[NewClusterKey(clusterId)] = snapshots[deleted:]
+	return deleted, err
+}
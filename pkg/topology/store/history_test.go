@@ -0,0 +1,201 @@
+package store_test
+
+import (
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	"github.com/rancher/opni/pkg/test"
+	"github.com/rancher/opni/pkg/topology/store"
+)
+
+var _ = Describe("Topology Snapshots", Ordered, Label(test.Unit), func() {
+	var obj nats.ObjectStore
+	cluster := &corev1.Reference{Id: "cluster-1"}
+	other := &corev1.Reference{Id: "cluster-10"}
+	base := time.Date(2023, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	put := func(ref *corev1.Reference, ts time.Time) {
+		_, err := obj.PutBytes(store.NewSnapshotKey(ref, ts), []byte(ts.String()))
+		Expect(err).NotTo(HaveOccurred())
+	}
+	timestamps := func(snapshots []store.Snapshot) []time.Time {
+		var ts []time.Time
+		for _, s := range snapshots {
+			ts = append(ts, s.Timestamp.UTC())
+		}
+		return ts
+	}
+
+	BeforeAll(func() {
+		var err error
+		obj, err = store.NewTopologyObjectStore(nc)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should parse snapshot keys", func() {
+		key := store.NewSnapshotKey(cluster, base)
+		Expect(strings.HasPrefix(key, store.NewClusterKey(cluster))).To(BeTrue())
+		ts, ok := store.ParseSnapshotKey(cluster, key)
+		Expect(ok).To(BeTrue())
+		Expect(ts.Equal(base)).To(BeTrue())
+
+		_, ok = store.ParseSnapshotKey(other, key)
+		Expect(ok).To(BeFalse())
+		_, ok = store.ParseSnapshotKey(cluster, store.NewClusterKey(cluster))
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should return no snapshots for an empty store", func() {
+		snapshots, err := store.ListSnapshots(obj, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(snapshots).To(BeEmpty())
+		_, err = store.SnapshotAt(snapshots, base)
+		Expect(err).To(MatchError(store.ErrNoSnapshots))
+	})
+
+	It("should list snapshots for a single cluster in order", func() {
+		put(cluster, base.Add(2*time.Hour))
+		put(cluster, base)
+		put(cluster, base.Add(time.Hour))
+		put(other, base.Add(30*time.Minute))
+		_, err := obj.PutBytes(store.NewClusterKey(cluster), []byte("latest"))
+		Expect(err).NotTo(HaveOccurred())
+
+		snapshots, err := store.ListSnapshots(obj, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(timestamps(snapshots)).To(Equal([]time.Time{
+			base, base.Add(time.Hour), base.Add(2 * time.Hour),
+		}))
+	})
+
+	It("should find the snapshot taken at a given time", func() {
+		snapshots, err := store.ListSnapshots(obj, cluster)
+		Expect(err).NotTo(HaveOccurred())
+
+		for ts, expected := range map[time.Time]time.Time{
+			base.Add(-time.Hour):       base,
+			base:                       base,
+			base.Add(59 * time.Minute): base,
+			base.Add(time.Hour):        base.Add(time.Hour),
+			base.Add(3 * time.Hour):    base.Add(2 * time.Hour),
+			base.Add(90 * time.Minute): base.Add(time.Hour),
+		} {
+			s, err := store.SnapshotAt(snapshots, ts)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.Timestamp.Equal(expected)).To(BeTrue(), "at %s", ts)
+		}
+	})
+
+	It("should prune snapshots outside of the retention window", func() {
+		By("keeping the newest snapshot taken before the window")
+		n, err := store.PruneSnapshots(obj, cluster, 90*time.Minute, base.Add(3*time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(1))
+
+		snapshots, err := store.ListSnapshots(obj, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(timestamps(snapshots)).To(Equal([]time.Time{
+			base.Add(time.Hour), base.Add(2 * time.Hour),
+		}))
+
+		By("always keeping the most recent snapshot")
+		n, err = store.PruneSnapshots(obj, cluster, time.Minute, base.Add(24*time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(1))
+		snapshots, err = store.ListSnapshots(obj, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(timestamps(snapshots)).To(Equal([]time.Time{base.Add(2 * time.Hour)}))
+
+		By("leaving other clusters' snapshots and the latest topology alone")
+		snapshots, err = store.ListSnapshots(obj, other)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(snapshots).To(HaveLen(1))
+		_, err = obj.GetInfo(store.NewClusterKey(cluster))
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
+
+var _ = Describe("Topology Snapshot Index", Label(test.Unit), func() {
+	var obj nats.ObjectStore
+	var index *store.SnapshotIndex
+	cluster := &corev1.Reference{Id: "cluster-index"}
+	base := time.Date(2023, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	timestamps := func(snapshots []store.Snapshot) []time.Time {
+		var ts []time.Time
+		for _, s := range snapshots {
+			ts = append(ts, s.Timestamp.UTC())
+		}
+		return ts
+	}
+	put := func(ts time.Time) {
+		Expect(index.Put(cluster, &nats.ObjectMeta{}, strings.NewReader(ts.String()), ts)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		obj, err = store.NewTopologyObjectStore(nc)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = obj.PutBytes(store.NewSnapshotKey(cluster, base), []byte("existing"))
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(func() {
+			snapshots, err := store.ListSnapshots(obj, cluster)
+			Expect(err).NotTo(HaveOccurred())
+			for _, s := range snapshots {
+				Expect(obj.Delete(s.Key)).To(Succeed())
+			}
+		})
+		index = store.NewSnapshotIndex(obj)
+	})
+
+	It("should load existing snapshots and keep track of new ones", func() {
+		snapshots, err := index.List(cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(timestamps(snapshots)).To(Equal([]time.Time{base}))
+
+		put(base.Add(2 * time.Hour))
+		put(base.Add(time.Hour))
+		snapshots, err = index.List(cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(timestamps(snapshots)).To(Equal([]time.Time{
+			base, base.Add(time.Hour), base.Add(2 * time.Hour),
+		}))
+		Expect(snapshots[2].Info.Digest).NotTo(BeEmpty())
+
+		By("matching the object store")
+		stored, err := store.ListSnapshots(obj, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(timestamps(stored)).To(Equal(timestamps(snapshots)))
+	})
+
+	It("should not read the object store again once loaded", func() {
+		_, err := index.List(cluster)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = obj.PutBytes(store.NewSnapshotKey(cluster, base.Add(time.Hour)), []byte("not indexed"))
+		Expect(err).NotTo(HaveOccurred())
+		snapshots, err := index.List(cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(snapshots).To(HaveLen(1))
+	})
+
+	It("should prune snapshots outside of the retention window", func() {
+		put(base.Add(time.Hour))
+		put(base.Add(2 * time.Hour))
+		n, err := index.Prune(cluster, 90*time.Minute, base.Add(3*time.Hour))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n).To(Equal(1))
+
+		snapshots, err := index.List(cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(timestamps(snapshots)).To(Equal([]time.Time{
+			base.Add(time.Hour), base.Add(2 * time.Hour),
+		}))
+		stored, err := store.ListSnapshots(obj, cluster)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(timestamps(stored)).To(Equal(timestamps(snapshots)))
+	})
+})
@@ -0,0 +1,29 @@
+package store_test
+
+import (
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rancher/opni/pkg/test"
+)
+
+func TestStore(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Topology Store Suite")
+}
+
+var nc *nats.Conn
+
+var _ = BeforeSuite(func() {
+	env := test.Environment{}
+	var err error
+	nc, err = env.StartEmbeddedJetstream()
+	Expect(err).NotTo(HaveOccurred())
+
+	DeferCleanup(func() {
+		nc.Close()
+		env.Stop()
+	})
+})
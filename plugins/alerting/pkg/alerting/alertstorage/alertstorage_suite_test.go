@@ -0,0 +1,13 @@
+package alertstorage_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestAlertStorage(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Alert Storage Suite")
+}
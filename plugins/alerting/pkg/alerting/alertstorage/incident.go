@@ -1,6 +1,11 @@
 package alertstorage
 
-import "google.golang.org/protobuf/types/known/timestamppb"
+import (
+	"time"
+
+	alertingv1 "github.com/rancher/opni/pkg/apis/alerting/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
 
 type State struct {
 	Healthy   bool                   `json:"healthy"`
@@ -36,3 +41,70 @@ func NewIncidentIntervals() *IncidentIntervals {
 		Values: []Interval{},
 	}
 }
+
+// ControlFlowState is the evaluation state of a control flow condition,
+// persisted so that the ordering of its operands survives restarts
+type ControlFlowState struct {
+	// whether x and y were firing when last observed
+	XFiring bool `json:"xFiring"`
+	YFiring bool `json:"yFiring"`
+	// IF_THEN : when x started firing
+	// IF_NOT_THEN : when y started firing while x was not firing
+	Start *timestamppb.Timestamp `json:"start,omitempty"`
+	// IF_THEN : y has started firing since x started firing
+	Followed bool `json:"followed"`
+	// IF_NOT_THEN : y started firing while x was firing
+	Preceded bool `json:"preceded"`
+}
+
+// Observe records the current state of the operands x and y, and returns
+// whether the control flow condition is healthy. An unhealthy condition
+// returns the time since which it has been unhealthy, so the evaluator
+// can compare it against the condition's "for" duration.
+func (s *ControlFlowState) Observe(
+	action alertingv1.ControlFlowAction,
+	xFiring, yFiring bool,
+	now time.Time,
+) (bool, *timestamppb.Timestamp) {
+	defer func() {
+		s.XFiring, s.YFiring = xFiring, yFiring
+	}()
+	switch action {
+	case alertingv1.ControlFlowAction_IF_THEN:
+		// fires when x fires and y does not follow within "for"
+		if !xFiring {
+			s.Start, s.Followed = nil, false
+			return true, timestamppb.New(now)
+		}
+		if s.Start == nil {
+			s.Start = timestamppb.New(now)
+			// y only follows x if it was not already firing before x
+			s.Followed = yFiring && !s.YFiring
+		} else if yFiring && !s.YFiring {
+			s.Followed = true
+		}
+		if s.Followed {
+			return true, timestamppb.New(now)
+		}
+		return false, s.Start
+	case alertingv1.ControlFlowAction_IF_NOT_THEN:
+		// fires when y fires while x is not firing, for longer than "for"
+		if !yFiring {
+			s.Start, s.Preceded = nil, false
+			return true, timestamppb.New(now)
+		}
+		if !s.YFiring {
+			// y is explained by x if x was firing when y started
+			s.Preceded = xFiring || s.XFiring
+		}
+		if xFiring || s.Preceded {
+			s.Start = nil
+			return true, timestamppb.New(now)
+		}
+		if s.Start == nil {
+			s.Start = timestamppb.New(now)
+		}
+		return false, s.Start
+	}
+	return true, timestamppb.New(now)
+}
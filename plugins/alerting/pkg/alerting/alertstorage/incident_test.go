@@ -0,0 +1,147 @@
+package alertstorage_test
+
+import (
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	alertingv1 "github.com/rancher/opni/pkg/apis/alerting/v1"
+	"github.com/rancher/opni/plugins/alerting/pkg/alerting/alertstorage"
+)
+
+var _ = Describe("Control Flow State", Label("unit"), func() {
+	var st *alertstorage.ControlFlowState
+	var now time.Time
+
+	BeforeEach(func() {
+		st = &alertstorage.ControlFlowState{}
+		now = time.Unix(1000, 0)
+	})
+
+	// observe advances the clock by one evaluation interval before observing
+	observe := func(action alertingv1.ControlFlowAction, xFiring, yFiring bool) (bool, time.Time) {
+		now = now.Add(30 * time.Second)
+		healthy, ts := st.Observe(action, xFiring, yFiring, now)
+		Expect(ts).NotTo(BeNil())
+		return healthy, ts.AsTime()
+	}
+
+	When("evaluating IF_THEN", func() {
+		action := alertingv1.ControlFlowAction_IF_THEN
+
+		It("should be healthy while x is not firing", func() {
+			healthy, _ := observe(action, false, false)
+			Expect(healthy).To(BeTrue())
+			healthy, _ = observe(action, false, true)
+			Expect(healthy).To(BeTrue())
+		})
+
+		It("should be unhealthy since x started firing until y follows", func() {
+			observe(action, false, false)
+			healthy, since := observe(action, true, false)
+			Expect(healthy).To(BeFalse())
+			xStart := since
+
+			healthy, since = observe(action, true, false)
+			Expect(healthy).To(BeFalse())
+			Expect(since).To(BeTemporally("==", xStart))
+
+			healthy, _ = observe(action, true, true)
+			Expect(healthy).To(BeTrue())
+			// y stopping again does not undo the follow
+			healthy, _ = observe(action, true, false)
+			Expect(healthy).To(BeTrue())
+		})
+
+		It("should count y starting together with x as following", func() {
+			healthy, _ := observe(action, true, true)
+			Expect(healthy).To(BeTrue())
+		})
+
+		It("should not count y firing before x as following", func() {
+			observe(action, false, true)
+			healthy, _ := observe(action, true, true)
+			Expect(healthy).To(BeFalse())
+
+			healthy, _ = observe(action, true, false)
+			Expect(healthy).To(BeFalse())
+			healthy, _ = observe(action, true, true)
+			Expect(healthy).To(BeTrue())
+		})
+
+		It("should reset when x stops firing", func() {
+			observe(action, true, true)
+			healthy, _ := observe(action, false, false)
+			Expect(healthy).To(BeTrue())
+			healthy, since := observe(action, true, false)
+			Expect(healthy).To(BeFalse())
+			Expect(since).To(BeTemporally("==", now))
+		})
+	})
+
+	When("evaluating IF_NOT_THEN", func() {
+		action := alertingv1.ControlFlowAction_IF_NOT_THEN
+
+		It("should be healthy while y is not firing", func() {
+			healthy, _ := observe(action, false, false)
+			Expect(healthy).To(BeTrue())
+			healthy, _ = observe(action, true, false)
+			Expect(healthy).To(BeTrue())
+		})
+
+		It("should be unhealthy since y started firing without x", func() {
+			observe(action, false, false)
+			healthy, since := observe(action, false, true)
+			Expect(healthy).To(BeFalse())
+			yStart := since
+
+			healthy, since = observe(action, false, true)
+			Expect(healthy).To(BeFalse())
+			Expect(since).To(BeTemporally("==", yStart))
+
+			healthy, _ = observe(action, false, false)
+			Expect(healthy).To(BeTrue())
+		})
+
+		It("should be healthy when x preceded y", func() {
+			observe(action, true, false)
+			healthy, _ := observe(action, true, true)
+			Expect(healthy).To(BeTrue())
+			// x resolving first does not make y unexplained
+			healthy, _ = observe(action, false, true)
+			Expect(healthy).To(BeTrue())
+		})
+
+		It("should be healthy when x and y start firing together", func() {
+			healthy, _ := observe(action, true, true)
+			Expect(healthy).To(BeTrue())
+			healthy, _ = observe(action, false, true)
+			Expect(healthy).To(BeTrue())
+		})
+
+		It("should restart the duration when x fires after y", func() {
+			_, yStart := observe(action, false, true)
+			healthy, _ := observe(action, true, true)
+			Expect(healthy).To(BeTrue())
+			healthy, since := observe(action, false, true)
+			Expect(healthy).To(BeFalse())
+			Expect(since).To(BeTemporally(">", yStart))
+		})
+	})
+
+	It("should resume from its persisted state", func() {
+		action := alertingv1.ControlFlowAction_IF_THEN
+		observe(action, false, true)
+		_, xStart := observe(action, true, true)
+
+		data, err := json.Marshal(st)
+		Expect(err).NotTo(HaveOccurred())
+		st = &alertstorage.ControlFlowState{}
+		Expect(json.Unmarshal(data, st)).To(Succeed())
+
+		healthy, since := observe(action, true, true)
+		Expect(healthy).To(BeFalse())
+		Expect(since).To(BeTemporally("==", xStart))
+	})
+})
@@ -3,6 +3,7 @@ package alertstorage
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"path"
 	"sync"
 	"time"
@@ -15,6 +16,8 @@ import (
 	"github.com/rancher/opni/pkg/util/future"
 	"go.uber.org/zap"
 	"golang.org/x/exp/slices"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -101,14 +104,14 @@ func (s *StorageNode) CreateCondition(ctx context.Context, conditionId string, c
 	return storage.Conditions.Put(ctx, path.Join(conditionPrefix, conditionId), condition)
 }
 
-func (s *StorageNode) GetCondition(ctx context.Context, conditionId string) (*alertingv1.AlertCondition, error) {
+func (s *StorageNode) GetCondition(ctx context.Context, conditionId string, opts ...storage.GetOpt) (*alertingv1.AlertCondition, error) {
 	ctxTimeout, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 	storage, err := s.storage.GetContext(ctxTimeout)
 	if err != nil {
 		return nil, err
 	}
-	return storage.Conditions.Get(ctx, path.Join(conditionPrefix, conditionId))
+	return storage.Conditions.Get(ctx, path.Join(conditionPrefix, conditionId), opts...)
 }
 
 func (s *StorageNode) ListConditions(ctx context.Context) ([]*alertingv1.AlertCondition, error) {
@@ -139,18 +142,59 @@ func (s *StorageNode) ListWithKeysConditions(ctx context.Context) ([]string, []*
 	return keys, items, nil
 }
 
-func (s *StorageNode) UpdateCondition(ctx context.Context, conditionId string, newCondition *alertingv1.AlertCondition) error {
+// UpdateCondition replaces the stored condition. Pass storage.WithRevision
+// to only replace the revision the caller read; if the condition has changed
+// since, an Aborted error is returned.
+func (s *StorageNode) UpdateCondition(
+	ctx context.Context,
+	conditionId string,
+	newCondition *alertingv1.AlertCondition,
+	opts ...storage.PutOpt,
+) error {
 	ctxTimeout, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
-	storage, err := s.storage.GetContext(ctxTimeout)
+	store, err := s.storage.GetContext(ctxTimeout)
 	if err != nil {
 		return err
 	}
-	_, err = storage.Conditions.Get(ctx, path.Join(conditionPrefix, conditionId))
+	_, err = store.Conditions.Get(ctx, path.Join(conditionPrefix, conditionId))
 	if err != nil {
 		return shared.WithNotFoundErrorf("condition to update '%s' not found : %s", conditionId, err)
 	}
-	return storage.Conditions.Put(ctx, path.Join(conditionPrefix, conditionId), newCondition)
+	err = store.Conditions.Put(ctx, path.Join(conditionPrefix, conditionId), newCondition, opts...)
+	if errors.Is(err, storage.ErrConflict) {
+		return status.Errorf(codes.Aborted, "condition '%s' was modified concurrently, retry the update", conditionId)
+	}
+	return err
+}
+
+// ModifyCondition applies mutator to the latest stored condition and writes it
+// back, retrying with the new latest condition if it was modified concurrently.
+func (s *StorageNode) ModifyCondition(
+	ctx context.Context,
+	conditionId string,
+	mutator func(*alertingv1.AlertCondition),
+) error {
+	ctxTimeout, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	store, err := s.storage.GetContext(ctxTimeout)
+	if err != nil {
+		return err
+	}
+	key := path.Join(conditionPrefix, conditionId)
+	for {
+		var revision int64
+		cond, err := store.Conditions.Get(ctxTimeout, key, storage.WithRevisionOut(&revision))
+		if err != nil {
+			return shared.WithNotFoundErrorf("condition to update '%s' not found : %s", conditionId, err)
+		}
+		mutator(cond)
+		err = store.Conditions.Put(ctxTimeout, key, cond, storage.WithRevision(revision))
+		if errors.Is(err, storage.ErrConflict) {
+			continue
+		}
+		return err
+	}
 }
 
 func (s *StorageNode) DeleteCondition(ctx context.Context, conditionId string) error {
@@ -177,14 +221,14 @@ func (s *StorageNode) CreateEndpoint(ctx context.Context, endpointId string, end
 	return storage.Endpoints.Put(ctx, path.Join(endpointPrefix, endpointId), endpoint)
 }
 
-func (s *StorageNode) GetEndpoint(ctx context.Context, endpointId string) (*alertingv1.AlertEndpoint, error) {
+func (s *StorageNode) GetEndpoint(ctx context.Context, endpointId string, opts ...storage.GetOpt) (*alertingv1.AlertEndpoint, error) {
 	ctxTimeout, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 	storage, err := s.storage.GetContext(ctxTimeout)
 	if err != nil {
 		return nil, err
 	}
-	return storage.Endpoints.Get(ctx, path.Join(endpointPrefix, endpointId))
+	return storage.Endpoints.Get(ctx, path.Join(endpointPrefix, endpointId), opts...)
 }
 
 func (s *StorageNode) ListEndpoints(ctx context.Context) ([]*alertingv1.AlertEndpoint, error) {
@@ -215,18 +259,30 @@ func (s *StorageNode) ListWithKeysEndpoints(ctx context.Context) ([]string, []*a
 	return keys, items, nil
 }
 
-func (s *StorageNode) UpdateEndpoint(ctx context.Context, endpointId string, newEndpoint *alertingv1.AlertEndpoint) error {
+// UpdateEndpoint replaces the stored endpoint. Pass storage.WithRevision
+// to only replace the revision the caller read; if the endpoint has changed
+// since, an Aborted error is returned.
+func (s *StorageNode) UpdateEndpoint(
+	ctx context.Context,
+	endpointId string,
+	newEndpoint *alertingv1.AlertEndpoint,
+	opts ...storage.PutOpt,
+) error {
 	ctxTimeout, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
-	storage, err := s.storage.GetContext(ctxTimeout)
+	store, err := s.storage.GetContext(ctxTimeout)
 	if err != nil {
 		return err
 	}
-	_, err = storage.Endpoints.Get(ctxTimeout, path.Join(endpointPrefix, endpointId))
+	_, err = store.Endpoints.Get(ctxTimeout, path.Join(endpointPrefix, endpointId))
 	if err != nil {
 		return shared.WithNotFoundErrorf("condition to update '%s' not found : %s", endpointId, err)
 	}
-	return storage.Endpoints.Put(ctx, path.Join(endpointPrefix, endpointId), newEndpoint)
+	err = store.Endpoints.Put(ctx, path.Join(endpointPrefix, endpointId), newEndpoint, opts...)
+	if errors.Is(err, storage.ErrConflict) {
+		return status.Errorf(codes.Aborted, "endpoint '%s' was modified concurrently, retry the update", endpointId)
+	}
+	return err
 }
 
 func (s *StorageNode) DeleteEndpoint(ctx context.Context, endpointId string) error {
@@ -331,6 +387,78 @@ func (s *StorageNode) DeleteConditionStatusTracker(
 	return sts.Delete(conditionId)
 }
 
+func controlFlowStateKey(conditionId string) string {
+	return conditionId + ".controlflow"
+}
+
+// GetControlFlowState returns the persisted evaluation state of a control flow
+// condition, or an empty state if it has not been evaluated yet
+func (s *StorageNode) GetControlFlowState(
+	ctx context.Context,
+	conditionId string) (*ControlFlowState, error) {
+	ctxTimeout, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	storage, err := s.storage.GetContext(ctxTimeout)
+	if err != nil {
+		return nil, err
+	}
+	sts, err := storage.StateStorage.GetContext(ctxTimeout)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := sts.Get(controlFlowStateKey(conditionId))
+	if errors.Is(err, nats.ErrKeyNotFound) {
+		return &ControlFlowState{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var st *ControlFlowState
+	err = json.Unmarshal(entry.Value(), &st)
+	if err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+func (s *StorageNode) UpdateControlFlowState(
+	ctx context.Context,
+	conditionId string,
+	value *ControlFlowState) error {
+	ctxTimeout, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	storage, err := s.storage.GetContext(ctxTimeout)
+	if err != nil {
+		return err
+	}
+	sts, err := storage.StateStorage.GetContext(ctxTimeout)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	_, err = sts.Put(controlFlowStateKey(conditionId), data)
+	return err
+}
+
+func (s *StorageNode) DeleteControlFlowState(
+	ctx context.Context,
+	conditionId string) error {
+	ctxTimeout, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	storage, err := s.storage.GetContext(ctxTimeout)
+	if err != nil {
+		return err
+	}
+	sts, err := storage.StateStorage.GetContext(ctxTimeout)
+	if err != nil {
+		return err
+	}
+	return sts.Delete(controlFlowStateKey(conditionId))
+}
+
 func (s *StorageNode) CreateIncidentTracker(
 	ctx context.Context,
 	conditionId string,
@@ -0,0 +1,184 @@
+package alertstorage_test
+
+import (
+	"context"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	alertingv1 "github.com/rancher/opni/pkg/apis/alerting/v1"
+	"github.com/rancher/opni/pkg/storage"
+	"github.com/rancher/opni/plugins/alerting/pkg/alerting/alertstorage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+type revisionedValue[T any] struct {
+	value    T
+	revision int64
+}
+
+// revisionedStore is an in-memory store which honors put revisions. If
+// beforePut is set, it is called (without the lock held) before each put.
+type revisionedStore[T proto.Message] struct {
+	mu        sync.Mutex
+	values    map[string]revisionedValue[T]
+	revision  int64
+	beforePut func(key string)
+}
+
+func newRevisionedStore[T proto.Message]() *revisionedStore[T] {
+	return &revisionedStore[T]{
+		values: map[string]revisionedValue[T]{},
+	}
+}
+
+func (s *revisionedStore[T]) Put(_ context.Context, key string, value T, opts ...storage.PutOpt) error {
+	if s.beforePut != nil {
+		s.beforePut(key)
+	}
+	options := storage.PutOptions{}
+	options.Apply(opts...)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if options.Revision != nil && s.values[key].revision != *options.Revision {
+		return storage.ErrConflict
+	}
+	s.revision++
+	s.values[key] = revisionedValue[T]{
+		value:    proto.Clone(value).(T),
+		revision: s.revision,
+	}
+	if options.RevisionOut != nil {
+		*options.RevisionOut = s.revision
+	}
+	return nil
+}
+
+func (s *revisionedStore[T]) Get(_ context.Context, key string, opts ...storage.GetOpt) (T, error) {
+	options := storage.GetOptions{}
+	options.Apply(opts...)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.values[key]
+	if !ok {
+		var zero T
+		return zero, storage.ErrNotFound
+	}
+	if options.RevisionOut != nil {
+		*options.RevisionOut = v.revision
+	}
+	return proto.Clone(v.value).(T), nil
+}
+
+func (s *revisionedStore[T]) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.values, key)
+	return nil
+}
+
+func (s *revisionedStore[T]) ListKeys(_ context.Context, prefix string) ([]string, error) {
+	return nil, nil
+}
+
+func (s *revisionedStore[T]) Watch(_ context.Context, prefix string) (<-chan storage.WatchEvent[storage.KeyRevision[T]], error) {
+	return nil, nil
+}
+
+var _ = Describe("Storage Node", Label("unit"), func() {
+	var conditions *revisionedStore[*alertingv1.AlertCondition]
+	var endpoints *revisionedStore[*alertingv1.AlertEndpoint]
+	var node *alertstorage.StorageNode
+	ctx := context.Background()
+
+	BeforeEach(func() {
+		conditions = newRevisionedStore[*alertingv1.AlertCondition]()
+		endpoints = newRevisionedStore[*alertingv1.AlertEndpoint]()
+		node = alertstorage.NewStorageNode(alertstorage.WithStorage(&alertstorage.StorageAPIs{
+			Conditions: conditions,
+			Endpoints:  endpoints,
+		}))
+		Expect(node.CreateCondition(ctx, "cond", &alertingv1.AlertCondition{Name: "cond"})).To(Succeed())
+		Expect(node.CreateEndpoint(ctx, "endp", &alertingv1.AlertEndpoint{Name: "endp"})).To(Succeed())
+	})
+
+	When("updating a condition at a revision", func() {
+		It("should apply the update if the condition has not changed", func() {
+			var revision int64
+			cond, err := node.GetCondition(ctx, "cond", storage.WithRevisionOut(&revision))
+			Expect(err).NotTo(HaveOccurred())
+			cond.Description = "updated"
+			Expect(node.UpdateCondition(ctx, "cond", cond, storage.WithRevision(revision))).To(Succeed())
+
+			cond, err = node.GetCondition(ctx, "cond")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cond.Description).To(Equal("updated"))
+		})
+		It("should reject the update if the condition has changed", func() {
+			var revision int64
+			cond, err := node.GetCondition(ctx, "cond", storage.WithRevisionOut(&revision))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(node.UpdateCondition(ctx, "cond", &alertingv1.AlertCondition{Name: "other"})).To(Succeed())
+
+			cond.Description = "updated"
+			err = node.UpdateCondition(ctx, "cond", cond, storage.WithRevision(revision))
+			Expect(status.Code(err)).To(Equal(codes.Aborted))
+
+			cond, err = node.GetCondition(ctx, "cond")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cond.Name).To(Equal("other"))
+		})
+	})
+
+	When("modifying a condition", func() {
+		It("should retry on top of concurrent changes", func() {
+			concurrentWrites := 0
+			conditions.beforePut = func(key string) {
+				if concurrentWrites > 0 {
+					return
+				}
+				concurrentWrites++
+				cond, err := node.GetCondition(ctx, "cond")
+				Expect(err).NotTo(HaveOccurred())
+				cond.Description = "concurrent"
+				Expect(node.UpdateCondition(ctx, "cond", cond)).To(Succeed())
+			}
+
+			calls := 0
+			err := node.ModifyCondition(ctx, "cond", func(cond *alertingv1.AlertCondition) {
+				calls++
+				cond.Silence = &alertingv1.SilenceInfo{SilenceId: "silence"}
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(calls).To(Equal(2))
+
+			cond, err := node.GetCondition(ctx, "cond")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cond.Description).To(Equal("concurrent"))
+			Expect(cond.Silence.GetSilenceId()).To(Equal("silence"))
+		})
+		It("should return not found for a missing condition", func() {
+			err := node.ModifyCondition(ctx, "missing", func(*alertingv1.AlertCondition) {})
+			Expect(status.Code(err)).To(Equal(codes.NotFound))
+		})
+	})
+
+	When("updating an endpoint at a revision", func() {
+		It("should reject the update if the endpoint has changed", func() {
+			var revision int64
+			endp, err := node.GetEndpoint(ctx, "endp", storage.WithRevisionOut(&revision))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(node.UpdateEndpoint(ctx, "endp", &alertingv1.AlertEndpoint{Name: "other"})).To(Succeed())
+
+			endp.Description = "updated"
+			err = node.UpdateEndpoint(ctx, "endp", endp, storage.WithRevision(revision))
+			Expect(status.Code(err)).To(Equal(codes.Aborted))
+
+			_, err = node.GetEndpoint(ctx, "endp", storage.WithRevisionOut(&revision))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(node.UpdateEndpoint(ctx, "endp", endp, storage.WithRevision(revision))).To(Succeed())
+		})
+	})
+})
@@ -12,6 +12,7 @@ import (
 	"github.com/prometheus/common/model"
 	"github.com/rancher/opni/pkg/alerting/backend"
 	"github.com/rancher/opni/pkg/metrics/unmarshal"
+	"github.com/rancher/opni/pkg/storage"
 	"github.com/rancher/opni/plugins/alerting/pkg/apis/alertops"
 	"github.com/rancher/opni/plugins/metrics/pkg/apis/cortexadmin"
 	"github.com/rancher/opni/plugins/metrics/pkg/apis/cortexops"
@@ -124,6 +125,9 @@ func (p *Plugin) CreateAlertCondition(ctx context.Context, req *alertingv1.Alert
 	if err := alertingv1.DetailsHasImplementation(req.GetAlertType()); err != nil {
 		return nil, shared.WithNotFoundError(fmt.Sprintf("%s", err))
 	}
+	if err := p.ensureCapability(ctx, req); err != nil {
+		return nil, err
+	}
 	newId := uuid.New().String()
 	_, err := setupCondition(p, lg, ctx, req, newId)
 	if err != nil {
@@ -180,10 +184,14 @@ func (p *Plugin) UpdateAlertCondition(ctx context.Context, req *alertingv1.Updat
 	lg := p.Logger.With("handler", "UpdateAlertCondition")
 	lg.Debugf("Updating alert condition %s", req.Id)
 	conditionId := req.Id.Id
-	existing, err := p.storageNode.GetCondition(ctx, req.Id.Id)
+	var revision int64
+	existing, err := p.storageNode.GetCondition(ctx, req.Id.Id, storage.WithRevisionOut(&revision))
 	if err != nil {
 		return nil, err
 	}
+	if err := p.ensureCapability(ctx, req.UpdateAlert); err != nil {
+		return nil, err
+	}
 
 	_, err = setupCondition(p, lg, ctx, req.UpdateAlert, req.Id.Id)
 	if err != nil {
@@ -212,7 +220,7 @@ func (p *Plugin) UpdateAlertCondition(ctx context.Context, req *alertingv1.Updat
 			return nil, err
 		}
 	}
-	if err := p.storageNode.UpdateCondition(ctx, conditionId, req.UpdateAlert); err != nil {
+	if err := p.storageNode.UpdateCondition(ctx, conditionId, req.UpdateAlert, storage.WithRevision(revision)); err != nil {
 		return nil, err
 	}
 	return &emptypb.Empty{}, nil
@@ -224,6 +232,17 @@ func (p *Plugin) DeleteAlertCondition(ctx context.Context, ref *corev1.Reference
 	if err != nil {
 		return nil, err
 	}
+	ids, conds, err := p.storageNode.ListWithKeysConditions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i, id := range ids {
+		for _, operand := range conditionOperands(conds[i].GetAlertType()) {
+			if operand.GetId() == ref.Id {
+				return nil, validation.Errorf("condition is referenced by condition %s (%s), delete it first", conds[i].GetName(), id)
+			}
+		}
+	}
 	if err := deleteCondition(p, lg, ctx, existing, ref.Id); err != nil {
 		return nil, err
 	}
@@ -275,6 +294,20 @@ func (p *Plugin) AlertConditionStatus(ctx context.Context, ref *corev1.Reference
 			}, nil
 		}
 	}
+	if operands := conditionOperands(cond.GetAlertType()); operands != nil {
+		if !p.msgNode.IsRunning(ref.Id) {
+			return &alertingv1.AlertStatusResponse{
+				State: alertingv1.AlertConditionState_INVALIDATED,
+			}, nil
+		}
+		for _, operand := range operands {
+			if _, err := p.storageNode.GetCondition(ctx, operand.GetId()); err != nil {
+				return &alertingv1.AlertStatusResponse{
+					State: alertingv1.AlertConditionState_INVALIDATED,
+				}, nil
+			}
+		}
+	}
 
 	if ref, _ := handleSwitchCortexRules(cond.GetAlertType()); ref != nil {
 		// check monitoring backend is installed
@@ -413,14 +446,15 @@ func (p *Plugin) ActivateSilence(ctx context.Context, req *alertingv1.SilenceReq
 		p.Logger.Errorf("failed to post silence : %s", err)
 		return nil, err
 	}
-	newCondition := util.ProtoClone(existing)
-	newCondition.Silence = &alertingv1.SilenceInfo{ // not exact, but the difference will be negligible
+	silence := &alertingv1.SilenceInfo{ // not exact, but the difference will be negligible
 		SilenceId: respSilence.GetSilenceId(),
 		StartsAt:  timestamppb.Now(),
 		EndsAt:    timestamppb.New(time.Now().Add(req.Duration.AsDuration())),
 	}
 	// update K,V with new silence info for the respective condition
-	if err := p.storageNode.UpdateCondition(ctx, req.ConditionId.Id, newCondition); err != nil {
+	if err := p.storageNode.ModifyCondition(ctx, req.ConditionId.Id, func(cond *alertingv1.AlertCondition) {
+		cond.Silence = silence
+	}); err != nil {
 		return nil, err
 	}
 	return &emptypb.Empty{}, nil
@@ -458,11 +492,10 @@ func (p *Plugin) DeactivateSilence(ctx context.Context, req *corev1.Reference) (
 		return nil, err
 	}
 
-	// update existing proto with the silence info
-	newCondition := util.ProtoClone(existing)
-	newCondition.Silence = nil
 	// update K,V with new silence info for the respective condition
-	if err := p.storageNode.UpdateCondition(ctx, req.Id, newCondition); err != nil {
+	if err := p.storageNode.ModifyCondition(ctx, req.Id, func(cond *alertingv1.AlertCondition) {
+		cond.Silence = nil
+	}); err != nil {
 		return nil, err
 	}
 	return &emptypb.Empty{}, nil
@@ -547,6 +580,19 @@ func (p *Plugin) Timeline(ctx context.Context, req *alertingv1.TimelineRequest)
 				}
 				addMu.Unlock()
 			}
+			if operands := conditionOperands(condition.GetAlertType()); operands != nil {
+				// check composite tracker
+				activeWindows, err := p.storageNode.GetActiveWindowsFromIncidentTracker(ctx, ids[idx], start, end)
+				if err != nil {
+					p.Logger.Errorf("failed to get active windows from composite incident tracker : %s", err)
+					return
+				}
+				addMu.Lock()
+				resp.Items[ids[idx]] = &alertingv1.ActiveWindows{
+					Windows: activeWindows,
+				}
+				addMu.Unlock()
+			}
 			if mb := condition.GetAlertType().GetMonitoringBackend(); mb != nil {
 				// check system tracker
 				activeWindows, err := p.storageNode.GetActiveWindowsFromIncidentTracker(ctx, ids[idx], start, end)
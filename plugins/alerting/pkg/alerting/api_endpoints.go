@@ -10,6 +10,7 @@ import (
 	"github.com/rancher/opni/pkg/alerting/backend"
 	alertingv1 "github.com/rancher/opni/pkg/apis/alerting/v1"
 	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	"github.com/rancher/opni/pkg/storage"
 	"github.com/rancher/opni/plugins/alerting/pkg/alerting/alertstorage"
 	"github.com/rancher/opni/plugins/alerting/pkg/apis/alertops"
 	"golang.org/x/exp/slices"
@@ -28,8 +29,9 @@ func unredactSecrets(
 	node *alertstorage.StorageNode,
 	endpointId string,
 	endp *alertingv1.AlertEndpoint,
+	opts ...storage.GetOpt,
 ) error {
-	unredacted, err := node.GetEndpoint(ctx, endpointId)
+	unredacted, err := node.GetEndpoint(ctx, endpointId, opts...)
 	if err != nil {
 		return err
 	}
@@ -61,7 +63,8 @@ func (p *Plugin) GetAlertEndpoint(ctx context.Context, ref *corev1.Reference) (*
 }
 
 func (p *Plugin) UpdateAlertEndpoint(ctx context.Context, req *alertingv1.UpdateAlertEndpointRequest) (*alertingv1.InvolvedConditions, error) {
-	if err := unredactSecrets(ctx, p.storageNode, req.Id.Id, req.GetUpdateAlert()); err != nil {
+	var revision int64
+	if err := unredactSecrets(ctx, p.storageNode, req.Id.Id, req.GetUpdateAlert(), storage.WithRevisionOut(&revision)); err != nil {
 		return nil, err
 	}
 	if err := req.Validate(); err != nil {
@@ -93,7 +96,7 @@ func (p *Plugin) UpdateAlertEndpoint(ctx context.Context, req *alertingv1.Update
 			return nil, err
 		}
 	}
-	if err := p.storageNode.UpdateEndpoint(ctx, req.Id.Id, req.GetUpdateAlert()); err != nil {
+	if err := p.storageNode.UpdateEndpoint(ctx, req.Id.Id, req.GetUpdateAlert(), storage.WithRevision(revision)); err != nil {
 		return nil, err
 	}
 	return &alertingv1.InvolvedConditions{
@@ -198,6 +201,15 @@ func (p *Plugin) TestAlertEndpoint(ctx context.Context, req *alertingv1.TestAler
 	if pg := req.Endpoint.GetPagerDuty(); pg != nil {
 		typeName = "pagerduty"
 	}
+	if wh := req.Endpoint.GetWebhook(); wh != nil {
+		typeName = "webhook"
+	}
+	if teams := req.Endpoint.GetMsTeams(); teams != nil {
+		typeName = "msteams"
+	}
+	if og := req.Endpoint.GetOpsgenie(); og != nil {
+		typeName = "opsgenie"
+	}
 	if typeName == "" {
 		typeName = "unknown"
 	}
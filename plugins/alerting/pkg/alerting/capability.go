@@ -0,0 +1,68 @@
+package alerting
+
+import (
+	"context"
+	"strings"
+
+	alertingv1 "github.com/rancher/opni/pkg/apis/alerting/v1"
+	capabilityv1 "github.com/rancher/opni/pkg/apis/capability/v1"
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	"github.com/rancher/opni/pkg/capabilities"
+	"github.com/rancher/opni/pkg/capabilities/wellknown"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// The alerting capability is installed on every cluster with conditions which
+// evaluate the cluster's metrics. Declaring the dependency keeps the metrics
+// capability from being uninstalled while the cluster still has them.
+func (p *Plugin) newCapabilityBackend() *capabilities.GatewayBackend {
+	return capabilities.NewGatewayBackend(&capabilityv1.Details{
+		Name:         wellknown.CapabilityAlerting,
+		Source:       "plugin_alerting",
+		Dependencies: []string{wellknown.CapabilityMetrics},
+	}, p.clusterStore, capabilities.WithUninstallCheck(p.canUninstallCapability))
+}
+
+// metricsCondition reports whether the condition evaluates the metrics of a
+// downstream cluster, and returns the cluster if it does
+func metricsCondition(cond *alertingv1.AlertCondition) (*corev1.Reference, bool) {
+	if cond.GetAlertType().GetSystem() != nil {
+		return nil, false
+	}
+	ref := cond.GetClusterId()
+	if ref.GetId() == "" {
+		return nil, false
+	}
+	return ref, true
+}
+
+// ensureCapability installs the alerting capability on the condition's
+// cluster if the condition depends on it.
+func (p *Plugin) ensureCapability(ctx context.Context, cond *alertingv1.AlertCondition) error {
+	ref, ok := metricsCondition(cond)
+	if !ok {
+		return nil
+	}
+	if err := p.capabilityBackend.EnsureInstalled(ctx, ref); err != nil {
+		return status.Errorf(codes.Unavailable, "failed to install the alerting capability on cluster %s: %v", ref.GetId(), err)
+	}
+	return nil
+}
+
+func (p *Plugin) canUninstallCapability(ctx context.Context, cluster *corev1.Reference) error {
+	conds, err := p.storageNode.ListConditions(ctx)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, cond := range conds {
+		if ref, ok := metricsCondition(cond); ok && ref.GetId() == cluster.GetId() {
+			names = append(names, cond.GetName())
+		}
+	}
+	if len(names) > 0 {
+		return status.Errorf(codes.FailedPrecondition, "cluster still has alert conditions which must be deleted first: %s", strings.Join(names, ", "))
+	}
+	return nil
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/rancher/opni/pkg/alerting/shared"
 	alertingv1 "github.com/rancher/opni/pkg/apis/alerting/v1"
 	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	"github.com/rancher/opni/pkg/validation"
 )
 
 func setupCondition(
@@ -84,6 +85,18 @@ func setupCondition(
 		}
 		return &corev1.Reference{Id: newConditionId}, nil
 	}
+	if c := req.AlertType.GetComposition(); c != nil {
+		if err := p.handleCompositionAlertCreation(ctx, c, newConditionId, req.Name); err != nil {
+			return nil, err
+		}
+		return &corev1.Reference{Id: newConditionId}, nil
+	}
+	if cf := req.AlertType.GetControlFlow(); cf != nil {
+		if err := p.handleControlFlowAlertCreation(ctx, cf, newConditionId, req.Name); err != nil {
+			return nil, err
+		}
+		return &corev1.Reference{Id: newConditionId}, nil
+	}
 	return nil, shared.AlertingErrNotImplemented
 }
 
@@ -106,6 +119,19 @@ func deleteCondition(p *Plugin, lg *zap.SugaredLogger, ctx context.Context, req
 		p.storageNode.DeleteConditionStatusTracker(ctx, id)
 		return nil
 	}
+	if r := req.AlertType.GetComposition(); r != nil {
+		p.msgNode.RemoveConfigListener(id)
+		p.storageNode.DeleteIncidentTracker(ctx, id)
+		p.storageNode.DeleteConditionStatusTracker(ctx, id)
+		return nil
+	}
+	if r := req.AlertType.GetControlFlow(); r != nil {
+		p.msgNode.RemoveConfigListener(id)
+		p.storageNode.DeleteIncidentTracker(ctx, id)
+		p.storageNode.DeleteConditionStatusTracker(ctx, id)
+		p.storageNode.DeleteControlFlowState(ctx, id)
+		return nil
+	}
 	if r, _ := handleSwitchCortexRules(req.AlertType); r != nil {
 		_, err := p.adminClient.Get().DeleteRule(ctx, &cortexadmin.RuleRequest{
 			ClusterId: r.Id,
@@ -175,6 +201,83 @@ func (p *Plugin) handleMonitoringBackendAlertCreation(
 	return nil
 }
 
+func (p *Plugin) handleCompositionAlertCreation(
+	ctx context.Context,
+	c *alertingv1.AlertConditionComposition,
+	newConditionId string,
+	conditionName string,
+) error {
+	if err := p.validateConditionOperands(ctx, newConditionId, c.GetX(), c.GetY()); err != nil {
+		return err
+	}
+	err := p.onCompositionConditionCreate(newConditionId, conditionName, c)
+	if err != nil {
+		p.Logger.Errorf("failed to create composition condition %s", err)
+	}
+	return err
+}
+
+func (p *Plugin) handleControlFlowAlertCreation(
+	ctx context.Context,
+	cf *alertingv1.AlertConditionControlFlow,
+	newConditionId string,
+	conditionName string,
+) error {
+	if err := p.validateConditionOperands(ctx, newConditionId, cf.GetX(), cf.GetY()); err != nil {
+		return err
+	}
+	err := p.onControlFlowConditionCreate(newConditionId, conditionName, cf)
+	if err != nil {
+		p.Logger.Errorf("failed to create control flow condition %s", err)
+	}
+	return err
+}
+
+// conditionOperands returns the conditions a composition or control flow condition is derived from
+func conditionOperands(t *alertingv1.AlertTypeDetails) []*corev1.Reference {
+	if c := t.GetComposition(); c != nil {
+		return []*corev1.Reference{c.GetX(), c.GetY()}
+	}
+	if cf := t.GetControlFlow(); cf != nil {
+		return []*corev1.Reference{cf.GetX(), cf.GetY()}
+	}
+	return nil
+}
+
+// validateConditionOperands checks that the referenced conditions exist, and that
+// following them never leads back to the condition being created or updated
+func (p *Plugin) validateConditionOperands(ctx context.Context, conditionId string, operands ...*corev1.Reference) error {
+	visited := map[string]struct{}{}
+	stack := append([]*corev1.Reference{}, operands...)
+	for len(stack) > 0 {
+		ref := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if ref.GetId() == conditionId {
+			return validation.Errorf("condition %s cannot reference itself", conditionId)
+		}
+		if _, ok := visited[ref.GetId()]; ok {
+			continue
+		}
+		visited[ref.GetId()] = struct{}{}
+		cond, err := p.storageNode.GetCondition(ctx, ref.GetId())
+		if err != nil {
+			return validation.Errorf("referenced condition %s could not be found : %s", ref.GetId(), err)
+		}
+		stack = append(stack, conditionOperands(cond.GetAlertType())...)
+	}
+	return nil
+}
+
+// isConditionFiring reports silenced conditions as firing, since silences only affect notifications
+func (p *Plugin) isConditionFiring(ctx context.Context, ref *corev1.Reference) (bool, error) {
+	st, err := p.AlertConditionStatus(ctx, ref)
+	if err != nil {
+		return false, err
+	}
+	return st.GetState() == alertingv1.AlertConditionState_FIRING ||
+		st.GetState() == alertingv1.AlertConditionState_SILENCED, nil
+}
+
 func (p *Plugin) handleKubeAlertCreation(ctx context.Context, k *alertingv1.AlertConditionKubeState, newId, alertName string) error {
 	baseKubeRule, err := metrics.NewKubeStateRule(
 		k.GetObjectType(),
@@ -650,3 +753,149 @@ func (p *Plugin) onCortexClusterStatusCreate(conditionId, conditionName string,
 	})
 	return nil
 }
+
+func (p *Plugin) onCompositionConditionCreate(conditionId, conditionName string, condition *alertingv1.AlertConditionComposition) error {
+	lg := p.Logger.With("onCompositionConditionCreate", conditionId)
+	lg.Debugf("received condition update: %v", condition)
+	jsCtx, cancel := context.WithCancel(p.Ctx)
+	lg.Debugf("Creating composition %s of %s and %s", condition.GetAction(), condition.GetX().GetId(), condition.GetY().GetId())
+
+	evaluator := p.newCompositeConditionEvaluator(
+		conditionId,
+		conditionName,
+		lg,
+		condition.GetTriggerAnnotations(),
+		jsCtx,
+		cancel,
+		0, // the operands already account for their own durations
+	)
+	// handles re-entrant conditions
+	evaluator.CalculateInitialState()
+	go func() {
+		defer cancel() // cancel parent context, if we return (non-recoverable)
+		evaluator.PollLoop(func(ctx context.Context) (bool, *timestamppb.Timestamp, error) {
+			xFiring, err := p.isConditionFiring(ctx, condition.GetX())
+			if err != nil {
+				return false, nil, err
+			}
+			yFiring, err := p.isConditionFiring(ctx, condition.GetY())
+			if err != nil {
+				return false, nil, err
+			}
+			switch condition.GetAction() {
+			case alertingv1.CompositionAction_AND:
+				return !(xFiring && yFiring), timestamppb.Now(), nil
+			case alertingv1.CompositionAction_OR:
+				return !(xFiring || yFiring), timestamppb.Now(), nil
+			}
+			return true, timestamppb.Now(), nil
+		})
+	}()
+	// spawn a watcher for the incidents
+	go func() {
+		evaluator.EvaluateLoop()
+	}()
+	p.msgNode.AddSystemConfigListener(conditionId, messaging.EvaluatorContext{
+		Ctx:    evaluator.evaluationCtx,
+		Cancel: evaluator.cancelEvaluation,
+	})
+	return nil
+}
+
+func (p *Plugin) onControlFlowConditionCreate(conditionId, conditionName string, condition *alertingv1.AlertConditionControlFlow) error {
+	lg := p.Logger.With("onControlFlowConditionCreate", conditionId)
+	lg.Debugf("received condition update: %v", condition)
+	jsCtx, cancel := context.WithCancel(p.Ctx)
+	lg.Debugf("Creating control flow %s of %s and %s with timeout %s",
+		condition.GetAction(), condition.GetX().GetId(), condition.GetY().GetId(), condition.GetFor().AsDuration())
+
+	evaluator := p.newCompositeConditionEvaluator(
+		conditionId,
+		conditionName,
+		lg,
+		condition.GetTriggerAnnotations(),
+		jsCtx,
+		cancel,
+		condition.GetFor().AsDuration(),
+	)
+	// handles re-entrant conditions
+	evaluator.CalculateInitialState()
+	go func() {
+		defer cancel() // cancel parent context, if we return (non-recoverable)
+		evaluator.PollLoop(func(ctx context.Context) (bool, *timestamppb.Timestamp, error) {
+			xFiring, err := p.isConditionFiring(ctx, condition.GetX())
+			if err != nil {
+				return false, nil, err
+			}
+			yFiring, err := p.isConditionFiring(ctx, condition.GetY())
+			if err != nil {
+				return false, nil, err
+			}
+			// the ordering of x and y is persisted, so it survives restarts and reindexing
+			st, err := p.storageNode.GetControlFlowState(ctx, conditionId)
+			if err != nil {
+				return false, nil, err
+			}
+			healthy, ts := st.Observe(condition.GetAction(), xFiring, yFiring, time.Now())
+			if err := p.storageNode.UpdateControlFlowState(ctx, conditionId, st); err != nil {
+				return false, nil, err
+			}
+			return healthy, ts, nil
+		})
+	}()
+	// spawn a watcher for the incidents
+	go func() {
+		evaluator.EvaluateLoop()
+	}()
+	p.msgNode.AddSystemConfigListener(conditionId, messaging.EvaluatorContext{
+		Ctx:    evaluator.evaluationCtx,
+		Cancel: evaluator.cancelEvaluation,
+	})
+	return nil
+}
+
+// newCompositeConditionEvaluator creates an evaluator for conditions whose health is
+// derived from the state of other conditions, rather than from a stream
+func (p *Plugin) newCompositeConditionEvaluator(
+	conditionId, conditionName string,
+	lg *zap.SugaredLogger,
+	labels map[string]string,
+	evaluationCtx context.Context,
+	cancel context.CancelFunc,
+	evaluateDuration time.Duration,
+) *InternalConditionEvaluator[*alertingv1.AlertStatusResponse] {
+	return NewInternalConditionEvaluator(
+		&internalConditionMetadata{
+			conditionId:        conditionId,
+			conditionName:      conditionName,
+			lg:                 lg,
+			clusterId:          "", // unused here
+			alertmanagerlabels: labels,
+		},
+		&internalConditionContext{
+			parentCtx:        p.Ctx,
+			evaluationCtx:    evaluationCtx,
+			evaluateInterval: time.Second * 30,
+			cancelEvaluation: cancel,
+			evaluateDuration: evaluateDuration,
+		},
+		&internalConditionStorage{
+			storageNode: p.storageNode,
+		},
+		&internalConditionState{},
+		&internalConditionHooks[*alertingv1.AlertStatusResponse]{
+			triggerHook: func(ctx context.Context, conditionId string, labels map[string]string) {
+				_, _ = p.TriggerAlerts(ctx, &alertingv1.TriggerAlertsRequest{
+					ConditionId: &corev1.Reference{Id: conditionId},
+					Annotations: labels,
+				})
+			},
+			resolveHook: func(ctx context.Context, conditionId string, labels map[string]string) {
+				_, _ = p.ResolveAlerts(ctx, &alertingv1.ResolveAlertsRequest{
+					ConditionId: &corev1.Reference{Id: conditionId},
+					Annotations: labels,
+				})
+			},
+		},
+	)
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/tidwall/gjson"
 	"go.uber.org/zap"
 	"golang.org/x/exp/slices"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
@@ -47,6 +48,10 @@ func handleChoicesByType(
 		return p.fetchPrometheusQueryInfo(ctx)
 	case alertingv1.AlertType_MonitoringBackend:
 		return p.fetchMonitoringBackendInfo(ctx)
+	case alertingv1.AlertType_Composition:
+		return p.fetchCompositionInfo(ctx)
+	case alertingv1.AlertType_ControlFlow:
+		return p.fetchControlFlowInfo(ctx)
 	default:
 		return nil, shared.AlertingErrNotImplemented
 	}
@@ -683,3 +688,59 @@ func (p *Plugin) fetchMonitoringBackendInfo(ctx context.Context) (*alertingv1.Li
 		},
 	}, nil
 }
+
+// the durations offered for control flow conditions, any positive duration is accepted
+var controlFlowFors = []time.Duration{
+	time.Minute,
+	time.Minute * 5,
+	time.Minute * 10,
+	time.Minute * 30,
+	time.Hour,
+}
+
+func (p *Plugin) fetchConditionRefs(ctx context.Context) ([]*corev1.Reference, error) {
+	ids, _, err := p.storageNode.ListWithKeysConditions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	refs := make([]*corev1.Reference, 0, len(ids))
+	for _, id := range ids {
+		refs = append(refs, &corev1.Reference{Id: id})
+	}
+	return refs, nil
+}
+
+func (p *Plugin) fetchCompositionInfo(ctx context.Context) (*alertingv1.ListAlertTypeDetails, error) {
+	refs, err := p.fetchConditionRefs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &alertingv1.ListAlertTypeDetails{
+		Type: &alertingv1.ListAlertTypeDetails_Composition{
+			Composition: &alertingv1.ListAlertConditionComposition{
+				X: refs,
+				Y: refs,
+			},
+		},
+	}, nil
+}
+
+func (p *Plugin) fetchControlFlowInfo(ctx context.Context) (*alertingv1.ListAlertTypeDetails, error) {
+	refs, err := p.fetchConditionRefs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fors := make([]*durationpb.Duration, 0, len(controlFlowFors))
+	for _, f := range controlFlowFors {
+		fors = append(fors, durationpb.New(f))
+	}
+	return &alertingv1.ListAlertTypeDetails{
+		Type: &alertingv1.ListAlertTypeDetails_ControlFlow{
+			ControlFlow: &alertingv1.ListAlertConditionControlFlow{
+				X:    refs,
+				Y:    refs,
+				Fors: fors,
+			},
+		},
+	}, nil
+}
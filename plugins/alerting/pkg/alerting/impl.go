@@ -227,6 +227,46 @@ func (c *InternalConditionEvaluator[T]) SubscriberLoop() {
 	}
 }
 
+// infinite & blocking : must be run in a goroutine
+//
+// PollLoop is the counterpart of SubscriberLoop for conditions whose health is not
+// published on a stream, and must be periodically derived by polling instead.
+// The status tracker is only updated on health transitions, so that the timestamp
+// of the last transition is preserved for EvaluateLoop
+func (c *InternalConditionEvaluator[T]) PollLoop(
+	poll func(ctx context.Context) (healthy bool, ts *timestamppb.Timestamp, err error),
+) {
+	defer c.cancelEvaluation()
+	ticker := time.NewTicker(c.evaluateInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.parentCtx.Done():
+			return
+		case <-c.evaluationCtx.Done():
+			return
+		case <-ticker.C:
+			healthy, ts, err := poll(c.evaluationCtx)
+			if err != nil {
+				c.lg.Warnf("failed to poll health of condition %s : %s", c.conditionName, err)
+				continue
+			}
+			lastKnownState, err := c.storageNode.GetConditionStatusTracker(c.evaluationCtx, c.conditionId)
+			if err == nil && lastKnownState.Healthy == healthy {
+				continue
+			}
+			err = c.UpdateState(c.evaluationCtx, &alertstorage.State{
+				Healthy:   healthy,
+				Firing:    c.IsFiring(),
+				Timestamp: ts,
+			})
+			if err != nil {
+				c.lg.Error(err)
+			}
+		}
+	}
+}
+
 // infinite & blocking : must be run in a goroutine
 func (c *InternalConditionEvaluator[T]) EvaluateLoop() {
 	defer c.cancelEvaluation() // cancel parent context, if we return (non-recoverable)
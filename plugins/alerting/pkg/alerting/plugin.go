@@ -14,8 +14,10 @@ import (
 
 	alertingv1 "github.com/rancher/opni/pkg/apis/alerting/v1"
 	managementv1 "github.com/rancher/opni/pkg/apis/management/v1"
+	"github.com/rancher/opni/pkg/capabilities"
 	"github.com/rancher/opni/pkg/logger"
 	managementext "github.com/rancher/opni/pkg/plugins/apis/apiextensions/management"
+	"github.com/rancher/opni/pkg/plugins/apis/capability"
 	"github.com/rancher/opni/pkg/plugins/apis/system"
 	"github.com/rancher/opni/pkg/plugins/meta"
 	"github.com/rancher/opni/pkg/util/future"
@@ -50,6 +52,9 @@ type Plugin struct {
 	natsConn        future.Future[*nats.Conn]
 	js              future.Future[nats.JetStreamContext]
 	globalWatchers  InternalConditionWatcher
+
+	clusterStore      future.Future[storage.ClusterStore]
+	capabilityBackend *capabilities.GatewayBackend
 }
 
 type StorageAPIs struct {
@@ -75,7 +80,9 @@ func NewPlugin(ctx context.Context) *Plugin {
 		cortexOpsClient: future.New[cortexops.CortexOpsClient](),
 		natsConn:        future.New[*nats.Conn](),
 		js:              future.New[nats.JetStreamContext](),
+		clusterStore:    future.New[storage.ClusterStore](),
 	}
+	p.capabilityBackend = p.newCapabilityBackend()
 	return p
 }
 
@@ -88,7 +95,8 @@ func Scheme(ctx context.Context) meta.Scheme {
 	scheme := meta.NewScheme()
 	p := NewPlugin(ctx)
 	scheme.Add(system.SystemPluginID, system.NewPlugin(p))
-	// We omit the trigger server here since it should only be used internally
+	// The trigger server is exposed so that conditions evaluated by other plugins,
+	// such as log based SLOs, can fire & resolve their alerts
 	scheme.Add(managementext.ManagementAPIExtensionPluginID,
 		managementext.NewPlugin(
 			util.PackService(
@@ -103,7 +111,12 @@ func Scheme(ctx context.Context) meta.Scheme {
 				&alertops.AlertingAdmin_ServiceDesc,
 				p.opsNode,
 			),
+			util.PackService(
+				&trigger.Alerting_ServiceDesc,
+				p,
+			),
 		),
 	)
+	scheme.Add(capability.CapabilityBackendPluginID, capability.NewPlugin(p.capabilityBackend))
 	return scheme
 }
@@ -44,6 +44,15 @@ func (p *Plugin) UseManagementAPI(client managementv1.ManagementClient) {
 		os.Exit(1)
 	}
 	objectList.Visit(func(config *v1beta1.GatewayConfig) {
+		backend, err := machinery.ConfigureStorageBackend(p.Ctx, &config.Spec.Storage)
+		if err != nil {
+			p.Logger.With(
+				"err", err,
+			).Error("failed to configure storage backend")
+			os.Exit(1)
+		}
+		p.clusterStore.Set(backend)
+
 		opt := shared.NewAlertingOptions{
 			Namespace:             config.Spec.Alerting.Namespace,
 			WorkerNodesService:    config.Spec.Alerting.WorkerNodeService,
@@ -178,6 +187,14 @@ func (p *Plugin) reindexAlarms() {
 			lg.Debug("re-indexing monitoring backend")
 			p.onCortexClusterStatusCreate(id, conds[i].Name, mc)
 		}
+		if c := conds[i].GetAlertType().GetComposition(); c != nil {
+			lg.Debug("re-indexing composition")
+			p.onCompositionConditionCreate(id, conds[i].Name, c)
+		}
+		if cf := conds[i].GetAlertType().GetControlFlow(); cf != nil {
+			lg.Debug("re-indexing control flow")
+			p.onControlFlowConditionCreate(id, conds[i].Name, cf)
+		}
 	}
 	lg.Info("re-indexing alarms complete")
 }
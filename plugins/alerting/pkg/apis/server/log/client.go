@@ -0,0 +1,55 @@
+package log
+
+import (
+	managementv1 "github.com/rancher/opni/pkg/apis/management/v1"
+	"github.com/rancher/opni/pkg/util/waitctx"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+type ClientOptions struct {
+	listenAddr  string
+	dialOptions []grpc.DialOption
+}
+
+type ClientOption func(*ClientOptions)
+
+func (o *ClientOptions) apply(opts ...ClientOption) {
+	for _, op := range opts {
+		op(o)
+	}
+}
+
+func WithListenAddress(addr string) ClientOption {
+	return func(o *ClientOptions) {
+		o.listenAddr = addr
+	}
+}
+
+func WithDialOptions(options ...grpc.DialOption) ClientOption {
+	return func(o *ClientOptions) {
+		o.dialOptions = append(o.dialOptions, options...)
+	}
+}
+
+func NewClient(ctx waitctx.PermissiveContext, opts ...ClientOption) (AlertLogsClient, error) {
+	options := ClientOptions{
+		listenAddr: managementv1.DefaultManagementSocket(),
+		dialOptions: []grpc.DialOption{
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithChainStreamInterceptor(otelgrpc.StreamClientInterceptor()),
+			grpc.WithChainUnaryInterceptor(otelgrpc.UnaryClientInterceptor()),
+		},
+	}
+	options.apply(opts...)
+	cc, err := grpc.DialContext(ctx, options.listenAddr, options.dialOptions...)
+	if err != nil {
+		return nil, err
+	}
+	waitctx.Permissive.Go(ctx, func() {
+		<-ctx.Done()
+		cc.Close()
+	})
+	return NewAlertLogsClient(cc), nil
+}
@@ -0,0 +1,86 @@
+package loggingadmin
+
+import (
+	"time"
+
+	"github.com/rancher/opni/pkg/validation"
+	"golang.org/x/exp/slices"
+)
+
+// LogEventFields are the keyword fields of the log documents that can be used as events
+var LogEventFields = []string{
+	"log_type",
+	"anomaly_level",
+	"kubernetes_component",
+	"kubernetes.namespace_name.keyword",
+	"kubernetes.container_name.keyword",
+	"kubernetes.pod_name.keyword",
+}
+
+// LogTextFields are the full text fields of the log documents that can be used as events,
+// their values are matched as phrases instead of exact terms
+var LogTextFields = []string{
+	"log",
+	"masked_log",
+}
+
+// maxLogEventBuckets bounds the number of buckets of a single count request
+const maxLogEventBuckets = 1000
+
+func validateLogEvents(events []*LogEvent) error {
+	for _, event := range events {
+		if !slices.Contains(LogEventFields, event.GetKey()) && !slices.Contains(LogTextFields, event.GetKey()) {
+			return validation.Errorf("unsupported log event key %q", event.GetKey())
+		}
+		if len(event.GetValues()) == 0 {
+			return validation.Errorf("log event %q must have at least one value", event.GetKey())
+		}
+	}
+	return nil
+}
+
+func (r *LogEventCountRequest) Validate() error {
+	if r.GetClusterId() == "" {
+		return validation.Error("clusterId must be set")
+	}
+	if r.GetServiceId() == "" {
+		return validation.Error("serviceId must be set")
+	}
+	if r.GetStart() == nil || r.GetEnd() == nil {
+		return validation.Error("start and end must be set")
+	}
+	window := r.GetEnd().AsTime().Sub(r.GetStart().AsTime())
+	if window <= 0 {
+		return validation.Error("end must be after start")
+	}
+	if r.Step != nil {
+		step := r.GetStep().AsDuration()
+		if step < time.Second {
+			return validation.Error("step must be at least 1s")
+		}
+		if window/step > maxLogEventBuckets {
+			return validation.Errorf("step is too small, at most %d buckets can be requested", maxLogEventBuckets)
+		}
+	}
+	if err := validateLogEvents(r.GetTotalEvents()); err != nil {
+		return err
+	}
+	return validateLogEvents(r.GetGoodEvents())
+}
+
+func (r *LogServicesRequest) Validate() error {
+	if r.GetClusterId() == "" {
+		return validation.Error("clusterId must be set")
+	}
+	return nil
+}
+
+func (r *LogEventsRequest) Validate() error {
+	if r.GetClusterId() == "" {
+		return validation.Error("clusterId must be set")
+	}
+	if r.GetServiceId() == "" {
+		return validation.Error("serviceId must be set")
+	}
+	return nil
+}
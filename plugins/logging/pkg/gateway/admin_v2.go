@@ -409,6 +409,46 @@ func (m *LoggingManagerV2) GetOpensearchStatus(ctx context.Context, in *emptypb.
 	}, nil
 }
 
+func (m *LoggingManagerV2) CountLogEvents(ctx context.Context, req *loggingadmin.LogEventCountRequest) (*loggingadmin.LogEventCountResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	resp, err := m.opensearchManager.CountLogEvents(ctx, req)
+	if err != nil {
+		m.logger.With("err", err).Error("failed to count log events")
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (m *LoggingManagerV2) ListLogServices(ctx context.Context, req *loggingadmin.LogServicesRequest) (*loggingadmin.LogServicesResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	services, err := m.opensearchManager.ListLogServices(ctx, req.GetClusterId())
+	if err != nil {
+		m.logger.With("err", err).Error("failed to list log services")
+		return nil, err
+	}
+	return &loggingadmin.LogServicesResponse{
+		Services: services,
+	}, nil
+}
+
+func (m *LoggingManagerV2) ListLogEvents(ctx context.Context, req *loggingadmin.LogEventsRequest) (*loggingadmin.LogEventsResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	events, err := m.opensearchManager.ListLogEvents(ctx, req.GetClusterId(), req.GetServiceId())
+	if err != nil {
+		m.logger.With("err", err).Error("failed to list log events")
+		return nil, err
+	}
+	return &loggingadmin.LogEventsResponse{
+		Events: events,
+	}, nil
+}
+
 func generateDataDetails(pools []opsterv1.NodePool) (*loggingadmin.DataDetails, error) {
 	var (
 		referencePool opsterv1.NodePool
@@ -0,0 +1,236 @@
+package opensearchdata
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/rancher/opni/plugins/logging/pkg/apis/loggingadmin"
+	loggingerrors "github.com/rancher/opni/plugins/logging/pkg/errors"
+	"github.com/tidwall/gjson"
+	"golang.org/x/exp/slices"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+/*
+Log events are counted from the opni log indices only : the total events are the
+log documents of a service that match the requested total events, and the good
+events are the subset of those that also match the requested good events.
+*/
+
+const (
+	// LogIndexAlias is the write alias of the opni log indices
+	LogIndexAlias     = "logs"
+	logClusterIdField = "cluster_id"
+	logServiceField   = "deployment.keyword"
+	logTimestampField = "time"
+
+	maxServiceBuckets = 500
+	maxEventBuckets   = 50
+)
+
+type jsonObj = map[string]any
+
+// logEventsFilter matches the log documents of the given service in [start, end) matching
+// every event, where an event matches any of its values.
+func logEventsFilter(clusterId, serviceId string, events []*loggingadmin.LogEvent, start, end time.Time) jsonObj {
+	filters := []any{
+		jsonObj{"term": jsonObj{logClusterIdField: clusterId}},
+		jsonObj{"term": jsonObj{logServiceField: serviceId}},
+		jsonObj{"range": jsonObj{logTimestampField: jsonObj{
+			"gte":    start.UnixMilli(),
+			"lt":     end.UnixMilli(),
+			"format": "epoch_millis",
+		}}},
+	}
+	for _, event := range events {
+		if slices.Contains(loggingadmin.LogTextFields, event.GetKey()) {
+			should := []any{}
+			for _, val := range event.GetValues() {
+				should = append(should, jsonObj{"match_phrase": jsonObj{event.GetKey(): val}})
+			}
+			filters = append(filters, jsonObj{"bool": jsonObj{
+				"should":               should,
+				"minimum_should_match": 1,
+			}})
+			continue
+		}
+		filters = append(filters, jsonObj{"terms": jsonObj{event.GetKey(): event.GetValues()}})
+	}
+	return jsonObj{"bool": jsonObj{"filter": filters}}
+}
+
+// LogEventsCountQuery counts the total & good log events of the request, and buckets them
+// by the request's step if it is set.
+//
+// Response paths : `hits.total.value`, `aggregations.good.doc_count`, and
+// `aggregations.events.buckets.#.{key,doc_count,good.doc_count}` for the buckets
+func LogEventsCountQuery(req *loggingadmin.LogEventCountRequest) ([]byte, error) {
+	start, end := req.GetStart().AsTime(), req.GetEnd().AsTime()
+	good := jsonObj{
+		"filter": logEventsFilter(req.GetClusterId(), req.GetServiceId(), req.GetGoodEvents(), start, end),
+	}
+	aggs := jsonObj{
+		"good": good,
+	}
+	if req.Step != nil {
+		aggs["events"] = jsonObj{
+			"date_histogram": jsonObj{
+				"field":          logTimestampField,
+				"fixed_interval": strconv.FormatInt(int64(req.GetStep().AsDuration()/time.Second), 10) + "s",
+				"min_doc_count":  0,
+				"extended_bounds": jsonObj{
+					"min": start.UnixMilli(),
+					"max": end.UnixMilli(),
+				},
+			},
+			"aggs": jsonObj{
+				"good": good,
+			},
+		}
+	}
+	return json.Marshal(jsonObj{
+		"size":             0,
+		"track_total_hits": true,
+		"query":            logEventsFilter(req.GetClusterId(), req.GetServiceId(), req.GetTotalEvents(), start, end),
+		"aggs":             aggs,
+	})
+}
+
+// LogServicesQuery lists the services that have emitted logs on the given cluster.
+//
+// Response path : `aggregations.services.buckets.#.key`
+func LogServicesQuery(clusterId string) ([]byte, error) {
+	return json.Marshal(jsonObj{
+		"size": 0,
+		"query": jsonObj{"bool": jsonObj{"filter": []any{
+			jsonObj{"term": jsonObj{logClusterIdField: clusterId}},
+		}}},
+		"aggs": jsonObj{
+			"services": jsonObj{
+				"terms": jsonObj{
+					"field": logServiceField,
+					"size":  maxServiceBuckets,
+				},
+			},
+		},
+	})
+}
+
+// logEventAggregationName is the name of the terms aggregation for the idx-th `LogEventFields`,
+// since field names contain dots they can't be used as-is in response paths
+func logEventAggregationName(idx int) string {
+	return "event_" + strconv.Itoa(idx)
+}
+
+// LogEventsQuery lists the most frequent values of each of the `LogEventFields`
+// for the logs of the given service.
+//
+// Response path : `aggregations.event_<field index>.buckets.#.key`
+func LogEventsQuery(clusterId, serviceId string) ([]byte, error) {
+	aggs := jsonObj{}
+	for idx, field := range loggingadmin.LogEventFields {
+		aggs[logEventAggregationName(idx)] = jsonObj{
+			"terms": jsonObj{
+				"field": field,
+				"size":  maxEventBuckets,
+			},
+		}
+	}
+	return json.Marshal(jsonObj{
+		"size": 0,
+		"query": jsonObj{"bool": jsonObj{"filter": []any{
+			jsonObj{"term": jsonObj{logClusterIdField: clusterId}},
+			jsonObj{"term": jsonObj{logServiceField: serviceId}},
+		}}},
+		"aggs": aggs,
+	})
+}
+
+func (m *Manager) CountLogEvents(ctx context.Context, req *loggingadmin.LogEventCountRequest) (*loggingadmin.LogEventCountResponse, error) {
+	query, err := LogEventsCountQuery(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := m.searchLogs(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	res := &loggingadmin.LogEventCountResponse{
+		Total: gjson.GetBytes(resp, "hits.total.value").Int(),
+		Good:  gjson.GetBytes(resp, "aggregations.good.doc_count").Int(),
+	}
+	for _, bucket := range gjson.GetBytes(resp, "aggregations.events.buckets").Array() {
+		res.Buckets = append(res.Buckets, &loggingadmin.LogEventCountBucket{
+			Timestamp: timestamppb.New(time.UnixMilli(bucket.Get("key").Int())),
+			Total:     bucket.Get("doc_count").Int(),
+			Good:      bucket.Get("good.doc_count").Int(),
+		})
+	}
+	return res, nil
+}
+
+func (m *Manager) ListLogServices(ctx context.Context, clusterId string) ([]string, error) {
+	query, err := LogServicesQuery(clusterId)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := m.searchLogs(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	services := []string{}
+	for _, svc := range gjson.GetBytes(resp, "aggregations.services.buckets.#.key").Array() {
+		services = append(services, svc.String())
+	}
+	return services, nil
+}
+
+func (m *Manager) ListLogEvents(ctx context.Context, clusterId, serviceId string) ([]*loggingadmin.LogEvent, error) {
+	query, err := LogEventsQuery(clusterId, serviceId)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := m.searchLogs(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	events := []*loggingadmin.LogEvent{}
+	for idx, field := range loggingadmin.LogEventFields {
+		keys := gjson.GetBytes(resp, "aggregations."+logEventAggregationName(idx)+".buckets.#.key").Array()
+		if len(keys) == 0 {
+			continue
+		}
+		event := &loggingadmin.LogEvent{
+			Key: field,
+		}
+		for _, val := range keys {
+			event.Values = append(event.Values, val.String())
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// searchLogs runs the query against the log indices. It only reads from opensearch,
+// so it doesn't wait on admin operations.
+func (m *Manager) searchLogs(ctx context.Context, query []byte) ([]byte, error) {
+	m.WaitForInit()
+	m.RLock()
+	defer m.RUnlock()
+
+	resp, err := m.Client.Indices.Search(ctx, []string{LogIndexAlias}, bytes.NewReader(query))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.IsError() {
+		return nil, loggingerrors.ErrOpensearchRequestFailed(resp.String())
+	}
+
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,101 @@
+package opensearchdata_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/rancher/opni/pkg/test"
+	"github.com/rancher/opni/pkg/validation"
+	"github.com/rancher/opni/plugins/logging/pkg/apis/loggingadmin"
+	"github.com/rancher/opni/plugins/logging/pkg/opensearchdata"
+	"github.com/tidwall/gjson"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var _ = Describe("Log event queries", Label(test.Unit), func() {
+	start := time.Unix(0, 0)
+	end := start.Add(time.Hour)
+
+	countRequest := func() *loggingadmin.LogEventCountRequest {
+		return &loggingadmin.LogEventCountRequest{
+			ClusterId: "agent",
+			ServiceId: "nginx",
+			Start:     timestamppb.New(start),
+			End:       timestamppb.New(end),
+			TotalEvents: []*loggingadmin.LogEvent{
+				{Key: "log_type", Values: []string{"workload"}},
+			},
+			GoodEvents: []*loggingadmin.LogEvent{
+				{Key: "log", Values: []string{"\" 200 ", "\" 201 "}},
+			},
+		}
+	}
+
+	It("should match keyword events as terms and text events as phrases", func() {
+		data, err := opensearchdata.LogEventsCountQuery(countRequest())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(gjson.GetBytes(data, "size").Int()).To(BeEquivalentTo(0))
+		Expect(gjson.GetBytes(data, "query.bool.filter").Array()).To(HaveLen(4))
+		Expect(gjson.GetBytes(data, "query.bool.filter.0.term.cluster_id").String()).To(Equal("agent"))
+		Expect(gjson.GetBytes(data, `query.bool.filter.1.term.deployment\.keyword`).String()).To(Equal("nginx"))
+		Expect(gjson.GetBytes(data, "query.bool.filter.2.range.time.lt").Int()).To(Equal(end.UnixMilli()))
+		Expect(gjson.GetBytes(data, "query.bool.filter.3.terms.log_type.0").String()).To(Equal("workload"))
+		Expect(gjson.GetBytes(data, "aggs.good.filter.bool.filter.3.bool.should.#.match_phrase.log").Array()).To(HaveLen(2))
+		Expect(gjson.GetBytes(data, "aggs.events").Exists()).To(BeFalse())
+	})
+
+	It("should bucket events by the requested step", func() {
+		req := countRequest()
+		req.Step = durationpb.New(time.Minute)
+		data, err := opensearchdata.LogEventsCountQuery(req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(gjson.GetBytes(data, "aggs.events.date_histogram.fixed_interval").String()).To(Equal("60s"))
+		Expect(gjson.GetBytes(data, "aggs.events.aggs.good.filter").Exists()).To(BeTrue())
+	})
+
+	It("should aggregate every candidate event field", func() {
+		data, err := opensearchdata.LogEventsQuery("agent", "nginx")
+		Expect(err).NotTo(HaveOccurred())
+		for _, field := range loggingadmin.LogEventFields {
+			Expect(gjson.GetBytes(data, `aggs.@values.#(terms.field=="`+field+`")`).Exists()).To(BeTrue(), field)
+		}
+	})
+
+	It("should list the services of a cluster", func() {
+		data, err := opensearchdata.LogServicesQuery("agent")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(gjson.GetBytes(data, "query.bool.filter.0.term.cluster_id").String()).To(Equal("agent"))
+		Expect(gjson.GetBytes(data, "aggs.services.terms.field").String()).To(Equal("deployment.keyword"))
+	})
+
+	Context("validating count requests", func() {
+		It("should accept known fields", func() {
+			Expect(countRequest().Validate()).To(Succeed())
+		})
+		It("should reject fields outside of the log event fields", func() {
+			req := countRequest()
+			req.GoodEvents = append(req.GoodEvents, &loggingadmin.LogEvent{
+				Key:    "_index",
+				Values: []string{"secrets"},
+			})
+			Expect(req.Validate()).To(BeAssignableToTypeOf(&validation.ValidationError{}))
+		})
+		It("should reject events without values", func() {
+			req := countRequest()
+			req.TotalEvents[0].Values = nil
+			Expect(req.Validate()).To(BeAssignableToTypeOf(&validation.ValidationError{}))
+		})
+		It("should reject inverted time ranges", func() {
+			req := countRequest()
+			req.Start, req.End = req.End, req.Start
+			Expect(req.Validate()).To(BeAssignableToTypeOf(&validation.ValidationError{}))
+		})
+		It("should reject too many buckets", func() {
+			req := countRequest()
+			req.Step = durationpb.New(time.Second)
+			Expect(req.Validate()).To(BeAssignableToTypeOf(&validation.ValidationError{}))
+		})
+	})
+})
@@ -63,3 +63,13 @@ func (c *AsyncOpensearchClient) Lock() {
 func (c *AsyncOpensearchClient) Unlock() {
 	c.rw.RUnlock()
 }
+
+// RLock guards read only requests against the client being unset. It is the same
+// shared lock as Lock, so concurrent readers don't block each other.
+func (c *AsyncOpensearchClient) RLock() {
+	c.rw.RLock()
+}
+
+func (c *AsyncOpensearchClient) RUnlock() {
+	c.rw.RUnlock()
+}
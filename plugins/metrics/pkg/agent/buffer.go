@@ -0,0 +1,184 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrBufferFull = errors.New("remote-write buffer is full")
+
+const bufferEntrySuffix = ".payload"
+
+type bufferEntry struct {
+	seq       uint64
+	size      int64
+	timestamp time.Time
+}
+
+// RemoteWriteBuffer is a bounded on-disk queue of remote-write payloads which
+// could not be delivered to the gateway. Each payload is stored in its own
+// file, named by a sequence number, so that payloads can be replayed in the
+// order they were received, including after the agent restarts.
+type RemoteWriteBuffer struct {
+	dir     string
+	maxSize int64
+
+	mu      sync.Mutex
+	entries []bufferEntry
+	size    int64
+	nextSeq uint64
+}
+
+// NewRemoteWriteBuffer opens the buffer in the given directory, creating it
+// if necessary. Payloads left in the directory by a previous run are kept
+// and will be replayed first.
+func NewRemoteWriteBuffer(dir string, maxSize int64) (*RemoteWriteBuffer, error) {
+	if maxSize <= 0 {
+		return nil, fmt.Errorf("invalid buffer size: %d", maxSize)
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create buffer directory: %w", err)
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read buffer directory: %w", err)
+	}
+	b := &RemoteWriteBuffer{
+		dir:     dir,
+		maxSize: maxSize,
+	}
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		name := f.Name()
+		if !strings.HasSuffix(name, bufferEntrySuffix) {
+			// partially written entries from a previous run
+			os.Remove(filepath.Join(dir, name))
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, bufferEntrySuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := f.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read buffer entry %s: %w", name, err)
+		}
+		b.entries = append(b.entries, bufferEntry{
+			seq:       seq,
+			size:      info.Size(),
+			timestamp: info.ModTime(),
+		})
+		b.size += info.Size()
+	}
+	sort.Slice(b.entries, func(i, j int) bool {
+		return b.entries[i].seq < b.entries[j].seq
+	})
+	if len(b.entries) > 0 {
+		b.nextSeq = b.entries[len(b.entries)-1].seq + 1
+	}
+	return b, nil
+}
+
+func (b *RemoteWriteBuffer) path(seq uint64) string {
+	return filepath.Join(b.dir, fmt.Sprintf("%020d%s", seq, bufferEntrySuffix))
+}
+
+// Append adds a payload to the end of the buffer. If the payload would
+// cause the buffer to exceed its maximum size, ErrBufferFull is returned.
+func (b *RemoteWriteBuffer) Append(data []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.size+int64(len(data)) > b.maxSize {
+		return ErrBufferFull
+	}
+	seq := b.nextSeq
+	path := b.path(seq)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write buffer entry: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write buffer entry: %w", err)
+	}
+	b.nextSeq++
+	b.entries = append(b.entries, bufferEntry{
+		seq:       seq,
+		size:      int64(len(data)),
+		timestamp: time.Now(),
+	})
+	b.size += int64(len(data))
+	return nil
+}
+
+// Peek returns the sequence number and contents of the oldest payload in the
+// buffer. If the buffer is empty, ok will be false.
+func (b *RemoteWriteBuffer) Peek() (seq uint64, data []byte, ok bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.entries) == 0 {
+		return 0, nil, false, nil
+	}
+	seq = b.entries[0].seq
+	data, err = os.ReadFile(b.path(seq))
+	if err != nil {
+		return seq, nil, true, fmt.Errorf("failed to read buffer entry: %w", err)
+	}
+	return seq, data, true, nil
+}
+
+// Remove deletes the oldest payload from the buffer, if its sequence number
+// matches seq.
+func (b *RemoteWriteBuffer) Remove(seq uint64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.entries) == 0 || b.entries[0].seq != seq {
+		return nil
+	}
+	if err := os.Remove(b.path(seq)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove buffer entry: %w", err)
+	}
+	b.size -= b.entries[0].size
+	b.entries = b.entries[1:]
+	return nil
+}
+
+// Len returns the number of payloads in the buffer.
+func (b *RemoteWriteBuffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.entries)
+}
+
+type BufferStats struct {
+	Count   int
+	Size    int64
+	MaxSize int64
+	// Time since the oldest payload in the buffer was received, or zero if
+	// the buffer is empty.
+	Lag time.Duration
+}
+
+func (b *RemoteWriteBuffer) Stats() BufferStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stats := BufferStats{
+		Count:   len(b.entries),
+		Size:    b.size,
+		MaxSize: b.maxSize,
+	}
+	if len(b.entries) > 0 {
+		stats.Lag = time.Since(b.entries[0].timestamp)
+	}
+	return stats
+}
@@ -0,0 +1,100 @@
+package agent_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/rancher/opni/plugins/metrics/pkg/agent"
+)
+
+var _ = Describe("Remote Write Buffer", Label("unit"), func() {
+	var dir string
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+	})
+
+	drain := func(b *agent.RemoteWriteBuffer) []string {
+		var payloads []string
+		for {
+			seq, data, ok, err := b.Peek()
+			Expect(err).NotTo(HaveOccurred())
+			if !ok {
+				return payloads
+			}
+			payloads = append(payloads, string(data))
+			Expect(b.Remove(seq)).To(Succeed())
+		}
+	}
+
+	It("should reject invalid sizes", func() {
+		_, err := agent.NewRemoteWriteBuffer(dir, 0)
+		Expect(err).To(HaveOccurred())
+	})
+	It("should return payloads in the order they were appended", func() {
+		b, err := agent.NewRemoteWriteBuffer(dir, 1024)
+		Expect(err).NotTo(HaveOccurred())
+		for _, p := range []string{"a", "b", "c"} {
+			Expect(b.Append([]byte(p))).To(Succeed())
+		}
+		Expect(b.Len()).To(Equal(3))
+		Expect(drain(b)).To(Equal([]string{"a", "b", "c"}))
+		Expect(b.Len()).To(Equal(0))
+		Expect(b.Stats().Size).To(BeZero())
+	})
+	It("should only remove the oldest payload", func() {
+		b, err := agent.NewRemoteWriteBuffer(dir, 1024)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(b.Append([]byte("a"))).To(Succeed())
+		Expect(b.Append([]byte("b"))).To(Succeed())
+		seq, _, _, err := b.Peek()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(b.Remove(seq + 1)).To(Succeed())
+		Expect(b.Len()).To(Equal(2))
+	})
+	It("should not exceed its maximum size", func() {
+		b, err := agent.NewRemoteWriteBuffer(dir, 10)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(b.Append([]byte("12345"))).To(Succeed())
+		Expect(b.Append([]byte("67890"))).To(Succeed())
+		Expect(b.Append([]byte("x"))).To(MatchError(agent.ErrBufferFull))
+
+		stats := b.Stats()
+		Expect(stats.Count).To(Equal(2))
+		Expect(stats.Size).To(BeEquivalentTo(10))
+		Expect(stats.MaxSize).To(BeEquivalentTo(10))
+		Expect(stats.Lag).To(BeNumerically(">", 0))
+
+		By("accepting payloads again once space is freed")
+		seq, _, _, err := b.Peek()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(b.Remove(seq)).To(Succeed())
+		Expect(b.Append([]byte("x"))).To(Succeed())
+		Expect(drain(b)).To(Equal([]string{"67890", "x"}))
+	})
+	It("should reload buffered payloads after a restart", func() {
+		b, err := agent.NewRemoteWriteBuffer(dir, 1024)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(b.Append([]byte("a"))).To(Succeed())
+		Expect(b.Append([]byte("b"))).To(Succeed())
+		seq, _, _, err := b.Peek()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(b.Remove(seq)).To(Succeed())
+		Expect(b.Append([]byte("c"))).To(Succeed())
+
+		// a partially written entry left behind by a crash
+		Expect(os.WriteFile(filepath.Join(dir, "tmp.payload.tmp"), []byte("partial"), 0o600)).To(Succeed())
+
+		reopened, err := agent.NewRemoteWriteBuffer(dir, 1024)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reopened.Len()).To(Equal(2))
+		Expect(reopened.Stats().Size).To(BeEquivalentTo(2))
+
+		By("appending new payloads after the reloaded ones")
+		Expect(reopened.Append([]byte("d"))).To(Succeed())
+		Expect(drain(reopened)).To(Equal([]string{"b", "c", "d"}))
+		Expect(filepath.Join(dir, "tmp.payload.tmp")).NotTo(BeAnExistingFile())
+	})
+})
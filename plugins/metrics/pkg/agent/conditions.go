@@ -1,6 +1,7 @@
 package agent
 
 const (
-	CondRemoteWrite = "Remote Write"
-	CondRuleSync    = "Rule Sync"
+	CondRemoteWrite       = "Remote Write"
+	CondRemoteWriteBuffer = "Remote Write Buffer"
+	CondRuleSync          = "Rule Sync"
 )
@@ -1,25 +1,36 @@
 package agent
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 	"sync"
+	"time"
 
 	"sync/atomic"
 
 	"github.com/gin-contrib/pprof"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/prometheus/model/relabel"
 	"github.com/valyala/bytebufferpool"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
 	"github.com/rancher/opni/pkg/clients"
 	"github.com/rancher/opni/pkg/health"
 	"github.com/rancher/opni/pkg/plugins/apis/apiextensions"
+	"github.com/rancher/opni/plugins/metrics/pkg/apis/node"
 	"github.com/rancher/opni/plugins/metrics/pkg/apis/remotewrite"
 )
 
+const (
+	bufferReplayInterval = 5 * time.Second
+	bufferReplayTimeout  = 30 * time.Second
+)
+
 type HttpServer struct {
 	apiextensions.UnsafeHTTPAPIExtensionServer
 
@@ -31,15 +42,53 @@ type HttpServer struct {
 	conditions health.ConditionTracker
 
 	enabled atomic.Bool
+
+	relabelMu      sync.RWMutex
+	relabelConfigs []*relabel.Config
+
+	buffer       *RemoteWriteBuffer
+	bufferFull   atomic.Bool
+	replayNotify chan struct{}
 }
 
-func NewHttpServer(ct health.ConditionTracker, lg *zap.SugaredLogger) *HttpServer {
-	return &HttpServer{
-		logger:     lg,
-		conditions: ct,
+type HttpServerOptions struct {
+	buffer *RemoteWriteBuffer
+}
+
+type HttpServerOption func(*HttpServerOptions)
+
+func (o *HttpServerOptions) apply(opts ...HttpServerOption) {
+	for _, op := range opts {
+		op(o)
 	}
 }
 
+// WithRemoteWriteBuffer enables buffering of remote-write payloads which
+// cannot be delivered to the gateway. Buffered payloads are replayed in order
+// once the gateway is reachable again.
+func WithRemoteWriteBuffer(buffer *RemoteWriteBuffer) HttpServerOption {
+	return func(o *HttpServerOptions) {
+		o.buffer = buffer
+	}
+}
+
+func NewHttpServer(ctx context.Context, ct health.ConditionTracker, lg *zap.SugaredLogger, opts ...HttpServerOption) *HttpServer {
+	options := HttpServerOptions{}
+	options.apply(opts...)
+
+	s := &HttpServer{
+		logger:       lg,
+		conditions:   ct,
+		buffer:       options.buffer,
+		replayNotify: make(chan struct{}, 1),
+	}
+	if s.buffer != nil {
+		s.updateBufferCondition()
+		go s.runBufferReplay(ctx)
+	}
+	return s
+}
+
 func (s *HttpServer) SetEnabled(enabled bool) {
 	if enabled {
 		s.conditions.Set(CondRemoteWrite, health.StatusPending, "")
@@ -47,12 +96,32 @@ func (s *HttpServer) SetEnabled(enabled bool) {
 		s.conditions.Clear(CondRemoteWrite)
 	}
 	s.enabled.Store(enabled)
+	if enabled {
+		s.notifyReplay()
+	}
 }
 
 func (s *HttpServer) SetRemoteWriteClient(client clients.Locker[remotewrite.RemoteWriteClient]) {
 	s.remoteWriteClientMu.Lock()
 	defer s.remoteWriteClientMu.Unlock()
 	s.remoteWriteClient = client
+	s.notifyReplay()
+}
+
+// SetRelabelConfigs sets the relabel configs applied to remote-write
+// payloads before they are sent to the gateway. Invalid configs are
+// ignored and leave the current configs in place.
+func (s *HttpServer) SetRelabelConfigs(configs []*node.RelabelConfig) {
+	cfgs, err := node.ToPrometheusConfigs(configs)
+	if err != nil {
+		s.logger.With(
+			zap.Error(err),
+		).Error("ignoring invalid relabel configs")
+		return
+	}
+	s.relabelMu.Lock()
+	defer s.relabelMu.Unlock()
+	s.relabelConfigs = cfgs
 }
 
 func (s *HttpServer) ConfigureRoutes(router *gin.Engine) {
@@ -65,60 +134,73 @@ func (s *HttpServer) handlePushRequest(c *gin.Context) {
 		c.Status(http.StatusServiceUnavailable)
 		return
 	}
+	buf := bytebufferpool.Get()
+	defer bytebufferpool.Put(buf)
+	if _, err := buf.ReadFrom(c.Request.Body); err != nil {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	s.relabelMu.RLock()
+	relabelConfigs := s.relabelConfigs
+	s.relabelMu.RUnlock()
+	if len(relabelConfigs) > 0 {
+		payload, ok, err := relabelPayload(buf.B, relabelConfigs)
+		if err != nil {
+			c.Error(err)
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		if !ok {
+			// all series were dropped
+			c.Status(http.StatusOK)
+			return
+		}
+		buf.B = payload
+	}
+
+	// While earlier payloads are still buffered, new payloads are added to the
+	// buffer behind them so that they are delivered in order.
+	if s.buffer != nil && s.buffer.Len() > 0 {
+		s.bufferOrReject(c, buf.B, http.StatusServiceUnavailable, "")
+		return
+	}
+
 	s.remoteWriteClientMu.RLock()
 	defer s.remoteWriteClientMu.RUnlock()
 	if s.remoteWriteClient == nil {
-		c.Status(http.StatusServiceUnavailable)
+		s.bufferOrReject(c, buf.B, http.StatusServiceUnavailable, "")
 		return
 	}
 	ok := s.remoteWriteClient.Use(func(rwc remotewrite.RemoteWriteClient) {
 		if rwc == nil {
 			s.conditions.Set(CondRemoteWrite, health.StatusPending, "gateway not connected")
 			c.Error(errors.New("gateway not connected"))
-			c.String(http.StatusServiceUnavailable, "gateway not connected")
+			s.bufferOrReject(c, buf.B, http.StatusServiceUnavailable, "gateway not connected")
 			return
 		}
 
-		buf := bytebufferpool.Get()
-		if _, err := buf.ReadFrom(c.Request.Body); err != nil {
-			c.Status(http.StatusInternalServerError)
-			return
-		}
 		_, err := rwc.Push(c.Request.Context(), &remotewrite.Payload{
 			Contents: buf.B,
 		})
-		bytebufferpool.Put(buf)
 
-		var respCode int
 		if err != nil {
-			stat := status.Convert(err)
-			// check if statusCode is a valid HTTP status code
-			if stat.Code() >= 100 && stat.Code() <= 599 {
-				respCode = int(stat.Code())
-			} else {
-				respCode = http.StatusServiceUnavailable
-			}
-			// As a special case, status code 400 may indicate a success.
-			// Cortex handles a variety of cases where prometheus would normally
-			// return an error, such as duplicate or out of order samples. Cortex
-			// will return code 400 to prometheus, which prometheus will treat as
-			// a non-retriable error. In this case, the remote write status condition
-			// will be cleared as if the request succeeded.
-			message := stat.Message()
-			if respCode == http.StatusBadRequest {
-				if strings.Contains(message, "out of bounds") ||
-					strings.Contains(message, "out of order sample") ||
-					strings.Contains(message, "duplicate sample for timestamp") ||
-					strings.Contains(message, "exemplars not ingested because series not already present") {
-					{
-						s.conditions.Clear(CondRemoteWrite)
-						c.Error(errors.New("soft error (request succeeded): " + message))
-						respCode = http.StatusOK // try returning 200, prometheus may be throttling on 400
-					}
-				}
-			} else {
-				s.conditions.Set(CondRemoteWrite, health.StatusFailure, stat.Message())
+			respCode, message, soft := remoteWriteErrorCode(err)
+			switch {
+			case soft:
+				s.conditions.Clear(CondRemoteWrite)
+				c.Error(errors.New("soft error (request succeeded): " + message))
+				respCode = http.StatusOK // try returning 200, prometheus may be throttling on 400
+			case respCode == http.StatusBadRequest:
+			default:
+				s.conditions.Set(CondRemoteWrite, health.StatusFailure, message)
 				c.Error(err)
+				// payloads rejected due to cluster ingestion limits are not
+				// buffered, so that prometheus backs off instead
+				if respCode >= http.StatusInternalServerError && status.Code(err) != codes.ResourceExhausted {
+					s.bufferOrReject(c, buf.B, respCode, message)
+					return
+				}
 			}
 
 			c.String(respCode, message)
@@ -129,6 +211,181 @@ func (s *HttpServer) handlePushRequest(c *gin.Context) {
 	})
 
 	if !ok {
-		c.Status(http.StatusServiceUnavailable)
+		s.bufferOrReject(c, buf.B, http.StatusServiceUnavailable, "")
+	}
+}
+
+// Returns the http status code corresponding to an error returned by the
+// gateway, and whether the error indicates that the request succeeded.
+func remoteWriteErrorCode(err error) (code int, message string, soft bool) {
+	stat := status.Convert(err)
+	// check if statusCode is a valid HTTP status code
+	if stat.Code() >= 100 && stat.Code() <= 599 {
+		code = int(stat.Code())
+	} else {
+		code = http.StatusServiceUnavailable
+	}
+	message = stat.Message()
+	// As a special case, status code 400 may indicate a success.
+	// Cortex handles a variety of cases where prometheus would normally
+	// return an error, such as duplicate or out of order samples. Cortex
+	// will return code 400 to prometheus, which prometheus will treat as
+	// a non-retriable error. In this case, the remote write status condition
+	// will be cleared as if the request succeeded.
+	if code == http.StatusBadRequest {
+		soft = strings.Contains(message, "out of bounds") ||
+			strings.Contains(message, "out of order sample") ||
+			strings.Contains(message, "duplicate sample for timestamp") ||
+			strings.Contains(message, "exemplars not ingested because series not already present")
+	}
+	return
+}
+
+// bufferOrReject adds the payload to the buffer and responds with 200 if
+// buffering is enabled, otherwise it responds with the given status code
+// and message.
+func (s *HttpServer) bufferOrReject(c *gin.Context, data []byte, code int, message string) {
+	if s.buffer == nil {
+		if message == "" {
+			c.Status(code)
+		} else {
+			c.String(code, message)
+		}
+		return
+	}
+	err := s.buffer.Append(data)
+	if errors.Is(err, ErrBufferFull) {
+		s.bufferFull.Store(true)
+	}
+	s.updateBufferCondition()
+	if err != nil {
+		s.logger.With(
+			zap.Error(err),
+		).Warn("failed to buffer remote-write payload")
+		c.Error(err)
+		c.String(http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+func (s *HttpServer) notifyReplay() {
+	if s.buffer == nil {
+		return
+	}
+	select {
+	case s.replayNotify <- struct{}{}:
+	default:
+	}
+}
+
+func (s *HttpServer) runBufferReplay(ctx context.Context) {
+	ticker := time.NewTicker(bufferReplayInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.replayNotify:
+		case <-ticker.C:
+		}
+		s.replayBuffer(ctx)
+		s.updateBufferCondition()
+	}
+}
+
+// replayBuffer pushes buffered payloads to the gateway in order, until the
+// buffer is empty or a payload cannot be delivered.
+func (s *HttpServer) replayBuffer(ctx context.Context) {
+	if !s.enabled.Load() || s.buffer.Len() == 0 {
+		return
+	}
+	lg := s.logger
+	replayed := 0
+	defer func() {
+		if replayed > 0 {
+			lg.With(
+				"replayed", replayed,
+				"remaining", s.buffer.Len(),
+			).Info("replayed buffered remote-write payloads")
+		}
+	}()
+	for ctx.Err() == nil {
+		seq, data, ok, err := s.buffer.Peek()
+		if !ok {
+			return
+		}
+		if err != nil {
+			lg.With(
+				zap.Error(err),
+			).Error("dropping unreadable buffered payload")
+		} else if !s.pushBuffered(ctx, data) {
+			return
+		}
+		if err := s.buffer.Remove(seq); err != nil {
+			lg.With(
+				zap.Error(err),
+			).Error("failed to remove buffered payload")
+			return
+		}
+		s.bufferFull.Store(false)
+		replayed++
+	}
+}
+
+// pushBuffered pushes a buffered payload to the gateway, and returns false if
+// the payload should be retried later.
+func (s *HttpServer) pushBuffered(ctx context.Context, data []byte) bool {
+	s.remoteWriteClientMu.RLock()
+	defer s.remoteWriteClientMu.RUnlock()
+	if s.remoteWriteClient == nil {
+		return false
+	}
+	done := false
+	s.remoteWriteClient.Use(func(rwc remotewrite.RemoteWriteClient) {
+		if rwc == nil {
+			return
+		}
+		ctx, ca := context.WithTimeout(ctx, bufferReplayTimeout)
+		defer ca()
+		_, err := rwc.Push(ctx, &remotewrite.Payload{
+			Contents: data,
+		})
+		if err == nil {
+			s.conditions.Clear(CondRemoteWrite)
+			done = true
+			return
+		}
+		code, message, soft := remoteWriteErrorCode(err)
+		switch {
+		case soft:
+			done = true
+		case code == http.StatusTooManyRequests || code >= http.StatusInternalServerError:
+			s.conditions.Set(CondRemoteWrite, health.StatusFailure, message)
+		default:
+			// the gateway will never accept this payload
+			s.logger.With(
+				"code", code,
+				"message", message,
+			).Warn("dropping buffered remote-write payload rejected by the gateway")
+			done = true
+		}
+	})
+	return done
+}
+
+func (s *HttpServer) updateBufferCondition() {
+	stats := s.buffer.Stats()
+	if stats.Count == 0 {
+		s.bufferFull.Store(false)
+		s.conditions.Clear(CondRemoteWriteBuffer)
+		return
+	}
+	reason := fmt.Sprintf("%d payloads buffered (%.1f/%.1f MiB), replay lag %s",
+		stats.Count, float64(stats.Size)/(1<<20), float64(stats.MaxSize)/(1<<20), stats.Lag.Round(time.Second))
+	if s.bufferFull.Load() {
+		s.conditions.Set(CondRemoteWriteBuffer, health.StatusFailure, "buffer is full: "+reason)
+	} else {
+		s.conditions.Set(CondRemoteWriteBuffer, health.StatusPending, reason)
 	}
 }
@@ -0,0 +1,79 @@
+package agent_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/rancher/opni/pkg/clients"
+	"github.com/rancher/opni/pkg/health"
+	"github.com/rancher/opni/pkg/logger"
+	"github.com/rancher/opni/plugins/metrics/pkg/agent"
+	"github.com/rancher/opni/plugins/metrics/pkg/apis/remotewrite"
+)
+
+type recordingRemoteWriteClient struct {
+	mu       sync.Mutex
+	payloads []string
+}
+
+func (c *recordingRemoteWriteClient) Push(_ context.Context, in *remotewrite.Payload, _ ...grpc.CallOption) (*emptypb.Empty, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.payloads = append(c.payloads, string(in.GetContents()))
+	return &emptypb.Empty{}, nil
+}
+
+func (c *recordingRemoteWriteClient) SyncRules(context.Context, *remotewrite.Payload, ...grpc.CallOption) (*emptypb.Empty, error) {
+	return &emptypb.Empty{}, nil
+}
+
+func (c *recordingRemoteWriteClient) received() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.payloads...)
+}
+
+var _ = Describe("Remote Write Buffer Replay", Label("unit"), func() {
+	It("should replay buffered payloads in order once the gateway is connected", func() {
+		ctx, ca := context.WithCancel(context.Background())
+		DeferCleanup(ca)
+
+		buffer, err := agent.NewRemoteWriteBuffer(GinkgoT().TempDir(), 1024)
+		Expect(err).NotTo(HaveOccurred())
+		lg := logger.NewPluginLogger().Named("test")
+		server := agent.NewHttpServer(ctx, health.NewDefaultConditionTracker(lg), lg,
+			agent.WithRemoteWriteBuffer(buffer),
+		)
+		server.SetEnabled(true)
+
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		server.ConfigureRoutes(router)
+
+		By("buffering payloads while the gateway is not connected")
+		for _, p := range []string{"a", "b", "c"} {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/agent/push", bytes.NewBufferString(p))
+			router.ServeHTTP(rec, req)
+			Expect(rec.Code).To(Equal(http.StatusOK))
+		}
+		Expect(buffer.Len()).To(Equal(3))
+
+		By("replaying them once a client is available")
+		client := &recordingRemoteWriteClient{}
+		server.SetRemoteWriteClient(clients.NewLocker(nil, func(grpc.ClientConnInterface) remotewrite.RemoteWriteClient {
+			return client
+		}))
+		Eventually(client.received).Should(Equal([]string{"a", "b", "c"}))
+		Eventually(buffer.Len).Should(Equal(0))
+	})
+})
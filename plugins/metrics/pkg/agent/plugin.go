@@ -2,6 +2,8 @@ package agent
 
 import (
 	"context"
+	"fmt"
+	"os"
 
 	healthpkg "github.com/rancher/opni/pkg/health"
 	"github.com/rancher/opni/pkg/logger"
@@ -13,6 +15,7 @@ import (
 	"github.com/rancher/opni/plugins/metrics/pkg/agent/drivers"
 	"github.com/rancher/opni/plugins/metrics/pkg/apis/node"
 	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 type Plugin struct {
@@ -26,15 +29,34 @@ type Plugin struct {
 	stopRuleStreamer context.CancelFunc
 }
 
+const (
+	// Directory in which remote-write payloads are buffered while the gateway
+	// is unreachable. Buffering is disabled if not set.
+	RemoteWriteBufferDirEnvVar = "OPNI_REMOTE_WRITE_BUFFER_DIR"
+	// Maximum size of the remote-write buffer, as a quantity (e.g. "1Gi").
+	RemoteWriteBufferSizeEnvVar = "OPNI_REMOTE_WRITE_BUFFER_SIZE"
+
+	defaultRemoteWriteBufferSize = 1 << 30 // 1Gi
+)
+
 func NewPlugin(ctx context.Context) *Plugin {
 	lg := logger.NewPluginLogger().Named("metrics")
 
 	ct := healthpkg.NewDefaultConditionTracker(lg)
 
+	var httpOptions []HttpServerOption
+	if buffer, err := newRemoteWriteBufferFromEnv(); err != nil {
+		lg.With(
+			zap.Error(err),
+		).Error("remote-write buffering is unavailable")
+	} else if buffer != nil {
+		httpOptions = append(httpOptions, WithRemoteWriteBuffer(buffer))
+	}
+
 	p := &Plugin{
 		ctx:          ctx,
 		logger:       lg,
-		httpServer:   NewHttpServer(ct, lg),
+		httpServer:   NewHttpServer(ctx, ct, lg, httpOptions...),
 		ruleStreamer: NewRuleStreamer(ct, lg),
 		node:         NewMetricsNode(ct, lg),
 	}
@@ -58,6 +80,22 @@ func NewPlugin(ctx context.Context) *Plugin {
 	return p
 }
 
+func newRemoteWriteBufferFromEnv() (*RemoteWriteBuffer, error) {
+	dir, ok := os.LookupEnv(RemoteWriteBufferDirEnvVar)
+	if !ok || dir == "" {
+		return nil, nil
+	}
+	size := int64(defaultRemoteWriteBufferSize)
+	if value, ok := os.LookupEnv(RemoteWriteBufferSizeEnvVar); ok {
+		q, err := resource.ParseQuantity(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", RemoteWriteBufferSizeEnvVar, err)
+		}
+		size = q.Value()
+	}
+	return NewRemoteWriteBuffer(dir, size)
+}
+
 func (p *Plugin) onConfigUpdated(cfg *node.MetricsCapabilityConfig) {
 	p.logger.Debug("metrics capability config updated")
 
@@ -65,6 +103,8 @@ func (p *Plugin) onConfigUpdated(cfg *node.MetricsCapabilityConfig) {
 	currentlyRunning := (p.stopRuleStreamer != nil)
 	shouldRun := cfg.GetEnabled()
 
+	p.httpServer.SetRelabelConfigs(cfg.GetSpec().GetRelabelConfigs())
+
 	startRuleStreamer := func() {
 		ctx, ca := context.WithCancel(p.ctx)
 		p.stopRuleStreamer = ca
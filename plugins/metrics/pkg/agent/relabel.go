@@ -0,0 +1,52 @@
+package agent
+
+import (
+	"fmt"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// relabelPayload applies the relabel configs to each series in a
+// snappy-compressed remote-write request. Series whose labels are dropped by
+// the configs are removed from the request. The second return value is false
+// if all series in the request were dropped.
+func relabelPayload(payload []byte, cfgs []*relabel.Config) ([]byte, bool, error) {
+	data, err := snappy.Decode(nil, payload)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to decode payload: %w", err)
+	}
+	var req prompb.WriteRequest
+	if err := req.Unmarshal(data); err != nil {
+		return nil, false, fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+
+	kept := req.Timeseries[:0]
+	for _, ts := range req.Timeseries {
+		lset := make(labels.Labels, 0, len(ts.Labels))
+		for _, l := range ts.Labels {
+			lset = append(lset, labels.Label{Name: l.Name, Value: l.Value})
+		}
+		lset = relabel.Process(labels.New(lset...), cfgs...)
+		if lset == nil {
+			continue
+		}
+		ts.Labels = make([]prompb.Label, 0, len(lset))
+		for _, l := range lset {
+			ts.Labels = append(ts.Labels, prompb.Label{Name: l.Name, Value: l.Value})
+		}
+		kept = append(kept, ts)
+	}
+	if len(kept) == 0 && len(req.Metadata) == 0 {
+		return nil, false, nil
+	}
+	req.Timeseries = kept
+
+	data, err = req.Marshal()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	return snappy.Encode(nil, data), true, nil
+}
@@ -0,0 +1,140 @@
+package agent_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/snappy"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/prometheus/prompb"
+	"google.golang.org/grpc"
+	"k8s.io/utils/pointer"
+
+	"github.com/rancher/opni/pkg/clients"
+	"github.com/rancher/opni/pkg/health"
+	"github.com/rancher/opni/pkg/logger"
+	"github.com/rancher/opni/plugins/metrics/pkg/agent"
+	"github.com/rancher/opni/plugins/metrics/pkg/apis/node"
+	"github.com/rancher/opni/plugins/metrics/pkg/apis/remotewrite"
+)
+
+func encodeSeries(series ...map[string]string) []byte {
+	req := prompb.WriteRequest{}
+	for _, s := range series {
+		ts := prompb.TimeSeries{
+			Samples: []prompb.Sample{{Value: 1, Timestamp: 1}},
+		}
+		for name, value := range s {
+			ts.Labels = append(ts.Labels, prompb.Label{Name: name, Value: value})
+		}
+		req.Timeseries = append(req.Timeseries, ts)
+	}
+	data, err := req.Marshal()
+	Expect(err).NotTo(HaveOccurred())
+	return snappy.Encode(nil, data)
+}
+
+func decodeSeries(payload string) []map[string]string {
+	data, err := snappy.Decode(nil, []byte(payload))
+	Expect(err).NotTo(HaveOccurred())
+	req := prompb.WriteRequest{}
+	Expect(req.Unmarshal(data)).To(Succeed())
+	var series []map[string]string
+	for _, ts := range req.Timeseries {
+		s := map[string]string{}
+		for _, l := range ts.Labels {
+			s[l.Name] = l.Value
+		}
+		series = append(series, s)
+	}
+	return series
+}
+
+var _ = Describe("Relabeling", Label("unit"), func() {
+	var server *agent.HttpServer
+	var client *recordingRemoteWriteClient
+	var router *gin.Engine
+
+	BeforeEach(func() {
+		ctx, ca := context.WithCancel(context.Background())
+		DeferCleanup(ca)
+		lg := logger.NewPluginLogger().Named("test")
+		server = agent.NewHttpServer(ctx, health.NewDefaultConditionTracker(lg), lg)
+		server.SetEnabled(true)
+		client = &recordingRemoteWriteClient{}
+		server.SetRemoteWriteClient(clients.NewLocker(nil, func(grpc.ClientConnInterface) remotewrite.RemoteWriteClient {
+			return client
+		}))
+		gin.SetMode(gin.TestMode)
+		router = gin.New()
+		server.ConfigureRoutes(router)
+	})
+
+	push := func(payload []byte) int {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/agent/push", bytes.NewReader(payload))
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	series := []map[string]string{
+		{"__name__": "up", "job": "a"},
+		{"__name__": "up", "job": "b"},
+	}
+
+	It("should forward payloads unchanged without relabel configs", func() {
+		payload := encodeSeries(series...)
+		Expect(push(payload)).To(Equal(http.StatusOK))
+		Expect(client.received()).To(Equal([]string{string(payload)}))
+	})
+	It("should keep matching series", func() {
+		server.SetRelabelConfigs([]*node.RelabelConfig{{
+			SourceLabels: []string{"job"},
+			Regex:        pointer.String("a"),
+			Action:       "keep",
+		}})
+		Expect(push(encodeSeries(series...))).To(Equal(http.StatusOK))
+		Expect(client.received()).To(HaveLen(1))
+		Expect(decodeSeries(client.received()[0])).To(Equal(series[:1]))
+	})
+	It("should drop matching series", func() {
+		server.SetRelabelConfigs([]*node.RelabelConfig{{
+			SourceLabels: []string{"job"},
+			Regex:        pointer.String("a"),
+			Action:       "drop",
+		}})
+		Expect(push(encodeSeries(series...))).To(Equal(http.StatusOK))
+		Expect(client.received()).To(HaveLen(1))
+		Expect(decodeSeries(client.received()[0])).To(Equal(series[1:]))
+	})
+	It("should replace labels", func() {
+		server.SetRelabelConfigs([]*node.RelabelConfig{{
+			SourceLabels: []string{"job"},
+			TargetLabel:  "team",
+			Replacement:  pointer.String("team-$1"),
+		}})
+		Expect(push(encodeSeries(series[0]))).To(Equal(http.StatusOK))
+		Expect(client.received()).To(HaveLen(1))
+		Expect(decodeSeries(client.received()[0])).To(Equal([]map[string]string{
+			{"__name__": "up", "job": "a", "team": "team-a"},
+		}))
+	})
+	It("should not forward payloads whose series were all dropped", func() {
+		server.SetRelabelConfigs([]*node.RelabelConfig{{
+			SourceLabels: []string{"__name__"},
+			Regex:        pointer.String("up"),
+			Action:       "drop",
+		}})
+		Expect(push(encodeSeries(series...))).To(Equal(http.StatusOK))
+		Expect(client.received()).To(BeEmpty())
+	})
+	It("should reject payloads which cannot be decoded", func() {
+		server.SetRelabelConfigs([]*node.RelabelConfig{{Action: "drop", SourceLabels: []string{"job"}}})
+		Expect(push([]byte("not snappy"))).To(Equal(http.StatusBadRequest))
+		Expect(client.received()).To(BeEmpty())
+	})
+})
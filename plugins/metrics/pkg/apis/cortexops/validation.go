@@ -0,0 +1,23 @@
+package cortexops
+
+import "github.com/rancher/opni/pkg/validation"
+
+func (r *RelabelRuleSet) Validate() error {
+	if err := validation.ValidateID(r.GetId()); err != nil {
+		return err
+	}
+	if r.Selector != nil {
+		if err := r.Selector.Validate(); err != nil {
+			return err
+		}
+	}
+	if len(r.GetRelabelConfigs()) == 0 {
+		return validation.Errorf("%w: %s", validation.ErrMissingRequiredField, "RelabelConfigs")
+	}
+	for i, cfg := range r.GetRelabelConfigs() {
+		if err := cfg.Validate(); err != nil {
+			return validation.Errorf("relabel config %d: %w", i, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package node
+
+import (
+	"fmt"
+
+	"github.com/prometheus/prometheus/model/relabel"
+	"gopkg.in/yaml.v2"
+)
+
+// ToPrometheusConfig converts the relabel config to its Prometheus
+// equivalent. Unset fields are given the same defaults as in a Prometheus
+// configuration file, and the config is validated in the same way.
+func (c *RelabelConfig) ToPrometheusConfig() (*relabel.Config, error) {
+	doc := map[string]any{}
+	if len(c.GetSourceLabels()) > 0 {
+		doc["source_labels"] = c.GetSourceLabels()
+	}
+	if c.Separator != nil {
+		doc["separator"] = c.GetSeparator()
+	}
+	if c.Regex != nil {
+		doc["regex"] = c.GetRegex()
+	}
+	if c.GetModulus() != 0 {
+		doc["modulus"] = c.GetModulus()
+	}
+	if c.GetTargetLabel() != "" {
+		doc["target_label"] = c.GetTargetLabel()
+	}
+	if c.Replacement != nil {
+		doc["replacement"] = c.GetReplacement()
+	}
+	if c.GetAction() != "" {
+		doc["action"] = c.GetAction()
+	}
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &relabel.Config{}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, fmt.Errorf("invalid relabel config: %w", err)
+	}
+	return cfg, nil
+}
+
+// ToPrometheusConfigs converts a list of relabel configs, see
+// ToPrometheusConfig.
+func ToPrometheusConfigs(configs []*RelabelConfig) ([]*relabel.Config, error) {
+	out := make([]*relabel.Config, 0, len(configs))
+	for i, c := range configs {
+		cfg, err := c.ToPrometheusConfig()
+		if err != nil {
+			return nil, fmt.Errorf("relabel config %d: %w", i, err)
+		}
+		out = append(out, cfg)
+	}
+	return out, nil
+}
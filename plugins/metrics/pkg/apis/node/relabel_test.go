@@ -0,0 +1,90 @@
+package node_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/relabel"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/utils/pointer"
+
+	"github.com/rancher/opni/plugins/metrics/pkg/apis/node"
+)
+
+var _ = Describe("Relabel Configs", Label("unit"), func() {
+	It("should use the same defaults as prometheus", func() {
+		cfg, err := (&node.RelabelConfig{
+			SourceLabels: []string{"job"},
+			TargetLabel:  "team",
+		}).ToPrometheusConfig()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.Action).To(Equal(relabel.DefaultRelabelConfig.Action))
+		Expect(cfg.Separator).To(Equal(relabel.DefaultRelabelConfig.Separator))
+		Expect(cfg.Regex).To(Equal(relabel.DefaultRelabelConfig.Regex))
+		Expect(cfg.Replacement).To(Equal(relabel.DefaultRelabelConfig.Replacement))
+	})
+	It("should convert all fields", func() {
+		cfg, err := (&node.RelabelConfig{
+			SourceLabels: []string{"a", "b"},
+			Separator:    pointer.String(","),
+			Regex:        pointer.String("x,(.*)"),
+			TargetLabel:  "c",
+			Replacement:  pointer.String("${1}"),
+			Action:       "replace",
+		}).ToPrometheusConfig()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.SourceLabels).To(Equal(model.LabelNames{"a", "b"}))
+		Expect(cfg.Separator).To(Equal(","))
+		Expect(cfg.Regex).To(Equal(relabel.MustNewRegexp("x,(.*)")))
+		Expect(cfg.TargetLabel).To(Equal("c"))
+		Expect(cfg.Replacement).To(Equal("${1}"))
+		Expect(cfg.Action).To(Equal(relabel.Replace))
+
+		cfg, err = (&node.RelabelConfig{
+			SourceLabels: []string{"a"},
+			Modulus:      4,
+			TargetLabel:  "shard",
+			Action:       "hashmod",
+		}).ToPrometheusConfig()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.Modulus).To(BeEquivalentTo(4))
+		Expect(cfg.Action).To(Equal(relabel.HashMod))
+	})
+	It("should allow setting fields to empty values", func() {
+		cfg, err := (&node.RelabelConfig{
+			SourceLabels: []string{"a"},
+			TargetLabel:  "b",
+			Replacement:  pointer.String(""),
+			Separator:    pointer.String(""),
+		}).ToPrometheusConfig()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.Replacement).To(BeEmpty())
+		Expect(cfg.Separator).To(BeEmpty())
+	})
+	DescribeTable("invalid configs",
+		func(c *node.RelabelConfig) {
+			_, err := c.ToPrometheusConfig()
+			Expect(err).To(HaveOccurred())
+			Expect(status.Code(c.Validate())).To(Equal(codes.InvalidArgument))
+		},
+		Entry("unknown action", &node.RelabelConfig{Action: "rename"}),
+		Entry("invalid regex", &node.RelabelConfig{Regex: pointer.String("(")}),
+		Entry("replace without a target label", &node.RelabelConfig{
+			SourceLabels: []string{"a"},
+			Action:       "replace",
+		}),
+		Entry("hashmod without a modulus", &node.RelabelConfig{
+			SourceLabels: []string{"a"},
+			TargetLabel:  "b",
+			Action:       "hashmod",
+		}),
+	)
+	It("should report the index of invalid configs", func() {
+		_, err := node.ToPrometheusConfigs([]*node.RelabelConfig{
+			{SourceLabels: []string{"a"}, Action: "keep"},
+			{Action: "rename"},
+		})
+		Expect(err).To(MatchError(ContainSubstring("relabel config 1")))
+	})
+})
@@ -8,3 +8,10 @@ func (s *SyncRequest) Validate() error {
 	}
 	return nil
 }
+
+func (c *RelabelConfig) Validate() error {
+	if _, err := c.ToPrometheusConfig(); err != nil {
+		return validation.Error(err.Error())
+	}
+	return nil
+}
@@ -3,8 +3,10 @@ package backend
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 	"google.golang.org/grpc/codes"
@@ -25,6 +27,7 @@ import (
 	"github.com/rancher/opni/pkg/storage"
 	"github.com/rancher/opni/pkg/task"
 	"github.com/rancher/opni/pkg/util"
+	"github.com/rancher/opni/pkg/validation"
 	"github.com/rancher/opni/plugins/metrics/pkg/apis/cortexops"
 	"github.com/rancher/opni/plugins/metrics/pkg/apis/node"
 	"github.com/rancher/opni/plugins/metrics/pkg/gateway/drivers"
@@ -43,6 +46,13 @@ type MetricsBackend struct {
 	desiredNodeSpecMu sync.RWMutex
 	desiredNodeSpec   map[string]*node.MetricsCapabilitySpec
 
+	// relabel rule sets are read on every sync, so they are cached until
+	// they are modified or the cache expires
+	relabelCacheMu         sync.Mutex
+	relabelCache           []*cortexops.RelabelRuleSet
+	relabelCacheExpiration time.Time
+	relabelCacheGeneration uint64
+
 	util.Initializer
 }
 
@@ -50,12 +60,13 @@ var _ node.NodeMetricsCapabilityServer = (*MetricsBackend)(nil)
 var _ cortexops.CortexOpsServer = (*MetricsBackend)(nil)
 
 type MetricsBackendConfig struct {
-	Logger              *zap.SugaredLogger             `validate:"required"`
-	StorageBackend      storage.Backend                `validate:"required"`
-	MgmtClient          managementv1.ManagementClient  `validate:"required"`
-	NodeManagerClient   capabilityv1.NodeManagerClient `validate:"required"`
-	UninstallController *task.Controller               `validate:"required"`
-	ClusterDriver       drivers.ClusterDriver          `validate:"required"`
+	Logger              *zap.SugaredLogger                                `validate:"required"`
+	StorageBackend      storage.Backend                                   `validate:"required"`
+	MgmtClient          managementv1.ManagementClient                     `validate:"required"`
+	NodeManagerClient   capabilityv1.NodeManagerClient                    `validate:"required"`
+	UninstallController *task.Controller                                  `validate:"required"`
+	ClusterDriver       drivers.ClusterDriver                             `validate:"required"`
+	RelabelRuleStore    storage.KeyValueStoreT[*cortexops.RelabelRuleSet] `validate:"required"`
 }
 
 func (m *MetricsBackend) Initialize(conf MetricsBackendConfig) {
@@ -80,6 +91,10 @@ func (m *MetricsBackend) Info(_ context.Context, _ *emptypb.Empty) (*capabilityv
 		Name:    wellknown.CapabilityMetrics,
 		Source:  "plugin_metrics",
 		Drivers: drivers,
+		// metrics has no dependencies or conflicts of its own, but the slo
+		// and alerting capabilities depend on it
+		Dependencies: nil,
+		Conflicts:    nil,
 	}, nil
 }
 
@@ -334,11 +349,22 @@ func (m *MetricsBackend) Sync(ctx context.Context, req *node.SyncRequest) (*node
 	status.Enabled = req.GetCurrentConfig().GetEnabled()
 	status.LastSync = timestamppb.Now()
 
+	relabelConfigs, err := m.relabelConfigsForCluster(ctx, cluster)
+	if err != nil {
+		m.Logger.With(
+			zap.Error(err),
+			"cluster", id,
+		).Warn("failed to read relabel rules")
+		// keep the agent's current rules rather than removing them
+		relabelConfigs = req.GetCurrentConfig().GetSpec().GetRelabelConfigs()
+	}
+
 	// todo: allow for this to be configurable
 	return buildResponse(req.GetCurrentConfig(), &node.MetricsCapabilityConfig{
 		Enabled:    enabled,
 		Conditions: conditions,
 		Spec: &node.MetricsCapabilitySpec{
+			RelabelConfigs: relabelConfigs,
 			Rules: &v1beta1.RulesSpec{
 				Discovery: &v1beta1.DiscoverySpec{
 					PrometheusRules: &v1beta1.PrometheusRulesSpec{},
@@ -408,3 +434,148 @@ func (m *MetricsBackend) UninstallCluster(ctx context.Context, in *emptypb.Empty
 	defer m.requestNodeSync(ctx, &corev1.Reference{})
 	return m.ClusterDriver.UninstallCluster(ctx, in)
 }
+
+// Relabel Rules
+
+const (
+	relabelRuleSetPrefix = "/relabel_rules/"
+	relabelCacheTTL      = 1 * time.Minute
+)
+
+func (m *MetricsBackend) ListRelabelRuleSets(ctx context.Context, _ *emptypb.Empty) (*cortexops.RelabelRuleSetList, error) {
+	m.WaitForInit()
+
+	items, err := m.listRelabelRuleSets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &cortexops.RelabelRuleSetList{
+		Items: items,
+	}, nil
+}
+
+func (m *MetricsBackend) GetRelabelRuleSet(ctx context.Context, ref *corev1.Reference) (*cortexops.RelabelRuleSet, error) {
+	m.WaitForInit()
+
+	if err := validation.Validate(ref); err != nil {
+		return nil, err
+	}
+	rs, err := m.RelabelRuleStore.Get(ctx, relabelRuleSetPrefix+ref.Id)
+	if err != nil {
+		if util.StatusCode(err) == codes.NotFound {
+			return nil, status.Errorf(codes.NotFound, "relabel rule set %q not found", ref.Id)
+		}
+		return nil, err
+	}
+	return rs, nil
+}
+
+func (m *MetricsBackend) PutRelabelRuleSet(ctx context.Context, in *cortexops.RelabelRuleSet) (*emptypb.Empty, error) {
+	m.WaitForInit()
+
+	if err := validation.Validate(in); err != nil {
+		return nil, err
+	}
+	defer m.invalidateRelabelCache()
+	if err := m.RelabelRuleStore.Put(ctx, relabelRuleSetPrefix+in.Id, in); err != nil {
+		return nil, err
+	}
+	m.requestNodeSync(ctx, &corev1.Reference{})
+	return &emptypb.Empty{}, nil
+}
+
+func (m *MetricsBackend) DeleteRelabelRuleSet(ctx context.Context, ref *corev1.Reference) (*emptypb.Empty, error) {
+	m.WaitForInit()
+
+	if err := validation.Validate(ref); err != nil {
+		return nil, err
+	}
+	defer m.invalidateRelabelCache()
+	if err := m.RelabelRuleStore.Delete(ctx, relabelRuleSetPrefix+ref.Id); err != nil {
+		if util.StatusCode(err) == codes.NotFound {
+			return nil, status.Errorf(codes.NotFound, "relabel rule set %q not found", ref.Id)
+		}
+		return nil, err
+	}
+	m.requestNodeSync(ctx, &corev1.Reference{})
+	return &emptypb.Empty{}, nil
+}
+
+// listRelabelRuleSets returns all relabel rule sets, sorted by id.
+func (m *MetricsBackend) listRelabelRuleSets(ctx context.Context) ([]*cortexops.RelabelRuleSet, error) {
+	keys, err := m.RelabelRuleStore.ListKeys(ctx, relabelRuleSetPrefix)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*cortexops.RelabelRuleSet, 0, len(keys))
+	for _, key := range keys {
+		rs, err := m.RelabelRuleStore.Get(ctx, key)
+		if err != nil {
+			if util.StatusCode(err) == codes.NotFound {
+				// deleted since listing
+				continue
+			}
+			return nil, err
+		}
+		items = append(items, rs)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Id < items[j].Id
+	})
+	return items, nil
+}
+
+// cachedRelabelRuleSets returns all relabel rule sets like listRelabelRuleSets,
+// but only reads them from storage if they were modified since they were last
+// read, or if the cache has expired. The cache expires so that changes made
+// through other gateway replicas are picked up.
+func (m *MetricsBackend) cachedRelabelRuleSets(ctx context.Context) ([]*cortexops.RelabelRuleSet, error) {
+	m.relabelCacheMu.Lock()
+	if m.relabelCache != nil && time.Now().Before(m.relabelCacheExpiration) {
+		defer m.relabelCacheMu.Unlock()
+		return m.relabelCache, nil
+	}
+	generation := m.relabelCacheGeneration
+	m.relabelCacheMu.Unlock()
+
+	items, err := m.listRelabelRuleSets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m.relabelCacheMu.Lock()
+	defer m.relabelCacheMu.Unlock()
+	// don't cache the rule sets if they were modified while being listed
+	if generation == m.relabelCacheGeneration {
+		m.relabelCache = items
+		m.relabelCacheExpiration = time.Now().Add(relabelCacheTTL)
+	}
+	return items, nil
+}
+
+func (m *MetricsBackend) invalidateRelabelCache() {
+	m.relabelCacheMu.Lock()
+	defer m.relabelCacheMu.Unlock()
+	m.relabelCache = nil
+	m.relabelCacheGeneration++
+}
+
+// relabelConfigsForCluster returns the relabel configs of all rule sets whose
+// selector matches the cluster, in order of rule set id.
+func (m *MetricsBackend) relabelConfigsForCluster(ctx context.Context, cluster *corev1.Cluster) ([]*node.RelabelConfig, error) {
+	ruleSets, err := m.cachedRelabelRuleSets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var configs []*node.RelabelConfig
+	for _, rs := range ruleSets {
+		selector := rs.GetSelector()
+		if selector == nil {
+			selector = &corev1.ClusterSelector{}
+		}
+		if storage.NewSelectorPredicate(selector)(cluster) {
+			configs = append(configs, rs.GetRelabelConfigs()...)
+		}
+	}
+	return configs, nil
+}
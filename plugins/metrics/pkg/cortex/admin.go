@@ -42,6 +42,8 @@ type CortexAdminServerConfig struct {
 	CortexClientSet ClientSet                  `validate:"required"`
 	Config          *v1beta1.GatewayConfigSpec `validate:"required"`
 	Logger          *zap.SugaredLogger         `validate:"required"`
+	// Optional; if set, cluster limits and usage are included in user stats
+	IngestLimiter *IngestLimiter
 }
 
 func (p *CortexAdminServer) Initialize(conf CortexAdminServerConfig) {
@@ -59,9 +61,33 @@ func (p *CortexAdminServer) AllUserStats(ctx context.Context, _ *emptypb.Empty)
 	if !p.Initialized() {
 		return nil, util.StatusError(codes.Unavailable)
 	}
-	client := p.CortexClientSet
+	stats, err := getUserStats(ctx, p.CortexClientSet, p.Config)
+	if err != nil {
+		return nil, err
+	}
+	statsList := &cortexadmin.UserIDStatsList{
+		Items: make([]*cortexadmin.UserIDStats, len(stats)),
+	}
+	for i, s := range stats {
+		usage := p.IngestLimiter.Usage(s.UserID)
+		statsList.Items[i] = &cortexadmin.UserIDStats{
+			UserID:             s.UserID,
+			IngestionRate:      s.IngestionRate,
+			NumSeries:          s.NumSeries,
+			APIIngestionRate:   s.APIIngestionRate,
+			RuleIngestionRate:  s.RuleIngestionRate,
+			IngestionRateLimit: usage.Limits.IngestionRate,
+			SeriesLimit:        usage.Limits.Series,
+			RejectedRequests:   usage.RejectedRequests,
+			RejectedSamples:    usage.RejectedSamples,
+		}
+	}
+	return statsList, nil
+}
+
+func getUserStats(ctx context.Context, client ClientSet, config *v1beta1.GatewayConfigSpec) ([]distributor.UserIDStats, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET",
-		fmt.Sprintf("https://%s/distributor/all_user_stats", p.Config.Cortex.Distributor.HTTPAddress), nil)
+		fmt.Sprintf("https://%s/distributor/all_user_stats", config.Cortex.Distributor.HTTPAddress), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -70,14 +96,7 @@ func (p *CortexAdminServer) AllUserStats(ctx context.Context, _ *emptypb.Empty)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get cluster stats: %w", err)
 	}
-	defer func(Body io.ReadCloser) {
-		err := Body.Close()
-		if err != nil {
-			p.Logger.With(
-				"err", err,
-			).Error("failed to close response body")
-		}
-	}(resp.Body)
+	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("failed to get cluster stats: %v", resp.StatusCode)
 	}
@@ -85,19 +104,7 @@ func (p *CortexAdminServer) AllUserStats(ctx context.Context, _ *emptypb.Empty)
 	if err = json.NewDecoder(resp.Body).Decode(&stats); err != nil {
 		return nil, fmt.Errorf("failed to decode user stats: %w", err)
 	}
-	statsList := &cortexadmin.UserIDStatsList{
-		Items: make([]*cortexadmin.UserIDStats, len(stats)),
-	}
-	for i, s := range stats {
-		statsList.Items[i] = &cortexadmin.UserIDStats{
-			UserID:            s.UserID,
-			IngestionRate:     s.IngestionRate,
-			NumSeries:         s.NumSeries,
-			APIIngestionRate:  s.APIIngestionRate,
-			RuleIngestionRate: s.RuleIngestionRate,
-		}
-	}
-	return statsList, nil
+	return stats, nil
 }
 
 func mapLabels(l *cortexadmin.Label, i int) cortexpb.LabelAdapter {
@@ -0,0 +1,13 @@
+package cortex
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestCortex(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Cortex Suite")
+}
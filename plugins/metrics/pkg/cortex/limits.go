@@ -0,0 +1,319 @@
+package cortex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/golang/snappy"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	"github.com/rancher/opni/pkg/config/v1beta1"
+	"github.com/rancher/opni/pkg/storage"
+	"github.com/rancher/opni/pkg/util"
+	metricsutil "github.com/rancher/opni/plugins/metrics/pkg/util"
+)
+
+const (
+	// Cluster label which limits the number of samples per second the cluster
+	// can send to the gateway. Bursts of up to two seconds worth of samples are
+	// allowed; larger payloads are rejected.
+	IngestionRateLimitLabel = "metrics.opni.io/ingestion-rate-limit"
+	// Cluster label which limits the number of active series the cluster can
+	// have in Cortex. Requests are rejected while the cluster is at or over
+	// the limit.
+	SeriesLimitLabel = "metrics.opni.io/series-limit"
+
+	// How long cluster limits are cached before being read from storage again
+	limitsRefreshInterval = 30 * time.Second
+	// How often the number of active series is read from Cortex
+	seriesRefreshInterval = 15 * time.Second
+)
+
+type ClusterLimits struct {
+	// Samples per second; 0 means unlimited
+	IngestionRate float64
+	// Active series; 0 means unlimited
+	Series uint64
+}
+
+// ParseClusterLimits reads ingestion limits from a cluster's labels.
+func ParseClusterLimits(labels map[string]string) (ClusterLimits, error) {
+	var limits ClusterLimits
+	if value, ok := labels[IngestionRateLimitLabel]; ok {
+		rate, err := strconv.ParseFloat(value, 64)
+		if err != nil || rate < 0 {
+			return ClusterLimits{}, fmt.Errorf("invalid value for label %s: %q", IngestionRateLimitLabel, value)
+		}
+		limits.IngestionRate = rate
+	}
+	if value, ok := labels[SeriesLimitLabel]; ok {
+		series, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return ClusterLimits{}, fmt.Errorf("invalid value for label %s: %q", SeriesLimitLabel, value)
+		}
+		limits.Series = series
+	}
+	return limits, nil
+}
+
+type TenantUsage struct {
+	Limits           ClusterLimits
+	RejectedRequests uint64
+	RejectedSamples  uint64
+}
+
+type tenantLimiter struct {
+	limits    ClusterLimits
+	limiter   *rate.Limiter
+	refreshed time.Time
+
+	numSeries        uint64
+	rejectedRequests uint64
+	rejectedSamples  uint64
+}
+
+// IngestLimiter enforces per-cluster ingestion limits on remote-write
+// requests. Limits are configured using cluster labels. Until the limiter is
+// initialized, all requests are allowed.
+type IngestLimiter struct {
+	IngestLimiterConfig
+	util.Initializer
+
+	mu      sync.Mutex
+	tenants map[string]*tenantLimiter
+}
+
+type IngestLimiterConfig struct {
+	PluginContext   context.Context            `validate:"required"`
+	CortexClientSet ClientSet                  `validate:"required"`
+	Config          *v1beta1.GatewayConfigSpec `validate:"required"`
+	ClusterStore    storage.ClusterStore       `validate:"required"`
+	Logger          *zap.SugaredLogger         `validate:"required"`
+}
+
+func (l *IngestLimiter) Initialize(conf IngestLimiterConfig) {
+	l.InitOnce(func() {
+		if err := metricsutil.Validate.Struct(conf); err != nil {
+			panic(err)
+		}
+		l.IngestLimiterConfig = conf
+		l.tenants = make(map[string]*tenantLimiter)
+		go l.runSeriesUpdater(conf.PluginContext)
+		go l.runClusterWatcher(conf.PluginContext)
+	})
+}
+
+// Allow checks whether the remote-write payload sent by the cluster is
+// within the cluster's limits. If it is not, a ResourceExhausted error is
+// returned, which the agent will retry. Payloads containing more samples than
+// the cluster's burst size can never be accepted, and are rejected with an
+// InvalidArgument error instead.
+func (l *IngestLimiter) Allow(ctx context.Context, clusterId string, payload []byte) error {
+	if l == nil || !l.Initialized() {
+		return nil
+	}
+	if !l.refreshLimits(ctx, clusterId) {
+		return nil
+	}
+	samples := countSamples(payload)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	t := l.tenants[clusterId]
+	reject := func(reason string) {
+		t.rejectedRequests++
+		t.rejectedSamples += uint64(samples)
+		mRejectedRequests.WithLabelValues(clusterId, reason).Inc()
+		mRejectedSamples.WithLabelValues(clusterId, reason).Add(float64(samples))
+	}
+	if t.limits.Series > 0 && t.numSeries >= t.limits.Series {
+		reject("series_limit")
+		return status.Errorf(codes.ResourceExhausted, "cluster %s has reached its active series limit (%d)", clusterId, t.limits.Series)
+	}
+	if t.limiter != nil && samples > t.limiter.Burst() {
+		reject("burst_limit")
+		return status.Errorf(codes.InvalidArgument, "payload of %d samples exceeds the ingestion burst limit of cluster %s (%d samples)", samples, clusterId, t.limiter.Burst())
+	}
+	if t.limiter != nil && !t.limiter.AllowN(time.Now(), samples) {
+		reject("rate_limit")
+		return status.Errorf(codes.ResourceExhausted, "cluster %s has exceeded its ingestion rate limit (%v samples/s)", clusterId, t.limits.IngestionRate)
+	}
+	return nil
+}
+
+// Usage returns the limits and rejection counts for the cluster.
+func (l *IngestLimiter) Usage(clusterId string) TenantUsage {
+	if l == nil || !l.Initialized() {
+		return TenantUsage{}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	t, ok := l.tenants[clusterId]
+	if !ok {
+		return TenantUsage{}
+	}
+	return TenantUsage{
+		Limits:           t.limits,
+		RejectedRequests: t.rejectedRequests,
+		RejectedSamples:  t.rejectedSamples,
+	}
+}
+
+// refreshLimits reads the cluster's limits from storage if they are out of
+// date, and reports whether the cluster has any limits.
+func (l *IngestLimiter) refreshLimits(ctx context.Context, clusterId string) bool {
+	l.mu.Lock()
+	t, ok := l.tenants[clusterId]
+	if !ok {
+		t = &tenantLimiter{}
+		l.tenants[clusterId] = t
+	}
+	stale := time.Since(t.refreshed) >= limitsRefreshInterval
+	if stale {
+		t.refreshed = time.Now()
+	}
+	hasLimits := t.limits != ClusterLimits{}
+	l.mu.Unlock()
+	if !stale {
+		return hasLimits
+	}
+
+	c, err := l.ClusterStore.GetCluster(ctx, &corev1.Reference{Id: clusterId})
+	if errors.Is(err, storage.ErrNotFound) || util.StatusCode(err) == codes.NotFound {
+		l.removeTenant(clusterId)
+		return false
+	}
+	if err != nil {
+		l.Logger.With(
+			zap.Error(err),
+			"clusterId", clusterId,
+		).Warn("failed to read cluster limits, keeping previous limits")
+		return hasLimits
+	}
+	limits, err := ParseClusterLimits(c.GetLabels())
+	if err != nil {
+		l.Logger.With(
+			zap.Error(err),
+			"clusterId", clusterId,
+		).Warn("ignoring invalid cluster limits")
+		limits = ClusterLimits{}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if limits != t.limits {
+		t.limits = limits
+		if limits.IngestionRate > 0 {
+			// allow bursts of up to 2 seconds worth of samples
+			t.limiter = rate.NewLimiter(rate.Limit(limits.IngestionRate), int(limits.IngestionRate*2)+1)
+		} else {
+			t.limiter = nil
+		}
+	}
+	return limits != ClusterLimits{}
+}
+
+func (l *IngestLimiter) removeTenant(clusterId string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.tenants, clusterId)
+}
+
+// runClusterWatcher removes the limiters of deleted clusters, and causes the
+// limits of updated clusters to be read again on their next request.
+func (l *IngestLimiter) runClusterWatcher(ctx context.Context) {
+	for ctx.Err() == nil {
+		eventC, err := l.ClusterStore.WatchClusters(ctx, nil)
+		if err != nil {
+			l.Logger.With(
+				zap.Error(err),
+			).Warn("failed to watch clusters, retrying")
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(limitsRefreshInterval):
+			}
+			continue
+		}
+		for event := range eventC {
+			switch event.EventType {
+			case storage.WatchEventDelete:
+				l.removeTenant(event.Previous.GetId())
+			case storage.WatchEventUpdate:
+				l.mu.Lock()
+				if t, ok := l.tenants[event.Current.GetId()]; ok {
+					t.refreshed = time.Time{}
+				}
+				l.mu.Unlock()
+			}
+		}
+	}
+}
+
+func (l *IngestLimiter) runSeriesUpdater(ctx context.Context) {
+	ticker := time.NewTicker(seriesRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if !l.hasSeriesLimits() {
+			continue
+		}
+		stats, err := getUserStats(ctx, l.CortexClientSet, l.Config)
+		if err != nil {
+			l.Logger.With(
+				zap.Error(err),
+			).Warn("failed to read active series from cortex")
+			continue
+		}
+		l.mu.Lock()
+		for _, s := range stats {
+			if t, ok := l.tenants[s.UserID]; ok {
+				t.numSeries = s.NumSeries
+			}
+		}
+		l.mu.Unlock()
+	}
+}
+
+func (l *IngestLimiter) hasSeriesLimits() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, t := range l.tenants {
+		if t.limits.Series > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the number of samples in a snappy-compressed remote-write request,
+// or 0 if the request cannot be decoded.
+func countSamples(payload []byte) int {
+	data, err := snappy.Decode(nil, payload)
+	if err != nil {
+		return 0
+	}
+	var req cortexpb.PreallocWriteRequest
+	if err := req.Unmarshal(data); err != nil {
+		return 0
+	}
+	defer cortexpb.ReuseSlice(req.Timeseries)
+	count := 0
+	for _, ts := range req.Timeseries {
+		count += len(ts.Samples)
+	}
+	return count
+}
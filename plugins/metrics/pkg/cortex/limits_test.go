@@ -0,0 +1,216 @@
+package cortex
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/golang/snappy"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	"github.com/rancher/opni/pkg/config/v1beta1"
+	"github.com/rancher/opni/pkg/logger"
+	"github.com/rancher/opni/pkg/storage"
+	"github.com/rancher/opni/pkg/util"
+)
+
+func newPayload(series, samplesPerSeries int) []byte {
+	req := cortexpb.WriteRequest{}
+	for i := 0; i < series; i++ {
+		ts := cortexpb.PreallocTimeseries{
+			TimeSeries: &cortexpb.TimeSeries{
+				Labels: []cortexpb.LabelAdapter{{Name: "__name__", Value: "test"}},
+			},
+		}
+		for j := 0; j < samplesPerSeries; j++ {
+			ts.Samples = append(ts.Samples, cortexpb.Sample{TimestampMs: int64(j), Value: 1})
+		}
+		req.Timeseries = append(req.Timeseries, ts)
+	}
+	data, err := req.Marshal()
+	Expect(err).NotTo(HaveOccurred())
+	return snappy.Encode(nil, data)
+}
+
+// clusterStore is a minimal cluster store, pkg/test can't be imported here
+// since it imports the metrics plugin.
+type clusterStore struct {
+	storage.ClusterStore
+	mu       sync.Mutex
+	clusters map[string]*corev1.Cluster
+	events   chan storage.WatchEvent[*corev1.Cluster]
+}
+
+func newClusterStore() *clusterStore {
+	return &clusterStore{
+		clusters: map[string]*corev1.Cluster{},
+		events:   make(chan storage.WatchEvent[*corev1.Cluster], 10),
+	}
+}
+
+func (s *clusterStore) CreateCluster(_ context.Context, cluster *corev1.Cluster) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clusters[cluster.GetId()] = cluster
+	return nil
+}
+
+func (s *clusterStore) DeleteCluster(_ context.Context, ref *corev1.Reference) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cluster, ok := s.clusters[ref.GetId()]
+	if !ok {
+		return storage.ErrNotFound
+	}
+	delete(s.clusters, ref.GetId())
+	s.events <- storage.WatchEvent[*corev1.Cluster]{
+		EventType: storage.WatchEventDelete,
+		Previous:  cluster,
+	}
+	return nil
+}
+
+func (s *clusterStore) GetCluster(_ context.Context, ref *corev1.Reference) (*corev1.Cluster, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cluster, ok := s.clusters[ref.GetId()]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return cluster, nil
+}
+
+func (s *clusterStore) WatchClusters(context.Context, []*corev1.Cluster) (<-chan storage.WatchEvent[*corev1.Cluster], error) {
+	return s.events, nil
+}
+
+var _ = Describe("Ingestion Limits", Label("unit"), func() {
+	Context("ParseClusterLimits", func() {
+		It("should parse limits from cluster labels", func() {
+			limits, err := ParseClusterLimits(map[string]string{
+				IngestionRateLimitLabel: "1000.5",
+				SeriesLimitLabel:        "20000",
+				"foo":                   "bar",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(limits).To(Equal(ClusterLimits{IngestionRate: 1000.5, Series: 20000}))
+		})
+		It("should return no limits if the labels are not set", func() {
+			limits, err := ParseClusterLimits(nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(limits).To(BeZero())
+		})
+		DescribeTable("invalid labels",
+			func(labels map[string]string) {
+				_, err := ParseClusterLimits(labels)
+				Expect(err).To(HaveOccurred())
+			},
+			Entry("non-numeric rate", map[string]string{IngestionRateLimitLabel: "fast"}),
+			Entry("negative rate", map[string]string{IngestionRateLimitLabel: "-1"}),
+			Entry("non-numeric series", map[string]string{SeriesLimitLabel: "many"}),
+			Entry("negative series", map[string]string{SeriesLimitLabel: "-1"}),
+			Entry("fractional series", map[string]string{SeriesLimitLabel: "1.5"}),
+		)
+	})
+
+	Context("countSamples", func() {
+		It("should count the samples of all series", func() {
+			Expect(countSamples(newPayload(3, 4))).To(Equal(12))
+			Expect(countSamples(newPayload(0, 0))).To(Equal(0))
+		})
+		It("should return 0 for payloads which cannot be decoded", func() {
+			Expect(countSamples([]byte("not snappy"))).To(Equal(0))
+			Expect(countSamples(snappy.Encode(nil, []byte("not protobuf")))).To(Equal(0))
+		})
+	})
+
+	Context("Allow", func() {
+		var limiter *IngestLimiter
+		var clusters *clusterStore
+		createCluster := func(id string, labels map[string]string) {
+			Expect(clusters.CreateCluster(context.Background(), &corev1.Cluster{
+				Id:       id,
+				Metadata: &corev1.ClusterMetadata{Labels: labels},
+			})).To(Succeed())
+		}
+
+		BeforeEach(func() {
+			ctx, ca := context.WithCancel(context.Background())
+			DeferCleanup(ca)
+			clusters = newClusterStore()
+			limiter = &IngestLimiter{}
+			limiter.Initialize(IngestLimiterConfig{
+				PluginContext:   ctx,
+				CortexClientSet: struct{ ClientSet }{},
+				Config:          &v1beta1.GatewayConfigSpec{},
+				ClusterStore:    clusters,
+				Logger:          logger.NewPluginLogger().Named("test"),
+			})
+		})
+
+		It("should allow all requests until initialized", func() {
+			var l *IngestLimiter
+			Expect(l.Allow(context.Background(), "foo", newPayload(1, 1))).To(Succeed())
+			Expect((&IngestLimiter{}).Allow(context.Background(), "foo", newPayload(1, 1))).To(Succeed())
+		})
+		It("should allow all requests from clusters without limits", func() {
+			createCluster("foo", nil)
+			for i := 0; i < 10; i++ {
+				Expect(limiter.Allow(context.Background(), "foo", newPayload(100, 100))).To(Succeed())
+			}
+		})
+		It("should charge the full number of samples against the rate limit", func() {
+			// burst of 21 samples
+			createCluster("foo", map[string]string{IngestionRateLimitLabel: "10"})
+			Expect(limiter.Allow(context.Background(), "foo", newPayload(2, 10))).To(Succeed())
+			err := limiter.Allow(context.Background(), "foo", newPayload(2, 10))
+			Expect(util.StatusCode(err)).To(Equal(codes.ResourceExhausted))
+
+			usage := limiter.Usage("foo")
+			Expect(usage.Limits.IngestionRate).To(Equal(10.0))
+			Expect(usage.RejectedRequests).To(BeEquivalentTo(1))
+			Expect(usage.RejectedSamples).To(BeEquivalentTo(20))
+		})
+		It("should reject payloads larger than the burst size", func() {
+			createCluster("foo", map[string]string{IngestionRateLimitLabel: "10"})
+			err := limiter.Allow(context.Background(), "foo", newPayload(1, 22))
+			Expect(util.StatusCode(err)).To(Equal(codes.InvalidArgument))
+			Expect(limiter.Usage("foo").RejectedSamples).To(BeEquivalentTo(22))
+
+			By("not consuming the bucket")
+			Expect(limiter.Allow(context.Background(), "foo", newPayload(1, 21))).To(Succeed())
+		})
+		It("should reject requests from clusters at their series limit", func() {
+			createCluster("foo", map[string]string{SeriesLimitLabel: "100"})
+			Expect(limiter.Allow(context.Background(), "foo", newPayload(1, 1))).To(Succeed())
+			limiter.mu.Lock()
+			limiter.tenants["foo"].numSeries = 100
+			limiter.mu.Unlock()
+			err := limiter.Allow(context.Background(), "foo", newPayload(1, 1))
+			Expect(util.StatusCode(err)).To(Equal(codes.ResourceExhausted))
+		})
+		It("should forget deleted clusters", func() {
+			createCluster("foo", map[string]string{IngestionRateLimitLabel: "10"})
+			Expect(limiter.Allow(context.Background(), "foo", newPayload(1, 1))).To(Succeed())
+			Expect(limiter.Usage("foo").Limits.IngestionRate).To(Equal(10.0))
+
+			Expect(clusters.DeleteCluster(context.Background(), &corev1.Reference{Id: "foo"})).To(Succeed())
+			Eventually(func() TenantUsage {
+				return limiter.Usage("foo")
+			}, 2*time.Second).Should(BeZero())
+			limiter.mu.Lock()
+			defer limiter.mu.Unlock()
+			Expect(limiter.tenants).NotTo(HaveKey("foo"))
+		})
+		It("should not track clusters which do not exist", func() {
+			Expect(limiter.Allow(context.Background(), "bar", newPayload(1, 1))).To(Succeed())
+			limiter.mu.Lock()
+			defer limiter.mu.Unlock()
+			Expect(limiter.tenants).NotTo(HaveKey("bar"))
+		})
+	})
+})
@@ -23,6 +23,18 @@ var (
 		Name:      "remote_write_requests_total",
 		Help:      "Total number of remote write requests forwarded to Cortex",
 	}, []string{"cluster_id", "code", "code_text"})
+	mRejectedRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "opni",
+		Subsystem: "gateway",
+		Name:      "remote_write_rejected_requests_total",
+		Help:      "Total number of remote write requests rejected due to cluster ingestion limits",
+	}, []string{"cluster_id", "reason"})
+	mRejectedSamples = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "opni",
+		Subsystem: "gateway",
+		Name:      "remote_write_rejected_samples_total",
+		Help:      "Total number of samples rejected due to cluster ingestion limits",
+	}, []string{"cluster_id", "reason"})
 )
 
 func Collectors() []prometheus.Collector {
@@ -30,5 +42,7 @@ func Collectors() []prometheus.Collector {
 		mIngestBytesTotal,
 		mIngestBytesByID,
 		mRemoteWriteRequests,
+		mRejectedRequests,
+		mRejectedSamples,
 	}
 }
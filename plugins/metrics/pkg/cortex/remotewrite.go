@@ -35,6 +35,8 @@ type RemoteWriteForwarderConfig struct {
 	CortexClientSet ClientSet                  `validate:"required"`
 	Config          *v1beta1.GatewayConfigSpec `validate:"required"`
 	Logger          *zap.SugaredLogger         `validate:"required"`
+	// Optional; if set, per-cluster ingestion limits are enforced
+	IngestLimiter *IngestLimiter
 }
 
 func (f *RemoteWriteForwarder) Initialize(conf RemoteWriteForwarderConfig) {
@@ -68,6 +70,10 @@ func (f *RemoteWriteForwarder) Push(ctx context.Context, payload *remotewrite.Pa
 		trace.WithAttributes(attribute.String("clusterId", clusterId)))
 	defer span.End()
 
+	if err := f.IngestLimiter.Allow(ctx, clusterId, payload.Contents); err != nil {
+		return nil, err
+	}
+
 	defer func() {
 		if pushErr != nil {
 			lg := f.Logger.With(
@@ -1,16 +1,27 @@
 package drivers
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sync"
 
 	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
 	"github.com/rancher/opni/plugins/metrics/pkg/apis/cortexops"
+	"google.golang.org/protobuf/types/known/emptypb"
 )
 
+// ClusterOps is the subset of the CortexOps API implemented by cluster
+// drivers. The remaining methods are implemented by the metrics backend.
+type ClusterOps interface {
+	GetClusterConfiguration(context.Context, *emptypb.Empty) (*cortexops.ClusterConfiguration, error)
+	ConfigureCluster(context.Context, *cortexops.ClusterConfiguration) (*emptypb.Empty, error)
+	GetClusterStatus(context.Context, *emptypb.Empty) (*cortexops.InstallStatus, error)
+	UninstallCluster(context.Context, *emptypb.Empty) (*emptypb.Empty, error)
+}
+
 type ClusterDriver interface {
-	cortexops.CortexOpsServer
+	ClusterOps
 	// Unique name of the driver
 	Name() string
 	// ShouldDisableNode is called during node sync for nodes which otherwise
@@ -42,6 +42,7 @@ type Plugin struct {
 	cortexAdmin       cortex.CortexAdminServer
 	cortexHttp        cortex.HttpApiServer
 	cortexRemoteWrite cortex.RemoteWriteForwarder
+	ingestLimiter     cortex.IngestLimiter
 	metrics           backend.MetricsBackend
 	uninstallRunner   cortex.UninstallTaskRunner
 
@@ -54,6 +55,7 @@ type Plugin struct {
 	cortexClientSet     future.Future[cortex.ClientSet]
 	uninstallController future.Future[*task.Controller]
 	clusterDriver       future.Future[drivers.ClusterDriver]
+	relabelRuleStore    future.Future[storage.KeyValueStoreT[*cortexops.RelabelRuleSet]]
 }
 
 func NewPlugin(ctx context.Context) *Plugin {
@@ -73,6 +75,7 @@ func NewPlugin(ctx context.Context) *Plugin {
 		cortexClientSet:     future.New[cortex.ClientSet](),
 		uninstallController: future.New[*task.Controller](),
 		clusterDriver:       future.New[drivers.ClusterDriver](),
+		relabelRuleStore:    future.New[storage.KeyValueStoreT[*cortexops.RelabelRuleSet]](),
 	}
 
 	future.Wait2(p.cortexClientSet, p.config,
@@ -81,6 +84,7 @@ func NewPlugin(ctx context.Context) *Plugin {
 				CortexClientSet: cortexClientSet,
 				Config:          &config.Spec,
 				Logger:          p.logger.Named("cortex-admin"),
+				IngestLimiter:   &p.ingestLimiter,
 			})
 		})
 
@@ -90,6 +94,18 @@ func NewPlugin(ctx context.Context) *Plugin {
 				CortexClientSet: cortexClientSet,
 				Config:          &config.Spec,
 				Logger:          p.logger.Named("cortex-rw"),
+				IngestLimiter:   &p.ingestLimiter,
+			})
+		})
+
+	future.Wait3(p.cortexClientSet, p.config, p.storageBackend,
+		func(cortexClientSet cortex.ClientSet, config *v1beta1.GatewayConfig, storageBackend storage.Backend) {
+			p.ingestLimiter.Initialize(cortex.IngestLimiterConfig{
+				PluginContext:   p.ctx,
+				CortexClientSet: cortexClientSet,
+				Config:          &config.Spec,
+				ClusterStore:    storageBackend,
+				Logger:          p.logger.Named("ingest-limiter"),
 			})
 		})
 
@@ -102,13 +118,14 @@ func NewPlugin(ctx context.Context) *Plugin {
 			})
 		})
 
-	future.Wait5(p.storageBackend, p.mgmtClient, p.nodeManagerClient, p.uninstallController, p.clusterDriver,
+	future.Wait6(p.storageBackend, p.mgmtClient, p.nodeManagerClient, p.uninstallController, p.clusterDriver, p.relabelRuleStore,
 		func(
 			storageBackend storage.Backend,
 			mgmtClient managementv1.ManagementClient,
 			nodeManagerClient capabilityv1.NodeManagerClient,
 			uninstallController *task.Controller,
 			clusterDriver drivers.ClusterDriver,
+			relabelRuleStore storage.KeyValueStoreT[*cortexops.RelabelRuleSet],
 		) {
 			p.metrics.Initialize(backend.MetricsBackendConfig{
 				Logger:              p.logger.Named("metrics-backend"),
@@ -117,6 +134,7 @@ func NewPlugin(ctx context.Context) *Plugin {
 				NodeManagerClient:   nodeManagerClient,
 				UninstallController: uninstallController,
 				ClusterDriver:       clusterDriver,
+				RelabelRuleStore:    relabelRuleStore,
 			})
 		})
 
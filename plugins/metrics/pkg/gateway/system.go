@@ -15,6 +15,7 @@ import (
 	"github.com/rancher/opni/pkg/machinery"
 	"github.com/rancher/opni/pkg/plugins/apis/system"
 	"github.com/rancher/opni/pkg/task"
+	"github.com/rancher/opni/plugins/metrics/pkg/apis/cortexops"
 	"github.com/rancher/opni/plugins/metrics/pkg/cortex"
 )
 
@@ -77,5 +78,6 @@ func (p *Plugin) UseKeyValueStore(client system.KeyValueStoreClient) {
 		os.Exit(1)
 	}
 	p.uninstallController.Set(ctrl)
+	p.relabelRuleStore.Set(system.NewKVStoreClient[*cortexops.RelabelRuleSet](client))
 	<-p.ctx.Done()
 }
@@ -72,6 +72,9 @@ func (p *Plugin) CreateSLO(ctx context.Context, slorequest *sloapi.CreateSLORequ
 	if validCluster == false {
 		return nil, validation.Error("invalid cluster")
 	}
+	if err := p.ensureCapability(ctx, slorequest.GetSlo()); err != nil {
+		return nil, err
+	}
 	sloStore := datasourceToSLO[slorequest.GetSlo().GetDatasource()].WithCurrentRequest(slorequest, ctx)
 	id, err := sloStore.Create()
 	if err != nil {
@@ -107,6 +110,9 @@ func (p *Plugin) UpdateSLO(ctx context.Context, req *sloapi.SLOData) (*emptypb.E
 	if err != nil {
 		return nil, err
 	}
+	if err := p.ensureCapability(ctx, req.GetSLO()); err != nil {
+		return nil, err
+	}
 	sloStore := datasourceToSLO[req.GetSLO().GetDatasource()].WithCurrentRequest(req, ctx)
 	updatedSLO, err := sloStore.Update(existing)
 	if err != nil { // exit when update fails
@@ -174,6 +180,13 @@ func (p *Plugin) CloneToClusters(ctx context.Context, req *sloapi.MultiClusterSL
 	if err := checkDatasource(existing.SLO.GetDatasource()); err != nil {
 		return nil, err
 	}
+	for _, cluster := range req.Clusters {
+		target := proto.Clone(existing.GetSLO()).(*sloapi.ServiceLevelObjective)
+		target.ClusterId = cluster.GetId()
+		if err := p.ensureCapability(ctx, target); err != nil {
+			return nil, err
+		}
+	}
 	sloStore := datasourceToSLO[existing.SLO.GetDatasource()].WithCurrentRequest(req, ctx)
 	svcBackend := datasourceToService[existing.SLO.GetDatasource()] // with current request is set in multi cluster clone
 	failures := []string{}
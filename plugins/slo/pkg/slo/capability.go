@@ -0,0 +1,61 @@
+package slo
+
+import (
+	"context"
+	"strings"
+
+	capabilityv1 "github.com/rancher/opni/pkg/apis/capability/v1"
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	"github.com/rancher/opni/pkg/capabilities"
+	"github.com/rancher/opni/pkg/capabilities/wellknown"
+	"github.com/rancher/opni/pkg/slo/shared"
+	sloapi "github.com/rancher/opni/plugins/slo/pkg/apis/slo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// The slo capability is installed on every cluster with monitoring SLOs, whose
+// rules and metrics live in the metrics capability's backend. Declaring the
+// dependency keeps the metrics capability from being uninstalled while the
+// cluster still has SLOs.
+func (p *Plugin) newCapabilityBackend() *capabilities.GatewayBackend {
+	return capabilities.NewGatewayBackend(&capabilityv1.Details{
+		Name:         wellknown.CapabilitySLO,
+		Source:       "plugin_slo",
+		Dependencies: []string{wellknown.CapabilityMetrics},
+	}, p.clusterStore, capabilities.WithUninstallCheck(p.canUninstallCapability))
+}
+
+// ensureCapability installs the slo capability on the SLO's cluster if the
+// SLO depends on it.
+func (p *Plugin) ensureCapability(ctx context.Context, slo *sloapi.ServiceLevelObjective) error {
+	if slo.GetDatasource() != shared.MonitoringDatasource {
+		return nil
+	}
+	if err := p.capabilityBackend.EnsureInstalled(ctx, &corev1.Reference{Id: slo.GetClusterId()}); err != nil {
+		return status.Errorf(codes.Unavailable, "failed to install the slo capability on cluster %s: %v", slo.GetClusterId(), err)
+	}
+	return nil
+}
+
+func (p *Plugin) canUninstallCapability(ctx context.Context, cluster *corev1.Reference) error {
+	storage, err := p.storage.GetContext(ctx)
+	if err != nil {
+		return err
+	}
+	slos, err := list(ctx, storage.SLOs, "/slos")
+	if err != nil {
+		return err
+	}
+	var names []string
+	for _, data := range slos {
+		if data.GetSLO().GetClusterId() == cluster.GetId() &&
+			data.GetSLO().GetDatasource() == shared.MonitoringDatasource {
+			names = append(names, data.GetSLO().GetName())
+		}
+	}
+	if len(names) > 0 {
+		return status.Errorf(codes.FailedPrecondition, "cluster still has SLOs which must be deleted first: %s", strings.Join(names, ", "))
+	}
+	return nil
+}
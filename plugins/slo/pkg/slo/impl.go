@@ -285,8 +285,10 @@ func (s SLOMonitoring) Status(existing *sloapi.SLOData) (*sloapi.SLOStatus, erro
 		return &sloapi.SLOStatus{State: sloapi.SLOStatusState_PartialDataOk}, nil
 	}
 	metadataBudget := (*metadataVector)[0].Value
+	objective := normalizeObjective(existing.GetSLO().GetTarget().GetValue())
+	budget := errorBudgetOf(1-(1-float64(metadataBudget))*(1-objective), objective)
 	if metadataBudget <= 0 {
-		return &sloapi.SLOStatus{State: sloapi.SLOStatusState_Breaching}, nil
+		return &sloapi.SLOStatus{State: sloapi.SLOStatusState_Breaching, Budget: budget}, nil
 	}
 	s.lg.With("sloId", slo.GetId()).Debug("sli status ", metadataVector.String())
 	//
@@ -303,14 +305,15 @@ func (s SLOMonitoring) Status(existing *sloapi.SLOData) (*sloapi.SLOStatus, erro
 		return nil, err
 	}
 	if alertDataVector1 == nil || alertDataVector1.Len() == 0 || alertDataVector2 == nil || alertDataVector2.Len() == 0 {
-		return &sloapi.SLOStatus{State: sloapi.SLOStatusState_PartialDataOk}, nil
+		return &sloapi.SLOStatus{State: sloapi.SLOStatusState_PartialDataOk, Budget: budget}, nil
 	}
 	if (*alertDataVector1)[len(*alertDataVector1)-1].Value > 0 || (*alertDataVector2)[len(*alertDataVector2)-1].Value > 0 {
-		return &sloapi.SLOStatus{State: sloapi.SLOStatusState_Warning}, nil
+		return &sloapi.SLOStatus{State: sloapi.SLOStatusState_Warning, Budget: budget}, nil
 	}
 	s.lg.With("sloId", slo.GetId()).Debug("alert status response vector ", alertDataVector1.String(), alertDataVector2.String())
 	return &sloapi.SLOStatus{
-		State: state,
+		State:  state,
+		Budget: budget,
 	}, nil
 }
 
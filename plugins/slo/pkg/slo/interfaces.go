@@ -68,6 +68,10 @@ type MonitoringServiceBackend struct {
 	RequestBase
 }
 
+type LoggingServiceBackend struct {
+	RequestBase
+}
+
 func NewSLOMonitoringStore(p *Plugin, lg *zap.SugaredLogger) SLOStore {
 	return &SLOMonitoring{
 		RequestBase{
@@ -89,3 +93,25 @@ func NewMonitoringServiceBackend(p *Plugin, lg *zap.SugaredLogger) ServiceBacken
 		},
 	}
 }
+
+func NewSLOLoggingStore(p *Plugin, lg *zap.SugaredLogger) SLOStore {
+	return &SLOLogging{
+		RequestBase{
+			req: nil,
+			p:   p,
+			ctx: context.Background(),
+			lg:  lg,
+		},
+	}
+}
+
+func NewLoggingServiceBackend(p *Plugin, lg *zap.SugaredLogger) ServiceBackend {
+	return &LoggingServiceBackend{
+		RequestBase{
+			req: nil,
+			p:   p,
+			ctx: context.Background(),
+			lg:  lg,
+		},
+	}
+}
@@ -0,0 +1,427 @@
+package slo
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	prommodel "github.com/prometheus/common/model"
+	alertingv1 "github.com/rancher/opni/pkg/apis/alerting/v1"
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	managementv1 "github.com/rancher/opni/pkg/apis/management/v1"
+	"github.com/rancher/opni/pkg/slo/query"
+	"github.com/rancher/opni/pkg/slo/shared"
+	"github.com/rancher/opni/pkg/util"
+	"github.com/rancher/opni/plugins/logging/pkg/apis/loggingadmin"
+	sloapi "github.com/rancher/opni/plugins/slo/pkg/apis/slo"
+	"golang.org/x/exp/slices"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Log based SLOs are evaluated against opensearch by the logging plugin, so unlike
+// SLOMonitoring there are no rules to apply or clean up on the datasource. Their alerts
+// are evaluated periodically by the plugin, see runLogAlerts.
+
+func (s *SLOLogging) WithCurrentRequest(req proto.Message, ctx context.Context) SLOStore {
+	s.req = req
+	s.ctx = ctx
+	return s
+}
+
+func (s SLOLogging) Create() (*corev1.Reference, error) {
+	req := (s.req).(*sloapi.CreateSLORequest)
+	slo := CreateSLORequestToStruct(req)
+	if err := checkLogService(s.RequestBase, req.GetSlo().GetClusterId(), req.GetSlo().GetServiceId()); err != nil {
+		return nil, err
+	}
+	ae := req.GetSlo().GetAttachedEndpoints()
+	if alertingv1.ShouldCreateRoutingNode(ae, nil) {
+		for _, alertId := range logAlertIds(slo.GetId()) {
+			if err := createRoutingNode(s.p, s.ctx, ae, alertId); err != nil {
+				s.p.logger.Errorf("creating routing node failed %s", err)
+			}
+		}
+	}
+	return &corev1.Reference{Id: slo.GetId()}, nil
+}
+
+func (s SLOLogging) Update(existing *sloapi.SLOData) (*sloapi.SLOData, error) {
+	incomingSLO := (s.req).(*sloapi.SLOData)
+	if err := checkLogService(s.RequestBase, incomingSLO.GetSLO().GetClusterId(), incomingSLO.GetSLO().GetServiceId()); err != nil {
+		return nil, err
+	}
+	if incomingSLO.GetCreatedAt() == nil {
+		incomingSLO.CreatedAt = existing.GetCreatedAt()
+	}
+	newAe := incomingSLO.GetSLO().GetAttachedEndpoints()
+	oldAe := existing.GetSLO().GetAttachedEndpoints()
+	for _, alertId := range logAlertIds(existing.GetId()) {
+		if alertingv1.ShouldCreateRoutingNode(newAe, oldAe) {
+			if err := createRoutingNode(s.p, s.ctx, newAe, alertId); err != nil {
+				s.p.logger.Errorf("creating routing node failed %s", err)
+			}
+		} else if alertingv1.ShouldUpdateRoutingNode(newAe, oldAe) {
+			if err := updateRoutingNode(s.p, s.ctx, newAe, alertId); err != nil {
+				s.p.logger.Errorf("updating routing node failed %s", err)
+			}
+		} else if alertingv1.ShouldDeleteRoutingNode(newAe, oldAe) {
+			if err := deleteRoutingNode(s.p, s.ctx, alertId); err != nil {
+				s.p.logger.Errorf("deleting routing node failed %s", err)
+			}
+		}
+	}
+	return incomingSLO, nil
+}
+
+func (s SLOLogging) Delete(existing *sloapi.SLOData) error {
+	if len(existing.GetSLO().GetAttachedEndpoints().GetItems()) == 0 {
+		return nil
+	}
+	for _, alertId := range logAlertIds(existing.GetId()) {
+		if err := deleteRoutingNode(s.p, s.ctx, alertId); err != nil {
+			s.p.logger.Errorf("deleting routing node failed %s", err)
+		}
+	}
+	return nil
+}
+
+func (s SLOLogging) Clone(clone *sloapi.SLOData) (*corev1.Reference, *sloapi.SLOData, error) {
+	clonedData := util.ProtoClone(clone)
+	clonedData.Id = uuid.New().String()
+	clonedData.SLO.Name = clone.GetSLO().GetName() + "-clone"
+	return &corev1.Reference{Id: clonedData.Id}, clonedData, nil
+}
+
+func (s SLOLogging) MultiClusterClone(
+	base *sloapi.SLOData,
+	inputClusters []*corev1.Reference,
+	svcBackend ServiceBackend,
+) ([]*corev1.Reference, []*sloapi.SLOData, []error) {
+	clusters, err := s.p.mgmtClient.Get().ListClusters(s.ctx, &managementv1.ListClustersRequest{})
+	if err != nil {
+		return nil, nil, []error{err}
+	}
+	var clusterIds []string
+	for _, cluster := range clusters.Items {
+		clusterIds = append(clusterIds, cluster.Id)
+	}
+	clusterDefinitions := make([]*sloapi.SLOData, len(inputClusters))
+	clusterIdsCreate := make([]*corev1.Reference, len(inputClusters))
+	errArr := make([]error, len(inputClusters))
+	var wg sync.WaitGroup
+	for idx, clusterId := range inputClusters {
+		clonedData := util.ProtoClone(base)
+		clonedData.Id = uuid.New().String()
+		clonedData.SLO.Name = base.GetSLO().GetName() + "-clone-" + strconv.Itoa(idx)
+		clonedData.SLO.ClusterId = clusterId.Id
+		clusterDefinitions[idx] = clonedData
+		clusterIdsCreate[idx] = &corev1.Reference{Id: clonedData.Id}
+
+		wg.Add(1)
+		idx := idx
+		clusterId := clusterId
+		go func() {
+			defer wg.Done()
+			if !slices.Contains(clusterIds, clusterId.Id) {
+				errArr[idx] = fmt.Errorf("cluster %s not found", clusterId.Id)
+				return
+			}
+			errArr[idx] = checkLogService(s.RequestBase, clusterId.Id, base.GetSLO().GetServiceId())
+		}()
+	}
+	wg.Wait()
+	return clusterIdsCreate, clusterDefinitions, errArr
+}
+
+// Status evaluates the SLO over its period, then checks the burn rate of the
+// page windows in the same way the multi-window multi-burn-rate alerts of the
+// monitoring datasource do.
+func (s SLOLogging) Status(existing *sloapi.SLOData) (*sloapi.SLOStatus, error) {
+	slo := existing.GetSLO()
+	period, err := prommodel.ParseDuration(slo.GetSloPeriod())
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	good, total, err := countSLOEvents(s.RequestBase, slo, now.Add(-time.Duration(period)), now)
+	if err != nil {
+		return nil, err
+	}
+	if total == 0 {
+		return &sloapi.SLOStatus{State: sloapi.SLOStatusState_NoData}, nil
+	}
+	objective := normalizeObjective(slo.GetTarget().GetValue())
+	budget := errorBudgetOf(float64(good)/float64(total), objective)
+	if errorBudgetRemaining(good, total, objective) <= 0 {
+		return &sloapi.SLOStatus{State: sloapi.SLOStatusState_Breaching, Budget: budget}, nil
+	}
+	s.lg.With("sloId", existing.GetId()).Debugf("sli status : %d good events out of %d", good, total)
+
+	burning, ok, err := isBurning(s.RequestBase, slo, objective, now, pageWindows(WindowDefaults(time.Duration(period))))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return &sloapi.SLOStatus{State: sloapi.SLOStatusState_PartialDataOk, Budget: budget}, nil
+	}
+	if burning {
+		return &sloapi.SLOStatus{State: sloapi.SLOStatusState_Warning, Budget: budget}, nil
+	}
+	return &sloapi.SLOStatus{State: sloapi.SLOStatusState_Ok, Budget: budget}, nil
+}
+
+func (s SLOLogging) Preview(_ *SLO) (*sloapi.SLOPreviewResponse, error) {
+	req := s.req.(*sloapi.CreateSLORequest)
+	slo := req.GetSlo()
+	objective := normalizeObjective(slo.GetTarget().GetValue())
+	preview := &sloapi.SLOPreviewResponse{
+		PlotVector: &sloapi.PlotVector{
+			Objective: objective,
+			Items:     []*sloapi.DataPoint{},
+			Windows:   []*sloapi.AlertFiringWindows{},
+		},
+	}
+	dur, err := prommodel.ParseDuration(slo.GetSloPeriod())
+	if err != nil {
+		return nil, err
+	}
+	cur := time.Now()
+	startTs, endTs := cur.Add(time.Duration(-dur)), cur
+	numSteps := 250
+	step := time.Duration(endTs.Sub(startTs).Seconds()/float64(numSteps)) * time.Second
+
+	resp, err := countLogEvents(s.RequestBase, &loggingadmin.LogEventCountRequest{
+		ClusterId:   slo.GetClusterId(),
+		ServiceId:   slo.GetServiceId(),
+		Start:       timestamppb.New(startTs),
+		End:         timestamppb.New(endTs),
+		TotalEvents: toLogEvents(slo.GetTotalEvents()),
+		GoodEvents:  toLogEvents(slo.GetGoodEvents()),
+		Step:        durationpb.New(step),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	windows := WindowDefaults(time.Duration(dur))
+	critical := &prommodel.SampleStream{}
+	severe := &prommodel.SampleStream{}
+	for _, bucket := range resp.GetBuckets() {
+		ts := bucket.GetTimestamp().AsTime()
+		total, good := bucket.GetTotal(), bucket.GetGood()
+		if total == 0 {
+			continue
+		}
+		preview.PlotVector.Items = append(preview.PlotVector.Items, &sloapi.DataPoint{
+			Timestamp: timestamppb.New(ts),
+			Sli:       float64(good) / float64(total) * 100,
+		})
+		burn := burnRate(good, total, objective)
+		critical.Values = append(critical.Values, firingSample(ts, burn > windows.GetSpeedPageQuick()))
+		severe.Values = append(severe.Values, firingSample(ts, burn > windows.GetSpeedPageSlow()))
+	}
+	severeWindows, err := DetectActiveWindows("severe", &prommodel.Matrix{severe})
+	if err != nil {
+		return nil, err
+	}
+	preview.PlotVector.Windows = append(preview.PlotVector.Windows, severeWindows...)
+	criticalWindows, err := DetectActiveWindows("critical", &prommodel.Matrix{critical})
+	if err != nil {
+		return nil, err
+	}
+	preview.PlotVector.Windows = append(preview.PlotVector.Windows, criticalWindows...)
+	return preview, nil
+}
+
+func (l *LoggingServiceBackend) WithCurrentRequest(req proto.Message, ctx context.Context) ServiceBackend {
+	l.req = req
+	l.ctx = ctx
+	return l
+}
+
+func (l LoggingServiceBackend) ListServices() (*sloapi.ServiceList, error) {
+	req := l.req.(*sloapi.ListServicesRequest)
+	res := &sloapi.ServiceList{}
+	services, err := listLogServices(l.RequestBase, req.GetClusterId())
+	if err != nil {
+		return nil, err
+	}
+	for _, svc := range services {
+		res.Items = append(res.Items, &sloapi.Service{
+			ClusterId: req.GetClusterId(),
+			ServiceId: svc,
+		})
+	}
+	return res, nil
+}
+
+// ListMetrics log documents are the only source of events for the logging datasource,
+// so there is a single metric per service
+func (l LoggingServiceBackend) ListMetrics() (*sloapi.MetricGroupList, error) {
+	req := l.req.(*sloapi.ListMetricsRequest)
+	if err := checkLogService(l.RequestBase, req.GetClusterId(), req.GetServiceId()); err != nil {
+		return nil, err
+	}
+	return &sloapi.MetricGroupList{
+		GroupNameToMetrics: map[string]*sloapi.MetricList{
+			query.LogMetricGroup: {
+				Items: []*sloapi.Metric{
+					{
+						Id: query.LogMetricId,
+						Metadata: &sloapi.MetricMetadata{
+							Description: "Log documents emitted by the service",
+							Type:        "counter",
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+func (l LoggingServiceBackend) ListEvents() (*sloapi.EventList, error) {
+	req := l.req.(*sloapi.ListEventsRequest)
+	res := &sloapi.EventList{
+		Items: []*sloapi.Event{},
+	}
+	if req.GetMetricId() != query.LogMetricId {
+		return nil, shared.WithNotFoundErrorf("metric %s not found for the logging datasource", req.GetMetricId())
+	}
+	ctxTimeout, cancelFunc := context.WithTimeout(l.ctx, 20*time.Second)
+	defer cancelFunc()
+	client, err := l.p.loggingAdminClient.GetContext(ctxTimeout)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.ListLogEvents(ctxTimeout, &loggingadmin.LogEventsRequest{
+		ClusterId: req.GetClusterId(),
+		ServiceId: req.GetServiceId(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range resp.GetEvents() {
+		res.Items = append(res.Items, &sloapi.Event{
+			Key:  event.GetKey(),
+			Vals: event.GetValues(),
+		})
+	}
+	return res, nil
+}
+
+func listLogServices(r RequestBase, clusterId string) ([]string, error) {
+	ctxTimeout, cancelFunc := context.WithTimeout(r.ctx, 20*time.Second)
+	defer cancelFunc()
+	client, err := r.p.loggingAdminClient.GetContext(ctxTimeout)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.ListLogServices(ctxTimeout, &loggingadmin.LogServicesRequest{
+		ClusterId: clusterId,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetServices(), nil
+}
+
+func checkLogService(r RequestBase, clusterId, serviceId string) error {
+	services, err := listLogServices(r, clusterId)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(services, serviceId) {
+		return shared.WithNotFoundErrorf("service %s has no logs on cluster %s", serviceId, clusterId)
+	}
+	return nil
+}
+
+func countLogEvents(r RequestBase, req *loggingadmin.LogEventCountRequest) (*loggingadmin.LogEventCountResponse, error) {
+	ctxTimeout, cancelFunc := context.WithTimeout(r.ctx, 20*time.Second)
+	defer cancelFunc()
+	client, err := r.p.loggingAdminClient.GetContext(ctxTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return client.CountLogEvents(ctxTimeout, req)
+}
+
+func countSLOEvents(r RequestBase, slo *sloapi.ServiceLevelObjective, start, end time.Time) (good, total int64, err error) {
+	resp, err := countLogEvents(r, &loggingadmin.LogEventCountRequest{
+		ClusterId:   slo.GetClusterId(),
+		ServiceId:   slo.GetServiceId(),
+		Start:       timestamppb.New(start),
+		End:         timestamppb.New(end),
+		TotalEvents: toLogEvents(slo.GetTotalEvents()),
+		GoodEvents:  toLogEvents(slo.GetGoodEvents()),
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+	return resp.GetGood(), resp.GetTotal(), nil
+}
+
+// toLogEvents drops the events without a key or values, which match every log document
+func toLogEvents(events []*sloapi.Event) []*loggingadmin.LogEvent {
+	var res []*loggingadmin.LogEvent
+	for _, event := range events {
+		if event.GetKey() == "" || len(event.GetVals()) == 0 {
+			continue
+		}
+		res = append(res, &loggingadmin.LogEvent{
+			Key:    event.GetKey(),
+			Values: event.GetVals(),
+		})
+	}
+	return res
+}
+
+// burnRate is the rate at which the error budget is consumed, relative to
+// the rate that would exactly consume it over the SLO period
+func burnRate(good, total int64, objective float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	errorRate := 1 - float64(good)/float64(total)
+	if objective >= 1 {
+		if errorRate > 0 {
+			return math.Inf(1)
+		}
+		return 0
+	}
+	return errorRate / (1 - objective)
+}
+
+func errorBudgetRemaining(good, total int64, objective float64) float64 {
+	return 1 - burnRate(good, total, objective)
+}
+
+// errorBudgetOf returns the error budget of an SLO, given its SLI over the
+// SLO period as a ratio. The budget is not defined if the objective is 100%.
+func errorBudgetOf(sli, objective float64) *sloapi.SLOErrorBudget {
+	if objective >= 1 || math.IsNaN(sli) || math.IsInf(sli, 0) {
+		return nil
+	}
+	return &sloapi.SLOErrorBudget{
+		Sli:       sli * 100,
+		Objective: objective * 100,
+		Remaining: (1 - (1-sli)/(1-objective)) * 100,
+	}
+}
+
+func firingSample(ts time.Time, firing bool) prommodel.SamplePair {
+	value := prommodel.SampleValue(0)
+	if firing {
+		value = 1
+	}
+	return prommodel.SamplePair{
+		Timestamp: prommodel.TimeFromUnixNano(ts.UnixNano()),
+		Value:     value,
+	}
+}
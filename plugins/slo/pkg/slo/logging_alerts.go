@@ -0,0 +1,189 @@
+package slo
+
+import (
+	"context"
+	"time"
+
+	prommodel "github.com/prometheus/common/model"
+	"github.com/rancher/opni/pkg/alerting/metrics"
+	alertingv1 "github.com/rancher/opni/pkg/apis/alerting/v1"
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	"github.com/rancher/opni/pkg/slo/shared"
+	sloapi "github.com/rancher/opni/plugins/slo/pkg/apis/slo"
+)
+
+// logAlertInterval is how often the alerts of log based SLOs are evaluated,
+// the same as the default interval of the monitoring SLO alerting rules
+const logAlertInterval = time.Minute
+
+const (
+	severityPage   = "page"
+	severityTicket = "ticket"
+)
+
+type burnWindow struct {
+	window Window
+	factor float64
+}
+
+func pageWindows(w *Windows) []burnWindow {
+	return []burnWindow{
+		{w.PageQuick, w.GetSpeedPageQuick()},
+		{w.PageSlow, w.GetSpeedPageSlow()},
+	}
+}
+
+func ticketWindows(w *Windows) []burnWindow {
+	return []burnWindow{
+		{w.TicketQuick, w.GetSpeedTicketQuick()},
+		{w.TicketSlow, w.GetSpeedTicketSlow()},
+	}
+}
+
+// logAlertIds are the ids of the page & ticket alerts of a log based SLO, they are
+// named like the alerting rules of monitoring SLOs and identify their routing nodes
+func logAlertIds(sloId string) map[string]string {
+	return map[string]string{
+		severityPage:   metrics.WithSloId(sloId, severityPage, AlertRuleSuffix),
+		severityTicket: metrics.WithSloId(sloId, severityTicket, AlertRuleSuffix),
+	}
+}
+
+// isBurning checks the windows in the same way the multi-window multi-burn-rate alerts of the
+// monitoring datasource do : the error budget is burning if it is consumed faster than the factor
+// of a window over both its short and long window. ok is false if a window has no events.
+func isBurning(
+	r RequestBase,
+	slo *sloapi.ServiceLevelObjective,
+	objective float64,
+	now time.Time,
+	windows []burnWindow,
+) (burning bool, ok bool, err error) {
+	for _, w := range windows {
+		windowBurning := true
+		for _, length := range []time.Duration{w.window.ShortWindow, w.window.LongWindow} {
+			good, total, err := countSLOEvents(r, slo, now.Add(-length), now)
+			if err != nil {
+				return false, false, err
+			}
+			if total == 0 {
+				return false, false, nil
+			}
+			if burnRate(good, total, objective) <= w.factor {
+				windowBurning = false
+			}
+		}
+		if windowBurning {
+			return true, true, nil
+		}
+	}
+	return false, true, nil
+}
+
+// runLogAlerts fires the alerts of the log based SLOs while their error budget is burning,
+// and resolves them once it isn't. Alerts are sent again on every evaluation so they don't
+// time out in AlertManager.
+func (p *Plugin) runLogAlerts(ctx context.Context) {
+	ticker := time.NewTicker(logAlertInterval)
+	defer ticker.Stop()
+	firing := map[string]struct{}{}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.evaluateLogAlerts(ctx, firing)
+		}
+	}
+}
+
+func (p *Plugin) evaluateLogAlerts(ctx context.Context, firing map[string]struct{}) {
+	lg := p.logger.With("component", "log-alerts")
+	ctxTimeout, cancelFunc := context.WithTimeout(ctx, logAlertInterval)
+	defer cancelFunc()
+	storage, err := p.storage.GetContext(ctxTimeout)
+	if err != nil {
+		return
+	}
+	slos, err := list(ctxTimeout, storage.SLOs, "/slos")
+	if err != nil {
+		lg.With("error", err).Warn("failed to list SLOs")
+		return
+	}
+	r := RequestBase{
+		p:   p,
+		ctx: ctxTimeout,
+		lg:  lg,
+	}
+	seen := map[string]struct{}{}
+	now := time.Now()
+	for _, data := range slos {
+		slo := data.GetSLO()
+		if slo.GetDatasource() != shared.LoggingDatasource {
+			continue
+		}
+		period, err := prommodel.ParseDuration(slo.GetSloPeriod())
+		if err != nil {
+			continue
+		}
+		windows := WindowDefaults(time.Duration(period))
+		objective := normalizeObjective(slo.GetTarget().GetValue())
+		for severity, alertId := range logAlertIds(data.GetId()) {
+			seen[alertId] = struct{}{}
+			bw := pageWindows(windows)
+			if severity == severityTicket {
+				bw = ticketWindows(windows)
+			}
+			burning, _, err := isBurning(r, slo, objective, now, bw)
+			if err != nil {
+				lg.With("sloId", data.GetId(), "error", err).Warn("failed to evaluate SLO alert")
+				continue
+			}
+			annotations := map[string]string{
+				"sloId":    data.GetId(),
+				"sloName":  slo.GetName(),
+				"severity": severity,
+			}
+			if burning {
+				p.triggerLogAlert(ctxTimeout, alertId, annotations)
+				firing[alertId] = struct{}{}
+			} else if _, ok := firing[alertId]; ok {
+				p.resolveLogAlert(ctxTimeout, alertId, annotations)
+				delete(firing, alertId)
+			}
+		}
+	}
+	// SLOs deleted while their alerts were firing
+	for alertId := range firing {
+		if _, ok := seen[alertId]; !ok {
+			p.resolveLogAlert(ctxTimeout, alertId, nil)
+			delete(firing, alertId)
+		}
+	}
+}
+
+func (p *Plugin) triggerLogAlert(ctx context.Context, alertId string, annotations map[string]string) {
+	client, err := p.alertTriggerClient.GetContext(ctx)
+	if err != nil {
+		return
+	}
+	if _, err := client.TriggerAlerts(ctx, &alertingv1.TriggerAlertsRequest{
+		ConditionId: &corev1.Reference{Id: alertId},
+		Annotations: annotations,
+	}); err != nil {
+		p.logger.With("alertId", alertId, "error", err).Debug("failed to trigger SLO alert")
+	}
+}
+
+func (p *Plugin) resolveLogAlert(ctx context.Context, alertId string, annotations map[string]string) {
+	client, err := p.alertTriggerClient.GetContext(ctx)
+	if err != nil {
+		return
+	}
+	if _, err := client.ResolveAlerts(ctx, &alertingv1.ResolveAlertsRequest{
+		ConditionId: &corev1.Reference{Id: alertId},
+		Annotations: annotations,
+	}); err != nil {
+		p.logger.With("alertId", alertId, "error", err).Debug("failed to resolve SLO alert")
+	}
+}
@@ -3,17 +3,21 @@ package slo
 import (
 	"context"
 	"github.com/rancher/opni/plugins/alerting/pkg/apis/server/endpoint"
+	"github.com/rancher/opni/plugins/alerting/pkg/apis/server/trigger"
 
 	"go.uber.org/zap"
 
 	managementv1 "github.com/rancher/opni/pkg/apis/management/v1"
+	"github.com/rancher/opni/pkg/capabilities"
 	"github.com/rancher/opni/pkg/logger"
 	managementext "github.com/rancher/opni/pkg/plugins/apis/apiextensions/management"
+	"github.com/rancher/opni/pkg/plugins/apis/capability"
 	"github.com/rancher/opni/pkg/plugins/apis/system"
 	"github.com/rancher/opni/pkg/plugins/meta"
 	"github.com/rancher/opni/pkg/storage"
 	"github.com/rancher/opni/pkg/util"
 	"github.com/rancher/opni/pkg/util/future"
+	"github.com/rancher/opni/plugins/logging/pkg/apis/loggingadmin"
 	"github.com/rancher/opni/plugins/metrics/pkg/apis/cortexadmin"
 	sloapi "github.com/rancher/opni/plugins/slo/pkg/apis/slo"
 )
@@ -29,6 +33,10 @@ type Plugin struct {
 	mgmtClient          future.Future[managementv1.ManagementClient]
 	adminClient         future.Future[cortexadmin.CortexAdminClient]
 	alertEndpointClient future.Future[endpoint.AlertEndpointsClient]
+	alertTriggerClient  future.Future[trigger.AlertingClient]
+	loggingAdminClient  future.Future[loggingadmin.LoggingAdminV2Client]
+	clusterStore        future.Future[storage.ClusterStore]
+	capabilityBackend   *capabilities.GatewayBackend
 }
 
 type StorageAPIs struct {
@@ -38,14 +46,19 @@ type StorageAPIs struct {
 }
 
 func NewPlugin(ctx context.Context) *Plugin {
-	return &Plugin{
+	p := &Plugin{
 		ctx:                 ctx,
 		logger:              logger.NewPluginLogger().Named("slo"),
 		storage:             future.New[StorageAPIs](),
 		mgmtClient:          future.New[managementv1.ManagementClient](),
 		adminClient:         future.New[cortexadmin.CortexAdminClient](),
 		alertEndpointClient: future.New[endpoint.AlertEndpointsClient](),
+		alertTriggerClient:  future.New[trigger.AlertingClient](),
+		loggingAdminClient:  future.New[loggingadmin.LoggingAdminV2Client](),
+		clusterStore:        future.New[storage.ClusterStore](),
 	}
+	p.capabilityBackend = p.newCapabilityBackend()
+	return p
 }
 
 var _ sloapi.SLOServer = (*Plugin)(nil)
@@ -56,5 +69,6 @@ func Scheme(ctx context.Context) meta.Scheme {
 	scheme.Add(system.SystemPluginID, system.NewPlugin(p))
 	scheme.Add(managementext.ManagementAPIExtensionPluginID,
 		managementext.NewPlugin(util.PackService(&sloapi.SLO_ServiceDesc, p)))
+	scheme.Add(capability.CapabilityBackendPluginID, capability.NewPlugin(p.capabilityBackend))
 	return scheme
 }
@@ -3,14 +3,19 @@ package slo
 import (
 	"context"
 	"github.com/rancher/opni/plugins/alerting/pkg/apis/server/endpoint"
+	"github.com/rancher/opni/plugins/alerting/pkg/apis/server/trigger"
 	"os"
 	"time"
 
 	managementv1 "github.com/rancher/opni/pkg/apis/management/v1"
+	"github.com/rancher/opni/pkg/config/v1beta1"
+	"github.com/rancher/opni/pkg/machinery"
 	"github.com/rancher/opni/pkg/plugins/apis/system"
 	"github.com/rancher/opni/pkg/slo/shared"
+	"github.com/rancher/opni/plugins/logging/pkg/apis/loggingadmin"
 	"github.com/rancher/opni/plugins/metrics/pkg/apis/cortexadmin"
 	sloapi "github.com/rancher/opni/plugins/slo/pkg/apis/slo"
+	"google.golang.org/grpc"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
@@ -36,12 +41,36 @@ func (p *Plugin) UseManagementAPI(client managementv1.ManagementClient) {
 		}
 		p.logger.Debug("cortex API available")
 		p.mgmtClient.Set(client)
+		p.configureClusterStore(client)
 		<-p.ctx.Done()
 	}
 	p.logger.Error("cortex api not available, stopping plugin")
 	os.Exit(1)
 }
 
+// configureClusterStore connects to the gateway's storage backend, which the
+// slo capability uses to install itself on clusters.
+func (p *Plugin) configureClusterStore(client managementv1.ManagementClient) {
+	cfg, err := client.GetConfig(context.Background(), &emptypb.Empty{}, grpc.WaitForReady(true))
+	if err != nil {
+		p.logger.With("err", err).Error("failed to get config")
+		os.Exit(1)
+	}
+	objectList, err := machinery.LoadDocuments(cfg.Documents)
+	if err != nil {
+		p.logger.With("err", err).Error("failed to load config")
+		os.Exit(1)
+	}
+	objectList.Visit(func(config *v1beta1.GatewayConfig) {
+		backend, err := machinery.ConfigureStorageBackend(p.ctx, &config.Spec.Storage)
+		if err != nil {
+			p.logger.With("err", err).Error("failed to configure storage backend")
+			os.Exit(1)
+		}
+		p.clusterStore.Set(backend)
+	})
+}
+
 func (p *Plugin) UseKeyValueStore(client system.KeyValueStoreClient) {
 	p.storage.Set(StorageAPIs{
 		SLOs:     system.NewKVStoreClient[*sloapi.SLOData](client),
@@ -62,9 +91,26 @@ func (p *Plugin) UseAPIExtensions(intf system.ExtensionClientInterface) {
 
 	p.adminClient.Set(adminClient)
 	p.alertEndpointClient.Set(alertingEndpointClient)
+	p.alertTriggerClient.Set(trigger.NewAlertingClient(cc))
+	// the logging plugin is optional, logging SLO requests wait on its client
+	// instead of holding up the monitoring datasource
+	go func() {
+		cc, err := intf.GetClientConn(p.ctx, "LoggingAdminV2")
+		if err != nil {
+			p.logger.Warn("logging admin API not available, logging SLOs are disabled", "error", err)
+			return
+		}
+		p.loggingAdminClient.Set(loggingadmin.NewLoggingAdminV2Client(cc))
+		p.runLogAlerts(p.ctx)
+	}()
 	RegisterDatasource(
 		shared.MonitoringDatasource,
 		NewSLOMonitoringStore(p, p.logger),
 		NewMonitoringServiceBackend(p, p.logger),
 	)
+	RegisterDatasource(
+		shared.LoggingDatasource,
+		NewSLOLoggingStore(p, p.logger),
+		NewLoggingServiceBackend(p, p.logger),
+	)
 }
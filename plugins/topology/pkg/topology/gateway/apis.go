@@ -7,74 +7,279 @@ Orchestrator API implementation
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/nats-io/nats.go"
 
 	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
 	kgraph "github.com/steveteuber/kubectl-graph/pkg/graph"
 
 	"github.com/rancher/opni/pkg/topology/graph"
 	"github.com/rancher/opni/pkg/topology/store"
+	"github.com/rancher/opni/pkg/validation"
 	"github.com/rancher/opni/plugins/topology/pkg/apis/orchestrator"
 	"github.com/rancher/opni/plugins/topology/pkg/apis/representation"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func (p *Plugin) GetGraph(ctx context.Context, ref *corev1.Reference) (*representation.TopologyGraph, error) {
-	// TODO(topology) : implement me
-	return nil, status.Error(codes.Unimplemented, "method not implemented")
+	if err := ref.Validate(); err != nil {
+		return nil, err
+	}
+	obj, err := p.topologyObjectStore(ctx)
+	if err != nil {
+		return nil, err
+	}
+	objInfo, err := obj.GetInfo(store.NewClusterKey(ref))
+	if err != nil {
+		return nil, objectStoreError(err)
+	}
+	repr, err := reprOf(objInfo)
+	if err != nil {
+		return nil, err
+	}
+	data, err := obj.GetBytes(store.NewClusterKey(ref))
+	if err != nil {
+		return nil, objectStoreError(err)
+	}
+	return &representation.TopologyGraph{
+		Id:   ref,
+		Data: data,
+		Repr: repr,
+	}, nil
 }
 
 func (p *Plugin) RenderGraph(ctx context.Context, clusterRef *corev1.Reference) (*representation.DOTRepresentation, error) {
-	if !p.topologyRemoteWrite.Initialized() {
-		return nil, status.Error(codes.Unavailable, "topology remote write not initialized")
+	rendered, err := p.RenderFormattedGraph(ctx, &representation.RenderGraphRequest{
+		ClusterId: clusterRef,
+		Format:    representation.RenderFormat_DOT,
+	})
+	if err != nil {
+		return nil, err
 	}
-	ctxCa, cancel := context.WithCancel(ctx)
-	defer cancel()
-	mgr, err := p.nc.GetContext(ctxCa)
+	return &representation.DOTRepresentation{
+		RawDotFormat: rendered.GetData(),
+	}, nil
+}
+
+func (p *Plugin) RenderFormattedGraph(ctx context.Context, req *representation.RenderGraphRequest) (*representation.RenderedGraph, error) {
+	if err := req.GetClusterId().Validate(); err != nil {
+		return nil, err
+	}
+	obj, err := p.topologyObjectStore(ctx)
+	if err != nil {
+		return nil, err
+	}
+	g, err := loadKubectlGraph(obj, store.NewClusterKey(req.GetClusterId()))
 	if err != nil {
+		return nil, err
+	}
+	filter := graph.Filter{
+		Namespaces:    req.GetFilter().GetNamespaces(),
+		Kinds:         req.GetFilter().GetKinds(),
+		LabelSelector: req.GetFilter().GetLabelSelector(),
+	}
+	if !filter.IsEmpty() {
+		g, err = filter.Apply(g)
+		if err != nil {
+			return nil, validation.Errorf("invalid label selector: %v", err)
+		}
+	}
+	// !! Cannot store marshalled digraph, since it will not capture information unless
+	// we implement the entire gonum.Graph and its sub interfaces ourselves
+	diGraph := graph.NewScientificKubeGraph()
+	if err := diGraph.FromKubectlGraph(g); err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
-	obj, err := store.GetTopologyObjectStore(mgr)
+
+	var data []byte
+	var contentType string
+	switch req.GetFormat() {
+	case representation.RenderFormat_DOT:
+		data, err = diGraph.RenderDOT()
+		contentType = "text/vnd.graphviz"
+	case representation.RenderFormat_JSON:
+		data, err = diGraph.RenderJSON()
+		contentType = "application/json"
+	case representation.RenderFormat_Mermaid:
+		data, err = diGraph.RenderMermaid()
+		contentType = "text/plain"
+	case representation.RenderFormat_SVG:
+		data, err = diGraph.RenderSVG()
+		contentType = "image/svg+xml"
+	default:
+		return nil, validation.Errorf("unknown render format: %s", req.GetFormat())
+	}
 	if err != nil {
 		return nil, err
 	}
-	objInfo, err := obj.GetInfo(store.NewClusterKey(clusterRef))
+	return &representation.RenderedGraph{
+		Format:      req.GetFormat(),
+		ContentType: contentType,
+		Data:        string(data),
+	}, nil
+}
+
+func (p *Plugin) DiffGraph(ctx context.Context, req *representation.GraphDiffRequest) (*representation.GraphDiff, error) {
+	if err := req.GetClusterId().Validate(); err != nil {
+		return nil, err
+	}
+	end := time.Now()
+	if req.End != nil {
+		end = req.End.AsTime()
+	}
+	start := end.Add(-time.Hour)
+	if req.Start != nil {
+		start = req.Start.AsTime()
+	}
+	if start.After(end) {
+		return nil, validation.Errorf("start time (%s) must not be after end time (%s)", start, end)
+	}
+
+	obj, err := p.topologyObjectStore(ctx)
 	if err != nil {
 		return nil, err
 	}
+	snapshots, err := store.ListSnapshots(obj, req.ClusterId)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	from, err := store.SnapshotAt(snapshots, start)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "no topology snapshots found for cluster %s", req.ClusterId.GetId())
+	}
+	to, _ := store.SnapshotAt(snapshots, end)
+	if to.Timestamp.After(end) {
+		return nil, status.Errorf(codes.NotFound, "no topology snapshots found for cluster %s before %s", req.ClusterId.GetId(), end)
+	}
+
+	before, err := loadKubectlGraph(obj, from.Key)
+	if err != nil {
+		return nil, err
+	}
+	after := before
+	if to.Key != from.Key {
+		after, err = loadKubectlGraph(obj, to.Key)
+		if err != nil {
+			return nil, err
+		}
+	}
+	diff := graph.Diff(before, after)
+
+	resp := &representation.GraphDiff{
+		ClusterId: req.ClusterId,
+		Start:     timestamppb.New(from.Timestamp),
+		End:       timestamppb.New(to.Timestamp),
+	}
+	for _, node := range diff.AddedNodes {
+		resp.AddedNodes = append(resp.AddedNodes, graphNode(node))
+	}
+	for _, node := range diff.RemovedNodes {
+		resp.RemovedNodes = append(resp.RemovedNodes, graphNode(node))
+	}
+	for _, change := range diff.ChangedNodes {
+		resp.ChangedNodes = append(resp.ChangedNodes, &representation.NodeChange{
+			Old:    graphNode(change.Old),
+			New:    graphNode(change.New),
+			Fields: change.Fields,
+		})
+	}
+	for _, edge := range diff.AddedEdges {
+		resp.AddedEdges = append(resp.AddedEdges, graphEdge(edge))
+	}
+	for _, edge := range diff.RemovedEdges {
+		resp.RemovedEdges = append(resp.RemovedEdges, graphEdge(edge))
+	}
+	for _, change := range diff.ChangedEdges {
+		resp.ChangedEdges = append(resp.ChangedEdges, &representation.EdgeChange{
+			Old: graphEdge(change.Old),
+			New: graphEdge(change.New),
+		})
+	}
+	return resp, nil
+}
+
+func (p *Plugin) topologyObjectStore(ctx context.Context) (nats.ObjectStore, error) {
+	if !p.topologyRemoteWrite.Initialized() {
+		return nil, status.Error(codes.Unavailable, "topology remote write not initialized")
+	}
+	ctxCa, cancel := context.WithCancel(ctx)
+	defer cancel()
+	mgr, err := p.nc.GetContext(ctxCa)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return store.GetTopologyObjectStore(mgr)
+}
+
+func objectStoreError(err error) error {
+	if errors.Is(err, nats.ErrObjectNotFound) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return err
+}
+
+func reprOf(objInfo *nats.ObjectInfo) (representation.GraphRepr, error) {
 	reprKey := objInfo.Headers.Get(store.ReprHeaderKey)
 	if reprKey == "" {
-		return nil, status.Error(
+		return representation.GraphRepr_None, status.Error(
 			codes.Internal,
 			"no representation header found for the cluster",
 		)
 	}
-	graphObj, err := obj.Get(store.NewClusterKey(clusterRef))
+	repr, ok := representation.GraphRepr_value[reprKey]
+	if !ok {
+		return representation.GraphRepr_None, status.Error(codes.Internal, "invalid representation key")
+	}
+	return representation.GraphRepr(repr), nil
+}
+
+func loadKubectlGraph(obj nats.ObjectStore, key string) (*kgraph.Graph, error) {
+	objInfo, err := obj.GetInfo(key)
+	if err != nil {
+		return nil, objectStoreError(err)
+	}
+	repr, err := reprOf(objInfo)
 	if err != nil {
 		return nil, err
 	}
-
-	switch reprKey {
-	case representation.GraphRepr_KubectlGraph.String():
-		var g *kgraph.Graph
-		if err := json.NewDecoder(graphObj).Decode(&g); err != nil {
-			return nil, err
-		}
-		// !! Cannot store marshalled digraph, since it will not capture information unless
-		// we implement the entire gonum.Graph and its sub interfaces ourselves
-		diGraph := graph.NewScientificKubeGraph()
-		diGraph.FromKubectlGraph(g)
-		bytes, err := diGraph.RenderDOT()
-		if err != nil {
-			return nil, err
-		}
-		return &representation.DOTRepresentation{
-			RawDotFormat: string(bytes),
-		}, nil
-	default:
+	if repr != representation.GraphRepr_KubectlGraph {
 		return nil, status.Error(codes.Internal, "invalid representation key")
 	}
+	graphObj, err := obj.Get(key)
+	if err != nil {
+		return nil, objectStoreError(err)
+	}
+	defer graphObj.Close()
+	var g *kgraph.Graph
+	if err := json.NewDecoder(graphObj).Decode(&g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func graphNode(node *kgraph.Node) *representation.GraphNode {
+	return &representation.GraphNode{
+		Uid:        string(node.UID),
+		ApiVersion: node.APIVersion,
+		Kind:       node.Kind,
+		Namespace:  node.Namespace,
+		Name:       node.Name,
+		Labels:     node.Labels,
+	}
+}
+
+func graphEdge(edge *kgraph.Relationship) *representation.GraphEdge {
+	return &representation.GraphEdge{
+		From:       string(edge.From),
+		To:         string(edge.To),
+		Label:      edge.Label,
+		Attributes: edge.Attr,
+	}
 }
 
 func (p *Plugin) GetClusterStatus(ctx context.Context, _ *emptypb.Empty) (*orchestrator.InstallStatus, error) {
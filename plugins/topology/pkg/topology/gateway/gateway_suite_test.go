@@ -0,0 +1,37 @@
+package gateway
+
+import (
+	"testing"
+
+	natsserver "github.com/nats-io/nats-server/v2/server"
+	natstest "github.com/nats-io/nats-server/v2/test"
+	"github.com/nats-io/nats.go"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestGateway(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Topology Gateway Suite")
+}
+
+var nc *nats.Conn
+
+// pkg/test imports this package, so the embedded jetstream server is
+// started here instead of through test.Environment.
+var _ = BeforeSuite(func() {
+	opts := natstest.DefaultTestOptions
+	opts.Port = natsserver.RANDOM_PORT
+	opts.JetStream = true
+	opts.StoreDir = GinkgoT().TempDir()
+	srv := natstest.RunServer(&opts)
+
+	var err error
+	nc, err = nats.Connect(srv.ClientURL())
+	Expect(err).NotTo(HaveOccurred())
+
+	DeferCleanup(func() {
+		nc.Close()
+		srv.Shutdown()
+	})
+})
@@ -6,6 +6,7 @@ Declares the topology plugin's gateway meta scheme.
 
 import (
 	"context"
+	"time"
 
 	"github.com/nats-io/nats.go"
 	capabilityv1 "github.com/rancher/opni/pkg/apis/capability/v1"
@@ -40,8 +41,9 @@ type Plugin struct {
 	topologyRemoteWrite stream.TopologyStreamWriter
 	topologyBackend     backend.TopologyBackend
 
-	nc      future.Future[*nats.Conn]
-	storage future.Future[ConfigStorageAPIs]
+	nc                future.Future[*nats.Conn]
+	storage           future.Future[ConfigStorageAPIs]
+	snapshotRetention future.Future[time.Duration]
 
 	mgmtClient future.Future[managementv1.ManagementClient]
 
@@ -56,6 +58,7 @@ func NewPlugin(ctx context.Context) *Plugin {
 		ctx:                 ctx,
 		logger:              logger.NewPluginLogger().Named("topology"),
 		nc:                  future.New[*nats.Conn](),
+		snapshotRetention:   future.New[time.Duration](),
 		storage:             future.New[ConfigStorageAPIs](),
 		mgmtClient:          future.New[managementv1.ManagementClient](),
 		storageBackend:      future.New[storage.Backend](),
@@ -64,10 +67,11 @@ func NewPlugin(ctx context.Context) *Plugin {
 		clusterDriver:       future.New[drivers.ClusterDriver](),
 		topologyBackend:     backend.TopologyBackend{},
 	}
-	future.Wait1(p.nc, func(nc *nats.Conn) {
+	future.Wait2(p.nc, p.snapshotRetention, func(nc *nats.Conn, snapshotRetention time.Duration) {
 		p.topologyRemoteWrite.Initialize(stream.TopologyStreamWriteConfig{
-			Logger: p.logger.With("component", "stream"),
-			Nc:     nc,
+			Logger:            p.logger.With("component", "stream"),
+			Nc:                nc,
+			SnapshotRetention: snapshotRetention,
 		})
 	})
 
@@ -0,0 +1,191 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	"github.com/rancher/opni/pkg/topology/graph"
+	"github.com/rancher/opni/pkg/topology/store"
+	"github.com/rancher/opni/pkg/validation"
+	"github.com/rancher/opni/plugins/topology/pkg/apis/representation"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func (p *Plugin) GetNeighbors(ctx context.Context, req *representation.NeighborsRequest) (*representation.Neighbors, error) {
+	if err := validateObjectReference(req.GetObject()); err != nil {
+		return nil, err
+	}
+	depth := int(req.GetDepth())
+	if depth < 0 {
+		return nil, validation.Error("depth must not be negative")
+	} else if depth == 0 {
+		depth = 1
+	}
+	g, err := p.loadClusterGraph(ctx, req.GetClusterId())
+	if err != nil {
+		return nil, err
+	}
+	node, err := findNode(g, req.GetObject())
+	if err != nil {
+		return nil, err
+	}
+
+	neighbors := g.Neighbors(node, depth, graph.Direction(req.GetDirection()))
+	resp := &representation.Neighbors{
+		Object: graphNodeOf(node),
+	}
+	nodes := []*graph.ScientificKubeNode{node}
+	for _, n := range neighbors {
+		resp.Neighbors = append(resp.Neighbors, &representation.Neighbor{
+			Node:      graphNodeOf(n.Node),
+			Depth:     int32(n.Depth),
+			Direction: representation.Direction(n.Direction),
+		})
+		nodes = append(nodes, n.Node)
+	}
+	for _, e := range g.EdgesBetween(nodes) {
+		resp.Edges = append(resp.Edges, graphEdgeOf(e))
+	}
+	return resp, nil
+}
+
+func (p *Plugin) GetShortestPath(ctx context.Context, req *representation.ShortestPathRequest) (*representation.GraphPath, error) {
+	if err := validateObjectReference(req.GetFrom()); err != nil {
+		return nil, err
+	}
+	if err := validateObjectReference(req.GetTo()); err != nil {
+		return nil, err
+	}
+	g, err := p.loadClusterGraph(ctx, req.GetClusterId())
+	if err != nil {
+		return nil, err
+	}
+	from, err := findNode(g, req.GetFrom())
+	if err != nil {
+		return nil, err
+	}
+	to, err := findNode(g, req.GetTo())
+	if err != nil {
+		return nil, err
+	}
+
+	path := g.ShortestPath(from, to, req.GetDirected())
+	if path == nil {
+		return nil, status.Errorf(codes.NotFound, "no path found between %s and %s", objectRef(req.GetFrom()), objectRef(req.GetTo()))
+	}
+	resp := &representation.GraphPath{}
+	for i, n := range path {
+		resp.Nodes = append(resp.Nodes, graphNodeOf(n))
+		if i == 0 {
+			continue
+		}
+		// edges on an undirected path may point in either direction
+		if e, ok := g.Edge(path[i-1].ID(), n.ID()).(*graph.ScientificKubeEdge); ok {
+			resp.Edges = append(resp.Edges, graphEdgeOf(e))
+		} else if e, ok := g.Edge(n.ID(), path[i-1].ID()).(*graph.ScientificKubeEdge); ok {
+			resp.Edges = append(resp.Edges, graphEdgeOf(e))
+		}
+	}
+	return resp, nil
+}
+
+func (p *Plugin) GetBlastRadius(ctx context.Context, req *representation.BlastRadiusRequest) (*representation.BlastRadius, error) {
+	if err := validateObjectReference(req.GetObject()); err != nil {
+		return nil, err
+	}
+	g, err := p.loadClusterGraph(ctx, req.GetClusterId())
+	if err != nil {
+		return nil, err
+	}
+	node, err := findNode(g, req.GetObject())
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &representation.BlastRadius{
+		Object: graphNodeOf(node),
+	}
+	for _, n := range g.BlastRadius(node) {
+		gn := graphNodeOf(n)
+		if graph.IsWorkload(n) {
+			resp.Workloads = append(resp.Workloads, gn)
+		}
+		resp.Affected = append(resp.Affected, gn)
+	}
+	return resp, nil
+}
+
+// loadClusterGraph loads the most recent topology of the cluster.
+func (p *Plugin) loadClusterGraph(ctx context.Context, clusterId *corev1.Reference) (*graph.ScientificKubeGraph, error) {
+	if err := clusterId.Validate(); err != nil {
+		return nil, err
+	}
+	obj, err := p.topologyObjectStore(ctx)
+	if err != nil {
+		return nil, err
+	}
+	kg, err := loadKubectlGraph(obj, store.NewClusterKey(clusterId))
+	if err != nil {
+		return nil, err
+	}
+	g := graph.NewScientificKubeGraph()
+	if err := g.FromKubectlGraph(kg); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return g, nil
+}
+
+func validateObjectReference(ref *representation.ObjectReference) error {
+	if ref == nil {
+		return validation.Error("object reference is required")
+	}
+	if ref.GetUid() == "" && ref.GetName() == "" {
+		return validation.Error("object reference must have either a uid or a name")
+	}
+	return nil
+}
+
+func objectRef(ref *representation.ObjectReference) graph.ObjectReference {
+	return graph.ObjectReference{
+		UID:       types.UID(ref.GetUid()),
+		Kind:      ref.GetKind(),
+		Namespace: ref.GetNamespace(),
+		Name:      ref.GetName(),
+	}
+}
+
+func findNode(g *graph.ScientificKubeGraph, ref *representation.ObjectReference) (*graph.ScientificKubeNode, error) {
+	node, err := g.FindNode(objectRef(ref))
+	switch {
+	case errors.Is(err, graph.ErrObjectNotFound):
+		return nil, status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, graph.ErrAmbiguousReference):
+		return nil, validation.Error(err.Error())
+	case err != nil:
+		return nil, err
+	}
+	return node, nil
+}
+
+func graphNodeOf(node *graph.ScientificKubeNode) *representation.GraphNode {
+	return &representation.GraphNode{
+		Uid:        string(node.UID),
+		ApiVersion: node.APIVersion,
+		Kind:       node.Kind,
+		Namespace:  node.Namespace,
+		Name:       node.Name,
+		Labels:     node.Labels,
+	}
+}
+
+func graphEdgeOf(edge *graph.ScientificKubeEdge) *representation.GraphEdge {
+	return &representation.GraphEdge{
+		From:       string(edge.From().(*graph.ScientificKubeNode).UID),
+		To:         string(edge.To().(*graph.ScientificKubeNode).UID),
+		Label:      edge.Label,
+		Attributes: edge.Attributes,
+	}
+}
@@ -0,0 +1,244 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
+	"github.com/rancher/opni/plugins/topology/pkg/apis/representation"
+	"github.com/rancher/opni/plugins/topology/pkg/apis/stream"
+	kgraph "github.com/steveteuber/kubectl-graph/pkg/graph"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var _ = Describe("Topology queries", Ordered, Label("unit"), func() {
+	var p *Plugin
+	cluster := &corev1.Reference{Id: "cluster-1"}
+
+	// node-1 runs web-1, which is owned by the web deployment and selected
+	// by the web service. node-2 runs other-1, which is unrelated.
+	newGraph := func() *kgraph.Graph {
+		kg := &kgraph.Graph{
+			Nodes:         map[types.UID]*kgraph.Node{},
+			Relationships: map[types.UID][]*kgraph.Relationship{},
+		}
+		object := func(apiVersion, kind, name string, owner *kgraph.Node) *kgraph.Node {
+			meta := &metav1.ObjectMeta{
+				UID:  types.UID(kind + "-" + name),
+				Name: name,
+			}
+			if kind != "Node" {
+				meta.Namespace = "default"
+			}
+			if owner != nil {
+				meta.OwnerReferences = []metav1.OwnerReference{{
+					APIVersion: owner.APIVersion,
+					Kind:       owner.Kind,
+					Name:       owner.Name,
+					UID:        owner.UID,
+				}}
+			}
+			return kg.Node(schema.FromAPIVersionAndKind(apiVersion, kind), meta)
+		}
+		node1 := object("v1", "Node", "node-1", nil)
+		node2 := object("v1", "Node", "node-2", nil)
+		deployment := object("apps/v1", "Deployment", "web", nil)
+		replicaSet := object("apps/v1", "ReplicaSet", "web-abc", deployment)
+		pod := object("v1", "Pod", "web-1", replicaSet)
+		service := object("v1", "Service", "web", nil)
+		endpoints := object("v1", "Endpoints", "web", nil)
+		kg.Relationship(service, "Endpoints", endpoints)
+		kg.Relationship(endpoints, "Pod", pod)
+		kg.Relationship(node1, "Pod", pod)
+		other := object("apps/v1", "Deployment", "other", nil)
+		otherPod := object("v1", "Pod", "other-1", object("apps/v1", "ReplicaSet", "other-abc", other))
+		kg.Relationship(node2, "Pod", otherPod)
+		return kg
+	}
+	ref := func(kind, name string) *representation.ObjectReference {
+		return &representation.ObjectReference{Kind: kind, Namespace: "default", Name: name}
+	}
+	names := func(nodes []*representation.GraphNode) []string {
+		var n []string
+		for _, node := range nodes {
+			n = append(n, node.GetKind()+"/"+node.GetName())
+		}
+		return n
+	}
+
+	BeforeAll(func() {
+		p = NewPlugin(context.Background())
+		p.nc.Set(nc)
+		p.snapshotRetention.Set(0)
+		Eventually(p.topologyRemoteWrite.Initialized).Should(BeTrue())
+
+		data, err := json.Marshal(newGraph())
+		Expect(err).NotTo(HaveOccurred())
+		_, err = p.topologyRemoteWrite.Push(context.Background(), &stream.Payload{
+			Graph: &stream.TopologyGraph{
+				ClusterId: cluster,
+				Data:      data,
+				Repr:      stream.GraphRepr_KubectlGraph,
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Context("GetNeighbors", func() {
+		It("should return neighbors and the edges between them", func() {
+			resp, err := p.GetNeighbors(context.Background(), &representation.NeighborsRequest{
+				ClusterId: cluster,
+				Object:    ref("Pod", "web-1"),
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.GetObject().GetUid()).To(Equal("Pod-web-1"))
+
+			var neighbors []string
+			for _, n := range resp.GetNeighbors() {
+				Expect(n.GetDepth()).To(BeEquivalentTo(1))
+				neighbors = append(neighbors, n.GetNode().GetKind()+"/"+n.GetNode().GetName())
+			}
+			Expect(neighbors).To(ConsistOf("ReplicaSet/web-abc", "Endpoints/web", "Node/node-1"))
+			Expect(resp.GetEdges()).To(HaveLen(3))
+			for _, e := range resp.GetEdges() {
+				Expect(e.GetTo()).To(Equal("Pod-web-1"))
+			}
+		})
+		It("should follow a single direction up to the requested depth", func() {
+			resp, err := p.GetNeighbors(context.Background(), &representation.NeighborsRequest{
+				ClusterId: cluster,
+				Object:    ref("Deployment", "web"),
+				Depth:     2,
+				Direction: representation.Direction_Downstream,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			var neighbors []*representation.GraphNode
+			for _, n := range resp.GetNeighbors() {
+				Expect(n.GetDirection()).To(Equal(representation.Direction_Downstream))
+				neighbors = append(neighbors, n.GetNode())
+			}
+			Expect(names(neighbors)).To(Equal([]string{"ReplicaSet/web-abc", "Pod/web-1"}))
+		})
+		It("should reject invalid requests", func() {
+			_, err := p.GetNeighbors(context.Background(), &representation.NeighborsRequest{
+				ClusterId: cluster,
+				Object:    ref("Pod", "web-1"),
+				Depth:     -1,
+			})
+			Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+
+			_, err = p.GetNeighbors(context.Background(), &representation.NeighborsRequest{
+				ClusterId: cluster,
+				Object:    &representation.ObjectReference{Kind: "Pod"},
+			})
+			Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+		})
+		It("should return NotFound for unknown objects or clusters", func() {
+			_, err := p.GetNeighbors(context.Background(), &representation.NeighborsRequest{
+				ClusterId: cluster,
+				Object:    ref("Pod", "missing"),
+			})
+			Expect(status.Code(err)).To(Equal(codes.NotFound))
+
+			_, err = p.GetNeighbors(context.Background(), &representation.NeighborsRequest{
+				ClusterId: &corev1.Reference{Id: "missing"},
+				Object:    ref("Pod", "web-1"),
+			})
+			Expect(status.Code(err)).To(Equal(codes.NotFound))
+		})
+	})
+
+	Context("GetShortestPath", func() {
+		It("should return the nodes and edges on an undirected path", func() {
+			resp, err := p.GetShortestPath(context.Background(), &representation.ShortestPathRequest{
+				ClusterId: cluster,
+				From:      ref("Service", "web"),
+				To:        ref("Deployment", "web"),
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(names(resp.GetNodes())).To(Equal([]string{
+				"Service/web", "Endpoints/web", "Pod/web-1", "ReplicaSet/web-abc", "Deployment/web",
+			}))
+			Expect(resp.GetEdges()).To(HaveLen(4))
+
+			By("reporting edges in their original direction")
+			last := resp.GetEdges()[3]
+			Expect(last.GetFrom()).To(Equal("Deployment-web"))
+			Expect(last.GetTo()).To(Equal("ReplicaSet-web-abc"))
+		})
+		It("should only follow edges forward in directed paths", func() {
+			resp, err := p.GetShortestPath(context.Background(), &representation.ShortestPathRequest{
+				ClusterId: cluster,
+				From:      ref("Service", "web"),
+				To:        ref("Pod", "web-1"),
+				Directed:  true,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(names(resp.GetNodes())).To(Equal([]string{"Service/web", "Endpoints/web", "Pod/web-1"}))
+
+			_, err = p.GetShortestPath(context.Background(), &representation.ShortestPathRequest{
+				ClusterId: cluster,
+				From:      ref("Service", "web"),
+				To:        ref("Deployment", "web"),
+				Directed:  true,
+			})
+			Expect(status.Code(err)).To(Equal(codes.NotFound))
+		})
+		It("should not connect objects through shared nodes", func() {
+			_, err := p.GetShortestPath(context.Background(), &representation.ShortestPathRequest{
+				ClusterId: cluster,
+				From:      ref("Deployment", "web"),
+				To:        ref("Deployment", "other"),
+			})
+			Expect(status.Code(err)).To(Equal(codes.NotFound))
+		})
+		It("should require both ends of the path", func() {
+			_, err := p.GetShortestPath(context.Background(), &representation.ShortestPathRequest{
+				ClusterId: cluster,
+				From:      ref("Service", "web"),
+			})
+			Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+		})
+	})
+
+	Context("GetBlastRadius", func() {
+		It("should return the workloads affected by a failing node", func() {
+			resp, err := p.GetBlastRadius(context.Background(), &representation.BlastRadiusRequest{
+				ClusterId: cluster,
+				Object:    &representation.ObjectReference{Kind: "Node", Name: "node-1"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.GetObject().GetName()).To(Equal("node-1"))
+			Expect(names(resp.GetWorkloads())).To(ConsistOf(
+				"Pod/web-1", "ReplicaSet/web-abc", "Deployment/web",
+			))
+			Expect(names(resp.GetAffected())).To(ConsistOf(
+				"Pod/web-1", "ReplicaSet/web-abc", "Deployment/web", "Endpoints/web", "Service/web",
+			))
+		})
+		It("should return the workloads affected by a failing service", func() {
+			resp, err := p.GetBlastRadius(context.Background(), &representation.BlastRadiusRequest{
+				ClusterId: cluster,
+				Object:    ref("Service", "web"),
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(names(resp.GetWorkloads())).To(ConsistOf(
+				"Pod/web-1", "ReplicaSet/web-abc", "Deployment/web",
+			))
+			Expect(names(resp.GetAffected())).NotTo(ContainElement("Node/node-1"))
+		})
+		It("should return NotFound for unknown objects", func() {
+			_, err := p.GetBlastRadius(context.Background(), &representation.BlastRadiusRequest{
+				ClusterId: cluster,
+				Object:    ref("Service", "missing"),
+			})
+			Expect(status.Code(err)).To(Equal(codes.NotFound))
+		})
+	})
+})
@@ -12,6 +12,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/nats-io/nats.go"
 	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
@@ -27,6 +28,9 @@ import (
 type TopologyStreamWriteConfig struct {
 	Logger *zap.SugaredLogger
 	Nc     *nats.Conn
+	// How long versioned snapshots of each cluster's topology are kept.
+	// Defaults to store.DefaultSnapshotRetention.
+	SnapshotRetention time.Duration
 }
 
 type TopologyStreamWriter struct {
@@ -34,6 +38,7 @@ type TopologyStreamWriter struct {
 	TopologyStreamWriteConfig
 
 	topologyObjectStore nats.ObjectStore
+	snapshots           *store.SnapshotIndex
 
 	util.Initializer
 }
@@ -48,6 +53,10 @@ func (t *TopologyStreamWriter) Initialize(conf TopologyStreamWriteConfig) {
 			os.Exit(1)
 		}
 		t.topologyObjectStore = objStore
+		t.snapshots = store.NewSnapshotIndex(objStore)
+		if conf.SnapshotRetention <= 0 {
+			conf.SnapshotRetention = store.DefaultSnapshotRetention
+		}
 		t.TopologyStreamWriteConfig = conf
 	})
 }
@@ -78,9 +87,45 @@ func (t *TopologyStreamWriter) Push(ctx context.Context, payload *stream.Payload
 		return nil, err
 	}
 	t.Logger.With("info", info).Debug("successfully pushed topology data")
+
+	if err := t.snapshot(payload, info); err != nil {
+		t.Logger.With(
+			"cluster", payload.Graph.ClusterId.GetId(),
+			zap.Error(err),
+		).Warn("failed to store topology snapshot")
+	}
 	return &emptypb.Empty{}, nil
 }
 
+// snapshot stores a versioned copy of the pushed topology, unless it is
+// identical to the most recent snapshot, and prunes snapshots which are
+// outside of the retention window.
+func (t *TopologyStreamWriter) snapshot(payload *stream.Payload, latest *nats.ObjectInfo) error {
+	clusterId := payload.Graph.ClusterId
+	snapshots, err := t.snapshots.List(clusterId)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if len(snapshots) == 0 || snapshots[len(snapshots)-1].Info.Digest != latest.Digest {
+		meta := t.objectDef(clusterId, payload.Graph.Repr)
+		if err := t.snapshots.Put(clusterId, meta, bytes.NewReader(payload.Graph.Data), now); err != nil {
+			return err
+		}
+	}
+	pruned, err := t.snapshots.Prune(clusterId, t.SnapshotRetention, now)
+	if err != nil {
+		return err
+	}
+	if pruned > 0 {
+		t.Logger.With(
+			"cluster", clusterId.GetId(),
+			"count", pruned,
+		).Debug("pruned old topology snapshots")
+	}
+	return nil
+}
+
 func (t *TopologyStreamWriter) SyncTopology(ctx context.Context, payload *stream.Payload) (*emptypb.Empty, error) {
 	if !t.Initialized() {
 		return nil, util.StatusError(codes.Unavailable)
@@ -3,6 +3,7 @@ package gateway
 import (
 	"context"
 	"os"
+	"time"
 
 	capabilityv1 "github.com/rancher/opni/pkg/apis/capability/v1"
 	corev1 "github.com/rancher/opni/pkg/apis/core/v1"
@@ -45,11 +46,28 @@ func (p *Plugin) UseManagementAPI(client managementv1.ManagementClient) {
 			os.Exit(1)
 		}
 		p.storageBackend.Set(backend)
+		p.snapshotRetention.Set(p.parseSnapshotRetention(config.Spec.Topology.SnapshotRetention))
 		p.configureTopologyManagement()
 	})
 	<-p.ctx.Done()
 }
 
+// parseSnapshotRetention returns the configured snapshot retention, or 0 to
+// use the default retention if it is not set or invalid.
+func (p *Plugin) parseSnapshotRetention(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	retention, err := time.ParseDuration(value)
+	if err != nil || retention <= 0 {
+		p.logger.With(
+			"snapshotRetention", value,
+		).Warn("invalid topology snapshot retention, using the default")
+		return 0
+	}
+	return retention
+}
+
 func (p *Plugin) UseKeyValueStore(client system.KeyValueStoreClient) {
 	// set other futures before trying to acquire NATS connection
 	ctrl, err := task.NewController(
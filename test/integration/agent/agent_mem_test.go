@@ -142,6 +142,9 @@ var _ = Describe("Agent Memory Tests", Ordered, Label("aberrant", "temporal"), f
 					Dir: path.Join(tempDir, "cache"),
 				},
 			},
+			Signatures: v1beta1.SignatureSpec{
+				Mode: v1beta1.SignatureModeDisabled,
+			},
 		}
 		configData, err := yaml.Marshal(gatewayConfig)
 		Expect(err).NotTo(HaveOccurred())
@@ -216,6 +219,9 @@ var _ = Describe("Agent Memory Tests", Ordered, Label("aberrant", "temporal"), f
 								Dir: path.Join(tempDir, "cache"),
 							},
 						},
+						Signatures: v1beta1.SignatureSpec{
+							Mode: v1beta1.SignatureModeDisabled,
+						},
 					},
 				},
 			}